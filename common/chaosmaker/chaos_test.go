@@ -41,6 +41,9 @@ var rules = []string{
 	`alert http any any -> any any (msg: "Suspicious netstat command traffic"; flow: established,to_client; content:"Active Internet connections"; http_server_body; depth:28; content:"tcp"; http_server_body; distance:0; classtype:trojan-activity; sid: 3013003; rev: 1; metadata:created_at 2018_09_26,by al0ne;)`,
 	`alert http any any -> any any (msg:"msfconsole powershell response"; flow:established; content:!"<html>"; content:!"<script>"; content:"|70 6f 77 65 72 73 68 65 6c 6c 2e 65 78 65|"; http_server_body; content:"|46 72 6f 6d 42 61 73 65 36 34 53 74 72 69 6e 67|"; http_server_body; classtype:exploit-kit; sid:3016005; rev:1;)`,
 	`alert tcp any any -> any any (msg:"ET SCAN Amap TCP Service Scan Detected"; flow:to_server; flags:PA; content:"service|3A|thc|3A 2F 2F|"; depth:105; content:"service|3A|thc"; within:40; reference:url,freeworld.thc.org/thc-amap/; reference:url,doc.emergingthreats.net/2010371; classtype:attempted-recon; sid:2010371; rev:2; metadata:created_at 2010_07_30, updated_at 2010_07_30;)`, `alert tcp any any -> any 21 (msg:"ET SCAN Grim's Ping ftp scanning tool"; flow:to_server,established; content:"PASS "; content:"gpuser@home.com"; within:18; reference:url,archives.neohapsis.com/archives/snort/2002-04/0448.html; reference:url,grimsping.cjb.net; reference:url,doc.emergingthreats.net/2007802; classtype:network-scan; sid:2007802; rev:4; metadata:created_at 2010_07_30, updated_at 2010_07_30;)`,
+	`alert tls $HOME_NET any -> $EXTERNAL_NET any (msg:"Observed Malicious SSL Cert (c2.example.com)"; flow:established,to_server; tls.sni; content:"c2.example.com"; endswith; classtype:domain-c2; sid:3017100; rev:1;)`,
+	`alert tls $EXTERNAL_NET 443 -> $HOME_NET any (msg:"Observed Malicious SSL Cert (Evil CA)"; flow:established,to_client; tls.cert_issuer; content:"CN=Evil CA"; tls.cert_subject; content:"O=Evil Corp"; classtype:domain-c2; sid:3017101; rev:1;)`,
+	`alert smb any any -> $HOME_NET 445 (msg:"SMB2 svcctl named pipe access"; flow:established,to_server; smb.named_pipe; content:"svcctl"; smb.share; content:"IPC$"; endswith; classtype:attempted-admin; sid:3017102; rev:1;)`,
 }
 
 func TestMUSTPASS_CrossVerify(t *testing.T) {
@@ -93,7 +96,8 @@ func TestMUSTPASS_CrossVerifyGroup(t *testing.T) {
 		getMap  = make(map[string]int)
 		wantMap = make(map[string]int)
 	)
-	group := match.NewGroup(match.WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *surirule.Rule) {
+	// 每条规则的流量是单独生成的，flowbits 的前置规则不会出现在同一个流中，所以不使用状态匹配
+	group := match.NewGroup(match.WithGroupFlowState(nil), match.WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *surirule.Rule) {
 		getMap[match.Raw]++
 	}))
 
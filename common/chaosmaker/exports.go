@@ -5,6 +5,7 @@ import (
 	"github.com/yaklang/yaklang/common/chaosmaker/rule"
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/eve"
 	"github.com/yaklang/yaklang/common/suricata/match"
 	surirule "github.com/yaklang/yaklang/common/suricata/rule"
 	"github.com/yaklang/yaklang/common/utils"
@@ -62,6 +63,22 @@ var (
 		"YieldSuricataRulesByKeywords": YieldRulesByKeywords,
 		"LoadSuricataToDatabase":       LoadSuricataToDatabase,
 		"TrafficGenerator":             NewChaosMaker,
+
+		// EVE JSON 输出
+		"EveRun":    eve.Run,
+		"EveStream": eve.Stream,
+
+		"eve_pcapFile":   eve.WithPcapFile,
+		"eve_device":     eve.WithDevice,
+		"eve_bpf":        eve.WithBPFFilter,
+		"eve_output":     eve.WithOutputFile,
+		"eve_context":    eve.WithContext,
+		"eve_stateful":   eve.WithStateful,
+		"eve_eventTypes": func(types ...string) eve.RunOption { return eve.WithLoggerOptions(eve.WithEventTypes(types...)) },
+		"eve_callback":   func(cb func(*eve.Event)) eve.RunOption { return eve.WithLoggerOptions(eve.WithEventCallback(cb)) },
+		"eve_communityIDSeed": func(seed int) eve.RunOption {
+			return eve.WithLoggerOptions(eve.WithCommunityIDSeed(uint16(seed)))
+		},
 	}
 )
 
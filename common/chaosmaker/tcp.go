@@ -13,11 +13,22 @@ import (
 func init() {
 	chaosMap.Store("suricata-tcp", &tcpHandler{
 		GenCountPerRule: 5,
+		Protocol:        protocol.TCP,
+	})
+	// tls / smb 的载荷由 suricata generate 构造，流量补全和 tcp 一致
+	chaosMap.Store("suricata-tls", &tcpHandler{
+		GenCountPerRule: 5,
+		Protocol:        protocol.TLS,
+	})
+	chaosMap.Store("suricata-smb", &tcpHandler{
+		GenCountPerRule: 5,
+		Protocol:        protocol.SMB,
 	})
 }
 
 type tcpHandler struct {
 	GenCountPerRule int
+	Protocol        string
 }
 
 var _ chaosHandler = (*tcpHandler)(nil)
@@ -27,7 +38,7 @@ func (t *tcpHandler) Generator(maker *ChaosMaker, chaosRule *rule.Storage, origi
 		return nil
 	}
 
-	if originRule.Protocol != protocol.TCP {
+	if originRule.Protocol != t.Protocol {
 		return nil
 	}
 
@@ -15,6 +15,7 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/minimartian"
 	"github.com/yaklang/yaklang/common/minimartian/mitm"
+	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
@@ -201,6 +202,8 @@ type ClientCertificationPair struct {
 	CrtPem []byte
 	KeyPem []byte
 	CaPem  [][]byte
+	// HostPattern 为空表示对这个 MITM 的所有上游 host 都使用这个证书
+	HostPattern string
 }
 
 func NewClientCertificationPair(crt, key []byte, cas ...[]byte) *ClientCertificationPair {
@@ -211,6 +214,12 @@ func NewClientCertificationPair(crt, key []byte, cas ...[]byte) *ClientCertifica
 	}
 }
 
+// InvisibleListener 是一个 invisible 模式的监听端口，Target 为空时由 SNI / Host 决定上游目标
+type InvisibleListener struct {
+	Addr   string
+	Target string
+}
+
 type ProxyAuth struct {
 	Username string
 	Password string
@@ -230,6 +239,9 @@ type MITMServer struct {
 
 	clientCerts []*ClientCertificationPair
 
+	// invisible 监听端口，给不支持设置代理的客户端使用
+	invisibleListeners []*InvisibleListener
+
 	DNSServers     []string
 	HostMapping    map[string]string
 	via            string
@@ -260,6 +272,9 @@ type MITMServer struct {
 	websocketRequestMirror         func(req []byte)
 	websocketResponseMirror        func(rsp []byte)
 
+	// server-sent events
+	sseEventHijackHandler func(req *http.Request, index int, event []byte, modifiable bool) []byte
+
 	maxContentLength int
 }
 
@@ -315,6 +330,24 @@ func (m *MITMServer) Serve(ctx context.Context, addr string) error {
 		config = append(config, lowhttp.WithETCHosts(m.HostMapping))
 	}
 
+	// 上游双向认证证书只用于这个 MITM 的上游连接，不加入 netx 全局证书库
+	var clientCerts []*netx.ClientCertificate
+	for _, pair := range m.clientCerts {
+		pattern := pair.HostPattern
+		if pattern == "" {
+			pattern = "*"
+		}
+		cert, err := netx.NewClientCertificate(pattern, pair.CrtPem, pair.KeyPem, pair.CaPem...)
+		if err != nil {
+			log.Errorf("load mitm client certificate for %v failed: %s", pattern, err)
+			continue
+		}
+		clientCerts = append(clientCerts, cert)
+	}
+	if len(clientCerts) > 0 {
+		config = append(config, lowhttp.WithClientCertificates(clientCerts...))
+	}
+
 	m.proxy.SetLowhttpConfig(config)
 	m.proxy.SetGMTLS(m.gmtls)
 	m.proxy.SetGMPrefer(m.gmPrefer)
@@ -322,6 +355,9 @@ func (m *MITMServer) Serve(ctx context.Context, addr string) error {
 
 	m.proxy.SetMITM(m.mitmConfig)
 	m.proxy.SetMaxContentLength(m.GetMaxContentLength())
+	if m.sseEventHijackHandler != nil {
+		m.proxy.SetSSEEventHandler(m.sseEventHijackHandler)
+	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -340,6 +376,19 @@ func (m *MITMServer) Serve(ctx context.Context, addr string) error {
 		}
 	}()
 
+	for _, invisible := range m.invisibleListeners {
+		invisibleLis, err := net.Listen("tcp", invisible.Addr)
+		if err != nil {
+			return utils.Errorf("listen invisible port: %v failed: %s", invisible.Addr, err)
+		}
+		defer invisibleLis.Close()
+		go func(l net.Listener, target string) {
+			if err := m.proxy.ServeInvisible(l, ctx, target); err != nil {
+				log.Errorf("serve invisible mitm listener %v failed: %s", l.Addr(), err)
+			}
+		}(invisibleLis, invisible.Target)
+	}
+
 	log.Infof("start to server mitm server: tcp://%v", addr)
 	err = m.proxy.Serve(lis, ctx)
 	if err != nil {
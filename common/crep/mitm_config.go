@@ -58,6 +58,19 @@ func MITM_SetHijackedMaxContentLength(i int) MITMConfig {
 	}
 }
 
+// MITM_AddInvisibleListener 额外监听 addr 作为 invisible(非代理) 端口，客户端直接把这个端口当作服务器连接
+// 自动识别 TLS 与明文 HTTP；target(host:port) 为空时上游目标由 SNI / Host 决定，否则总是连接 target
+// 请求与通过代理的请求一样被劫持和记录
+func MITM_AddInvisibleListener(addr string, target string) MITMConfig {
+	return func(server *MITMServer) error {
+		if addr == "" {
+			return utils.Error("empty invisible listener addr")
+		}
+		server.invisibleListeners = append(server.invisibleListeners, &InvisibleListener{Addr: addr, Target: target})
+		return nil
+	}
+}
+
 func MITM_MutualTLSClient(crt, key []byte, cas ...[]byte) MITMConfig {
 	return func(server *MITMServer) error {
 		server.clientCerts = append(server.clientCerts, NewClientCertificationPair(crt, key, cas...))
@@ -65,6 +78,16 @@ func MITM_MutualTLSClient(crt, key []byte, cas ...[]byte) MITMConfig {
 	}
 }
 
+// MITM_MutualTLSClientForHost 只在上游 host 匹配 pattern 时使用这个客户端证书，pattern 支持 glob，多个用逗号分隔
+func MITM_MutualTLSClientForHost(pattern string, crt, key []byte, cas ...[]byte) MITMConfig {
+	return func(server *MITMServer) error {
+		pair := NewClientCertificationPair(crt, key, cas...)
+		pair.HostPattern = pattern
+		server.clientCerts = append(server.clientCerts, pair)
+		return nil
+	}
+}
+
 func MITM_SetCaCertAndPrivKey(ca []byte, key []byte) MITMConfig {
 	return func(server *MITMServer) error {
 		if ca == nil || key == nil {
@@ -513,3 +536,14 @@ func MITM_SetWebsocketResponseMirrorRaw(f func(req []byte)) MITMConfig {
 		return nil
 	}
 }
+
+// server-sent events
+
+// MITM_SetSSEEventHijackRaw 设置 text/event-stream 响应中每一个事件的处理函数，返回 nil 表示丢弃该事件
+// modifiable 为 false 时(压缩或固定 Content-Length 的响应)事件无法被修改，处理函数的返回值会被忽略
+func MITM_SetSSEEventHijackRaw(c func(req *http.Request, index int, event []byte, modifiable bool) []byte) MITMConfig {
+	return func(server *MITMServer) error {
+		server.sseEventHijackHandler = c
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package crep
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestMITM_InvisibleListener(t *testing.T) {
+	token := utils.RandStringBytes(16)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", token, r.Host)
+	})
+	plainServer := httptest.NewServer(handler)
+	defer plainServer.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	var (
+		mu     sync.Mutex
+		hosts  []string
+		scheme []bool
+	)
+	boundPlainPort := utils.GetRandomAvailableTCPPort()
+	boundTLSPort := utils.GetRandomAvailableTCPPort()
+	derivedPort := utils.GetRandomAvailableTCPPort()
+	mitmServer, err := NewMITMServer(
+		MITM_AddInvisibleListener(utils.HostPort("127.0.0.1", boundPlainPort), plainServer.Listener.Addr().String()),
+		MITM_AddInvisibleListener(utils.HostPort("127.0.0.1", boundTLSPort), tlsServer.Listener.Addr().String()),
+		MITM_AddInvisibleListener(utils.HostPort("127.0.0.1", derivedPort), ""),
+		MITM_SetHTTPRequestHijackRaw(func(isHttps bool, req *http.Request, raw []byte) []byte {
+			mu.Lock()
+			hosts = append(hosts, req.Host)
+			scheme = append(scheme, isHttps)
+			mu.Unlock()
+			return raw
+		}),
+	)
+	require.NoError(t, err)
+
+	port := utils.GetRandomAvailableTCPPort()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mitmServer.Serve(ctx, utils.HostPort("127.0.0.1", port))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", boundPlainPort), 3))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", boundTLSPort), 3))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", derivedPort), 3))
+
+	get := func(u string, host string) string {
+		// no proxy: the client connects to the invisible port directly
+		client := &http.Client{
+			Transport: &http.Transport{
+				Proxy:           nil,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: "www.example.com"},
+			},
+			Timeout: 10 * time.Second,
+		}
+		req, err := http.NewRequest("GET", u, nil)
+		require.NoError(t, err)
+		req.Host = host
+		rsp, err := client.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		body, _ := io.ReadAll(rsp.Body)
+		return string(body)
+	}
+
+	// bound plain http target, Host is kept
+	body := get(fmt.Sprintf("http://127.0.0.1:%d/", boundPlainPort), "www.example.com")
+	require.Equal(t, token+"|www.example.com", body)
+
+	// bound https target, tls is detected automatically
+	body = get(fmt.Sprintf("https://127.0.0.1:%d/", boundTLSPort), "www.example.com")
+	require.Equal(t, token+"|www.example.com", body)
+
+	// target derived from Host
+	derivedHost := plainServer.Listener.Addr().String()
+	body = get(fmt.Sprintf("http://127.0.0.1:%d/", derivedPort), derivedHost)
+	require.Equal(t, token+"|"+derivedHost, body)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"www.example.com", "www.example.com", derivedHost}, hosts)
+	require.Equal(t, []bool{false, true, false}, scheme)
+}
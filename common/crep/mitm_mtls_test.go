@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/uuid"
+	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
 	"net/http"
@@ -217,4 +218,8 @@ func TestMTLS_MITM(t *testing.T) {
 	if !utils.IContains(string(raw), uid) {
 		panic("MITM mTLS Failed")
 	}
+	// MITM 的客户端证书只用于 MITM 自己的上游连接
+	if len(netx.GetClientCertificatesForHost("127.0.0.1")) > 0 {
+		panic("MITM client certificate leaked into netx")
+	}
 }
@@ -0,0 +1,161 @@
+package crep
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+func TestMITM_ServerSentEvents(t *testing.T) {
+	firstEventReceived := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, "id: 1\ndata: hello\n\n")
+		flusher.Flush()
+		// the second event is only sent after client received the first one
+		select {
+		case <-firstEventReceived:
+		case <-time.After(5 * time.Second):
+			return
+		}
+		fmt.Fprint(w, "id: 2\ndata: drop-me\n\n")
+		fmt.Fprint(w, "id: 3\nevent: done\ndata: bye\n\n")
+		flusher.Flush()
+	}))
+	defer server.Close()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	mitmServer, err := NewMITMServer(MITM_SetSSEEventHijackRaw(func(req *http.Request, index int, event []byte, modifiable bool) []byte {
+		if !modifiable {
+			t.Error("chunked event stream should be modifiable")
+		}
+		mu.Lock()
+		events = append(events, string(event))
+		mu.Unlock()
+		if strings.Contains(string(event), "drop-me") {
+			return nil
+		}
+		return []byte(strings.ReplaceAll(string(event), "hello", "HELLO"))
+	}))
+	require.NoError(t, err)
+
+	port := utils.GetRandomAvailableTCPPort()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mitmServer.Serve(ctx, utils.HostPort("127.0.0.1", port))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", port), 3))
+
+	proxyUrl, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", port))
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyUrl)}, Timeout: 10 * time.Second}
+	rsp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	reader := bufio.NewReader(rsp.Body)
+	var received []string
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, "data:") {
+			received = append(received, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			if len(received) == 1 {
+				close(firstEventReceived)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	require.Equal(t, []string{"HELLO", "bye"}, received)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 3)
+}
+
+func TestMITM_ServerSentEvents_EncodedAndTooLarge(t *testing.T) {
+	const eventCount = 500
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		flusher := w.(http.Flusher)
+		gw := gzip.NewWriter(w)
+		for i := 0; i < eventCount; i++ {
+			fmt.Fprintf(gw, "id: %d\ndata: %s\n\n", i, utils.RandStringBytes(32))
+			gw.Flush()
+			flusher.Flush()
+		}
+		gw.Close()
+	}))
+	defer server.Close()
+
+	var (
+		mu       sync.Mutex
+		events   int
+		tooLarge bool
+		saved    []byte
+	)
+	mirrored := make(chan struct{})
+	mitmServer, err := NewMITMServer(
+		MITM_SetMaxContentLength(1024),
+		MITM_SetSSEEventHijackRaw(func(req *http.Request, index int, event []byte, modifiable bool) []byte {
+			if modifiable {
+				t.Error("gzip event stream should not be modifiable")
+			}
+			mu.Lock()
+			events++
+			mu.Unlock()
+			return nil
+		}),
+		MITM_SetHTTPResponseMirrorInstance(func(isHttps bool, req, rsp []byte, remoteAddr string, response *http.Response) {
+			mu.Lock()
+			tooLarge = httpctx.GetResponseTooLarge(response.Request)
+			saved = rsp
+			mu.Unlock()
+			close(mirrored)
+		}),
+	)
+	require.NoError(t, err)
+
+	port := utils.GetRandomAvailableTCPPort()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mitmServer.Serve(ctx, utils.HostPort("127.0.0.1", port))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", port), 3))
+
+	proxyUrl, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", port))
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyUrl)}, Timeout: 10 * time.Second}
+	rsp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, eventCount, strings.Count(string(body), "data: "))
+
+	select {
+	case <-mirrored:
+	case <-time.After(5 * time.Second):
+		t.Fatal("response mirror not called")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, eventCount, events)
+	require.True(t, tooLarge)
+	require.Less(t, len(saved), 4096)
+}
@@ -119,9 +119,11 @@ func (D *DNSLogGRPCServer) QueryExistedDNSLog(ctx context.Context, params *tpb.Q
 		}
 	}
 
+	facadeEvents := queryFacadeEvents(token, mode)
 	mergeResults := func(results []*tpb.DNSLogEvent) []*tpb.DNSLogEvent {
-		var extraEvents = make([]*tpb.DNSLogEvent, len(results), len(results)+len(httpResults)+len(httpResults))
+		var extraEvents = make([]*tpb.DNSLogEvent, len(results), len(results)+len(httpResults)+len(httpResults)+len(facadeEvents))
 		copy(extraEvents, results)
+		extraEvents = append(extraEvents, facadeEvents...)
 		if len(httpResults) > 0 {
 			for _, item := range httpResults {
 				var t string
@@ -183,6 +185,36 @@ func (D *DNSLogGRPCServer) QueryExistedDNSLog(ctx context.Context, params *tpb.Q
 	return rsp, nil
 }
 
+// facadeEventTypes 是通过 token 查询 dnslog 时一起返回的 FacadeServer 反连类型
+var facadeEventTypes = map[string]string{
+	facades.FTPMsgFlag:  "FTP",
+	facades.SMTPMsgFlag: "SMTP",
+	facades.LDAPMsgFlag: "LDAP",
+}
+
+// queryFacadeEvents 把本进程中正在监听的 FacadeServer 记录的 FTP / SMTP / LDAP 反连转换为 DNSLogEvent
+// FTP 的 token 是路径的第一段，SMTP 是收件人的用户名，LDAP 是查询的 DN
+func queryFacadeEvents(token, mode string) []*tpb.DNSLogEvent {
+	if token == "" {
+		return nil
+	}
+	var events []*tpb.DNSLogEvent
+	for _, n := range facades.QueryNotificationsByToken(token, facades.FTPMsgFlag, facades.SMTPMsgFlag, facades.LDAPMsgFlag) {
+		ip, port, _ := utils.ParseStringToHostPort(n.RemoteAddr)
+		events = append(events, &tpb.DNSLogEvent{
+			Type:       facadeEventTypes[n.Type],
+			Token:      token,
+			RemoteAddr: n.RemoteAddr,
+			RemoteIP:   ip,
+			RemotePort: int32(port),
+			Raw:        n.Raw,
+			Timestamp:  n.Timestamp,
+			Mode:       mode,
+		})
+	}
+	return events
+}
+
 func NewDNSLogServer(domain string, externalIP string) (*DNSLogGRPCServer, error) {
 	ip := externalIP
 	if externalIP == "" {
@@ -226,6 +258,9 @@ func NewDNSLogServer(domain string, externalIP string) (*DNSLogGRPCServer, error
 			event.RemoteIP = host
 			event.RemotePort = int32(port)
 		}
+		if defaultInteractshServer != nil {
+			defaultInteractshServer.RecordDNS(event.Domain, event.Type, string(event.Raw), event.RemoteAddr)
+		}
 		if ok {
 			log.Infof("token: %v", token)
 			result, existed := cache.Get(token)
@@ -0,0 +1,48 @@
+package cybertunnel
+
+import (
+	"context"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/cybertunnel/tpb"
+	"github.com/yaklang/yaklang/common/facades"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestDNSLogServer_QueryFacadeEvents(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	server := facades.NewFacadeServer("127.0.0.1", port)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.ServeWithContext(ctx)
+	addr := utils.HostPort("127.0.0.1", port)
+	require.NoError(t, utils.WaitConnect(addr, 3))
+
+	token := utils.RandStringBytes(10)
+	err := smtp.SendMail(addr, nil, "app@victim.example", []string{token + "@facade.example"}, []byte("Subject: test\r\n\r\nhello\r\n"))
+	require.NoError(t, err)
+
+	dnslog := &DNSLogGRPCServer{
+		cache:            utils.NewTTLCache[[]*tpb.DNSLogEvent](time.Minute),
+		tokenToModeCache: utils.NewTTLCache[string](time.Minute),
+	}
+	// SMTP 的反连与 dnslog 使用同一个 token 查询
+	var events []*tpb.DNSLogEvent
+	require.Eventually(t, func() bool {
+		rsp, err := dnslog.QueryExistedDNSLog(context.Background(), &tpb.QueryExistedDNSLogParams{Token: token})
+		require.NoError(t, err)
+		events = rsp.GetEvents()
+		return len(events) > 0
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, "SMTP", events[0].GetType())
+	require.Equal(t, token, events[0].GetToken())
+	require.Equal(t, "127.0.0.1", events[0].GetRemoteIP())
+	require.Contains(t, string(events[0].GetRaw()), "hello")
+
+	rsp, err := dnslog.QueryExistedDNSLog(context.Background(), &tpb.QueryExistedDNSLogParams{Token: utils.RandStringBytes(10)})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetEvents(), 0)
+}
@@ -432,7 +432,7 @@ func QueryExistedDNSLogEventsByLocalEx(token, mode string, timeout ...float64) (
 			}
 			return nil, err
 		}
-		return results, nil
+		return append(results, queryFacadeEvents(token, mode)...), nil
 	}
 }
 
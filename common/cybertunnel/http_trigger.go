@@ -43,6 +43,14 @@ func NewHTTPTrigger(external string, dnslogDomain string) (*HTTPTrigger, error)
 }
 
 func (t *HTTPTrigger) serveRequest(isTls bool, req []byte, conn net.Conn) error {
+	if defaultInteractshServer != nil {
+		if rsp := defaultInteractshServer.ServeHTTPRequest(isTls, req, conn.RemoteAddr().String()); rsp != nil {
+			conn.Write(rsp)
+			conn.Close()
+			return nil
+		}
+	}
+
 	reqUrl, err := lowhttp.ExtractURLFromHTTPRequestRaw(req, isTls)
 	if err != nil {
 		return err
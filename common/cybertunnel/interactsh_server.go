@@ -0,0 +1,375 @@
+package cybertunnel
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+// interactsh 客户端默认的 correlation-id 长度，完整的子域名标签是 correlation-id + 13 位 nonce
+const interactshCorrelationIDLength = 20
+
+var (
+	// interactshMaxSessionInteractions 是每个 correlation-id 保存的未轮询交互记录上限，超过之后丢弃最早的记录
+	interactshMaxSessionInteractions = 1000
+	// interactshSessionIdleTimeout 内没有注册或轮询的 correlation-id 会被删除，命中的交互不会延长它的生命周期
+	interactshSessionIdleTimeout = time.Hour
+)
+
+// defaultInteractshServer 不为空时，HTTP 触发器和 DNSLog 会把命中的请求记录为 interactsh 交互
+var defaultInteractshServer *InteractshServer
+
+// InteractshInteraction 与 interactsh server 的 Interaction 结构保持一致
+type InteractshInteraction struct {
+	Protocol      string    `json:"protocol"`
+	UniqueID      string    `json:"unique-id"`
+	FullId        string    `json:"full-id"`
+	QType         string    `json:"q-type,omitempty"`
+	RawRequest    string    `json:"raw-request,omitempty"`
+	RawResponse   string    `json:"raw-response,omitempty"`
+	SMTPFrom      string    `json:"smtp-from,omitempty"`
+	RemoteAddress string    `json:"remote-address"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type interactshSession struct {
+	mu sync.Mutex
+
+	secretKey       string
+	aesKey          []byte
+	encryptedAESKey string
+	// interactions 是已经加密的交互记录，轮询之后清空，最多保存 interactshMaxSessionInteractions 条
+	interactions []string
+}
+
+// InteractshServer 实现 interactsh 客户端使用的 /register、/poll、/deregister 接口
+// 交互记录使用客户端注册的 RSA 公钥加密的 AES 密钥进行 AES-256-CFB 加密
+type InteractshServer struct {
+	// Domain 是 interactsh 使用的根域名
+	Domain string
+	// Token 不为空时，API 请求需要在 Authorization 头中带上该值
+	Token string
+
+	sessions *utils.Cache[*interactshSession]
+}
+
+func NewInteractshServer(domain, token string) *InteractshServer {
+	sessions := utils.NewTTLCache[*interactshSession](interactshSessionIdleTimeout)
+	// 只有注册和轮询才会刷新过期时间，客户端不再轮询之后 session 会过期
+	sessions.SkipTtlExtensionOnHit(true)
+	return &InteractshServer{
+		Domain:   strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."),
+		Token:    token,
+		sessions: sessions,
+	}
+}
+
+// EnableInteractshServer 开启 interactsh 兼容服务，复用 HTTP 触发器和 DNSLog 的监听
+func EnableInteractshServer(domain, token string) *InteractshServer {
+	defaultInteractshServer = NewInteractshServer(domain, token)
+	return defaultInteractshServer
+}
+
+func parseInteractshPublicKey(encoded string) (*rsa.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, utils.Errorf("decode public key failed: %v", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, utils.Error("invalid public key pem")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		if pub, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+			return pub, nil
+		}
+		return nil, utils.Errorf("parse public key failed: %v", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, utils.Error("public key is not rsa")
+	}
+	return rsaPub, nil
+}
+
+// Register 注册 correlation-id，publicKey 是 base64 编码的 PEM 公钥
+func (s *InteractshServer) Register(correlationID, secretKey, publicKey string) error {
+	correlationID = strings.ToLower(correlationID)
+	if correlationID == "" || secretKey == "" {
+		return utils.Error("correlation-id and secret-key are required")
+	}
+	if _, ok := s.sessions.Get(correlationID); ok {
+		return utils.Error("could not set id and public key: correlation-id provided already exists")
+	}
+	pub, err := parseInteractshPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		return err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, aesKey, nil)
+	if err != nil {
+		return utils.Errorf("encrypt aes key failed: %v", err)
+	}
+	s.sessions.Set(correlationID, &interactshSession{
+		secretKey:       secretKey,
+		aesKey:          aesKey,
+		encryptedAESKey: base64.StdEncoding.EncodeToString(encryptedKey),
+	})
+	log.Infof("interactsh correlation-id registered: %v", correlationID)
+	return nil
+}
+
+func (s *InteractshServer) getSession(correlationID, secretKey string) (*interactshSession, error) {
+	session, ok := s.sessions.Get(strings.ToLower(correlationID))
+	if !ok {
+		return nil, utils.Error("could not get correlation-id from cache")
+	}
+	if session.secretKey != secretKey {
+		return nil, utils.Error("invalid secret key passed for user")
+	}
+	return session, nil
+}
+
+// Deregister 删除 correlation-id 以及未轮询的交互记录
+func (s *InteractshServer) Deregister(correlationID, secretKey string) error {
+	if _, err := s.getSession(correlationID, secretKey); err != nil {
+		return err
+	}
+	s.sessions.Remove(strings.ToLower(correlationID))
+	return nil
+}
+
+// Poll 返回加密的交互记录和用公钥加密的 AES 密钥，返回之后记录被清空
+func (s *InteractshServer) Poll(correlationID, secretKey string) ([]string, string, error) {
+	session, err := s.getSession(correlationID, secretKey)
+	if err != nil {
+		return nil, "", err
+	}
+	s.sessions.Set(strings.ToLower(correlationID), session)
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	data := session.interactions
+	session.interactions = nil
+	return data, session.encryptedAESKey, nil
+}
+
+func interactshEncrypt(key, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
+	iv := ciphertext[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(ciphertext[aes.BlockSize:], plaintext)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// findCorrelation 在文本中查找已注册的 correlation-id，返回 unique-id（correlation-id + nonce）
+func (s *InteractshServer) findCorrelation(text string) (string, string, bool) {
+	chunks := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, chunk := range chunks {
+		if len(chunk) < interactshCorrelationIDLength {
+			continue
+		}
+		correlationID := chunk[:interactshCorrelationIDLength]
+		if _, ok := s.sessions.Get(correlationID); ok {
+			return correlationID, chunk, true
+		}
+	}
+	return "", "", false
+}
+
+// fullID 是根域名之前的子域名部分
+func (s *InteractshServer) fullID(host, uniqueID string) string {
+	host = strings.Trim(strings.ToLower(host), ".")
+	if s.Domain != "" {
+		if before, ok := strings.CutSuffix(host, "."+s.Domain); ok {
+			return before
+		}
+	}
+	return uniqueID
+}
+
+// Record 记录一次交互，text 中不包含已注册的 correlation-id 时返回 false
+func (s *InteractshServer) Record(text string, interaction *InteractshInteraction) bool {
+	correlationID, uniqueID, ok := s.findCorrelation(text)
+	if !ok {
+		return false
+	}
+	session, ok := s.sessions.Get(correlationID)
+	if !ok {
+		return false
+	}
+	interaction.UniqueID = uniqueID
+	if interaction.FullId == "" {
+		interaction.FullId = uniqueID
+	}
+	if interaction.Timestamp.IsZero() {
+		interaction.Timestamp = time.Now().UTC()
+	}
+	raw, err := json.Marshal(interaction)
+	if err != nil {
+		return false
+	}
+	encrypted, err := interactshEncrypt(session.aesKey, raw)
+	if err != nil {
+		log.Errorf("interactsh encrypt interaction failed: %v", err)
+		return false
+	}
+	session.mu.Lock()
+	session.interactions = append(session.interactions, encrypted)
+	if dropped := len(session.interactions) - interactshMaxSessionInteractions; dropped > 0 {
+		session.interactions = append([]string(nil), session.interactions[dropped:]...)
+		log.Warnf("interactsh correlation-id %v has too many unpolled interactions, drop %v oldest", correlationID, dropped)
+	}
+	session.mu.Unlock()
+	log.Infof("interactsh %v interaction from %v: %v", interaction.Protocol, interaction.RemoteAddress, interaction.FullId)
+	return true
+}
+
+// RecordDNS 记录 DNS 查询
+func (s *InteractshServer) RecordDNS(domain, qtype, rawRequest, remoteAddr string) bool {
+	domain = strings.Trim(strings.ToLower(domain), ".")
+	host, _, _ := utils.ParseStringToHostPort(remoteAddr)
+	if host == "" {
+		host = remoteAddr
+	}
+	_, uniqueID, ok := s.findCorrelation(domain)
+	if !ok {
+		return false
+	}
+	return s.Record(domain, &InteractshInteraction{
+		Protocol:      "dns",
+		FullId:        s.fullID(domain, uniqueID),
+		QType:         qtype,
+		RawRequest:    rawRequest,
+		RemoteAddress: host,
+	})
+}
+
+func interactshJSONResponse(status int, data any) []byte {
+	body, _ := json.Marshal(data)
+	header := fmt.Sprintf("HTTP/1.1 %d %s\r\nContent-Type: application/json; charset=utf-8\r\nAccess-Control-Allow-Origin: *\r\nConnection: close\r\n\r\n", status, http.StatusText(status))
+	rsp, _, _ := lowhttp.FixHTTPResponse(append([]byte(header), body...))
+	return rsp
+}
+
+func interactshError(status int, err error) []byte {
+	return interactshJSONResponse(status, map[string]string{"error": err.Error()})
+}
+
+func (s *InteractshServer) serveAPI(method, path string, req []byte) []byte {
+	if s.Token != "" && lowhttp.GetHTTPPacketHeader(req, "Authorization") != s.Token {
+		return interactshError(401, utils.Error("unauthorized"))
+	}
+	u, _ := url.Parse(path)
+	var params struct {
+		PublicKey     string `json:"public-key"`
+		SecretKey     string `json:"secret-key"`
+		CorrelationID string `json:"correlation-id"`
+	}
+
+	switch u.Path {
+	case "/register", "/deregister":
+		if method != "POST" {
+			return interactshError(405, utils.Error("method not allowed"))
+		}
+		if err := json.Unmarshal(lowhttp.GetHTTPPacketBody(req), &params); err != nil {
+			return interactshError(400, utils.Errorf("could not decode json body: %v", err))
+		}
+		if u.Path == "/register" {
+			if err := s.Register(params.CorrelationID, params.SecretKey, params.PublicKey); err != nil {
+				return interactshError(400, err)
+			}
+			return interactshJSONResponse(200, map[string]string{"message": "registration successful"})
+		}
+		if err := s.Deregister(params.CorrelationID, params.SecretKey); err != nil {
+			return interactshError(400, utils.Errorf("could not remove id: %v", err))
+		}
+		return interactshJSONResponse(200, map[string]string{"message": "deregistration successful"})
+	default:
+		query := u.Query()
+		data, aesKey, err := s.Poll(query.Get("id"), query.Get("secret"))
+		if err != nil {
+			return interactshError(400, utils.Errorf("could not get interactions: %v", err))
+		}
+		if data == nil {
+			data = []string{}
+		}
+		return interactshJSONResponse(200, map[string]any{
+			"data":    data,
+			"extra":   []string{},
+			"aes_key": aesKey,
+		})
+	}
+}
+
+// ServeHTTPRequest 处理 interactsh API 和带有 correlation-id 的 HTTP 请求，其他请求返回 nil
+func (s *InteractshServer) ServeHTTPRequest(isTls bool, req []byte, remoteAddr string) []byte {
+	method := lowhttp.GetHTTPRequestMethod(req)
+	path := lowhttp.GetHTTPRequestPath(req)
+	switch strings.SplitN(path, "?", 2)[0] {
+	case "/register", "/deregister", "/poll":
+		return s.serveAPI(method, path, req)
+	}
+
+	host := lowhttp.GetHTTPPacketHeader(req, "Host")
+	if h, _, err := utils.ParseStringToHostPort(host); err == nil {
+		host = h
+	}
+	text := host
+	_, uniqueID, ok := s.findCorrelation(host)
+	if !ok {
+		// 通过 IP 访问时 correlation-id 可能出现在路径中
+		text = path
+		if _, uniqueID, ok = s.findCorrelation(path); !ok {
+			return nil
+		}
+	}
+
+	// 与 interactsh 一致，在响应中返回反转的 unique-id
+	reversed := []rune(uniqueID)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	rsp, _, _ := lowhttp.FixHTTPResponse([]byte("HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nConnection: close\r\n\r\n<html><head></head><body>" + string(reversed) + "</body></html>"))
+
+	protocol := "http"
+	if isTls {
+		protocol = "https"
+	}
+	remoteHost, _, _ := utils.ParseStringToHostPort(remoteAddr)
+	s.Record(text, &InteractshInteraction{
+		Protocol:      protocol,
+		FullId:        s.fullID(host, uniqueID),
+		RawRequest:    string(req),
+		RawResponse:   string(rsp),
+		RemoteAddress: remoteHost,
+	})
+	return rsp
+}
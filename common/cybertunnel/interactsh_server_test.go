@@ -0,0 +1,142 @@
+package cybertunnel
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func testInteractshRequest(t *testing.T, s *InteractshServer, method, path string, body any) (int, map[string]any) {
+	var raw []byte
+	if body != nil {
+		var err error
+		raw, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req := fmt.Sprintf("%s %s HTTP/1.1\r\nHost: oast.test\r\nContent-Type: application/json\r\n\r\n%s", method, path, raw)
+	fixed := lowhttp.FixHTTPRequest([]byte(req))
+	rsp := s.ServeHTTPRequest(false, fixed, "127.0.0.1:34567")
+	require.NotNil(t, rsp)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(lowhttp.GetHTTPPacketBody(rsp), &result))
+	return lowhttp.GetStatusCodeFromResponse(rsp), result
+}
+
+func TestInteractshServer(t *testing.T) {
+	s := NewInteractshServer("oast.test", "")
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicKey := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: publicKeyDER}))
+
+	correlationID := "c0rre1ationid0000001"
+	secret := "d1d0a6f5-2b11-4c3a-9b1e-1f1e2d3c4b5a"
+	status, _ := testInteractshRequest(t, s, "POST", "/register", map[string]string{
+		"public-key": publicKey, "secret-key": secret, "correlation-id": correlationID,
+	})
+	require.Equal(t, 200, status)
+
+	uniqueID := correlationID + "abcdefghijklm"
+	req := lowhttp.FixHTTPRequest([]byte("GET /ping HTTP/1.1\r\nHost: " + uniqueID + ".oast.test\r\n\r\n"))
+	rsp := s.ServeHTTPRequest(false, req, "10.0.0.1:4444")
+	require.Contains(t, string(rsp), "mlkjihgfedcba1000000dinoita1err0c")
+	require.True(t, s.RecordDNS("data."+uniqueID+".oast.test.", "A", "raw-dns", "10.0.0.2:53"))
+	require.False(t, s.RecordDNS("unknown0000000000000000.oast.test", "A", "", "10.0.0.2:53"))
+
+	// 未注册的请求不处理
+	require.Nil(t, s.ServeHTTPRequest(false, lowhttp.FixHTTPRequest([]byte("GET / HTTP/1.1\r\nHost: www.example.com\r\n\r\n")), "10.0.0.1:4444"))
+
+	status, _ = testInteractshRequest(t, s, "GET", "/poll?id="+correlationID+"&secret=wrong", nil)
+	require.Equal(t, 400, status)
+
+	status, result := testInteractshRequest(t, s, "GET", "/poll?id="+correlationID+"&secret="+secret, nil)
+	require.Equal(t, 200, status)
+	encryptedKey, err := base64.StdEncoding.DecodeString(result["aes_key"].(string))
+	require.NoError(t, err)
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, encryptedKey, nil)
+	require.NoError(t, err)
+
+	data := result["data"].([]any)
+	require.Len(t, data, 2)
+	var interactions []*InteractshInteraction
+	for _, item := range data {
+		ciphertext, err := base64.StdEncoding.DecodeString(item.(string))
+		require.NoError(t, err)
+		block, err := aes.NewCipher(aesKey)
+		require.NoError(t, err)
+		plaintext := make([]byte, len(ciphertext)-aes.BlockSize)
+		cipher.NewCFBDecrypter(block, ciphertext[:aes.BlockSize]).XORKeyStream(plaintext, ciphertext[aes.BlockSize:])
+		var interaction InteractshInteraction
+		require.NoError(t, json.Unmarshal(plaintext, &interaction))
+		interactions = append(interactions, &interaction)
+	}
+	require.Equal(t, "http", interactions[0].Protocol)
+	require.Equal(t, uniqueID, interactions[0].UniqueID)
+	require.Equal(t, uniqueID, interactions[0].FullId)
+	require.Equal(t, "10.0.0.1", interactions[0].RemoteAddress)
+	require.Equal(t, "dns", interactions[1].Protocol)
+	require.Equal(t, "A", interactions[1].QType)
+	require.Equal(t, "data."+uniqueID, interactions[1].FullId)
+
+	// 拉取之后记录被清空
+	_, result = testInteractshRequest(t, s, "GET", "/poll?id="+correlationID+"&secret="+secret, nil)
+	require.Len(t, result["data"], 0)
+
+	status, _ = testInteractshRequest(t, s, "POST", "/deregister", map[string]string{
+		"secret-key": secret, "correlation-id": correlationID,
+	})
+	require.Equal(t, 200, status)
+	require.False(t, s.RecordDNS(uniqueID+".oast.test", "A", "", "10.0.0.2:53"))
+}
+
+func TestInteractshServer_SessionLimits(t *testing.T) {
+	originMax, originIdle := interactshMaxSessionInteractions, interactshSessionIdleTimeout
+	interactshMaxSessionInteractions, interactshSessionIdleTimeout = 3, 500*time.Millisecond
+	defer func() {
+		interactshMaxSessionInteractions, interactshSessionIdleTimeout = originMax, originIdle
+	}()
+	s := NewInteractshServer("oast.test", "")
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicKey := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+
+	correlationID := "c0rre1ationid0000002"
+	secret := "secret"
+	require.NoError(t, s.Register(correlationID, secret, publicKey))
+	host := correlationID + "abcdefghijklm.oast.test."
+	for i := 0; i < 5; i++ {
+		require.True(t, s.RecordDNS(host, "A", fmt.Sprint(i), "10.0.0.2:53"))
+	}
+	// 只保留最新的记录
+	data, _, err := s.Poll(correlationID, secret)
+	require.NoError(t, err)
+	require.Len(t, data, 3)
+
+	// 轮询会刷新过期时间，命中的交互不会
+	time.Sleep(300 * time.Millisecond)
+	_, _, err = s.Poll(correlationID, secret)
+	require.NoError(t, err)
+	time.Sleep(300 * time.Millisecond)
+	require.True(t, s.RecordDNS(host, "A", "", "10.0.0.2:53"))
+	time.Sleep(300 * time.Millisecond)
+	require.False(t, s.RecordDNS(host, "A", "", "10.0.0.2:53"))
+	_, _, err = s.Poll(correlationID, secret)
+	require.Error(t, err)
+}
@@ -15,6 +15,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/yaklang/yaklang/common/cybertunnel/tpb"
+	"github.com/yaklang/yaklang/common/facades"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 )
@@ -66,6 +67,20 @@ func GetTunnelServerCommandCli() *cli.App {
 			Usage: "Public IP Address: Set the public IP address",
 		},
 
+		cli.IntFlag{
+			Name:  "facade-port",
+			Usage: "Serve FTP/SMTP/LDAP reverse facade on this port, hits are returned with dnslog events of the same token",
+		},
+
+		cli.BoolFlag{
+			Name:  "interactsh",
+			Usage: "Serve interactsh client protocol (register/poll/deregister) on the http trigger, use --domain as root domain",
+		},
+		cli.StringFlag{
+			Name:  "interactsh-token",
+			Usage: "Authorization token required by interactsh clients",
+		},
+
 		cli.StringFlag{
 			Name: "secondary-password,x", Hidden: true,
 			EnvVar: "YAK_BRIDGE_SECONDARY_PASSWORD",
@@ -220,6 +235,12 @@ func GetTunnelServerCommandCli() *cli.App {
 			log.Errorf("build tunnel server failed: %s", err)
 			return err
 		}
+		if c.Bool("interactsh") {
+			if c.String("domain") == "" {
+				return utils.Error("empty interactsh domain config")
+			}
+			EnableInteractshServer(c.String("domain"), c.String("interactsh-token"))
+		}
 		s.SecondaryPassword = c.String("secondary-password")
 		if s.SecondaryPassword == "" {
 			err := s.InitialReverseTrigger()
@@ -238,6 +259,17 @@ func GetTunnelServerCommandCli() *cli.App {
 			}
 			tpb.RegisterDNSLogServer(grpcTrans, dnslogServer)
 		}
+		if port := c.Int("facade-port"); port > 0 {
+			go func() {
+				for {
+					err := facades.NewFacadeServer("0.0.0.0", port).Serve()
+					if err != nil {
+						log.Errorf("serve facade server failed: %s", err)
+					}
+					time.Sleep(time.Second)
+				}
+			}()
+		}
 		//else {
 		//	tpb.RegisterTunnelServer(grpcTrans, s)
 		//}
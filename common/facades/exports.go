@@ -1,8 +1,9 @@
 package facades
 
 var FacadesExports = map[string]interface{}{
-	"NewFacadeServer": NewFacadeServer,
-	"Serve":           Serve,
+	"NewFacadeServer":           NewFacadeServer,
+	"Serve":                     Serve,
+	"QueryNotificationsByToken": QueryNotificationsByToken,
 
 	// 使用参数
 	"javaClassName":     SetJavaClassName,
@@ -13,4 +14,8 @@ var FacadesExports = map[string]interface{}{
 	"ldapResourceAddr":  SetLdapResourceAddr,
 	"rmiResourceAddr":   SetRmiResourceAddr,
 	"evilClassResource": SetRmiResourceAddr,
+	"ntlmChallenge":     SetNTLMChallenge,
+	"ntlmDomain":        SetNTLMDomain,
+	"httpNTLMCapture":   SetHTTPNTLMCapture,
+	"greetingFallback":  SetGreetingFallback,
 }
@@ -2,6 +2,8 @@ package facades
 
 import (
 	"fmt"
+	"time"
+
 	uuid "github.com/google/uuid"
 	"github.com/yaklang/yaklang/common/utils"
 )
@@ -19,6 +21,7 @@ type Notification struct {
 	Uuid         string `json:"uuid"`
 	ResponseInfo string `json:"response_info"`
 	ConnectHash  string `json:"connect_hash"`
+	Timestamp    int64  `json:"timestamp"`
 }
 
 func NewNotification(t string, remoteAddr string, raw []byte, token string) *Notification {
@@ -28,6 +31,7 @@ func NewNotification(t string, remoteAddr string, raw []byte, token string) *Not
 		Raw:        raw,
 		Token:      token,
 		Uuid:       uuid.New().String(),
+		Timestamp:  time.Now().Unix(),
 	}
 }
 
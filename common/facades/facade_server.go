@@ -13,6 +13,7 @@ import (
 	"github.com/yaklang/yaklang/common/yserx"
 	"github.com/yaklang/yaklang/common/yso"
 	"net"
+	"strings"
 	"sync"
 
 	//"palm/common/yak/yaklib"
@@ -24,8 +25,15 @@ const (
 	LDAPMsgFlag         = "ldap_flag"
 	RMIMsgFlag          = "rmi"
 	RMIHandshakeMsgFlag = "rmi-handshake"
+	SMBMsgFlag          = "smb"
+	NTLMMsgFlag         = "ntlm"
+	FTPMsgFlag          = "ftp"
+	SMTPMsgFlag         = "smtp"
 )
 
+// 每个 FacadeServer 保留的最近通知数量，用于按 token 查询
+const notificationHistorySize = 1024
+
 const emptyVerbose = "<empty>"
 const getInfoFailedVerbose = "<get info failed>"
 
@@ -110,6 +118,16 @@ type FacadeServer struct {
 	//resourceName               string
 	ldapEntry map[string]interface{}
 	httpMux   *sync.Mutex
+
+	// NTLM 质询使用的固定 ServerChallenge 和域名，为空时随机生成质询
+	ntlmChallenge []byte
+	ntlmDomain    string
+
+	// 客户端连接后一直不发送数据时按照 FTP / SMTP 发送欢迎信息，默认开启
+	greetingFallback bool
+
+	historyMux    sync.Mutex
+	notifications []*Notification
 }
 
 type ResourcesInfo struct {
@@ -198,6 +216,40 @@ func SetReverseAddress(address string) FacadeServerConfig {
 	}
 }
 
+// SetNTLMChallenge 设置 NTLM 质询中固定的 8 字节 ServerChallenge（hex），方便使用彩虹表破解 NetNTLMv1
+func SetNTLMChallenge(challenge string) FacadeServerConfig {
+	return func(f *FacadeServer) {
+		raw, err := codec.DecodeHex(challenge)
+		if err != nil || len(raw) != 8 {
+			log.Errorf("invalid ntlm challenge %#v: need 8 bytes hex", challenge)
+			return
+		}
+		f.ntlmChallenge = raw
+	}
+}
+
+// SetNTLMDomain 设置 NTLM 质询中返回的 NetBIOS 域名
+func SetNTLMDomain(domain string) FacadeServerConfig {
+	return func(f *FacadeServer) {
+		f.ntlmDomain = domain
+	}
+}
+
+// SetHTTPNTLMCapture 对路径为 name 的 HTTP 请求要求 NTLM 认证，用于在 SSRF 中捕获 NetNTLM 哈希
+func SetHTTPNTLMCapture(name string) FacadeServerConfig {
+	return func(f *FacadeServer) {
+		f.SaveHttpRoute("/"+strings.TrimPrefix(name, "/"), &HttpResource{responseType: HttpResourceType_NTLM})
+	}
+}
+
+// SetGreetingFallback 设置客户端连接后一段时间内没有发送数据时，是否发送 220 欢迎信息并按照 FTP / SMTP 处理，默认开启
+// 发送数据较慢的 HTTP 客户端会先收到欢迎信息，请求仍然会按照 HTTP 记录；不需要 FTP / SMTP 时可以关闭
+func SetGreetingFallback(enable bool) FacadeServerConfig {
+	return func(f *FacadeServer) {
+		f.greetingFallback = enable
+	}
+}
+
 func (f *FacadeServer) Config(configs ...FacadeServerConfig) {
 	for _, config := range configs {
 		config(f)
@@ -293,6 +345,7 @@ func NewFacadeServer(host string, port int, configs ...FacadeServerConfig) *Faca
 		rmiResourceAddrs:  NewFacadeServerResource[[]byte](),
 		ldapResourceAddrs: NewFacadeServerResource[map[string]any](),
 		httpMux:           &sync.Mutex{},
+		greetingFallback:  true,
 	}
 	facadeServer.rmiResourceAddrs.SetResource("", nil, emptyVerbose)
 	facadeServer.httpResource.SetResource("", NewHttpRawResource([]byte(defaultHTTPFallback)), emptyVerbose)
@@ -327,6 +380,7 @@ func (f *FacadeServer) triggerNotificationEx(t string, conn net.Conn, token stri
 	notif.ConnectHash = codec.Md5(fmt.Sprintf("%p", conn))
 	// 响应内容
 	notif.ResponseInfo = responseInfo
+	f.saveNotification(notif)
 	if len(f.handlers) <= 0 {
 		//spew.Dump(notif)
 	}
@@ -338,6 +392,34 @@ func (f *FacadeServer) triggerNotificationEx(t string, conn net.Conn, token stri
 		handle(notif)
 	}
 }
+func (f *FacadeServer) saveNotification(n *Notification) {
+	f.historyMux.Lock()
+	defer f.historyMux.Unlock()
+	f.notifications = append(f.notifications, n)
+	if len(f.notifications) > notificationHistorySize {
+		f.notifications = f.notifications[len(f.notifications)-notificationHistorySize:]
+	}
+}
+
+// QueryNotificationsByToken 查询 token 中包含指定 token 的通知（不区分大小写），可以指定协议类型
+// HTTP 的 token 是请求路径，FTP 是路径的第一段，SMTP 是收件人的用户名
+func (f *FacadeServer) QueryNotificationsByToken(token string, types ...string) []*Notification {
+	token = strings.ToLower(token)
+	f.historyMux.Lock()
+	defer f.historyMux.Unlock()
+	var res []*Notification
+	for _, n := range f.notifications {
+		if n.Token == "" || !strings.Contains(strings.ToLower(n.Token), token) {
+			continue
+		}
+		if len(types) > 0 && !utils.StringArrayContains(types, n.Type) {
+			continue
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
 func (f *FacadeServer) Serve() error {
 	return f.ServeWithContext(context.Background())
 }
@@ -350,6 +432,8 @@ func (f *FacadeServer) CancelServe() {
 func (f *FacadeServer) ServeWithContext(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	f.cancel = cancel
+	servingFacadeServers.Store(f, struct{}{})
+	defer servingFacadeServers.Delete(f)
 	log.Debugf("start to handle facade server: for %v", utils.HostPort(f.Host, f.Port))
 	lis, err := net.Listen("tcp", utils.HostPort(f.Host, f.Port))
 	if err != nil {
@@ -387,7 +471,20 @@ func (f *FacadeServer) handleConn(conn net.Conn) {
 	isTls := utils.NewBool(false)
 WRAPPER:
 	peekableConn := utils.NewPeekableNetConn(conn)
+	if f.greetingFallback && !isTls.IsSet() {
+		peekableConn.SetReadDeadline(time.Now().Add(greetingWaitTimeout))
+	}
 	raw, err := peekableConn.Peek(4)
+	if len(raw) == 0 && utils.IsErrorNetOpTimeout(err) {
+		// 客户端在等待服务端先发送欢迎信息
+		err := f.serveGreeting(peekableConn)
+		if err != nil {
+			log.Errorf("serve ftp/smtp failed: %s", err)
+		}
+		peekableConn.Close()
+		return
+	}
+	peekableConn.SetReadDeadline(time.Time{})
 	//buff := make([]byte, 7)
 	//typ, err := peekableConn.Read(buff)
 	//typ := utils.StableReaderEx(peekableConn, 5*time.Second, 10240)
@@ -491,6 +588,17 @@ WRAPPER:
 		}
 		cli.Serve()
 		peekableConn.Close()
+	case 0x00: // NetBIOS session message
+		if head, err := peekableConn.Peek(8); err == nil && len(head) >= 8 && isSMBHeader(head[4:]) {
+			f.triggerNotification(SMBMsgFlag, conn, "", nil)
+			err := f.smbServe(peekableConn)
+			if err != nil {
+				log.Errorf("serve smb failed: %s", err)
+			}
+			peekableConn.Close()
+			return
+		}
+		fallthrough
 	default:
 		log.Infof("start to fallback http handlers for: %s", conn.RemoteAddr())
 		err = f.getHTTPHandler(isTls.IsSet())(peekableConn)
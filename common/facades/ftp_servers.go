@@ -0,0 +1,188 @@
+package facades
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ftpSession 记录一次 FTP 会话，XXE 通过 ftp:// 外带数据时，数据会出现在 CWD / RETR 的路径中
+type ftpSession struct {
+	User     string
+	Password string
+	// Files 是根据 CWD 和 RETR 还原出来的完整路径
+	Files []string
+
+	cwd     []string
+	lines   []string
+	dataLis net.Listener
+}
+
+func (s *ftpSession) changeDir(dir string) {
+	if strings.HasPrefix(dir, "/") {
+		s.cwd = nil
+	}
+	for _, chunk := range strings.Split(dir, "/") {
+		switch chunk {
+		case "", ".":
+		case "..":
+			if len(s.cwd) > 0 {
+				s.cwd = s.cwd[:len(s.cwd)-1]
+			}
+		default:
+			s.cwd = append(s.cwd, chunk)
+		}
+	}
+}
+
+func (s *ftpSession) dir() string {
+	return "/" + strings.Join(s.cwd, "/")
+}
+
+// Token 是路径中的第一段（ftp://host/<token>/...），没有路径时使用非匿名的用户名
+func (s *ftpSession) Token() string {
+	var p string
+	switch {
+	case len(s.Files) > 0:
+		p = s.Files[0]
+	case len(s.cwd) > 0:
+		p = s.dir()
+	}
+	if first, _, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/"); first != "" {
+		return first
+	}
+	if !strings.EqualFold(s.User, "anonymous") && !strings.EqualFold(s.User, "ftp") {
+		return s.User
+	}
+	return ""
+}
+
+func (s *ftpSession) String() string {
+	files := s.Files
+	if len(files) == 0 && len(s.cwd) > 0 {
+		files = []string{s.dir()}
+	}
+	return fmt.Sprintf("user: %v, password: %v, path: %v", s.User, s.Password, strings.Join(files, ", "))
+}
+
+func (s *ftpSession) passiveListen(conn net.Conn) (net.Listener, error) {
+	if s.dataLis != nil {
+		s.dataLis.Close()
+	}
+	host, _, _ := net.SplitHostPort(conn.LocalAddr().String())
+	lis, err := net.Listen("tcp", utils.HostPort(host, 0))
+	if err != nil {
+		return nil, err
+	}
+	s.dataLis = lis
+	return lis, nil
+}
+
+// transfer 接受被动模式的数据连接并立即关闭，只用于让客户端发出 RETR / LIST
+func (s *ftpSession) transfer(w *bufio.Writer) {
+	if s.dataLis == nil {
+		replyLine(w, "425 Use PASV or EPSV first")
+		return
+	}
+	replyLine(w, "150 Opening BINARY mode data connection")
+	if tcpLis, ok := s.dataLis.(*net.TCPListener); ok {
+		tcpLis.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+	if dataConn, err := s.dataLis.Accept(); err == nil {
+		dataConn.Close()
+	}
+	s.dataLis.Close()
+	s.dataLis = nil
+	replyLine(w, "226 Transfer complete")
+}
+
+// ftpServe 处理 FTP 客户端的命令，firstLine 是协议识别时已经读到的第一条命令
+func (f *FacadeServer) ftpServe(conn net.Conn, reader *bufio.Reader, firstLine string) error {
+	session := &ftpSession{}
+	w := bufio.NewWriter(conn)
+	defer func() {
+		if session.dataLis != nil {
+			session.dataLis.Close()
+		}
+		f.triggerNotificationEx(FTPMsgFlag, conn, session.Token(), []byte(strings.Join(session.lines, "\r\n")), session.String())
+	}()
+
+	line := firstLine
+	for round := 0; round < 256; round++ {
+		if round > 0 {
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			raw, err := reader.ReadString('\n')
+			if err != nil {
+				return nil
+			}
+			line = raw
+		}
+		line = strings.TrimRight(line, "\r\n")
+		session.lines = append(session.lines, line)
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "USER":
+			session.User = arg
+			replyLine(w, "331 Please specify the password")
+		case "PASS":
+			session.Password = arg
+			replyLine(w, "230 Login successful")
+		case "SYST":
+			replyLine(w, "215 UNIX Type: L8")
+		case "FEAT":
+			replyLine(w, "211-Features:\r\n EPSV\r\n PASV\r\n211 End")
+		case "TYPE", "MODE", "STRU", "OPTS", "NOOP", "PORT", "EPRT":
+			replyLine(w, "200 Command okay")
+		case "PWD", "XPWD":
+			replyLine(w, fmt.Sprintf("257 %q is the current directory", session.dir()))
+		case "CWD", "XCWD":
+			session.changeDir(arg)
+			replyLine(w, "250 Directory successfully changed")
+		case "CDUP":
+			session.changeDir("..")
+			replyLine(w, "250 Directory successfully changed")
+		case "SIZE", "MDTM":
+			replyLine(w, "550 Could not get file information")
+		case "EPSV":
+			lis, err := session.passiveListen(conn)
+			if err != nil {
+				replyLine(w, "425 Cannot open data connection")
+				continue
+			}
+			replyLine(w, fmt.Sprintf("229 Entering Extended Passive Mode (|||%d|)", lis.Addr().(*net.TCPAddr).Port))
+		case "PASV":
+			lis, err := session.passiveListen(conn)
+			if err != nil {
+				replyLine(w, "425 Cannot open data connection")
+				continue
+			}
+			ip := lis.Addr().(*net.TCPAddr).IP.To4()
+			if ip == nil {
+				replyLine(w, "425 Use EPSV for IPv6")
+				continue
+			}
+			port := lis.Addr().(*net.TCPAddr).Port
+			replyLine(w, fmt.Sprintf("227 Entering Passive Mode (%d,%d,%d,%d,%d,%d)", ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff))
+		case "RETR":
+			session.Files = append(session.Files, path.Join(session.dir(), arg))
+			session.transfer(w)
+		case "LIST", "NLST", "MLSD":
+			session.transfer(w)
+		case "QUIT":
+			replyLine(w, "221 Goodbye")
+			return nil
+		default:
+			// 外带的数据中可能包含换行，会被当作单独的命令，全部保留在记录中
+			log.Debugf("ftp unknown command from %v: %v", conn.RemoteAddr(), line)
+			replyLine(w, "500 Unknown command")
+		}
+	}
+	return utils.Error("too many ftp commands")
+}
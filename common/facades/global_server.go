@@ -7,6 +7,9 @@ import (
 
 var facadeServers sync.Map
 
+// 正在监听的 FacadeServer，用于按 token 查询所有服务的通知
+var servingFacadeServers sync.Map
+
 func GetFacadeServer(token string) *FacadeServer {
 	v, ok := facadeServers.Load(token)
 	if !ok {
@@ -25,3 +28,13 @@ func RegisterFacadeServer(ctx context.Context, token string, server *FacadeServe
 func DeleteFacadeServer(token string) {
 	facadeServers.Delete(token)
 }
+
+// QueryNotificationsByToken 在所有正在监听的 FacadeServer 中查询 token 中包含指定 token 的通知，可以指定协议类型，例如 ftp / smtp
+func QueryNotificationsByToken(token string, types ...string) []*Notification {
+	var res []*Notification
+	servingFacadeServers.Range(func(key, value any) bool {
+		res = append(res, key.(*FacadeServer).QueryNotificationsByToken(token, types...)...)
+		return true
+	})
+	return res
+}
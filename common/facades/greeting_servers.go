@@ -0,0 +1,66 @@
+package facades
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// FTP 和 SMTP 都由服务端先发送 220 欢迎信息，客户端连接之后在这段时间内没有发送数据时按照这两种协议处理(可以通过 SetGreetingFallback 关闭)
+const greetingWaitTimeout = 2 * time.Second
+
+const greetingBanner = "220 facade ESMTP FTP service ready"
+
+var smtpCommands = map[string]bool{"HELO": true, "EHLO": true, "MAIL": true, "RCPT": true, "STARTTLS": true}
+
+func replyLine(w *bufio.Writer, line string) {
+	w.WriteString(line + "\r\n")
+	w.Flush()
+}
+
+// serveGreeting 发送 FTP / SMTP 通用的欢迎信息，根据客户端的第一条命令区分协议
+func (f *FacadeServer) serveGreeting(conn *utils.BufferedPeekableConn) error {
+	w := bufio.NewWriter(conn)
+	replyLine(w, greetingBanner)
+
+	reader := bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if isHTTPRequestLine(line) {
+		// 发送数据较慢的 HTTP 客户端，欢迎信息之后仍然按照 HTTP 处理，保证请求被记录
+		log.Infof("handle slow http client for %v", conn.RemoteAddr())
+		replayConn := &greetingReplayConn{Conn: conn.GetOriginConn(), reader: io.MultiReader(strings.NewReader(line), reader)}
+		return f.getHTTPHandler(false)(utils.NewPeekableNetConn(replayConn))
+	}
+	cmd, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if smtpCommands[strings.ToUpper(cmd)] {
+		log.Infof("handle smtp for %v", conn.RemoteAddr())
+		return f.smtpServe(conn.GetOriginConn(), reader, line)
+	}
+	log.Infof("handle ftp for %v", conn.RemoteAddr())
+	return f.ftpServe(conn.GetOriginConn(), reader, line)
+}
+
+// isHTTPRequestLine 判断是否为 HTTP 请求行，例如 GET /path HTTP/1.1
+func isHTTPRequestLine(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") && strings.ToUpper(fields[0]) == fields[0]
+}
+
+// greetingReplayConn 重新读取已经被 bufio.Reader 读出的数据
+type greetingReplayConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *greetingReplayConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
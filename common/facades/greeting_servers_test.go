@@ -0,0 +1,148 @@
+package facades
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func startGreetingFacadeServer(t *testing.T, typ string, configs ...FacadeServerConfig) (*FacadeServer, string, chan *Notification) {
+	port := utils.GetRandomAvailableTCPPort()
+	server := NewFacadeServer("127.0.0.1", port, configs...)
+	notifications := make(chan *Notification, 16)
+	server.OnHandle(func(n *Notification) {
+		if n.Type == typ {
+			notifications <- n
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go server.ServeWithContext(ctx)
+	addr := utils.HostPort("127.0.0.1", port)
+	require.NoError(t, utils.WaitConnect(addr, 3))
+	return server, addr, notifications
+}
+
+func waitGreetingNotification(t *testing.T, notifications chan *Notification) *Notification {
+	select {
+	case n := <-notifications:
+		return n
+	case <-time.After(10 * time.Second):
+		t.Fatal("notification timeout")
+	}
+	return nil
+}
+
+func TestFacadeFTP(t *testing.T) {
+	server, addr, notifications := startGreetingFacadeServer(t, FTPMsgFlag)
+	conn, err := textproto.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _, err = conn.ReadResponse(220)
+	require.NoError(t, err)
+	cmd := func(expect int, format string, args ...any) string {
+		id, err := conn.Cmd(format, args...)
+		require.NoError(t, err)
+		conn.StartResponse(id)
+		defer conn.EndResponse(id)
+		_, msg, err := conn.ReadResponse(expect)
+		require.NoError(t, err)
+		return msg
+	}
+
+	// 与 Java FtpURLConnection 处理 ftp://host/xxe-token/root:x:0:0/passwd 的顺序一致
+	cmd(331, "USER anonymous")
+	cmd(230, "PASS Java1.8.0@")
+	cmd(200, "TYPE I")
+	cmd(250, "CWD xxe-token")
+	cmd(250, "CWD root:x:0:0")
+	msg := cmd(229, "EPSV")
+	var port int
+	_, err = fmt.Sscanf(msg, "Entering Extended Passive Mode (|||%d|)", &port)
+	require.NoError(t, err)
+	dataConn, err := net.Dial("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	defer dataConn.Close()
+
+	id, err := conn.Cmd("RETR passwd")
+	require.NoError(t, err)
+	conn.StartResponse(id)
+	_, _, err = conn.ReadResponse(150)
+	require.NoError(t, err)
+	_, _, err = conn.ReadResponse(226)
+	require.NoError(t, err)
+	conn.EndResponse(id)
+	cmd(221, "QUIT")
+
+	n := waitGreetingNotification(t, notifications)
+	require.Equal(t, "xxe-token", n.Token)
+	require.Equal(t, "user: anonymous, password: Java1.8.0@, path: /xxe-token/root:x:0:0/passwd", n.ResponseInfo)
+	require.Contains(t, string(n.Raw), "CWD root:x:0:0")
+
+	require.Len(t, server.QueryNotificationsByToken("XXE-TOKEN", FTPMsgFlag), 1)
+	require.Len(t, server.QueryNotificationsByToken("xxe-token", SMTPMsgFlag), 0)
+}
+
+func TestFacadeSMTP(t *testing.T) {
+	server, addr, notifications := startGreetingFacadeServer(t, SMTPMsgFlag)
+	err := smtp.SendMail(addr, nil, "app@victim.example", []string{"mail-token@facade.example"}, []byte("Subject: test\r\n\r\nhello\r\n.hidden\r\n"))
+	require.NoError(t, err)
+
+	n := waitGreetingNotification(t, notifications)
+	require.Equal(t, "mail-token", n.Token)
+	require.Equal(t, "from: app@victim.example, rcpt: mail-token@facade.example, data: 33 bytes", n.ResponseInfo)
+	require.Contains(t, string(n.Raw), "RCPT TO:<mail-token@facade.example>")
+	require.Contains(t, string(n.Raw), "\r\n.hidden\r\n")
+
+	require.Len(t, server.QueryNotificationsByToken("mail-token"), 1)
+	require.Len(t, QueryNotificationsByToken("mail-token", SMTPMsgFlag), 1)
+}
+
+func TestFacadeGreetingSlowHTTPClient(t *testing.T) {
+	_, addr, notifications := startGreetingFacadeServer(t, "http")
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// 发送数据较慢的 HTTP 客户端会先收到欢迎信息，请求仍然按照 HTTP 处理
+	time.Sleep(greetingWaitTimeout + 500*time.Millisecond)
+	_, err = conn.Write([]byte("GET /slow-token HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	banner, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Contains(t, banner, "220")
+	rsp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	rsp.Body.Close()
+
+	n := waitGreetingNotification(t, notifications)
+	require.Contains(t, n.Token, "slow-token")
+}
+
+func TestFacadeGreetingFallbackDisabled(t *testing.T) {
+	_, addr, notifications := startGreetingFacadeServer(t, "http", SetGreetingFallback(false))
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	time.Sleep(greetingWaitTimeout + 500*time.Millisecond)
+	_, err = conn.Write([]byte("GET /slow-token HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	require.NoError(t, err)
+	rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	rsp.Body.Close()
+
+	n := waitGreetingNotification(t, notifications)
+	require.Contains(t, n.Token, "slow-token")
+}
@@ -38,6 +38,8 @@ const (
 	HttpResourceType_File HttpResourceType = "file"
 	HttpResourceType_Body HttpResourceType = "body"
 	HttpResourceType_Raw  HttpResourceType = "raw"
+	// HttpResourceType_NTLM 要求客户端进行 NTLM 认证并捕获 NetNTLM 哈希
+	HttpResourceType_NTLM HttpResourceType = "ntlm"
 )
 
 type HttpResource struct {
@@ -112,7 +114,8 @@ func (f *FacadeServer) getHTTPHandler(isHttps bool) FacadeConnectionHandler {
 		var c net.Conn = peekConn
 		c.SetDeadline(time.Now().Add(3 * time.Second))
 		log.Infof("start to read http request from %s", c.RemoteAddr())
-		req, err := utils.ReadHTTPRequestFromBufioReader(bufio.NewReader(c))
+		reader := bufio.NewReader(c)
+		req, err := utils.ReadHTTPRequestFromBufioReader(reader)
 		if err != nil {
 			log.Errorf("read http request from conn[%s] failed", c.RemoteAddr())
 			return err
@@ -136,6 +139,9 @@ func (f *FacadeServer) getHTTPHandler(isHttps bool) FacadeConnectionHandler {
 		} else {
 			msgType = "http"
 		}
+		if handled, err := f.serveHTTPNTLM(peekConn, reader, req, msgType); handled {
+			return err
+		}
 		// TODO: has multi read map risk
 		for k, v := range f.httpResource.Resources {
 			pattern := k
@@ -191,6 +197,23 @@ func (f *FacadeServer) getHTTPHandler(isHttps bool) FacadeConnectionHandler {
 						f.triggerNotificationEx(msgType, peekConn.GetOriginConn(), token, reqRaw, token)
 					}
 					return nil
+				case HttpResourceType_NTLM:
+					if !response.disableNotify {
+						f.triggerNotificationEx(msgType, peekConn.GetOriginConn(), token, reqRaw, "ntlm challenge")
+					}
+					c.SetDeadline(time.Now().Add(3 * time.Second))
+					c.Write([]byte(httpNTLMRequired))
+					next, err := utils.ReadHTTPRequestFromBufioReader(reader)
+					if err != nil {
+						c.Close()
+						return nil
+					}
+					handled, err := f.serveHTTPNTLM(peekConn, reader, next, msgType)
+					if !handled {
+						c.Write([]byte(defaultHTTPFallback))
+					}
+					c.Close()
+					return err
 				}
 			}
 		}
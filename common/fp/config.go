@@ -60,6 +60,9 @@ type Config struct {
 	// 禁用专门的 Web 指纹扫描
 	DisableWebFingerprint bool
 
+	// 启用 TLS 服务端指纹，默认关闭，开启时每个 TLS 端口会额外发送 10 次握手
+	EnableTLSServerFingerprint bool
+
 	// 这个选项标志着，如果 Web 指纹检测中途已经检测出了某些指纹，也应该继续检测其他指纹
 	WebFingerprintUseAllRules bool
 
@@ -470,6 +473,12 @@ func WithDisableWebFingerprint(t bool) ConfigOption {
 	}
 }
 
+func WithEnableTLSServerFingerprint(t bool) ConfigOption {
+	return func(config *Config) {
+		config.EnableTLSServerFingerprint = t
+	}
+}
+
 func WithFingerprintDataSize(size int) ConfigOption {
 	return func(config *Config) {
 		config.FingerprintDataSize = size
@@ -107,6 +107,13 @@ func (m *MatchResult) Merge(f *MatchResult) {
 	m.Fingerprint.ServiceName = strings.Trim(m.Fingerprint.ServiceName, "/")
 
 	m.Fingerprint.HttpFlows = append(m.Fingerprint.HttpFlows, f.Fingerprint.HttpFlows...)
+	m.Fingerprint.ProbeBanners = mergeProbeBanners(append(m.Fingerprint.ProbeBanners, f.Fingerprint.ProbeBanners...))
+	if len(m.Fingerprint.TLSInspectResults) <= 0 {
+		m.Fingerprint.TLSInspectResults = f.Fingerprint.TLSInspectResults
+	}
+	if m.Fingerprint.TLSServerFingerprint == nil {
+		m.Fingerprint.TLSServerFingerprint = f.Fingerprint.TLSServerFingerprint
+	}
 	if f.Fingerprint.CPEFromUrls != nil && m.Fingerprint.CPEFromUrls != nil {
 		for k, v := range f.Fingerprint.CPEFromUrls {
 			_, ok := m.Fingerprint.CPEFromUrls[k]
@@ -166,6 +166,9 @@ func (f *Matcher) MatchWithContext(ctx context.Context, host string, port int, o
 	// if port open, check tls...
 	if matchResult.State == OPEN && matchResult.Fingerprint != nil {
 		matchResult.Fingerprint.TLSInspectResults, _ = netx.TLSInspectTimeout(utils2.HostPort(host, port), 5)
+		if len(matchResult.Fingerprint.TLSInspectResults) > 0 && config.EnableTLSServerFingerprint {
+			matchResult.Fingerprint.TLSServerFingerprint, _ = netx.TLSServerFingerprintTimeout(utils2.HostPort(host, port), 5)
+		}
 	}
 
 	matchResult.Tidy()
@@ -149,6 +149,7 @@ func mergeInfo(rawInfos ...*FingerprintInfo) *FingerprintInfo {
 		return info[0]
 	}
 	root := info[0]
+	banners := root.ProbeBanners
 	for _, infoIns := range info[1:] {
 		if infoIns == nil {
 			continue
@@ -166,10 +167,30 @@ func mergeInfo(rawInfos ...*FingerprintInfo) *FingerprintInfo {
 
 		root.HttpFlows = append(root.HttpFlows, infoIns.HttpFlows...)
 		root.CPEs = append(root.CPEs, infoIns.CPEs...)
+		banners = append(banners, infoIns.ProbeBanners...)
 	}
+	root.ProbeBanners = mergeProbeBanners(banners)
 	return root
 }
 
+// mergeProbeBanners 按照探针和协议去重，保留第一次收到的 Banner
+func mergeProbeBanners(banners []*ProbeBanner) []*ProbeBanner {
+	var ret []*ProbeBanner
+	seen := make(map[string]struct{})
+	for _, b := range banners {
+		if b == nil {
+			continue
+		}
+		key := fmt.Sprintf("%v/%v", b.Proto, b.Probe)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		ret = append(ret, b)
+	}
+	return ret
+}
+
 func mergeError(info ...error) error {
 	if len(info) <= 0 {
 		return nil
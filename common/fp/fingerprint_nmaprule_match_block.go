@@ -86,6 +86,17 @@ func match(rule *NmapMatch, data []rune, port int, host net.IP, safeBanner strin
 	return nil
 }
 
+func (f *FingerprintInfo) addProbeBanner(probe *NmapProbe, size int) {
+	if size <= 0 {
+		return
+	}
+	f.ProbeBanners = append(f.ProbeBanners, &ProbeBanner{
+		Probe:  probe.Name,
+		Proto:  probe.Proto,
+		Banner: f.Banner,
+	})
+}
+
 func (f *Matcher) matchBlock(ctx context.Context, host net.IP, port int, block *RuleBlock, config *Config) (open PortState, _ *FingerprintInfo, _ error) {
 	rootCtx := ctx
 	ctx, _ = context.WithTimeout(ctx, config.ProbeTimeout)
@@ -155,6 +166,7 @@ func (f *Matcher) matchBlock(ctx context.Context, host net.IP, port int, block *
 					break
 				}
 			}
+			resultFingerprintInfo.addProbeBanner(block.Probe, len(banner))
 
 			return OPEN, resultFingerprintInfo, nil
 		}
@@ -199,6 +211,7 @@ func (f *Matcher) matchBlock(ctx context.Context, host net.IP, port int, block *
 						break
 					}
 				}
+				resultFingerprintInfo.addProbeBanner(block.Probe, len(banner))
 			} else {
 				return UNKNOWN, resultFingerprintInfo, nil
 			}
@@ -0,0 +1,48 @@
+package fp
+
+import (
+	"net"
+	"sort"
+
+	utils2 "github.com/yaklang/yaklang/common/utils"
+)
+
+// MatchBanner 使用 nmap 服务指纹规则匹配被动获取的服务端 Banner（例如从流量中看到的第一个响应），不会发送任何数据包
+//
+// NULL 探针的规则优先匹配，之后按照探针的顺序匹配其他探针的规则，都没有匹配到时返回 nil
+func MatchBanner(host string, port int, proto TransportProto, banner []byte) *FingerprintInfo {
+	if len(banner) <= 0 {
+		return nil
+	}
+	rules, err := GetDefaultNmapServiceProbeRules()
+	if err != nil {
+		return nil
+	}
+
+	probes := make([]*NmapProbe, 0, len(rules))
+	for probe := range rules {
+		if probe.Proto == proto {
+			probes = append(probes, probe)
+		}
+	}
+	sort.SliceStable(probes, func(i, j int) bool {
+		if (probes[i].Name == "NULL") != (probes[j].Name == "NULL") {
+			return probes[i].Name == "NULL"
+		}
+		return probes[i].Index < probes[j].Index
+	})
+
+	ip := net.ParseIP(host)
+	safeBanner := bannerToString(banner)
+	runes := utils2.AsciiBytesToRegexpMatchedRunes(banner)
+	for _, probe := range probes {
+		for _, rule := range rules[probe] {
+			if info := match(rule, runes, port, ip, safeBanner, proto); info != nil {
+				info.IP = host
+				info.addProbeBanner(probe, len(banner))
+				return info
+			}
+		}
+	}
+	return nil
+}
@@ -36,6 +36,18 @@ type FingerprintInfo struct {
 
 	// tls info for fill...
 	TLSInspectResults []*netx.TLSInspectResult
+	// TLSServerFingerprint 是 JARM 风格的 TLS 服务端指纹
+	TLSServerFingerprint *netx.TLSServerFingerprint
+
+	// ProbeBanners 记录每个收到响应的探针的 Banner
+	ProbeBanners []*ProbeBanner `json:"probe_banners"`
+}
+
+// ProbeBanner 是某个探针收到的 Banner
+type ProbeBanner struct {
+	Probe  string         `json:"probe"`
+	Proto  TransportProto `json:"proto"`
+	Banner string         `json:"banner"`
 }
 
 type HTTPFlow struct {
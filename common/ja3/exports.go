@@ -4,7 +4,17 @@ var (
 	Exports = map[string]interface{}{
 		"ParseJA3":                      ParseJA3,
 		"ParseJA3S":                     ParseJA3S,
+		"ParseJA3FromClientHello":       ParseJA3FromClientHello,
+		"ParseJA3SFromServerHello":      ParseJA3SFromServerHello,
 		"ParseJA3ToClientHelloSpec":     ParseJA3ToClientHelloSpec,
 		"GetTransportByClientHelloSpec": GetTransportByClientHelloSpec,
+
+		"ParseJA4FromClientHello":     ParseJA4FromClientHello,
+		"ParseJA4FromClientHelloRaw":  ParseJA4FromClientHelloRaw,
+		"ParseJA4SFromServerHello":    ParseJA4SFromServerHello,
+		"ParseJA4SFromServerHelloRaw": ParseJA4SFromServerHelloRaw,
+		"ParseJA4H":                   ParseJA4H,
+		"ParseJA4X":                   ParseJA4X,
+		"ParseJA4ToClientHelloSpec":   ParseJA4ToClientHelloSpec,
 	}
 )
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/refraction-networking/utls"
 	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
 	"net"
 	"net/http"
 	"strings"
@@ -148,6 +149,22 @@ func ParseJA3S(ja3sFullString string) (*JA3S, error) {
 	return ja3s, nil
 }
 
+// ParseJA3FromClientHello 从抓到的 ClientHello 计算 JA3，例如 pcaputil.WithTLSClientHello 回调中的 hello
+func ParseJA3FromClientHello(hello *tlsutils.HandshakeClientHello) (*JA3, error) {
+	if hello == nil {
+		return nil, errors.New("empty client hello")
+	}
+	return ParseJA3(hello.JA3String())
+}
+
+// ParseJA3SFromServerHello 从抓到的 ServerHello 计算 JA3S
+func ParseJA3SFromServerHello(hello *tlsutils.HandshakeServerHello) (*JA3S, error) {
+	if hello == nil {
+		return nil, errors.New("empty server hello")
+	}
+	return ParseJA3S(hello.JA3SString())
+}
+
 func ParseJA3ToClientHelloSpec(str string) (*tls.ClientHelloSpec, error) {
 	var (
 		extensions string
@@ -0,0 +1,449 @@
+package ja3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	gmx509 "github.com/yaklang/yaklang/common/gmsm/x509"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+// JA4 系列指纹参考 https://github.com/FoxIO-LLC/ja4 ，每一段哈希都是 sha256 的前 12 个字符
+
+const ja4EmptyHash = "000000000000"
+
+func ja4Hash(s string) string {
+	if s == "" {
+		return ja4EmptyHash
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func ja4HexList(list []uint16) string {
+	items := make([]string, 0, len(list))
+	for _, v := range list {
+		items = append(items, fmt.Sprintf("%04x", v))
+	}
+	return strings.Join(items, ",")
+}
+
+func ja4SortedHexList(list []uint16) string {
+	sorted := append([]uint16{}, list...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return ja4HexList(sorted)
+}
+
+func ja4Count(n int) string {
+	if n > 99 {
+		n = 99
+	}
+	return fmt.Sprintf("%02d", n)
+}
+
+func ja4Version(v uint16) string {
+	switch v {
+	case VersionTLS13:
+		return "13"
+	case VersionTLS12:
+		return "12"
+	case VersionTLS11:
+		return "11"
+	case VersionTLS10:
+		return "10"
+	case VersionSSL30:
+		return "s3"
+	case 0x0200:
+		return "s2"
+	case 0xfeff:
+		return "d1"
+	case 0xfefd:
+		return "d2"
+	case 0xfefc:
+		return "d3"
+	}
+	return "00"
+}
+
+func isAlphanumeric(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// ja4ALPN 返回 ALPN 的首尾字符，首尾不是字母数字时使用十六进制的首尾字符
+func ja4ALPN(alpn string) string {
+	if alpn == "" {
+		return "00"
+	}
+	first, last := alpn[0], alpn[len(alpn)-1]
+	if !isAlphanumeric(first) || !isAlphanumeric(last) {
+		h := hex.EncodeToString([]byte(alpn))
+		return string(h[0]) + string(h[len(h)-1])
+	}
+	return string(first) + string(last)
+}
+
+func withoutGREASE(list []uint16) []uint16 {
+	var ret []uint16
+	for _, v := range list {
+		if !tlsutils.IsGREASE(v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// JA4 是 TLS 客户端指纹
+type JA4 struct {
+	// Protocol 为 t(TCP) / q(QUIC) / d(DTLS)
+	Protocol string
+	Version  uint16
+	SNI      bool
+	// CipherSuites / Extensions 去掉了 GREASE，保持原始顺序
+	CipherSuites        []uint16
+	Extensions          []uint16
+	SignatureAlgorithms []uint16
+	// ALPN 是第一个 ALPN 协议
+	ALPN string
+}
+
+func (j *JA4) prefix() string {
+	proto := j.Protocol
+	if proto == "" {
+		proto = "t"
+	}
+	sni := "i"
+	if j.SNI {
+		sni = "d"
+	}
+	return proto + ja4Version(j.Version) + sni + ja4Count(len(j.CipherSuites)) + ja4Count(len(j.Extensions)) + ja4ALPN(j.ALPN)
+}
+
+// extensionsWithoutSNIAndALPN 计算哈希时不包括 SNI 和 ALPN
+func (j *JA4) extensionsWithoutSNIAndALPN() []uint16 {
+	var ret []uint16
+	for _, ext := range j.Extensions {
+		if ext == extensionServerName || ext == extensionALPN {
+			continue
+		}
+		ret = append(ret, ext)
+	}
+	return ret
+}
+
+func (j *JA4) rawParts() (string, string) {
+	exts := ja4SortedHexList(j.extensionsWithoutSNIAndALPN())
+	if len(j.SignatureAlgorithms) > 0 {
+		exts += "_" + ja4HexList(j.SignatureAlgorithms)
+	}
+	return ja4SortedHexList(j.CipherSuites), exts
+}
+
+// Fingerprint 返回 JA4 指纹，例如 t13d1516h2_8daaf6152771_02713d6af862
+func (j *JA4) Fingerprint() string {
+	ciphers, exts := j.rawParts()
+	c := ja4EmptyHash
+	if len(j.extensionsWithoutSNIAndALPN()) > 0 {
+		c = ja4Hash(exts)
+	}
+	return j.prefix() + "_" + ja4Hash(ciphers) + "_" + c
+}
+
+// Raw 返回 JA4_r，即排序之后没有哈希的原文，可以使用 ParseJA4ToClientHelloSpec 还原 ClientHelloSpec
+func (j *JA4) Raw() string {
+	ciphers, exts := j.rawParts()
+	return j.prefix() + "_" + ciphers + "_" + exts
+}
+
+func (j *JA4) String() string {
+	return j.Fingerprint()
+}
+
+// ParseJA4FromClientHello 从抓到的 ClientHello 计算 JA4
+func ParseJA4FromClientHello(hello *tlsutils.HandshakeClientHello) (*JA4, error) {
+	if hello == nil {
+		return nil, errors.New("empty client hello")
+	}
+	j := &JA4{
+		Protocol:            "t",
+		Version:             hello.MaxVersion(),
+		SNI:                 hello.SNI() != "",
+		CipherSuites:        withoutGREASE(hello.CipherSuites()),
+		Extensions:          withoutGREASE(hello.ExtensionTypes()),
+		SignatureAlgorithms: withoutGREASE(hello.SignatureAlgorithms()),
+	}
+	if alpn := hello.ALPN(); len(alpn) > 0 {
+		j.ALPN = alpn[0]
+	}
+	return j, nil
+}
+
+// ParseJA4FromClientHelloRaw 从 TLS 记录或者握手消息中解析 ClientHello 并计算 JA4
+func ParseJA4FromClientHelloRaw(raw []byte) (*JA4, error) {
+	hello, err := tlsutils.ParseClientHello(raw)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJA4FromClientHello(hello)
+}
+
+// JA4S 是 TLS 服务端指纹
+type JA4S struct {
+	Protocol    string
+	Version     uint16
+	CipherSuite uint16
+	// Extensions 保持原始顺序
+	Extensions []uint16
+	ALPN       string
+}
+
+func (j *JA4S) prefix() string {
+	proto := j.Protocol
+	if proto == "" {
+		proto = "t"
+	}
+	return proto + ja4Version(j.Version) + ja4Count(len(j.Extensions)) + ja4ALPN(j.ALPN) + "_" + fmt.Sprintf("%04x", j.CipherSuite)
+}
+
+// Fingerprint 返回 JA4S 指纹，例如 t130200_1301_234ea6891581
+func (j *JA4S) Fingerprint() string {
+	return j.prefix() + "_" + ja4Hash(ja4HexList(j.Extensions))
+}
+
+// Raw 返回 JA4S_r
+func (j *JA4S) Raw() string {
+	return j.prefix() + "_" + ja4HexList(j.Extensions)
+}
+
+func (j *JA4S) String() string {
+	return j.Fingerprint()
+}
+
+// ParseJA4SFromServerHello 从抓到的 ServerHello 计算 JA4S
+func ParseJA4SFromServerHello(hello *tlsutils.HandshakeServerHello) (*JA4S, error) {
+	if hello == nil {
+		return nil, errors.New("empty server hello")
+	}
+	j := &JA4S{
+		Protocol:    "t",
+		Version:     hello.SelectedVersion(),
+		CipherSuite: hello.CipherSuite,
+	}
+	for _, ext := range hello.Extensions {
+		j.Extensions = append(j.Extensions, ext.TypeInt)
+		// ALPN: 2 字节列表长度 + 1 字节协议长度 + 协议
+		if ext.TypeInt == extensionALPN && len(ext.RawData) > 3 {
+			j.ALPN = string(ext.RawData[3:])
+		}
+	}
+	return j, nil
+}
+
+// ParseJA4SFromServerHelloRaw 从 TLS 记录或者握手消息中解析 ServerHello 并计算 JA4S
+func ParseJA4SFromServerHelloRaw(raw []byte) (*JA4S, error) {
+	hello, err := tlsutils.ParseServerHello(raw)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJA4SFromServerHello(hello)
+}
+
+// JA4H 是 HTTP 客户端指纹
+type JA4H struct {
+	Method  string
+	Version string
+	// Headers 是按照原始顺序排列的请求头名称，不包括 Cookie 和 Referer
+	Headers        []string
+	HasCookie      bool
+	HasReferer     bool
+	AcceptLanguage string
+	// Cookies 是 Cookie 中的 name=value，保持原始顺序
+	Cookies []string
+}
+
+func (j *JA4H) prefix() string {
+	method := strings.ToLower(j.Method)
+	if len(method) > 2 {
+		method = method[:2]
+	}
+	cookie, referer := "n", "n"
+	if j.HasCookie {
+		cookie = "c"
+	}
+	if j.HasReferer {
+		referer = "r"
+	}
+	lang := strings.ToLower(strings.ReplaceAll(j.AcceptLanguage, "-", ""))
+	lang = strings.Split(strings.ReplaceAll(lang, ";", ","), ",")[0]
+	if len(lang) > 4 {
+		lang = lang[:4]
+	}
+	lang += strings.Repeat("0", 4-len(lang))
+	return method + j.Version + cookie + referer + ja4Count(len(j.Headers)) + lang
+}
+
+func (j *JA4H) cookieParts() (string, string) {
+	var names, pairs []string
+	for _, c := range j.Cookies {
+		name, _, _ := strings.Cut(c, "=")
+		names = append(names, name)
+		pairs = append(pairs, c)
+	}
+	sort.Strings(names)
+	sort.Strings(pairs)
+	return strings.Join(names, ","), strings.Join(pairs, ",")
+}
+
+// Fingerprint 返回 JA4H 指纹，例如 ge11cn20enus_60ca1bd65281_ac95b44401d9_8df6a44f726c
+func (j *JA4H) Fingerprint() string {
+	names, pairs := j.cookieParts()
+	return j.prefix() + "_" + ja4Hash(strings.Join(j.Headers, ",")) + "_" + ja4Hash(names) + "_" + ja4Hash(pairs)
+}
+
+// Raw 返回 JA4H_r
+func (j *JA4H) Raw() string {
+	names, pairs := j.cookieParts()
+	return j.prefix() + "_" + strings.Join(j.Headers, ",") + "_" + names + "_" + pairs
+}
+
+func (j *JA4H) String() string {
+	return j.Fingerprint()
+}
+
+// ParseJA4H 从原始 HTTP 请求报文计算 JA4H，需要原始报文才能保留请求头的顺序
+func ParseJA4H(raw []byte) (*JA4H, error) {
+	reader := bufio.NewReader(bytes.NewReader(raw))
+	firstLine, err := reader.ReadString('\n')
+	if err != nil && firstLine == "" {
+		return nil, errors.New("empty http request")
+	}
+	fields := strings.Fields(firstLine)
+	if len(fields) < 3 || !strings.HasPrefix(strings.ToUpper(fields[2]), "HTTP/") {
+		return nil, fmt.Errorf("invalid http request line: %q", strings.TrimSpace(firstLine))
+	}
+	j := &JA4H{Method: fields[0]}
+	switch strings.TrimPrefix(strings.ToUpper(fields[2]), "HTTP/") {
+	case "1.0":
+		j.Version = "10"
+	case "1.1":
+		j.Version = "11"
+	case "2", "2.0":
+		j.Version = "20"
+	case "3", "3.0":
+		j.Version = "30"
+	default:
+		j.Version = "00"
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && !strings.HasPrefix(name, ":") {
+			name = strings.TrimSpace(name)
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(name) {
+			case "cookie":
+				j.HasCookie = true
+				for _, c := range strings.Split(value, ";") {
+					if c = strings.TrimSpace(c); c != "" {
+						j.Cookies = append(j.Cookies, c)
+					}
+				}
+			case "referer":
+				j.HasReferer = true
+			default:
+				if strings.EqualFold(name, "accept-language") {
+					j.AcceptLanguage = value
+				}
+				j.Headers = append(j.Headers, name)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return j, nil
+}
+
+// JA4X 是证书指纹，由签发者、主体的 RDN 属性 OID 和扩展的 OID 计算，与证书内容无关，可以用来识别证书的生成工具
+type JA4X struct {
+	// 每一项都是 OID 的 DER 编码的十六进制
+	IssuerOIDs    []string
+	SubjectOIDs   []string
+	ExtensionOIDs []string
+}
+
+// Fingerprint 返回 JA4X 指纹，例如 a373a9f83c6b_2bab15409345_7bf9a7bf7029
+func (j *JA4X) Fingerprint() string {
+	return ja4Hash(strings.Join(j.IssuerOIDs, ",")) + "_" + ja4Hash(strings.Join(j.SubjectOIDs, ",")) + "_" + ja4Hash(strings.Join(j.ExtensionOIDs, ","))
+}
+
+// Raw 返回 JA4X_r
+func (j *JA4X) Raw() string {
+	return strings.Join(j.IssuerOIDs, ",") + "_" + strings.Join(j.SubjectOIDs, ",") + "_" + strings.Join(j.ExtensionOIDs, ",")
+}
+
+func (j *JA4X) String() string {
+	return j.Fingerprint()
+}
+
+func ja4OIDHex(oid asn1.ObjectIdentifier) string {
+	der, err := asn1.Marshal(oid)
+	if err != nil || len(der) < 2 {
+		return ""
+	}
+	// 去掉 tag 和 length，OID 的长度不会超过 127
+	return hex.EncodeToString(der[2:])
+}
+
+func ja4NameOIDs(name pkix.Name) []string {
+	var ret []string
+	for _, attr := range name.Names {
+		ret = append(ret, ja4OIDHex(attr.Type))
+	}
+	return ret
+}
+
+func ja4ExtensionOIDs(exts []pkix.Extension) []string {
+	var ret []string
+	for _, ext := range exts {
+		ret = append(ret, ja4OIDHex(ext.Id))
+	}
+	return ret
+}
+
+// ParseJA4X 从 DER 或者 PEM 格式的证书计算 JA4X，支持国密证书
+func ParseJA4X(raw []byte) (*JA4X, error) {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	if cert, err := x509.ParseCertificate(raw); err == nil {
+		return &JA4X{
+			IssuerOIDs:    ja4NameOIDs(cert.Issuer),
+			SubjectOIDs:   ja4NameOIDs(cert.Subject),
+			ExtensionOIDs: ja4ExtensionOIDs(cert.Extensions),
+		}, nil
+	}
+	cert, err := gmx509.ParseCertificate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate failed: %v", err)
+	}
+	return &JA4X{
+		IssuerOIDs:    ja4NameOIDs(cert.Issuer),
+		SubjectOIDs:   ja4NameOIDs(cert.Subject),
+		ExtensionOIDs: ja4ExtensionOIDs(cert.Extensions),
+	}, nil
+}
@@ -0,0 +1,187 @@
+package ja3
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/refraction-networking/utls"
+)
+
+func parseJA4HexList(field string) ([]uint16, error) {
+	if field == "" {
+		return nil, nil
+	}
+	var ret []uint16
+	for _, item := range strings.Split(field, ",") {
+		v, err := strconv.ParseUint(item, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ja4 hex value %q: %v", item, err)
+		}
+		ret = append(ret, uint16(v))
+	}
+	return ret, nil
+}
+
+func parseJA4Version(v string) (uint16, error) {
+	switch v {
+	case "13":
+		return tls.VersionTLS13, nil
+	case "12":
+		return tls.VersionTLS12, nil
+	case "11":
+		return tls.VersionTLS11, nil
+	case "10":
+		return tls.VersionTLS10, nil
+	}
+	return 0, fmt.Errorf("unsupported ja4 tls version: %v", v)
+}
+
+// ja4ALPNProtocols 根据 ALPN 的首尾字符猜测客户端提供的 ALPN 列表
+func ja4ALPNProtocols(alpn string) []string {
+	switch alpn {
+	case "00":
+		return nil
+	case "h2":
+		return []string{"h2", "http/1.1"}
+	case "h1":
+		return []string{"http/1.1"}
+	case "h3":
+		return []string{"h3"}
+	}
+	return nil
+}
+
+// ParseJA4ToClientHelloSpec 从 JA4_r 构造 utls 的 ClientHelloSpec，构造出的 ClientHello 计算得到的 JA4 与输入一致
+//
+// JA4 只保留了排序后的加密套件和扩展，所以扩展的顺序、曲线、ALPN 列表等信息会使用常见的默认值；
+// pre_shared_key 使用占位的 identity 和 binder，只用于复现指纹，不能真正恢复会话；
+// 前缀中的加密套件数量和扩展数量与列表不一致时返回错误；哈希形式的 JA4 无法还原，需要使用 JA4_r
+func ParseJA4ToClientHelloSpec(ja4r string) (*tls.ClientHelloSpec, error) {
+	parts := strings.Split(strings.TrimSpace(ja4r), "_")
+	if len(parts) < 3 || len(parts) > 4 || len(parts[0]) != 10 {
+		return nil, errors.New("not a valid JA4_r string")
+	}
+	if len(parts[1]) == 12 && !strings.Contains(parts[1], ",") {
+		return nil, errors.New("hashed JA4 cannot be converted to client hello spec, use JA4_r instead")
+	}
+
+	prefix := parts[0]
+	if prefix[0] != 't' {
+		return nil, fmt.Errorf("only tcp tls JA4 is supported, got protocol %q", prefix[:1])
+	}
+	version, err := parseJA4Version(prefix[1:3])
+	if err != nil {
+		return nil, err
+	}
+	ciphers, err := parseJA4HexList(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	exts, err := parseJA4HexList(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	var sigAlgs []tls.SignatureScheme
+	if len(parts) == 4 {
+		list, err := parseJA4HexList(parts[3])
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range list {
+			sigAlgs = append(sigAlgs, tls.SignatureScheme(v))
+		}
+	}
+	alpn := ja4ALPNProtocols(prefix[8:10])
+	if prefix[8:10] != "00" && alpn == nil {
+		return nil, fmt.Errorf("cannot guess alpn protocols from %q", prefix[8:10])
+	}
+
+	spec := &tls.ClientHelloSpec{
+		CipherSuites:       ciphers,
+		CompressionMethods: []uint8{0},
+		TLSVersMin:         tls.VersionTLS10,
+		TLSVersMax:         version,
+	}
+	if prefix[3] == 'd' {
+		spec.Extensions = append(spec.Extensions, &tls.SNIExtension{})
+	}
+	if alpn != nil {
+		spec.Extensions = append(spec.Extensions, &tls.ALPNExtension{AlpnProtocols: alpn})
+	}
+
+	var padding, psk tls.TLSExtension
+	for _, id := range exts {
+		var ext tls.TLSExtension
+		switch id {
+		case extensionStatusRequest:
+			ext = &tls.StatusRequestExtension{}
+		case extensionSupportedCurves:
+			ext = &tls.SupportedCurvesExtension{Curves: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}}
+		case extensionSupportedPoints:
+			ext = &tls.SupportedPointsExtension{SupportedPoints: []uint8{0}}
+		case extensionSignatureAlgorithms:
+			ext = &tls.SignatureAlgorithmsExtension{SupportedSignatureAlgorithms: sigAlgs}
+		case extensionSCT:
+			ext = &tls.SCTExtension{}
+		case 21:
+			padding = &tls.UtlsPaddingExtension{GetPaddingLen: tls.BoringPaddingStyle}
+			continue
+		case extensionMasterSecret:
+			ext = &tls.UtlsExtendedMasterSecretExtension{}
+		case 27:
+			ext = &tls.UtlsCompressCertExtension{Algorithms: []tls.CertCompressionAlgo{tls.CertCompressionBrotli}}
+		case 28:
+			ext = &tls.FakeRecordSizeLimitExtension{Limit: 0x4001}
+		case extensionSessionTicket:
+			ext = &tls.SessionTicketExtension{}
+		case extensionPreSharedKey:
+			// pre_shared_key 需要恢复的会话，这里只填充占位数据，与 padding 一样需要放在最后
+			psk = &tls.FakePreSharedKeyExtension{
+				PskIdentities: []tls.PskIdentity{{Label: make([]byte, 32)}},
+				// utls 序列化 binder 时不会写入长度，需要自带 1 字节长度前缀
+				PskBinders: [][]byte{append([]byte{32}, make([]byte, 32)...)},
+			}
+			continue
+		case extensionSupportedVersions:
+			versions := []uint16{version}
+			for v := version - 1; v >= tls.VersionTLS12; v-- {
+				versions = append(versions, v)
+			}
+			ext = &tls.SupportedVersionsExtension{Versions: versions}
+		case extensionPSKModes:
+			ext = &tls.PSKKeyExchangeModesExtension{Modes: []uint8{tls.PskModeDHE}}
+		case extensionSignatureAlgorithmsCert:
+			ext = &tls.SignatureAlgorithmsCertExtension{SupportedSignatureAlgorithms: sigAlgs}
+		case extensionKeyShare:
+			ext = &tls.KeyShareExtension{KeyShares: []tls.KeyShare{{Group: tls.X25519}}}
+		case 0x4469:
+			ext = &tls.ApplicationSettingsExtension{SupportedProtocols: alpn}
+		case extensionRenegotiationInfo:
+			ext = &tls.RenegotiationInfoExtension{Renegotiation: tls.RenegotiateOnceAsClient}
+		case extensionServerName, extensionALPN:
+			// JA4_r 的扩展列表中不包括 SNI 和 ALPN
+			continue
+		default:
+			ext = &tls.GenericExtension{Id: id}
+		}
+		spec.Extensions = append(spec.Extensions, ext)
+	}
+	if padding != nil {
+		// padding 的长度由其他扩展决定，需要放在最后
+		spec.Extensions = append(spec.Extensions, padding)
+	}
+	if psk != nil {
+		// TLS 1.3 要求 pre_shared_key 必须是最后一个扩展
+		spec.Extensions = append(spec.Extensions, psk)
+	}
+
+	if count := ja4Count(len(ciphers)); count != prefix[4:6] {
+		return nil, fmt.Errorf("ja4 cipher count mismatch: prefix has %v, list has %v", prefix[4:6], count)
+	}
+	if count := ja4Count(len(spec.Extensions)); count != prefix[6:8] {
+		return nil, fmt.Errorf("ja4 extension count mismatch: prefix has %v, rebuilt client hello has %v", prefix[6:8], count)
+	}
+	return spec, nil
+}
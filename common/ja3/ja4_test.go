@@ -0,0 +1,105 @@
+package ja3
+
+import (
+	"net"
+	"testing"
+
+	"github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+// Chrome 120 的 JA4
+const chromeJA4r = "t13d1516h2_002f,0035,009c,009d,1301,1302,1303,c013,c014,c02b,c02c,c02f,c030,cca8,cca9_0005,000a,000b,000d,0012,0017,001b,0023,002b,002d,0033,4469,fe0d,ff01_0403,0804,0401,0503,0805,0501,0806,0601"
+
+func TestJA4ClientHelloSpecRoundTrip(t *testing.T) {
+	spec, err := ParseJA4ToClientHelloSpec(chromeJA4r)
+	require.NoError(t, err)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	uconn := tls.UClient(client, &tls.Config{ServerName: "www.example.com"}, tls.HelloCustom)
+	require.NoError(t, uconn.ApplyPreset(spec))
+	require.NoError(t, uconn.BuildHandshakeState())
+
+	ja4, err := ParseJA4FromClientHelloRaw(uconn.HandshakeState.Hello.Raw)
+	require.NoError(t, err)
+	require.Equal(t, chromeJA4r, ja4.Raw())
+	require.Equal(t, "t13d1516h2_8daaf6152771_02713d6af862", ja4.Fingerprint())
+
+	_, err = ParseJA4ToClientHelloSpec(ja4.Fingerprint())
+	require.Error(t, err)
+}
+
+func TestJA4ClientHelloSpecRoundTrip_PreSharedKey(t *testing.T) {
+	// Chrome 恢复会话时的 JA4_r，包含 pre_shared_key(0029)
+	const ja4r = "t13d1517h2_002f,0035,009c,009d,1301,1302,1303,c013,c014,c02b,c02c,c02f,c030,cca8,cca9_0005,000a,000b,000d,0012,0017,001b,0023,0029,002b,002d,0033,4469,fe0d,ff01_0403,0804,0401,0503,0805,0501,0806,0601"
+	spec, err := ParseJA4ToClientHelloSpec(ja4r)
+	require.NoError(t, err)
+	_, isPSK := spec.Extensions[len(spec.Extensions)-1].(*tls.FakePreSharedKeyExtension)
+	require.True(t, isPSK, "pre_shared_key must be the last extension")
+
+	client, server := net.Pipe()
+	defer server.Close()
+	uconn := tls.UClient(client, &tls.Config{ServerName: "www.example.com"}, tls.HelloCustom)
+	require.NoError(t, uconn.ApplyPreset(spec))
+	require.NoError(t, uconn.BuildHandshakeState())
+
+	ja4, err := ParseJA4FromClientHelloRaw(uconn.HandshakeState.Hello.Raw)
+	require.NoError(t, err)
+	require.Equal(t, ja4r, ja4.Raw())
+}
+
+func TestJA4ClientHelloSpec_CountMismatch(t *testing.T) {
+	_, err := ParseJA4ToClientHelloSpec("t13d1517h2" + chromeJA4r[10:])
+	require.ErrorContains(t, err, "extension count")
+	_, err = ParseJA4ToClientHelloSpec("t13d1416h2" + chromeJA4r[10:])
+	require.ErrorContains(t, err, "cipher count")
+}
+
+func TestJA4S(t *testing.T) {
+	hello := &tlsutils.HandshakeServerHello{
+		Version:     0x0303,
+		CipherSuite: 0x1301,
+		Extensions: []*tlsutils.HandshakeClientHelloExt{
+			{TypeInt: 0x002b, RawData: []byte{0x03, 0x04}},
+			{TypeInt: 0x0033, RawData: make([]byte, 36)},
+		},
+	}
+	ja4s, err := ParseJA4SFromServerHello(hello)
+	require.NoError(t, err)
+	require.Equal(t, "t130200_1301_002b,0033", ja4s.Raw())
+	require.Equal(t, "t130200_1301_"+ja4Hash("002b,0033"), ja4s.Fingerprint())
+}
+
+func TestJA4H(t *testing.T) {
+	ja4h, err := ParseJA4H([]byte("GET /index HTTP/1.1\r\n" +
+		"Host: www.example.com\r\n" +
+		"User-Agent: curl/8.0\r\n" +
+		"Accept-Language: en-US,en;q=0.9\r\n" +
+		"Cookie: b=2; a=1\r\n" +
+		"Referer: https://www.example.com/\r\n\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, "ge11cr03enus_Host,User-Agent,Accept-Language_a,b_a=1,b=2", ja4h.Raw())
+	require.Len(t, ja4h.Fingerprint(), len("ge11cr03enus_")+12*3+2)
+
+	ja4h, err = ParseJA4H([]byte("POST / HTTP/1.0\r\nHost: a\r\n\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, "po10nn010000_"+ja4Hash("Host")+"_000000000000_000000000000", ja4h.Fingerprint())
+}
+
+func TestJA4X(t *testing.T) {
+	ca, _, err := tlsutils.GenerateSelfSignedCertKey("127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	ja4x, err := ParseJA4X(ca)
+	require.NoError(t, err)
+	// CN = 2.5.4.3
+	require.Contains(t, ja4x.SubjectOIDs, "550403")
+	require.NotEmpty(t, ja4x.ExtensionOIDs)
+
+	gmCert, _, err := tlsutils.GenerateGMSelfSignedCertKey("yaklang")
+	require.NoError(t, err)
+	gmJA4X, err := ParseJA4X(gmCert)
+	require.NoError(t, err)
+	require.Len(t, gmJA4X.Fingerprint(), 38)
+}
@@ -0,0 +1,963 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type asmToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeAsmLine 按空白切分一行，双引号中的内容作为一个 token，未加引号的 ; 开头表示注释
+func tokenizeAsmLine(line string) ([]asmToken, error) {
+	var tokens []asmToken
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == ';':
+			return tokens, nil
+		case c == '"':
+			end := i + 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, utils.Errorf("unterminated string: %s", line[i:])
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, utils.Errorf("invalid string %s: %v", line[i:end+1], err)
+			}
+			tokens = append(tokens, asmToken{text: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' && line[end] != '\r' {
+				end++
+			}
+			tokens = append(tokens, asmToken{text: line[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func parseAccessFlags(tokens []asmToken, table []accessFlag) (uint16, error) {
+	var flags uint16
+	for _, token := range tokens {
+		found := false
+		for _, flag := range table {
+			if token.text == flag.name {
+				flags |= flag.mask
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if strings.HasPrefix(token.text, "0x") {
+			value, err := strconv.ParseUint(token.text[2:], 16, 16)
+			if err == nil {
+				flags |= uint16(value)
+				continue
+			}
+		}
+		return 0, utils.Errorf("unknown access flag %s", token.text)
+	}
+	return flags, nil
+}
+
+// splitMemberRef 把 owner/name(desc)ret 或者 owner/name 拆分开
+func splitMemberRef(ref string) (string, string, string, error) {
+	head, desc := ref, ""
+	if i := strings.IndexByte(ref, '('); i >= 0 {
+		head, desc = ref[:i], ref[i:]
+	}
+	i := strings.LastIndexByte(head, '/')
+	if i <= 0 || i == len(head)-1 {
+		return "", "", "", utils.Errorf("invalid member reference %s", ref)
+	}
+	return head[:i], head[i+1:], desc, nil
+}
+
+type asmInstruction struct {
+	inst           *Instruction
+	target         string
+	defaultTarget  string
+	targets        []string
+	expectedTarget int
+	lineNo         int
+}
+
+type asmCatch struct {
+	start, end, handler string
+	catchType           uint16
+	lineNo              int
+}
+
+type asmVariable struct {
+	index      uint16
+	name, desc uint16
+	start, end string
+	lineNo     int
+}
+
+type asmLine struct {
+	pos  int
+	line uint16
+}
+
+type methodAssembler struct {
+	member    *MemberInfo
+	name      string
+	desc      string
+	insts     []*asmInstruction
+	labels    map[string]int
+	lines     []*asmLine
+	catches   []*asmCatch
+	variables []*asmVariable
+	throws    []uint16
+	hasCode   bool
+	// 正在解析的 switch 指令
+	pendingSwitch *asmInstruction
+}
+
+type classAssembler struct {
+	obj       *ClassObject
+	builder   *constantPoolBuilder
+	bootstrap []*BootstrapMethod
+	bsmIndex  map[string]uint16
+	method    *methodAssembler
+	hasClass  bool
+	hasSuper  bool
+	lineNo    int
+}
+
+/*
+*
+Assemble 把 Jasmin 风格的文本（Disassemble 的输出）汇编为 class，
+max_stack、max_locals 和 StackMapTable 会根据字节码重新计算，.limit 和 ; frame 注释会被忽略
+
+	.catch java/lang/Exception from L0 to L8 using L11
+	.var 0 is this LFoo; from L0 to L20
+	.line 10
+	ldc "hello"
+	invokevirtual java/io/PrintStream/println(Ljava/lang/String;)V
+	invokedynamic run()Ljava/lang/Runnable; invokestatic java/lang/invoke/LambdaMetafactory/metafactory(...)Ljava/lang/invoke/CallSite; methodtype ()V ...
+*/
+func Assemble(text string, opts ...FrameOption) (obj *ClassObject, err error) {
+	a := &classAssembler{
+		obj: &ClassObject{
+			Magic:        0xCAFEBABE,
+			MajorVersion: 52,
+		},
+		bsmIndex: make(map[string]uint16),
+	}
+	a.builder = newConstantPoolBuilder(a.obj)
+	defer func() {
+		if r := recover(); r != nil {
+			obj = nil
+			err = utils.Errorf("assemble failed at line %d: %v", a.lineNo, r)
+		}
+	}()
+
+	for i, line := range strings.Split(text, "\n") {
+		a.lineNo = i + 1
+		tokens, err := tokenizeAsmLine(line)
+		if err != nil {
+			return nil, utils.Errorf("line %d: %v", a.lineNo, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if err := a.handleLine(tokens); err != nil {
+			return nil, utils.Errorf("line %d: %v", a.lineNo, err)
+		}
+	}
+	if a.method != nil {
+		return nil, utils.Errorf("method %s%s is not terminated by .end method", a.method.name, a.method.desc)
+	}
+	if !a.hasClass {
+		return nil, utils.Error("missing .class directive")
+	}
+	if !a.hasSuper && a.obj.GetClassName() != "java/lang/Object" {
+		a.obj.SuperClass = a.builder.class("java/lang/Object")
+	}
+	if len(a.bootstrap) > 0 {
+		a.builder.utf8("BootstrapMethods")
+		a.obj.Attributes = append(a.obj.Attributes, &BootstrapMethodsAttribute{BootstrapMethods: a.bootstrap})
+	}
+	if err := a.obj.ComputeMaxsAndFrames(opts...); err != nil {
+		return nil, err
+	}
+	return a.obj, nil
+}
+
+func (a *classAssembler) handleLine(tokens []asmToken) error {
+	if a.method != nil && a.method.pendingSwitch != nil {
+		return a.method.handleSwitchLine(tokens)
+	}
+	first := tokens[0]
+	if !first.quoted && strings.HasPrefix(first.text, ".") {
+		return a.handleDirective(first.text, tokens[1:])
+	}
+	if a.method == nil {
+		return utils.Errorf("unexpected %s outside of method", first.text)
+	}
+	if !first.quoted && len(first.text) > 1 && strings.HasSuffix(first.text, ":") {
+		name := strings.TrimSuffix(first.text, ":")
+		if _, ok := a.method.labels[name]; ok {
+			return utils.Errorf("duplicate label %s", name)
+		}
+		a.method.labels[name] = len(a.method.insts)
+		if len(tokens) == 1 {
+			return nil
+		}
+		tokens = tokens[1:]
+	}
+	return a.handleInstruction(tokens)
+}
+
+func (a *classAssembler) expectArgs(directive string, tokens []asmToken, n int) error {
+	if len(tokens) != n {
+		return utils.Errorf("%s expects %d arguments, got %d", directive, n, len(tokens))
+	}
+	return nil
+}
+
+func (a *classAssembler) handleDirective(directive string, tokens []asmToken) error {
+	m := a.method
+	inMethod := func() error {
+		if m == nil {
+			return utils.Errorf("%s outside of method", directive)
+		}
+		return nil
+	}
+	switch directive {
+	case ".version":
+		if len(tokens) < 1 || len(tokens) > 2 {
+			return utils.Errorf(".version expects major [minor]")
+		}
+		major, err := strconv.ParseUint(tokens[0].text, 10, 16)
+		if err != nil {
+			return utils.Errorf("invalid major version %s", tokens[0].text)
+		}
+		a.obj.MajorVersion = uint16(major)
+		if len(tokens) == 2 {
+			minor, err := strconv.ParseUint(tokens[1].text, 10, 16)
+			if err != nil {
+				return utils.Errorf("invalid minor version %s", tokens[1].text)
+			}
+			a.obj.MinorVersion = uint16(minor)
+		}
+	case ".source":
+		if err := a.expectArgs(directive, tokens, 1); err != nil {
+			return err
+		}
+		a.builder.utf8("SourceFile")
+		a.obj.Attributes = append(a.obj.Attributes, &SourceFileAttribute{AttrLen: 2, SourceFileIndex: a.builder.utf8(tokens[0].text)})
+	case ".class":
+		if a.hasClass {
+			return utils.Error("duplicate .class directive")
+		}
+		if len(tokens) < 1 {
+			return utils.Error(".class expects a class name")
+		}
+		flags, err := parseAccessFlags(tokens[:len(tokens)-1], classAccessFlags)
+		if err != nil {
+			return err
+		}
+		a.obj.AccessFlags = flags
+		a.obj.AccessFlagsVerbose = getAccessFlagsVerbose(flags)
+		a.obj.ThisClass = a.builder.class(tokens[len(tokens)-1].text)
+		a.hasClass = true
+	case ".super":
+		if err := a.expectArgs(directive, tokens, 1); err != nil {
+			return err
+		}
+		a.obj.SuperClass = a.builder.class(tokens[0].text)
+		a.hasSuper = true
+	case ".implements":
+		if err := a.expectArgs(directive, tokens, 1); err != nil {
+			return err
+		}
+		a.obj.Interfaces = append(a.obj.Interfaces, a.builder.class(tokens[0].text))
+	case ".field":
+		return a.handleField(tokens)
+	case ".method":
+		if m != nil {
+			return utils.Error("nested .method")
+		}
+		if !a.hasClass {
+			return utils.Error(".class must be declared before methods")
+		}
+		if len(tokens) < 1 {
+			return utils.Error(".method expects name and descriptor")
+		}
+		nameDesc := tokens[len(tokens)-1].text
+		i := strings.IndexByte(nameDesc, '(')
+		if i <= 0 {
+			return utils.Errorf("invalid method %s", nameDesc)
+		}
+		if _, _, err := parseMethodDescriptor(nameDesc[i:]); err != nil {
+			return err
+		}
+		flags, err := parseAccessFlags(tokens[:len(tokens)-1], methodAccessFlags)
+		if err != nil {
+			return err
+		}
+		a.method = &methodAssembler{
+			member: &MemberInfo{
+				AccessFlags:     flags,
+				NameIndex:       a.builder.utf8(nameDesc[:i]),
+				DescriptorIndex: a.builder.utf8(nameDesc[i:]),
+			},
+			name:   nameDesc[:i],
+			desc:   nameDesc[i:],
+			labels: make(map[string]int),
+		}
+	case ".end":
+		if len(tokens) != 1 || tokens[0].text != "method" {
+			return utils.Error("expect .end method")
+		}
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.finishMethod(); err != nil {
+			return utils.Errorf("method %s%s: %v", m.name, m.desc, err)
+		}
+		a.method = nil
+	case ".limit":
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.expectArgs(directive, tokens, 2); err != nil {
+			return err
+		}
+		if tokens[0].text != "stack" && tokens[0].text != "locals" {
+			return utils.Errorf("unknown .limit %s", tokens[0].text)
+		}
+		if _, err := strconv.ParseUint(tokens[1].text, 10, 16); err != nil {
+			return utils.Errorf("invalid .limit %s", tokens[1].text)
+		}
+		m.hasCode = true
+	case ".throws":
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.expectArgs(directive, tokens, 1); err != nil {
+			return err
+		}
+		m.throws = append(m.throws, a.builder.class(tokens[0].text))
+	case ".catch":
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.expectArgs(directive, tokens, 7); err != nil {
+			return err
+		}
+		if tokens[1].text != "from" || tokens[3].text != "to" || tokens[5].text != "using" {
+			return utils.Error("expect .catch <type|all> from <label> to <label> using <label>")
+		}
+		c := &asmCatch{start: tokens[2].text, end: tokens[4].text, handler: tokens[6].text, lineNo: a.lineNo}
+		if tokens[0].quoted || tokens[0].text != "all" {
+			c.catchType = a.builder.class(tokens[0].text)
+		}
+		m.catches = append(m.catches, c)
+		m.hasCode = true
+	case ".var":
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.expectArgs(directive, tokens, 8); err != nil {
+			return err
+		}
+		if tokens[1].text != "is" || tokens[4].text != "from" || tokens[6].text != "to" {
+			return utils.Error("expect .var <index> is <name> <descriptor> from <label> to <label>")
+		}
+		index, err := strconv.ParseUint(tokens[0].text, 10, 16)
+		if err != nil {
+			return utils.Errorf("invalid local variable index %s", tokens[0].text)
+		}
+		m.variables = append(m.variables, &asmVariable{
+			index: uint16(index), name: a.builder.utf8(tokens[2].text), desc: a.builder.utf8(tokens[3].text),
+			start: tokens[5].text, end: tokens[7].text, lineNo: a.lineNo,
+		})
+		m.hasCode = true
+	case ".line":
+		if err := inMethod(); err != nil {
+			return err
+		}
+		if err := a.expectArgs(directive, tokens, 1); err != nil {
+			return err
+		}
+		line, err := strconv.ParseUint(tokens[0].text, 10, 16)
+		if err != nil {
+			return utils.Errorf("invalid line number %s", tokens[0].text)
+		}
+		m.lines = append(m.lines, &asmLine{pos: len(m.insts), line: uint16(line)})
+	default:
+		return utils.Errorf("unknown directive %s", directive)
+	}
+	return nil
+}
+
+func (a *classAssembler) handleField(tokens []asmToken) error {
+	if !a.hasClass {
+		return utils.Error(".class must be declared before fields")
+	}
+	var value []asmToken
+	for i, token := range tokens {
+		if !token.quoted && token.text == "=" {
+			tokens, value = tokens[:i], tokens[i+1:]
+			break
+		}
+	}
+	if len(tokens) < 2 {
+		return utils.Error(".field expects name and descriptor")
+	}
+	flags, err := parseAccessFlags(tokens[:len(tokens)-2], fieldAccessFlags)
+	if err != nil {
+		return err
+	}
+	desc := tokens[len(tokens)-1].text
+	if end, err := fieldDescriptorEnd(desc, 0); err != nil || end != len(desc) {
+		return utils.Errorf("invalid field descriptor %s", desc)
+	}
+	field := &MemberInfo{
+		AccessFlags:     flags,
+		NameIndex:       a.builder.utf8(tokens[len(tokens)-2].text),
+		DescriptorIndex: a.builder.utf8(desc),
+	}
+	if len(value) > 0 {
+		index, rest, err := a.parseConstant(value)
+		if err != nil {
+			return err
+		}
+		if len(rest) > 0 {
+			return utils.Errorf("unexpected %s after field value", rest[0].text)
+		}
+		a.builder.utf8("ConstantValue")
+		field.Attributes = append(field.Attributes, &ConstantValueAttribute{AttrLen: 2, ConstantValueIndex: index})
+	}
+	a.obj.Fields = append(a.obj.Fields, field)
+	return nil
+}
+
+// parseMethodHandle 解析 kind [interface] ref，返回 CONSTANT_MethodHandle 的下标
+func (a *classAssembler) parseMethodHandle(tokens []asmToken) (uint16, []asmToken, error) {
+	if len(tokens) < 2 {
+		return 0, nil, utils.Error("method handle expects kind and reference")
+	}
+	kind := -1
+	for i, name := range methodHandleKinds {
+		if i > 0 && name == tokens[0].text {
+			kind = i
+		}
+	}
+	if kind < 0 {
+		return 0, nil, utils.Errorf("unknown method handle kind %s", tokens[0].text)
+	}
+	tokens = tokens[1:]
+	isInterface := kind == 9
+	if tokens[0].text == "interface" && !tokens[0].quoted {
+		isInterface = true
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 1 {
+		return 0, nil, utils.Error("method handle expects reference")
+	}
+	var ref uint16
+	if kind <= 4 {
+		if len(tokens) < 2 {
+			return 0, nil, utils.Error("field handle expects owner/name and descriptor")
+		}
+		owner, name, _, err := splitMemberRef(tokens[0].text)
+		if err != nil {
+			return 0, nil, err
+		}
+		ref = a.builder.memberRef(CONSTANT_Fieldref, owner, name, tokens[1].text)
+		tokens = tokens[2:]
+	} else {
+		owner, name, desc, err := splitMemberRef(tokens[0].text)
+		if err != nil {
+			return 0, nil, err
+		}
+		tag := uint8(CONSTANT_Methodref)
+		if isInterface {
+			tag = CONSTANT_InterfaceMethodref
+		}
+		ref = a.builder.memberRef(tag, owner, name, desc)
+		tokens = tokens[1:]
+	}
+	return a.builder.methodHandle(uint8(kind), ref), tokens, nil
+}
+
+// parseConstant 解析一个常量，返回常量池下标和剩下的 token
+func (a *classAssembler) parseConstant(tokens []asmToken) (uint16, []asmToken, error) {
+	if len(tokens) == 0 {
+		return 0, nil, utils.Error("expect constant")
+	}
+	token := tokens[0]
+	if token.quoted {
+		return a.builder.string(token.text), tokens[1:], nil
+	}
+	switch token.text {
+	case "class":
+		if len(tokens) < 2 {
+			return 0, nil, utils.Error("class constant expects class name")
+		}
+		return a.builder.class(tokens[1].text), tokens[2:], nil
+	case "methodtype":
+		if len(tokens) < 2 {
+			return 0, nil, utils.Error("methodtype constant expects descriptor")
+		}
+		if _, _, err := parseMethodDescriptor(tokens[1].text); err != nil {
+			return 0, nil, err
+		}
+		return a.builder.methodType(tokens[1].text), tokens[2:], nil
+	case "methodhandle":
+		return a.parseMethodHandle(tokens[1:])
+	}
+	text := token.text
+	switch {
+	case strings.HasSuffix(text, "L"):
+		value, err := strconv.ParseInt(strings.TrimSuffix(text, "L"), 10, 64)
+		if err != nil {
+			return 0, nil, utils.Errorf("invalid long %s", text)
+		}
+		return a.builder.add(&ConstantLongInfo{Value: value}), tokens[1:], nil
+	case strings.HasSuffix(text, "f"):
+		value, err := strconv.ParseFloat(strings.TrimSuffix(text, "f"), 32)
+		if err != nil {
+			return 0, nil, utils.Errorf("invalid float %s", text)
+		}
+		return a.builder.add(&ConstantFloatInfo{Value: float32(value)}), tokens[1:], nil
+	case strings.HasSuffix(text, "d"):
+		value, err := strconv.ParseFloat(strings.TrimSuffix(text, "d"), 64)
+		if err != nil {
+			return 0, nil, utils.Errorf("invalid double %s", text)
+		}
+		return a.builder.add(&ConstantDoubleInfo{Value: value}), tokens[1:], nil
+	}
+	value, err := strconv.ParseInt(text, 10, 32)
+	if err != nil {
+		return 0, nil, utils.Errorf("invalid constant %s", text)
+	}
+	return a.builder.add(&ConstantIntegerInfo{Value: int32(value)}), tokens[1:], nil
+}
+
+func (a *classAssembler) bootstrapMethod(handle uint16, args []uint16) uint16 {
+	key := fmt.Sprint(handle, args)
+	if index, ok := a.bsmIndex[key]; ok {
+		return index
+	}
+	index := uint16(len(a.bootstrap))
+	a.bootstrap = append(a.bootstrap, &BootstrapMethod{BootstrapMethodRef: handle, BootstrapArguments: args})
+	a.bsmIndex[key] = index
+	return index
+}
+
+func parseInt(text string, min, max int64) (int32, error) {
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || value < min || value > max {
+		return 0, utils.Errorf("invalid integer %s, expect [%d, %d]", text, min, max)
+	}
+	return int32(value), nil
+}
+
+func (a *classAssembler) handleInstruction(tokens []asmToken) error {
+	m := a.method
+	name := tokens[0].text
+	opcode, ok := opcodeByName[name]
+	if !ok || tokens[0].quoted || opcode == OP_wide {
+		return utils.Errorf("unknown instruction %s", name)
+	}
+	args := tokens[1:]
+	inst := &Instruction{Opcode: opcode}
+	item := &asmInstruction{inst: inst, lineNo: a.lineNo}
+	expect := func(n int) error {
+		if len(args) != n {
+			return utils.Errorf("%s expects %d operands, got %d", name, n, len(args))
+		}
+		return nil
+	}
+
+	var err error
+	switch opcodeTable[opcode].operand {
+	case operandNone:
+		err = expect(0)
+	case operandByte, operandShort:
+		if err = expect(1); err == nil {
+			if opcode == OP_bipush {
+				inst.Value, err = parseInt(args[0].text, math.MinInt8, math.MaxInt8)
+			} else {
+				inst.Value, err = parseInt(args[0].text, math.MinInt16, math.MaxInt16)
+			}
+		}
+	case operandLocal:
+		if err = expect(1); err == nil {
+			var index int32
+			index, err = parseInt(args[0].text, 0, math.MaxUint16)
+			inst.Index = uint16(index)
+			inst.Wide = index > math.MaxUint8
+		}
+	case operandIinc:
+		if err = expect(2); err == nil {
+			var index int32
+			if index, err = parseInt(args[0].text, 0, math.MaxUint16); err == nil {
+				inst.Index = uint16(index)
+				inst.Value, err = parseInt(args[1].text, math.MinInt16, math.MaxInt16)
+				inst.Wide = index > math.MaxUint8 || inst.Value < math.MinInt8 || inst.Value > math.MaxInt8
+			}
+		}
+	case operandConstU1, operandConstU2:
+		var rest []asmToken
+		inst.Index, rest, err = a.parseConstant(args)
+		if err == nil && len(rest) > 0 {
+			err = utils.Errorf("unexpected %s after constant", rest[0].text)
+		}
+		if err == nil {
+			switch a.obj.ConstantPool[inst.Index-1].(type) {
+			case *ConstantLongInfo, *ConstantDoubleInfo:
+				inst.Opcode = OP_ldc2_w
+			default:
+				if inst.Opcode == OP_ldc2_w {
+					err = utils.Errorf("ldc2_w expects long or double constant")
+				} else if inst.Index > math.MaxUint8 {
+					inst.Opcode = OP_ldc_w
+				}
+			}
+		}
+	case operandField:
+		if err = expect(2); err == nil {
+			var owner, field string
+			if owner, field, _, err = splitMemberRef(args[0].text); err == nil {
+				inst.Index = a.builder.memberRef(CONSTANT_Fieldref, owner, field, args[1].text)
+			}
+		}
+	case operandMethod, operandInterfaceMethod:
+		tag := uint8(CONSTANT_Methodref)
+		if opcode == OP_invokeinterface {
+			tag = CONSTANT_InterfaceMethodref
+			// 参数数量根据描述符重新计算
+			if len(args) == 2 {
+				args = args[:1]
+			}
+		} else if len(args) == 2 && args[0].text == "interface" && !args[0].quoted {
+			tag = CONSTANT_InterfaceMethodref
+			args = args[1:]
+		}
+		if err = expect(1); err == nil {
+			var owner, method, desc string
+			if owner, method, desc, err = splitMemberRef(args[0].text); err == nil {
+				var params []string
+				if params, _, err = parseMethodDescriptor(desc); err == nil {
+					inst.Index = a.builder.memberRef(tag, owner, method, desc)
+					count := 1
+					for _, param := range params {
+						count++
+						if descriptorType(param).isWide() {
+							count++
+						}
+					}
+					inst.Value = int32(count)
+				}
+			}
+		}
+	case operandDynamic:
+		if len(args) < 3 {
+			err = utils.Error("invokedynamic expects name, descriptor and bootstrap method")
+			break
+		}
+		nameDesc := args[0].text
+		i := strings.IndexByte(nameDesc, '(')
+		if i <= 0 {
+			err = utils.Errorf("invalid invokedynamic name and descriptor %s", nameDesc)
+			break
+		}
+		if _, _, err = parseMethodDescriptor(nameDesc[i:]); err != nil {
+			break
+		}
+		var handle uint16
+		var rest []asmToken
+		if handle, rest, err = a.parseMethodHandle(args[1:]); err != nil {
+			break
+		}
+		var bsmArgs []uint16
+		for len(rest) > 0 && err == nil {
+			var arg uint16
+			if arg, rest, err = a.parseConstant(rest); err == nil {
+				bsmArgs = append(bsmArgs, arg)
+			}
+		}
+		if err == nil {
+			inst.Index = a.builder.invokeDynamic(a.bootstrapMethod(handle, bsmArgs), nameDesc[:i], nameDesc[i:])
+		}
+	case operandClass:
+		if err = expect(1); err == nil {
+			inst.Index = a.builder.class(args[0].text)
+		}
+	case operandNewArray:
+		if err = expect(1); err == nil {
+			err = utils.Errorf("unknown newarray type %s", args[0].text)
+			for typ, typeName := range newArrayTypes {
+				if typeName == args[0].text {
+					inst.Value, err = int32(typ), nil
+				}
+			}
+		}
+	case operandMultiANewArray:
+		if err = expect(2); err == nil {
+			inst.Index = a.builder.class(args[0].text)
+			inst.Value, err = parseInt(args[1].text, 1, math.MaxUint8)
+		}
+	case operandBranch, operandBranchWide:
+		if err = expect(1); err == nil {
+			item.target = args[0].text
+		}
+	case operandTableSwitch:
+		if len(args) < 1 || len(args) > 2 {
+			err = utils.Error("tableswitch expects low [high]")
+			break
+		}
+		if inst.Low, err = parseInt(args[0].text, math.MinInt32, math.MaxInt32); err != nil {
+			break
+		}
+		item.expectedTarget = -1
+		if len(args) == 2 {
+			var high int32
+			if high, err = parseInt(args[1].text, math.MinInt32, math.MaxInt32); err != nil {
+				break
+			}
+			if high < inst.Low {
+				err = utils.Errorf("tableswitch high %d < low %d", high, inst.Low)
+				break
+			}
+			item.expectedTarget = int(high-inst.Low) + 1
+		}
+		m.pendingSwitch = item
+	case operandLookupSwitch:
+		err = expect(0)
+		m.pendingSwitch = item
+	}
+	if err != nil {
+		return err
+	}
+	m.insts = append(m.insts, item)
+	m.hasCode = true
+	return nil
+}
+
+// handleSwitchLine 解析 switch 指令的跳转表，直到 default 为止
+func (m *methodAssembler) handleSwitchLine(tokens []asmToken) error {
+	item := m.pendingSwitch
+	var parts []string
+	for _, token := range tokens {
+		parts = append(parts, token.text)
+	}
+	line := strings.Join(parts, " ")
+	key, target := "", line
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		key, target = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	}
+	if target == "" || strings.ContainsAny(target, " \t") {
+		return utils.Errorf("invalid switch entry: %s", line)
+	}
+	if key == "default" {
+		item.defaultTarget = target
+		m.pendingSwitch = nil
+		if item.inst.Opcode == OP_tableswitch && item.expectedTarget >= 0 && item.expectedTarget != len(item.targets) {
+			return utils.Errorf("tableswitch expects %d targets, got %d", item.expectedTarget, len(item.targets))
+		}
+		if len(item.targets) == 0 && item.inst.Opcode == OP_tableswitch {
+			return utils.Error("tableswitch needs at least one target")
+		}
+		return nil
+	}
+	if item.inst.Opcode == OP_lookupswitch {
+		value, err := parseInt(key, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return err
+		}
+		if n := len(item.inst.Keys); n > 0 && item.inst.Keys[n-1] >= value {
+			return utils.Errorf("lookupswitch keys must be sorted: %d", value)
+		}
+		item.inst.Keys = append(item.inst.Keys, value)
+	} else {
+		if key != "" {
+			return utils.Errorf("invalid tableswitch entry: %s", line)
+		}
+		item.inst.Keys = append(item.inst.Keys, item.inst.Low+int32(len(item.targets)))
+	}
+	item.targets = append(item.targets, target)
+	// 先占位，保证 layout 时 switch 的长度正确
+	item.inst.Targets = append(item.inst.Targets, 0)
+	return nil
+}
+
+// layout 计算每条指令的偏移，超出范围的 goto 和 jsr 会被替换为 goto_w 和 jsr_w
+func (m *methodAssembler) layout() (int, error) {
+	for {
+		offset := 0
+		for _, item := range m.insts {
+			item.inst.Offset = offset
+			offset += item.inst.Size()
+		}
+		resolve := func(label string) (int, error) {
+			pos, ok := m.labels[label]
+			if !ok {
+				return 0, utils.Errorf("undefined label %s", label)
+			}
+			if pos == len(m.insts) {
+				return offset, nil
+			}
+			return m.insts[pos].inst.Offset, nil
+		}
+		changed := false
+		for _, item := range m.insts {
+			inst := item.inst
+			var err error
+			if item.target != "" {
+				if inst.Target, err = resolve(item.target); err != nil {
+					return 0, utils.Errorf("line %d: %v", item.lineNo, err)
+				}
+				relative := inst.Target - inst.Offset
+				if relative < math.MinInt16 || relative > math.MaxInt16 {
+					switch inst.Opcode {
+					case OP_goto:
+						inst.Opcode, changed = OP_goto_w, true
+					case OP_jsr:
+						inst.Opcode, changed = OP_jsr_w, true
+					}
+				}
+			}
+			if inst.IsSwitch() {
+				if inst.Default, err = resolve(item.defaultTarget); err != nil {
+					return 0, utils.Errorf("line %d: %v", item.lineNo, err)
+				}
+				inst.Targets = make([]int, len(item.targets))
+				for i, target := range item.targets {
+					if inst.Targets[i], err = resolve(target); err != nil {
+						return 0, utils.Errorf("line %d: %v", item.lineNo, err)
+					}
+				}
+			}
+		}
+		if !changed {
+			return offset, nil
+		}
+	}
+}
+
+func (a *classAssembler) finishMethod() error {
+	m := a.method
+	if m.pendingSwitch != nil {
+		return utils.Error("switch without default")
+	}
+	a.obj.Methods = append(a.obj.Methods, m.member)
+	if len(m.throws) > 0 {
+		a.builder.utf8("Exceptions")
+		m.member.Attributes = append(m.member.Attributes, &ExceptionsAttribute{
+			AttrLen:             uint32(2 + 2*len(m.throws)),
+			ExceptionIndexTable: m.throws,
+		})
+	}
+	if !m.hasCode {
+		return nil
+	}
+	if m.member.AccessFlags&(0x0100|0x0400) != 0 {
+		return utils.Error("native or abstract method cannot have code")
+	}
+	if len(m.insts) == 0 {
+		return utils.Error("method has no instructions")
+	}
+	length, err := m.layout()
+	if err != nil {
+		return err
+	}
+	if length > math.MaxUint16 {
+		return utils.Errorf("code is too large: %d bytes", length)
+	}
+	insts := make([]*Instruction, len(m.insts))
+	for i, item := range m.insts {
+		insts[i] = item.inst
+	}
+	code, err := EncodeInstructions(insts)
+	if err != nil {
+		return err
+	}
+	offsetOf := func(label string, lineNo int) (uint16, error) {
+		pos, ok := m.labels[label]
+		if !ok {
+			return 0, utils.Errorf("line %d: undefined label %s", lineNo, label)
+		}
+		if pos == len(insts) {
+			return uint16(length), nil
+		}
+		return uint16(insts[pos].Offset), nil
+	}
+
+	a.builder.utf8("Code")
+	codeAttr := &CodeAttribute{Code: code, ExceptionTable: []*ExceptionTableEntry{}}
+	for _, c := range m.catches {
+		entry := &ExceptionTableEntry{CatchType: c.catchType}
+		if entry.StartPc, err = offsetOf(c.start, c.lineNo); err != nil {
+			return err
+		}
+		if entry.EndPc, err = offsetOf(c.end, c.lineNo); err != nil {
+			return err
+		}
+		if entry.HandlerPc, err = offsetOf(c.handler, c.lineNo); err != nil {
+			return err
+		}
+		if entry.StartPc >= entry.EndPc || int(entry.HandlerPc) >= length {
+			return utils.Errorf("line %d: invalid exception range", c.lineNo)
+		}
+		codeAttr.ExceptionTable = append(codeAttr.ExceptionTable, entry)
+	}
+	if len(m.lines) > 0 {
+		a.builder.utf8("LineNumberTable")
+		table := &LineNumberTableAttribute{AttrLen: uint32(2 + 4*len(m.lines))}
+		for _, line := range m.lines {
+			if line.pos >= len(insts) {
+				return utils.Errorf(".line %d is not followed by an instruction", line.line)
+			}
+			table.LineNumberTable = append(table.LineNumberTable, &LineNumberTableEntry{StartPc: uint16(insts[line.pos].Offset), LineNumber: line.line})
+		}
+		codeAttr.Attributes = append(codeAttr.Attributes, table)
+	}
+	if len(m.variables) > 0 {
+		a.builder.utf8("LocalVariableTable")
+		table := &LocalVariableTableAttribute{AttrLen: uint32(2 + 10*len(m.variables))}
+		for _, variable := range m.variables {
+			start, err := offsetOf(variable.start, variable.lineNo)
+			if err != nil {
+				return err
+			}
+			end, err := offsetOf(variable.end, variable.lineNo)
+			if err != nil {
+				return err
+			}
+			if end < start {
+				return utils.Errorf("line %d: invalid local variable range", variable.lineNo)
+			}
+			table.LocalVariableTable = append(table.LocalVariableTable, &LocalVariableTableEntry{
+				StartPc: start, Length: end - start, NameIndex: variable.name, DescriptorIndex: variable.desc, Index: variable.index,
+			})
+		}
+		codeAttr.Attributes = append(codeAttr.Attributes, table)
+	}
+	m.member.Attributes = append([]AttributeInfo{codeAttr}, m.member.Attributes...)
+	return nil
+}
@@ -0,0 +1,237 @@
+package javaclassparser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	disassembleNoise = regexp.MustCompile(`(?m)^\s*(;.*|\.limit .*)\n`)
+	labelPattern     = regexp.MustCompile(`\bL\d+\b`)
+)
+
+func normalizeDisassemble(text string) string {
+	text = disassembleNoise.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "ldc_w ", "ldc ")
+	return labelPattern.ReplaceAllString(text, "L")
+}
+
+func loadTemplateClasses(t *testing.T) map[string][]byte {
+	files, err := filepath.Glob("../yso/resources/classes/*.class")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	result := make(map[string][]byte)
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		require.NoError(t, err)
+		result[filepath.Base(file)] = raw
+	}
+	return result
+}
+
+func TestDecodeEncodeInstructions(t *testing.T) {
+	for name, raw := range loadTemplateClasses(t) {
+		obj, err := Parse(raw)
+		require.NoError(t, err, name)
+		for _, method := range obj.Methods {
+			code := obj.getMethodCode(method)
+			if code == nil {
+				continue
+			}
+			insts, err := DecodeInstructions(code.Code)
+			require.NoError(t, err, name)
+			encoded, err := EncodeInstructions(insts)
+			require.NoError(t, err, name)
+			require.Equal(t, code.Code, encoded, name)
+		}
+	}
+}
+
+func TestComputeFramesMatchesJavac(t *testing.T) {
+	for name, raw := range loadTemplateClasses(t) {
+		obj, err := Parse(raw)
+		require.NoError(t, err, name)
+		type expected struct {
+			maxStack, maxLocals uint16
+			offsets             []int
+		}
+		var want []expected
+		for _, method := range obj.Methods {
+			code := obj.getMethodCode(method)
+			if code == nil {
+				want = append(want, expected{})
+				continue
+			}
+			e := expected{maxStack: code.MaxStack, maxLocals: code.MaxLocals}
+			for _, attr := range code.Attributes {
+				if table, ok := attr.(*StackMapTableAttribute); ok {
+					offset := -1
+					for _, frame := range table.Entries {
+						offset += int(frame.OffsetDelta) + 1
+						e.offsets = append(e.offsets, offset)
+					}
+				}
+			}
+			want = append(want, e)
+		}
+
+		require.NoError(t, obj.ComputeMaxsAndFrames(), name)
+		for i, method := range obj.Methods {
+			code := obj.getMethodCode(method)
+			if code == nil {
+				continue
+			}
+			require.Equal(t, want[i].maxStack, code.MaxStack, name)
+			require.GreaterOrEqual(t, code.MaxLocals, want[i].maxLocals, name)
+			var offsets []int
+			for _, attr := range code.Attributes {
+				if table, ok := attr.(*StackMapTableAttribute); ok {
+					offset := -1
+					for _, frame := range table.Entries {
+						offset += int(frame.OffsetDelta) + 1
+						offsets = append(offsets, offset)
+					}
+				}
+			}
+			require.Equal(t, want[i].offsets, offsets, name)
+		}
+		_, err = Parse(obj.Bytes())
+		require.NoError(t, err, name)
+	}
+}
+
+func TestDisassembleAssembleRoundTrip(t *testing.T) {
+	for name, raw := range loadTemplateClasses(t) {
+		obj, err := Parse(raw)
+		require.NoError(t, err, name)
+		text, err := obj.Disassemble()
+		require.NoError(t, err, name)
+
+		assembled, err := Assemble(text)
+		require.NoError(t, err, name)
+		reparsed, err := Parse(assembled.Bytes())
+		require.NoError(t, err, name)
+		require.Equal(t, obj.GetClassName(), reparsed.GetClassName())
+
+		again, err := reparsed.Disassemble()
+		require.NoError(t, err, name)
+		// 常量池的顺序会变化，ldc 可能变为 ldc_w，标签的偏移也会随之变化
+		require.Equal(t, normalizeDisassemble(text), normalizeDisassemble(again), name)
+
+		// 再汇编一次，结果应当不再变化
+		stable, err := Assemble(again)
+		require.NoError(t, err, name)
+		stableText, err := stable.Disassemble()
+		require.NoError(t, err, name)
+		require.Equal(t, again, stableText, name)
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	text := `
+.version 52 0
+.class public Hello
+.super java/lang/Object
+.field private static final GREETING Ljava/lang/String; = "hello \"world\""
+.field public static final BIG J = 1234567890123L
+
+.method public <init>()V
+    aload_0
+    invokespecial java/lang/Object/<init>()V
+    return
+.end method
+
+.method public static pick(I)I
+    iload_0
+    tableswitch 1 3
+        L1
+        L2
+        L1
+        default : L3
+L1:
+    iload_0
+    istore 300
+    iinc 300 1000
+    iload 300
+    ireturn
+L2:
+    ldc 5L
+    l2i
+    ireturn
+L3:
+    iconst_m1
+    ireturn
+.end method
+
+.method public static safe(Ljava/lang/Runnable;)Ljava/lang/String;
+    .catch java/lang/RuntimeException from L0 to L1 using L2
+    .var 0 is r Ljava/lang/Runnable; from L0 to L3
+L0:
+    .line 7
+    aload_0
+    invokeinterface java/lang/Runnable/run()V
+    getstatic Hello/GREETING Ljava/lang/String;
+L1:
+    areturn
+L2:
+    astore_1
+    aconst_null
+L3:
+    areturn
+.end method
+`
+	obj, err := Assemble(text)
+	require.NoError(t, err)
+	obj, err = Parse(obj.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "Hello", obj.GetClassName())
+
+	out, err := obj.Disassemble()
+	require.NoError(t, err)
+	require.Contains(t, out, `.field private static final GREETING Ljava/lang/String; = "hello \"world\""`)
+	require.Contains(t, out, "iinc 300 1000")
+	require.Contains(t, out, "istore 300")
+	require.Contains(t, out, "ldc2_w 5L")
+	require.Contains(t, out, "invokeinterface java/lang/Runnable/run()V 1")
+	require.Contains(t, out, ".catch java/lang/RuntimeException from L0 to L")
+	require.Contains(t, out, ".var 0 is r Ljava/lang/Runnable; from L0 to L")
+	require.Contains(t, out, ".line 7")
+
+	var code *CodeAttribute
+	for _, method := range obj.Methods {
+		if name, _ := obj.getUtf8Value(method.NameIndex); name == "pick" {
+			code = obj.getMethodCode(method)
+		}
+	}
+	require.NotNil(t, code)
+	require.Equal(t, uint16(301), code.MaxLocals)
+	require.Equal(t, uint16(2), code.MaxStack)
+
+	dump, err := obj.Dump()
+	require.NoError(t, err)
+	require.Contains(t, dump, "tableswitch 1 3")
+
+	_, err = Assemble(".class Foo\n.method static f()V\n    goto Missing\n.end method\n")
+	require.ErrorContains(t, err, "undefined label Missing")
+	_, err = Assemble(".class Foo\n.method static f()V\n    bipush 200\n.end method\n")
+	require.ErrorContains(t, err, "line 3")
+}
+
+func TestDumpMethodsDisassembleFailed(t *testing.T) {
+	obj, err := Assemble(".class public Foo\n.super java/lang/Object\n.method public static broken()V\n    return\n.end method\n")
+	require.NoError(t, err)
+	for _, method := range obj.Methods {
+		if name, _ := obj.getUtf8Value(method.NameIndex); name == "broken" {
+			// bipush 缺少操作数，无法反汇编
+			obj.getMethodCode(method).Code = []byte{0x10}
+		}
+	}
+	dump, err := obj.Dump()
+	require.NoError(t, err)
+	require.Contains(t, dump, "broken();")
+}
@@ -1,5 +1,7 @@
 package javaclassparser
 
+import "github.com/yaklang/yaklang/common/utils"
+
 /*
 *
 属性表，储存了方法的字节码等信息
@@ -104,6 +106,197 @@ func (self *LineNumberTableAttribute) readInfo(cp *ClassParser) {
 	}
 }
 
+/*
+*
+存放方法的局部变量信息，是调试信息
+
+	LOCAL_VARIABLE_TABLE_ATTRIBUTE {
+		u2 attribute_name_index;
+		u4 attribute_length;
+		u2 local_variable_table_length;
+		{
+			u2 start_pc;
+			u2 length;
+			u2 name_index;
+			u2 descriptor_index;
+			u2 index;
+		} local_variable_table[local_variable_table_length];
+	}
+*/
+type LocalVariableTableAttribute struct {
+	Type               string
+	AttrLen            uint32
+	LocalVariableTable []*LocalVariableTableEntry
+}
+
+type LocalVariableTableEntry struct {
+	StartPc         uint16
+	Length          uint16
+	NameIndex       uint16
+	DescriptorIndex uint16
+	Index           uint16
+}
+
+func (self *LocalVariableTableAttribute) readInfo(cp *ClassParser) {
+	localVariableTableLength := cp.reader.readUint16()
+	self.LocalVariableTable = make([]*LocalVariableTableEntry, localVariableTableLength)
+	for i := range self.LocalVariableTable {
+		self.LocalVariableTable[i] = &LocalVariableTableEntry{
+			StartPc:         cp.reader.readUint16(),
+			Length:          cp.reader.readUint16(),
+			NameIndex:       cp.reader.readUint16(),
+			DescriptorIndex: cp.reader.readUint16(),
+			Index:           cp.reader.readUint16(),
+		}
+	}
+}
+
+/*
+*
+类型检查验证器使用的栈映射帧，class 版本 50 之后跳转目标和异常处理器的位置都需要一个帧
+
+	STACK_MAP_TABLE_ATTRIBUTE {
+		u2 attribute_name_index;
+		u4 attribute_length;
+		u2 number_of_entries;
+		stack_map_frame entries[number_of_entries];
+	}
+*/
+type StackMapTableAttribute struct {
+	Type    string
+	AttrLen uint32
+	Entries []*StackMapFrame
+}
+
+// 栈映射帧的类型
+const (
+	StackMapFrameSameMax             = 63
+	StackMapFrameSameLocals1StackMax = 127
+	StackMapFrameSameLocals1StackExt = 247
+	StackMapFrameChopMin             = 248
+	StackMapFrameSameExt             = 251
+	StackMapFrameAppendMax           = 254
+	StackMapFrameFull                = 255
+)
+
+/*
+*
+栈映射帧，FrameType 决定了 OffsetDelta、Locals 和 Stack 的含义：
+same / same_extended 没有 Locals 和 Stack，same_locals_1_stack_item 只有一个 Stack，
+chop 去掉最后 251-FrameType 个局部变量，append 追加 Locals，full 给出完整的 Locals 和 Stack
+*/
+type StackMapFrame struct {
+	FrameType   uint8
+	OffsetDelta uint16
+	Locals      []*VerificationTypeInfo
+	Stack       []*VerificationTypeInfo
+}
+
+// 验证类型的 tag
+const (
+	VerificationTop               = 0
+	VerificationInteger           = 1
+	VerificationFloat             = 2
+	VerificationDouble            = 3
+	VerificationLong              = 4
+	VerificationNull              = 5
+	VerificationUninitializedThis = 6
+	VerificationObject            = 7
+	VerificationUninitialized     = 8
+)
+
+/*
+*
+验证类型，Object 类型使用 CpoolIndex 指向 CONSTANT_Class，Uninitialized 类型使用 Offset 指向创建对象的 new 指令
+*/
+type VerificationTypeInfo struct {
+	Tag        uint8
+	CpoolIndex uint16
+	Offset     uint16
+}
+
+func (self *StackMapTableAttribute) readInfo(cp *ClassParser) {
+	numberOfEntries := cp.reader.readUint16()
+	self.Entries = make([]*StackMapFrame, numberOfEntries)
+	for i := range self.Entries {
+		frame := &StackMapFrame{FrameType: cp.reader.readUint8()}
+		switch {
+		case frame.FrameType <= StackMapFrameSameMax:
+			frame.OffsetDelta = uint16(frame.FrameType)
+		case frame.FrameType <= StackMapFrameSameLocals1StackMax:
+			frame.OffsetDelta = uint16(frame.FrameType - 64)
+			frame.Stack = readVerificationTypeInfos(cp.reader, 1)
+		case frame.FrameType < StackMapFrameSameLocals1StackExt:
+			panic(utils.Errorf("java.lang.ClassFormatError: reserved stack map frame type %d", frame.FrameType))
+		case frame.FrameType == StackMapFrameSameLocals1StackExt:
+			frame.OffsetDelta = cp.reader.readUint16()
+			frame.Stack = readVerificationTypeInfos(cp.reader, 1)
+		case frame.FrameType <= StackMapFrameSameExt:
+			frame.OffsetDelta = cp.reader.readUint16()
+		case frame.FrameType <= StackMapFrameAppendMax:
+			frame.OffsetDelta = cp.reader.readUint16()
+			frame.Locals = readVerificationTypeInfos(cp.reader, int(frame.FrameType-StackMapFrameSameExt))
+		default:
+			frame.OffsetDelta = cp.reader.readUint16()
+			frame.Locals = readVerificationTypeInfos(cp.reader, int(cp.reader.readUint16()))
+			frame.Stack = readVerificationTypeInfos(cp.reader, int(cp.reader.readUint16()))
+		}
+		self.Entries[i] = frame
+	}
+}
+
+func readVerificationTypeInfos(reader *ClassReader, n int) []*VerificationTypeInfo {
+	infos := make([]*VerificationTypeInfo, n)
+	for i := range infos {
+		info := &VerificationTypeInfo{Tag: reader.readUint8()}
+		switch info.Tag {
+		case VerificationObject:
+			info.CpoolIndex = reader.readUint16()
+		case VerificationUninitialized:
+			info.Offset = reader.readUint16()
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+/*
+*
+invokedynamic 指令使用的引导方法
+
+	BOOTSTRAP_METHODS_ATTRIBUTE {
+		u2 attribute_name_index;
+		u4 attribute_length;
+		u2 num_bootstrap_methods;
+		{
+			u2 bootstrap_method_ref;
+			u2 num_bootstrap_arguments;
+			u2 bootstrap_arguments[num_bootstrap_arguments];
+		} bootstrap_methods[num_bootstrap_methods];
+	}
+*/
+type BootstrapMethodsAttribute struct {
+	Type             string
+	AttrLen          uint32
+	BootstrapMethods []*BootstrapMethod
+}
+
+type BootstrapMethod struct {
+	BootstrapMethodRef uint16
+	BootstrapArguments []uint16
+}
+
+func (self *BootstrapMethodsAttribute) readInfo(cp *ClassParser) {
+	numBootstrapMethods := cp.reader.readUint16()
+	self.BootstrapMethods = make([]*BootstrapMethod, numBootstrapMethods)
+	for i := range self.BootstrapMethods {
+		self.BootstrapMethods[i] = &BootstrapMethod{
+			BootstrapMethodRef: cp.reader.readUint16(),
+			BootstrapArguments: cp.reader.readUint16s(),
+		}
+	}
+}
+
 /*
 *
 
@@ -201,8 +394,12 @@ func newAttributeInfo(attrName string, attrLen uint32) AttributeInfo {
 		return &ExceptionsAttribute{AttrLen: attrLen}
 	case "LineNumberTable":
 		return &LineNumberTableAttribute{AttrLen: attrLen}
-	//case "LocalVariableTable":
-	//	return &LocalVariableTableAttribute{}
+	case "LocalVariableTable":
+		return &LocalVariableTableAttribute{AttrLen: attrLen}
+	case "StackMapTable":
+		return &StackMapTableAttribute{AttrLen: attrLen}
+	case "BootstrapMethods":
+		return &BootstrapMethodsAttribute{AttrLen: attrLen}
 	case "SourceFile":
 		return &SourceFileAttribute{AttrLen: attrLen}
 	case "Synthetic":
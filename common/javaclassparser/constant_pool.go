@@ -0,0 +1,208 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// memberRef 是 Fieldref、Methodref、InterfaceMethodref 解析之后的结果
+type memberRef struct {
+	Tag   uint8
+	Owner string
+	Name  string
+	Desc  string
+}
+
+func (this *ClassObject) getUtf8Value(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	utf8Info, ok := info.(*ConstantUtf8Info)
+	if !ok {
+		return "", utils.Errorf("index %d is not ConstantUtf8Info", index)
+	}
+	return utf8Info.Value, nil
+}
+
+func (this *ClassObject) getClassNameByIndex(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	classInfo, ok := info.(*ConstantClassInfo)
+	if !ok {
+		return "", utils.Errorf("index %d is not ConstantClassInfo", index)
+	}
+	return this.getUtf8Value(classInfo.NameIndex)
+}
+
+func (this *ClassObject) getNameAndType(index uint16) (string, string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", "", err
+	}
+	nat, ok := info.(*ConstantNameAndTypeInfo)
+	if !ok {
+		return "", "", utils.Errorf("index %d is not ConstantNameAndTypeInfo", index)
+	}
+	name, err := this.getUtf8Value(nat.NameIndex)
+	if err != nil {
+		return "", "", err
+	}
+	desc, err := this.getUtf8Value(nat.DescriptorIndex)
+	if err != nil {
+		return "", "", err
+	}
+	return name, desc, nil
+}
+
+func (this *ClassObject) getMemberRef(index uint16) (*memberRef, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return nil, err
+	}
+	var ref *ConstantMemberrefInfo
+	var tag uint8
+	switch ret := info.(type) {
+	case *ConstantFieldrefInfo:
+		ref, tag = &ret.ConstantMemberrefInfo, CONSTANT_Fieldref
+	case *ConstantMethodrefInfo:
+		ref, tag = &ret.ConstantMemberrefInfo, CONSTANT_Methodref
+	case *ConstantInterfaceMethodrefInfo:
+		ref, tag = &ret.ConstantMemberrefInfo, CONSTANT_InterfaceMethodref
+	default:
+		return nil, utils.Errorf("index %d is not a member reference", index)
+	}
+	owner, err := this.getClassNameByIndex(ref.ClassIndex)
+	if err != nil {
+		return nil, err
+	}
+	name, desc, err := this.getNameAndType(ref.NameAndTypeIndex)
+	if err != nil {
+		return nil, err
+	}
+	return &memberRef{Tag: tag, Owner: owner, Name: name, Desc: desc}, nil
+}
+
+/*
+*
+constantPoolBuilder 向常量池中添加常量，已经存在的常量会直接复用
+常量池的下标从 1 开始，long 和 double 占两个位置，第二个位置为 nil
+*/
+type constantPoolBuilder struct {
+	obj   *ClassObject
+	index map[string]uint16
+}
+
+func newConstantPoolBuilder(obj *ClassObject) *constantPoolBuilder {
+	b := &constantPoolBuilder{obj: obj, index: make(map[string]uint16)}
+	for i, info := range obj.ConstantPool {
+		if info == nil {
+			continue
+		}
+		key := constantKey(info)
+		if _, ok := b.index[key]; !ok {
+			b.index[key] = uint16(i + 1)
+		}
+	}
+	return b
+}
+
+func constantKey(info ConstantInfo) string {
+	switch ret := info.(type) {
+	case *ConstantUtf8Info:
+		return "utf8:" + ret.Value
+	case *ConstantIntegerInfo:
+		return fmt.Sprintf("int:%d", ret.Value)
+	case *ConstantFloatInfo:
+		return fmt.Sprintf("float:%d", math.Float32bits(ret.Value))
+	case *ConstantLongInfo:
+		return fmt.Sprintf("long:%d", ret.Value)
+	case *ConstantDoubleInfo:
+		return fmt.Sprintf("double:%d", math.Float64bits(ret.Value))
+	case *ConstantStringInfo:
+		return fmt.Sprintf("string:%d", ret.StringIndex)
+	case *ConstantClassInfo:
+		return fmt.Sprintf("class:%d", ret.NameIndex)
+	case *ConstantNameAndTypeInfo:
+		return fmt.Sprintf("nat:%d:%d", ret.NameIndex, ret.DescriptorIndex)
+	case *ConstantFieldrefInfo:
+		return fmt.Sprintf("field:%d:%d", ret.ClassIndex, ret.NameAndTypeIndex)
+	case *ConstantMethodrefInfo:
+		return fmt.Sprintf("method:%d:%d", ret.ClassIndex, ret.NameAndTypeIndex)
+	case *ConstantInterfaceMethodrefInfo:
+		return fmt.Sprintf("imethod:%d:%d", ret.ClassIndex, ret.NameAndTypeIndex)
+	case *ConstantMethodTypeInfo:
+		return fmt.Sprintf("mtype:%d", ret.DescriptorIndex)
+	case *ConstantMethodHandleInfo:
+		return fmt.Sprintf("mhandle:%d:%d", ret.ReferenceKind, ret.ReferenceIndex)
+	case *ConstantInvokeDynamicInfo:
+		return fmt.Sprintf("indy:%d:%d", ret.BootstrapMethodAttrIndex, ret.NameAndTypeIndex)
+	}
+	return fmt.Sprintf("unknown:%p", info)
+}
+
+func (b *constantPoolBuilder) add(info ConstantInfo) uint16 {
+	key := constantKey(info)
+	if index, ok := b.index[key]; ok {
+		return index
+	}
+	index := len(b.obj.ConstantPool) + 1
+	wide := false
+	switch info.(type) {
+	case *ConstantLongInfo, *ConstantDoubleInfo:
+		wide = true
+	}
+	if wide && index+1 > 0xffff || index > 0xffff {
+		panic(utils.Error("constant pool is too large"))
+	}
+	b.obj.ConstantPool = append(b.obj.ConstantPool, info)
+	if wide {
+		b.obj.ConstantPool = append(b.obj.ConstantPool, nil)
+	}
+	b.index[key] = uint16(index)
+	return uint16(index)
+}
+
+func (b *constantPoolBuilder) utf8(value string) uint16 {
+	return b.add(&ConstantUtf8Info{Value: value})
+}
+
+func (b *constantPoolBuilder) class(name string) uint16 {
+	return b.add(&ConstantClassInfo{NameIndex: b.utf8(name)})
+}
+
+func (b *constantPoolBuilder) string(value string) uint16 {
+	return b.add(&ConstantStringInfo{StringIndex: b.utf8(value)})
+}
+
+func (b *constantPoolBuilder) nameAndType(name, desc string) uint16 {
+	return b.add(&ConstantNameAndTypeInfo{NameIndex: b.utf8(name), DescriptorIndex: b.utf8(desc)})
+}
+
+func (b *constantPoolBuilder) memberRef(tag uint8, owner, name, desc string) uint16 {
+	ref := ConstantMemberrefInfo{ClassIndex: b.class(owner), NameAndTypeIndex: b.nameAndType(name, desc)}
+	switch tag {
+	case CONSTANT_Fieldref:
+		return b.add(&ConstantFieldrefInfo{ConstantMemberrefInfo: ref})
+	case CONSTANT_InterfaceMethodref:
+		return b.add(&ConstantInterfaceMethodrefInfo{ConstantMemberrefInfo: ref})
+	default:
+		return b.add(&ConstantMethodrefInfo{ConstantMemberrefInfo: ref})
+	}
+}
+
+func (b *constantPoolBuilder) methodType(desc string) uint16 {
+	return b.add(&ConstantMethodTypeInfo{DescriptorIndex: b.utf8(desc)})
+}
+
+func (b *constantPoolBuilder) methodHandle(kind uint8, refIndex uint16) uint16 {
+	return b.add(&ConstantMethodHandleInfo{ReferenceKind: kind, ReferenceIndex: refIndex})
+}
+
+func (b *constantPoolBuilder) invokeDynamic(bootstrap uint16, name, desc string) uint16 {
+	return b.add(&ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: bootstrap, NameAndTypeIndex: b.nameAndType(name, desc)})
+}
@@ -0,0 +1,389 @@
+package javaclassparser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// 反编译时输出的修饰符，顺序和 Java 源码的习惯一致
+var (
+	classModifiers = []accessFlag{
+		{0x0001, "public"}, {0x0400, "abstract"}, {0x0010, "final"},
+	}
+	fieldModifiers = []accessFlag{
+		{0x0001, "public"}, {0x0002, "private"}, {0x0004, "protected"}, {0x0008, "static"}, {0x0010, "final"},
+		{0x0080, "transient"}, {0x0040, "volatile"},
+	}
+	methodModifiers = []accessFlag{
+		{0x0001, "public"}, {0x0002, "private"}, {0x0004, "protected"}, {0x0400, "abstract"}, {0x0008, "static"},
+		{0x0010, "final"}, {0x0020, "synchronized"}, {0x0100, "native"}, {0x0800, "strictfp"},
+	}
+)
+
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "final": true, "finally": true, "float": true, "for": true,
+	"goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true,
+	"interface": true, "long": true, "native": true, "new": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "try": true, "void": true, "volatile": true, "while": true, "true": true, "false": true,
+	"null": true, "var": true, "_": true,
+}
+
+var primitiveTypeNames = map[byte]string{
+	'B': "byte", 'C': "char", 'D': "double", 'F': "float", 'I': "int", 'J': "long", 'S': "short", 'Z': "boolean", 'V': "void",
+}
+
+func isJavaIdentifier(name string) bool {
+	if name == "" || javaKeywords[name] {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f:
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func modifierNames(flags uint16, table []accessFlag) []string {
+	var result []string
+	for _, flag := range table {
+		if flags&flag.mask != 0 {
+			result = append(result, flag.name)
+		}
+	}
+	return result
+}
+
+// classDecompiler 保存反编译一个类时的公共状态，主要是类名的输出方式和需要导入的类
+type classDecompiler struct {
+	obj *ClassObject
+	// name 是当前类的内部类名，例如 java/lang/String
+	name         string
+	pkg          string
+	declaredName string
+	// imports 记录简单类名对应的全限定类名，用于判断是否冲突
+	imports map[string]string
+}
+
+/*
+*
+Decompile 把 class 反编译为 Java 源码，方法体会还原 if/else、循环、switch、try/catch 等结构，
+存在 LocalVariableTable 时使用其中的变量名
+
+无法还原的方法会保留反汇编结果作为注释，并生成抛出异常的方法体，保证输出的源码仍然可以被解析
+*/
+func (this *ClassObject) Decompile() (string, error) {
+	name, err := this.getClassNameByIndex(this.ThisClass)
+	if err != nil {
+		return "", utils.Errorf("get class name failed: %v", err)
+	}
+	d := &classDecompiler{obj: this, name: name, declaredName: name, imports: make(map[string]string)}
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		d.pkg = strings.ReplaceAll(name[:i], "/", ".")
+		d.declaredName = name[i+1:]
+	}
+	d.imports[d.declaredName] = d.qualifiedName(name)
+
+	body, err := d.writeClass()
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if d.pkg != "" {
+		buf.WriteString("package " + d.pkg + ";\n\n")
+	}
+	var imports []string
+	for simple, full := range d.imports {
+		pkg := strings.TrimSuffix(full, "."+simple)
+		if pkg == full || pkg == d.pkg || pkg == "java.lang" {
+			continue
+		}
+		imports = append(imports, full)
+	}
+	sort.Strings(imports)
+	for _, full := range imports {
+		buf.WriteString("import " + full + ";\n")
+	}
+	if len(imports) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(body)
+	return buf.String(), nil
+}
+
+func (d *classDecompiler) qualifiedName(internal string) string {
+	return strings.ReplaceAll(internal, "/", ".")
+}
+
+// className 返回类在源码中的写法，需要时记录导入，简单类名冲突时使用全限定类名
+func (d *classDecompiler) className(internal string) string {
+	if strings.HasPrefix(internal, "[") {
+		return d.typeName(internal)
+	}
+	if internal == d.name {
+		return d.declaredName
+	}
+	full := d.qualifiedName(internal)
+	pkg, simple := "", full
+	if i := strings.LastIndexByte(full, '.'); i >= 0 {
+		pkg, simple = full[:i], full[i+1:]
+	}
+	// 内部类 Outer$Inner 写作 Outer.Inner，匿名类和局部类保持原来的名字
+	outer, inner := simple, ""
+	if parts := strings.Split(simple, "$"); len(parts) > 1 {
+		nested := parts[0] != ""
+		for _, part := range parts[1:] {
+			if !isJavaIdentifier(part) || part[0] >= '0' && part[0] <= '9' {
+				nested = false
+			}
+		}
+		if nested {
+			outer, inner = parts[0], "."+strings.Join(parts[1:], ".")
+		}
+	}
+	qualified := outer
+	if pkg != "" {
+		qualified = pkg + "." + outer
+	}
+	if existing, ok := d.imports[outer]; ok && existing != qualified {
+		return qualified + inner
+	}
+	if pkg == "" && d.pkg != "" {
+		return outer + inner
+	}
+	d.imports[outer] = qualified
+	return outer + inner
+}
+
+// typeName 把字段描述符转换为 Java 类型
+func (d *classDecompiler) typeName(desc string) string {
+	if desc == "" || desc == "null" {
+		return "Object"
+	}
+	switch desc[0] {
+	case '[':
+		return d.typeName(desc[1:]) + "[]"
+	case 'L':
+		return d.className(strings.TrimSuffix(desc[1:], ";"))
+	}
+	if name, ok := primitiveTypeNames[desc[0]]; ok {
+		return name
+	}
+	return "Object"
+}
+
+func (d *classDecompiler) writeClass() (string, error) {
+	obj := d.obj
+	isInterface := obj.AccessFlags&0x0200 != 0
+	var header []string
+	if isInterface {
+		header = modifierNames(obj.AccessFlags&0x0001, classModifiers)
+		header = append(header, "interface", d.declaredName)
+	} else {
+		header = modifierNames(obj.AccessFlags, classModifiers)
+		header = append(header, "class", d.declaredName)
+		if obj.SuperClass != 0 {
+			super, err := obj.getClassNameByIndex(obj.SuperClass)
+			if err != nil {
+				return "", err
+			}
+			if super != "java/lang/Object" {
+				header = append(header, "extends", d.className(super))
+			}
+		}
+	}
+	var interfaces []string
+	for _, index := range obj.Interfaces {
+		name, err := obj.getClassNameByIndex(index)
+		if err != nil {
+			return "", err
+		}
+		interfaces = append(interfaces, d.className(name))
+	}
+	if len(interfaces) > 0 {
+		keyword := "implements"
+		if isInterface {
+			keyword = "extends"
+		}
+		header = append(header, keyword, strings.Join(interfaces, ", "))
+	}
+
+	var buf strings.Builder
+	buf.WriteString(strings.Join(header, " ") + " {\n")
+	members := 0
+	for _, field := range obj.Fields {
+		line, err := d.writeField(field, isInterface)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString("    " + line + "\n")
+		members++
+	}
+	for _, method := range obj.Methods {
+		if method.AccessFlags&0x0040 != 0 {
+			// 桥接方法由编译器生成，源码中不存在
+			continue
+		}
+		text, err := d.writeMethod(method, isInterface)
+		if err != nil {
+			return "", err
+		}
+		if members > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(text)
+		members++
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
+
+func (d *classDecompiler) writeField(field *MemberInfo, isInterface bool) (string, error) {
+	name, err := d.obj.getUtf8Value(field.NameIndex)
+	if err != nil {
+		return "", err
+	}
+	desc, err := d.obj.getUtf8Value(field.DescriptorIndex)
+	if err != nil {
+		return "", err
+	}
+	flags := field.AccessFlags
+	if isInterface {
+		flags &^= 0x0001 | 0x0008 | 0x0010
+	}
+	parts := append(modifierNames(flags, fieldModifiers), d.typeName(desc), name)
+	for _, attr := range field.Attributes {
+		if ret, ok := attr.(*ConstantValueAttribute); ok {
+			value, err := d.constant(ret.ConstantValueIndex)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, "=", d.renderAs(value, desc, precAssign))
+		}
+	}
+	return strings.Join(parts, " ") + ";", nil
+}
+
+func (d *classDecompiler) writeMethod(method *MemberInfo, isInterface bool) (string, error) {
+	m, err := newMethodDecompiler(d, method)
+	if err != nil {
+		return "", err
+	}
+	flags := method.AccessFlags
+	if isInterface {
+		flags &^= 0x0001 | 0x0400
+	}
+	modifiers := modifierNames(flags, methodModifiers)
+	if isInterface && m.code != nil && flags&(0x0008|0x0002) == 0 {
+		modifiers = append(modifiers, "default")
+	}
+
+	var body []javaStmt
+	var failure error
+	if m.code != nil {
+		body, failure = m.decompile()
+	}
+
+	var header string
+	switch m.name {
+	case "<clinit>":
+		header = "static"
+	default:
+		params := make([]string, len(m.params))
+		for i, p := range m.paramVars {
+			typ := d.typeName(p.typ)
+			if i == len(m.params)-1 && flags&0x0080 != 0 && strings.HasSuffix(typ, "[]") {
+				typ = strings.TrimSuffix(typ, "[]") + "..."
+			}
+			params[i] = typ + " " + p.name
+		}
+		name := m.name
+		if name == "<init>" {
+			name = d.declaredName
+		} else {
+			name = d.typeName(m.ret) + " " + name
+		}
+		header = strings.Join(append(modifiers, name+"("+strings.Join(params, ", ")+")"), " ")
+		var throws []string
+		for _, attr := range method.Attributes {
+			if ret, ok := attr.(*ExceptionsAttribute); ok {
+				for _, index := range ret.ExceptionIndexTable {
+					class, err := d.obj.getClassNameByIndex(index)
+					if err != nil {
+						return "", err
+					}
+					throws = append(throws, d.className(class))
+				}
+			}
+		}
+		if len(throws) > 0 {
+			header += " throws " + strings.Join(throws, ", ")
+		}
+	}
+
+	w := &stmtWriter{d: d, ret: m.ret}
+	if m.code == nil {
+		w.line(1, header+";")
+		return w.buf.String(), nil
+	}
+	if failure != nil {
+		// 无法还原的方法保留反汇编结果，方法体直接抛出异常
+		w.line(1, header+" {")
+		w.line(2, "// decompile failed: "+strings.ReplaceAll(failure.Error(), "\n", " "))
+		if lines, err := d.obj.disassembleCode(m.code); err == nil {
+			for _, line := range lines {
+				w.line(2, "// "+line)
+			}
+		}
+		w.line(2, `throw new UnsupportedOperationException("decompile failed");`)
+		w.line(1, "}")
+		return w.buf.String(), nil
+	}
+	w.labels = markLabels(body)
+	m.nameLabels(body, w.labels)
+	w.block(1, header, body, "")
+	return w.buf.String(), nil
+}
+
+// constant 把常量池中可以被 ldc 加载的常量转换为表达式
+func (d *classDecompiler) constant(index uint16) (javaExpr, error) {
+	info, err := d.obj.getConstantInfo(index)
+	if err != nil {
+		return nil, err
+	}
+	switch ret := info.(type) {
+	case *ConstantIntegerInfo:
+		return intLiteral(int64(ret.Value)), nil
+	case *ConstantFloatInfo:
+		return &literalExpr{text: javaFloatLiteral(float64(ret.Value), 32), typ: "F"}, nil
+	case *ConstantLongInfo:
+		return &literalExpr{text: strconv.FormatInt(ret.Value, 10) + "L", typ: "J"}, nil
+	case *ConstantDoubleInfo:
+		return &literalExpr{text: javaFloatLiteral(ret.Value, 64), typ: "D"}, nil
+	case *ConstantStringInfo:
+		value, err := d.obj.getUtf8Value(ret.StringIndex)
+		if err != nil {
+			return nil, err
+		}
+		return &literalExpr{text: javaStringLiteral(value), typ: descString}, nil
+	case *ConstantClassInfo:
+		name, err := d.obj.getUtf8Value(ret.NameIndex)
+		if err != nil {
+			return nil, err
+		}
+		return &classLiteralExpr{typ: classDescriptor(name)}, nil
+	case *ConstantMethodTypeInfo, *ConstantMethodHandleInfo:
+		return &rawExpr{text: "null", typ: descObject}, nil
+	}
+	return nil, utils.Errorf("index %d is not a loadable constant", index)
+}
@@ -0,0 +1,713 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Java 运算符优先级，数值越大结合越紧
+const (
+	precAssign = iota + 1
+	precTernary
+	precOr
+	precAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPostfix
+	precPrimary
+)
+
+var binaryPrecedence = map[string]int{
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative,
+	"+": precAdditive, "-": precAdditive,
+	"<<": precShift, ">>": precShift, ">>>": precShift,
+	"<": precRelational, "<=": precRelational, ">": precRelational, ">=": precRelational,
+	"==": precEquality, "!=": precEquality,
+	"&": precBitAnd, "^": precBitXor, "|": precBitOr,
+	"&&": precAnd, "||": precOr,
+}
+
+var negatedOperator = map[string]string{
+	"==": "!=", "!=": "==", "<": ">=", ">=": "<", ">": "<=", "<=": ">",
+}
+
+const (
+	descString  = "Ljava/lang/String;"
+	descObject  = "Ljava/lang/Object;"
+	descBoolean = "Z"
+)
+
+// javaExpr 是反编译过程中的表达式树，exprType 返回字段描述符，未知时为空
+type javaExpr interface {
+	exprType() string
+}
+
+type literalExpr struct {
+	text     string
+	typ      string
+	isInt    bool
+	intValue int64
+}
+
+type localExpr struct {
+	v *localVar
+}
+
+type fieldExpr struct {
+	obj   javaExpr // 静态字段为 nil
+	owner string
+	name  string
+	typ   string
+}
+
+type arrayElemExpr struct {
+	array javaExpr
+	index javaExpr
+	typ   string
+}
+
+type arrayLengthExpr struct {
+	array javaExpr
+}
+
+type invokeExpr struct {
+	obj    javaExpr // 静态方法为 nil
+	owner  string
+	name   string
+	params []string
+	ret    string
+	args   []javaExpr
+	super  bool
+}
+
+type newExpr struct {
+	class       string
+	params      []string
+	args        []javaExpr
+	initialized bool
+}
+
+// constructorCallExpr 是构造方法中的 this(...) 或者 super(...)
+type constructorCallExpr struct {
+	keyword string
+	params  []string
+	args    []javaExpr
+}
+
+type newArrayExpr struct {
+	typ  string // 数组的描述符
+	dims []javaExpr
+	init []javaExpr
+}
+
+type binaryExpr struct {
+	op    string
+	left  javaExpr
+	right javaExpr
+	typ   string
+}
+
+type unaryExpr struct {
+	op      string
+	operand javaExpr
+	typ     string
+}
+
+type castExpr struct {
+	typ     string
+	operand javaExpr
+}
+
+type instanceOfExpr struct {
+	operand javaExpr
+	typ     string
+}
+
+type conditionalExpr struct {
+	cond javaExpr
+	then javaExpr
+	els  javaExpr
+}
+
+// compareResultExpr 是 lcmp、fcmpl 等指令的结果，通常会和后面的 if 合并为比较表达式
+type compareResultExpr struct {
+	left  javaExpr
+	right javaExpr
+	typ   string
+}
+
+type incExpr struct {
+	target javaExpr
+	op     string
+	prefix bool
+}
+
+type classLiteralExpr struct {
+	typ string
+}
+
+type concatPart struct {
+	expr javaExpr
+	typ  string
+}
+
+type concatExpr struct {
+	parts []*concatPart
+}
+
+type methodRefExpr struct {
+	owner string
+	name  string
+}
+
+type lambdaExpr struct {
+	params []*localVar
+	body   javaExpr
+}
+
+type rawExpr struct {
+	text string
+	typ  string
+}
+
+func (e *literalExpr) exprType() string         { return e.typ }
+func (e *localExpr) exprType() string           { return e.v.typ }
+func (e *fieldExpr) exprType() string           { return e.typ }
+func (e *arrayElemExpr) exprType() string       { return e.typ }
+func (e *arrayLengthExpr) exprType() string     { return "I" }
+func (e *invokeExpr) exprType() string          { return e.ret }
+func (e *newExpr) exprType() string             { return classDescriptor(e.class) }
+func (e *newArrayExpr) exprType() string        { return e.typ }
+func (e *constructorCallExpr) exprType() string { return "V" }
+func (e *binaryExpr) exprType() string          { return e.typ }
+func (e *unaryExpr) exprType() string           { return e.typ }
+func (e *castExpr) exprType() string            { return e.typ }
+func (e *instanceOfExpr) exprType() string      { return descBoolean }
+func (e *compareResultExpr) exprType() string   { return "I" }
+func (e *incExpr) exprType() string             { return e.target.exprType() }
+func (e *classLiteralExpr) exprType() string    { return "Ljava/lang/Class;" }
+func (e *concatExpr) exprType() string          { return descString }
+func (e *methodRefExpr) exprType() string       { return descObject }
+func (e *lambdaExpr) exprType() string          { return descObject }
+func (e *rawExpr) exprType() string             { return e.typ }
+
+func (e *conditionalExpr) exprType() string {
+	if t := e.then.exprType(); t != "" && t != "null" {
+		return t
+	}
+	return e.els.exprType()
+}
+
+func isBooleanExpr(e javaExpr) bool {
+	switch ret := e.(type) {
+	case *conditionalExpr:
+		return isBooleanExpr(ret.then) && isBooleanExpr(ret.els)
+	case *literalExpr:
+		return ret.typ == descBoolean || ret.isInt && (ret.intValue == 0 || ret.intValue == 1)
+	}
+	return e.exprType() == descBoolean
+}
+
+func intLiteral(v int64) *literalExpr {
+	return &literalExpr{text: strconv.FormatInt(v, 10), typ: "I", isInt: true, intValue: v}
+}
+
+func isIntLiteral(e javaExpr, v int64) bool {
+	l, ok := e.(*literalExpr)
+	return ok && l.isInt && l.intValue == v
+}
+
+// toBoolean 把 0/1 形式的整数表达式转换为布尔表达式
+func toBoolean(e javaExpr) javaExpr {
+	switch ret := e.(type) {
+	case *literalExpr:
+		if ret.isInt {
+			if ret.intValue == 0 {
+				return &literalExpr{text: "false", typ: descBoolean}
+			}
+			return &literalExpr{text: "true", typ: descBoolean}
+		}
+	case *conditionalExpr:
+		switch {
+		case isIntLiteral(ret.then, 1) && isIntLiteral(ret.els, 0):
+			return ret.cond
+		case isIntLiteral(ret.then, 0) && isIntLiteral(ret.els, 1):
+			return negateCondition(ret.cond)
+		}
+		return &conditionalExpr{cond: ret.cond, then: toBoolean(ret.then), els: toBoolean(ret.els)}
+	}
+	return e
+}
+
+// negateCondition 对条件取反，比较运算直接翻转运算符，逻辑运算使用德摩根定律
+func negateCondition(e javaExpr) javaExpr {
+	switch ret := e.(type) {
+	case *binaryExpr:
+		if op, ok := negatedOperator[ret.op]; ok {
+			return &binaryExpr{op: op, left: ret.left, right: ret.right, typ: descBoolean}
+		}
+		switch ret.op {
+		case "&&":
+			return &binaryExpr{op: "||", left: negateCondition(ret.left), right: negateCondition(ret.right), typ: descBoolean}
+		case "||":
+			return &binaryExpr{op: "&&", left: negateCondition(ret.left), right: negateCondition(ret.right), typ: descBoolean}
+		}
+	case *unaryExpr:
+		if ret.op == "!" {
+			return ret.operand
+		}
+	case *literalExpr:
+		switch ret.text {
+		case "true":
+			return &literalExpr{text: "false", typ: descBoolean}
+		case "false":
+			return &literalExpr{text: "true", typ: descBoolean}
+		}
+	case *conditionalExpr:
+		if isBooleanExpr(ret) {
+			return negateCondition(toBoolean(ret))
+		}
+	}
+	return &unaryExpr{op: "!", operand: e, typ: descBoolean}
+}
+
+// compareWithZero 构造 ifeq、ifne 等指令的条件
+func compareWithZero(e javaExpr, op string) javaExpr {
+	if cmp, ok := e.(*compareResultExpr); ok {
+		return &binaryExpr{op: op, left: cmp.left, right: cmp.right, typ: descBoolean}
+	}
+	if (op == "==" || op == "!=") && isBooleanExpr(e) {
+		if _, ok := e.(*literalExpr); !ok {
+			if op == "==" {
+				return negateCondition(toBoolean(e))
+			}
+			return toBoolean(e)
+		}
+	}
+	return &binaryExpr{op: op, left: e, right: intLiteral(0), typ: descBoolean}
+}
+
+// exprChildren 返回表达式的直接子表达式，用于遍历和替换
+func exprChildren(e javaExpr) []*javaExpr {
+	switch ret := e.(type) {
+	case *fieldExpr:
+		if ret.obj != nil {
+			return []*javaExpr{&ret.obj}
+		}
+	case *arrayElemExpr:
+		return []*javaExpr{&ret.array, &ret.index}
+	case *arrayLengthExpr:
+		return []*javaExpr{&ret.array}
+	case *invokeExpr:
+		var result []*javaExpr
+		if ret.obj != nil {
+			result = append(result, &ret.obj)
+		}
+		for i := range ret.args {
+			result = append(result, &ret.args[i])
+		}
+		return result
+	case *newExpr:
+		var result []*javaExpr
+		for i := range ret.args {
+			result = append(result, &ret.args[i])
+		}
+		return result
+	case *constructorCallExpr:
+		var result []*javaExpr
+		for i := range ret.args {
+			result = append(result, &ret.args[i])
+		}
+		return result
+	case *newArrayExpr:
+		var result []*javaExpr
+		for i := range ret.dims {
+			result = append(result, &ret.dims[i])
+		}
+		for i := range ret.init {
+			result = append(result, &ret.init[i])
+		}
+		return result
+	case *binaryExpr:
+		return []*javaExpr{&ret.left, &ret.right}
+	case *unaryExpr:
+		return []*javaExpr{&ret.operand}
+	case *castExpr:
+		return []*javaExpr{&ret.operand}
+	case *instanceOfExpr:
+		return []*javaExpr{&ret.operand}
+	case *conditionalExpr:
+		return []*javaExpr{&ret.cond, &ret.then, &ret.els}
+	case *compareResultExpr:
+		return []*javaExpr{&ret.left, &ret.right}
+	case *incExpr:
+		return []*javaExpr{&ret.target}
+	case *concatExpr:
+		var result []*javaExpr
+		for _, part := range ret.parts {
+			result = append(result, &part.expr)
+		}
+		return result
+	case *lambdaExpr:
+		return []*javaExpr{&ret.body}
+	}
+	return nil
+}
+
+// walkExpr 先序遍历表达式，visit 返回 false 时不再进入子表达式
+func walkExpr(e javaExpr, visit func(javaExpr) bool) {
+	if e == nil || !visit(e) {
+		return
+	}
+	for _, child := range exprChildren(e) {
+		walkExpr(*child, visit)
+	}
+}
+
+// replaceExpr 自底向上替换表达式
+func replaceExpr(e javaExpr, replace func(javaExpr) javaExpr) javaExpr {
+	if e == nil {
+		return nil
+	}
+	for _, child := range exprChildren(e) {
+		*child = replaceExpr(*child, replace)
+	}
+	return replace(e)
+}
+
+func exprUsesLocal(e javaExpr, v *localVar) bool {
+	found := false
+	walkExpr(e, func(e javaExpr) bool {
+		if l, ok := e.(*localExpr); ok && l.v == v {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// hasSideEffects 判断表达式求值是否会修改状态
+func hasSideEffects(e javaExpr) bool {
+	found := false
+	walkExpr(e, func(e javaExpr) bool {
+		switch ret := e.(type) {
+		case *invokeExpr, *incExpr, *constructorCallExpr:
+			found = true
+		case *newExpr:
+			found = ret.initialized
+		}
+		return !found
+	})
+	return found
+}
+
+// readsMemory 判断表达式是否读取字段、数组或者调用方法，这些值可能被其他语句修改
+func readsMemory(e javaExpr) bool {
+	found := false
+	walkExpr(e, func(e javaExpr) bool {
+		switch ret := e.(type) {
+		case *fieldExpr, *arrayElemExpr, *arrayLengthExpr, *invokeExpr, *incExpr:
+			found = true
+		case *newExpr:
+			found = ret.initialized
+		}
+		return !found
+	})
+	return found
+}
+
+// isStableExpr 表达式可以被重复使用而不需要先保存到临时变量中
+func isStableExpr(e javaExpr) bool {
+	switch ret := e.(type) {
+	case *literalExpr, *localExpr, *classLiteralExpr:
+		return true
+	case *newExpr:
+		return !ret.initialized
+	}
+	return false
+}
+
+func javaCharLiteral(v int64) string {
+	switch v {
+	case '\'':
+		return `'\''`
+	case '\\':
+		return `'\\'`
+	case '\n':
+		return `'\n'`
+	case '\r':
+		return `'\r'`
+	case '\t':
+		return `'\t'`
+	case 0:
+		return `'\0'`
+	}
+	if v > 0 && v < 0x7f && unicode.IsPrint(rune(v)) {
+		return fmt.Sprintf("'%c'", rune(v))
+	}
+	return fmt.Sprintf(`'\u%04x'`, v&0xffff)
+}
+
+// javaStringLiteral 把字符串转换为 Java 字符串字面量
+func javaStringLiteral(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f || r == unicode.ReplacementChar {
+				buf.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else if r > 0xffff {
+				for _, u := range []rune{0xd800 + (r-0x10000)>>10, 0xdc00 + (r-0x10000)&0x3ff} {
+					buf.WriteString(fmt.Sprintf(`\u%04x`, u))
+				}
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func javaFloatLiteral(f float64, bitSize int) string {
+	suffix := "d"
+	box := "Double"
+	if bitSize == 32 {
+		suffix = "f"
+		box = "Float"
+	}
+	switch text := formatFloat(f, bitSize); text {
+	case "NaN":
+		return box + ".NaN"
+	case "Infinity":
+		return box + ".POSITIVE_INFINITY"
+	case "-Infinity":
+		return box + ".NEGATIVE_INFINITY"
+	default:
+		if !strings.ContainsAny(text, ".eE") {
+			text += ".0"
+		}
+		return text + suffix
+	}
+}
+
+// renderAs 按照目标类型输出表达式，整数常量会根据需要转换为 boolean 或 char
+func (d *classDecompiler) renderAs(e javaExpr, typ string, prec int) string {
+	switch typ {
+	case descBoolean:
+		if isBooleanExpr(e) {
+			return d.render(toBoolean(e), prec)
+		}
+	case "C":
+		if l, ok := e.(*literalExpr); ok && l.isInt {
+			return javaCharLiteral(l.intValue)
+		}
+	}
+	return d.render(e, prec)
+}
+
+func (d *classDecompiler) renderArgs(args []javaExpr, params []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		typ := ""
+		if i < len(params) {
+			typ = params[i]
+		}
+		parts[i] = d.renderAs(arg, typ, precAssign)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// render 输出表达式，优先级低于 prec 时加上括号
+func (d *classDecompiler) render(e javaExpr, prec int) string {
+	text, own := d.renderExpr(e)
+	if own < prec {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func (d *classDecompiler) renderExpr(e javaExpr) (string, int) {
+	switch ret := e.(type) {
+	case *literalExpr:
+		if strings.HasPrefix(ret.text, "-") {
+			return ret.text, precUnary
+		}
+		return ret.text, precPrimary
+	case *localExpr:
+		return ret.v.name, precPrimary
+	case *fieldExpr:
+		if ret.obj == nil {
+			return d.className(ret.owner) + "." + ret.name, precPrimary
+		}
+		return d.render(ret.obj, precPrimary) + "." + ret.name, precPrimary
+	case *arrayElemExpr:
+		return d.render(ret.array, precPrimary) + "[" + d.render(ret.index, precAssign) + "]", precPrimary
+	case *arrayLengthExpr:
+		return d.render(ret.array, precPrimary) + ".length", precPrimary
+	case *invokeExpr:
+		args := "(" + d.renderArgs(ret.args, ret.params) + ")"
+		switch {
+		case ret.super:
+			return "super." + ret.name + args, precPrimary
+		case ret.obj == nil:
+			return d.className(ret.owner) + "." + ret.name + args, precPrimary
+		}
+		return d.render(ret.obj, precPrimary) + "." + ret.name + args, precPrimary
+	case *newExpr:
+		return "new " + d.className(ret.class) + "(" + d.renderArgs(ret.args, ret.params) + ")", precPrimary
+	case *constructorCallExpr:
+		return ret.keyword + "(" + d.renderArgs(ret.args, ret.params) + ")", precPrimary
+	case *newArrayExpr:
+		return d.renderNewArray(ret), precPrimary
+	case *binaryExpr:
+		prec := binaryPrecedence[ret.op]
+		leftType, rightType := ret.left.exprType(), ret.right.exprType()
+		if leftType == descBoolean || leftType == "C" {
+			rightType = leftType
+		} else if rightType == descBoolean || rightType == "C" {
+			leftType = rightType
+		}
+		return d.renderAs(ret.left, leftType, prec) + " " + ret.op + " " + d.renderAs(ret.right, rightType, prec+1), prec
+	case *unaryExpr:
+		operand := d.render(ret.operand, precUnary)
+		if ret.op == "!" {
+			operand = d.render(toBoolean(ret.operand), precUnary)
+		}
+		if ret.op == "-" && strings.HasPrefix(operand, "-") {
+			operand = "(" + operand + ")"
+		}
+		return ret.op + operand, precUnary
+	case *castExpr:
+		operand := d.render(ret.operand, precUnary)
+		if strings.HasPrefix(operand, "-") || strings.HasPrefix(operand, "+") {
+			operand = "(" + operand + ")"
+		}
+		return "(" + d.typeName(ret.typ) + ") " + operand, precUnary
+	case *instanceOfExpr:
+		return d.render(ret.operand, precRelational) + " instanceof " + d.typeName(ret.typ), precRelational
+	case *conditionalExpr:
+		typ := ret.exprType()
+		if isBooleanExpr(ret.then) && isBooleanExpr(ret.els) && (ret.then.exprType() == descBoolean || ret.els.exprType() == descBoolean) {
+			typ = descBoolean
+		}
+		return d.renderAs(toBoolean(ret.cond), descBoolean, precOr) + " ? " + d.renderAs(ret.then, typ, precTernary) + " : " + d.renderAs(ret.els, typ, precTernary), precTernary
+	case *compareResultExpr:
+		box := map[string]string{"J": "Long", "F": "Float", "D": "Double"}[ret.typ]
+		if box == "" {
+			box = "Integer"
+		}
+		return box + ".compare(" + d.render(ret.left, precAssign) + ", " + d.render(ret.right, precAssign) + ")", precPrimary
+	case *incExpr:
+		if ret.prefix {
+			return ret.op + d.render(ret.target, precUnary), precUnary
+		}
+		return d.render(ret.target, precPostfix) + ret.op, precPostfix
+	case *classLiteralExpr:
+		return d.typeName(ret.typ) + ".class", precPrimary
+	case *concatExpr:
+		return d.renderConcat(ret), precAdditive
+	case *methodRefExpr:
+		return d.className(ret.owner) + "::" + ret.name, precPrimary
+	case *lambdaExpr:
+		names := make([]string, len(ret.params))
+		for i, v := range ret.params {
+			names[i] = v.name
+		}
+		params := strings.Join(names, ", ")
+		if len(ret.params) != 1 {
+			params = "(" + params + ")"
+		}
+		return params + " -> " + d.render(ret.body, precAssign), precAssign
+	case *rawExpr:
+		return ret.text, precPrimary
+	}
+	return "null", precPrimary
+}
+
+func (d *classDecompiler) renderNewArray(e *newArrayExpr) string {
+	elem := strings.TrimLeft(e.typ, "[")
+	depth := len(e.typ) - len(elem)
+	base := d.typeName(elem)
+	if len(e.init) > 0 && len(e.dims) == 1 {
+		elemType := e.typ[1:]
+		parts := make([]string, len(e.init))
+		for i, v := range e.init {
+			parts[i] = d.renderAs(v, elemType, precAssign)
+		}
+		// 数组初始化时没有赋值的元素保持默认值
+		if size, ok := e.dims[0].(*literalExpr); ok && size.isInt {
+			for i := int64(len(parts)); i < size.intValue; i++ {
+				parts = append(parts, d.renderAs(zeroValue(elemType), elemType, precAssign))
+			}
+		}
+		return "new " + base + strings.Repeat("[]", depth) + "{" + strings.Join(parts, ", ") + "}"
+	}
+	var buf strings.Builder
+	buf.WriteString("new " + base)
+	for _, dim := range e.dims {
+		buf.WriteString("[" + d.render(dim, precAssign) + "]")
+	}
+	buf.WriteString(strings.Repeat("[]", depth-len(e.dims)))
+	return buf.String()
+}
+
+func (d *classDecompiler) renderConcat(e *concatExpr) string {
+	parts := e.parts
+	isString := func(i int) bool {
+		return i < len(parts) && parts[i].expr.exprType() == descString
+	}
+	var result []string
+	if !isString(0) && !isString(1) {
+		result = append(result, `""`)
+	}
+	for i, part := range parts {
+		prec := precAdditive + 1
+		if i == 0 && len(result) == 0 {
+			prec = precAdditive
+		}
+		result = append(result, d.renderAs(part.expr, part.typ, prec))
+	}
+	return strings.Join(result, " + ")
+}
+
+// zeroValue 返回类型的默认值，用于变量声明
+func zeroValue(typ string) javaExpr {
+	switch typ {
+	case "Z":
+		return &literalExpr{text: "false", typ: descBoolean}
+	case "J":
+		return &literalExpr{text: "0L", typ: "J"}
+	case "F":
+		return &literalExpr{text: "0.0f", typ: "F"}
+	case "D":
+		return &literalExpr{text: "0.0", typ: "D"}
+	case "B", "C", "I", "S":
+		return &literalExpr{text: "0", typ: typ, isInt: true}
+	}
+	return &literalExpr{text: "null", typ: "null"}
+}
@@ -0,0 +1,1613 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type varKind int
+
+const (
+	varLocal varKind = iota
+	// varStack 保存在多个前驱之间合并的操作数栈的值
+	varStack
+	// varTemp 保存需要提前求值的表达式
+	varTemp
+	varCatch
+	varLambda
+	// varCapture 保存 lambda 捕获的值，不会被内联回 lambda 中
+	varCapture
+)
+
+// localVar 是反编译得到的局部变量，param 为 true 的变量（this、参数、lambda 参数）不需要声明
+type localVar struct {
+	name  string
+	typ   string
+	slot  int
+	sort  byte
+	kind  varKind
+	param bool
+	// debug 表示变量来自 LocalVariableTable
+	debug bool
+}
+
+func (v *localVar) isTemp() bool {
+	return v.kind == varStack || v.kind == varTemp
+}
+
+type lvtEntry struct {
+	start, end, slot int
+	name, desc       string
+}
+
+// localSort 把描述符归类为局部变量指令使用的类型：I、J、F、D、A
+func localSort(desc string) byte {
+	if desc == "" {
+		return 'A'
+	}
+	switch desc[0] {
+	case 'Z', 'B', 'C', 'S', 'I':
+		return 'I'
+	case 'J', 'F', 'D':
+		return desc[0]
+	}
+	return 'A'
+}
+
+type termKind int
+
+const (
+	termNext termKind = iota
+	termCond
+	termSwitch
+	termReturn
+	termThrow
+)
+
+// terminator 是基本块最后的控制流转移
+type terminator struct {
+	kind  termKind
+	value javaExpr
+	next  *basicBlock
+	// termCond 条件为真时跳转到 trueSucc
+	trueSucc, falseSucc *basicBlock
+	keys                []int32
+	targets             []*basicBlock
+	defaultSucc         *basicBlock
+}
+
+func (t *terminator) successors() []*basicBlock {
+	var result []*basicBlock
+	add := func(b *basicBlock) {
+		for _, s := range result {
+			if s == b {
+				return
+			}
+		}
+		result = append(result, b)
+	}
+	switch t.kind {
+	case termNext:
+		add(t.next)
+	case termCond:
+		add(t.trueSucc)
+		add(t.falseSucc)
+	case termSwitch:
+		for _, target := range t.targets {
+			add(target)
+		}
+		add(t.defaultSucc)
+	}
+	return result
+}
+
+type basicBlock struct {
+	start, end int
+	insts      []*Instruction
+	// succs 只包含正常的控制流，异常处理块在 handlers 中
+	succs    []*basicBlock
+	preds    []*basicBlock
+	handlers []*basicBlock
+	// excPreds 是被异常处理块覆盖的基本块
+	excPreds    []*basicBlock
+	isHandler   bool
+	handlerType string
+	handlerVar  *localVar
+
+	executed  bool
+	stmts     []javaStmt
+	term      *terminator
+	exit      []javaExpr
+	entryVars []*localVar
+}
+
+type exceptionEntry struct {
+	start, end int
+	handler    *basicBlock
+	// catchType 为空表示捕获所有异常
+	catchType string
+}
+
+// methodDecompiler 反编译一个方法：先按基本块符号执行得到语句和条件，再根据控制流图还原结构
+type methodDecompiler struct {
+	d      *classDecompiler
+	obj    *ClassObject
+	name   string
+	desc   string
+	params []string
+	ret    string
+	static bool
+	code   *CodeAttribute
+
+	thisVar   *localVar
+	paramVars []*localVar
+	vars      []*localVar
+	lvt       []*lvtEntry
+	lvtVars   map[*lvtEntry]*localVar
+	namedVars map[string]*localVar
+	slotVars  map[string]*localVar
+
+	entry      *basicBlock
+	blocks     []*basicBlock
+	blockAt    map[int]*basicBlock
+	order      []*basicBlock
+	exceptions []*exceptionEntry
+
+	idom    map[*basicBlock]*basicBlock
+	ipdom   map[*basicBlock]*basicBlock
+	loops   map[*basicBlock]*loopInfo
+	regions []*tryRegion
+
+	active    map[*basicBlock]int
+	emitCount map[*basicBlock]int
+	steps     int
+}
+
+func newMethodDecompiler(d *classDecompiler, method *MemberInfo) (*methodDecompiler, error) {
+	obj := d.obj
+	name, err := obj.getUtf8Value(method.NameIndex)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := obj.getUtf8Value(method.DescriptorIndex)
+	if err != nil {
+		return nil, err
+	}
+	params, ret, err := parseMethodDescriptor(desc)
+	if err != nil {
+		return nil, err
+	}
+	m := &methodDecompiler{
+		d: d, obj: obj, name: name, desc: desc, params: params, ret: ret,
+		static:    method.AccessFlags&0x0008 != 0,
+		code:      obj.getMethodCode(method),
+		lvtVars:   make(map[*lvtEntry]*localVar),
+		namedVars: make(map[string]*localVar),
+		slotVars:  make(map[string]*localVar),
+	}
+	if m.code != nil {
+		for _, attr := range m.code.Attributes {
+			table, ok := attr.(*LocalVariableTableAttribute)
+			if !ok {
+				continue
+			}
+			for _, entry := range table.LocalVariableTable {
+				name, err1 := obj.getUtf8Value(entry.NameIndex)
+				desc, err2 := obj.getUtf8Value(entry.DescriptorIndex)
+				if err1 != nil || err2 != nil {
+					continue
+				}
+				if _, err := fieldDescriptorEnd(desc, 0); err != nil {
+					continue
+				}
+				m.lvt = append(m.lvt, &lvtEntry{
+					start: int(entry.StartPc), end: int(entry.StartPc) + int(entry.Length), slot: int(entry.Index),
+					name: name, desc: desc,
+				})
+			}
+		}
+	}
+
+	slot := 0
+	if !m.static {
+		m.thisVar = &localVar{name: "this", typ: classDescriptor(d.name), sort: 'A', param: true}
+		m.bindParam(m.thisVar, 0)
+		slot = 1
+	}
+	for i, p := range params {
+		v := &localVar{name: "arg" + strconv.Itoa(i), typ: p, slot: slot, sort: localSort(p), param: true}
+		m.bindParam(v, slot)
+		m.paramVars = append(m.paramVars, v)
+		slot++
+		if p == "J" || p == "D" {
+			slot++
+		}
+	}
+	return m, nil
+}
+
+func (m *methodDecompiler) bindParam(v *localVar, slot int) {
+	for _, entry := range m.lvt {
+		if entry.slot != slot || entry.start != 0 || localSort(entry.desc) != v.sort {
+			continue
+		}
+		if v != m.thisVar && isJavaIdentifier(entry.name) {
+			v.name = entry.name
+			v.debug = true
+		}
+		m.lvtVars[entry] = v
+		m.namedVars[entry.name+":"+entry.desc] = v
+	}
+	m.slotVars[fmt.Sprintf("%d:%c", slot, v.sort)] = v
+	m.vars = append(m.vars, v)
+}
+
+func (m *methodDecompiler) newSyntheticVar(name, typ string, kind varKind) *localVar {
+	v := &localVar{name: name, typ: typ, kind: kind, slot: -1, sort: localSort(typ)}
+	m.vars = append(m.vars, v)
+	return v
+}
+
+// localVarAt 查找在 pc 处使用 slot 的局部变量，优先使用 LocalVariableTable
+func (m *methodDecompiler) localVarAt(slot int, sort byte, pcs ...int) *localVar {
+	for _, pc := range pcs {
+		for _, entry := range m.lvt {
+			if entry.slot == slot && pc >= entry.start && pc < entry.end && localSort(entry.desc) == sort {
+				if v, ok := m.lvtVars[entry]; ok {
+					return v
+				}
+				key := entry.name + ":" + entry.desc
+				v, ok := m.namedVars[key]
+				if !ok {
+					v = &localVar{name: entry.name, typ: entry.desc, slot: slot, sort: sort, debug: true}
+					m.namedVars[key] = v
+					m.vars = append(m.vars, v)
+				}
+				m.lvtVars[entry] = v
+				return v
+			}
+		}
+	}
+	key := fmt.Sprintf("%d:%c", slot, sort)
+	v, ok := m.slotVars[key]
+	if !ok {
+		v = &localVar{slot: slot, sort: sort}
+		if sort != 'I' && sort != 'A' {
+			v.typ = string(sort)
+		}
+		m.slotVars[key] = v
+		m.vars = append(m.vars, v)
+	}
+	return v
+}
+
+// inferType 没有调试信息的局部变量根据写入的值推断类型
+func (v *localVar) inferType(typ string) {
+	if v.debug || v.param || v.sort != 'I' && v.sort != 'A' {
+		return
+	}
+	switch {
+	case typ == "" || typ == "null":
+	case v.typ == "":
+		v.typ = typ
+	case v.typ != typ:
+		if v.sort == 'I' {
+			v.typ = "I"
+		} else {
+			v.typ = descObject
+		}
+	}
+}
+
+// assignNames 为变量分配不重复的名字
+func (m *methodDecompiler) assignNames() {
+	used := make(map[string]bool)
+	counters := make(map[string]int)
+	for _, v := range m.vars {
+		if v.typ == "" || v.typ == "null" {
+			switch v.sort {
+			case 'I':
+				v.typ = "I"
+			case 'J', 'F', 'D':
+				v.typ = string(v.sort)
+			default:
+				v.typ = descObject
+			}
+		}
+		if v == m.thisVar {
+			used["this"] = true
+			continue
+		}
+		base, numbered := v.name, false
+		switch v.kind {
+		case varStack:
+			base, numbered = "stack", true
+		case varTemp:
+			base, numbered = "tmp", true
+		case varLambda:
+			base, numbered = "p", true
+		case varCapture:
+			base, numbered = "captured", true
+		case varCatch:
+			if base == "" {
+				base = "ex"
+			}
+		default:
+			if !v.debug && !v.param && v.slot >= 0 {
+				base = "var" + strconv.Itoa(v.slot)
+				if v.sort != 'I' && v.sort != 'A' || m.slotVars[fmt.Sprintf("%d:%c", v.slot, 'I')] != nil && v.sort == 'A' {
+					base += strings.ToLower(string(v.sort))
+				}
+			}
+		}
+		if !isJavaIdentifier(base) {
+			base = "var" + strconv.Itoa(v.slot)
+			if v.slot < 0 {
+				base = "v"
+			}
+		}
+		name := base
+		if numbered {
+			counters[base]++
+			name = base + strconv.Itoa(counters[base])
+		}
+		for i := 2; used[name]; i++ {
+			if numbered {
+				counters[base]++
+				name = base + strconv.Itoa(counters[base])
+			} else {
+				name = base + strconv.Itoa(i)
+			}
+		}
+		used[name] = true
+		v.name = name
+	}
+}
+
+func (m *methodDecompiler) nameLabels(body []javaStmt, used map[*stmtLabel]bool) {
+	count := 0
+	walkStmts(body, func(s javaStmt) {
+		var label *stmtLabel
+		switch ret := s.(type) {
+		case *ifStmt:
+			label = ret.label
+		case *loopStmt:
+			label = ret.label
+		case *switchStmt:
+			label = ret.label
+		case *tryStmt:
+			label = ret.label
+		}
+		if label != nil && used[label] && label.name == "" {
+			count++
+			label.name = "label" + strconv.Itoa(count)
+		}
+	})
+}
+
+// decompile 还原方法体，方法过于复杂或者字节码不符合预期时返回错误
+func (m *methodDecompiler) decompile() (body []javaStmt, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("decompile %s%s failed: %v", m.name, m.desc, r)
+		}
+	}()
+	if err := m.buildBlocks(); err != nil {
+		return nil, err
+	}
+	for _, b := range m.order {
+		m.executeBlock(b)
+	}
+	m.fixStackVars()
+	m.skipEmptyBlocks()
+	m.mergeConditions()
+	m.analyze()
+
+	m.active = make(map[*basicBlock]int)
+	m.emitCount = make(map[*basicBlock]int)
+	body, _ = m.emitSeq(m.entry, nil, nil)
+
+	for i := 0; i < 8; i++ {
+		changed := convertTernary(body)
+		if inlineTemps(&body) {
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	simplifyConditions(body)
+	body = removeTailJumps(body, nil, nil)
+	if n := len(body); n > 0 && m.ret == "V" {
+		if ret, ok := body[n-1].(*returnStmt); ok && ret.value == nil {
+			body = body[:n-1]
+		}
+	}
+	m.fixCatchVariables(body)
+	declareLocals(&body)
+	m.assignNames()
+	return body, nil
+}
+
+// buildBlocks 划分基本块，建立正常控制流和异常控制流的边
+func (m *methodDecompiler) buildBlocks() error {
+	insts, err := DecodeInstructions(m.code.Code)
+	if err != nil {
+		return err
+	}
+	if len(insts) == 0 {
+		return utils.Error("empty code")
+	}
+	codeLen := len(m.code.Code)
+	index := make(map[int]int, len(insts))
+	for i, inst := range insts {
+		index[inst.Offset] = i
+		switch inst.Opcode {
+		case OP_jsr, OP_jsr_w, OP_ret:
+			return utils.Errorf("%s is not supported", inst.Name())
+		}
+	}
+	leaders := map[int]bool{0: true}
+	mark := func(pc int) error {
+		if _, ok := index[pc]; !ok {
+			return utils.Errorf("invalid jump target %d", pc)
+		}
+		leaders[pc] = true
+		return nil
+	}
+	for i, inst := range insts {
+		if inst.IsBranch() {
+			if err := mark(inst.Target); err != nil {
+				return err
+			}
+		}
+		if inst.IsSwitch() {
+			if err := mark(inst.Default); err != nil {
+				return err
+			}
+			for _, target := range inst.Targets {
+				if err := mark(target); err != nil {
+					return err
+				}
+			}
+		}
+		if (inst.IsBranch() || inst.IsUnconditional()) && i+1 < len(insts) {
+			leaders[insts[i+1].Offset] = true
+		}
+	}
+	type rawEntry struct {
+		start, end, handler int
+		catchType           string
+	}
+	var entries []rawEntry
+	for _, entry := range m.code.ExceptionTable {
+		start, end, handler := int(entry.StartPc), int(entry.EndPc), int(entry.HandlerPc)
+		_, okStart := index[start]
+		_, okEnd := index[end]
+		_, okHandler := index[handler]
+		if start >= end || !okStart || !okEnd && end != codeLen || !okHandler || handler >= start && handler < end {
+			continue
+		}
+		catchType := ""
+		if entry.CatchType != 0 {
+			catchType, err = m.obj.getClassNameByIndex(entry.CatchType)
+			if err != nil {
+				return err
+			}
+		}
+		leaders[start], leaders[handler] = true, true
+		if end < codeLen {
+			leaders[end] = true
+		}
+		entries = append(entries, rawEntry{start, end, handler, catchType})
+	}
+
+	m.blockAt = make(map[int]*basicBlock)
+	var current *basicBlock
+	for _, inst := range insts {
+		if leaders[inst.Offset] {
+			current = &basicBlock{start: inst.Offset}
+			m.blocks = append(m.blocks, current)
+			m.blockAt[inst.Offset] = current
+		}
+		current.insts = append(current.insts, inst)
+		current.end = inst.Offset + inst.Size()
+	}
+	for _, entry := range entries {
+		handler := m.blockAt[entry.handler]
+		if !handler.isHandler {
+			handler.isHandler = true
+			handler.handlerType = entry.catchType
+		} else if handler.handlerType != entry.catchType {
+			handler.handlerType = ""
+		}
+		m.exceptions = append(m.exceptions, &exceptionEntry{start: entry.start, end: entry.end, handler: handler, catchType: entry.catchType})
+		for _, b := range m.blocks {
+			if b.start >= entry.start && b.start < entry.end && !containsBlock(b.handlers, handler) {
+				b.handlers = append(b.handlers, handler)
+			}
+		}
+	}
+	for i, b := range m.blocks {
+		var next *basicBlock
+		if i+1 < len(m.blocks) {
+			next = m.blocks[i+1]
+		}
+		last := b.insts[len(b.insts)-1]
+		var succs []*basicBlock
+		switch {
+		case last.IsSwitch():
+			for _, target := range last.Targets {
+				succs = append(succs, m.blockAt[target])
+			}
+			succs = append(succs, m.blockAt[last.Default])
+		case last.Opcode == OP_goto || last.Opcode == OP_goto_w:
+			succs = append(succs, m.blockAt[last.Target])
+		case last.IsBranch():
+			if next == nil {
+				return utils.Error("code falls off the end")
+			}
+			succs = append(succs, m.blockAt[last.Target], next)
+		case last.IsUnconditional():
+		default:
+			if next == nil {
+				return utils.Error("code falls off the end")
+			}
+			succs = append(succs, next)
+		}
+		for _, s := range succs {
+			if !containsBlock(b.succs, s) {
+				b.succs = append(b.succs, s)
+			}
+		}
+	}
+	m.entry = m.blocks[0]
+	m.computeOrder()
+	return nil
+}
+
+func containsBlock(list []*basicBlock, b *basicBlock) bool {
+	for _, item := range list {
+		if item == b {
+			return true
+		}
+	}
+	return false
+}
+
+// computeOrder 计算从入口可达的基本块的逆后序，并重新建立前驱
+func (m *methodDecompiler) computeOrder() {
+	visited := make(map[*basicBlock]bool)
+	var post []*basicBlock
+	var visit func(b *basicBlock)
+	visit = func(b *basicBlock) {
+		visited[b] = true
+		for _, s := range b.succs {
+			if !visited[s] {
+				visit(s)
+			}
+		}
+		for _, h := range b.handlers {
+			if !visited[h] {
+				visit(h)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(m.entry)
+	m.order = make([]*basicBlock, len(post))
+	for i, b := range post {
+		m.order[len(post)-1-i] = b
+	}
+	for _, b := range m.blocks {
+		b.preds, b.excPreds = nil, nil
+	}
+	for _, b := range m.order {
+		for _, s := range b.succs {
+			if !containsBlock(s.preds, b) {
+				s.preds = append(s.preds, b)
+			}
+		}
+		for _, h := range b.handlers {
+			h.excPreds = append(h.excPreds, b)
+		}
+	}
+}
+
+// execState 是符号执行一个基本块时的操作数栈
+type execState struct {
+	m     *methodDecompiler
+	b     *basicBlock
+	stack []javaExpr
+}
+
+func (s *execState) push(e javaExpr) {
+	s.stack = append(s.stack, e)
+}
+
+func (s *execState) pop() javaExpr {
+	if len(s.stack) == 0 {
+		panic(fmt.Sprintf("stack underflow at block %d", s.b.start))
+	}
+	e := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return e
+}
+
+func (s *execState) popN(n int) []javaExpr {
+	result := make([]javaExpr, n)
+	for i := n - 1; i >= 0; i-- {
+		result[i] = s.pop()
+	}
+	return result
+}
+
+func (s *execState) peek() javaExpr {
+	if len(s.stack) == 0 {
+		panic(fmt.Sprintf("stack underflow at block %d", s.b.start))
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *execState) onStack(e javaExpr) bool {
+	for _, item := range s.stack {
+		if item == e {
+			return true
+		}
+	}
+	return false
+}
+
+// spillAt 把栈上的表达式保存到临时变量，保证它在后续语句之前求值
+func (s *execState) spillAt(i int) {
+	e := s.stack[i]
+	typ := e.exprType()
+	if typ == "" || typ == "null" || typ == "V" {
+		typ = descObject
+	}
+	v := s.m.newSyntheticVar("", typ, varTemp)
+	s.b.stmts = append(s.b.stmts, &assignStmt{target: &localExpr{v: v}, value: e})
+	ref := &localExpr{v: v}
+	for j := range s.stack {
+		if s.stack[j] == e {
+			s.stack[j] = ref
+		}
+	}
+}
+
+func (s *execState) spill(conflict func(javaExpr) bool) {
+	for i, e := range s.stack {
+		if !isStableExpr(e) && conflict(e) {
+			s.spillAt(i)
+		}
+	}
+}
+
+// emit 添加会修改内存的语句，之前读取内存的栈上表达式需要先求值
+func (s *execState) emit(stmt javaStmt) {
+	s.spill(readsMemory)
+	s.b.stmts = append(s.b.stmts, stmt)
+}
+
+func category(e javaExpr) int {
+	switch e.exprType() {
+	case "J", "D":
+		return 2
+	}
+	return 1
+}
+
+// sameLocation 判断两个表达式是否表示同一个变量、字段或数组元素
+func sameLocation(a, b javaExpr) bool {
+	if a == b {
+		return true
+	}
+	switch x := a.(type) {
+	case *localExpr:
+		y, ok := b.(*localExpr)
+		return ok && x.v == y.v
+	case *literalExpr:
+		y, ok := b.(*literalExpr)
+		return ok && x.text == y.text
+	case *fieldExpr:
+		y, ok := b.(*fieldExpr)
+		if !ok || x.owner != y.owner || x.name != y.name {
+			return false
+		}
+		if x.obj == nil || y.obj == nil {
+			return x.obj == nil && y.obj == nil
+		}
+		return sameLocation(x.obj, y.obj)
+	case *arrayElemExpr:
+		y, ok := b.(*arrayElemExpr)
+		return ok && sameLocation(x.array, y.array) && sameLocation(x.index, y.index)
+	}
+	return false
+}
+
+var compoundOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "<<": true, ">>": true, ">>>": true, "&": true, "|": true, "^": true,
+}
+
+// makeAssign 生成赋值语句，能写成 x += y、x++ 的形式时使用复合赋值
+func makeAssign(target, value javaExpr) javaStmt {
+	switch ret := value.(type) {
+	case *binaryExpr:
+		if compoundOperators[ret.op] && sameLocation(ret.left, target) {
+			if (ret.op == "+" || ret.op == "-") && isIntLiteral(ret.right, 1) {
+				return &exprStmt{expr: &incExpr{target: target, op: ret.op + ret.op}}
+			}
+			return &assignStmt{target: target, value: ret.right, op: ret.op + "="}
+		}
+	case *concatExpr:
+		if len(ret.parts) >= 2 && target.exprType() == descString && sameLocation(ret.parts[0].expr, target) {
+			rest := &concatExpr{parts: ret.parts[1:]}
+			if len(rest.parts) == 1 {
+				return &assignStmt{target: target, value: rest.parts[0].expr, op: "+="}
+			}
+			return &assignStmt{target: target, value: rest, op: "+="}
+		}
+	}
+	return &assignStmt{target: target, value: value}
+}
+
+var (
+	arithmeticOperators = []string{"+", "-", "*", "/", "%"}
+	numericTypes        = []string{"I", "J", "F", "D"}
+	castTypes           = []string{"J", "F", "D", "I", "F", "D", "I", "J", "D", "I", "J", "F", "B", "C", "S"}
+	conditionOperators  = []string{"==", "!=", "<", ">=", ">", "<="}
+	arrayElementTypes   = []string{"I", "J", "F", "D", descObject, "B", "C", "S"}
+)
+
+func (m *methodDecompiler) constant(index uint16) javaExpr {
+	e, err := m.d.constant(index)
+	if err != nil {
+		panic(err.Error())
+	}
+	return e
+}
+
+func (m *methodDecompiler) memberRef(index uint16) *memberRef {
+	ref, err := m.obj.getMemberRef(index)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ref
+}
+
+func (m *methodDecompiler) className(index uint16) string {
+	name, err := m.obj.getClassNameByIndex(index)
+	if err != nil {
+		panic(err.Error())
+	}
+	return name
+}
+
+// enterBlock 计算基本块入口处的操作数栈，多个前驱的值不同时使用栈变量合并
+func (m *methodDecompiler) enterBlock(b *basicBlock) []javaExpr {
+	if b.isHandler {
+		typ := "java/lang/Throwable"
+		if b.handlerType != "" {
+			typ = b.handlerType
+		}
+		v := m.newSyntheticVar("", classDescriptor(typ), varCatch)
+		b.handlerVar = v
+		b.entryVars = []*localVar{v}
+		return []javaExpr{&localExpr{v: v}}
+	}
+	if b == m.entry || len(b.preds) == 0 {
+		return nil
+	}
+	var first []javaExpr
+	executed, allExecuted := 0, true
+	for _, p := range b.preds {
+		if !p.executed {
+			allExecuted = false
+			continue
+		}
+		if executed == 0 {
+			first = p.exit
+		} else if len(p.exit) != len(first) {
+			panic(fmt.Sprintf("stack height mismatch at block %d", b.start))
+		}
+		executed++
+	}
+	if len(first) == 0 {
+		return nil
+	}
+	stack := make([]javaExpr, len(first))
+	b.entryVars = make([]*localVar, len(first))
+	for depth, e := range first {
+		same := allExecuted
+		for _, p := range b.preds {
+			if p.executed && p.exit[depth] != e {
+				same = false
+			}
+		}
+		if same {
+			stack[depth] = e
+			continue
+		}
+		typ := e.exprType()
+		if typ == "" || typ == "null" {
+			typ = descObject
+		}
+		v := m.newSyntheticVar("", typ, varStack)
+		b.entryVars[depth] = v
+		stack[depth] = &localExpr{v: v}
+	}
+	return stack
+}
+
+// fixStackVars 在前驱的末尾给后继的栈变量赋值
+func (m *methodDecompiler) fixStackVars() {
+	for _, b := range m.order {
+		for depth, v := range b.entryVars {
+			if v == nil {
+				continue
+			}
+			for _, p := range b.preds {
+				if !p.executed || depth >= len(p.exit) {
+					continue
+				}
+				if l, ok := p.exit[depth].(*localExpr); ok && l.v == v {
+					continue
+				}
+				p.stmts = append(p.stmts, &assignStmt{target: &localExpr{v: v}, value: p.exit[depth]})
+			}
+		}
+	}
+}
+
+// executeBlock 符号执行基本块，得到语句和最后的控制流转移
+func (m *methodDecompiler) executeBlock(b *basicBlock) {
+	s := &execState{m: m, b: b, stack: m.enterBlock(b)}
+	for _, inst := range b.insts {
+		m.execute(s, inst)
+	}
+	if b.term == nil {
+		b.term = &terminator{kind: termNext, next: b.succs[0]}
+	}
+	if len(b.term.successors()) > 1 || m.leavesHandlers(b) {
+		// 离开 try 范围时栈上的表达式需要在 try 里面求值
+		for i, e := range s.stack {
+			if !isStableExpr(e) {
+				s.spillAt(i)
+			}
+		}
+	}
+	b.exit = s.stack
+	b.executed = true
+}
+
+// leavesHandlers 判断后继基本块的异常处理器是否和 b 不同
+func (m *methodDecompiler) leavesHandlers(b *basicBlock) bool {
+	for _, succ := range b.term.successors() {
+		if len(succ.handlers) != len(b.handlers) {
+			return true
+		}
+		for _, h := range succ.handlers {
+			if !containsBlock(b.handlers, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *methodDecompiler) store(s *execState, v *localVar, value javaExpr) {
+	s.spill(func(e javaExpr) bool {
+		return exprUsesLocal(e, v)
+	})
+	v.inferType(value.exprType())
+	if l, ok := value.(*localExpr); ok && l.v == v {
+		return
+	}
+	s.b.stmts = append(s.b.stmts, makeAssign(&localExpr{v: v}, value))
+}
+
+func (m *methodDecompiler) execute(s *execState, inst *Instruction) {
+	op := inst.Opcode
+	next := inst.Offset + inst.Size()
+	switch {
+	case op == OP_nop:
+	case op == OP_aconst_null:
+		s.push(&literalExpr{text: "null", typ: "null"})
+	case op >= OP_iconst_m1 && op <= OP_iconst_5:
+		s.push(intLiteral(int64(op) - OP_iconst_0))
+	case op == OP_lconst_0 || op == OP_lconst_1:
+		s.push(&literalExpr{text: strconv.Itoa(int(op-OP_lconst_0)) + "L", typ: "J"})
+	case op >= OP_fconst_0 && op <= OP_fconst_2:
+		s.push(&literalExpr{text: strconv.Itoa(int(op-OP_fconst_0)) + ".0f", typ: "F"})
+	case op == OP_dconst_0 || op == OP_dconst_1:
+		s.push(&literalExpr{text: strconv.Itoa(int(op-OP_dconst_0)) + ".0", typ: "D"})
+	case op == OP_bipush || op == OP_sipush:
+		s.push(intLiteral(int64(inst.Value)))
+	case op == OP_ldc || op == OP_ldc_w || op == OP_ldc2_w:
+		s.push(m.constant(inst.Index))
+	case op >= OP_iload && op <= OP_aload:
+		sort := "IJFDA"[op-OP_iload]
+		s.push(&localExpr{v: m.localVarAt(int(inst.Index), sort, inst.Offset)})
+	case op >= OP_iload_0 && op <= OP_aload_3:
+		sort := "IJFDA"[(op-OP_iload_0)/4]
+		s.push(&localExpr{v: m.localVarAt(int(op-OP_iload_0)%4, sort, inst.Offset)})
+	case op >= OP_iaload && op <= OP_saload:
+		index := s.pop()
+		array := s.pop()
+		typ := arrayElementTypes[op-OP_iaload]
+		if t := array.exprType(); strings.HasPrefix(t, "[") {
+			typ = t[1:]
+		} else if op == OP_baload {
+			typ = "B"
+		}
+		s.push(&arrayElemExpr{array: array, index: index, typ: typ})
+	case op >= OP_istore && op <= OP_astore:
+		sort := "IJFDA"[op-OP_istore]
+		m.store(s, m.localVarAt(int(inst.Index), sort, next, inst.Offset), s.pop())
+	case op >= OP_istore_0 && op <= OP_astore_3:
+		sort := "IJFDA"[(op-OP_istore_0)/4]
+		m.store(s, m.localVarAt(int(op-OP_istore_0)%4, sort, next, inst.Offset), s.pop())
+	case op >= OP_iastore && op <= OP_sastore:
+		value := s.pop()
+		index := s.pop()
+		array := s.pop()
+		if arr, ok := array.(*newArrayExpr); ok && s.onStack(arr) {
+			if size, ok := arr.dims[0].(*literalExpr); ok && len(arr.dims) == 1 && size.isInt &&
+				isIntLiteral(index, int64(len(arr.init))) && int64(len(arr.init)) < size.intValue {
+				arr.init = append(arr.init, value)
+				return
+			}
+			for i, e := range s.stack {
+				if e == array {
+					s.spillAt(i)
+					array = s.stack[i]
+					break
+				}
+			}
+		}
+		typ := arrayElementTypes[op-OP_iastore]
+		if t := array.exprType(); strings.HasPrefix(t, "[") {
+			typ = t[1:]
+		}
+		target := &arrayElemExpr{array: array, index: index, typ: typ}
+		s.emit(makeAssign(target, value))
+	case op == OP_pop || op == OP_pop2:
+		e := s.pop()
+		if op == OP_pop2 && category(e) == 1 {
+			m.discard(s, s.pop())
+		}
+		m.discard(s, e)
+	case op == OP_dup:
+		m.dupTop(s, 1)
+		s.push(s.peek())
+	case op == OP_dup_x1:
+		m.dupTop(s, 1)
+		v1, v2 := s.pop(), s.pop()
+		s.push(v1)
+		s.push(v2)
+		s.push(v1)
+	case op == OP_dup_x2:
+		m.dupTop(s, 1)
+		v1, v2 := s.pop(), s.pop()
+		if category(v2) == 2 {
+			s.push(v1)
+			s.push(v2)
+			s.push(v1)
+		} else {
+			v3 := s.pop()
+			s.push(v1)
+			s.push(v3)
+			s.push(v2)
+			s.push(v1)
+		}
+	case op == OP_dup2:
+		if category(s.peek()) == 2 {
+			m.dupTop(s, 1)
+			s.push(s.peek())
+		} else {
+			m.dupTop(s, 2)
+			v1, v2 := s.pop(), s.pop()
+			s.push(v2)
+			s.push(v1)
+			s.push(v2)
+			s.push(v1)
+		}
+	case op == OP_dup2_x1:
+		if category(s.peek()) == 2 {
+			m.dupTop(s, 1)
+			v1, v2 := s.pop(), s.pop()
+			s.push(v1)
+			s.push(v2)
+			s.push(v1)
+		} else {
+			m.dupTop(s, 2)
+			v1, v2, v3 := s.pop(), s.pop(), s.pop()
+			s.push(v2)
+			s.push(v1)
+			s.push(v3)
+			s.push(v2)
+			s.push(v1)
+		}
+	case op == OP_dup2_x2:
+		if category(s.peek()) == 2 {
+			m.dupTop(s, 1)
+			v1, v2 := s.pop(), s.pop()
+			if category(v2) == 2 {
+				s.push(v1)
+				s.push(v2)
+				s.push(v1)
+			} else {
+				v3 := s.pop()
+				s.push(v1)
+				s.push(v3)
+				s.push(v2)
+				s.push(v1)
+			}
+		} else {
+			m.dupTop(s, 2)
+			v1, v2, v3 := s.pop(), s.pop(), s.pop()
+			if category(v3) == 2 {
+				s.push(v2)
+				s.push(v1)
+				s.push(v3)
+				s.push(v2)
+				s.push(v1)
+			} else {
+				v4 := s.pop()
+				s.push(v2)
+				s.push(v1)
+				s.push(v4)
+				s.push(v3)
+				s.push(v2)
+				s.push(v1)
+			}
+		}
+	case op == OP_swap:
+		v1, v2 := s.pop(), s.pop()
+		s.push(v1)
+		s.push(v2)
+	case op >= OP_iadd && op <= OP_drem:
+		right, left := s.pop(), s.pop()
+		s.push(&binaryExpr{op: arithmeticOperators[(op-OP_iadd)/4], left: left, right: right, typ: numericTypes[(op-OP_iadd)%4]})
+	case op >= OP_ineg && op <= OP_dneg:
+		s.push(&unaryExpr{op: "-", operand: s.pop(), typ: numericTypes[op-OP_ineg]})
+	case op >= OP_ishl && op <= OP_lushr:
+		right, left := s.pop(), s.pop()
+		s.push(&binaryExpr{op: []string{"<<", ">>", ">>>"}[(op-OP_ishl)/2], left: left, right: right, typ: numericTypes[(op-OP_ishl)%2]})
+	case op >= OP_iand && op <= OP_lxor:
+		right, left := s.pop(), s.pop()
+		typ := numericTypes[(op-OP_iand)%2]
+		if typ == "I" && left.exprType() == descBoolean && right.exprType() == descBoolean {
+			typ = descBoolean
+		}
+		s.push(&binaryExpr{op: []string{"&", "|", "^"}[(op-OP_iand)/2], left: left, right: right, typ: typ})
+	case op == OP_iinc:
+		m.iinc(s, inst)
+	case op >= OP_i2l && op <= OP_i2s:
+		s.push(&castExpr{typ: castTypes[op-OP_i2l], operand: s.pop()})
+	case op >= OP_lcmp && op <= OP_dcmpg:
+		right, left := s.pop(), s.pop()
+		s.push(&compareResultExpr{left: left, right: right, typ: left.exprType()})
+	case op >= OP_ifeq && op <= OP_ifle:
+		cond := compareWithZero(s.pop(), conditionOperators[op-OP_ifeq])
+		m.branch(s, inst, cond)
+	case op >= OP_if_icmpeq && op <= OP_if_acmpne:
+		right, left := s.pop(), s.pop()
+		operator := conditionOperators[(op-OP_if_icmpeq)%6]
+		if op >= OP_if_acmpeq {
+			operator = conditionOperators[op-OP_if_acmpeq]
+		}
+		m.branch(s, inst, &binaryExpr{op: operator, left: left, right: right, typ: descBoolean})
+	case op == OP_ifnull || op == OP_ifnonnull:
+		operator := "=="
+		if op == OP_ifnonnull {
+			operator = "!="
+		}
+		m.branch(s, inst, &binaryExpr{op: operator, left: s.pop(), right: &literalExpr{text: "null", typ: "null"}, typ: descBoolean})
+	case op == OP_goto || op == OP_goto_w:
+		s.b.term = &terminator{kind: termNext, next: m.blockAt[inst.Target]}
+	case op == OP_tableswitch || op == OP_lookupswitch:
+		term := &terminator{kind: termSwitch, value: s.pop(), keys: inst.Keys, defaultSucc: m.blockAt[inst.Default]}
+		for _, target := range inst.Targets {
+			term.targets = append(term.targets, m.blockAt[target])
+		}
+		s.b.term = term
+	case op >= OP_ireturn && op <= OP_areturn:
+		s.b.term = &terminator{kind: termReturn, value: s.pop()}
+	case op == OP_return:
+		s.b.term = &terminator{kind: termReturn}
+	case op == OP_getstatic:
+		ref := m.memberRef(inst.Index)
+		s.push(&fieldExpr{owner: ref.Owner, name: ref.Name, typ: ref.Desc})
+	case op == OP_putstatic:
+		ref := m.memberRef(inst.Index)
+		s.emit(makeAssign(&fieldExpr{owner: ref.Owner, name: ref.Name, typ: ref.Desc}, s.pop()))
+	case op == OP_getfield:
+		ref := m.memberRef(inst.Index)
+		s.push(&fieldExpr{obj: s.pop(), owner: ref.Owner, name: ref.Name, typ: ref.Desc})
+	case op == OP_putfield:
+		ref := m.memberRef(inst.Index)
+		value := s.pop()
+		s.emit(makeAssign(&fieldExpr{obj: s.pop(), owner: ref.Owner, name: ref.Name, typ: ref.Desc}, value))
+	case op >= OP_invokevirtual && op <= OP_invokeinterface:
+		m.invoke(s, inst)
+	case op == OP_invokedynamic:
+		m.invokeDynamic(s, inst)
+	case op == OP_new:
+		s.push(&newExpr{class: m.className(inst.Index)})
+	case op == OP_newarray:
+		desc, ok := newArrayDescriptors[uint8(inst.Value)]
+		if !ok {
+			panic(fmt.Sprintf("invalid newarray type %d", inst.Value))
+		}
+		s.push(&newArrayExpr{typ: "[" + desc, dims: []javaExpr{s.pop()}})
+	case op == OP_anewarray:
+		s.push(&newArrayExpr{typ: "[" + classDescriptor(m.className(inst.Index)), dims: []javaExpr{s.pop()}})
+	case op == OP_multianewarray:
+		dims := s.popN(int(inst.Value))
+		s.push(&newArrayExpr{typ: m.className(inst.Index), dims: dims})
+	case op == OP_arraylength:
+		s.push(&arrayLengthExpr{array: s.pop()})
+	case op == OP_athrow:
+		s.b.term = &terminator{kind: termThrow, value: s.pop()}
+	case op == OP_checkcast:
+		typ, operand := classDescriptor(m.className(inst.Index)), s.pop()
+		if cast, ok := operand.(*castExpr); ok && cast.typ == typ {
+			s.push(cast)
+			break
+		}
+		s.push(&castExpr{typ: typ, operand: operand})
+	case op == OP_instanceof:
+		s.push(&instanceOfExpr{operand: s.pop(), typ: classDescriptor(m.className(inst.Index))})
+	case op == OP_monitorenter || op == OP_monitorexit:
+		s.emit(&monitorStmt{enter: op == OP_monitorenter, lock: s.pop()})
+	default:
+		panic(fmt.Sprintf("unsupported instruction %s", inst.Name()))
+	}
+}
+
+// dupTop 复制栈顶之前，把不能重复求值的表达式保存到临时变量
+func (m *methodDecompiler) dupTop(s *execState, n int) {
+	for i := len(s.stack) - n; i < len(s.stack); i++ {
+		if i < 0 {
+			panic(fmt.Sprintf("stack underflow at block %d", s.b.start))
+		}
+		e := s.stack[i]
+		if _, isArray := e.(*newArrayExpr); isArray || isStableExpr(e) {
+			continue
+		}
+		s.spillAt(i)
+	}
+}
+
+// discard 处理被 pop 丢弃的值，有副作用时保留为语句
+func (m *methodDecompiler) discard(s *execState, e javaExpr) {
+	switch ret := e.(type) {
+	case *invokeExpr, *incExpr:
+		s.emit(&exprStmt{expr: e})
+	case *newExpr:
+		if ret.initialized {
+			s.emit(&exprStmt{expr: e})
+		}
+	default:
+		if hasSideEffects(e) {
+			s.spill(readsMemory)
+			v := m.newSyntheticVar("", e.exprType(), varLocal)
+			v.typ = e.exprType()
+			if v.typ == "" || v.typ == "null" {
+				v.typ = descObject
+			}
+			v.name = "unused"
+			s.b.stmts = append(s.b.stmts, &assignStmt{target: &localExpr{v: v}, value: e})
+		}
+	}
+}
+
+func (m *methodDecompiler) branch(s *execState, inst *Instruction, cond javaExpr) {
+	target := m.blockAt[inst.Target]
+	next := m.blockAt[inst.Offset+inst.Size()]
+	if target == next {
+		s.b.term = &terminator{kind: termNext, next: next}
+		return
+	}
+	s.b.term = &terminator{kind: termCond, value: cond, trueSucc: target, falseSucc: next}
+}
+
+func (m *methodDecompiler) iinc(s *execState, inst *Instruction) {
+	v := m.localVarAt(int(inst.Index), 'I', inst.Offset)
+	delta := int64(inst.Value)
+	if n := len(s.stack); n > 0 && (delta == 1 || delta == -1) {
+		if l, ok := s.stack[n-1].(*localExpr); ok && l.v == v {
+			s.stack = s.stack[:n-1]
+			s.spill(func(e javaExpr) bool {
+				return exprUsesLocal(e, v)
+			})
+			op := "++"
+			if delta < 0 {
+				op = "--"
+			}
+			s.push(&incExpr{target: &localExpr{v: v}, op: op})
+			return
+		}
+	}
+	s.spill(func(e javaExpr) bool {
+		return exprUsesLocal(e, v)
+	})
+	switch {
+	case delta == 1:
+		s.b.stmts = append(s.b.stmts, &exprStmt{expr: &incExpr{target: &localExpr{v: v}, op: "++"}})
+	case delta == -1:
+		s.b.stmts = append(s.b.stmts, &exprStmt{expr: &incExpr{target: &localExpr{v: v}, op: "--"}})
+	case delta < 0:
+		s.b.stmts = append(s.b.stmts, &assignStmt{target: &localExpr{v: v}, value: intLiteral(-delta), op: "-="})
+	default:
+		s.b.stmts = append(s.b.stmts, &assignStmt{target: &localExpr{v: v}, value: intLiteral(delta), op: "+="})
+	}
+}
+
+func isStringBuilder(class string) bool {
+	return class == "java/lang/StringBuilder" || class == "java/lang/StringBuffer"
+}
+
+// builderConcat 把 new StringBuilder().append(a).append(b).toString() 还原为字符串拼接
+func builderConcat(e javaExpr) *concatExpr {
+	var parts []*concatPart
+	for {
+		switch ret := e.(type) {
+		case *invokeExpr:
+			if ret.name != "append" || !isStringBuilder(ret.owner) || len(ret.args) != 1 || ret.params[0] == "[C" || ret.obj == nil {
+				return nil
+			}
+			parts = append([]*concatPart{{expr: ret.args[0], typ: ret.params[0]}}, parts...)
+			e = ret.obj
+		case *newExpr:
+			if !isStringBuilder(ret.class) || !ret.initialized {
+				return nil
+			}
+			switch {
+			case len(ret.params) == 0:
+			case len(ret.params) == 1 && ret.params[0] == descString:
+				parts = append([]*concatPart{{expr: ret.args[0], typ: descString}}, parts...)
+			default:
+				return nil
+			}
+			if len(parts) == 0 {
+				parts = append(parts, &concatPart{expr: &literalExpr{text: `""`, typ: descString}, typ: descString})
+			}
+			return &concatExpr{parts: parts}
+		default:
+			return nil
+		}
+	}
+}
+
+func (m *methodDecompiler) invoke(s *execState, inst *Instruction) {
+	ref := m.memberRef(inst.Index)
+	params, ret, err := parseMethodDescriptor(ref.Desc)
+	if err != nil {
+		panic(err.Error())
+	}
+	args := s.popN(len(params))
+	var obj javaExpr
+	if inst.Opcode != OP_invokestatic {
+		obj = s.pop()
+	}
+	if inst.Opcode == OP_invokespecial && ref.Name == "<init>" {
+		if n, ok := obj.(*newExpr); ok && !n.initialized {
+			n.params, n.args, n.initialized = params, args, true
+			if !s.onStack(n) {
+				s.emit(&exprStmt{expr: n})
+			}
+			return
+		}
+		if len(args) == 0 && ref.Owner != m.d.name {
+			// 调用父类的无参构造方法可以省略
+			return
+		}
+		keyword := "super"
+		if ref.Owner == m.d.name {
+			keyword = "this"
+		}
+		s.emit(&exprStmt{expr: &constructorCallExpr{keyword: keyword, params: params, args: args}})
+		return
+	}
+	call := &invokeExpr{obj: obj, owner: ref.Owner, name: ref.Name, params: params, ret: ret, args: args}
+	if inst.Opcode == OP_invokespecial && ref.Owner != m.d.name {
+		if l, ok := obj.(*localExpr); ok && l.v == m.thisVar && m.thisVar != nil {
+			call.super = true
+		}
+	}
+	if ref.Name == "toString" && len(args) == 0 && isStringBuilder(ref.Owner) {
+		if concat := builderConcat(obj); concat != nil {
+			s.push(concat)
+			return
+		}
+	}
+	if ret == "V" {
+		s.emit(&exprStmt{expr: call})
+		return
+	}
+	s.push(call)
+}
+
+func (m *methodDecompiler) methodHandle(index uint16) (uint8, *memberRef) {
+	info, err := m.obj.getConstantInfo(index)
+	if err != nil {
+		panic(err.Error())
+	}
+	handle, ok := info.(*ConstantMethodHandleInfo)
+	if !ok {
+		panic(fmt.Sprintf("index %d is not ConstantMethodHandleInfo", index))
+	}
+	return handle.ReferenceKind, m.memberRef(handle.ReferenceIndex)
+}
+
+func (m *methodDecompiler) invokeDynamic(s *execState, inst *Instruction) {
+	info, err := m.obj.getConstantInfo(inst.Index)
+	if err != nil {
+		panic(err.Error())
+	}
+	indy, ok := info.(*ConstantInvokeDynamicInfo)
+	if !ok {
+		panic(fmt.Sprintf("index %d is not ConstantInvokeDynamicInfo", inst.Index))
+	}
+	name, desc, err := m.obj.getNameAndType(indy.NameAndTypeIndex)
+	if err != nil {
+		panic(err.Error())
+	}
+	params, ret, err := parseMethodDescriptor(desc)
+	if err != nil {
+		panic(err.Error())
+	}
+	bootstrapMethods := m.obj.getBootstrapMethods()
+	if int(indy.BootstrapMethodAttrIndex) >= len(bootstrapMethods) {
+		panic(fmt.Sprintf("bootstrap method %d not found", indy.BootstrapMethodAttrIndex))
+	}
+	bootstrap := bootstrapMethods[indy.BootstrapMethodAttrIndex]
+	_, bsm := m.methodHandle(bootstrap.BootstrapMethodRef)
+	args := s.popN(len(params))
+
+	var result javaExpr
+	switch {
+	case bsm.Owner == "java/lang/invoke/LambdaMetafactory" && len(bootstrap.BootstrapArguments) >= 3:
+		result = m.lambda(s, bootstrap, args)
+	case bsm.Owner == "java/lang/invoke/StringConcatFactory":
+		result = m.stringConcat(bsm.Name, bootstrap, params, args)
+	}
+	if result == nil {
+		result = &invokeExpr{owner: bsm.Owner, name: name, params: params, ret: ret, args: args}
+	}
+	if ret == "V" {
+		s.emit(&exprStmt{expr: result})
+		return
+	}
+	s.push(result)
+}
+
+// lambda 还原 LambdaMetafactory 生成的函数式接口对象，没有捕获值时使用方法引用
+func (m *methodDecompiler) lambda(s *execState, bootstrap *BootstrapMethod, captured []javaExpr) javaExpr {
+	kind, impl := m.methodHandle(bootstrap.BootstrapArguments[1])
+	if len(captured) == 0 {
+		if kind == 8 {
+			return &methodRefExpr{owner: impl.Owner, name: "new"}
+		}
+		return &methodRefExpr{owner: impl.Owner, name: impl.Name}
+	}
+	info, err := m.obj.getConstantInfo(bootstrap.BootstrapArguments[2])
+	if err != nil {
+		panic(err.Error())
+	}
+	methodType, ok := info.(*ConstantMethodTypeInfo)
+	if !ok {
+		return nil
+	}
+	samDesc, err := m.obj.getUtf8Value(methodType.DescriptorIndex)
+	if err != nil {
+		panic(err.Error())
+	}
+	samParams, _, err := parseMethodDescriptor(samDesc)
+	if err != nil {
+		panic(err.Error())
+	}
+	implParams, implRet, err := parseMethodDescriptor(impl.Desc)
+	if err != nil {
+		panic(err.Error())
+	}
+	// 捕获的值在创建 lambda 时求值，不能直接放到 lambda 中
+	for i, e := range captured {
+		if isStableExpr(e) {
+			continue
+		}
+		v := m.newSyntheticVar("", e.exprType(), varCapture)
+		s.emit(&assignStmt{target: &localExpr{v: v}, value: e})
+		captured[i] = &localExpr{v: v}
+	}
+	lambda := &lambdaExpr{}
+	args := append([]javaExpr{}, captured...)
+	for _, p := range samParams {
+		v := m.newSyntheticVar("", p, varLambda)
+		v.param = true
+		lambda.params = append(lambda.params, v)
+		args = append(args, &localExpr{v: v})
+	}
+	switch kind {
+	case 6:
+		lambda.body = &invokeExpr{owner: impl.Owner, name: impl.Name, params: implParams, ret: implRet, args: args}
+	case 8:
+		lambda.body = &newExpr{class: impl.Owner, params: implParams, args: args, initialized: true}
+	default:
+		lambda.body = &invokeExpr{obj: args[0], owner: impl.Owner, name: impl.Name, params: implParams, ret: implRet, args: args[1:]}
+	}
+	return lambda
+}
+
+// stringConcat 还原 StringConcatFactory 生成的字符串拼接，\u0001 是参数，\u0002 是常量
+func (m *methodDecompiler) stringConcat(name string, bootstrap *BootstrapMethod, params []string, args []javaExpr) javaExpr {
+	concat := &concatExpr{}
+	if name != "makeConcatWithConstants" {
+		for i, arg := range args {
+			concat.parts = append(concat.parts, &concatPart{expr: arg, typ: params[i]})
+		}
+	} else {
+		if len(bootstrap.BootstrapArguments) == 0 {
+			return nil
+		}
+		info, err := m.obj.getConstantInfo(bootstrap.BootstrapArguments[0])
+		if err != nil {
+			panic(err.Error())
+		}
+		recipe, ok := info.(*ConstantStringInfo)
+		if !ok {
+			return nil
+		}
+		value, err := m.obj.getUtf8Value(recipe.StringIndex)
+		if err != nil {
+			panic(err.Error())
+		}
+		var literal strings.Builder
+		flush := func() {
+			if literal.Len() > 0 {
+				concat.parts = append(concat.parts, &concatPart{expr: &literalExpr{text: javaStringLiteral(literal.String()), typ: descString}, typ: descString})
+				literal.Reset()
+			}
+		}
+		argIndex, constIndex := 0, 1
+		for _, r := range value {
+			switch r {
+			case '\u0001':
+				if argIndex >= len(args) {
+					return nil
+				}
+				flush()
+				concat.parts = append(concat.parts, &concatPart{expr: args[argIndex], typ: params[argIndex]})
+				argIndex++
+			case '\u0002':
+				if constIndex >= len(bootstrap.BootstrapArguments) {
+					return nil
+				}
+				flush()
+				c := m.constant(bootstrap.BootstrapArguments[constIndex])
+				concat.parts = append(concat.parts, &concatPart{expr: c, typ: c.exprType()})
+				constIndex++
+			default:
+				literal.WriteRune(r)
+			}
+		}
+		flush()
+	}
+	if len(concat.parts) == 0 {
+		return &literalExpr{text: `""`, typ: descString}
+	}
+	return concat
+}
+
+func (b *basicBlock) isForwarder() bool {
+	return len(b.stmts) == 0 && b.term.kind == termNext && !b.isHandler && len(b.entryVars) == 0 && len(b.exit) == 0
+}
+
+// resolveForwarder 跳过只包含 goto 的基本块
+func resolveForwarder(b *basicBlock) *basicBlock {
+	seen := make(map[*basicBlock]bool)
+	for b.isForwarder() && !seen[b] {
+		seen[b] = true
+		b = b.term.next
+	}
+	return b
+}
+
+// skipEmptyBlocks 让跳转直接指向只包含 goto 的基本块的目标
+func (m *methodDecompiler) skipEmptyBlocks() {
+	for _, b := range m.order {
+		term := b.term
+		switch term.kind {
+		case termNext:
+			term.next = resolveForwarder(term.next)
+		case termCond:
+			term.trueSucc, term.falseSucc = resolveForwarder(term.trueSucc), resolveForwarder(term.falseSucc)
+			if term.trueSucc == term.falseSucc && !hasSideEffects(term.value) {
+				b.term = &terminator{kind: termNext, next: term.trueSucc}
+			}
+		case termSwitch:
+			for i, target := range term.targets {
+				term.targets[i] = resolveForwarder(target)
+			}
+			term.defaultSucc = resolveForwarder(term.defaultSucc)
+		}
+	}
+	m.entry = resolveForwarder(m.entry)
+	m.refreshGraph()
+}
+
+// mergeConditions 把只包含条件判断的基本块合并到前驱的条件中，还原 && 和 ||
+func (m *methodDecompiler) mergeConditions() {
+	for m.mergeOnce() {
+		m.refreshGraph()
+	}
+}
+
+func (m *methodDecompiler) mergeOnce() bool {
+	for _, a := range m.order {
+		if a.term.kind != termCond {
+			continue
+		}
+		for _, s := range []*basicBlock{a.term.falseSucc, a.term.trueSucc} {
+			if m.tryMerge(a, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *methodDecompiler) tryMerge(a, s *basicBlock) bool {
+	if s == a || s.isHandler || len(s.stmts) > 0 || s.term.kind != termCond || len(s.preds) != 1 || s.preds[0] != a {
+		return false
+	}
+	if len(s.entryVars) > 0 || len(s.handlers) != len(a.handlers) {
+		return false
+	}
+	for i := range s.handlers {
+		if s.handlers[i] != a.handlers[i] {
+			return false
+		}
+	}
+	ca, ta, fa := a.term.value, a.term.trueSucc, a.term.falseSucc
+	cs, ts, fs := s.term.value, s.term.trueSucc, s.term.falseSucc
+	if ts == fs || ts == s || fs == s {
+		return false
+	}
+	var cond javaExpr
+	switch {
+	case s == fa && ta == ts:
+		cond = &binaryExpr{op: "||", left: ca, right: cs, typ: descBoolean}
+	case s == fa && ta == fs:
+		cond = &binaryExpr{op: "&&", left: negateCondition(ca), right: cs, typ: descBoolean}
+	case s == ta && fa == fs:
+		cond = &binaryExpr{op: "&&", left: ca, right: cs, typ: descBoolean}
+	case s == ta && fa == ts:
+		cond = &binaryExpr{op: "||", left: negateCondition(ca), right: cs, typ: descBoolean}
+	default:
+		return false
+	}
+	a.term = &terminator{kind: termCond, value: cond, trueSucc: ts, falseSucc: fs}
+	s.executed = false
+	return true
+}
+
+// refreshGraph 根据基本块的 terminator 重新计算控制流边和逆后序
+func (m *methodDecompiler) refreshGraph() {
+	for _, b := range m.order {
+		b.succs = b.term.successors()
+	}
+	var blocks []*basicBlock
+	for _, b := range m.blocks {
+		if b.executed {
+			blocks = append(blocks, b)
+		}
+	}
+	m.blocks = blocks
+	m.computeOrder()
+}
@@ -0,0 +1,691 @@
+package javaclassparser
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type javaStmt interface{}
+
+type exprStmt struct {
+	expr javaExpr
+}
+
+// assignStmt 是赋值语句，op 为空表示普通赋值，declare 表示同时声明局部变量
+type assignStmt struct {
+	target  javaExpr
+	value   javaExpr
+	op      string
+	declare bool
+}
+
+// declStmt 声明局部变量并使用默认值初始化
+type declStmt struct {
+	v *localVar
+}
+
+type returnStmt struct {
+	value javaExpr
+}
+
+type throwStmt struct {
+	value javaExpr
+}
+
+type commentStmt struct {
+	text string
+}
+
+type monitorStmt struct {
+	enter bool
+	lock  javaExpr
+}
+
+// stmtLabel 是 break、continue 可以跳转到的结构，只有被带标签的跳转使用时才会输出
+type stmtLabel struct {
+	name string
+}
+
+type ifStmt struct {
+	cond  javaExpr
+	then  []javaStmt
+	els   []javaStmt
+	label *stmtLabel
+}
+
+type loopStmt struct {
+	cond    javaExpr // 为 nil 表示 while (true)
+	doWhile bool
+	body    []javaStmt
+	label   *stmtLabel
+}
+
+type switchCase struct {
+	keys      []int32
+	isDefault bool
+	body      []javaStmt
+}
+
+type switchStmt struct {
+	value javaExpr
+	cases []*switchCase
+	label *stmtLabel
+}
+
+type catchClause struct {
+	types []string
+	v     *localVar
+	body  []javaStmt
+}
+
+type tryStmt struct {
+	body    []javaStmt
+	catches []*catchClause
+	label   *stmtLabel
+}
+
+type breakStmt struct {
+	label   *stmtLabel
+	labeled bool
+}
+
+type continueStmt struct {
+	label   *stmtLabel
+	labeled bool
+}
+
+// stmtExprs 返回语句自身包含的表达式，不包括嵌套的语句块
+func stmtExprs(s javaStmt) []*javaExpr {
+	switch ret := s.(type) {
+	case *exprStmt:
+		return []*javaExpr{&ret.expr}
+	case *assignStmt:
+		return []*javaExpr{&ret.target, &ret.value}
+	case *returnStmt:
+		if ret.value != nil {
+			return []*javaExpr{&ret.value}
+		}
+	case *throwStmt:
+		return []*javaExpr{&ret.value}
+	case *monitorStmt:
+		return []*javaExpr{&ret.lock}
+	case *ifStmt:
+		return []*javaExpr{&ret.cond}
+	case *loopStmt:
+		if ret.cond != nil {
+			return []*javaExpr{&ret.cond}
+		}
+	case *switchStmt:
+		return []*javaExpr{&ret.value}
+	}
+	return nil
+}
+
+// stmtBodies 返回语句中嵌套的语句块
+func stmtBodies(s javaStmt) []*[]javaStmt {
+	switch ret := s.(type) {
+	case *ifStmt:
+		return []*[]javaStmt{&ret.then, &ret.els}
+	case *loopStmt:
+		return []*[]javaStmt{&ret.body}
+	case *switchStmt:
+		var result []*[]javaStmt
+		for _, c := range ret.cases {
+			result = append(result, &c.body)
+		}
+		return result
+	case *tryStmt:
+		result := []*[]javaStmt{&ret.body}
+		for _, c := range ret.catches {
+			result = append(result, &c.body)
+		}
+		return result
+	}
+	return nil
+}
+
+// walkStmts 遍历所有语句，包括嵌套的语句块
+func walkStmts(stmts []javaStmt, visit func(javaStmt)) {
+	for _, s := range stmts {
+		visit(s)
+		for _, body := range stmtBodies(s) {
+			walkStmts(*body, visit)
+		}
+	}
+}
+
+func walkStmtExprs(stmts []javaStmt, visit func(javaExpr)) {
+	walkStmts(stmts, func(s javaStmt) {
+		for _, e := range stmtExprs(s) {
+			walkExpr(*e, func(e javaExpr) bool {
+				visit(e)
+				return true
+			})
+		}
+	})
+}
+
+func isJumpTo(s javaStmt, label *stmtLabel, isContinue bool) bool {
+	switch ret := s.(type) {
+	case *breakStmt:
+		return !isContinue && ret.label == label
+	case *continueStmt:
+		return isContinue && ret.label == label
+	}
+	return false
+}
+
+func containsJumpTo(stmts []javaStmt, label *stmtLabel, isContinue bool) bool {
+	found := false
+	walkStmts(stmts, func(s javaStmt) {
+		if isJumpTo(s, label, isContinue) {
+			found = true
+		}
+	})
+	return found
+}
+
+// removeTailJumps 删除位于语句块末尾、跳转目标就是自然执行位置的 break 和 continue
+func removeTailJumps(stmts []javaStmt, breaks []*stmtLabel, cont *stmtLabel) []javaStmt {
+	for i, s := range stmts {
+		var tailBreaks []*stmtLabel
+		var tailCont *stmtLabel
+		if i == len(stmts)-1 {
+			tailBreaks, tailCont = breaks, cont
+		}
+		own := func(label *stmtLabel) []*stmtLabel {
+			return append(append([]*stmtLabel{}, tailBreaks...), label)
+		}
+		switch ret := s.(type) {
+		case *ifStmt:
+			ret.then = removeTailJumps(ret.then, own(ret.label), tailCont)
+			ret.els = removeTailJumps(ret.els, own(ret.label), tailCont)
+		case *tryStmt:
+			ret.body = removeTailJumps(ret.body, own(ret.label), tailCont)
+			for _, c := range ret.catches {
+				c.body = removeTailJumps(c.body, own(ret.label), tailCont)
+			}
+		case *loopStmt:
+			ret.body = removeTailJumps(ret.body, nil, ret.label)
+		case *switchStmt:
+			for j, c := range ret.cases {
+				if j == len(ret.cases)-1 {
+					c.body = removeTailJumps(c.body, own(ret.label), tailCont)
+				} else {
+					c.body = removeTailJumps(c.body, nil, nil)
+				}
+			}
+		}
+	}
+	if n := len(stmts); n > 0 {
+		last := stmts[n-1]
+		if tail, ok := last.(*ifStmt); ok && len(tail.then) == 0 && len(tail.els) == 0 && !hasSideEffects(tail.cond) {
+			return removeTailJumps(stmts[:n-1], breaks, cont)
+		}
+		for _, label := range breaks {
+			if isJumpTo(last, label, false) {
+				return stmts[:n-1]
+			}
+		}
+		if cont != nil && isJumpTo(last, cont, true) {
+			return stmts[:n-1]
+		}
+	}
+	return stmts
+}
+
+// convertTernary 把分别给同一个临时变量赋值的 if/else 转换为条件表达式
+func convertTernary(stmts []javaStmt) bool {
+	changed := false
+	for i, s := range stmts {
+		for _, body := range stmtBodies(s) {
+			if convertTernary(*body) {
+				changed = true
+			}
+		}
+		ifs, ok := s.(*ifStmt)
+		if !ok || len(ifs.then) != 1 || len(ifs.els) != 1 {
+			continue
+		}
+		a, ok1 := ifs.then[0].(*assignStmt)
+		b, ok2 := ifs.els[0].(*assignStmt)
+		if !ok1 || !ok2 || a.op != "" || b.op != "" {
+			continue
+		}
+		la, ok1 := a.target.(*localExpr)
+		lb, ok2 := b.target.(*localExpr)
+		if !ok1 || !ok2 || la.v != lb.v || !la.v.isTemp() {
+			continue
+		}
+		stmts[i] = &assignStmt{target: a.target, value: &conditionalExpr{cond: ifs.cond, then: a.value, els: b.value}}
+		changed = true
+	}
+	return changed
+}
+
+// inlineTemps 把只赋值一次并且只在下一条语句中使用一次的临时变量内联
+func inlineTemps(root *[]javaStmt) bool {
+	uses := make(map[*localVar]int)
+	assigns := make(map[*localVar]int)
+	walkStmts(*root, func(s javaStmt) {
+		target := javaExpr(nil)
+		if assign, ok := s.(*assignStmt); ok {
+			if l, ok := assign.target.(*localExpr); ok {
+				assigns[l.v]++
+				if assign.op != "" {
+					uses[l.v]++
+				}
+				target = assign.target
+			}
+		}
+		for _, e := range stmtExprs(s) {
+			if *e == target {
+				continue
+			}
+			walkExpr(*e, func(e javaExpr) bool {
+				if l, ok := e.(*localExpr); ok {
+					uses[l.v]++
+				}
+				return true
+			})
+		}
+	})
+
+	changed := false
+	var process func(list *[]javaStmt)
+	process = func(list *[]javaStmt) {
+		for i := 0; i < len(*list); i++ {
+			for _, body := range stmtBodies((*list)[i]) {
+				process(body)
+			}
+		}
+		for i := len(*list) - 2; i >= 0; i-- {
+			assign, ok := (*list)[i].(*assignStmt)
+			if !ok || assign.op != "" {
+				continue
+			}
+			l, ok := assign.target.(*localExpr)
+			if !ok || !l.v.isTemp() || assigns[l.v] != 1 || uses[l.v] != 1 {
+				continue
+			}
+			next := (*list)[i+1]
+			if _, isLoop := next.(*loopStmt); isLoop {
+				continue
+			}
+			var exprs []*javaExpr
+			if nextAssign, ok := next.(*assignStmt); ok {
+				if _, isLocal := nextAssign.target.(*localExpr); isLocal {
+					exprs = []*javaExpr{&nextAssign.value}
+				} else {
+					exprs = stmtExprs(next)
+				}
+			} else {
+				exprs = stmtExprs(next)
+			}
+			found := false
+			for _, e := range exprs {
+				if exprUsesLocal(*e, l.v) {
+					*e = replaceExpr(*e, func(e javaExpr) javaExpr {
+						if local, ok := e.(*localExpr); ok && local.v == l.v {
+							return assign.value
+						}
+						return e
+					})
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			changed = true
+		}
+	}
+	process(root)
+	return changed
+}
+
+// simplifyConditions 化简条件中与 0 比较的布尔表达式
+func simplifyConditions(stmts []javaStmt) {
+	simplify := func(e javaExpr) javaExpr {
+		return replaceExpr(e, func(e javaExpr) javaExpr {
+			if b, ok := e.(*binaryExpr); ok && (b.op == "==" || b.op == "!=") && isIntLiteral(b.right, 0) {
+				if _, isLiteral := b.left.(*literalExpr); !isLiteral && isBooleanExpr(b.left) {
+					return compareWithZero(b.left, b.op)
+				}
+			}
+			return e
+		})
+	}
+	walkStmts(stmts, func(s javaStmt) {
+		for _, e := range stmtExprs(s) {
+			*e = simplify(*e)
+		}
+	})
+}
+
+type stmtListNode struct {
+	parent *stmtListNode
+	// 在父语句块中包含该语句块的语句的下标
+	parentIndex int
+	depth       int
+	list        *[]javaStmt
+}
+
+type varOccurrence struct {
+	node  *stmtListNode
+	index int
+}
+
+// declareLocals 在包含变量所有使用位置的最内层语句块中声明变量
+func declareLocals(root *[]javaStmt) {
+	occurrences := make(map[*localVar][]varOccurrence)
+	var order []*localVar
+	var walk func(list *[]javaStmt, node *stmtListNode, scoped map[*localVar]bool)
+	walk = func(list *[]javaStmt, node *stmtListNode, scoped map[*localVar]bool) {
+		for i, s := range *list {
+			for _, e := range stmtExprs(s) {
+				walkExpr(*e, func(e javaExpr) bool {
+					if l, ok := e.(*localExpr); ok && !l.v.param && !scoped[l.v] {
+						if _, ok := occurrences[l.v]; !ok {
+							order = append(order, l.v)
+						}
+						occurrences[l.v] = append(occurrences[l.v], varOccurrence{node: node, index: i})
+					}
+					return true
+				})
+			}
+			visitBody := func(body *[]javaStmt, scope map[*localVar]bool) {
+				walk(body, &stmtListNode{parent: node, parentIndex: i, depth: node.depth + 1, list: body}, scope)
+			}
+			if try, ok := s.(*tryStmt); ok {
+				visitBody(&try.body, scoped)
+				for _, c := range try.catches {
+					scope := make(map[*localVar]bool, len(scoped)+1)
+					for k := range scoped {
+						scope[k] = true
+					}
+					scope[c.v] = true
+					visitBody(&c.body, scope)
+				}
+				continue
+			}
+			for _, body := range stmtBodies(s) {
+				visitBody(body, scoped)
+			}
+		}
+	}
+	walk(root, &stmtListNode{list: root}, map[*localVar]bool{})
+
+	type insertion struct {
+		index int
+		v     *localVar
+	}
+	inserts := make(map[*stmtListNode][]insertion)
+	for _, v := range order {
+		occs := occurrences[v]
+		lca := occs[0].node
+		for _, occ := range occs[1:] {
+			a, b := lca, occ.node
+			for a.depth > b.depth {
+				a = a.parent
+			}
+			for b.depth > a.depth {
+				b = b.parent
+			}
+			for a != b {
+				a, b = a.parent, b.parent
+			}
+			lca = a
+		}
+		first := -1
+		for _, occ := range occs {
+			node, index := occ.node, occ.index
+			for node != lca {
+				node, index = node.parent, node.parentIndex
+			}
+			if first < 0 || index < first {
+				first = index
+			}
+		}
+		if assign, ok := (*lca.list)[first].(*assignStmt); ok && assign.op == "" && !assign.declare {
+			if l, ok := assign.target.(*localExpr); ok && l.v == v && !exprUsesLocal(assign.value, v) {
+				assign.declare = true
+				continue
+			}
+		}
+		inserts[lca] = append(inserts[lca], insertion{index: first, v: v})
+	}
+	for node, items := range inserts {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].index > items[j].index
+		})
+		list := *node.list
+		for _, item := range items {
+			list = append(list[:item.index], append([]javaStmt{&declStmt{v: item.v}}, list[item.index:]...)...)
+		}
+		*node.list = list
+	}
+}
+
+// fixCatchVariables 异常变量在 catch 之外也被使用时，为 catch 使用新的变量
+func (m *methodDecompiler) fixCatchVariables(root []javaStmt) {
+	outside := make(map[*localVar]bool)
+	var walk func(stmts []javaStmt, scoped map[*localVar]bool)
+	walk = func(stmts []javaStmt, scoped map[*localVar]bool) {
+		for _, s := range stmts {
+			for _, e := range stmtExprs(s) {
+				walkExpr(*e, func(e javaExpr) bool {
+					if l, ok := e.(*localExpr); ok && !scoped[l.v] {
+						outside[l.v] = true
+					}
+					return true
+				})
+			}
+			if try, ok := s.(*tryStmt); ok {
+				walk(try.body, scoped)
+				for _, c := range try.catches {
+					if scoped[c.v] {
+						// 嵌套的 catch 不能重复声明同一个变量
+						outside[c.v] = true
+					}
+					scope := map[*localVar]bool{c.v: true}
+					for k := range scoped {
+						scope[k] = true
+					}
+					walk(c.body, scope)
+				}
+				continue
+			}
+			for _, body := range stmtBodies(s) {
+				walk(*body, scoped)
+			}
+		}
+	}
+	walk(root, map[*localVar]bool{})
+	walkStmts(root, func(s javaStmt) {
+		try, ok := s.(*tryStmt)
+		if !ok {
+			return
+		}
+		for _, c := range try.catches {
+			if !outside[c.v] || c.v.param {
+				continue
+			}
+			fresh := m.newSyntheticVar(c.v.name, c.v.typ, varCatch)
+			c.body = append([]javaStmt{&assignStmt{target: &localExpr{v: c.v}, value: &localExpr{v: fresh}}}, c.body...)
+			c.v = fresh
+		}
+	})
+}
+
+// markLabels 找出需要输出的标签
+func markLabels(stmts []javaStmt) map[*stmtLabel]bool {
+	used := make(map[*stmtLabel]bool)
+	walkStmts(stmts, func(s javaStmt) {
+		switch ret := s.(type) {
+		case *breakStmt:
+			if ret.labeled {
+				used[ret.label] = true
+			}
+		case *continueStmt:
+			if ret.labeled {
+				used[ret.label] = true
+			}
+		}
+	})
+	return used
+}
+
+type stmtWriter struct {
+	d      *classDecompiler
+	buf    bytes.Buffer
+	labels map[*stmtLabel]bool
+	// ret 是当前方法的返回值类型
+	ret string
+}
+
+func (w *stmtWriter) line(indent int, text string) {
+	w.buf.WriteString(strings.Repeat("    ", indent))
+	w.buf.WriteString(text)
+	w.buf.WriteByte('\n')
+}
+
+func (w *stmtWriter) labelPrefix(label *stmtLabel) string {
+	if label != nil && w.labels[label] {
+		return label.name + ": "
+	}
+	return ""
+}
+
+func (w *stmtWriter) block(indent int, header string, body []javaStmt, footer string) {
+	w.line(indent, header+" {")
+	w.stmts(indent+1, body)
+	w.line(indent, "}"+footer)
+}
+
+func (w *stmtWriter) stmts(indent int, stmts []javaStmt) {
+	for _, s := range stmts {
+		w.stmt(indent, s)
+	}
+}
+
+func (w *stmtWriter) stmt(indent int, s javaStmt) {
+	d := w.d
+	switch ret := s.(type) {
+	case *exprStmt:
+		w.line(indent, d.render(ret.expr, precAssign)+";")
+	case *assignStmt:
+		typ := ret.target.exprType()
+		target := d.render(ret.target, precPrimary)
+		if ret.declare {
+			target = d.typeName(typ) + " " + target
+		}
+		op := "="
+		if ret.op != "" {
+			op = ret.op
+		}
+		w.line(indent, target+" "+op+" "+d.renderAs(ret.value, typ, precAssign)+";")
+	case *declStmt:
+		w.line(indent, d.typeName(ret.v.typ)+" "+ret.v.name+" = "+d.renderAs(zeroValue(ret.v.typ), ret.v.typ, precAssign)+";")
+	case *returnStmt:
+		if ret.value == nil {
+			w.line(indent, "return;")
+		} else {
+			w.line(indent, "return "+d.renderAs(ret.value, w.ret, precAssign)+";")
+		}
+	case *throwStmt:
+		w.line(indent, "throw "+d.render(ret.value, precAssign)+";")
+	case *commentStmt:
+		w.line(indent, "// "+ret.text)
+	case *monitorStmt:
+		name := "monitorexit"
+		if ret.enter {
+			name = "monitorenter"
+		}
+		w.line(indent, "// "+name+"("+d.render(ret.lock, precAssign)+")")
+	case *ifStmt:
+		prefix := w.labelPrefix(ret.label)
+		cond := d.renderAs(ret.cond, descBoolean, precAssign)
+		if len(ret.els) == 0 {
+			w.block(indent, prefix+"if ("+cond+")", ret.then, "")
+			return
+		}
+		w.block(indent, prefix+"if ("+cond+")", ret.then, "")
+		for {
+			if len(ret.els) == 1 {
+				if next, ok := ret.els[0].(*ifStmt); ok && !w.labels[next.label] {
+					cond := d.renderAs(next.cond, descBoolean, precAssign)
+					w.buf.Truncate(w.buf.Len() - 1)
+					w.buf.WriteString(" else if (" + cond + ") {\n")
+					w.stmts(indent+1, next.then)
+					w.line(indent, "}")
+					if len(next.els) == 0 {
+						return
+					}
+					ret = next
+					continue
+				}
+			}
+			w.buf.Truncate(w.buf.Len() - 1)
+			w.buf.WriteString(" else {\n")
+			w.stmts(indent+1, ret.els)
+			w.line(indent, "}")
+			return
+		}
+	case *loopStmt:
+		prefix := w.labelPrefix(ret.label)
+		cond := "true"
+		if ret.cond != nil {
+			cond = d.renderAs(ret.cond, descBoolean, precAssign)
+		}
+		if ret.doWhile {
+			w.block(indent, prefix+"do", ret.body, " while ("+cond+");")
+		} else {
+			w.block(indent, prefix+"while ("+cond+")", ret.body, "")
+		}
+	case *switchStmt:
+		w.line(indent, w.labelPrefix(ret.label)+"switch ("+d.render(ret.value, precAssign)+") {")
+		for _, c := range ret.cases {
+			for _, key := range c.keys {
+				label := strconv.Itoa(int(key))
+				if ret.value.exprType() == "C" {
+					label = javaCharLiteral(int64(key))
+				}
+				w.line(indent+1, "case "+label+":")
+			}
+			if c.isDefault {
+				w.line(indent+1, "default:")
+			}
+			w.stmts(indent+2, c.body)
+		}
+		w.line(indent, "}")
+	case *tryStmt:
+		w.block(indent, w.labelPrefix(ret.label)+"try", ret.body, "")
+		for _, c := range ret.catches {
+			types := make([]string, len(c.types))
+			for i, t := range c.types {
+				types[i] = d.className(t)
+			}
+			w.buf.Truncate(w.buf.Len() - 1)
+			w.buf.WriteString(" catch (" + strings.Join(types, " | ") + " " + c.v.name + ") {\n")
+			w.stmts(indent+1, c.body)
+			w.line(indent, "}")
+		}
+	case *breakStmt:
+		if ret.labeled {
+			w.line(indent, "break "+ret.label.name+";")
+		} else {
+			w.line(indent, "break;")
+		}
+	case *continueStmt:
+		if ret.labeled {
+			w.line(indent, "continue "+ret.label.name+";")
+		} else {
+			w.line(indent, "continue;")
+		}
+	}
+}
@@ -0,0 +1,788 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// maxEmitSteps 限制结构化时处理基本块的次数，避免无法还原的控制流导致输出过大
+	maxEmitSteps = 100000
+	// maxBlockEmits 限制同一个基本块被复制输出的次数
+	maxBlockEmits = 8
+)
+
+type loopInfo struct {
+	header  *basicBlock
+	body    map[*basicBlock]bool
+	latches []*basicBlock
+	exit    *basicBlock
+}
+
+type tryHandler struct {
+	types []string
+	block *basicBlock
+}
+
+// tryRegion 是异常表中起止位置相同的一组条目，对应源码中的一个 try
+type tryRegion struct {
+	start, end int
+	// first 是进入 try 时执行的第一个基本块
+	first    *basicBlock
+	handlers []*tryHandler
+}
+
+func (r *tryRegion) contains(b *basicBlock) bool {
+	return b.start >= r.start && b.start < r.end
+}
+
+func (r *tryRegion) containsLoop(loop *loopInfo) bool {
+	for b := range loop.body {
+		if !r.contains(b) {
+			return false
+		}
+	}
+	return true
+}
+
+type ctxKind int
+
+const (
+	ctxLoop ctxKind = iota
+	ctxSwitch
+	ctxIf
+	ctxTry
+	ctxCatch
+)
+
+// structCtx 是正在输出的结构，用于把跳转转换为 break、continue
+type structCtx struct {
+	kind   ctxKind
+	parent *structCtx
+	// start 是打开循环或者 try 的基本块
+	start  *basicBlock
+	loop   *loopInfo
+	region *tryRegion
+	// follow 是结构结束之后执行的基本块，也就是 break 的目标
+	follow *basicBlock
+	label  *stmtLabel
+}
+
+// computeIdom 使用 Cooper-Harvey-Kennedy 算法计算直接支配节点，order 为逆后序，第一个元素是入口
+func computeIdom(order []*basicBlock, preds func(*basicBlock) []*basicBlock) map[*basicBlock]*basicBlock {
+	index := make(map[*basicBlock]int, len(order))
+	for i, b := range order {
+		index[b] = i
+	}
+	idom := map[*basicBlock]*basicBlock{order[0]: order[0]}
+	intersect := func(a, b *basicBlock) *basicBlock {
+		for a != b {
+			for index[a] > index[b] {
+				a = idom[a]
+			}
+			for index[b] > index[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range order[1:] {
+			var newIdom *basicBlock
+			for _, p := range preds(b) {
+				if _, ok := index[p]; !ok || idom[p] == nil {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if newIdom != nil && idom[b] != newIdom {
+				idom[b] = newIdom
+				changed = true
+			}
+		}
+	}
+	return idom
+}
+
+func reversePostorder(entry *basicBlock, succs func(*basicBlock) []*basicBlock) []*basicBlock {
+	visited := map[*basicBlock]bool{}
+	var post []*basicBlock
+	var visit func(b *basicBlock)
+	visit = func(b *basicBlock) {
+		visited[b] = true
+		for _, s := range succs(b) {
+			if !visited[s] {
+				visit(s)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(entry)
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
+	}
+	return post
+}
+
+// analyze 计算支配树、后支配树、循环和 try 的范围
+func (m *methodDecompiler) analyze() {
+	m.idom = computeIdom(m.order, func(b *basicBlock) []*basicBlock {
+		return append(append([]*basicBlock{}, b.preds...), b.excPreds...)
+	})
+
+	// 后支配树使用一个虚拟的出口，return 和 throw 都连接到这个出口
+	exit := &basicBlock{start: -1}
+	var terminals []*basicBlock
+	for _, b := range m.order {
+		if len(b.succs) == 0 {
+			terminals = append(terminals, b)
+		}
+	}
+	reverseOrder := reversePostorder(exit, func(b *basicBlock) []*basicBlock {
+		if b == exit {
+			return terminals
+		}
+		return b.preds
+	})
+	ipdom := computeIdom(reverseOrder, func(b *basicBlock) []*basicBlock {
+		if len(b.succs) == 0 {
+			return []*basicBlock{exit}
+		}
+		return b.succs
+	})
+	m.ipdom = make(map[*basicBlock]*basicBlock)
+	for b, p := range ipdom {
+		if b != exit && p != exit {
+			m.ipdom[b] = p
+		}
+	}
+
+	m.findLoops()
+	m.findRegions()
+}
+
+func (m *methodDecompiler) dominates(a, b *basicBlock) bool {
+	for b != nil {
+		if a == b {
+			return true
+		}
+		p := m.idom[b]
+		if p == b {
+			break
+		}
+		b = p
+	}
+	return false
+}
+
+func (m *methodDecompiler) postDominates(a, b *basicBlock) bool {
+	for b != nil {
+		if a == b {
+			return true
+		}
+		b = m.ipdom[b]
+	}
+	return false
+}
+
+// findLoops 根据回边找出自然循环
+func (m *methodDecompiler) findLoops() {
+	m.loops = make(map[*basicBlock]*loopInfo)
+	for _, b := range m.order {
+		for _, h := range b.succs {
+			if !m.dominates(h, b) {
+				continue
+			}
+			loop, ok := m.loops[h]
+			if !ok {
+				loop = &loopInfo{header: h, body: map[*basicBlock]bool{h: true}}
+				m.loops[h] = loop
+			}
+			loop.latches = append(loop.latches, b)
+			stack := []*basicBlock{b}
+			for len(stack) > 0 {
+				x := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if loop.body[x] || !m.dominates(h, x) {
+					continue
+				}
+				loop.body[x] = true
+				stack = append(stack, x.preds...)
+				stack = append(stack, x.excPreds...)
+			}
+		}
+	}
+	for _, loop := range m.loops {
+		loop.exit = m.loopExit(loop)
+	}
+}
+
+// loopExit 选择循环的出口：优先使用循环头或者回边处的条件判断，否则使用跳出次数最多的基本块
+func (m *methodDecompiler) loopExit(loop *loopInfo) *basicBlock {
+	condExit := func(b *basicBlock) *basicBlock {
+		if b.term.kind != termCond {
+			return nil
+		}
+		t, f := b.term.trueSucc, b.term.falseSucc
+		switch {
+		case loop.body[t] && !loop.body[f]:
+			return f
+		case !loop.body[t] && loop.body[f]:
+			return t
+		}
+		return nil
+	}
+	if exit := condExit(loop.header); exit != nil {
+		return exit
+	}
+	for _, latch := range loop.latches {
+		if exit := condExit(latch); exit != nil {
+			return exit
+		}
+	}
+	counts := make(map[*basicBlock]int)
+	var best *basicBlock
+	for _, b := range m.order {
+		if !loop.body[b] {
+			continue
+		}
+		for _, s := range b.succs {
+			if loop.body[s] {
+				continue
+			}
+			counts[s]++
+			if best == nil || counts[s] > counts[best] || counts[s] == counts[best] && s.start < best.start {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+// findRegions 把异常表按照范围分组，外层的 try 排在前面
+func (m *methodDecompiler) findRegions() {
+	m.regions = nil
+	byRange := make(map[[2]int]*tryRegion)
+	for _, entry := range m.mergeExceptions() {
+		if !entry.handler.executed {
+			continue
+		}
+		key := [2]int{entry.start, entry.end}
+		region, ok := byRange[key]
+		if !ok {
+			region = &tryRegion{start: entry.start, end: entry.end}
+			byRange[key] = region
+			m.regions = append(m.regions, region)
+		}
+		catchType := entry.catchType
+		if catchType == "" {
+			catchType = "java/lang/Throwable"
+		}
+		var handler *tryHandler
+		for _, h := range region.handlers {
+			if h.block == entry.handler {
+				handler = h
+			}
+		}
+		if handler == nil {
+			handler = &tryHandler{block: entry.handler}
+			region.handlers = append(region.handlers, handler)
+		}
+		exists := false
+		for _, t := range handler.types {
+			exists = exists || t == catchType
+		}
+		if !exists {
+			handler.types = append(handler.types, catchType)
+		}
+	}
+	reachable := make(map[*basicBlock]bool, len(m.order))
+	for _, b := range m.order {
+		reachable[b] = true
+	}
+	var regions []*tryRegion
+	for _, region := range m.regions {
+		// 范围开头的 goto 块已经被跳过，使用它的目标作为入口
+		first := resolveForwarder(m.blockAt[region.start])
+		if !reachable[first] || !region.contains(first) {
+			continue
+		}
+		region.first = first
+		regions = append(regions, region)
+	}
+	m.regions = regions
+	sort.SliceStable(m.regions, func(i, j int) bool {
+		a, b := m.regions[i], m.regions[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		return a.end > b.end
+	})
+}
+
+// cannotThrow 判断指令是否不会抛出异常，编译器生成异常表时会把 return 之类的指令排除在外
+func cannotThrow(inst *Instruction) bool {
+	switch op := inst.Opcode; {
+	case op <= 0x2d, op >= 0x36 && op <= 0x4e, op == 0x84, op == 0xa7, op == 0xc8, op >= 0xac && op <= 0xb1:
+		return true
+	}
+	return false
+}
+
+// mergeExceptions 合并处理器相同、中间只隔着不会抛出异常的指令的异常表条目
+func (m *methodDecompiler) mergeExceptions() []*exceptionEntry {
+	entries := make([]*exceptionEntry, 0, len(m.exceptions))
+	for _, entry := range m.exceptions {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].start < entries[j].start
+	})
+	gapCannotThrow := func(start, end int) bool {
+		for _, b := range m.blocks {
+			if b.start >= end || b.end <= start {
+				continue
+			}
+			if b.isHandler {
+				return false
+			}
+			for _, inst := range b.insts {
+				if inst.Offset >= start && inst.Offset < end && !cannotThrow(inst) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	var merged []*exceptionEntry
+	for _, entry := range entries {
+		joined := false
+		for _, prev := range merged {
+			if prev.handler == entry.handler && prev.catchType == entry.catchType &&
+				entry.start >= prev.end && gapCannotThrow(prev.end, entry.start) {
+				if entry.end > prev.end {
+					prev.end = entry.end
+				}
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// regionAt 返回从基本块开始、需要输出的最外层 try
+func (m *methodDecompiler) regionAt(b *basicBlock, ctx *structCtx, opened map[interface{}]bool) *tryRegion {
+	for _, region := range m.regions {
+		if region.first != b || opened[region] || m.regionHandled(region, ctx) {
+			continue
+		}
+		return region
+	}
+	return nil
+}
+
+// regionHandled 判断 try 的所有异常处理块是否已经由外层的 try 输出，例如 finally 被拆分成的多段范围
+func (m *methodDecompiler) regionHandled(region *tryRegion, ctx *structCtx) bool {
+	for _, h := range region.handlers {
+		found := false
+		for c := ctx; c != nil && !found; c = c.parent {
+			if (c.kind != ctxTry && c.kind != ctxCatch) || c.region == nil {
+				continue
+			}
+			for _, other := range c.region.handlers {
+				if other.block == h.block {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// jumpStmt 把跳转到 target 转换为 break 或者 continue，不是结构的跳转目标时返回 nil
+func (m *methodDecompiler) jumpStmt(target *basicBlock, ctx *structCtx) javaStmt {
+	crossedLoop, crossedBreakable := false, false
+	for c := ctx; c != nil; c = c.parent {
+		switch c.kind {
+		case ctxLoop:
+			if target == c.loop.header {
+				return &continueStmt{label: c.label, labeled: crossedLoop}
+			}
+			if c.follow != nil && target == c.follow {
+				return &breakStmt{label: c.label, labeled: crossedBreakable}
+			}
+			crossedLoop, crossedBreakable = true, true
+		case ctxSwitch:
+			if c.follow != nil && target == c.follow {
+				return &breakStmt{label: c.label, labeled: crossedBreakable}
+			}
+			crossedBreakable = true
+		default:
+			if c.follow != nil && target == c.follow {
+				return &breakStmt{label: c.label, labeled: true}
+			}
+		}
+	}
+	return nil
+}
+
+// advance 处理顺序执行到 target，返回下一个需要输出的基本块，以及是否到达了 stop
+func (m *methodDecompiler) advance(out *[]javaStmt, target *basicBlock, ctx *structCtx, stop *basicBlock) (*basicBlock, bool) {
+	if target == nil {
+		return nil, false
+	}
+	if target == stop {
+		return nil, true
+	}
+	if s := m.jumpStmt(target, ctx); s != nil {
+		*out = append(*out, s)
+		return nil, false
+	}
+	if m.active[target] > 0 || m.emitCount[target] >= maxBlockEmits {
+		// 无法用结构化语句表示的跳转
+		*out = append(*out, &commentStmt{text: fmt.Sprintf("goto L%d", target.start)})
+		return nil, false
+	}
+	return target, false
+}
+
+// emitSeq 从 start 开始输出语句直到 stop，返回的布尔值表示是否会顺序执行到 stop
+func (m *methodDecompiler) emitSeq(start *basicBlock, ctx *structCtx, stop *basicBlock) ([]javaStmt, bool) {
+	var out []javaStmt
+	var emitted []*basicBlock
+	defer func() {
+		for _, b := range emitted {
+			m.active[b]--
+		}
+	}()
+	first := true
+	for cur := start; cur != nil; {
+		if cur == stop {
+			return out, true
+		}
+		m.steps++
+		if m.steps > maxEmitSteps {
+			panic("control flow is too complex")
+		}
+		opened := make(map[interface{}]bool)
+		if first {
+			for c := ctx; c != nil && c.start == cur; c = c.parent {
+				if c.loop != nil {
+					opened[c.loop] = true
+				}
+				if c.region != nil {
+					opened[c.region] = true
+				}
+			}
+			first = false
+		}
+		loop := m.loops[cur]
+		if loop != nil && opened[loop] {
+			loop = nil
+		}
+		var reached bool
+		if region := m.regionAt(cur, ctx, opened); region != nil && (loop == nil || region.containsLoop(loop)) {
+			try, follow := m.emitTry(cur, region, ctx)
+			out = append(out, try)
+			if cur, reached = m.advance(&out, follow, ctx, stop); reached {
+				return out, true
+			}
+			continue
+		}
+		if loop != nil {
+			out = append(out, m.emitLoop(cur, loop, ctx))
+			if cur, reached = m.advance(&out, loop.exit, ctx, stop); reached {
+				return out, true
+			}
+			continue
+		}
+
+		m.emitCount[cur]++
+		m.active[cur]++
+		emitted = append(emitted, cur)
+		out = append(out, cur.stmts...)
+		term := cur.term
+		switch term.kind {
+		case termReturn:
+			out = append(out, &returnStmt{value: term.value})
+			cur = nil
+		case termThrow:
+			out = append(out, &throwStmt{value: term.value})
+			cur = nil
+		case termNext:
+			cur, reached = m.advance(&out, term.next, ctx, stop)
+		case termCond:
+			cur, reached = m.emitIf(&out, cur, ctx, stop)
+		case termSwitch:
+			cur, reached = m.emitSwitch(&out, cur, ctx, stop)
+		}
+		if reached {
+			return out, true
+		}
+	}
+	return out, false
+}
+
+// followOf 返回分支结构结束之后执行的基本块，也就是直接后支配节点
+func (m *methodDecompiler) followOf(b *basicBlock, ctx *structCtx, stop *basicBlock) *basicBlock {
+	f := m.ipdom[b]
+	if f == nil {
+		return nil
+	}
+	if f == stop || stop != nil && m.postDominates(f, stop) {
+		return stop
+	}
+	if m.active[f] > 0 {
+		return nil
+	}
+	for c := ctx; c != nil; c = c.parent {
+		switch c.kind {
+		case ctxLoop:
+			// 只能从循环内部到达的出口代码可以放在循环里面输出
+			if f == c.loop.header || f == c.loop.exit || !c.loop.body[f] && !m.dominates(c.loop.header, f) {
+				return nil
+			}
+		case ctxTry:
+			if !c.region.contains(f) {
+				return nil
+			}
+		}
+	}
+	return f
+}
+
+// reaches 判断不经过跳转目标和 stop 时，from 是否可以执行到 to
+func (m *methodDecompiler) reaches(from, to *basicBlock, ctx *structCtx, stop *basicBlock) bool {
+	seen := make(map[*basicBlock]bool)
+	queue := []*basicBlock{from}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if b == to {
+			return true
+		}
+		if seen[b] || b == stop || m.active[b] > 0 || m.jumpStmt(b, ctx) != nil {
+			continue
+		}
+		seen[b] = true
+		queue = append(queue, b.succs...)
+	}
+	return false
+}
+
+func (m *methodDecompiler) emitIf(out *[]javaStmt, b *basicBlock, ctx *structCtx, stop *basicBlock) (*basicBlock, bool) {
+	cond, t, f := b.term.value, b.term.trueSucc, b.term.falseSucc
+	if t != stop {
+		if s := m.jumpStmt(t, ctx); s != nil {
+			*out = append(*out, &ifStmt{cond: cond, then: []javaStmt{s}})
+			return m.advance(out, f, ctx, stop)
+		}
+	}
+	if f != stop {
+		if s := m.jumpStmt(f, ctx); s != nil {
+			*out = append(*out, &ifStmt{cond: negateCondition(cond), then: []javaStmt{s}})
+			return m.advance(out, t, ctx, stop)
+		}
+	}
+	label := &stmtLabel{}
+	if t == stop || f == stop {
+		if t == stop {
+			cond, t = negateCondition(cond), f
+		}
+		then, _ := m.emitSeq(t, &structCtx{kind: ctxIf, parent: ctx, follow: stop, label: label}, stop)
+		*out = append(*out, &ifStmt{cond: cond, then: then, label: label})
+		return nil, true
+	}
+
+	follow := m.followOf(b, ctx, stop)
+	if follow == nil {
+		switch {
+		case !m.reaches(t, f, ctx, stop):
+			follow = f
+		case !m.reaches(f, t, ctx, stop):
+			follow = t
+		}
+	}
+	if follow == t {
+		cond, t, f = negateCondition(cond), f, t
+	}
+	ictx := &structCtx{kind: ctxIf, parent: ctx, follow: follow, label: label}
+	then, _ := m.emitSeq(t, ictx, follow)
+	var els []javaStmt
+	if f != follow {
+		els, _ = m.emitSeq(f, ictx, follow)
+	}
+	if len(then) == 0 && len(els) > 0 {
+		cond, then, els = negateCondition(cond), els, nil
+	}
+	*out = append(*out, &ifStmt{cond: cond, then: then, els: els, label: label})
+	if follow == nil {
+		return nil, false
+	}
+	return m.advance(out, follow, ctx, stop)
+}
+
+func (m *methodDecompiler) emitSwitch(out *[]javaStmt, b *basicBlock, ctx *structCtx, stop *basicBlock) (*basicBlock, bool) {
+	term := b.term
+	follow := m.followOf(b, ctx, stop)
+	label := &stmtLabel{}
+	sctx := &structCtx{kind: ctxSwitch, parent: ctx, follow: follow, label: label}
+	targets := term.successors()
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].start < targets[j].start
+	})
+
+	sw := &switchStmt{value: term.value, label: label}
+	var caseTargets []*basicBlock
+	for _, target := range targets {
+		c := &switchCase{isDefault: term.defaultSucc == target}
+		for i, key := range term.keys {
+			if term.targets[i] == target {
+				c.keys = append(c.keys, key)
+			}
+		}
+		if target == follow {
+			if c.isDefault {
+				continue
+			}
+			// 直接跳出 switch 的 case
+			c.body = []javaStmt{&breakStmt{label: label}}
+		}
+		sw.cases = append(sw.cases, c)
+		caseTargets = append(caseTargets, target)
+	}
+	for i, c := range sw.cases {
+		target := caseTargets[i]
+		if target == follow {
+			continue
+		}
+		nextStop := follow
+		if i+1 < len(sw.cases) {
+			nextStop = caseTargets[i+1]
+		}
+		body, reached := m.emitSeq(target, sctx, nextStop)
+		if reached && nextStop == follow && i+1 < len(sw.cases) {
+			body = append(body, &breakStmt{label: label})
+		}
+		c.body = body
+	}
+	*out = append(*out, sw)
+	if follow == nil {
+		return nil, false
+	}
+	return m.advance(out, follow, ctx, stop)
+}
+
+func (m *methodDecompiler) emitLoop(header *basicBlock, loop *loopInfo, ctx *structCtx) javaStmt {
+	label := &stmtLabel{}
+	lctx := &structCtx{kind: ctxLoop, parent: ctx, start: header, loop: loop, follow: loop.exit, label: label}
+	body, _ := m.emitSeq(header, lctx, nil)
+	return refineLoop(&loopStmt{body: body, label: label})
+}
+
+func isJumpIf(s javaStmt, label *stmtLabel, isContinue bool) (*ifStmt, bool) {
+	ifs, ok := s.(*ifStmt)
+	if !ok || len(ifs.els) != 0 || len(ifs.then) != 1 || !isJumpTo(ifs.then[0], label, isContinue) {
+		return nil, false
+	}
+	return ifs, true
+}
+
+// refineLoop 把 while (true) 转换为 while (cond) 或者 do-while
+func refineLoop(loop *loopStmt) *loopStmt {
+	body := loop.body
+	if n := len(body); n > 0 && isJumpTo(body[n-1], loop.label, true) {
+		body = body[:n-1]
+	}
+	loop.body = body
+	if len(body) > 0 {
+		if ifs, ok := isJumpIf(body[0], loop.label, false); ok {
+			loop.cond, loop.body = negateCondition(ifs.cond), body[1:]
+			return loop
+		}
+	}
+	n := len(body)
+	if n >= 1 {
+		if ifs, ok := isJumpIf(body[n-1], loop.label, false); ok && !containsJumpTo(body[:n-1], loop.label, true) {
+			loop.doWhile, loop.cond, loop.body = true, negateCondition(ifs.cond), body[:n-1]
+			return loop
+		}
+	}
+	if n >= 2 {
+		if ifs, ok := isJumpIf(body[n-2], loop.label, true); ok && isJumpTo(body[n-1], loop.label, false) && !containsJumpTo(body[:n-2], loop.label, true) {
+			loop.doWhile, loop.cond, loop.body = true, ifs.cond, body[:n-2]
+			return loop
+		}
+	}
+	return loop
+}
+
+// tryFollow 选择 try 结束之后执行的基本块，也就是 try 范围内跳出范围次数最多的目标
+func (m *methodDecompiler) tryFollow(region *tryRegion, ctx *structCtx) *basicBlock {
+	counts := make(map[*basicBlock]int)
+	var best *basicBlock
+	for _, b := range m.order {
+		if !region.contains(b) {
+			continue
+		}
+		for _, s := range b.succs {
+			if region.contains(s) || m.jumpStmt(s, ctx) != nil {
+				continue
+			}
+			counts[s]++
+			if best == nil || counts[s] > counts[best] || counts[s] == counts[best] && s.start < best.start {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+func (m *methodDecompiler) emitTry(b *basicBlock, region *tryRegion, ctx *structCtx) (*tryStmt, *basicBlock) {
+	follow := m.tryFollow(region, ctx)
+	label := &stmtLabel{}
+	tctx := &structCtx{kind: ctxTry, parent: ctx, start: b, region: region, follow: follow, label: label}
+	body, _ := m.emitSeq(b, tctx, follow)
+	try := &tryStmt{body: body, label: label}
+	for _, h := range region.handlers {
+		cctx := &structCtx{kind: ctxCatch, parent: ctx, region: region, follow: follow, label: label}
+		cbody, _ := m.emitSeq(h.block, cctx, follow)
+		v := h.block.handlerVar
+		// 异常先保存到局部变量时直接使用该变量作为 catch 的参数
+		if len(cbody) > 0 {
+			if assign, ok := cbody[0].(*assignStmt); ok && assign.op == "" {
+				target, ok1 := assign.target.(*localExpr)
+				value, ok2 := assign.value.(*localExpr)
+				if ok1 && ok2 && value.v == v && target.v.kind == varLocal && !target.v.param && !stmtsUseLocal(cbody[1:], v) {
+					v = target.v
+					cbody = cbody[1:]
+				}
+			}
+		}
+		try.catches = append(try.catches, &catchClause{types: h.types, v: v, body: cbody})
+	}
+	return try, follow
+}
+
+func stmtsUseLocal(stmts []javaStmt, v *localVar) bool {
+	found := false
+	walkStmtExprs(stmts, func(e javaExpr) {
+		if l, ok := e.(*localExpr); ok && l.v == v {
+			found = true
+		}
+	})
+	return found
+}
@@ -0,0 +1,180 @@
+package javaclassparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/yak/java/java2ssa"
+)
+
+func TestDecompileTemplates(t *testing.T) {
+	for name, raw := range loadTemplateClasses(t) {
+		obj, err := Parse(raw)
+		require.NoError(t, err, name)
+		src, err := obj.Decompile()
+		require.NoError(t, err, name)
+		require.NotContains(t, src, "decompile failed", name)
+		require.NotContains(t, src, "// goto", name)
+		if obj.GetClassName() == "" {
+			// 模板类的类名在使用时才会被替换
+			continue
+		}
+		_, err = java2ssa.Frontend(src, false)
+		require.NoError(t, err, name+"\n"+src)
+	}
+
+	obj, err := Parse(loadTemplateClasses(t)["RuntimeExec.class"])
+	require.NoError(t, err)
+	src, err := obj.Decompile()
+	require.NoError(t, err)
+	require.Contains(t, src, "package payload;")
+	require.Contains(t, src, "import java.io.IOException;")
+	require.Contains(t, src, `String cmd = "{{cmd}}";`)
+	require.Contains(t, src, `if (!File.separator.equals("/")) {`)
+	require.Contains(t, src, `new String[]{"/bin/sh", "-c", cmd}`)
+	require.Contains(t, src, "} catch (IOException ")
+}
+
+func TestDecompileControlFlow(t *testing.T) {
+	text := `
+.version 52 0
+.class public demo/Flow
+.super java/lang/Object
+
+.method public static sum(I)I
+    .var 0 is n I from L0 to L4
+    .var 1 is total I from L1 to L4
+    .var 2 is i I from L2 to L4
+L0:
+    iconst_0
+    istore_1
+L1:
+    iconst_0
+    istore_2
+L2:
+    iload_2
+    iload_0
+    if_icmpge L3
+    iload_1
+    iload_2
+    iadd
+    istore_1
+    iinc 2 1
+    goto L2
+L3:
+    iload_1
+    ireturn
+L4:
+.end method
+
+.method public static sign(I)Ljava/lang/String;
+    iload_0
+    ifle L0
+    ldc "positive"
+    goto L1
+L0:
+    ldc "other"
+L1:
+    areturn
+.end method
+
+.method public static greet(Ljava/lang/String;I)Ljava/lang/String;
+    .var 0 is name Ljava/lang/String; from L0 to L1
+    .var 1 is count I from L0 to L1
+L0:
+    new java/lang/StringBuilder
+    dup
+    invokespecial java/lang/StringBuilder/<init>()V
+    ldc "hello "
+    invokevirtual java/lang/StringBuilder/append(Ljava/lang/String;)Ljava/lang/StringBuilder;
+    aload_0
+    invokevirtual java/lang/StringBuilder/append(Ljava/lang/String;)Ljava/lang/StringBuilder;
+    ldc " x"
+    invokevirtual java/lang/StringBuilder/append(Ljava/lang/String;)Ljava/lang/StringBuilder;
+    iload_1
+    invokevirtual java/lang/StringBuilder/append(I)Ljava/lang/StringBuilder;
+    invokevirtual java/lang/StringBuilder/toString()Ljava/lang/String;
+L1:
+    areturn
+.end method
+
+.method public static code(I)I
+    iload_0
+    lookupswitch
+        1 : L0
+        10 : L1
+        default : L2
+L0:
+    bipush 100
+    ireturn
+L1:
+    sipush 1000
+    ireturn
+L2:
+    iconst_m1
+    ireturn
+.end method
+
+.method public static parse(Ljava/lang/String;)I
+    .catch java/lang/NumberFormatException from L0 to L1 using L2
+    .var 0 is s Ljava/lang/String; from L0 to L3
+    .var 1 is e Ljava/lang/NumberFormatException; from L3 to L4
+L0:
+    aload_0
+    invokestatic java/lang/Integer/parseInt(Ljava/lang/String;)I
+L1:
+    ireturn
+L2:
+    astore_1
+L3:
+    aload_1
+    invokevirtual java/lang/NumberFormatException/printStackTrace()V
+L4:
+    iconst_0
+    ireturn
+.end method
+
+.method public static countdown(I)V
+    .var 0 is n I from L0 to L1
+L0:
+    getstatic java/lang/System/out Ljava/io/PrintStream;
+    iload_0
+    invokevirtual java/io/PrintStream/println(I)V
+    iinc 0 -1
+    iload_0
+    ifgt L0
+L1:
+    return
+.end method
+`
+	obj, err := Assemble(text)
+	require.NoError(t, err)
+	obj, err = Parse(obj.Bytes())
+	require.NoError(t, err)
+	src, err := obj.Decompile()
+	require.NoError(t, err)
+	for _, snippet := range []string{
+		"package demo;",
+		"public class Flow {",
+		"public static int sum(int n) {",
+		"int total = 0;",
+		"while (i < n) {",
+		"total += i;",
+		"i++;",
+		"return total;",
+		`return arg0 <= 0 ? "other" : "positive";`,
+		`return "hello " + name + " x" + count;`,
+		"switch (",
+		"case 10:",
+		"return 1000;",
+		"tmp1 = Integer.parseInt(s);",
+		"} catch (NumberFormatException e) {",
+		"e.printStackTrace();",
+		"do {",
+		"} while (n > 0);",
+	} {
+		require.Contains(t, src, snippet, src)
+	}
+	_, err = java2ssa.Frontend(src, false)
+	require.NoError(t, err, src)
+}
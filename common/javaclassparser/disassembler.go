@@ -0,0 +1,540 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type accessFlag struct {
+	mask uint16
+	name string
+}
+
+var (
+	classAccessFlags = []accessFlag{
+		{0x0001, "public"}, {0x0010, "final"}, {0x0020, "super"}, {0x0200, "interface"}, {0x0400, "abstract"},
+		{0x1000, "synthetic"}, {0x2000, "annotation"}, {0x4000, "enum"}, {0x8000, "module"},
+	}
+	fieldAccessFlags = []accessFlag{
+		{0x0001, "public"}, {0x0002, "private"}, {0x0004, "protected"}, {0x0008, "static"}, {0x0010, "final"},
+		{0x0040, "volatile"}, {0x0080, "transient"}, {0x1000, "synthetic"}, {0x4000, "enum"},
+	}
+	methodAccessFlags = []accessFlag{
+		{0x0001, "public"}, {0x0002, "private"}, {0x0004, "protected"}, {0x0008, "static"}, {0x0010, "final"},
+		{0x0020, "synchronized"}, {0x0040, "bridge"}, {0x0080, "varargs"}, {0x0100, "native"}, {0x0400, "abstract"},
+		{0x0800, "strict"}, {0x1000, "synthetic"},
+	}
+)
+
+// formatAccessFlags 把访问标志转换为关键字，没有对应关键字的位使用十六进制表示
+func formatAccessFlags(flags uint16, table []accessFlag) []string {
+	var result []string
+	for _, flag := range table {
+		if flags&flag.mask != 0 {
+			result = append(result, flag.name)
+			flags &^= flag.mask
+		}
+	}
+	if flags != 0 {
+		result = append(result, fmt.Sprintf("0x%04x", flags))
+	}
+	return result
+}
+
+var methodHandleKinds = []string{
+	"", "getfield", "getstatic", "putfield", "putstatic",
+	"invokevirtual", "invokestatic", "invokespecial", "newinvokespecial", "invokeinterface",
+}
+
+// quoteIfNeeded 名称中包含空白等特殊字符时使用双引号包裹
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"") || strings.HasPrefix(s, ";") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+func (this *ClassObject) formatMemberRef(index uint16, withInterface bool) (string, error) {
+	ref, err := this.getMemberRef(index)
+	if err != nil {
+		return "", err
+	}
+	prefix := ""
+	if withInterface && ref.Tag == CONSTANT_InterfaceMethodref {
+		prefix = "interface "
+	}
+	if ref.Tag == CONSTANT_Fieldref {
+		return quoteIfNeeded(ref.Owner+"/"+ref.Name) + " " + ref.Desc, nil
+	}
+	return prefix + quoteIfNeeded(ref.Owner+"/"+ref.Name+ref.Desc), nil
+}
+
+func (this *ClassObject) formatMethodHandle(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	handle, ok := info.(*ConstantMethodHandleInfo)
+	if !ok {
+		return "", utils.Errorf("index %d is not ConstantMethodHandleInfo", index)
+	}
+	if handle.ReferenceKind == 0 || int(handle.ReferenceKind) >= len(methodHandleKinds) {
+		return "", utils.Errorf("invalid method handle kind %d", handle.ReferenceKind)
+	}
+	ref, err := this.formatMemberRef(handle.ReferenceIndex, true)
+	if err != nil {
+		return "", err
+	}
+	return methodHandleKinds[handle.ReferenceKind] + " " + ref, nil
+}
+
+// formatConstant 把可以被 ldc 加载的常量转换为文本
+func (this *ClassObject) formatConstant(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	switch ret := info.(type) {
+	case *ConstantIntegerInfo:
+		return strconv.Itoa(int(ret.Value)), nil
+	case *ConstantFloatInfo:
+		return formatFloat(float64(ret.Value), 32) + "f", nil
+	case *ConstantLongInfo:
+		return strconv.FormatInt(ret.Value, 10) + "L", nil
+	case *ConstantDoubleInfo:
+		return formatFloat(ret.Value, 64) + "d", nil
+	case *ConstantStringInfo:
+		value, err := this.getUtf8Value(ret.StringIndex)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Quote(value), nil
+	case *ConstantClassInfo:
+		name, err := this.getUtf8Value(ret.NameIndex)
+		if err != nil {
+			return "", err
+		}
+		return "class " + quoteIfNeeded(name), nil
+	case *ConstantMethodTypeInfo:
+		desc, err := this.getUtf8Value(ret.DescriptorIndex)
+		if err != nil {
+			return "", err
+		}
+		return "methodtype " + desc, nil
+	case *ConstantMethodHandleInfo:
+		handle, err := this.formatMethodHandle(index)
+		if err != nil {
+			return "", err
+		}
+		return "methodhandle " + handle, nil
+	}
+	return "", utils.Errorf("index %d is not a loadable constant", index)
+}
+
+func (this *ClassObject) getBootstrapMethods() []*BootstrapMethod {
+	for _, attr := range this.Attributes {
+		if ret, ok := attr.(*BootstrapMethodsAttribute); ok {
+			return ret.BootstrapMethods
+		}
+	}
+	return nil
+}
+
+func (this *ClassObject) formatInvokeDynamic(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	indy, ok := info.(*ConstantInvokeDynamicInfo)
+	if !ok {
+		return "", utils.Errorf("index %d is not ConstantInvokeDynamicInfo", index)
+	}
+	name, desc, err := this.getNameAndType(indy.NameAndTypeIndex)
+	if err != nil {
+		return "", err
+	}
+	bootstrapMethods := this.getBootstrapMethods()
+	if int(indy.BootstrapMethodAttrIndex) >= len(bootstrapMethods) {
+		return "", utils.Errorf("bootstrap method %d not found", indy.BootstrapMethodAttrIndex)
+	}
+	bootstrap := bootstrapMethods[indy.BootstrapMethodAttrIndex]
+	handle, err := this.formatMethodHandle(bootstrap.BootstrapMethodRef)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{quoteIfNeeded(name + desc), handle}
+	for _, arg := range bootstrap.BootstrapArguments {
+		constant, err := this.formatConstant(arg)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, constant)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func labelName(offset int) string {
+	return fmt.Sprintf("L%d", offset)
+}
+
+func (this *ClassObject) formatVerificationTypes(types []*VerificationTypeInfo) string {
+	var names []string
+	for _, t := range types {
+		switch t.Tag {
+		case VerificationObject:
+			name, err := this.getClassNameByIndex(t.CpoolIndex)
+			if err != nil {
+				name = fmt.Sprintf("#%d", t.CpoolIndex)
+			}
+			names = append(names, name)
+		case VerificationUninitialized:
+			names = append(names, fmt.Sprintf("uninitialized(%s)", labelName(int(t.Offset))))
+		default:
+			names = append(names, verificationType{tag: t.Tag}.String())
+		}
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func (this *ClassObject) formatStackMapFrame(frame *StackMapFrame) string {
+	switch {
+	case frame.FrameType <= StackMapFrameSameMax:
+		return "same"
+	case frame.FrameType <= StackMapFrameSameLocals1StackMax, frame.FrameType == StackMapFrameSameLocals1StackExt:
+		return "same_locals_1_stack_item " + this.formatVerificationTypes(frame.Stack)
+	case frame.FrameType < StackMapFrameSameExt:
+		return fmt.Sprintf("chop %d", StackMapFrameSameExt-int(frame.FrameType))
+	case frame.FrameType == StackMapFrameSameExt:
+		return "same_extended"
+	case frame.FrameType <= StackMapFrameAppendMax:
+		return "append " + this.formatVerificationTypes(frame.Locals)
+	}
+	return "full locals " + this.formatVerificationTypes(frame.Locals) + " stack " + this.formatVerificationTypes(frame.Stack)
+}
+
+// formatInstruction 把一条指令转换为文本，switch 指令会返回多行
+func (this *ClassObject) formatInstruction(inst *Instruction) ([]string, error) {
+	name := inst.Name()
+	var operand string
+	var err error
+	switch inst.operand() {
+	case operandNone:
+		return []string{name}, nil
+	case operandByte, operandShort:
+		operand = strconv.Itoa(int(inst.Value))
+	case operandLocal:
+		operand = strconv.Itoa(int(inst.Index))
+	case operandIinc:
+		operand = fmt.Sprintf("%d %d", inst.Index, inst.Value)
+	case operandConstU1, operandConstU2:
+		operand, err = this.formatConstant(inst.Index)
+	case operandField:
+		operand, err = this.formatMemberRef(inst.Index, false)
+	case operandMethod:
+		operand, err = this.formatMemberRef(inst.Index, true)
+	case operandInterfaceMethod:
+		operand, err = this.formatMemberRef(inst.Index, false)
+		operand += " " + strconv.Itoa(int(inst.Value))
+	case operandDynamic:
+		operand, err = this.formatInvokeDynamic(inst.Index)
+	case operandClass:
+		operand, err = this.getClassNameByIndex(inst.Index)
+		operand = quoteIfNeeded(operand)
+	case operandNewArray:
+		var ok bool
+		if operand, ok = newArrayTypes[uint8(inst.Value)]; !ok {
+			err = utils.Errorf("invalid newarray type %d", inst.Value)
+		}
+	case operandMultiANewArray:
+		operand, err = this.getClassNameByIndex(inst.Index)
+		operand = fmt.Sprintf("%s %d", quoteIfNeeded(operand), inst.Value)
+	case operandBranch, operandBranchWide:
+		operand = labelName(inst.Target)
+	case operandTableSwitch:
+		lines := []string{fmt.Sprintf("%s %d %d", name, inst.Low, inst.Low+int32(len(inst.Targets))-1)}
+		for _, target := range inst.Targets {
+			lines = append(lines, "    "+labelName(target))
+		}
+		return append(lines, "    default : "+labelName(inst.Default)), nil
+	case operandLookupSwitch:
+		lines := []string{name}
+		for i, target := range inst.Targets {
+			lines = append(lines, fmt.Sprintf("    %d : %s", inst.Keys[i], labelName(target)))
+		}
+		return append(lines, "    default : "+labelName(inst.Default)), nil
+	}
+	if err != nil {
+		return nil, utils.Errorf("%s at %d: %v", name, inst.Offset, err)
+	}
+	return []string{name + " " + operand}, nil
+}
+
+func (this *ClassObject) getMethodCode(method *MemberInfo) *CodeAttribute {
+	for _, attr := range method.Attributes {
+		if code, ok := attr.(*CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
+
+// disassembleCode 把 Code 属性转换为 Jasmin 风格的文本行，不包含 .method 和 .end method
+func (this *ClassObject) disassembleCode(code *CodeAttribute) ([]string, error) {
+	insts, err := DecodeInstructions(code.Code)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[int]bool{}
+	lines := map[int][]int{}
+	frames := map[int]*StackMapFrame{}
+	var localVariables []*LocalVariableTableEntry
+	for _, inst := range insts {
+		if inst.IsBranch() {
+			labels[inst.Target] = true
+		}
+		if inst.IsSwitch() {
+			labels[inst.Default] = true
+			for _, target := range inst.Targets {
+				labels[target] = true
+			}
+		}
+	}
+	for _, entry := range code.ExceptionTable {
+		labels[int(entry.StartPc)] = true
+		labels[int(entry.EndPc)] = true
+		labels[int(entry.HandlerPc)] = true
+	}
+	for _, attr := range code.Attributes {
+		switch ret := attr.(type) {
+		case *LineNumberTableAttribute:
+			for _, entry := range ret.LineNumberTable {
+				lines[int(entry.StartPc)] = append(lines[int(entry.StartPc)], int(entry.LineNumber))
+			}
+		case *LocalVariableTableAttribute:
+			for _, entry := range ret.LocalVariableTable {
+				labels[int(entry.StartPc)] = true
+				labels[int(entry.StartPc)+int(entry.Length)] = true
+				localVariables = append(localVariables, entry)
+			}
+		case *StackMapTableAttribute:
+			offset := -1
+			for _, frame := range ret.Entries {
+				offset += int(frame.OffsetDelta) + 1
+				frames[offset] = frame
+				labels[offset] = true
+			}
+		}
+	}
+
+	var result []string
+	result = append(result, fmt.Sprintf("    .limit stack %d", code.MaxStack), fmt.Sprintf("    .limit locals %d", code.MaxLocals))
+	for _, entry := range code.ExceptionTable {
+		catchType := "all"
+		if entry.CatchType != 0 {
+			name, err := this.getClassNameByIndex(entry.CatchType)
+			if err != nil {
+				return nil, err
+			}
+			catchType = quoteIfNeeded(name)
+		}
+		result = append(result, fmt.Sprintf("    .catch %s from %s to %s using %s", catchType, labelName(int(entry.StartPc)), labelName(int(entry.EndPc)), labelName(int(entry.HandlerPc))))
+	}
+	for _, entry := range localVariables {
+		name, err := this.getUtf8Value(entry.NameIndex)
+		if err != nil {
+			return nil, err
+		}
+		desc, err := this.getUtf8Value(entry.DescriptorIndex)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fmt.Sprintf("    .var %d is %s %s from %s to %s", entry.Index, quoteIfNeeded(name), desc, labelName(int(entry.StartPc)), labelName(int(entry.StartPc)+int(entry.Length))))
+	}
+	for _, attr := range code.Attributes {
+		if ret, ok := attr.(*UnparsedAttribute); ok {
+			result = append(result, fmt.Sprintf("    ; attribute %s (%d bytes) is not shown", ret.Name, len(ret.Info)))
+		}
+	}
+
+	for _, inst := range insts {
+		if labels[inst.Offset] {
+			result = append(result, labelName(inst.Offset)+":")
+		}
+		if frame, ok := frames[inst.Offset]; ok {
+			result = append(result, "    ; frame "+this.formatStackMapFrame(frame))
+		}
+		for _, line := range lines[inst.Offset] {
+			result = append(result, fmt.Sprintf("    .line %d", line))
+		}
+		text, err := this.formatInstruction(inst)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range text {
+			result = append(result, "    "+line)
+		}
+	}
+	if labels[len(code.Code)] {
+		result = append(result, labelName(len(code.Code))+":")
+	}
+	return result, nil
+}
+
+// DisassembleMethod 把方法转换为 Jasmin 风格的文本
+func (this *ClassObject) DisassembleMethod(method *MemberInfo) (string, error) {
+	name, err := this.getUtf8Value(method.NameIndex)
+	if err != nil {
+		return "", err
+	}
+	desc, err := this.getUtf8Value(method.DescriptorIndex)
+	if err != nil {
+		return "", err
+	}
+	header := append([]string{".method"}, formatAccessFlags(method.AccessFlags, methodAccessFlags)...)
+	result := []string{strings.Join(append(header, quoteIfNeeded(name+desc)), " ")}
+	var exceptions []string
+	for _, attr := range method.Attributes {
+		switch ret := attr.(type) {
+		case *ExceptionsAttribute:
+			for _, index := range ret.ExceptionIndexTable {
+				class, err := this.getClassNameByIndex(index)
+				if err != nil {
+					return "", err
+				}
+				exceptions = append(exceptions, "    .throws "+quoteIfNeeded(class))
+			}
+		case *UnparsedAttribute:
+			result = append(result, fmt.Sprintf("    ; attribute %s (%d bytes) is not shown", ret.Name, len(ret.Info)))
+		}
+	}
+	result = append(result, exceptions...)
+	if code := this.getMethodCode(method); code != nil {
+		lines, err := this.disassembleCode(code)
+		if err != nil {
+			return "", utils.Errorf("disassemble method %s%s failed: %v", name, desc, err)
+		}
+		result = append(result, lines...)
+	}
+	result = append(result, ".end method")
+	return strings.Join(result, "\n"), nil
+}
+
+func (this *ClassObject) disassembleField(field *MemberInfo) (string, error) {
+	name, err := this.getUtf8Value(field.NameIndex)
+	if err != nil {
+		return "", err
+	}
+	desc, err := this.getUtf8Value(field.DescriptorIndex)
+	if err != nil {
+		return "", err
+	}
+	parts := append([]string{".field"}, formatAccessFlags(field.AccessFlags, fieldAccessFlags)...)
+	parts = append(parts, quoteIfNeeded(name), desc)
+	for _, attr := range field.Attributes {
+		if ret, ok := attr.(*ConstantValueAttribute); ok {
+			value, err := this.formatConstant(ret.ConstantValueIndex)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, "=", value)
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+/*
+*
+Disassemble 把整个 class 转换为 Jasmin 风格的文本，可以使用 Assemble 重新生成 class
+
+	.version 52 0
+	.class public super Foo
+	.super java/lang/Object
+	.method public <init>()V
+	    .limit stack 1
+	    .limit locals 1
+	    aload_0
+	    invokespecial java/lang/Object/<init>()V
+	    return
+	.end method
+
+StackMapTable 以注释的形式给出，汇编时会重新计算；文本中没有表示的属性（例如 InnerClasses、注解）也以注释列出
+*/
+func (this *ClassObject) Disassemble() (string, error) {
+	var result []string
+	result = append(result, fmt.Sprintf(".version %d %d", this.MajorVersion, this.MinorVersion))
+	for _, attr := range this.Attributes {
+		if ret, ok := attr.(*SourceFileAttribute); ok {
+			source, err := this.getUtf8Value(ret.SourceFileIndex)
+			if err != nil {
+				return "", err
+			}
+			result = append(result, ".source "+strconv.Quote(source))
+		}
+	}
+	className, err := this.getClassNameByIndex(this.ThisClass)
+	if err != nil {
+		return "", err
+	}
+	header := append([]string{".class"}, formatAccessFlags(this.AccessFlags, classAccessFlags)...)
+	result = append(result, strings.Join(append(header, quoteIfNeeded(className)), " "))
+	if this.SuperClass != 0 {
+		superClass, err := this.getClassNameByIndex(this.SuperClass)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, ".super "+quoteIfNeeded(superClass))
+	}
+	for _, index := range this.Interfaces {
+		name, err := this.getClassNameByIndex(index)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, ".implements "+quoteIfNeeded(name))
+	}
+	var skipped []string
+	for _, attr := range this.Attributes {
+		if ret, ok := attr.(*UnparsedAttribute); ok {
+			skipped = append(skipped, ret.Name)
+		}
+	}
+	sort.Strings(skipped)
+	for _, name := range skipped {
+		result = append(result, fmt.Sprintf("; attribute %s is not shown", name))
+	}
+
+	if len(this.Fields) > 0 {
+		result = append(result, "")
+	}
+	for _, field := range this.Fields {
+		line, err := this.disassembleField(field)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, line)
+	}
+	for _, method := range this.Methods {
+		text, err := this.DisassembleMethod(method)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, "", text)
+	}
+	return strings.Join(result, "\n") + "\n", nil
+}
@@ -2,6 +2,7 @@ package javaclassparser
 
 import (
 	"fmt"
+	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"regexp"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 const classTemplate = "\n//Class Declaration\n%s class %s{%s}"
 const attrTemplate = `%s %s %s;`
+const methodTemplate = "%s %s %s {\n%s\t}"
 
 type ClassObjectDumper struct {
 	imports map[string]struct{}
@@ -136,7 +138,23 @@ func (c *ClassObjectDumper) DumpMethods() ([]string, error) {
 		}
 		paramsNewStr := strings.Join(paramsNewStrList, ", ")
 		name = fmt.Sprintf("%s(%s)", name, paramsNewStr)
-		result = append(result, fmt.Sprintf(attrTemplate, accessFlags, returnType, name))
+		code := c.obj.getMethodCode(method)
+		if code == nil {
+			result = append(result, fmt.Sprintf(attrTemplate, accessFlags, returnType, name))
+			continue
+		}
+		// 方法体以注释的形式输出字节码，无法反汇编时只输出方法签名
+		lines, err := c.obj.disassembleCode(code)
+		if err != nil {
+			log.Warnf("disassemble method %s failed: %v", name, err)
+			result = append(result, fmt.Sprintf(attrTemplate, accessFlags, returnType, name))
+			continue
+		}
+		body := ""
+		for _, line := range lines {
+			body += fmt.Sprintf("\t\t// %s\n", line)
+		}
+		result = append(result, fmt.Sprintf(methodTemplate, accessFlags, returnType, name, body))
 
 	}
 	return result, nil
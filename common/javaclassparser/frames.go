@@ -0,0 +1,1095 @@
+package javaclassparser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// 返回地址只会出现在 jsr/ret 中，不能出现在栈映射帧里
+const verificationReturnAddress = 0xff
+
+// verificationType 是分析时使用的验证类型，long 和 double 在局部变量和操作数栈中占两个位置，第二个位置为 top
+type verificationType struct {
+	tag    uint8
+	class  string
+	offset int
+}
+
+var (
+	typeTop               = verificationType{tag: VerificationTop}
+	typeInteger           = verificationType{tag: VerificationInteger}
+	typeFloat             = verificationType{tag: VerificationFloat}
+	typeLong              = verificationType{tag: VerificationLong}
+	typeDouble            = verificationType{tag: VerificationDouble}
+	typeNull              = verificationType{tag: VerificationNull}
+	typeUninitializedThis = verificationType{tag: VerificationUninitializedThis}
+	typeReturnAddress     = verificationType{tag: verificationReturnAddress}
+)
+
+func objectType(class string) verificationType {
+	return verificationType{tag: VerificationObject, class: class}
+}
+
+func (t verificationType) isWide() bool {
+	return t.tag == VerificationLong || t.tag == VerificationDouble
+}
+
+func (t verificationType) isReference() bool {
+	switch t.tag {
+	case VerificationNull, VerificationObject, VerificationUninitialized, VerificationUninitializedThis:
+		return true
+	}
+	return false
+}
+
+func (t verificationType) String() string {
+	switch t.tag {
+	case VerificationTop:
+		return "top"
+	case VerificationInteger:
+		return "int"
+	case VerificationFloat:
+		return "float"
+	case VerificationLong:
+		return "long"
+	case VerificationDouble:
+		return "double"
+	case VerificationNull:
+		return "null"
+	case VerificationUninitializedThis:
+		return "uninitializedThis"
+	case VerificationObject:
+		return t.class
+	case VerificationUninitialized:
+		return "uninitialized"
+	}
+	return "returnAddress"
+}
+
+// descriptorType 把字段描述符转换成验证类型，boolean、byte、char、short 都按 int 处理
+func descriptorType(desc string) verificationType {
+	if desc == "" {
+		return typeTop
+	}
+	switch desc[0] {
+	case 'B', 'C', 'I', 'S', 'Z':
+		return typeInteger
+	case 'F':
+		return typeFloat
+	case 'J':
+		return typeLong
+	case 'D':
+		return typeDouble
+	case 'L':
+		return objectType(strings.TrimSuffix(desc[1:], ";"))
+	case '[':
+		return objectType(desc)
+	}
+	return typeTop
+}
+
+// classDescriptor 把内部类名转换成描述符，数组类型本身就是描述符
+func classDescriptor(class string) string {
+	if strings.HasPrefix(class, "[") {
+		return class
+	}
+	return "L" + class + ";"
+}
+
+// parseMethodDescriptor 把方法描述符拆分为参数和返回值的字段描述符
+func parseMethodDescriptor(desc string) ([]string, string, error) {
+	if !strings.HasPrefix(desc, "(") {
+		return nil, "", utils.Errorf("invalid method descriptor: %s", desc)
+	}
+	var params []string
+	pos := 1
+	for pos < len(desc) && desc[pos] != ')' {
+		end, err := fieldDescriptorEnd(desc, pos)
+		if err != nil {
+			return nil, "", utils.Errorf("invalid method descriptor %s: %v", desc, err)
+		}
+		params = append(params, desc[pos:end])
+		pos = end
+	}
+	if pos >= len(desc) {
+		return nil, "", utils.Errorf("invalid method descriptor: %s", desc)
+	}
+	ret := desc[pos+1:]
+	if ret != "V" {
+		if end, err := fieldDescriptorEnd(ret, 0); err != nil || end != len(ret) {
+			return nil, "", utils.Errorf("invalid method descriptor: %s", desc)
+		}
+	}
+	return params, ret, nil
+}
+
+func fieldDescriptorEnd(desc string, pos int) (int, error) {
+	for pos < len(desc) && desc[pos] == '[' {
+		pos++
+	}
+	if pos >= len(desc) {
+		return 0, utils.Error("unexpected end of descriptor")
+	}
+	switch desc[pos] {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		return pos + 1, nil
+	case 'L':
+		end := strings.IndexByte(desc[pos:], ';')
+		if end < 0 {
+			return 0, utils.Error("unterminated class descriptor")
+		}
+		return pos + end + 1, nil
+	}
+	return 0, utils.Errorf("unknown descriptor char %q", desc[pos])
+}
+
+type frameState struct {
+	locals []verificationType
+	stack  []verificationType
+}
+
+func (s *frameState) clone() *frameState {
+	return &frameState{
+		locals: append([]verificationType(nil), s.locals...),
+		stack:  append([]verificationType(nil), s.stack...),
+	}
+}
+
+func (s *frameState) push(t verificationType) {
+	s.stack = append(s.stack, t)
+	if t.isWide() {
+		s.stack = append(s.stack, typeTop)
+	}
+}
+
+func (s *frameState) pop() (verificationType, error) {
+	n := len(s.stack)
+	if n == 0 {
+		return typeTop, utils.Error("operand stack underflow")
+	}
+	if n >= 2 && s.stack[n-1] == typeTop && s.stack[n-2].isWide() {
+		t := s.stack[n-2]
+		s.stack = s.stack[:n-2]
+		return t, nil
+	}
+	t := s.stack[n-1]
+	s.stack = s.stack[:n-1]
+	return t, nil
+}
+
+func (s *frameState) popN(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := s.pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// popSlots 按槽位弹出，用于 pop2、dup2 这类不关心具体类型的指令
+func (s *frameState) popSlots(n int) ([]verificationType, error) {
+	if len(s.stack) < n {
+		return nil, utils.Error("operand stack underflow")
+	}
+	slots := append([]verificationType(nil), s.stack[len(s.stack)-n:]...)
+	s.stack = s.stack[:len(s.stack)-n]
+	return slots, nil
+}
+
+func (s *frameState) load(index int) verificationType {
+	if index < len(s.locals) {
+		return s.locals[index]
+	}
+	return typeTop
+}
+
+func (s *frameState) store(index int, t verificationType) {
+	size := 1
+	if t.isWide() {
+		size = 2
+	}
+	for len(s.locals) < index+size {
+		s.locals = append(s.locals, typeTop)
+	}
+	if index > 0 && s.locals[index-1].isWide() {
+		s.locals[index-1] = typeTop
+	}
+	s.locals[index] = t
+	if t.isWide() {
+		s.locals[index+1] = typeTop
+	}
+}
+
+// toVerificationList 把按槽位存储的类型转换成栈映射帧中的列表，long 和 double 只占一项
+func toVerificationList(slots []verificationType, trimTop bool) []verificationType {
+	var list []verificationType
+	for i := 0; i < len(slots); i++ {
+		list = append(list, slots[i])
+		if slots[i].isWide() {
+			i++
+		}
+	}
+	if trimTop {
+		for len(list) > 0 && list[len(list)-1] == typeTop {
+			list = list[:len(list)-1]
+		}
+	}
+	return list
+}
+
+func equalVerificationList(a, b []verificationType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FrameOption 是计算栈映射帧时的选项
+type FrameOption func(a *frameAnalyzer)
+
+// WithCommonSuperClass 设置计算两个类的公共父类的方法，默认只处理数组，其余的返回 java/lang/Object
+func WithCommonSuperClass(f func(a, b string) string) FrameOption {
+	return func(a *frameAnalyzer) {
+		a.commonSuperClass = f
+	}
+}
+
+type localVariableHint struct {
+	start, end int
+	index      int
+	desc       string
+}
+
+type frameAnalyzer struct {
+	obj       *ClassObject
+	builder   *constantPoolBuilder
+	className string
+
+	static bool
+	name   string
+	desc   string
+
+	insts    []*Instruction
+	index    map[int]int
+	handlers []*ExceptionTableEntry
+	hints    []*localVariableHint
+	newTypes map[int]string
+
+	frames    []*frameState
+	maxStack  int
+	maxLocals int
+
+	commonSuperClass func(a, b string) string
+}
+
+func (a *frameAnalyzer) initialFrame() (*frameState, error) {
+	params, _, err := parseMethodDescriptor(a.desc)
+	if err != nil {
+		return nil, err
+	}
+	state := &frameState{}
+	if !a.static {
+		if a.name == "<init>" && a.className != "java/lang/Object" {
+			state.locals = append(state.locals, typeUninitializedThis)
+		} else {
+			state.locals = append(state.locals, objectType(a.className))
+		}
+	}
+	for _, param := range params {
+		t := descriptorType(param)
+		state.locals = append(state.locals, t)
+		if t.isWide() {
+			state.locals = append(state.locals, typeTop)
+		}
+	}
+	return state, nil
+}
+
+func (a *frameAnalyzer) superClassOf(x, y string) string {
+	if x == y {
+		return x
+	}
+	if strings.HasPrefix(x, "[") && strings.HasPrefix(y, "[") {
+		ex, ey := x[1:], y[1:]
+		if (ex[0] == 'L' || ex[0] == '[') && (ey[0] == 'L' || ey[0] == '[') {
+			return "[" + classDescriptor(a.superClassOf(descriptorType(ex).class, descriptorType(ey).class))
+		}
+		return "java/lang/Object"
+	}
+	if strings.HasPrefix(x, "[") || strings.HasPrefix(y, "[") {
+		return "java/lang/Object"
+	}
+	if a.commonSuperClass != nil {
+		if class := a.commonSuperClass(x, y); class != "" {
+			return class
+		}
+	}
+	return "java/lang/Object"
+}
+
+// mergeType 合并两个控制流汇合处的类型，local 为局部变量下标，操作数栈传入 -1
+func (a *frameAnalyzer) mergeType(x, y verificationType, offset, local int) verificationType {
+	if x == y {
+		return x
+	}
+	isObject := func(t verificationType) bool {
+		return t.tag == VerificationObject || t.tag == VerificationNull
+	}
+	if !isObject(x) || !isObject(y) {
+		return typeTop
+	}
+	if x.tag == VerificationNull {
+		return y
+	}
+	if y.tag == VerificationNull {
+		return x
+	}
+	// 有调试信息时直接使用局部变量声明的类型
+	if local >= 0 {
+		for _, hint := range a.hints {
+			if hint.index == local && hint.start <= offset && offset < hint.end {
+				if t := descriptorType(hint.desc); t.tag == VerificationObject {
+					return t
+				}
+			}
+		}
+	}
+	return objectType(a.superClassOf(x.class, y.class))
+}
+
+func (a *frameAnalyzer) merge(index int, state *frameState) (bool, error) {
+	if a.frames[index] == nil {
+		a.frames[index] = state.clone()
+		return true, nil
+	}
+	old := a.frames[index]
+	offset := a.insts[index].Offset
+	if len(old.stack) != len(state.stack) {
+		return false, utils.Errorf("inconsistent stack height at %d: %d != %d", offset, len(old.stack), len(state.stack))
+	}
+	changed := false
+	for i := range old.stack {
+		t := a.mergeType(old.stack[i], state.stack[i], offset, -1)
+		if t == typeTop && old.stack[i] != typeTop {
+			return false, utils.Errorf("incompatible stack types at %d: %v and %v", offset, old.stack[i], state.stack[i])
+		}
+		if t != old.stack[i] {
+			old.stack[i] = t
+			changed = true
+		}
+	}
+	for i := 0; i < len(old.locals) || i < len(state.locals); i++ {
+		x, y := typeTop, typeTop
+		if i < len(old.locals) {
+			x = old.locals[i]
+		}
+		if i < len(state.locals) {
+			y = state.locals[i]
+		}
+		t := a.mergeType(x, y, offset, i)
+		if i >= len(old.locals) {
+			old.locals = append(old.locals, typeTop)
+		}
+		if t != old.locals[i] {
+			old.locals[i] = t
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+func (a *frameAnalyzer) constantType(index uint16) (verificationType, error) {
+	info, err := a.obj.getConstantInfo(index)
+	if err != nil {
+		return typeTop, err
+	}
+	switch info.(type) {
+	case *ConstantIntegerInfo:
+		return typeInteger, nil
+	case *ConstantFloatInfo:
+		return typeFloat, nil
+	case *ConstantLongInfo:
+		return typeLong, nil
+	case *ConstantDoubleInfo:
+		return typeDouble, nil
+	case *ConstantStringInfo:
+		return objectType("java/lang/String"), nil
+	case *ConstantClassInfo:
+		return objectType("java/lang/Class"), nil
+	case *ConstantMethodTypeInfo:
+		return objectType("java/lang/invoke/MethodType"), nil
+	case *ConstantMethodHandleInfo:
+		return objectType("java/lang/invoke/MethodHandle"), nil
+	}
+	return typeTop, utils.Errorf("constant %d cannot be loaded by ldc", index)
+}
+
+var (
+	loadStoreTypes  = []verificationType{typeInteger, typeLong, typeFloat, typeDouble, {tag: VerificationObject}}
+	conversionTypes = []verificationType{
+		typeLong, typeFloat, typeDouble, typeInteger, typeFloat, typeDouble, typeInteger, typeLong,
+		typeDouble, typeInteger, typeLong, typeFloat, typeInteger, typeInteger, typeInteger,
+	}
+)
+
+func (a *frameAnalyzer) loadLocal(s *frameState, index int, kind verificationType) error {
+	t := s.load(index)
+	if kind.tag == VerificationObject {
+		if !t.isReference() {
+			return utils.Errorf("local %d is %v, not a reference", index, t)
+		}
+	} else if t != kind {
+		return utils.Errorf("local %d is %v, not %v", index, t, kind)
+	}
+	if index+1 > a.maxLocals {
+		a.maxLocals = index + 1
+	}
+	s.push(t)
+	return nil
+}
+
+func (a *frameAnalyzer) storeLocal(s *frameState, index int, kind verificationType) error {
+	t, err := s.pop()
+	if err != nil {
+		return err
+	}
+	if kind.tag == VerificationObject {
+		if !t.isReference() && t != typeReturnAddress {
+			return utils.Errorf("store %v to reference local %d", t, index)
+		}
+	} else if t != kind {
+		return utils.Errorf("store %v to %v local %d", t, kind, index)
+	}
+	s.store(index, t)
+	return nil
+}
+
+// execute 模拟执行一条指令，修改 s 为执行之后的状态
+func (a *frameAnalyzer) execute(inst *Instruction, s *frameState) error {
+	op := int(inst.Opcode)
+	switch {
+	case op == OP_nop, op == OP_breakpoint, op == OP_impdep1, op == OP_impdep2,
+		op == OP_goto, op == OP_goto_w, op == OP_return:
+	case op == OP_aconst_null:
+		s.push(typeNull)
+	case op >= OP_iconst_m1 && op <= OP_iconst_5, op == OP_bipush, op == OP_sipush:
+		s.push(typeInteger)
+	case op == OP_lconst_0 || op == OP_lconst_1:
+		s.push(typeLong)
+	case op >= OP_fconst_0 && op <= OP_fconst_2:
+		s.push(typeFloat)
+	case op == OP_dconst_0 || op == OP_dconst_1:
+		s.push(typeDouble)
+	case op == OP_ldc, op == OP_ldc_w, op == OP_ldc2_w:
+		t, err := a.constantType(inst.Index)
+		if err != nil {
+			return err
+		}
+		if t.isWide() != (op == OP_ldc2_w) {
+			return utils.Errorf("constant %d cannot be loaded by %s", inst.Index, inst.Name())
+		}
+		s.push(t)
+	case op >= OP_iload && op <= OP_aload:
+		return a.loadLocal(s, int(inst.Index), loadStoreTypes[op-OP_iload])
+	case op >= OP_iload_0 && op <= OP_aload_3:
+		return a.loadLocal(s, (op-OP_iload_0)%4, loadStoreTypes[(op-OP_iload_0)/4])
+	case op >= OP_iaload && op <= OP_saload:
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		array, err := s.pop()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case OP_laload:
+			s.push(typeLong)
+		case OP_faload:
+			s.push(typeFloat)
+		case OP_daload:
+			s.push(typeDouble)
+		case OP_aaload:
+			if array.tag == VerificationObject && strings.HasPrefix(array.class, "[") {
+				s.push(descriptorType(array.class[1:]))
+			} else if array.tag == VerificationNull {
+				s.push(typeNull)
+			} else {
+				s.push(objectType("java/lang/Object"))
+			}
+		default:
+			s.push(typeInteger)
+		}
+	case op >= OP_istore && op <= OP_astore:
+		return a.storeLocal(s, int(inst.Index), loadStoreTypes[op-OP_istore])
+	case op >= OP_istore_0 && op <= OP_astore_3:
+		return a.storeLocal(s, (op-OP_istore_0)%4, loadStoreTypes[(op-OP_istore_0)/4])
+	case op >= OP_iastore && op <= OP_sastore:
+		return s.popN(3)
+	case op == OP_pop:
+		_, err := s.popSlots(1)
+		return err
+	case op == OP_pop2:
+		_, err := s.popSlots(2)
+		return err
+	case op >= OP_dup && op <= OP_swap:
+		// 按照槽位处理 dup 系列指令，long 和 double 的两个槽位会被整体复制
+		counts := map[int][2]int{
+			OP_dup: {1, 0}, OP_dup_x1: {1, 1}, OP_dup_x2: {1, 2},
+			OP_dup2: {2, 0}, OP_dup2_x1: {2, 1}, OP_dup2_x2: {2, 2},
+		}
+		if op == OP_swap {
+			slots, err := s.popSlots(2)
+			if err != nil {
+				return err
+			}
+			s.stack = append(s.stack, slots[1], slots[0])
+			return nil
+		}
+		count := counts[op]
+		top, err := s.popSlots(count[0])
+		if err != nil {
+			return err
+		}
+		under, err := s.popSlots(count[1])
+		if err != nil {
+			return err
+		}
+		s.stack = append(s.stack, top...)
+		s.stack = append(s.stack, under...)
+		s.stack = append(s.stack, top...)
+	case op >= OP_iadd && op <= OP_drem:
+		if err := s.popN(2); err != nil {
+			return err
+		}
+		s.push(loadStoreTypes[(op-OP_iadd)%4])
+	case op >= OP_ineg && op <= OP_dneg:
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		s.push(loadStoreTypes[op-OP_ineg])
+	case op >= OP_ishl && op <= OP_lxor:
+		if err := s.popN(2); err != nil {
+			return err
+		}
+		s.push(loadStoreTypes[(op-OP_ishl)%2])
+	case op == OP_iinc:
+		if t := s.load(int(inst.Index)); t != typeInteger {
+			return utils.Errorf("iinc local %d is %v, not int", inst.Index, t)
+		}
+	case op >= OP_i2l && op <= OP_i2s:
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		s.push(conversionTypes[op-OP_i2l])
+	case op >= OP_lcmp && op <= OP_dcmpg:
+		if err := s.popN(2); err != nil {
+			return err
+		}
+		s.push(typeInteger)
+	case op >= OP_ifeq && op <= OP_ifle, op == OP_ifnull, op == OP_ifnonnull,
+		op == OP_tableswitch, op == OP_lookupswitch, op >= OP_ireturn && op <= OP_areturn,
+		op == OP_putstatic, op == OP_athrow, op == OP_monitorenter, op == OP_monitorexit:
+		return s.popN(1)
+	case op >= OP_if_icmpeq && op <= OP_if_acmpne, op == OP_putfield:
+		return s.popN(2)
+	case op == OP_jsr, op == OP_jsr_w:
+		s.push(typeReturnAddress)
+	case op == OP_ret:
+		if t := s.load(int(inst.Index)); t != typeReturnAddress {
+			return utils.Errorf("ret local %d is %v, not returnAddress", inst.Index, t)
+		}
+	case op == OP_getstatic, op == OP_getfield:
+		ref, err := a.obj.getMemberRef(inst.Index)
+		if err != nil {
+			return err
+		}
+		if op == OP_getfield {
+			if err := s.popN(1); err != nil {
+				return err
+			}
+		}
+		s.push(descriptorType(ref.Desc))
+	case op >= OP_invokevirtual && op <= OP_invokedynamic:
+		return a.executeInvoke(inst, s)
+	case op == OP_new:
+		s.push(verificationType{tag: VerificationUninitialized, offset: inst.Offset})
+	case op == OP_newarray:
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		desc, ok := newArrayDescriptors[uint8(inst.Value)]
+		if !ok {
+			return utils.Errorf("invalid newarray type %d", inst.Value)
+		}
+		s.push(objectType("[" + desc))
+	case op == OP_anewarray, op == OP_checkcast:
+		class, err := a.obj.getClassNameByIndex(inst.Index)
+		if err != nil {
+			return err
+		}
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		if op == OP_anewarray {
+			class = "[" + classDescriptor(class)
+		}
+		s.push(objectType(class))
+	case op == OP_arraylength, op == OP_instanceof:
+		if err := s.popN(1); err != nil {
+			return err
+		}
+		s.push(typeInteger)
+	case op == OP_multianewarray:
+		class, err := a.obj.getClassNameByIndex(inst.Index)
+		if err != nil {
+			return err
+		}
+		if err := s.popN(int(inst.Value)); err != nil {
+			return err
+		}
+		s.push(objectType(class))
+	default:
+		return utils.Errorf("unsupported opcode 0x%02x", op)
+	}
+	return nil
+}
+
+func (a *frameAnalyzer) executeInvoke(inst *Instruction, s *frameState) error {
+	var name, desc string
+	if inst.Opcode == OP_invokedynamic {
+		info, err := a.obj.getConstantInfo(inst.Index)
+		if err != nil {
+			return err
+		}
+		indy, ok := info.(*ConstantInvokeDynamicInfo)
+		if !ok {
+			return utils.Errorf("index %d is not ConstantInvokeDynamicInfo", inst.Index)
+		}
+		name, desc, err = a.obj.getNameAndType(indy.NameAndTypeIndex)
+		if err != nil {
+			return err
+		}
+	} else {
+		ref, err := a.obj.getMemberRef(inst.Index)
+		if err != nil {
+			return err
+		}
+		name, desc = ref.Name, ref.Desc
+	}
+	params, ret, err := parseMethodDescriptor(desc)
+	if err != nil {
+		return err
+	}
+	if err := s.popN(len(params)); err != nil {
+		return err
+	}
+	if inst.Opcode != OP_invokestatic && inst.Opcode != OP_invokedynamic {
+		receiver, err := s.pop()
+		if err != nil {
+			return err
+		}
+		if inst.Opcode == OP_invokespecial && name == "<init>" {
+			var initialized verificationType
+			switch receiver.tag {
+			case VerificationUninitializedThis:
+				initialized = objectType(a.className)
+			case VerificationUninitialized:
+				class, ok := a.newTypes[receiver.offset]
+				if !ok {
+					return utils.Errorf("no new instruction at %d", receiver.offset)
+				}
+				initialized = objectType(class)
+			default:
+				return utils.Errorf("call <init> on initialized %v", receiver)
+			}
+			for i := range s.locals {
+				if s.locals[i] == receiver {
+					s.locals[i] = initialized
+				}
+			}
+			for i := range s.stack {
+				if s.stack[i] == receiver {
+					s.stack[i] = initialized
+				}
+			}
+		}
+	}
+	if ret != "V" {
+		s.push(descriptorType(ret))
+	}
+	return nil
+}
+
+func (a *frameAnalyzer) successors(i int, in, out *frameState) (map[int]*frameState, error) {
+	inst := a.insts[i]
+	next := map[int]*frameState{}
+	switch {
+	case inst.Opcode == OP_jsr || inst.Opcode == OP_jsr_w:
+		// 子程序返回之后继续执行下一条指令，这里只用于计算 max_stack
+		next[inst.Target] = out
+		if i+1 < len(a.insts) {
+			next[a.insts[i+1].Offset] = in
+		}
+		return next, nil
+	case inst.IsBranch():
+		next[inst.Target] = out
+	case inst.IsSwitch():
+		next[inst.Default] = out
+		for _, target := range inst.Targets {
+			next[target] = out
+		}
+	}
+	if !inst.IsUnconditional() {
+		if i+1 >= len(a.insts) {
+			return nil, utils.Errorf("%s at %d falls off the end of code", inst.Name(), inst.Offset)
+		}
+		next[a.insts[i+1].Offset] = out
+	}
+	return next, nil
+}
+
+func (a *frameAnalyzer) analyze() error {
+	initial, err := a.initialFrame()
+	if err != nil {
+		return err
+	}
+	a.maxLocals = len(initial.locals)
+	a.frames = make([]*frameState, len(a.insts))
+	a.frames[0] = initial
+	worklist := []int{0}
+	queued := map[int]bool{0: true}
+	for len(worklist) > 0 {
+		i := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		queued[i] = false
+
+		inst := a.insts[i]
+		in := a.frames[i]
+		out := in.clone()
+		if err := a.execute(inst, out); err != nil {
+			return utils.Errorf("%s at %d: %v", inst.Name(), inst.Offset, err)
+		}
+		for _, state := range []*frameState{in, out} {
+			if len(state.stack) > a.maxStack {
+				a.maxStack = len(state.stack)
+			}
+			if len(state.locals) > a.maxLocals {
+				a.maxLocals = len(state.locals)
+			}
+		}
+
+		enqueue := func(offset int, state *frameState) error {
+			index, ok := a.index[offset]
+			if !ok {
+				return utils.Errorf("%s at %d jumps to invalid offset %d", inst.Name(), inst.Offset, offset)
+			}
+			changed, err := a.merge(index, state)
+			if err != nil {
+				return err
+			}
+			if changed && !queued[index] {
+				queued[index] = true
+				worklist = append(worklist, index)
+			}
+			return nil
+		}
+
+		for _, handler := range a.handlers {
+			if inst.Offset < int(handler.StartPc) || inst.Offset >= int(handler.EndPc) {
+				continue
+			}
+			catchType := objectType("java/lang/Throwable")
+			if handler.CatchType != 0 {
+				class, err := a.obj.getClassNameByIndex(handler.CatchType)
+				if err != nil {
+					return err
+				}
+				catchType = objectType(class)
+			}
+			for _, state := range []*frameState{in, out} {
+				if err := enqueue(int(handler.HandlerPc), &frameState{locals: state.locals, stack: []verificationType{catchType}}); err != nil {
+					return err
+				}
+			}
+		}
+
+		next, err := a.successors(i, in, out)
+		if err != nil {
+			return err
+		}
+		offsets := make([]int, 0, len(next))
+		for offset := range next {
+			offsets = append(offsets, offset)
+		}
+		sort.Ints(offsets)
+		for _, offset := range offsets {
+			if err := enqueue(offset, next[offset]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// computeFrames 计算 code 的 max_stack、max_locals，并在需要的时候生成 StackMapTable
+func (this *ClassObject) computeFrames(builder *constantPoolBuilder, method *MemberInfo, code *CodeAttribute, opts ...FrameOption) error {
+	name, err := this.getUtf8Value(method.NameIndex)
+	if err != nil {
+		return err
+	}
+	desc, err := this.getUtf8Value(method.DescriptorIndex)
+	if err != nil {
+		return err
+	}
+	a := &frameAnalyzer{
+		obj:       this,
+		builder:   builder,
+		className: this.GetClassName(),
+		static:    method.AccessFlags&0x0008 != 0,
+		name:      name,
+		desc:      desc,
+		index:     make(map[int]int),
+		handlers:  code.ExceptionTable,
+		newTypes:  make(map[int]string),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.insts, err = DecodeInstructions(code.Code)
+	if err != nil {
+		return err
+	}
+	if len(a.insts) == 0 {
+		return utils.Errorf("method %s%s has empty code", name, desc)
+	}
+	hasSubroutine := false
+	for i, inst := range a.insts {
+		a.index[inst.Offset] = i
+		switch inst.Opcode {
+		case OP_new:
+			class, err := this.getClassNameByIndex(inst.Index)
+			if err != nil {
+				return err
+			}
+			a.newTypes[inst.Offset] = class
+		case OP_jsr, OP_jsr_w, OP_ret:
+			hasSubroutine = true
+		}
+	}
+	a.index[len(code.Code)] = len(a.insts)
+	for _, attr := range code.Attributes {
+		table, ok := attr.(*LocalVariableTableAttribute)
+		if !ok {
+			continue
+		}
+		for _, entry := range table.LocalVariableTable {
+			desc, err := this.getUtf8Value(entry.DescriptorIndex)
+			if err != nil {
+				continue
+			}
+			a.hints = append(a.hints, &localVariableHint{
+				start: int(entry.StartPc), end: int(entry.StartPc) + int(entry.Length), index: int(entry.Index), desc: desc,
+			})
+		}
+	}
+
+	needFrames := this.MajorVersion >= 50
+	if needFrames && hasSubroutine {
+		if this.MajorVersion >= 51 {
+			return utils.Errorf("method %s%s: jsr/ret is not allowed in class version %d", name, desc, this.MajorVersion)
+		}
+		needFrames = false
+	}
+	if err := a.analyze(); err != nil {
+		return utils.Errorf("method %s%s: %v", name, desc, err)
+	}
+
+	// 不可达的代码替换为 nop ... athrow，并且从异常表中去掉，这样就不需要为它们推导类型
+	framePoints := map[int]*frameState{}
+	deadFrame := &frameState{stack: []verificationType{objectType("java/lang/Throwable")}}
+	newCode := append([]byte(nil), code.Code...)
+	for i := 0; i < len(a.insts); {
+		if a.frames[i] != nil {
+			i++
+			continue
+		}
+		start := i
+		for i < len(a.insts) && a.frames[i] == nil {
+			i++
+		}
+		begin, end := a.insts[start].Offset, len(code.Code)
+		if i < len(a.insts) {
+			end = a.insts[i].Offset
+		}
+		for pc := begin; pc < end-1; pc++ {
+			newCode[pc] = OP_nop
+		}
+		newCode[end-1] = OP_athrow
+		framePoints[begin] = deadFrame
+		if a.maxStack < 1 {
+			a.maxStack = 1
+		}
+	}
+	var handlers []*ExceptionTableEntry
+	for _, handler := range code.ExceptionTable {
+		start := -1
+		for i, inst := range a.insts {
+			live := a.frames[i] != nil && inst.Offset >= int(handler.StartPc) && inst.Offset < int(handler.EndPc)
+			if live && start < 0 {
+				start = inst.Offset
+			}
+			if !live && start >= 0 && (inst.Offset >= int(handler.StartPc)) {
+				handlers = append(handlers, &ExceptionTableEntry{StartPc: uint16(start), EndPc: uint16(inst.Offset), HandlerPc: handler.HandlerPc, CatchType: handler.CatchType})
+				start = -1
+			}
+		}
+		if start >= 0 {
+			handlers = append(handlers, &ExceptionTableEntry{StartPc: uint16(start), EndPc: handler.EndPc, HandlerPc: handler.HandlerPc, CatchType: handler.CatchType})
+		}
+	}
+	for _, handler := range handlers {
+		framePoints[int(handler.HandlerPc)] = a.frames[a.index[int(handler.HandlerPc)]]
+	}
+	for i, inst := range a.insts {
+		if a.frames[i] == nil {
+			continue
+		}
+		var targets []int
+		if inst.IsBranch() {
+			targets = append(targets, inst.Target)
+		} else if inst.IsSwitch() {
+			targets = append(append(targets, inst.Default), inst.Targets...)
+		}
+		for _, target := range targets {
+			framePoints[target] = a.frames[a.index[target]]
+		}
+	}
+
+	code.Code = newCode
+	code.ExceptionTable = handlers
+	code.MaxStack = uint16(a.maxStack)
+	code.MaxLocals = uint16(a.maxLocals)
+	for _, hint := range a.hints {
+		size := 1
+		if descriptorType(hint.desc).isWide() {
+			size = 2
+		}
+		if hint.index+size > int(code.MaxLocals) {
+			code.MaxLocals = uint16(hint.index + size)
+		}
+	}
+
+	var attributes []AttributeInfo
+	for _, attr := range code.Attributes {
+		switch ret := attr.(type) {
+		case *StackMapTableAttribute:
+			continue
+		case *UnparsedAttribute:
+			if ret.Name == "StackMapTable" {
+				continue
+			}
+		}
+		attributes = append(attributes, attr)
+	}
+	code.Attributes = attributes
+	if !needFrames || len(framePoints) == 0 {
+		return nil
+	}
+
+	initial, _ := a.initialFrame()
+	stackMap := &StackMapTableAttribute{}
+	builder.utf8("StackMapTable")
+	offsets := make([]int, 0, len(framePoints))
+	for offset := range framePoints {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	prevLocals := toVerificationList(initial.locals, true)
+	prevOffset := -1
+	for _, offset := range offsets {
+		state := framePoints[offset]
+		locals := toVerificationList(state.locals, true)
+		stack := toVerificationList(state.stack, false)
+		for _, t := range append(append([]verificationType(nil), locals...), stack...) {
+			if t == typeReturnAddress {
+				return utils.Errorf("method %s%s: returnAddress in stack map frame at %d", name, desc, offset)
+			}
+		}
+		delta := offset - prevOffset - 1
+		frame := &StackMapFrame{OffsetDelta: uint16(delta)}
+		switch {
+		case len(stack) == 0 && equalVerificationList(locals, prevLocals):
+			if delta <= StackMapFrameSameMax {
+				frame.FrameType = uint8(delta)
+			} else {
+				frame.FrameType = StackMapFrameSameExt
+			}
+		case len(stack) == 1 && equalVerificationList(locals, prevLocals):
+			if delta <= StackMapFrameSameLocals1StackMax-64 {
+				frame.FrameType = uint8(64 + delta)
+			} else {
+				frame.FrameType = StackMapFrameSameLocals1StackExt
+			}
+			frame.Stack = a.toVerificationInfos(stack)
+		case len(stack) == 0 && len(locals) > len(prevLocals) && len(locals)-len(prevLocals) <= 3 &&
+			equalVerificationList(locals[:len(prevLocals)], prevLocals):
+			frame.FrameType = uint8(StackMapFrameSameExt + len(locals) - len(prevLocals))
+			frame.Locals = a.toVerificationInfos(locals[len(prevLocals):])
+		case len(stack) == 0 && len(locals) < len(prevLocals) && len(prevLocals)-len(locals) <= 3 &&
+			equalVerificationList(locals, prevLocals[:len(locals)]):
+			frame.FrameType = uint8(StackMapFrameSameExt - (len(prevLocals) - len(locals)))
+		default:
+			frame.FrameType = StackMapFrameFull
+			frame.Locals = a.toVerificationInfos(locals)
+			frame.Stack = a.toVerificationInfos(stack)
+		}
+		stackMap.Entries = append(stackMap.Entries, frame)
+		prevLocals = locals
+		prevOffset = offset
+	}
+	code.Attributes = append(code.Attributes, stackMap)
+	return nil
+}
+
+func (a *frameAnalyzer) toVerificationInfos(types []verificationType) []*VerificationTypeInfo {
+	infos := make([]*VerificationTypeInfo, len(types))
+	for i, t := range types {
+		info := &VerificationTypeInfo{Tag: t.tag}
+		switch t.tag {
+		case VerificationObject:
+			info.CpoolIndex = a.builder.class(t.class)
+		case VerificationUninitialized:
+			info.Offset = uint16(t.offset)
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+// ComputeMaxsAndFrames 根据方法的字节码重新计算 max_stack、max_locals 和 StackMapTable，
+// 修改了字节码之后需要调用，不可达的代码会被替换为 nop 和 athrow
+func (this *ClassObject) ComputeMaxsAndFrames(opts ...FrameOption) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("compute frames failed: %v", r)
+		}
+	}()
+	builder := newConstantPoolBuilder(this)
+	for _, method := range this.Methods {
+		for _, attr := range method.Attributes {
+			if code, ok := attr.(*CodeAttribute); ok {
+				if err := this.computeFrames(builder, method, code, opts...); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
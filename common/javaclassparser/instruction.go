@@ -0,0 +1,274 @@
+package javaclassparser
+
+import (
+	"encoding/binary"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+/*
+*
+一条 JVM 指令，跳转目标都是相对于方法开头的绝对偏移
+
+	Index   局部变量下标或者常量池下标
+	Value   bipush/sipush 的立即数、iinc 的增量、newarray 的元素类型、multianewarray 的维度
+	Target  条件跳转、goto、jsr 的目标
+	Default/Low/Keys/Targets  tableswitch 和 lookupswitch 的跳转表，tableswitch 的 Keys 为 Low 开始的连续整数
+*/
+type Instruction struct {
+	Offset  int
+	Opcode  uint8
+	Wide    bool
+	Index   uint16
+	Value   int32
+	Target  int
+	Default int
+	Low     int32
+	Keys    []int32
+	Targets []int
+}
+
+func (i *Instruction) Name() string {
+	return GetOpcodeName(i.Opcode)
+}
+
+func (i *Instruction) operand() operandKind {
+	if info := opcodeTable[i.Opcode]; info != nil {
+		return info.operand
+	}
+	return operandNone
+}
+
+// IsBranch 是否为 if*、goto、jsr 这类带一个跳转目标的指令
+func (i *Instruction) IsBranch() bool {
+	kind := i.operand()
+	return kind == operandBranch || kind == operandBranchWide
+}
+
+// IsSwitch 是否为 tableswitch 或 lookupswitch
+func (i *Instruction) IsSwitch() bool {
+	return i.Opcode == OP_tableswitch || i.Opcode == OP_lookupswitch
+}
+
+// IsUnconditional 执行之后不会继续执行下一条指令
+func (i *Instruction) IsUnconditional() bool {
+	switch i.Opcode {
+	case OP_goto, OP_goto_w, OP_ret, OP_tableswitch, OP_lookupswitch, OP_athrow,
+		OP_ireturn, OP_lreturn, OP_freturn, OP_dreturn, OP_areturn, OP_return:
+		return true
+	}
+	return false
+}
+
+// Size 返回指令编码之后的长度，switch 指令的长度和所在的偏移有关
+func (i *Instruction) Size() int {
+	switch i.operand() {
+	case operandByte, operandLocal, operandConstU1, operandNewArray:
+		if i.Wide {
+			return 4
+		}
+		return 2
+	case operandIinc:
+		if i.Wide {
+			return 6
+		}
+		return 3
+	case operandShort, operandConstU2, operandField, operandMethod, operandClass, operandBranch:
+		return 3
+	case operandMultiANewArray:
+		return 4
+	case operandInterfaceMethod, operandDynamic, operandBranchWide:
+		return 5
+	case operandTableSwitch:
+		return 1 + switchPadding(i.Offset) + 12 + 4*len(i.Targets)
+	case operandLookupSwitch:
+		return 1 + switchPadding(i.Offset) + 8 + 8*len(i.Targets)
+	}
+	return 1
+}
+
+func switchPadding(offset int) int {
+	return (4 - (offset+1)%4) % 4
+}
+
+// DecodeInstructions 把 Code 属性中的字节码解码成指令列表
+func DecodeInstructions(code []byte) (insts []*Instruction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			insts = nil
+			err = utils.Errorf("decode bytecode failed: %v", r)
+		}
+	}()
+
+	u1 := func(pos int) int { return int(code[pos]) }
+	s1 := func(pos int) int32 { return int32(int8(code[pos])) }
+	u2 := func(pos int) int { return int(binary.BigEndian.Uint16(code[pos:])) }
+	s2 := func(pos int) int32 { return int32(int16(binary.BigEndian.Uint16(code[pos:]))) }
+	s4 := func(pos int) int32 { return int32(binary.BigEndian.Uint32(code[pos:])) }
+
+	for pc := 0; pc < len(code); {
+		inst := &Instruction{Offset: pc, Opcode: code[pc]}
+		info := opcodeTable[inst.Opcode]
+		if info == nil {
+			return nil, utils.Errorf("unknown opcode 0x%02x at %d", inst.Opcode, pc)
+		}
+		switch info.operand {
+		case operandByte:
+			inst.Value = s1(pc + 1)
+		case operandShort:
+			inst.Value = s2(pc + 1)
+		case operandLocal:
+			inst.Index = uint16(u1(pc + 1))
+		case operandIinc:
+			inst.Index = uint16(u1(pc + 1))
+			inst.Value = s1(pc + 2)
+		case operandConstU1:
+			inst.Index = uint16(u1(pc + 1))
+		case operandConstU2, operandField, operandMethod, operandDynamic, operandClass:
+			inst.Index = uint16(u2(pc + 1))
+		case operandInterfaceMethod:
+			inst.Index = uint16(u2(pc + 1))
+			inst.Value = int32(u1(pc + 3))
+		case operandNewArray:
+			inst.Value = int32(u1(pc + 1))
+		case operandMultiANewArray:
+			inst.Index = uint16(u2(pc + 1))
+			inst.Value = int32(u1(pc + 3))
+		case operandBranch:
+			inst.Target = pc + int(s2(pc+1))
+		case operandBranchWide:
+			inst.Target = pc + int(s4(pc+1))
+		case operandTableSwitch:
+			pos := pc + 1 + switchPadding(pc)
+			inst.Default = pc + int(s4(pos))
+			inst.Low = s4(pos + 4)
+			high := s4(pos + 8)
+			if high < inst.Low {
+				return nil, utils.Errorf("invalid tableswitch at %d: low %d > high %d", pc, inst.Low, high)
+			}
+			pos += 12
+			for key := int64(inst.Low); key <= int64(high); key++ {
+				inst.Keys = append(inst.Keys, int32(key))
+				inst.Targets = append(inst.Targets, pc+int(s4(pos)))
+				pos += 4
+			}
+		case operandLookupSwitch:
+			pos := pc + 1 + switchPadding(pc)
+			inst.Default = pc + int(s4(pos))
+			n := int(s4(pos + 4))
+			if n < 0 {
+				return nil, utils.Errorf("invalid lookupswitch at %d: npairs %d", pc, n)
+			}
+			pos += 8
+			for j := 0; j < n; j++ {
+				inst.Keys = append(inst.Keys, s4(pos))
+				inst.Targets = append(inst.Targets, pc+int(s4(pos+4)))
+				pos += 8
+			}
+		case operandWide:
+			inst.Opcode = code[pc+1]
+			inst.Wide = true
+			switch inst.operand() {
+			case operandLocal:
+				inst.Index = uint16(u2(pc + 2))
+			case operandIinc:
+				inst.Index = uint16(u2(pc + 2))
+				inst.Value = s2(pc + 4)
+			default:
+				return nil, utils.Errorf("invalid opcode 0x%02x after wide at %d", inst.Opcode, pc)
+			}
+		}
+		size := inst.Size()
+		if pc+size > len(code) {
+			return nil, utils.Errorf("truncated instruction %s at %d", info.name, pc)
+		}
+		insts = append(insts, inst)
+		pc += size
+	}
+	return insts, nil
+}
+
+// EncodeInstructions 把指令编码成字节码，指令的 Offset 必须已经是最终的位置
+func EncodeInstructions(insts []*Instruction) ([]byte, error) {
+	writer := NewJavaBufferWrite()
+	for _, inst := range insts {
+		if len(writer.Bytes()) != inst.Offset {
+			return nil, utils.Errorf("instruction %s offset mismatch: %d != %d", inst.Name(), inst.Offset, len(writer.Bytes()))
+		}
+		relative := func(target int) int { return target - inst.Offset }
+		if inst.Wide {
+			writer.Write1Byte(OP_wide)
+		}
+		writer.Write1Byte(inst.Opcode)
+		switch inst.operand() {
+		case operandByte:
+			if inst.Value < -128 || inst.Value > 127 {
+				return nil, utils.Errorf("bipush value %d out of range", inst.Value)
+			}
+			writer.Write1Byte(uint8(int8(inst.Value)))
+		case operandShort:
+			if inst.Value < -32768 || inst.Value > 32767 {
+				return nil, utils.Errorf("sipush value %d out of range", inst.Value)
+			}
+			writer.Write2Byte(uint16(int16(inst.Value)))
+		case operandLocal, operandConstU1:
+			if inst.Wide {
+				writer.Write2Byte(inst.Index)
+			} else {
+				if inst.Index > 0xff {
+					return nil, utils.Errorf("%s index %d out of range", inst.Name(), inst.Index)
+				}
+				writer.Write1Byte(inst.Index)
+			}
+		case operandIinc:
+			if inst.Wide {
+				writer.Write2Byte(inst.Index)
+				writer.Write2Byte(uint16(int16(inst.Value)))
+			} else {
+				writer.Write1Byte(inst.Index)
+				writer.Write1Byte(uint8(int8(inst.Value)))
+			}
+		case operandConstU2, operandField, operandMethod, operandClass:
+			writer.Write2Byte(inst.Index)
+		case operandInterfaceMethod:
+			writer.Write2Byte(inst.Index)
+			writer.Write1Byte(inst.Value)
+			writer.Write1Byte(0)
+		case operandDynamic:
+			writer.Write2Byte(inst.Index)
+			writer.Write2Byte(0)
+		case operandNewArray:
+			writer.Write1Byte(inst.Value)
+		case operandMultiANewArray:
+			writer.Write2Byte(inst.Index)
+			writer.Write1Byte(inst.Value)
+		case operandBranch:
+			offset := relative(inst.Target)
+			if offset < -32768 || offset > 32767 {
+				return nil, utils.Errorf("%s at %d: branch offset %d out of range", inst.Name(), inst.Offset, offset)
+			}
+			writer.Write2Byte(uint16(int16(offset)))
+		case operandBranchWide:
+			writer.Write4Byte(uint32(int32(relative(inst.Target))))
+		case operandTableSwitch, operandLookupSwitch:
+			for i := 0; i < switchPadding(inst.Offset); i++ {
+				writer.Write1Byte(0)
+			}
+			writer.Write4Byte(uint32(int32(relative(inst.Default))))
+			if inst.Opcode == OP_tableswitch {
+				writer.Write4Byte(uint32(inst.Low))
+				writer.Write4Byte(uint32(inst.Low + int32(len(inst.Targets)) - 1))
+				for _, target := range inst.Targets {
+					writer.Write4Byte(uint32(int32(relative(target))))
+				}
+			} else {
+				writer.Write4Byte(len(inst.Targets))
+				for i, target := range inst.Targets {
+					writer.Write4Byte(uint32(inst.Keys[i]))
+					writer.Write4Byte(uint32(int32(relative(target))))
+				}
+			}
+		}
+	}
+	return writer.Bytes(), nil
+}
@@ -10,30 +10,33 @@ import (
 )
 
 const (
-	ClassObjectType              = "ClassObject"
-	MemberInfoType               = "MemberInfo"
-	ConstantInteger              = "ConstantInteger"
-	ConstantFloat                = "ConstantFloat"
-	ConstantLong                 = "ConstantLong"
-	ConstantDouble               = "ConstantDouble"
-	ConstantUtf8                 = "ConstantUtf8"
-	ConstantString               = "ConstantString"
-	ConstantClass                = "ConstantClass"
-	ConstantFieldref             = "ConstantFieldref"
-	ConstantMethodref            = "ConstantMethodref"
-	ConstantInterfaceMethodref   = "ConstantInterfaceMethodref"
-	ConstantNameAndType          = "ConstantNameAndType"
-	ConstantMethodType           = "ConstantMethodType"
-	ConstantMethodHandle         = "ConstantMethodHandle"
-	ConstantInvokeDynamic        = "ConstantInvokeDynamic"
-	CodeAttributeType            = "CodeAttribute"
-	ConstantValueAttributeType   = "ConstantValueAttribute"
-	DeprecatedAttributeType      = "DeprecatedAttribute"
-	ExceptionsAttributeType      = "ExceptionsAttribute"
-	LineNumberTableAttributeType = "LineNumberTableAttribute"
-	SourceFileAttributeType      = "SourceFileAttribute"
-	SyntheticAttributeType       = "SyntheticAttribute"
-	UnparsedAttributeType        = "UnparsedAttribute"
+	ClassObjectType                 = "ClassObject"
+	MemberInfoType                  = "MemberInfo"
+	ConstantInteger                 = "ConstantInteger"
+	ConstantFloat                   = "ConstantFloat"
+	ConstantLong                    = "ConstantLong"
+	ConstantDouble                  = "ConstantDouble"
+	ConstantUtf8                    = "ConstantUtf8"
+	ConstantString                  = "ConstantString"
+	ConstantClass                   = "ConstantClass"
+	ConstantFieldref                = "ConstantFieldref"
+	ConstantMethodref               = "ConstantMethodref"
+	ConstantInterfaceMethodref      = "ConstantInterfaceMethodref"
+	ConstantNameAndType             = "ConstantNameAndType"
+	ConstantMethodType              = "ConstantMethodType"
+	ConstantMethodHandle            = "ConstantMethodHandle"
+	ConstantInvokeDynamic           = "ConstantInvokeDynamic"
+	CodeAttributeType               = "CodeAttribute"
+	ConstantValueAttributeType      = "ConstantValueAttribute"
+	DeprecatedAttributeType         = "DeprecatedAttribute"
+	ExceptionsAttributeType         = "ExceptionsAttribute"
+	LineNumberTableAttributeType    = "LineNumberTableAttribute"
+	LocalVariableTableAttributeType = "LocalVariableTableAttribute"
+	StackMapTableAttributeType      = "StackMapTableAttribute"
+	BootstrapMethodsAttributeType   = "BootstrapMethodsAttribute"
+	SourceFileAttributeType         = "SourceFileAttribute"
+	SyntheticAttributeType          = "SyntheticAttribute"
+	UnparsedAttributeType           = "UnparsedAttribute"
 )
 
 func _MarshalJavaClass(cp *ClassObject, charLength int) []byte {
@@ -154,22 +157,86 @@ func writeAttributes(writer *JavaBufferWriter, info []AttributeInfo, classObj *C
 			codeAttr := info[j].(*CodeAttribute)
 			n := classObj.findUtf8IndexFromPool("Code") + 1
 			writer.Write2Byte(n)
-			writer.Write4Byte(codeAttr.AttrLen)
-			writer.Write2Byte(codeAttr.MaxStack)
-			writer.Write2Byte(codeAttr.MaxLocals)
+			// 字节码可能被修改过，属性长度按实际写入的内容计算
+			body := NewJavaBufferWrite()
+			body.charLength = writer.charLength
+			body.Write2Byte(codeAttr.MaxStack)
+			body.Write2Byte(codeAttr.MaxLocals)
 			codel := len(codeAttr.Code)
-			writer.Write4Byte(codel)
-			writer.Write(codeAttr.Code)
+			body.Write4Byte(codel)
+			body.Write(codeAttr.Code)
 
 			exceptionTable := codeAttr.ExceptionTable
-			writer.Write2Byte(len(exceptionTable))
+			body.Write2Byte(len(exceptionTable))
 			for exceptionTableIndex := 0; exceptionTableIndex < len(exceptionTable); exceptionTableIndex++ {
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].StartPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].EndPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].HandlerPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].CatchType)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].StartPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].EndPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].HandlerPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].CatchType)
+			}
+			writeAttributes(body, codeAttr.Attributes, classObj)
+			writer.Write4Byte(len(body.Bytes()))
+			writer.Write(body.Bytes())
+		case *LocalVariableTableAttribute:
+			n := classObj.findUtf8IndexFromPool("LocalVariableTable") + 1
+			writer.Write2Byte(n)
+			table := info[j].(*LocalVariableTableAttribute).LocalVariableTable
+			writer.Write4Byte(2 + 10*len(table))
+			writer.Write2Byte(len(table))
+			for _, entry := range table {
+				writer.Write2Byte(entry.StartPc)
+				writer.Write2Byte(entry.Length)
+				writer.Write2Byte(entry.NameIndex)
+				writer.Write2Byte(entry.DescriptorIndex)
+				writer.Write2Byte(entry.Index)
+			}
+		case *StackMapTableAttribute:
+			n := classObj.findUtf8IndexFromPool("StackMapTable") + 1
+			writer.Write2Byte(n)
+			body := NewJavaBufferWrite()
+			entries := info[j].(*StackMapTableAttribute).Entries
+			body.Write2Byte(len(entries))
+			for _, frame := range entries {
+				body.Write1Byte(frame.FrameType)
+				switch {
+				case frame.FrameType <= StackMapFrameSameMax:
+				case frame.FrameType <= StackMapFrameSameLocals1StackMax:
+					writeVerificationTypeInfos(body, frame.Stack)
+				case frame.FrameType == StackMapFrameSameLocals1StackExt:
+					body.Write2Byte(frame.OffsetDelta)
+					writeVerificationTypeInfos(body, frame.Stack)
+				case frame.FrameType <= StackMapFrameSameExt:
+					body.Write2Byte(frame.OffsetDelta)
+				case frame.FrameType <= StackMapFrameAppendMax:
+					body.Write2Byte(frame.OffsetDelta)
+					writeVerificationTypeInfos(body, frame.Locals)
+				default:
+					body.Write2Byte(frame.OffsetDelta)
+					body.Write2Byte(len(frame.Locals))
+					writeVerificationTypeInfos(body, frame.Locals)
+					body.Write2Byte(len(frame.Stack))
+					writeVerificationTypeInfos(body, frame.Stack)
+				}
+			}
+			writer.Write4Byte(len(body.Bytes()))
+			writer.Write(body.Bytes())
+		case *BootstrapMethodsAttribute:
+			n := classObj.findUtf8IndexFromPool("BootstrapMethods") + 1
+			writer.Write2Byte(n)
+			methods := info[j].(*BootstrapMethodsAttribute).BootstrapMethods
+			length := 2
+			for _, method := range methods {
+				length += 4 + 2*len(method.BootstrapArguments)
+			}
+			writer.Write4Byte(length)
+			writer.Write2Byte(len(methods))
+			for _, method := range methods {
+				writer.Write2Byte(method.BootstrapMethodRef)
+				writer.Write2Byte(len(method.BootstrapArguments))
+				for _, argument := range method.BootstrapArguments {
+					writer.Write2Byte(argument)
+				}
 			}
-			writeAttributes(writer, codeAttr.Attributes, classObj)
 		case *ConstantValueAttribute:
 			n := classObj.findUtf8IndexFromPool("ConstantValue") + 1
 			writer.Write2Byte(n)
@@ -218,6 +285,18 @@ func writeAttributes(writer *JavaBufferWriter, info []AttributeInfo, classObj *C
 
 }
 
+func writeVerificationTypeInfos(writer *JavaBufferWriter, infos []*VerificationTypeInfo) {
+	for _, info := range infos {
+		writer.Write1Byte(info.Tag)
+		switch info.Tag {
+		case VerificationObject:
+			writer.Write2Byte(info.CpoolIndex)
+		case VerificationUninitialized:
+			writer.Write2Byte(info.Offset)
+		}
+	}
+}
+
 func _MarshalToJson(classObj *ClassObject) (string, error) {
 	AddVerboseAndType(classObj, classObj)
 	byteBuf := bytes.NewBuffer([]byte{})
@@ -283,6 +362,12 @@ func AddVerboseAndType(classObj *ClassObject, obj interface{}) {
 		ret.Type = ExceptionsAttributeType
 	case *LineNumberTableAttribute:
 		ret.Type = LineNumberTableAttributeType
+	case *LocalVariableTableAttribute:
+		ret.Type = LocalVariableTableAttributeType
+	case *StackMapTableAttribute:
+		ret.Type = StackMapTableAttributeType
+	case *BootstrapMethodsAttribute:
+		ret.Type = BootstrapMethodsAttributeType
 	case *SourceFileAttribute:
 		ret.Type = SourceFileAttributeType
 		ret.SourceFileIndexVerbose, _ = classObj.getUtf8(ret.SourceFileIndex)
@@ -453,6 +538,27 @@ func mapToClassObject(objData map[string]interface{}) (_ *ClassObject, err error
 			if err != nil {
 				return err
 			}
+		case LocalVariableTableAttributeType:
+			d := &LocalVariableTableAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
+		case StackMapTableAttributeType:
+			d := &StackMapTableAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
+		case BootstrapMethodsAttributeType:
+			d := &BootstrapMethodsAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
 		case SourceFileAttributeType:
 			d := &SourceFileAttribute{}
 			Attributes = append(Attributes, d)
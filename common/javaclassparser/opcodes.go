@@ -0,0 +1,311 @@
+package javaclassparser
+
+// 操作数的种类，决定了指令的长度和文本格式
+type operandKind int
+
+const (
+	operandNone            operandKind = iota
+	operandByte                        // bipush: s1
+	operandShort                       // sipush: s2
+	operandLocal                       // xload/xstore/ret: u1，wide 时为 u2
+	operandIinc                        // iinc: u1 u1，wide 时为 u2 s2
+	operandConstU1                     // ldc: u1
+	operandConstU2                     // ldc_w/ldc2_w: u2
+	operandField                       // get/put field/static: u2
+	operandMethod                      // invokevirtual/invokespecial/invokestatic: u2
+	operandInterfaceMethod             // invokeinterface: u2 u1 0
+	operandDynamic                     // invokedynamic: u2 0 0
+	operandClass                       // new/anewarray/checkcast/instanceof: u2
+	operandNewArray                    // newarray: u1
+	operandMultiANewArray              // multianewarray: u2 u1
+	operandBranch                      // if*/goto/jsr: s2
+	operandBranchWide                  // goto_w/jsr_w: s4
+	operandTableSwitch
+	operandLookupSwitch
+	operandWide
+)
+
+const (
+	OP_nop             = 0x00
+	OP_aconst_null     = 0x01
+	OP_iconst_m1       = 0x02
+	OP_iconst_0        = 0x03
+	OP_iconst_1        = 0x04
+	OP_iconst_2        = 0x05
+	OP_iconst_3        = 0x06
+	OP_iconst_4        = 0x07
+	OP_iconst_5        = 0x08
+	OP_lconst_0        = 0x09
+	OP_lconst_1        = 0x0a
+	OP_fconst_0        = 0x0b
+	OP_fconst_1        = 0x0c
+	OP_fconst_2        = 0x0d
+	OP_dconst_0        = 0x0e
+	OP_dconst_1        = 0x0f
+	OP_bipush          = 0x10
+	OP_sipush          = 0x11
+	OP_ldc             = 0x12
+	OP_ldc_w           = 0x13
+	OP_ldc2_w          = 0x14
+	OP_iload           = 0x15
+	OP_lload           = 0x16
+	OP_fload           = 0x17
+	OP_dload           = 0x18
+	OP_aload           = 0x19
+	OP_iload_0         = 0x1a
+	OP_lload_0         = 0x1e
+	OP_fload_0         = 0x22
+	OP_dload_0         = 0x26
+	OP_aload_0         = 0x2a
+	OP_aload_3         = 0x2d
+	OP_iaload          = 0x2e
+	OP_laload          = 0x2f
+	OP_faload          = 0x30
+	OP_daload          = 0x31
+	OP_aaload          = 0x32
+	OP_baload          = 0x33
+	OP_caload          = 0x34
+	OP_saload          = 0x35
+	OP_istore          = 0x36
+	OP_lstore          = 0x37
+	OP_fstore          = 0x38
+	OP_dstore          = 0x39
+	OP_astore          = 0x3a
+	OP_istore_0        = 0x3b
+	OP_lstore_0        = 0x3f
+	OP_fstore_0        = 0x43
+	OP_dstore_0        = 0x47
+	OP_astore_0        = 0x4b
+	OP_astore_3        = 0x4e
+	OP_iastore         = 0x4f
+	OP_sastore         = 0x56
+	OP_pop             = 0x57
+	OP_pop2            = 0x58
+	OP_dup             = 0x59
+	OP_dup_x1          = 0x5a
+	OP_dup_x2          = 0x5b
+	OP_dup2            = 0x5c
+	OP_dup2_x1         = 0x5d
+	OP_dup2_x2         = 0x5e
+	OP_swap            = 0x5f
+	OP_iadd            = 0x60
+	OP_ladd            = 0x61
+	OP_fadd            = 0x62
+	OP_dadd            = 0x63
+	OP_drem            = 0x73
+	OP_ineg            = 0x74
+	OP_lneg            = 0x75
+	OP_fneg            = 0x76
+	OP_dneg            = 0x77
+	OP_ishl            = 0x78
+	OP_lshl            = 0x79
+	OP_ishr            = 0x7a
+	OP_lshr            = 0x7b
+	OP_iushr           = 0x7c
+	OP_lushr           = 0x7d
+	OP_iand            = 0x7e
+	OP_land            = 0x7f
+	OP_ior             = 0x80
+	OP_lor             = 0x81
+	OP_ixor            = 0x82
+	OP_lxor            = 0x83
+	OP_iinc            = 0x84
+	OP_i2l             = 0x85
+	OP_i2f             = 0x86
+	OP_i2d             = 0x87
+	OP_l2i             = 0x88
+	OP_l2f             = 0x89
+	OP_l2d             = 0x8a
+	OP_f2i             = 0x8b
+	OP_f2l             = 0x8c
+	OP_f2d             = 0x8d
+	OP_d2i             = 0x8e
+	OP_d2l             = 0x8f
+	OP_d2f             = 0x90
+	OP_i2b             = 0x91
+	OP_i2c             = 0x92
+	OP_i2s             = 0x93
+	OP_lcmp            = 0x94
+	OP_fcmpl           = 0x95
+	OP_fcmpg           = 0x96
+	OP_dcmpl           = 0x97
+	OP_dcmpg           = 0x98
+	OP_ifeq            = 0x99
+	OP_ifne            = 0x9a
+	OP_iflt            = 0x9b
+	OP_ifge            = 0x9c
+	OP_ifgt            = 0x9d
+	OP_ifle            = 0x9e
+	OP_if_icmpeq       = 0x9f
+	OP_if_icmpne       = 0xa0
+	OP_if_icmplt       = 0xa1
+	OP_if_icmpge       = 0xa2
+	OP_if_icmpgt       = 0xa3
+	OP_if_icmple       = 0xa4
+	OP_if_acmpeq       = 0xa5
+	OP_if_acmpne       = 0xa6
+	OP_goto            = 0xa7
+	OP_jsr             = 0xa8
+	OP_ret             = 0xa9
+	OP_tableswitch     = 0xaa
+	OP_lookupswitch    = 0xab
+	OP_ireturn         = 0xac
+	OP_lreturn         = 0xad
+	OP_freturn         = 0xae
+	OP_dreturn         = 0xaf
+	OP_areturn         = 0xb0
+	OP_return          = 0xb1
+	OP_getstatic       = 0xb2
+	OP_putstatic       = 0xb3
+	OP_getfield        = 0xb4
+	OP_putfield        = 0xb5
+	OP_invokevirtual   = 0xb6
+	OP_invokespecial   = 0xb7
+	OP_invokestatic    = 0xb8
+	OP_invokeinterface = 0xb9
+	OP_invokedynamic   = 0xba
+	OP_new             = 0xbb
+	OP_newarray        = 0xbc
+	OP_anewarray       = 0xbd
+	OP_arraylength     = 0xbe
+	OP_athrow          = 0xbf
+	OP_checkcast       = 0xc0
+	OP_instanceof      = 0xc1
+	OP_monitorenter    = 0xc2
+	OP_monitorexit     = 0xc3
+	OP_wide            = 0xc4
+	OP_multianewarray  = 0xc5
+	OP_ifnull          = 0xc6
+	OP_ifnonnull       = 0xc7
+	OP_goto_w          = 0xc8
+	OP_jsr_w           = 0xc9
+	OP_breakpoint      = 0xca
+	OP_impdep1         = 0xfe
+	OP_impdep2         = 0xff
+)
+
+type opcodeInfo struct {
+	name    string
+	operand operandKind
+}
+
+var (
+	opcodeTable  [256]*opcodeInfo
+	opcodeByName = make(map[string]uint8)
+)
+
+// newarray 的元素类型
+var newArrayTypes = map[uint8]string{
+	4: "boolean", 5: "char", 6: "float", 7: "double",
+	8: "byte", 9: "short", 10: "int", 11: "long",
+}
+
+var newArrayDescriptors = map[uint8]string{
+	4: "Z", 5: "C", 6: "F", 7: "D",
+	8: "B", 9: "S", 10: "I", 11: "J",
+}
+
+func init() {
+	register := func(opcode uint8, name string, operand operandKind) {
+		opcodeTable[opcode] = &opcodeInfo{name: name, operand: operand}
+		opcodeByName[name] = opcode
+	}
+	names := []string{
+		"nop", "aconst_null", "iconst_m1", "iconst_0", "iconst_1", "iconst_2", "iconst_3", "iconst_4", "iconst_5",
+		"lconst_0", "lconst_1", "fconst_0", "fconst_1", "fconst_2", "dconst_0", "dconst_1",
+	}
+	for i, name := range names {
+		register(uint8(i), name, operandNone)
+	}
+	register(OP_bipush, "bipush", operandByte)
+	register(OP_sipush, "sipush", operandShort)
+	register(OP_ldc, "ldc", operandConstU1)
+	register(OP_ldc_w, "ldc_w", operandConstU2)
+	register(OP_ldc2_w, "ldc2_w", operandConstU2)
+
+	prefixes := []string{"i", "l", "f", "d", "a"}
+	for i, prefix := range prefixes {
+		register(uint8(OP_iload+i), prefix+"load", operandLocal)
+		register(uint8(OP_istore+i), prefix+"store", operandLocal)
+		for n := 0; n < 4; n++ {
+			register(uint8(OP_iload_0+i*4+n), prefix+"load_"+string(rune('0'+n)), operandNone)
+			register(uint8(OP_istore_0+i*4+n), prefix+"store_"+string(rune('0'+n)), operandNone)
+		}
+	}
+	for i, prefix := range []string{"i", "l", "f", "d", "a", "b", "c", "s"} {
+		register(uint8(OP_iaload+i), prefix+"aload", operandNone)
+		register(uint8(OP_iastore+i), prefix+"astore", operandNone)
+	}
+
+	names = []string{"pop", "pop2", "dup", "dup_x1", "dup_x2", "dup2", "dup2_x1", "dup2_x2", "swap"}
+	for i, name := range names {
+		register(uint8(OP_pop+i), name, operandNone)
+	}
+	for i, op := range []string{"add", "sub", "mul", "div", "rem", "neg"} {
+		for j, prefix := range prefixes[:4] {
+			register(uint8(OP_iadd+i*4+j), prefix+op, operandNone)
+		}
+	}
+	names = []string{"ishl", "lshl", "ishr", "lshr", "iushr", "lushr", "iand", "land", "ior", "lor", "ixor", "lxor"}
+	for i, name := range names {
+		register(uint8(OP_ishl+i), name, operandNone)
+	}
+	register(OP_iinc, "iinc", operandIinc)
+	names = []string{
+		"i2l", "i2f", "i2d", "l2i", "l2f", "l2d", "f2i", "f2l", "f2d", "d2i", "d2l", "d2f", "i2b", "i2c", "i2s",
+		"lcmp", "fcmpl", "fcmpg", "dcmpl", "dcmpg",
+	}
+	for i, name := range names {
+		register(uint8(OP_i2l+i), name, operandNone)
+	}
+	names = []string{
+		"ifeq", "ifne", "iflt", "ifge", "ifgt", "ifle",
+		"if_icmpeq", "if_icmpne", "if_icmplt", "if_icmpge", "if_icmpgt", "if_icmple", "if_acmpeq", "if_acmpne",
+		"goto", "jsr",
+	}
+	for i, name := range names {
+		register(uint8(OP_ifeq+i), name, operandBranch)
+	}
+	register(OP_ret, "ret", operandLocal)
+	register(OP_tableswitch, "tableswitch", operandTableSwitch)
+	register(OP_lookupswitch, "lookupswitch", operandLookupSwitch)
+	names = []string{"ireturn", "lreturn", "freturn", "dreturn", "areturn", "return"}
+	for i, name := range names {
+		register(uint8(OP_ireturn+i), name, operandNone)
+	}
+	register(OP_getstatic, "getstatic", operandField)
+	register(OP_putstatic, "putstatic", operandField)
+	register(OP_getfield, "getfield", operandField)
+	register(OP_putfield, "putfield", operandField)
+	register(OP_invokevirtual, "invokevirtual", operandMethod)
+	register(OP_invokespecial, "invokespecial", operandMethod)
+	register(OP_invokestatic, "invokestatic", operandMethod)
+	register(OP_invokeinterface, "invokeinterface", operandInterfaceMethod)
+	register(OP_invokedynamic, "invokedynamic", operandDynamic)
+	register(OP_new, "new", operandClass)
+	register(OP_newarray, "newarray", operandNewArray)
+	register(OP_anewarray, "anewarray", operandClass)
+	register(OP_arraylength, "arraylength", operandNone)
+	register(OP_athrow, "athrow", operandNone)
+	register(OP_checkcast, "checkcast", operandClass)
+	register(OP_instanceof, "instanceof", operandClass)
+	register(OP_monitorenter, "monitorenter", operandNone)
+	register(OP_monitorexit, "monitorexit", operandNone)
+	register(OP_wide, "wide", operandWide)
+	register(OP_multianewarray, "multianewarray", operandMultiANewArray)
+	register(OP_ifnull, "ifnull", operandBranch)
+	register(OP_ifnonnull, "ifnonnull", operandBranch)
+	register(OP_goto_w, "goto_w", operandBranchWide)
+	register(OP_jsr_w, "jsr_w", operandBranchWide)
+	register(OP_breakpoint, "breakpoint", operandNone)
+	register(OP_impdep1, "impdep1", operandNone)
+	register(OP_impdep2, "impdep2", operandNone)
+}
+
+// GetOpcodeName 返回操作码的助记符，未知的操作码返回空字符串
+func GetOpcodeName(opcode uint8) string {
+	if info := opcodeTable[opcode]; info != nil {
+		return info.name
+	}
+	return ""
+}
@@ -172,7 +172,7 @@ func (this *ClassObject) SetMethodName(old, name string) error {
 }
 
 func (this *ClassObject) findUtf8IndexFromPool(v string) int {
-	for i := 1; i < len(this.ConstantPool); i++ {
+	for i := 0; i < len(this.ConstantPool); i++ {
 		s, ok := this.ConstantPool[i].(*ConstantUtf8Info)
 		if ok {
 			if s.Value == v {
@@ -211,7 +211,7 @@ func (this *ClassObject) getUtf8(index uint16) (string, error) {
 	case *ConstantMethodHandleInfo:
 	case *ConstantInvokeDynamicInfo:
 	}
-	return "", utils.Errorf("index %d is not utf8")
+	return "", utils.Errorf("index %d is not utf8", index)
 }
 func (this *ClassObject) getConstantInfo(index uint16) (ConstantInfo, error) {
 	index -= 1
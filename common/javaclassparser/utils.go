@@ -48,7 +48,7 @@ func Interface2Uint64(v interface{}) (uint64, error) {
 	case uint8:
 		return uint64(v.(uint8)), nil
 	case int8:
-		return uint64(v.(int)), nil
+		return uint64(v.(int8)), nil
 	default:
 		return 0, ValueTypeError
 	}
@@ -0,0 +1,85 @@
+package minimartian
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+
+	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+// maxRecordedClientHelloSize 超过这个大小的 ClientHello 不再记录
+const maxRecordedClientHelloSize = 16 * 1024
+
+// clientHelloConn 在与客户端 TLS 握手时记录客户端发送的 ClientHello，握手完成后计算 JA3 / JA4 指纹
+//
+// 与 peekedConn 一样，可以通过 r 把已经读取的数据放回去
+type clientHelloConn struct {
+	net.Conn
+	r io.Reader
+
+	recording bool
+	buf       bytes.Buffer
+
+	ja3 string
+	ja4 string
+}
+
+func newClientHelloConn(conn net.Conn, r io.Reader) *clientHelloConn {
+	if r == nil {
+		r = conn
+	}
+	return &clientHelloConn{Conn: conn, r: r, recording: true}
+}
+
+func (c *clientHelloConn) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	if c.recording && n > 0 {
+		if c.buf.Len()+n > maxRecordedClientHelloSize {
+			c.recording = false
+			c.buf = bytes.Buffer{}
+		} else {
+			c.buf.Write(b[:n])
+		}
+	}
+	return n, err
+}
+
+// handshakeFinished 停止记录并计算指纹，需要在 tls 握手完成后调用
+func (c *clientHelloConn) handshakeFinished() {
+	if !c.recording {
+		return
+	}
+	c.recording = false
+	defer func() {
+		c.buf = bytes.Buffer{}
+	}()
+
+	hello, err := tlsutils.ParseClientHello(c.buf.Bytes())
+	if err != nil {
+		log.Debugf("mitm: parse client hello from %v failed: %v", c.RemoteAddr(), err)
+		return
+	}
+	if j, err := ja3.ParseJA3FromClientHello(hello); err == nil {
+		c.ja3 = j.Calc()
+	}
+	if j, err := ja3.ParseJA4FromClientHello(hello); err == nil {
+		c.ja4 = j.Fingerprint()
+	}
+}
+
+// getClientHelloFingerprint 获取客户端 tls 连接的 JA3 / JA4 指纹
+func getClientHelloFingerprint(conn net.Conn) (string, string, bool) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", "", false
+	}
+	c, ok := tlsConn.NetConn().(*clientHelloConn)
+	if !ok || (c.ja3 == "" && c.ja4 == "") {
+		return "", "", false
+	}
+	return c.ja3, c.ja4, true
+}
@@ -16,7 +16,7 @@ import (
 // proxyH2 proxies HTTP/2 traffic between a client connection, `cc`, and the HTTP/2 `url` assuming
 // h2 is being used. Since no browsers use h2c, it's safe to assume all traffic uses TLS.
 // Revision this func from martian h2 package since it was not compatible with martian modifier style
-func (p *Proxy) proxyH2(closing chan bool, cc *tls.Conn, url *url.URL) error {
+func (p *Proxy) proxyH2(closing chan bool, cc *tls.Conn, url *url.URL, ja3, ja4 string) error {
 	log.Debugf("Proxying %v with HTTP/2", url)
 	go func() {
 		select {
@@ -25,7 +25,7 @@ func (p *Proxy) proxyH2(closing chan bool, cc *tls.Conn, url *url.URL) error {
 		cc.Close()
 	}()
 
-	return lowhttp.ServeHTTP2Connection(cc, func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	return lowhttp.ServeHTTP2ConnectionWithStreamInfo(cc, func(info *lowhttp.H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
 		reqBytes := bytes.NewBuffer(header)
 
 		io.Copy(reqBytes, body) //
@@ -34,6 +34,10 @@ func (p *Proxy) proxyH2(closing chan bool, cc *tls.Conn, url *url.URL) error {
 			return nil, nil, err
 		}
 		httpctx.SetRequestHTTPS(req, true)
+		if ja3 != "" || ja4 != "" {
+			httpctx.SetRequestTLSClientFingerprint(req, ja3, ja4)
+		}
+		lowhttp.SetHTTP2StreamInfo(req, info)
 		if req.URL != nil {
 			req.URL.Scheme = "https"
 		}
@@ -0,0 +1,135 @@
+package minimartian
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/minimartian/nosigpipe"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+// ServeInvisible 以 invisible 模式在 l 上接收连接：客户端不知道代理的存在，直接把这个端口当作服务器
+// 连接上的第一个包会用来判断是 TLS 还是明文 HTTP。
+// target 为空时，上游目标由 SNI / Host 决定，端口默认使用监听端口（Host 中显式写了端口时使用 Host 中的端口）；
+// target 不为空时(host:port)，这个端口上的所有请求都会被发送到 target，Host 保持不变。
+// 请求和普通代理请求一样经过 request/response modifier，会被正常劫持和记录
+func (p *Proxy) ServeInvisible(l net.Listener, ctx context.Context, target string) error {
+	defer l.Close()
+
+	var targetHost string
+	var targetPort int
+	if target != "" {
+		var err error
+		targetHost, targetPort, err = utils.ParseStringToHostPort(target)
+		if err != nil {
+			return utils.Errorf("invalid invisible target %#v: %s", target, err)
+		}
+	}
+	_, listenPort, err := utils.ParseStringToHostPort(l.Addr().String())
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.closing:
+		}
+		l.Close()
+	}()
+
+	log.Infof("(mitm) ready for recv invisible connection from: %v, target: %v", l.Addr().String(), utils.StringOr(target, "(sni/host)"))
+	for {
+		if p.Closing() {
+			return nil
+		}
+		conn, err := l.Accept()
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+			if p.Closing() {
+				return nil
+			}
+			return utils.Errorf("mitm(invisible): failed to accept: %v", err)
+		}
+		nosigpipe.IgnoreSIGPIPE(conn)
+		log.Debugf("mitm(invisible): accepted connection from %s", conn.RemoteAddr())
+		go p.handleInvisibleConn(conn, ctx, targetHost, targetPort, listenPort)
+	}
+}
+
+func (p *Proxy) handleInvisibleConn(conn net.Conn, rootCtx context.Context, targetHost string, targetPort int, listenPort int) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("handle invisible mitm conn failed: %s", err)
+			utils.PrintCurrentGoroutineRuntimeStack()
+		}
+	}()
+
+	p.connsMu.Lock()
+	p.conns.Add(1)
+	p.connsMu.Unlock()
+	defer p.conns.Done()
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	prefix, err := reader.Peek(3)
+	if err != nil && len(prefix) == 0 {
+		log.Debugf("mitm(invisible): peek first bytes from %v failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	conn = &peekedConn{Conn: conn, r: reader}
+
+	isHttps := netx.IsTLSClientHelloPrefix(prefix)
+	if isHttps {
+		if p.mitm == nil {
+			log.Errorf("mitm(invisible): no MITM config set for tls connection from %v", conn.RemoteAddr())
+			return
+		}
+		// 证书优先使用 SNI，没有 SNI 时使用绑定的目标
+		helloConn := newClientHelloConn(conn, nil)
+		tlsConn := tls.Server(helloConn, p.mitm.TLSForHost(utils.StringOr(targetHost, "127.0.0.1"), false))
+		if err := tlsConn.HandshakeContext(utils.TimeoutContextSeconds(10)); err != nil {
+			log.Errorf("mitm(invisible): tls handshake with %v failed: %v", conn.RemoteAddr(), err)
+			return
+		}
+		helloConn.handshakeFinished()
+		conn = tlsConn
+	}
+
+	host, port, connectedTo := targetHost, targetPort, ""
+	if targetHost != "" {
+		connectedTo = utils.HostPort(targetHost, targetPort)
+	} else {
+		port = listenPort
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			host = tlsConn.ConnectionState().ServerName
+		}
+	}
+
+	p.handleSessionLoop(conn, rootCtx, func(s *Session) {
+		if isHttps {
+			s.MarkSecure()
+		}
+		s.Set(httpctx.REQUEST_CONTEXT_KEY_IsHttps, isHttps)
+		s.Set(httpctx.REQUEST_CONTEXT_KEY_ViaInvisibleListener, true)
+		s.Set(httpctx.REQUEST_CONTEXT_KEY_ConnectedTo, connectedTo)
+		s.Set(httpctx.REQUEST_CONTEXT_KEY_ConnectedToHost, host)
+		s.Set(httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort, port)
+	})
+}
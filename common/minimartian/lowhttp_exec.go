@@ -2,6 +2,7 @@ package minimartian
 
 import (
 	"io"
+	"net"
 	"net/http"
 
 	"github.com/yaklang/yaklang/common/log"
@@ -69,7 +70,16 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 		lowhttp.WithNativeHTTPRequestInstance(req),
 	)
 
-	if connectedPort := httpctx.GetContextIntInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort); connectedPort > 0 {
+	if httpctx.GetRequestViaInvisibleListener(req) {
+		// invisible 监听端口：绑定了目标时总是连接目标，否则 host 来自 Host/SNI，Host 中没有端口时使用监听端口
+		connectedPort := httpctx.GetContextIntInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort)
+		if httpctx.GetContextStringInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedTo) != "" {
+			opts = append(opts, lowhttp.WithHost(httpctx.GetContextStringInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedToHost)))
+			opts = append(opts, lowhttp.WithPort(connectedPort))
+		} else if _, _, err := net.SplitHostPort(req.Host); err != nil && connectedPort > 0 {
+			opts = append(opts, lowhttp.WithPort(connectedPort))
+		}
+	} else if connectedPort := httpctx.GetContextIntInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort); connectedPort > 0 {
 		portValid := (connectedPort == 443 && isHttps) || (connectedPort == 80 && !isHttps)
 		if !portValid {
 			// 修复host和port
@@ -86,6 +96,15 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 			return
 		}
 
+		// server-sent events should be forwarded event by event, never buffered
+		if key == "content-type" && lowhttp.IsSSEContentType(value) {
+			p.handleSSEResponse(req, bwr)
+			return
+		}
+		if httpctx.GetResponseIsSSE(req) {
+			return
+		}
+
 		// filter / forward to client conn via Content-Type
 		if key == "content-type" {
 			if ret := httpctx.GetResponseContentTypeFiltered(req); ret != nil {
@@ -257,19 +257,26 @@ func (p *Proxy) handleLoop(isTLSConn bool, conn net.Conn, rootCtx context.Contex
 
 	/* TLS */
 	if isTLSConn {
-		conn = tls.Server(conn, p.mitm.TLSForHost("127.0.0.1", false))
+		helloConn := newClientHelloConn(conn, nil)
+		conn = tls.Server(helloConn, p.mitm.TLSForHost("127.0.0.1", false))
 		if tlsConn, ok := conn.(*tls.Conn); ok && tlsConn != nil {
 			err := tlsConn.HandshakeContext(utils.TimeoutContextSeconds(5))
 			if err != nil {
 				log.Errorf("mitm recv tls conn from client, but handshake error: %v", err)
 				return
 			}
+			helloConn.handshakeFinished()
 			conn = tlsConn
 		}
 		p.handleLoop(false, conn, rootCtx)
 		return
 	}
 
+	p.handleSessionLoop(conn, rootCtx, nil)
+}
+
+// handleSessionLoop 为连接创建 session，并循环处理连接上的请求，setup 用于在处理请求之前初始化 session
+func (p *Proxy) handleSessionLoop(conn net.Conn, rootCtx context.Context, setup func(s *Session)) {
 	/* handle cleaning proxy! */
 	brw := bufio.NewReadWriter(bufio.NewReader(ctxio.NewReader(rootCtx, conn)), bufio.NewWriter(ctxio.NewWriter(rootCtx, conn)))
 	s, err := newSession(conn, brw)
@@ -277,6 +284,9 @@ func (p *Proxy) handleLoop(isTLSConn bool, conn net.Conn, rootCtx context.Contex
 		log.Errorf("mitm: failed to create session: %v", err)
 		return
 	}
+	if setup != nil {
+		setup(s)
+	}
 
 	ctx, err := withSession(s)
 	if err != nil {
@@ -438,18 +448,19 @@ func (p *Proxy) handleConnectionTunnel(req *http.Request, timer *time.Timer, con
 		// fallback: 最普通的情况，没有任何 http2 支持
 		// do as ordinary https server and use *tls.Conn
 		tlsConfig := p.mitm.TLSForHost(req.Host, p.http2 && serverUseH2)
-		tlsconn := tls.Server(&peekedConn{
-			Conn: conn,
-			r:    io.MultiReader(bytes.NewReader(b), bytes.NewReader(buf), conn),
-		}, tlsConfig)
+		helloConn := newClientHelloConn(conn, io.MultiReader(bytes.NewReader(b), bytes.NewReader(buf), conn))
+		tlsconn := tls.Server(helloConn, tlsConfig)
 		if err := tlsconn.Handshake(); err != nil {
 			p.mitm.HandshakeErrorCallback(req, err)
 			return err
 		}
+		helloConn.handshakeFinished()
+		// 在区分 h2 / http1.1 之前获取客户端指纹，两种情况下的请求都需要带上 JA3 / JA4
+		ja3, ja4, _ := getClientHelloFingerprint(tlsconn)
 		nextProto := tlsconn.ConnectionState().NegotiatedProtocol
 		log.Debugf("connect from browser: %v use: %v", tlsconn.RemoteAddr().String(), nextProto)
 		if nextProto == "h2" {
-			return p.proxyH2(p.closing, tlsconn, req.URL)
+			return p.proxyH2(p.closing, tlsconn, req.URL, ja3, ja4)
 		}
 
 		brw.Writer.Reset(tlsconn)
@@ -527,6 +538,9 @@ func (p *Proxy) handle(ctx *Context, timer *time.Timer, conn net.Conn, brw *bufi
 		req.URL.Scheme = "https"
 		isHttps = true
 		httpctx.SetRequestHTTPS(req, true)
+		if ja3, ja4, ok := getClientHelloFingerprint(tconn); ok {
+			httpctx.SetRequestTLSClientFingerprint(req, ja3, ja4)
+		}
 	}
 
 	if session.IsSecure() {
@@ -562,6 +576,9 @@ func (p *Proxy) handle(ctx *Context, timer *time.Timer, conn net.Conn, brw *bufi
 	if ctx.GetSessionBoolValue(httpctx.REQUEST_CONTEXT_KEY_ViaConnect) {
 		httpctx.SetRequestViaCONNECT(req, true)
 	}
+	if ctx.GetSessionBoolValue(httpctx.REQUEST_CONTEXT_KEY_ViaInvisibleListener) {
+		httpctx.SetRequestViaInvisibleListener(req, true)
+	}
 
 	isProxy := req.Method == "CONNECT" || req.Header.Get("Proxy-Connection") != "" || req.Header.Get("Proxy-Authorization") != ""
 	if isProxy {
@@ -85,6 +85,9 @@ type Proxy struct {
 
 	maxContentLength int
 
+	// server-sent events handler, called for every event of text/event-stream response
+	sseEventHandler SSEEventHandler
+
 	h2Cache sync.Map
 }
 
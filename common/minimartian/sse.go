@@ -0,0 +1,221 @@
+package minimartian
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+// SSEEventHandler is called for every complete event of a text/event-stream response,
+// the returned bytes will be forwarded to client, return nil to drop the event.
+// modifiable is false for content-encoded or fixed Content-Length streams, the original
+// event is forwarded no matter what the handler returns.
+type SSEEventHandler func(req *http.Request, index int, event []byte, modifiable bool) []byte
+
+// SetSSEEventHandler sets the handler for server-sent events.
+func (p *Proxy) SetSSEEventHandler(h SSEEventHandler) {
+	p.sseEventHandler = h
+}
+
+// handleSSEResponse forwards text/event-stream response to client without buffering,
+// each event will be passed to sse event handler before writing to client.
+// lowhttp only reads a copy of the body for the saved flow, the copy stops at MaxContentLength
+func (p *Proxy) handleSSEResponse(req *http.Request, bwr io.Writer) {
+	httpctx.SetResponseSSEHash(req, uuid.NewString())
+	httpctx.SetResponseHeaderCallback(req, func(response *http.Response, headerBytes []byte, bodyReader io.Reader) (io.Reader, error) {
+		httpctx.SetMITMSkipFrontendFeedback(req, true)
+		bwr.Write(headerBytes)
+		utils.FlushWriter(bwr)
+
+		chunked := false
+		for _, te := range response.TransferEncoding {
+			if utils.IContains(te, "chunked") {
+				chunked = true
+			}
+		}
+		// encoded stream or fixed content-length can not be modified event by event
+		encoding := response.Header.Get("Content-Encoding")
+		modifiable := encoding == "" && (chunked || response.ContentLength <= 0)
+
+		recorder := newSSERecorder(req, chunked, int64(p.GetMaxContentLength()))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer recorder.Close()
+			p.forwardSSEEvents(req, bodyReader, bwr, recorder, chunked, encoding, modifiable)
+		}()
+		httpctx.SetResponseFinishedCallback(req, func() {
+			<-done
+			if recorder.truncated {
+				httpctx.SetResponseTooLargeSize(req, recorder.total)
+			}
+			utils.FlushWriter(bwr)
+		})
+		return recorder.r, nil
+	})
+}
+
+// sseRecorder keeps a copy of the event stream for lowhttp to save into the flow,
+// recording stops after limit bytes and the response is marked as too large
+type sseRecorder struct {
+	req     *http.Request
+	r       *utils.PipeReader
+	w       *utils.PipeWriter
+	chunked bool
+	limit   int64
+
+	count     int64
+	total     int64
+	truncated bool
+	closed    bool
+}
+
+func newSSERecorder(req *http.Request, chunked bool, limit int64) *sseRecorder {
+	r, w := utils.NewBufPipe(nil)
+	return &sseRecorder{req: req, r: r, w: w, chunked: chunked, limit: limit}
+}
+
+// Record saves decoded transfer payload, the buffered pipe never blocks the forwarder
+func (s *sseRecorder) Record(payload []byte) {
+	s.total += int64(len(payload))
+	if s.closed || len(payload) <= 0 {
+		return
+	}
+	if s.limit > 0 && s.count+int64(len(payload)) > s.limit {
+		payload = payload[:s.limit-s.count]
+		s.truncated = true
+		httpctx.SetResponseTooLarge(s.req, true)
+	}
+	s.count += int64(len(payload))
+	if len(payload) > 0 {
+		if s.chunked {
+			fmt.Fprintf(s.w, "%x\r\n%s\r\n", len(payload), payload)
+		} else {
+			s.w.Write(payload)
+		}
+	}
+	if s.truncated {
+		s.Close()
+	}
+}
+
+func (s *sseRecorder) Close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.chunked {
+		s.w.Write([]byte("0\r\n\r\n"))
+	}
+	s.w.Close()
+}
+
+func (p *Proxy) forwardSSEEvents(req *http.Request, r io.Reader, w io.Writer, recorder *sseRecorder, chunked bool, encoding string, modifiable bool) {
+	var body io.Reader = r
+	if chunked {
+		body = httputil.NewChunkedReader(r)
+	}
+
+	var writeErr error
+	write := func(b []byte) {
+		if writeErr != nil || len(b) <= 0 {
+			return
+		}
+		if chunked {
+			_, writeErr = fmt.Fprintf(w, "%x\r\n%s\r\n", len(b), b)
+		} else {
+			_, writeErr = w.Write(b)
+		}
+		utils.FlushWriter(w)
+		if writeErr != nil {
+			log.Debugf("mitm: write server-sent event to client failed: %v", writeErr)
+		}
+	}
+
+	index := 0
+	handleEvent := func(event []byte) []byte {
+		index++
+		if p.sseEventHandler == nil {
+			return event
+		}
+		handled := p.sseEventHandler(req, index, event, modifiable)
+		if !modifiable {
+			return event
+		}
+		return handled
+	}
+
+	// encoded stream is forwarded as it is, events are split from the decoded copy
+	var (
+		decodedWriter *io.PipeWriter
+		decodeDone    chan struct{}
+	)
+	if encoding != "" {
+		var decodedReader *io.PipeReader
+		decodedReader, decodedWriter = io.Pipe()
+		decodeDone = make(chan struct{})
+		go func() {
+			defer close(decodeDone)
+			defer io.Copy(io.Discard, decodedReader)
+			decoded, err := lowhttp.ContentEncodingDecodeReader(encoding, decodedReader)
+			if err != nil {
+				log.Debugf("mitm: decode server-sent events(%v) failed: %v", encoding, err)
+				return
+			}
+			splitter := lowhttp.NewSSEEventSplitter()
+			buf := make([]byte, 4096)
+			for {
+				n, err := decoded.Read(buf)
+				for _, event := range splitter.Feed(buf[:n]) {
+					handleEvent(event)
+				}
+				if err != nil {
+					if err != io.EOF && err != io.ErrUnexpectedEOF {
+						log.Debugf("mitm: decode server-sent events(%v) failed: %v", encoding, err)
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	splitter := lowhttp.NewSSEEventSplitter()
+	buf := make([]byte, 4096)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			recorder.Record(buf[:n])
+			if decodedWriter != nil {
+				write(buf[:n])
+				decodedWriter.Write(buf[:n])
+			} else {
+				for _, event := range splitter.Feed(buf[:n]) {
+					write(handleEvent(event))
+				}
+			}
+		}
+		if err != nil {
+			if err != io.EOF && !strings.Contains(err.Error(), "closed") {
+				log.Debugf("mitm: read server-sent events failed: %v", err)
+			}
+			break
+		}
+	}
+	if decodedWriter != nil {
+		decodedWriter.Close()
+		<-decodeDone
+	}
+	write(splitter.Rest())
+	if chunked && writeErr == nil {
+		_, writeErr = w.Write([]byte("0\r\n\r\n"))
+		utils.FlushWriter(w)
+	}
+}
@@ -192,6 +192,7 @@ func DialX(target string, opt ...DialXOption) (net.Conn, error) {
 	if config.ShouldOverrideSNI {
 		sni = config.SNI
 	}
+	dialHosts := clientCertificateDialHosts(target)
 
 	var tlsConfig any = &tls.Config{
 		ServerName:         sni,
@@ -220,7 +221,7 @@ func DialX(target string, opt ...DialXOption) (net.Conn, error) {
 
 		switch strategy {
 		case TLS_Strategy_Ordinary:
-			tlsConn, err := UpgradeToTLSConnectionWithTimeout(conn, sni, tlsConfig, tlsTimeout, config.TLSNextProto...)
+			tlsConn, err := upgradeToTLSConnectionWithTimeout(conn, sni, tlsConfig, tlsTimeout, dialHosts, config.ClientCertificates, config.TLSNextProto...)
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -241,7 +242,7 @@ func DialX(target string, opt ...DialXOption) (net.Conn, error) {
 					Renegotiation:      gmtls.RenegotiateFreelyAsClient,
 				}
 			}
-			tlsConn, err := UpgradeToTLSConnectionWithTimeout(conn, sni, tlsConfig, tlsTimeout, config.TLSNextProto...)
+			tlsConn, err := upgradeToTLSConnectionWithTimeout(conn, sni, tlsConfig, tlsTimeout, dialHosts, config.ClientCertificates, config.TLSNextProto...)
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -255,7 +256,7 @@ func DialX(target string, opt ...DialXOption) (net.Conn, error) {
 				InsecureSkipVerify: true,
 				Renegotiation:      gmtls.RenegotiateFreelyAsClient,
 			}
-			tlsConn, err := UpgradeToTLSConnectionWithTimeout(conn, sni, gmtlsConfig, tlsTimeout, config.TLSNextProto...)
+			tlsConn, err := upgradeToTLSConnectionWithTimeout(conn, sni, gmtlsConfig, tlsTimeout, dialHosts, config.ClientCertificates, config.TLSNextProto...)
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -31,6 +31,8 @@ type dialXConfig struct {
 	ShouldOverrideSNI         bool
 	SNI                       string
 	TLSNextProto              []string
+	// ClientCertificates 只对这次连接生效，优先于全局按 host 选择的证书
+	ClientCertificates []*ClientCertificate
 
 	// Retry
 	EnableTimeoutRetry  bool
@@ -185,6 +187,13 @@ func DialX_WithTLSNextProto(nextProtos ...string) DialXOption {
 	}
 }
 
+// DialX_WithClientCertificates 为这次连接额外提供按 host 选择的客户端证书，不影响其他连接
+func DialX_WithClientCertificates(certs ...*ClientCertificate) DialXOption {
+	return func(c *dialXConfig) {
+		c.ClientCertificates = append(c.ClientCertificates, certs...)
+	}
+}
+
 func DialX_WithTLSConfig(tlsConfig any) DialXOption {
 	return func(c *dialXConfig) {
 		c.EnableTLS = true
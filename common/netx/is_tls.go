@@ -55,3 +55,15 @@ func IsTLSService(addr string, proxies ...string) bool {
 
 	return isHttps
 }
+
+// IsTLSClientHelloPrefix 根据客户端发出的前几个字节判断是否是 TLS(含 SSLv3 / 国密) 握手
+// 0x16 为 handshake record，紧跟的两个字节为 record 版本号(0x03 0x00-0x04, 国密为 0x01 0x01)
+func IsTLSClientHelloPrefix(b []byte) bool {
+	if len(b) < 1 || b[0] != 0x16 {
+		return false
+	}
+	if len(b) < 3 {
+		return true
+	}
+	return b[1] == 0x03 || (b[1] == 0x01 && b[2] == 0x01)
+}
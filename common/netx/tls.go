@@ -2,8 +2,6 @@ package netx
 
 import (
 	"crypto/tls"
-	"crypto/x509"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/yaklang/yaklang/common/gmsm/gmtls"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
@@ -17,6 +15,28 @@ func UpgradeToTLSConnection(conn net.Conn, sni string, i any) (net.Conn, error)
 }
 
 func UpgradeToTLSConnectionWithTimeout(conn net.Conn, sni string, i any, timeout time.Duration, tlsNextProto ...string) (net.Conn, error) {
+	var dialHosts []string
+	if conn != nil && conn.RemoteAddr() != nil {
+		dialHosts = clientCertificateDialHosts(conn.RemoteAddr().String())
+	}
+	return upgradeToTLSConnectionWithTimeout(conn, sni, i, timeout, dialHosts, nil, tlsNextProto...)
+}
+
+// clientCertificateDialHosts 返回按 host 选择客户端证书时使用的拨号地址，包括 host:port 与 host
+func clientCertificateDialHosts(target string) []string {
+	if target == "" {
+		return nil
+	}
+	hosts := []string{target}
+	if host, _, err := utils.ParseStringToHostPort(target); err == nil && host != "" && host != target {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// upgradeToTLSConnectionWithTimeout 中 dialHosts 是实际拨号的地址，SNI 被覆盖时也能按拨号地址选择客户端证书
+// clientCerts 是只对这个连接生效的客户端证书
+func upgradeToTLSConnectionWithTimeout(conn net.Conn, sni string, i any, timeout time.Duration, dialHosts []string, clientCerts []*ClientCertificate, tlsNextProto ...string) (net.Conn, error) {
 	if i == nil {
 		i = &tls.Config{
 			ServerName:         sni,
@@ -52,7 +72,7 @@ func UpgradeToTLSConnectionWithTimeout(conn net.Conn, sni string, i any, timeout
 			tlsConfig.NextProtos = tlsNextProto
 		}
 		tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
-		err := LoadCertificatesConfig(tlsConfig)
+		err := loadCertificatesConfigForHost(tlsConfig, clientCerts, append([]string{sni, tlsConfig.ServerName}, dialHosts...)...)
 		if err != nil {
 			log.Warnf("LoadCertificatesConfig(tlsConfig) error: %s", err)
 		}
@@ -68,8 +88,12 @@ func UpgradeToTLSConnectionWithTimeout(conn net.Conn, sni string, i any, timeout
 			gmtlsConfig.NextProtos = tlsNextProto
 		}
 		gmtlsConfig.Renegotiation = gmtls.RenegotiateFreelyAsClient
+		err := loadCertificatesConfigForHost(gmtlsConfig, clientCerts, append([]string{sni, gmtlsConfig.ServerName}, dialHosts...)...)
+		if err != nil {
+			log.Warnf("LoadCertificatesConfig(gmtlsConfig) error: %s", err)
+		}
 		var sConn = gmtls.Client(conn, gmtlsConfig)
-		err := sConn.HandshakeContext(utils.TimeoutContext(timeout))
+		err = sConn.HandshakeContext(utils.TimeoutContext(timeout))
 		if err != nil {
 			conn.Close()
 			return nil, err
@@ -85,7 +109,6 @@ var (
 	// authenticate to the server if required.
 	// load p12/pfx file to presetClientCertificates
 	presetClientCertificates []tls.Certificate
-	clientRootCA             = x509.NewCertPool()
 )
 
 func LoadP12Bytes(p12bytes []byte, password string) error {
@@ -97,60 +120,49 @@ func LoadP12Bytes(p12bytes []byte, password string) error {
 	if err != nil {
 		return err
 	}
-	for _, caBytes := range ca {
-		if !clientRootCA.AppendCertsFromPEM(caBytes) {
-			log.Warn("append certs from pem failed")
-			spew.Dump(caBytes)
-		}
-	}
+	appendClientCertificateChain(&client.Certificate, ca...)
 	presetClientCertificates = append(presetClientCertificates, client)
 	return nil
 }
 
+// LoadCertificatesConfig 为客户端 TLS 配置设置 GetClientCertificate，host 由 ServerName 决定
 func LoadCertificatesConfig(i any) error {
 	switch ret := i.(type) {
 	case *tls.Config:
-		if len(ret.Certificates) > 0 {
-			certs := make([]tls.Certificate, len(ret.Certificates), len(ret.Certificates)+len(presetClientCertificates))
-			copy(certs, ret.Certificates)
-			certs = append(certs, presetClientCertificates...)
-			ret.Certificates = certs
-			ret.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
-				for _, cert := range certs {
-					err := info.SupportsCertificate(&cert)
-					if err != nil {
-						continue
-					}
-					return &cert, nil
-				}
-				return nil, utils.Errorf("all [%v] certificates are tested, no one is supported for %v", len(certs), info.Version)
-			}
-		} else {
+		return loadCertificatesConfigForHost(ret, nil, ret.ServerName)
+	case *gmtls.Config:
+		return loadCertificatesConfigForHost(ret, nil, ret.ServerName)
+	default:
+		return loadCertificatesConfigForHost(i, nil)
+	}
+}
+
+// loadCertificatesConfigForHost 候选证书的顺序为：配置中自带的证书，按 host 选择的证书(extra 在前)，全局预设证书
+func loadCertificatesConfigForHost(i any, extra []*ClientCertificate, hosts ...string) error {
+	switch ret := i.(type) {
+	case *tls.Config:
+		hostCerts := getClientCertificatesForHost(extra, hosts...)
+		if len(ret.Certificates) == 0 && len(hostCerts) == 0 && len(presetClientCertificates) == 0 {
 			// 服务端请求客户端证书时，如果客户端没有配置证书，是否能完成握手取决于服务器的配置
-			if len(presetClientCertificates) == 0 {
-				return nil
-			}
-			ret.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
-				// 服务端请求客户端证书时，如果客户端没有配置证书，是否能完成握手取决于服务器的配置
-				//if len(presetClientCertificates) == 0 {
-				//	log.Warn("server request client certificate, but no client certificate configured")
-				//	// sendClientCertificate 不允许发送 nil，否则会 panic 所以尝试发送一个空的证书
-				//	// 这个解决方案可能会导致服务器拒绝握手，因为它可能会试图验证一个空的证书。
-				//	// 如果服务器配置为VerifyClientCertIfGiven，并且它期望如果客户端提供了证书就必须是有效的，那么这个方法可能会失败。
-				//	return &tls.Certificate{}, nil
-				//}
-				for _, cert := range presetClientCertificates {
-					err := info.SupportsCertificate(&cert)
-					if err != nil {
-						continue
-					}
-					return &cert, nil
+			return nil
+		}
+		certs := make([]tls.Certificate, 0, len(ret.Certificates)+len(hostCerts)+len(presetClientCertificates))
+		certs = append(certs, ret.Certificates...)
+		certs = append(certs, hostCerts...)
+		certs = append(certs, presetClientCertificates...)
+		ret.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			for _, cert := range certs {
+				err := info.SupportsCertificate(&cert)
+				if err != nil {
+					continue
 				}
-				return nil, utils.Errorf("all [%v] certificates are tested, no one is supported for %v", len(presetClientCertificates), info.Version)
+				return &cert, nil
 			}
+			return nil, utils.Errorf("all [%v] certificates are tested, no one is supported for %v", len(certs), info.Version)
 		}
 		return nil
 	case *gmtls.Config:
+		loadGMCertificatesConfig(ret, extra, hosts...)
 		return nil
 	default:
 		log.Warnf("invalid tlsConfig type %T", i)
@@ -0,0 +1,258 @@
+package netx
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/pem"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/glob"
+	"github.com/yaklang/yaklang/common/gmsm/gmtls"
+	"github.com/yaklang/yaklang/common/gmsm/x509"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+// ClientCertificate 是按照 host 模式选择的客户端证书
+// 普通证书会同时生成 tls / gmtls 两种形式，SM2 证书只有 gmtls 形式
+type ClientCertificate struct {
+	patterns []glob.Glob
+	tls      *tls.Certificate
+	gmtls    *gmtls.Certificate
+}
+
+var (
+	hostClientCertificatesMutex = new(sync.RWMutex)
+	hostClientCertificates      []*ClientCertificate
+)
+
+// parseClientCertificateHostPattern 解析 host 模式，多个模式使用逗号分隔，支持 glob，例如 *.example.com
+// glob 中的 * 不跨越 "."，单独的 * 匹配所有 host；*.example.com 同时也会匹配 example.com 本身
+func parseClientCertificateHostPattern(pattern string) ([]glob.Glob, error) {
+	var globs []glob.Glob
+	for _, p := range utils.PrettifyListFromStringSplitEx(pattern, ",", "|", "\n") {
+		p = strings.ToLower(p)
+		if host, _, err := utils.ParseStringToHostPort(p); err == nil && host != "" {
+			p = host
+		}
+		var g glob.Glob
+		var err error
+		if p == "*" {
+			g, err = glob.Compile(p)
+		} else {
+			g, err = glob.Compile(p, '.')
+		}
+		if err != nil {
+			return nil, utils.Errorf("invalid host pattern %#v: %s", p, err)
+		}
+		globs = append(globs, g)
+		if strings.HasPrefix(p, "*.") {
+			globs = append(globs, glob.MustCompile(p[2:], '.'))
+		}
+	}
+	if len(globs) == 0 {
+		return nil, utils.Error("empty host pattern")
+	}
+	return globs, nil
+}
+
+func (c *ClientCertificate) match(hosts ...string) bool {
+	for _, host := range hosts {
+		host = strings.ToLower(strings.Trim(host, "[]"))
+		if host == "" {
+			continue
+		}
+		for _, g := range c.patterns {
+			if g.Match(host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckClientCertificatePEM 检查 crt/key 是否能作为客户端证书使用，isSM2 表示只能用于国密 TLS
+func CheckClientCertificatePEM(crtPem, keyPem []byte) (isSM2 bool, err error) {
+	if _, err := tls.X509KeyPair(crtPem, keyPem); err == nil {
+		return false, nil
+	}
+	if _, err := gmtls.X509KeyPair(crtPem, keyPem); err != nil {
+		return false, utils.Errorf("invalid client certificate crt/key pem: %s", err)
+	}
+	return true, nil
+}
+
+// AddClientCertificateForHost 添加一个 PEM 格式的客户端证书，只在连接 pattern 匹配的 host 时使用
+// 支持 RSA / ECDSA 以及国密 SM2 证书，SM2 证书只会在国密 TLS 握手时使用；cas 会附加到发送给服务器的证书链中
+func AddClientCertificateForHost(pattern string, crtPem, keyPem []byte, cas ...[]byte) error {
+	_, err := RegisterClientCertificateForHost(pattern, crtPem, keyPem, cas...)
+	return err
+}
+
+// NewClientCertificate 解析一个只在连接 pattern 匹配的 host 时使用的客户端证书，不会加入全局证书库
+// 通过 DialX_WithClientCertificates / lowhttp.WithClientCertificates 只对单次连接生效
+func NewClientCertificate(pattern string, crtPem, keyPem []byte, cas ...[]byte) (*ClientCertificate, error) {
+	patterns, err := parseClientCertificateHostPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cert := &ClientCertificate{patterns: patterns}
+	if c, err := tls.X509KeyPair(crtPem, keyPem); err == nil {
+		cert.tls = &c
+	}
+	if c, err := gmtls.X509KeyPair(crtPem, keyPem); err == nil {
+		cert.gmtls = &c
+	}
+	if cert.tls == nil && cert.gmtls == nil {
+		return nil, utils.Errorf("parse client certificate for %v failed: invalid crt/key pem", pattern)
+	}
+	if cert.tls != nil {
+		appendClientCertificateChain(&cert.tls.Certificate, cas...)
+	}
+	if cert.gmtls != nil {
+		appendClientCertificateChain(&cert.gmtls.Certificate, cas...)
+	}
+	return cert, nil
+}
+
+// appendClientCertificateChain 把 CA(通常是中间证书)追加到发送给服务器的证书链中，服务器据此验证客户端证书
+func appendClientCertificateChain(chain *[][]byte, cas ...[]byte) {
+	for _, ca := range cas {
+		for {
+			var block *pem.Block
+			block, ca = pem.Decode(ca)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			exists := false
+			for _, der := range *chain {
+				if bytes.Equal(der, block.Bytes) {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				*chain = append(*chain, block.Bytes)
+			}
+		}
+	}
+}
+
+// RegisterClientCertificateForHost 与 AddClientCertificateForHost 相同，返回的 remove 函数用于移除这个证书
+// 全局证书库对进程内所有 TLS 连接生效，pattern 必须显式指定，"*" 表示所有 host
+func RegisterClientCertificateForHost(pattern string, crtPem, keyPem []byte, cas ...[]byte) (remove func(), err error) {
+	cert, err := NewClientCertificate(pattern, crtPem, keyPem, cas...)
+	if err != nil {
+		return nil, err
+	}
+
+	hostClientCertificatesMutex.Lock()
+	defer hostClientCertificatesMutex.Unlock()
+	hostClientCertificates = append(hostClientCertificates, cert)
+	return func() {
+		hostClientCertificatesMutex.Lock()
+		defer hostClientCertificatesMutex.Unlock()
+		for i, c := range hostClientCertificates {
+			if c == cert {
+				hostClientCertificates = append(hostClientCertificates[:i:i], hostClientCertificates[i+1:]...)
+				return
+			}
+		}
+	}, nil
+}
+
+// AddClientP12ForHost 添加一个 PKCS#12(p12/pfx) 格式的客户端证书，只在连接 pattern 匹配的 host 时使用
+func AddClientP12ForHost(pattern string, p12bytes []byte, password string) error {
+	crt, key, cas, err := tlsutils.LoadP12ToPEM(p12bytes, password)
+	if err != nil {
+		return err
+	}
+	return AddClientCertificateForHost(pattern, crt, key, cas...)
+}
+
+// ResetClientCertificatesForHost 清空所有按 host 选择的客户端证书
+func ResetClientCertificatesForHost() {
+	hostClientCertificatesMutex.Lock()
+	defer hostClientCertificatesMutex.Unlock()
+	hostClientCertificates = nil
+}
+
+// GetClientCertificatesForHost 返回任意一个 host 匹配的客户端证书，按照添加顺序排列
+// 通常传入连接目标 host 与 SNI
+func GetClientCertificatesForHost(hosts ...string) []tls.Certificate {
+	return getClientCertificatesForHost(nil, hosts...)
+}
+
+// getClientCertificatesForHost 中 extra 是只对当前连接生效的证书，排在全局证书库之前
+func getClientCertificatesForHost(extra []*ClientCertificate, hosts ...string) []tls.Certificate {
+	hostClientCertificatesMutex.RLock()
+	defer hostClientCertificatesMutex.RUnlock()
+
+	var certs []tls.Certificate
+	for _, c := range append(extra[:len(extra):len(extra)], hostClientCertificates...) {
+		if c.tls != nil && c.match(hosts...) {
+			certs = append(certs, *c.tls)
+		}
+	}
+	return certs
+}
+
+// GetGMClientCertificatesForHost 返回任意一个 host 匹配的客户端证书(gmtls 形式)，SM2 证书排在前面
+func GetGMClientCertificatesForHost(hosts ...string) []gmtls.Certificate {
+	return getGMClientCertificatesForHost(nil, hosts...)
+}
+
+func getGMClientCertificatesForHost(extra []*ClientCertificate, hosts ...string) []gmtls.Certificate {
+	hostClientCertificatesMutex.RLock()
+	defer hostClientCertificatesMutex.RUnlock()
+
+	var sm2Certs, certs []gmtls.Certificate
+	for _, c := range append(extra[:len(extra):len(extra)], hostClientCertificates...) {
+		if c.gmtls == nil || !c.match(hosts...) {
+			continue
+		}
+		if c.tls == nil {
+			sm2Certs = append(sm2Certs, *c.gmtls)
+		} else {
+			certs = append(certs, *c.gmtls)
+		}
+	}
+	return append(sm2Certs, certs...)
+}
+
+func loadGMCertificatesConfig(config *gmtls.Config, extra []*ClientCertificate, hosts ...string) {
+	certs := append([]gmtls.Certificate{}, config.Certificates...)
+	certs = append(certs, getGMClientCertificatesForHost(extra, hosts...)...)
+	if len(certs) == 0 || config.GetClientCertificate != nil {
+		return
+	}
+	config.GetClientCertificate = func(info *gmtls.CertificateRequestInfo) (*gmtls.Certificate, error) {
+		for _, cert := range certs {
+			if len(info.AcceptableCAs) > 0 && !gmCertificateIssuedByAcceptableCA(&cert, info.AcceptableCAs) {
+				continue
+			}
+			return &cert, nil
+		}
+		// 没有匹配 AcceptableCAs 的证书时，仍然发送第一个证书，由服务器决定是否接受
+		return &certs[0], nil
+	}
+}
+
+func gmCertificateIssuedByAcceptableCA(cert *gmtls.Certificate, cas [][]byte) bool {
+	for _, raw := range cert.Certificate {
+		c, err := x509.ParseCertificate(raw)
+		if err != nil {
+			continue
+		}
+		for _, ca := range cas {
+			if string(c.RawIssuer) == string(ca) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package netx
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+func TestClientCertificateHostPattern(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		host    string
+		match   bool
+	}{
+		{"*.example.com", "www.example.com", true},
+		{"*.example.com", "example.com", true},
+		{"*.example.com", "a.b.example.com", false},
+		{"*.example.com", "example.org", false},
+		{"example.com:443", "example.com", true},
+		{"10.0.0.*", "10.0.0.3", true},
+		{"a.com, b.com", "B.com", true},
+		{"*", "anything.local", true},
+	} {
+		patterns, err := parseClientCertificateHostPattern(c.pattern)
+		require.NoError(t, err)
+		require.Equal(t, c.match, (&ClientCertificate{patterns: patterns}).match(c.host), "%v -> %v", c.pattern, c.host)
+	}
+}
+
+func TestClientCertificateForHost_DialX(t *testing.T) {
+	ca, caKey, err := tlsutils.GenerateSelfSignedCertKey("", nil, nil)
+	require.NoError(t, err)
+	sCert, sKey, err := tlsutils.SignServerCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+	cCert, cKey, err := tlsutils.SignClientCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+
+	token := utils.RandStringBytes(20)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(token))
+	}))
+	server.TLS, err = tlsutils.GetX509MutualAuthServerTlsConfig(ca, sCert, sKey)
+	require.NoError(t, err)
+	server.StartTLS()
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	request := func(opts ...DialXOption) (string, error) {
+		conn, err := DialX(addr, append([]DialXOption{DialX_WithTLS(true), DialX_WithTimeout(5 * time.Second)}, opts...)...)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: 127.0.0.1\r\nConnection: close\r\n\r\n"))
+		if err != nil {
+			return "", err
+		}
+		raw, err := utils.ReadConnWithTimeout(conn, 3*time.Second)
+		return string(raw), err
+	}
+
+	remove, err := RegisterClientCertificateForHost("*.example.com", cCert, cKey, ca)
+	require.NoError(t, err)
+	rsp, _ := request()
+	require.NotContains(t, rsp, token)
+	remove()
+
+	remove, err = RegisterClientCertificateForHost("127.0.0.*", cCert, cKey, ca)
+	require.NoError(t, err)
+	defer remove()
+	rsp, _ = request()
+	require.Contains(t, rsp, token)
+
+	// SNI 被覆盖时按照拨号地址选择证书
+	rsp, _ = request(DialX_WithSNI("www.example.org"))
+	require.Contains(t, rsp, token)
+}
+
+func TestClientCertificateForHost_PerDial(t *testing.T) {
+	ca, caKey, err := tlsutils.GenerateSelfSignedCertKey("", nil, nil)
+	require.NoError(t, err)
+	sCert, sKey, err := tlsutils.SignServerCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+	cCert, cKey, err := tlsutils.SignClientCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+
+	token := utils.RandStringBytes(20)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(token))
+	}))
+	server.TLS, err = tlsutils.GetX509MutualAuthServerTlsConfig(ca, sCert, sKey)
+	require.NoError(t, err)
+	server.StartTLS()
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	request := func(opts ...DialXOption) string {
+		conn, err := DialX(addr, append([]DialXOption{DialX_WithTLS(true), DialX_WithTimeout(5 * time.Second)}, opts...)...)
+		if err != nil {
+			return ""
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		conn.Write([]byte("GET / HTTP/1.1\r\nHost: 127.0.0.1\r\nConnection: close\r\n\r\n"))
+		raw, _ := utils.ReadConnWithTimeout(conn, 3*time.Second)
+		return string(raw)
+	}
+
+	cert, err := NewClientCertificate("*", cCert, cKey, ca)
+	require.NoError(t, err)
+	require.Contains(t, request(DialX_WithClientCertificates(cert)), token)
+	// 只对传入证书的连接生效
+	require.NotContains(t, request(), token)
+}
+
+func TestClientCertificateForHost_CAChainAndRemove(t *testing.T) {
+	ca, caKey, err := tlsutils.GenerateSelfSignedCertKey("", nil, nil)
+	require.NoError(t, err)
+	cCert, cKey, err := tlsutils.SignClientCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+
+	remove, err := RegisterClientCertificateForHost("remove-ca.example.com", cCert, cKey, ca, ca)
+	require.NoError(t, err)
+	certs := GetClientCertificatesForHost("remove-ca.example.com")
+	require.Len(t, certs, 1)
+	// CA 附加在证书链中，重复的 CA 只出现一次
+	caBlock, _ := pem.Decode(ca)
+	require.Len(t, certs[0].Certificate, 2)
+	require.Equal(t, caBlock.Bytes, certs[0].Certificate[1])
+	remove()
+	require.Len(t, GetClientCertificatesForHost("remove-ca.example.com"), 0)
+
+	_, err = RegisterClientCertificateForHost("", cCert, cKey, ca)
+	require.Error(t, err)
+}
@@ -0,0 +1,338 @@
+package netx
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+// TLSServerFingerprint 是通过多个不同的 ClientHello 探测得到的 TLS 服务端指纹
+//
+// 算法参考 JARM：每个探测记录服务端选择的密码套件下标(2 位)和版本(1 位)，
+// 拼接之后的 30 个字符加上 ALPN 与扩展列表 sha256 的前 32 个字符组成 62 位指纹。
+// 探测包与 JARM 的实现不完全一致，所以指纹不保证与 JARM 工具相同，只在 yaklang 内部比较
+type TLSServerFingerprint struct {
+	Fingerprint string
+	// Raw 是每个探测的原始结果，格式为 cipher|version|alpn|extensions，用逗号分隔
+	Raw string
+	// JA3S 是第一个成功握手的 ServerHello 的 JA3S 原文
+	JA3S string
+}
+
+func (t *TLSServerFingerprint) String() string {
+	return t.Fingerprint
+}
+
+const (
+	tlsProbeCipherForward = iota
+	tlsProbeCipherReverse
+	tlsProbeCipherTopHalf
+	tlsProbeCipherBottomHalf
+	tlsProbeCipherMiddleOut
+)
+
+type tlsServerProbe struct {
+	version    uint16
+	cipherMode int
+	grease     bool
+	// alpn 为 nil 时不发送 ALPN 扩展
+	alpn []string
+	// tls13 为 true 时发送 supported_versions 和 key_share
+	tls13 bool
+	// noTLS13Ciphers 为 true 时不发送 TLS 1.3 的密码套件
+	noTLS13Ciphers bool
+}
+
+var (
+	tlsProbeALPN     = []string{"http/0.9", "http/1.0", "http/1.1", "spdy/1", "spdy/2", "spdy/3", "h2", "h2c", "hq"}
+	tlsProbeRareALPN = []string{"hq", "h2c", "spdy/3", "spdy/2", "spdy/1", "http/1.0", "http/0.9"}
+
+	tlsServerProbes = []*tlsServerProbe{
+		{version: 0x0303, cipherMode: tlsProbeCipherForward, alpn: tlsProbeALPN, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherReverse, alpn: tlsProbeALPN, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherTopHalf, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherBottomHalf, alpn: tlsProbeRareALPN, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherMiddleOut, grease: true, alpn: tlsProbeRareALPN, noTLS13Ciphers: true},
+		{version: 0x0302, cipherMode: tlsProbeCipherForward, alpn: tlsProbeALPN, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherForward, alpn: tlsProbeALPN, tls13: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherReverse, alpn: tlsProbeALPN, tls13: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherForward, alpn: tlsProbeALPN, tls13: true, noTLS13Ciphers: true},
+		{version: 0x0303, cipherMode: tlsProbeCipherMiddleOut, grease: true, alpn: tlsProbeALPN, tls13: true},
+	}
+
+	// tlsProbeCiphers 的下标用于计算指纹，不能修改顺序
+	tlsProbeCiphers = []uint16{
+		0x0016, 0x0033, 0x0067, 0xc09e, 0xc0a2, 0x009e, 0x0039, 0x006b,
+		0xc09f, 0xc0a3, 0x009f, 0x0045, 0x00be, 0x0088, 0x00c4, 0x009a,
+		0xc008, 0xc009, 0xc023, 0xc0ac, 0xc0ae, 0xc02b, 0xc00a, 0xc024,
+		0xc0ad, 0xc0af, 0xc02c, 0xc072, 0xc073, 0xcca9, 0x1302, 0x1301,
+		0xcc14, 0xc007, 0xc012, 0xc013, 0xc027, 0xc02f, 0xc014, 0xc028,
+		0xc030, 0xc060, 0xc061, 0xc076, 0xc077, 0xcca8, 0x1305, 0x1304,
+		0x1303, 0xcc13, 0xc011, 0x000a, 0x002f, 0x003c, 0xc09c, 0xc0a0,
+		0x009c, 0x0035, 0x003d, 0xc09d, 0xc0a1, 0x009d, 0x0041, 0x00ba,
+		0x0084, 0x00c0, 0x0007, 0x0004, 0x0005,
+	}
+)
+
+func isTLS13Cipher(c uint16) bool {
+	return c>>8 == 0x13
+}
+
+func (p *tlsServerProbe) ciphers() []uint16 {
+	var list []uint16
+	for _, c := range tlsProbeCiphers {
+		if p.noTLS13Ciphers && isTLS13Cipher(c) {
+			continue
+		}
+		list = append(list, c)
+	}
+
+	n := len(list)
+	var ret []uint16
+	switch p.cipherMode {
+	case tlsProbeCipherReverse:
+		for i := n - 1; i >= 0; i-- {
+			ret = append(ret, list[i])
+		}
+	case tlsProbeCipherTopHalf:
+		ret = append(ret, list[:n/2]...)
+	case tlsProbeCipherBottomHalf:
+		ret = append(ret, list[n/2:]...)
+	case tlsProbeCipherMiddleOut:
+		// 从中间开始向两边交替取
+		mid := n / 2
+		ret = append(ret, list[mid])
+		for i := 1; mid-i >= 0 || mid+i < n; i++ {
+			if mid+i < n {
+				ret = append(ret, list[mid+i])
+			}
+			if mid-i >= 0 {
+				ret = append(ret, list[mid-i])
+			}
+		}
+	default:
+		ret = list
+	}
+	if p.grease {
+		ret = append([]uint16{0x0a0a}, ret...)
+	}
+	return ret
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return binary.BigEndian.AppendUint16(b, v)
+}
+
+func appendTLSExtension(b []byte, typ uint16, data []byte) []byte {
+	b = appendUint16(b, typ)
+	b = appendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func randomBytes(n int) []byte {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return buf
+}
+
+// build 构造完整的 ClientHello 记录
+func (p *tlsServerProbe) build(serverName string) []byte {
+	var ext []byte
+	if p.grease {
+		ext = appendTLSExtension(ext, 0x0a0a, nil)
+	}
+	if serverName != "" && !utils.IsIPv4(serverName) && !utils.IsIPv6(serverName) {
+		var sni []byte
+		sni = appendUint16(sni, uint16(len(serverName)+3))
+		sni = append(sni, 0x00)
+		sni = appendUint16(sni, uint16(len(serverName)))
+		sni = append(sni, serverName...)
+		ext = appendTLSExtension(ext, 0x0000, sni)
+	}
+	ext = appendTLSExtension(ext, 0x0017, nil)
+	ext = appendTLSExtension(ext, 0xff01, []byte{0x00})
+	ext = appendTLSExtension(ext, 0x000a, []byte{0x00, 0x08, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19})
+	ext = appendTLSExtension(ext, 0x000b, []byte{0x01, 0x00})
+	ext = appendTLSExtension(ext, 0x0023, nil)
+	if p.alpn != nil {
+		var protos []byte
+		for _, proto := range p.alpn {
+			protos = append(protos, byte(len(proto)))
+			protos = append(protos, proto...)
+		}
+		ext = appendTLSExtension(ext, 0x0010, append(appendUint16(nil, uint16(len(protos))), protos...))
+	}
+	ext = appendTLSExtension(ext, 0x000d, []byte{
+		0x00, 0x14,
+		0x04, 0x03, 0x08, 0x04, 0x04, 0x01, 0x05, 0x03, 0x08, 0x05,
+		0x05, 0x01, 0x08, 0x06, 0x06, 0x01, 0x02, 0x01, 0x02, 0x03,
+	})
+	if p.tls13 {
+		var share []byte
+		share = appendUint16(share, 0x001d)
+		share = appendUint16(share, 32)
+		share = append(share, randomBytes(32)...)
+		ext = appendTLSExtension(ext, 0x0033, append(appendUint16(nil, uint16(len(share))), share...))
+		ext = appendTLSExtension(ext, 0x002d, []byte{0x01, 0x01})
+		ext = appendTLSExtension(ext, 0x002b, []byte{0x08, 0x03, 0x04, 0x03, 0x03, 0x03, 0x02, 0x03, 0x01})
+	}
+
+	var body []byte
+	body = appendUint16(body, p.version)
+	body = append(body, randomBytes(32)...)
+	body = append(body, 32)
+	body = append(body, randomBytes(32)...)
+	ciphers := p.ciphers()
+	body = appendUint16(body, uint16(len(ciphers)*2))
+	for _, c := range ciphers {
+		body = appendUint16(body, c)
+	}
+	body = append(body, 0x01, 0x00)
+	body = appendUint16(body, uint16(len(ext)))
+	body = append(body, ext...)
+
+	handshake := []byte{tlsutils.HandshakeTypeClientHello, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	handshake = append(handshake, body...)
+
+	record := []byte{0x16}
+	record = appendUint16(record, p.version)
+	record = appendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+func tlsProbeVersionChar(v uint16) string {
+	switch v {
+	case 0x0300:
+		return "a"
+	case 0x0301:
+		return "b"
+	case 0x0302:
+		return "c"
+	case 0x0303:
+		return "d"
+	case 0x0304:
+		return "e"
+	}
+	return "0"
+}
+
+// probeResult 返回 cipher|version|alpn|extensions，没有 ServerHello 时返回 |||
+func tlsServerProbeResult(hello *tlsutils.HandshakeServerHello) string {
+	if hello == nil {
+		return "|||"
+	}
+	var alpn string
+	var exts []string
+	for _, ext := range hello.Extensions {
+		exts = append(exts, fmt.Sprintf("%04x", ext.TypeInt))
+		if ext.TypeInt == 0x0010 && len(ext.RawData) > 3 {
+			alpn = string(ext.RawData[3:])
+		}
+	}
+	return fmt.Sprintf("%04x|%04x|%s|%s", hello.CipherSuite, hello.SelectedVersion(), alpn, strings.Join(exts, "-"))
+}
+
+func tlsServerFingerprintHash(raw []string) string {
+	var fuzzy strings.Builder
+	var tail strings.Builder
+	for _, item := range raw {
+		fields := strings.SplitN(item, "|", 4)
+		if len(fields) != 4 || fields[0] == "" {
+			fuzzy.WriteString("000")
+			continue
+		}
+		index := 0
+		for i, c := range tlsProbeCiphers {
+			if fmt.Sprintf("%04x", c) == fields[0] {
+				index = i + 1
+				break
+			}
+		}
+		version, _ := hex.DecodeString(fields[1])
+		var v uint16
+		if len(version) == 2 {
+			v = binary.BigEndian.Uint16(version)
+		}
+		fuzzy.WriteString(fmt.Sprintf("%02x", index))
+		fuzzy.WriteString(tlsProbeVersionChar(v))
+		tail.WriteString(fields[2])
+		tail.WriteString(fields[3])
+	}
+	if tail.Len() == 0 {
+		return fuzzy.String() + strings.Repeat("0", 32)
+	}
+	sum := sha256.Sum256([]byte(tail.String()))
+	return fuzzy.String() + hex.EncodeToString(sum[:])[:32]
+}
+
+func tlsServerProbeOnce(addr string, serverName string, probe *tlsServerProbe, timeout time.Duration) (*tlsutils.HandshakeServerHello, error) {
+	conn, err := DialTCPTimeout(timeout, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(probe.build(serverName)); err != nil {
+		return nil, err
+	}
+	var buf []byte
+	chunk := make([]byte, 1500)
+	for len(buf) < 16384 {
+		n, err := conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if len(buf) > 0 && buf[0] != 0x16 {
+			return nil, utils.Errorf("server responded with tls record type 0x%02x", buf[0])
+		}
+		if hello, parseErr := tlsutils.ParseServerHello(buf); parseErr == nil {
+			return hello, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, utils.Error("cannot find tls server hello")
+}
+
+// TLSServerFingerprintTimeout 使用 10 个不同的 ClientHello 探测目标，计算 JARM 风格的服务端指纹，
+// 所有探测都没有收到 ServerHello 时返回错误
+func TLSServerFingerprintTimeout(addr string, seconds float64) (*TLSServerFingerprint, error) {
+	host, port, _ := utils.ParseStringToHostPort(addr)
+	if port <= 0 {
+		port = 443
+	}
+	if host == "" {
+		host = addr
+	}
+	timeout := 5 * time.Second
+	if seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	result := &TLSServerFingerprint{}
+	var raw []string
+	for _, probe := range tlsServerProbes {
+		hello, err := tlsServerProbeOnce(utils.HostPort(host, port), host, probe, timeout)
+		if err != nil {
+			raw = append(raw, tlsServerProbeResult(nil))
+			continue
+		}
+		if result.JA3S == "" {
+			result.JA3S = hello.JA3SString()
+		}
+		raw = append(raw, tlsServerProbeResult(hello))
+	}
+	if result.JA3S == "" {
+		return nil, utils.Errorf("no tls server hello from %v", utils.HostPort(host, port))
+	}
+	result.Raw = strings.Join(raw, ",")
+	result.Fingerprint = tlsServerFingerprintHash(raw)
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package netx
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestTLSServerFingerprint(t *testing.T) {
+	host, port := utils.DebugMockHTTPS([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"))
+	addr := utils.HostPort(host, port)
+
+	fp, err := TLSServerFingerprintTimeout(addr, 3)
+	require.NoError(t, err)
+	require.Len(t, fp.Fingerprint, 62)
+	require.NotEmpty(t, fp.JA3S)
+
+	// 同一个服务端的指纹保持不变
+	again, err := TLSServerFingerprintTimeout(addr, 3)
+	require.NoError(t, err)
+	require.Equal(t, fp.Fingerprint, again.Fingerprint)
+}
+
+func TestTLSInspectGM(t *testing.T) {
+	host, port := utils.DebugMockOnlyGMHTTP(context.Background(), nil)
+	results, err := TLSInspectTimeout(utils.HostPort(host, port), 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	require.Equal(t, "gmtls", results[0].Protocol)
+	require.Contains(t, results[0].Description, "SM2")
+}
+
+func TestTLSInspectNoGMFallbackForPlainService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	var accepted int64
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&accepted, 1)
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+
+	results, err := TLSInspectTimeout(lis.Addr().String(), 3)
+	require.NoError(t, err)
+	require.Empty(t, results)
+	// 对端不是 TLS / TLCP 服务时不会再使用 gmtls 重新连接
+	require.EqualValues(t, 1, atomic.LoadInt64(&accepted))
+}
@@ -3,9 +3,12 @@ package netx
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
+	"github.com/yaklang/yaklang/common/gmsm/gmtls"
+	gmx509 "github.com/yaklang/yaklang/common/gmsm/x509"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
@@ -118,7 +121,8 @@ func TLSInspectTimeout(addr string, seconds float64, proto ...string) ([]*TLSIns
 		KeyLogWriter:       nil,
 		NextProtos:         []string{"h2", "http/1.1"},
 	}
-	tlsConn := tls.Client(conn, tlsConfig)
+	recorder := &recordHeaderConn{Conn: conn}
+	tlsConn := tls.Client(recorder, tlsConfig)
 	if len(proto) > 0 {
 		tlsConfig.NextProtos = proto
 	}
@@ -126,9 +130,85 @@ func TLSInspectTimeout(addr string, seconds float64, proto ...string) ([]*TLSIns
 	if err != nil {
 		log.Errorf("TLSInspect: handshake error: %s", err)
 	}
+	if len(results) <= 0 && isTLSRecordPrefix(recorder.head) {
+		// 标准库不支持国密 TLCP，对端回复了 TLS / TLCP 记录但是握手失败时再使用 gmtls 获取 SM2 证书
+		results = tlsInspectGM(host, port, dialTimeout)
+	}
 	return results, nil
 }
 
+// recordHeaderConn 记录对端返回的前 3 个字节(记录类型和版本号)，用来判断握手失败时对端是否是 TLS / TLCP 服务
+type recordHeaderConn struct {
+	net.Conn
+	head []byte
+}
+
+func (c *recordHeaderConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if need := 3 - len(c.head); need > 0 && n > 0 {
+		if n < need {
+			need = n
+		}
+		c.head = append(c.head, b[:need]...)
+	}
+	return n, err
+}
+
+// isTLSRecordPrefix 判断是否是服务端返回的 TLS / TLCP 记录：handshake(0x16) 或 alert(0x15)，版本号为 0x03xx 或国密的 0x0101
+func isTLSRecordPrefix(b []byte) bool {
+	if len(b) < 3 || (b[0] != 0x16 && b[0] != 0x15) {
+		return false
+	}
+	return b[1] == 0x03 || (b[1] == 0x01 && b[2] == 0x01)
+}
+
+func tlsInspectGM(host string, port int, timeout time.Duration) []*TLSInspectResult {
+	conn, err := DialTCPTimeout(timeout, utils.HostPort(host, port))
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	var results []*TLSInspectResult
+	gmConn := gmtls.Client(conn, &gmtls.Config{
+		GMSupport:          &gmtls.GMSupport{},
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*gmx509.Certificate) error {
+			for _, raw := range rawCerts {
+				info, err := tlsutils.ParseCertificateInfo(raw)
+				if err != nil {
+					continue
+				}
+				var accounts []string
+				domains := append([]string{}, info.DNSNames...)
+				for _, e := range info.EmailAddresses {
+					if account, domain, ok := strings.Cut(e, "@"); ok {
+						domains = append(domains, domain)
+						accounts = append(accounts, account)
+					} else {
+						accounts = append(accounts, e)
+					}
+				}
+				results = append(results, &TLSInspectResult{
+					Protocol:        "gmtls",
+					Description:     info.String(),
+					Raw:             raw,
+					RelativeDomains: utils.RemoveRepeatStringSlice(domains),
+					RelativeEmail:   utils.RemoveRepeatStringSlice(info.EmailAddresses),
+					RelativeAccount: utils.RemoveRepeatStringSlice(accounts),
+				})
+			}
+			return nil
+		},
+	})
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if err := gmConn.Handshake(); err != nil && len(results) <= 0 {
+		log.Debugf("TLSInspect: gmtls handshake error: %s", err)
+	}
+	return results
+}
+
 // Inspect 检查目标地址的TLS证书，并返回其证书信息与错误
 // Example:
 // ```
@@ -143,6 +143,39 @@ func arpDial(ctx context.Context, ifaceName string, addrs string) (map[string]ne
 }
 
 func ArpIPAddressesWithContext(ctx context.Context, ifaceName string, addrs string) (map[string]net.HardwareAddr, error) {
+	// IPv6 地址使用邻居发现协议
+	v4Addrs, v6Addrs := splitIPv6Targets(addrs)
+	if len(v6Addrs) > 0 {
+		v6Results, err := NDPWithPcap(ctx, ifaceName, strings.Join(v6Addrs, ","))
+		if err != nil {
+			log.Errorf("send ndp request with pcap failed: %s", err)
+		}
+		if v4Addrs == "" {
+			if len(v6Results) > 0 {
+				for ip, hw := range v6Results {
+					arpTableTTLCache.Set(ip, hw)
+				}
+				return v6Results, nil
+			}
+			return nil, utils.Errorf("cannot fetch (%v) %v 's mac address", ifaceName, addrs)
+		}
+		v4Results, err := arpIPv4AddressesWithContext(ctx, ifaceName, v4Addrs)
+		if v4Results == nil {
+			v4Results = make(map[string]net.HardwareAddr)
+		}
+		for ip, hw := range v6Results {
+			arpTableTTLCache.Set(ip, hw)
+			v4Results[ip] = hw
+		}
+		if len(v4Results) > 0 {
+			return v4Results, nil
+		}
+		return nil, err
+	}
+	return arpIPv4AddressesWithContext(ctx, ifaceName, addrs)
+}
+
+func arpIPv4AddressesWithContext(ctx context.Context, ifaceName string, addrs string) (map[string]net.HardwareAddr, error) {
 	var resultsMap = make(map[string]net.HardwareAddr)
 	var err error
 	if runtime.GOOS != "windows" {
@@ -0,0 +1,241 @@
+package arpx
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/pcap"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/omap"
+)
+
+// IPv6 没有 ARP，使用 ICMPv6 邻居发现协议（NDP）获取 mac 地址：
+// 向目标的 solicited-node 组播地址发送 Neighbor Solicitation，目标回复 Neighbor Advertisement
+
+// splitIPv6Targets 把目标拆分为 IPv4 目标（仍然是逗号分隔的字符串，支持网段）和 IPv6 地址列表
+//
+// IPv6 网段过大，无法逐个发送邻居请求，所以只支持单个地址
+func splitIPv6Targets(targets string) (string, []string) {
+	var v4, v6 []string
+	for _, item := range utils.PrettifyListFromStringSplitEx(targets, ",", "\n") {
+		ip := net.ParseIP(utils.FixForParseIP(item))
+		if ip != nil && ip.To4() == nil {
+			v6 = append(v6, ip.String())
+			continue
+		}
+		if strings.Contains(item, ":") && strings.Contains(item, "/") {
+			log.Warnf("ipv6 network %v is not supported for neighbor discovery, skip", item)
+			continue
+		}
+		v4 = append(v4, item)
+	}
+	return strings.Join(v4, ","), v6
+}
+
+// solicitedNodeMulticast 返回 ip 对应的 solicited-node 组播地址 ff02::1:ffXX:XXXX 和组播 mac 地址 33:33:ff:XX:XX:XX
+func solicitedNodeMulticast(ip net.IP) (net.IP, net.HardwareAddr) {
+	ip = ip.To16()
+	group := net.IP{0xff, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01, 0xff, ip[13], ip[14], ip[15]}
+	mac := net.HardwareAddr{0x33, 0x33, 0xff, ip[13], ip[14], ip[15]}
+	return group, mac
+}
+
+// ndpSourceIP 选择发送邻居请求使用的源地址，优先使用链路本地地址
+func ndpSourceIP(iface *net.Interface) (net.IP, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, utils.Errorf("fetch src ip failed: %s", err)
+	}
+	var global net.IP
+	for _, a := range addrs {
+		ip, _, err := net.ParseCIDR(a.String())
+		if err != nil || ip.To4() != nil {
+			continue
+		}
+		if ip.IsLinkLocalUnicast() {
+			return ip, nil
+		}
+		if global == nil && ip.IsGlobalUnicast() {
+			global = ip
+		}
+	}
+	if global != nil {
+		return global, nil
+	}
+	return nil, utils.Errorf("iface[%v] 's ipv6 address cannot be found", iface.Name)
+}
+
+func newNeighborSolicitationPacket(iface *net.Interface, ip string) (gopacket.SerializeBuffer, error) {
+	target := net.ParseIP(utils.FixForParseIP(ip))
+	if target == nil || target.To4() != nil {
+		return nil, utils.Errorf("parse ipv6[%v] failed", ip)
+	}
+	src, err := ndpSourceIP(iface)
+	if err != nil {
+		return nil, err
+	}
+	group, groupMac := solicitedNodeMulticast(target)
+
+	eth := &layers.Ethernet{SrcMAC: iface.HardwareAddr, DstMAC: groupMac, EthernetType: layers.EthernetTypeIPv6}
+	ip6 := &layers.IPv6{
+		Version:    6,
+		NextHeader: layers.IPProtocolICMPv6,
+		// RFC 4861: 邻居发现报文的 hop limit 必须是 255
+		HopLimit: 255,
+		SrcIP:    src,
+		DstIP:    group,
+	}
+	icmp6 := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0)}
+	if err := icmp6.SetNetworkLayerForChecksum(ip6); err != nil {
+		return nil, err
+	}
+	ns := &layers.ICMPv6NeighborSolicitation{
+		TargetAddress: target,
+		Options: layers.ICMPv6Options{
+			{Type: layers.ICMPv6OptSourceAddress, Data: iface.HardwareAddr},
+		},
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}
+	err = gopacket.SerializeLayers(buf, opts, eth, ip6, icmp6, ns)
+	if err != nil {
+		return nil, utils.Errorf("serialize neighbor solicitation packet failed: %s", err)
+	}
+	return buf, nil
+}
+
+// ParseNeighborAdvertisement 从邻居通告报文中解析出 ip 和 mac 地址，
+// 优先使用 Target Link-Layer Address 选项，没有选项时使用以太网源地址
+func ParseNeighborAdvertisement(packet gopacket.Packet) (net.IP, net.HardwareAddr, bool) {
+	na, ok := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement).(*layers.ICMPv6NeighborAdvertisement)
+	if !ok || na.TargetAddress == nil {
+		return nil, nil, false
+	}
+	for _, opt := range na.Options {
+		if opt.Type == layers.ICMPv6OptTargetAddress && len(opt.Data) >= 6 {
+			return na.TargetAddress, net.HardwareAddr(opt.Data[:6]), true
+		}
+	}
+	if eth, ok := packet.LinkLayer().(*layers.Ethernet); ok {
+		return na.TargetAddress, eth.SrcMAC, true
+	}
+	return nil, nil, false
+}
+
+// NDPWithPcap 通过邻居发现协议获取 IPv6 地址的 mac 地址，targets 为逗号分隔的 IPv6 地址
+func NDPWithPcap(ctx context.Context, ifaceName string, targets string) (map[string]net.HardwareAddr, error) {
+	ifaceIns, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	if ifaceIns.Flags&net.FlagLoopback != 0 {
+		return nil, TargetIsLoopback
+	}
+
+	// 没有设置 deadline 时，发送完邻居请求之后默认等待 5 秒
+	timeout := 5 * time.Second
+	if ctx == nil {
+		ctx = context.Background()
+	} else if ddl, ok := ctx.Deadline(); ok {
+		timeout = time.Until(ddl)
+	}
+
+	_, targetList := splitIPv6Targets(targets)
+	if len(targetList) == 0 {
+		return nil, utils.Errorf("no ipv6 target in %v", targets)
+	}
+	targetFilter := make(map[string]struct{}, len(targetList))
+	for _, t := range targetList {
+		targetFilter[t] = struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := omap.NewOrderedMap(map[string]net.HardwareAddr{})
+	var resultSize int64 = 0
+	senderMutex := new(sync.Mutex)
+
+	err = pcaputil.Start(
+		pcaputil.WithDevice(ifaceName),
+		pcaputil.WithEnableCache(true),
+		pcaputil.WithBPFFilter("icmp6"),
+		pcaputil.WithContext(ctx),
+		pcaputil.WithNetInterfaceCreated(func(handle *pcap.Handle) {
+			go func() {
+				for _, p := range targetList {
+					buf, err := newNeighborSolicitationPacket(ifaceIns, p)
+					if err != nil {
+						log.Errorf("new neighbor solicitation packet failed: %s", err)
+						continue
+					}
+					for i := 0; i < 2; i++ {
+						select {
+						case <-ctx.Done():
+							return
+						default:
+						}
+						if results.Have(p) {
+							break
+						}
+						senderMutex.Lock()
+						err = handle.WritePacketData(buf.Bytes())
+						time.Sleep(5 * time.Millisecond) // some ms delay for write
+						senderMutex.Unlock()
+						if err != nil {
+							log.Errorf("write packet failed: %s", err)
+							break
+						}
+					}
+				}
+				time.Sleep(timeout)
+				cancel()
+			}()
+		}),
+		pcaputil.WithEveryPacket(func(packet gopacket.Packet) {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			ip, hw, ok := ParseNeighborAdvertisement(packet)
+			if !ok || bytes.Equal(ifaceIns.HardwareAddr, hw) {
+				return
+			}
+			ipAddr := ip.String()
+			if _, ok := targetFilter[ipAddr]; !ok || results.Have(ipAddr) {
+				return
+			}
+			log.Infof("IPv6[%v] 's mac addr: %v", ipAddr, hw)
+			results.Set(ipAddr, hw)
+			if atomic.AddInt64(&resultSize, 1) >= int64(len(targetList)) {
+				cancel()
+			}
+		}),
+	)
+	if results.Len() > 0 {
+		ret := make(map[string]net.HardwareAddr)
+		results.ForEach(func(i string, v net.HardwareAddr) bool {
+			ret[i] = v
+			return true
+		})
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, utils.Errorf("cannot fetch (%v) %v 's mac address by ndp", ifaceName, targets)
+}
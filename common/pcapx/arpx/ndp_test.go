@@ -0,0 +1,51 @@
+package arpx
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIPv6Targets(t *testing.T) {
+	v4, v6 := splitIPv6Targets("192.168.1.1/30,fe80::1,[2001:db8::2],2001:db8::/64")
+	require.Equal(t, "192.168.1.1/30", v4)
+	require.Equal(t, []string{"fe80::1", "2001:db8::2"}, v6)
+}
+
+func TestNeighborSolicitationPacket(t *testing.T) {
+	target := net.ParseIP("fe80::aabb:ccff:fedd:eeff")
+	group, mac := solicitedNodeMulticast(target)
+	require.Equal(t, "ff02::1:ffdd:eeff", group.String())
+	require.Equal(t, "33:33:ff:dd:ee:ff", mac.String())
+
+	// 构造邻居通告，检查解析结果
+	targetMac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{2, 0, 0, 0, 0, 1}, DstMAC: net.HardwareAddr{2, 0, 0, 0, 0, 2}, EthernetType: layers.EthernetTypeIPv6}
+	ip6 := &layers.IPv6{Version: 6, NextHeader: layers.IPProtocolICMPv6, HopLimit: 255, SrcIP: target, DstIP: net.ParseIP("fe80::1")}
+	icmp6 := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborAdvertisement, 0)}
+	require.NoError(t, icmp6.SetNetworkLayerForChecksum(ip6))
+	na := &layers.ICMPv6NeighborAdvertisement{
+		Flags:         0x60,
+		TargetAddress: target,
+		Options:       layers.ICMPv6Options{{Type: layers.ICMPv6OptTargetAddress, Data: targetMac}},
+	}
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip6, icmp6, na))
+
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	ip, hw, ok := ParseNeighborAdvertisement(packet)
+	require.True(t, ok)
+	require.Equal(t, target.String(), ip.String())
+	require.Equal(t, targetMac.String(), hw.String())
+
+	// 没有选项时使用以太网源地址
+	na.Options = nil
+	buf = gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip6, icmp6, na))
+	_, hw, ok = ParseNeighborAdvertisement(gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default))
+	require.True(t, ok)
+	require.Equal(t, eth.SrcMAC.String(), hw.String())
+}
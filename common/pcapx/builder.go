@@ -3,7 +3,6 @@ package pcapx
 import (
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/yaklang/yaklang/common/go-funk"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"net"
@@ -48,10 +47,12 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		udpConfig   *layers.UDP
 		tcpConfig   *layers.TCP
 		icmp4Config *layers.ICMPv4
+		icmp6Config *ICMPv6Config
 
 		// link and network
 		arpConfig      *layers.ARP
 		ip4Config      *layers.IPv4
+		ip6Config      *layers.IPv6
 		ethernetConfig *layers.Ethernet
 	)
 	for _, opt := range opts {
@@ -82,6 +83,14 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 			if err != nil {
 				return nil, utils.Errorf("set icmp4 config failed: %s", err)
 			}
+		case ICMPv6Option:
+			if icmp6Config == nil {
+				icmp6Config = newDefaultICMPv6Config()
+			}
+			err := optFunc(icmp6Config)
+			if err != nil {
+				return nil, utils.Errorf("set icmp6 config failed: %s", err)
+			}
 		case ArpConfig:
 			if arpConfig == nil {
 				arpConfig = &layers.ARP{
@@ -103,6 +112,14 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 			if err != nil {
 				return nil, utils.Errorf("set ipv4 config failed: %s", err)
 			}
+		case IPv6Option:
+			if ip6Config == nil {
+				ip6Config = NewDefaultIPv6Layer()
+			}
+			err := optFunc(ip6Config)
+			if err != nil {
+				return nil, utils.Errorf("set ipv6 config failed: %s", err)
+			}
 		case EthernetOption:
 			if ethernetConfig == nil {
 				ethernetConfig = &layers.Ethernet{
@@ -119,91 +136,123 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		}
 	}
 
+	// ipv4_srcIp / ipv4_dstIp 中填写的是 IPv6 地址时，自动使用 IPv6 层
+	if ip4Config != nil && ip6Config == nil && (isIPv6Address(ip4Config.SrcIP) || isIPv6Address(ip4Config.DstIP)) {
+		ip6Config = ipv4ToIPv6Layer(ip4Config)
+		ip4Config = nil
+	}
+	// 只设置了 ICMPv6 时默认使用 IPv6 层
+	if icmp6Config != nil && ip4Config == nil && ip6Config == nil && arpConfig == nil {
+		ip6Config = NewDefaultIPv6Layer()
+	}
+
+	/*
+		check network layer?
+	*/
+	var networkLayerCount int
+	for _, enabled := range []bool{arpConfig != nil, ip4Config != nil, ip6Config != nil} {
+		if enabled {
+			networkLayerCount++
+		}
+	}
+	if networkLayerCount > 1 {
+		return nil, utils.Errorf("PacketBuilder: only one network layer is allowed, need ip / ipv6 / arp layer")
+	}
+
+	var networkEthernetType = layers.EthernetTypeIPv4
+	if ip6Config != nil {
+		networkEthernetType = layers.EthernetTypeIPv6
+	}
+
 	/**
 	LinkLayer can be Ethernet(Default) or Loopback
 	*/
 	var linkLayer *layers.Ethernet
 	if baseConfig.Loopback {
-		linkLayer = &layers.Ethernet{EthernetType: layers.EthernetTypeIPv4}
+		// loopback 网卡上的以太网头的 mac 地址全为 0
+		linkLayer = &layers.Ethernet{
+			EthernetType: networkEthernetType,
+			SrcMAC:       make(net.HardwareAddr, 6),
+			DstMAC:       make(net.HardwareAddr, 6),
+		}
 	} else if ethernetConfig != nil {
 		linkLayer = ethernetConfig
 	} else {
 		var err error
-		linkLayer, err = GetPublicToServerLinkLayerIPv4()
+		if ip6Config != nil {
+			linkLayer, err = GetPublicToServerLinkLayerIPv6()
+		} else {
+			linkLayer, err = GetPublicToServerLinkLayerIPv4()
+		}
 		if err != nil {
 			log.Errorf("PacketBuilder: %v", err)
 			linkLayer = &layers.Ethernet{
-				EthernetType: layers.EthernetTypeIPv4,
+				EthernetType: networkEthernetType,
 				SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
 				DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
 			}
 		}
 	}
 
-	/*
-		check network layer?
-	*/
-	var fetched = false
-	var networkLayerRaw any
-	for _, l := range []any{
-		arpConfig, ip4Config,
-	} {
-		if funk.IsEmpty(l) {
-			if fetched {
-				return nil, utils.Errorf("PacketBuilder: only one network layer is allowed, need ip / arp layer")
-			} else {
-				fetched = true
-				networkLayerRaw = l
-			}
-		}
-	}
-	if networkLayerRaw == nil {
-		return nil, utils.Errorf("PacketBuilder: network layer is empty")
-	}
-
 	var networkLayer gopacket.SerializableLayer
 	var ipEnabled bool
-	if !funk.IsEmpty(ip4Config) {
+	if ip4Config != nil {
 		ipEnabled = true
 		networkLayer = ip4Config
-		if ip4Config.Version == 6 {
-			linkLayer.EthernetType = layers.EthernetTypeIPv6
-		}
-	} else if !funk.IsEmpty(arpConfig) {
+		linkLayer.EthernetType = layers.EthernetTypeIPv4
+	} else if ip6Config != nil {
+		ipEnabled = true
+		networkLayer = ip6Config
+		linkLayer.EthernetType = layers.EthernetTypeIPv6
+	} else if arpConfig != nil {
 		networkLayer = arpConfig
 		linkLayer.EthernetType = layers.EthernetTypeARP
 	} else {
 		return nil, utils.Errorf("PacketBuilder: network layer is empty")
 	}
-	_ = networkLayer
+
+	// setProtocol 设置网络层中的上层协议字段
+	setProtocol := func(proto layers.IPProtocol) {
+		if ip4Config != nil {
+			ip4Config.Protocol = proto
+		} else {
+			ip6Config.NextHeader = proto
+		}
+	}
 
 	var err error
 	if ipEnabled {
 		// TCP/IP Stack!
 		// TransportLayer can be TCP(Default) / ICMP / IGMP / UDP ...
-		var transportLayer gopacket.SerializableLayer
+		var transportLayers []gopacket.SerializableLayer
+		networkForChecksum := networkLayer.(gopacket.NetworkLayer)
 	TRANS:
 		if tcpConfig != nil {
-			tcpLayer := tcpConfig
-			ip4Layer := networkLayer.(*layers.IPv4)
-			err := tcpLayer.SetNetworkLayerForChecksum(ip4Layer)
+			err := tcpConfig.SetNetworkLayerForChecksum(networkForChecksum)
 			if err != nil {
 				return nil, utils.Errorf("TCP checksum failed: %s", err)
 			}
-			ip4Layer.Protocol = layers.IPProtocolTCP
-			transportLayer = tcpLayer
-		} else if icmp4Config != nil {
-			transportLayer = icmp4Config
-			ip4Layer := networkLayer.(*layers.IPv4)
-			ip4Layer.Protocol = layers.IPProtocolICMPv4
+			setProtocol(layers.IPProtocolTCP)
+			transportLayers = append(transportLayers, tcpConfig)
+		} else if icmp4Config != nil && ip4Config != nil {
+			setProtocol(layers.IPProtocolICMPv4)
+			transportLayers = append(transportLayers, icmp4Config)
+		} else if icmp6Config != nil && ip6Config != nil {
+			err := icmp6Config.ICMPv6.SetNetworkLayerForChecksum(networkForChecksum)
+			if err != nil {
+				return nil, utils.Errorf("ICMPv6 checksum failed: %s", err)
+			}
+			setProtocol(layers.IPProtocolICMPv6)
+			transportLayers = append(transportLayers, icmp6Config.layers()...)
+		} else if icmp4Config != nil || icmp6Config != nil {
+			return nil, utils.Errorf("PacketBuilder: icmp4 needs ipv4 layer and icmp6 needs ipv6 layer")
 		} else if udpConfig != nil {
-			ip4Layer := networkLayer.(*layers.IPv4)
-			ip4Layer.Protocol = layers.IPProtocolUDP
-			err := udpConfig.SetNetworkLayerForChecksum(ip4Layer)
+			setProtocol(layers.IPProtocolUDP)
+			err := udpConfig.SetNetworkLayerForChecksum(networkForChecksum)
 			if err != nil {
 				return nil, utils.Errorf("UDP checksum failed: %s", err)
 			}
-			transportLayer = udpConfig
+			transportLayers = append(transportLayers, udpConfig)
 		} else {
 			log.Warn("PacketBuilder: tcp layer is empty, use default")
 			tcpConfig = NewDefaultTCPLayer()
@@ -216,7 +265,7 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		var buf = gopacket.NewSerializeBuffer()
 		err = gopacket.SerializeLayers(
 			buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
-			linkLayer, networkLayer, transportLayer, gopacket.Payload(baseConfig.Payload),
+			append(append([]gopacket.SerializableLayer{linkLayer, networkLayer}, transportLayers...), gopacket.Payload(baseConfig.Payload))...,
 		)
 		if err != nil {
 			return nil, utils.Errorf(`gopacket.SerializeLayers failed: %s`, err)
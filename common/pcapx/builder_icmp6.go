@@ -0,0 +1,146 @@
+package pcapx
+
+import (
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var icmp6LayerExports = map[string]any{
+	"ICMPV6_TYPE_DEST_UNREACH":           layers.ICMPv6TypeDestinationUnreachable,
+	"ICMPV6_TYPE_PACKET_TOO_BIG":         layers.ICMPv6TypePacketTooBig,
+	"ICMPV6_TYPE_TIME_EXCEEDED":          layers.ICMPv6TypeTimeExceeded,
+	"ICMPV6_TYPE_PARAM_PROBLEM":          layers.ICMPv6TypeParameterProblem,
+	"ICMPV6_TYPE_ECHO_REQUEST":           layers.ICMPv6TypeEchoRequest,
+	"ICMPV6_TYPE_ECHO_REPLY":             layers.ICMPv6TypeEchoReply,
+	"ICMPV6_TYPE_ROUTER_SOLICITATION":    layers.ICMPv6TypeRouterSolicitation,
+	"ICMPV6_TYPE_ROUTER_ADVERTISEMENT":   layers.ICMPv6TypeRouterAdvertisement,
+	"ICMPV6_TYPE_NEIGHBOR_SOLICITATION":  layers.ICMPv6TypeNeighborSolicitation,
+	"ICMPV6_TYPE_NEIGHBOR_ADVERTISEMENT": layers.ICMPv6TypeNeighborAdvertisement,
+	"ICMPV6_TYPE_REDIRECT":               layers.ICMPv6TypeRedirect,
+
+	"icmp6_type":                 WithICMPv6_Type,
+	"icmp6_id":                   WithICMPv6_Id,
+	"icmp6_seq":                  WithICMPv6_Sequence,
+	"icmp6_payload":              WithICMPv6_Payload,
+	"icmp6_neighborSolicitation": WithICMPv6_NeighborSolicitation,
+}
+
+func init() {
+	for k, v := range icmp6LayerExports {
+		Exports[k] = v
+	}
+}
+
+// ICMPv6Config 是 ICMPv6 报文，TypeCode 之后的消息体由 Echo 或者 NeighborSolicitation 决定
+type ICMPv6Config struct {
+	ICMPv6               *layers.ICMPv6
+	Echo                 *layers.ICMPv6Echo
+	NeighborSolicitation *layers.ICMPv6NeighborSolicitation
+	Payload              []byte
+}
+
+type ICMPv6Option func(config *ICMPv6Config) error
+
+func newDefaultICMPv6Config() *ICMPv6Config {
+	return &ICMPv6Config{
+		ICMPv6: &layers.ICMPv6{
+			TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeEchoRequest, 0),
+		},
+	}
+}
+
+// layers 返回需要序列化的 ICMPv6 层，调用前需要设置好校验和使用的网络层
+func (c *ICMPv6Config) layers() []gopacket.SerializableLayer {
+	ret := []gopacket.SerializableLayer{c.ICMPv6}
+	switch c.ICMPv6.TypeCode.Type() {
+	case layers.ICMPv6TypeEchoRequest, layers.ICMPv6TypeEchoReply:
+		if c.Echo == nil {
+			c.Echo = &layers.ICMPv6Echo{}
+		}
+		ret = append(ret, c.Echo)
+	case layers.ICMPv6TypeNeighborSolicitation:
+		if c.NeighborSolicitation != nil {
+			ret = append(ret, c.NeighborSolicitation)
+		}
+	}
+	if len(c.Payload) > 0 {
+		ret = append(ret, gopacket.Payload(c.Payload))
+	}
+	return ret
+}
+
+func WithICMPv6_Type(icmpType any, icmpCode any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		if funk.IsEmpty(icmpCode) {
+			icmpCode = 0
+		}
+		config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(uint8(utils.InterfaceToInt(icmpType)), uint8(utils.InterfaceToInt(icmpCode)))
+		return nil
+	}
+}
+
+func WithICMPv6_Id(id any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		if config.Echo == nil {
+			config.Echo = &layers.ICMPv6Echo{}
+		}
+		config.Echo.Identifier = uint16(utils.InterfaceToInt(id))
+		return nil
+	}
+}
+
+func WithICMPv6_Sequence(sequence any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		if config.Echo == nil {
+			config.Echo = &layers.ICMPv6Echo{}
+		}
+		config.Echo.SeqNumber = uint16(utils.InterfaceToInt(sequence))
+		return nil
+	}
+}
+
+func WithICMPv6_Payload(i []byte) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		config.Payload = i
+		return nil
+	}
+}
+
+// WithICMPv6_NeighborSolicitation 构造邻居请求报文（IPv6 中替代 ARP 请求），srcMac 不为空时会带上源链路层地址选项
+func WithICMPv6_NeighborSolicitation(target any, srcMac any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		targetIP := net.ParseIP(utils.FixForParseIP(utils.InterfaceToString(target)))
+		if targetIP == nil {
+			return utils.Errorf("WithICMPv6_NeighborSolicitation error: invalid target %v", target)
+		}
+		config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0)
+		config.NeighborSolicitation = &layers.ICMPv6NeighborSolicitation{TargetAddress: targetIP}
+
+		var hw net.HardwareAddr
+		switch ret := srcMac.(type) {
+		case net.HardwareAddr:
+			hw = ret
+		case []byte:
+			hw = ret
+		default:
+			if !funk.IsEmpty(srcMac) {
+				var err error
+				hw, err = net.ParseMAC(utils.InterfaceToString(srcMac))
+				if err != nil {
+					return utils.Errorf("WithICMPv6_NeighborSolicitation error: invalid mac %v", srcMac)
+				}
+			}
+		}
+		if len(hw) > 0 {
+			config.NeighborSolicitation.Options = append(config.NeighborSolicitation.Options, layers.ICMPv6Option{
+				Type: layers.ICMPv6OptSourceAddress,
+				Data: hw,
+			})
+		}
+		return nil
+	}
+}
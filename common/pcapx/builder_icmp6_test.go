@@ -0,0 +1,67 @@
+package pcapx
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestSmoking_ICMPv6Echo(t *testing.T) {
+	packets, err := PacketBuilder(
+		WithLoopback(),
+		WithIPv6_SrcIP("2001:db8::1"),
+		WithIPv6_DstIP("2001:db8::2"),
+		WithICMPv6_Type(layers.ICMPv6TypeEchoRequest, nil),
+		WithICMPv6_Id(1234),
+		WithICMPv6_Sequence(7),
+		WithPayload([]byte("hello yakit pcapx world")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	if packet.ErrorLayer() != nil {
+		t.Fatal(packet.ErrorLayer().Error())
+	}
+	echo, ok := packet.Layer(layers.LayerTypeICMPv6Echo).(*layers.ICMPv6Echo)
+	if !ok {
+		t.Fatal("expect icmpv6 echo layer, not found")
+	}
+	if echo.Identifier != 1234 || echo.SeqNumber != 7 {
+		t.Fatalf("bad echo: %v", echo)
+	}
+	if !bytes.Contains(packets, []byte("hello yakit pcapx world")) {
+		t.Fatal("payload not found")
+	}
+}
+
+func TestSmoking_ICMPv6NeighborSolicitation(t *testing.T) {
+	mac, _ := net.ParseMAC("00:11:22:33:44:55")
+	packets, err := PacketBuilder(
+		WithLoopback(),
+		WithIPv6_SrcIP("fe80::1"),
+		WithIPv6_DstIP("ff02::1:ff00:2"),
+		WithIPv6_HopLimit(255),
+		WithICMPv6_NeighborSolicitation("fe80::2", mac),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	if packet.ErrorLayer() != nil {
+		t.Fatal(packet.ErrorLayer().Error())
+	}
+	ns, ok := packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation).(*layers.ICMPv6NeighborSolicitation)
+	if !ok {
+		t.Fatal("expect neighbor solicitation layer, not found")
+	}
+	if ns.TargetAddress.String() != "fe80::2" {
+		t.Fatalf("bad target: %v", ns.TargetAddress)
+	}
+	if len(ns.Options) != 1 || !bytes.Equal(ns.Options[0].Data, mac) {
+		t.Fatalf("bad options: %v", ns.Options)
+	}
+}
@@ -0,0 +1,124 @@
+package pcapx
+
+import (
+	"net"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var ipv6LayerExports = map[string]any{
+	"ipv6_srcIp":             WithIPv6_SrcIP,
+	"ipv6_dstIp":             WithIPv6_DstIP,
+	"ipv6_hopLimit":          WithIPv6_HopLimit,
+	"ipv6_trafficClass":      WithIPv6_TrafficClass,
+	"ipv6_flowLabel":         WithIPv6_FlowLabel,
+	"ipv6_nextLayerProtocol": WithIPv6_NextHeader,
+
+	"IPV6_PROTOCOL_TCP":    int(layers.IPProtocolTCP),
+	"IPV6_PROTOCOL_UDP":    int(layers.IPProtocolUDP),
+	"IPV6_PROTOCOL_ICMPV6": int(layers.IPProtocolICMPv6),
+}
+
+func init() {
+	for k, v := range ipv6LayerExports {
+		Exports[k] = v
+	}
+}
+
+type IPv6Option func(pv6 *layers.IPv6) error
+
+func NewDefaultIPv6Layer() *layers.IPv6 {
+	return &layers.IPv6{
+		Version:    6,
+		HopLimit:   64,
+		NextHeader: layers.IPProtocolTCP,
+	}
+}
+
+/*
+// IPv6 is the layer for the IPv6 header.
+type IPv6 struct {
+	BaseLayer
+	Version      uint8
+	TrafficClass uint8
+	FlowLabel    uint32
+	Length       uint16
+	NextHeader   IPProtocol
+	HopLimit     uint8
+	SrcIP        net.IP
+	DstIP        net.IP
+	HopByHop     *IPv6HopByHop
+}
+
+一般来说，不需要操作的字段有：Length / NextHeader（会根据传输层自动设置）
+*/
+
+func WithIPv6_SrcIP(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.SrcIP = net.ParseIP(utils.FixForParseIP(utils.InterfaceToString(i)))
+		if pv6.SrcIP == nil {
+			return utils.Errorf("WithIPv6_SrcIP error: %v", i)
+		}
+		return nil
+	}
+}
+
+func WithIPv6_DstIP(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.DstIP = net.ParseIP(utils.FixForParseIP(utils.InterfaceToString(i)))
+		if pv6.DstIP == nil {
+			return utils.Errorf("WithIPv6_DstIP error: %v", i)
+		}
+		return nil
+	}
+}
+
+func WithIPv6_HopLimit(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.HopLimit = uint8(utils.InterfaceToInt(i))
+		return nil
+	}
+}
+
+func WithIPv6_TrafficClass(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.TrafficClass = uint8(utils.InterfaceToInt(i))
+		return nil
+	}
+}
+
+func WithIPv6_FlowLabel(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.FlowLabel = uint32(utils.InterfaceToInt(i)) & 0xfffff
+		return nil
+	}
+}
+
+func WithIPv6_NextHeader(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		proto, err := parseIPProtocol(i)
+		if err != nil {
+			return err
+		}
+		pv6.NextHeader = proto
+		return nil
+	}
+}
+
+// ipv4ToIPv6Layer 用于兼容使用 ipv4_srcIp / ipv4_dstIp 填写 IPv6 地址的情况
+func ipv4ToIPv6Layer(pv4 *layers.IPv4) *layers.IPv6 {
+	pv6 := NewDefaultIPv6Layer()
+	pv6.SrcIP = pv4.SrcIP
+	pv6.DstIP = pv4.DstIP
+	pv6.TrafficClass = pv4.TOS
+	if pv4.TTL > 0 {
+		pv6.HopLimit = pv4.TTL
+	}
+	pv6.NextHeader = pv4.Protocol
+	return pv6
+}
+
+func isIPv6Address(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil && ip.To16() != nil
+}
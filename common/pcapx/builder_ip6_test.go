@@ -0,0 +1,87 @@
+package pcapx
+
+import (
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestSmoking_IPv6TCP(t *testing.T) {
+	packets, err := PacketBuilder(
+		WithLoopback(),
+		WithIPv6_SrcIP("2001:db8::1"),
+		WithIPv6_DstIP("2001:db8::2"),
+		WithTCP_SrcPort(40000),
+		WithTCP_DstPort(443),
+		WithTCP_Flags("syn"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	if packet.ErrorLayer() != nil {
+		t.Fatal(packet.ErrorLayer().Error())
+	}
+	ip6, ok := packet.NetworkLayer().(*layers.IPv6)
+	if !ok {
+		t.Fatalf("expect ipv6 layer, got %v", packet.NetworkLayer().LayerType())
+	}
+	if ip6.NextHeader != layers.IPProtocolTCP || ip6.DstIP.String() != "2001:db8::2" {
+		t.Fatalf("bad ipv6 layer: %v", ip6)
+	}
+	tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok || !tcp.SYN || tcp.DstPort != 443 {
+		t.Fatal("expect ipv6 tcp syn layer")
+	}
+
+	// 重新计算校验和，应当与构造时一致
+	checksum := tcp.Checksum
+	tcp.SetNetworkLayerForChecksum(ip6)
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}, tcp, gopacket.Payload(tcp.Payload)); err != nil {
+		t.Fatal(err)
+	}
+	if tcp.Checksum != checksum {
+		t.Fatalf("tcp checksum mismatch: %x != %x", tcp.Checksum, checksum)
+	}
+}
+
+func TestSmoking_IPv4OptionWithIPv6Address(t *testing.T) {
+	packets, err := PacketBuilder(
+		WithLoopback(),
+		WithIPv4_SrcIP("::1"),
+		WithIPv4_DstIP("::1"),
+		WithIPv4_TTL(33),
+		WithUDP_SrcPort(5353),
+		WithUDP_DstPort(53),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	if packet.LinkLayer().(*layers.Ethernet).EthernetType != layers.EthernetTypeIPv6 {
+		t.Fatal("expect ipv6 ethernet type")
+	}
+	ip6, ok := packet.NetworkLayer().(*layers.IPv6)
+	if !ok {
+		t.Fatalf("expect ipv6 layer, got %v", packet.NetworkLayer().LayerType())
+	}
+	if ip6.HopLimit != 33 || ip6.NextHeader != layers.IPProtocolUDP {
+		t.Fatalf("bad ipv6 layer: %v", ip6)
+	}
+	if packet.Layer(layers.LayerTypeUDP) == nil {
+		t.Fatal("expect udp layer")
+	}
+}
+
+func TestSmoking_MultiNetworkLayer(t *testing.T) {
+	_, err := PacketBuilder(
+		WithLoopback(),
+		WithIPv4_SrcIP("1.1.1.1"),
+		WithIPv6_SrcIP("2001:db8::1"),
+	)
+	if err == nil {
+		t.Fatal("expect error for ipv4 and ipv6 layers")
+	}
+}
@@ -0,0 +1,148 @@
+package passivefp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ClientApp 是被动识别到的客户端程序
+type ClientApp struct {
+	// Kind 是识别来源：http / tls / ssh
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Raw 是原始特征，例如 User-Agent 或 SSH 版本字符串
+	Raw string `json:"raw"`
+	JA3 string `json:"ja3,omitempty"`
+	JA4 string `json:"ja4,omitempty"`
+	SNI string `json:"sni,omitempty"`
+}
+
+func (c *ClientApp) String() string {
+	name := c.Name
+	if name == "" {
+		name = "unknown"
+	}
+	if c.Version != "" {
+		name += "/" + c.Version
+	}
+	return c.Kind + ":" + name
+}
+
+type userAgentRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// userAgentRules 按顺序匹配，基于 Chromium 的浏览器需要放在 Chrome 之前，Chrome 需要放在 Safari 之前
+var userAgentRules = []*userAgentRule{
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Yandex", regexp.MustCompile(`YaBrowser/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+	{"curl", regexp.MustCompile(`^curl/([\d.]+)`)},
+	{"Wget", regexp.MustCompile(`^Wget/([\d.]+)`)},
+	{"python-requests", regexp.MustCompile(`python-requests/([\d.]+)`)},
+	{"Python urllib", regexp.MustCompile(`Python-urllib/([\d.]+)`)},
+	{"aiohttp", regexp.MustCompile(`aiohttp/([\d.]+)`)},
+	{"Go http client", regexp.MustCompile(`Go-http-client/([\d.]+)`)},
+	{"okhttp", regexp.MustCompile(`okhttp/([\d.]+)`)},
+	{"Java", regexp.MustCompile(`Java/([\d._]+)`)},
+	{"Apache HttpClient", regexp.MustCompile(`Apache-HttpClient/([\d.]+)`)},
+	{"PostmanRuntime", regexp.MustCompile(`PostmanRuntime/([\d.]+)`)},
+	{"sqlmap", regexp.MustCompile(`sqlmap/([\d.]+)`)},
+	{"Nmap", regexp.MustCompile(`Nmap Scripting Engine`)},
+	{"Nuclei", regexp.MustCompile(`Nuclei`)},
+	{"Masscan", regexp.MustCompile(`masscan/([\d.]+)`)},
+	{"Googlebot", regexp.MustCompile(`Googlebot/([\d.]+)`)},
+	{"bingbot", regexp.MustCompile(`bingbot/([\d.]+)`)},
+}
+
+type userAgentOSRule struct {
+	name   string
+	flavor string
+	re     *regexp.Regexp
+}
+
+var userAgentOSRules = []*userAgentOSRule{
+	{"Windows", "10 or 11", regexp.MustCompile(`Windows NT 10\.0`)},
+	{"Windows", "8.1", regexp.MustCompile(`Windows NT 6\.3`)},
+	{"Windows", "8", regexp.MustCompile(`Windows NT 6\.2`)},
+	{"Windows", "7", regexp.MustCompile(`Windows NT 6\.1`)},
+	{"Windows", "Vista", regexp.MustCompile(`Windows NT 6\.0`)},
+	{"Windows", "XP", regexp.MustCompile(`Windows NT 5\.[12]`)},
+	{"Android", "", regexp.MustCompile(`Android ([\d.]+)`)},
+	{"iOS", "", regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)},
+	{"Mac OS X", "", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+	{"Chrome OS", "", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"Linux", "", regexp.MustCompile(`Linux|X11`)},
+}
+
+// ParseUserAgent 从 User-Agent 中识别客户端程序，无法识别时 Name 为空
+func ParseUserAgent(ua string) *ClientApp {
+	app := &ClientApp{Kind: "http", Raw: ua}
+	for _, rule := range userAgentRules {
+		if matches := rule.re.FindStringSubmatch(ua); matches != nil {
+			app.Name = rule.name
+			if len(matches) > 1 {
+				app.Version = matches[1]
+			}
+			break
+		}
+	}
+	return app
+}
+
+// ParseUserAgentOS 从 User-Agent 中识别客户端的操作系统
+func ParseUserAgentOS(ua string) (*OSMatch, bool) {
+	for _, rule := range userAgentOSRules {
+		matches := rule.re.FindStringSubmatch(ua)
+		if matches == nil {
+			continue
+		}
+		flavor := rule.flavor
+		if flavor == "" && len(matches) > 1 {
+			flavor = strings.ReplaceAll(matches[1], "_", ".")
+		}
+		class := "unix"
+		switch rule.name {
+		case "Windows":
+			class = "win"
+		case "Android", "iOS":
+			class = "mobile"
+		}
+		return &OSMatch{
+			Class:     class,
+			Name:      rule.name,
+			Flavor:    flavor,
+			Distance:  -1,
+			Signature: ua,
+		}, true
+	}
+	return nil, false
+}
+
+var sshVersionRegexp = regexp.MustCompile(`^SSH-([\d.]+)-([^\s_-]+)(?:[_-](\S+))?(?:\s+(.*))?$`)
+
+// ParseSSHVersion 从 SSH 版本字符串（例如 SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13）中识别 SSH 客户端
+func ParseSSHVersion(line string) (*ClientApp, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	matches := sshVersionRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, false
+	}
+	return &ClientApp{
+		Kind:    "ssh",
+		Name:    matches[2],
+		Version: matches[3],
+		Raw:     line,
+	}, true
+}
+
+// defaultTLSClients 是已知客户端的 JA4 指纹
+var defaultTLSClients = map[string]string{
+	"t13d1516h2_8daaf6152771_02713d6af862": "Chromium",
+}
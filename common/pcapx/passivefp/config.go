@@ -0,0 +1,124 @@
+package passivefp
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Config 是被动指纹识别的配置，pcap 文件和网卡二选一，都为空时监听所有网卡
+type Config struct {
+	ctx      context.Context
+	pcapFile string
+	devices  []string
+	bpf      string
+
+	// db 不为空时识别结果保存为主机 / 端口资产
+	db        *gorm.DB
+	runtimeId string
+
+	osSignatures []*OSSignature
+	tlsClients   map[string]string
+	// maxBannerTries 是每个端口最多尝试匹配的 Banner 数量，匹配成功后不再尝试
+	maxBannerTries int
+	// stateTTL 是已输出结果和端口匹配状态的保留时间，超过这个时间没有再出现时淘汰，之后会重新输出
+	stateTTL  time.Duration
+	callbacks []func(*Result)
+}
+
+type Option func(c *Config)
+
+func NewConfig(opts ...Option) *Config {
+	c := &Config{
+		ctx:            context.Background(),
+		tlsClients:     make(map[string]string),
+		maxBannerTries: 5,
+		stateTTL:       30 * time.Minute,
+	}
+	for k, v := range defaultTLSClients {
+		c.tlsClients[k] = v
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if len(c.osSignatures) <= 0 {
+		c.osSignatures = DefaultOSSignatures()
+	}
+	return c
+}
+
+func WithContext(ctx context.Context) Option {
+	return func(c *Config) {
+		c.ctx = ctx
+	}
+}
+
+// WithPcapFile 从 pcap 文件读取流量，读取完成后 Run 返回
+func WithPcapFile(filename string) Option {
+	return func(c *Config) {
+		c.pcapFile = filename
+	}
+}
+
+// WithDevice 从网卡实时抓包，需要通过 context 结束
+func WithDevice(devices ...string) Option {
+	return func(c *Config) {
+		c.devices = append(c.devices, devices...)
+	}
+}
+
+func WithBPFFilter(bpf string) Option {
+	return func(c *Config) {
+		c.bpf = bpf
+	}
+}
+
+// WithDatabase 把识别结果保存到数据库，操作系统和客户端保存为 HostOS / HostClientApp，服务保存为端口资产
+func WithDatabase(db *gorm.DB) Option {
+	return func(c *Config) {
+		c.db = db
+	}
+}
+
+func WithRuntimeID(id string) Option {
+	return func(c *Config) {
+		c.runtimeId = id
+	}
+}
+
+// WithOSSignatures 使用自定义的操作系统签名代替内置签名
+func WithOSSignatures(sigs ...*OSSignature) Option {
+	return func(c *Config) {
+		c.osSignatures = append(c.osSignatures, sigs...)
+	}
+}
+
+// WithTLSClient 添加已知客户端的 TLS 指纹，fingerprint 可以是 JA3 的 md5 或者 JA4
+func WithTLSClient(fingerprint string, name string) Option {
+	return func(c *Config) {
+		c.tlsClients[fingerprint] = name
+	}
+}
+
+func WithMaxBannerTries(n int) Option {
+	return func(c *Config) {
+		c.maxBannerTries = n
+	}
+}
+
+// WithStateTTL 设置已输出结果和端口匹配状态的保留时间，默认 30 分钟
+func WithStateTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		if ttl > 0 {
+			c.stateTTL = ttl
+		}
+	}
+}
+
+// WithResultCallback 设置识别结果的回调，同一个结果只会回调一次
+func WithResultCallback(cb func(*Result)) Option {
+	return func(c *Config) {
+		c.callbacks = append(c.callbacks, cb)
+	}
+}
@@ -0,0 +1,374 @@
+package passivefp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+// maxBannerSize 是用来匹配服务指纹的 Banner 的最大长度
+const maxBannerSize = 4096
+
+type ResultType string
+
+const (
+	ResultOS      ResultType = "os"
+	ResultClient  ResultType = "client"
+	ResultService ResultType = "service"
+)
+
+// Result 是被动识别的结果，根据 Type 只有 OS / Client / Service 中的一个不为空
+type Result struct {
+	Type ResultType `json:"type"`
+	Host string     `json:"host"`
+	// Port 只在服务识别结果中有意义
+	Port int `json:"port,omitempty"`
+	// Source 是识别依据：tcp-syn / tcp-synack / user-agent / tls / ssh / banner
+	Source    string              `json:"source"`
+	OS        *OSMatch            `json:"os,omitempty"`
+	Client    *ClientApp          `json:"client,omitempty"`
+	Service   *fp.FingerprintInfo `json:"service,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+func (r *Result) String() string {
+	switch r.Type {
+	case ResultOS:
+		return fmt.Sprintf("[os] %v: %v (%v)", r.Host, r.OS.String(), r.Source)
+	case ResultClient:
+		return fmt.Sprintf("[client] %v: %v", r.Host, r.Client.String())
+	case ResultService:
+		return fmt.Sprintf("[service] %v: %v", utils.HostPort(r.Host, r.Port), r.Service.ServiceName)
+	}
+	return fmt.Sprintf("[%v] %v", r.Type, r.Host)
+}
+
+type flowState struct {
+	clientSeen bool
+	serverSeen bool
+}
+
+type serviceState struct {
+	tries   int
+	matched bool
+}
+
+// Engine 从抓到的数据包和重组后的 TCP 数据中被动识别操作系统、客户端程序和服务，不会发送任何数据包
+//
+// 已输出的结果和端口的匹配状态在一段时间没有再出现后会被淘汰，长时间监听网卡时内存不会无限增长，
+// 使用结束后需要调用 Close 释放
+type Engine struct {
+	config *Config
+
+	mu       sync.Mutex
+	seen     *utils.Cache[struct{}]
+	flows    *utils.Cache[*flowState]
+	services *utils.Cache[*serviceState]
+}
+
+func NewEngine(opts ...Option) *Engine {
+	config := NewConfig(opts...)
+	return &Engine{
+		config:   config,
+		seen:     utils.NewTTLCache[struct{}](config.stateTTL),
+		flows:    utils.NewTTLCache[*flowState](5 * time.Minute),
+		services: utils.NewTTLCache[*serviceState](config.stateTTL),
+	}
+}
+
+// Close 释放 Engine 中的缓存
+func (e *Engine) Close() {
+	e.seen.Close()
+	e.flows.Close()
+	e.services.Close()
+}
+
+// CaptureOptions 返回把流量交给 Engine 处理的抓包选项，可以与其他 pcaputil 选项一起使用
+func (e *Engine) CaptureOptions() []pcaputil.CaptureOption {
+	return []pcaputil.CaptureOption{
+		pcaputil.WithEveryPacket(e.FeedPacket),
+		pcaputil.WithOnTrafficFlowOnDataFrameReassembled(e.FeedFrame),
+	}
+}
+
+// FeedPacket 从 SYN / SYN+ACK 包中识别操作系统
+func (e *Engine) FeedPacket(packet gopacket.Packet) {
+	sig, err := ExtractTCPSignature(packet)
+	if err != nil {
+		return
+	}
+	match, ok := MatchOS(e.config.osSignatures, sig)
+	if !ok {
+		return
+	}
+	source := "tcp-syn"
+	if sig.IsResponse {
+		source = "tcp-synack"
+	}
+	host := packet.NetworkLayer().NetworkFlow().Src().String()
+	e.emit(&Result{Type: ResultOS, Host: host, Source: source, OS: match})
+}
+
+// FeedFrame 从每个方向的第一个数据帧中识别客户端程序和服务
+func (e *Engine) FeedFrame(flow *pcaputil.TrafficFlow, conn *pcaputil.TrafficConnection, frame *pcaputil.TrafficFrame) {
+	if flow == nil || flow.ClientConn == nil || frame == nil || len(frame.Payload) <= 0 {
+		return
+	}
+	isClient := conn == flow.ClientConn
+
+	e.mu.Lock()
+	state, ok := e.flows.Get(flow.Hash)
+	if !ok {
+		state = &flowState{}
+		e.flows.Set(flow.Hash, state)
+	}
+	first := false
+	if isClient && !state.clientSeen {
+		state.clientSeen, first = true, true
+	} else if !isClient && !state.serverSeen {
+		state.serverSeen, first = true, true
+	}
+	e.mu.Unlock()
+	if !first {
+		return
+	}
+
+	clientIP := flow.ClientConn.LocalIP().String()
+	serverIP := flow.ClientConn.RemoteIP().String()
+	if isClient {
+		e.feedClientPayload(clientIP, frame.Payload)
+	} else {
+		e.feedServerPayload(serverIP, flow.ClientConn.RemotePort(), frame.Payload)
+	}
+}
+
+var httpRequestLineRegexp = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/\d`)
+
+func (e *Engine) feedClientPayload(host string, payload []byte) {
+	switch {
+	case payload[0] == 0x16:
+		hello, err := tlsutils.ParseClientHello(payload)
+		if err != nil {
+			return
+		}
+		app := &ClientApp{Kind: "tls", SNI: hello.SNI()}
+		if j, err := ja3.ParseJA3FromClientHello(hello); err == nil {
+			app.JA3 = j.Calc()
+		}
+		if j, err := ja3.ParseJA4FromClientHello(hello); err == nil {
+			app.JA4 = j.Fingerprint()
+			app.Raw = j.Raw()
+		}
+		if name, ok := e.config.tlsClients[app.JA4]; ok {
+			app.Name = name
+		} else {
+			app.Name = e.config.tlsClients[app.JA3]
+		}
+		e.emit(&Result{Type: ResultClient, Host: host, Source: "tls", Client: app})
+	case strings.HasPrefix(string(payload[:utils.Min(len(payload), 4)]), "SSH-"):
+		line, _, _ := strings.Cut(string(payload), "\n")
+		if app, ok := ParseSSHVersion(line); ok {
+			e.emit(&Result{Type: ResultClient, Host: host, Source: "ssh", Client: app})
+		}
+	case httpRequestLineRegexp.Match(payload):
+		ua := lowhttp.GetHTTPPacketHeader(payload, "User-Agent")
+		if ua == "" {
+			return
+		}
+		e.emit(&Result{Type: ResultClient, Host: host, Source: "user-agent", Client: ParseUserAgent(ua)})
+		if match, ok := ParseUserAgentOS(ua); ok {
+			e.emit(&Result{Type: ResultOS, Host: host, Source: "user-agent", OS: match})
+		}
+	}
+}
+
+func (e *Engine) feedServerPayload(host string, port int, payload []byte) {
+	key := utils.HostPort(host, port)
+	e.mu.Lock()
+	state, ok := e.services.Get(key)
+	if !ok {
+		state = &serviceState{}
+		e.services.Set(key, state)
+	}
+	if state.matched || state.tries >= e.config.maxBannerTries {
+		e.mu.Unlock()
+		return
+	}
+	state.tries++
+	firstTry := state.tries == 1
+	e.mu.Unlock()
+
+	if len(payload) > maxBannerSize {
+		payload = payload[:maxBannerSize]
+	}
+	info := fp.MatchBanner(host, port, fp.TCP, payload)
+	if info == nil {
+		if !firstTry {
+			return
+		}
+		// 没有匹配到规则，但是服务端有响应说明端口开放
+		info = &fp.FingerprintInfo{
+			IP:          host,
+			Port:        port,
+			Proto:       fp.TCP,
+			ServiceName: fp.GetDefaultTCPServiceName(port),
+			Banner:      utils.EscapeInvalidUTF8Byte(payload),
+		}
+		if payload[0] == 0x16 {
+			if _, err := tlsutils.ParseServerHello(payload); err == nil {
+				info.ServiceName = "ssl"
+			}
+		}
+	} else {
+		e.mu.Lock()
+		state.matched = true
+		e.mu.Unlock()
+	}
+
+	e.emit(&Result{Type: ResultService, Host: host, Port: port, Source: "banner", Service: info})
+	if info.OperationVerbose != "" {
+		e.emit(&Result{
+			Type:   ResultOS,
+			Host:   host,
+			Source: "banner",
+			OS:     &OSMatch{Name: info.OperationVerbose, Distance: -1, Signature: info.ProductVerbose},
+		})
+	}
+}
+
+func (r *Result) key() string {
+	switch r.Type {
+	case ResultOS:
+		return strings.Join([]string{"os", r.Host, r.Source, r.OS.Name, r.OS.Flavor}, "|")
+	case ResultClient:
+		c := r.Client
+		return strings.Join([]string{"client", r.Host, c.Kind, c.Name, c.Version, c.Raw, c.JA4}, "|")
+	case ResultService:
+		s := r.Service
+		return strings.Join([]string{"service", utils.HostPort(r.Host, r.Port), s.ServiceName, s.ProductVerbose, s.Version}, "|")
+	}
+	return ""
+}
+
+func (e *Engine) emit(r *Result) {
+	key := r.key()
+	e.mu.Lock()
+	if _, ok := e.seen.Get(key); ok {
+		e.mu.Unlock()
+		return
+	}
+	e.seen.Set(key, struct{}{})
+	e.mu.Unlock()
+
+	r.Timestamp = time.Now()
+	if e.config.db != nil {
+		e.save(r)
+	}
+	for _, cb := range e.config.callbacks {
+		cb(r)
+	}
+}
+
+func (e *Engine) save(r *Result) {
+	db := e.config.db
+	var err error
+	switch r.Type {
+	case ResultOS:
+		item := &schema.HostOS{
+			Host:      r.Host,
+			Class:     r.OS.Class,
+			Name:      r.OS.Name,
+			Flavor:    r.OS.Flavor,
+			Source:    r.Source,
+			Distance:  r.OS.Distance,
+			Signature: r.OS.Signature,
+			RuntimeId: e.config.runtimeId,
+		}
+		err = yakit.CreateOrUpdateHostOS(db, item.CalcHash(), item)
+	case ResultClient:
+		item := &schema.HostClientApp{
+			Host:      r.Host,
+			Kind:      r.Client.Kind,
+			Name:      r.Client.Name,
+			Version:   r.Client.Version,
+			Raw:       r.Client.Raw,
+			JA3:       r.Client.JA3,
+			JA4:       r.Client.JA4,
+			RuntimeId: e.config.runtimeId,
+		}
+		err = yakit.CreateOrUpdateHostClientApp(db, item.CalcHash(), item)
+	case ResultService:
+		info := r.Service
+		port := &schema.Port{
+			Host:        r.Host,
+			Port:        r.Port,
+			Proto:       string(fp.TCP),
+			ServiceType: info.ServiceName,
+			State:       string(fp.OPEN),
+			Reason:      "passive traffic",
+			Fingerprint: info.Banner,
+			CPE:         strings.Join(info.CPEs, "|"),
+			From:        "passivefp",
+			RuntimeId:   e.config.runtimeId,
+		}
+		if err = yakit.CreateOrUpdatePort(db, port.CalcHash(), port); err != nil {
+			break
+		}
+		banner := &schema.PortBanner{
+			Host:      r.Host,
+			Port:      r.Port,
+			Proto:     string(fp.TCP),
+			Probe:     "passive",
+			Banner:    info.Banner,
+			RuntimeId: e.config.runtimeId,
+		}
+		err = yakit.CreateOrUpdatePortBanner(db, banner.CalcHash(), banner)
+	}
+	if err != nil {
+		log.Errorf("save passive fingerprint result failed: %v", err)
+	}
+}
+
+// Run 从 pcap 文件或网卡读取流量并进行被动指纹识别，读取 pcap 文件时读取完成后返回
+func Run(opts ...Option) error {
+	engine := NewEngine(opts...)
+	defer engine.Close()
+	config := engine.config
+
+	captureOpts := append(engine.CaptureOptions(), pcaputil.WithContext(config.ctx))
+	if config.pcapFile != "" {
+		captureOpts = append(captureOpts, pcaputil.WithFile(config.pcapFile))
+	} else {
+		captureOpts = append(captureOpts, pcaputil.WithDevice(config.devices...))
+	}
+	if config.bpf != "" {
+		captureOpts = append(captureOpts, pcaputil.WithBPFFilter(config.bpf))
+	}
+	if err := pcaputil.Start(captureOpts...); err != nil {
+		log.Errorf("passive fingerprint capture failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Stream 在后台被动识别流量中的操作系统、客户端和服务指纹，识别结果通过 channel 返回，抓包结束或 ctx 结束后 channel 关闭
+func Stream(ctx context.Context, opts ...Option) chan *Result {
+	return pcaputil.StreamResults(ctx, "passive fingerprint", func(ctx context.Context, emit func(*Result)) error {
+		runOpts := append(append([]Option{}, opts...), WithContext(ctx), WithResultCallback(emit))
+		return Run(runOpts...)
+	})
+}
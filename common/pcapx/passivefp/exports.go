@@ -0,0 +1,52 @@
+package passivefp
+
+import (
+	"context"
+	"time"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+)
+
+// withProjectDatabase 默认把结果保存到项目数据库，可以通过 save(false) 关闭
+func withProjectDatabase(opts []Option) []Option {
+	return append([]Option{WithDatabase(consts.GetGormProjectDatabase())}, opts...)
+}
+
+var Exports = map[string]any{
+	"Run": func(opts ...Option) error {
+		return Run(withProjectDatabase(opts)...)
+	},
+	"Stream": func(ctx context.Context, opts ...Option) chan *Result {
+		return Stream(ctx, withProjectDatabase(opts)...)
+	},
+	"ParseUserAgent":      ParseUserAgent,
+	"ParseOSSignatures":   ParseOSSignatures,
+	"ExtractTCPSignature": ExtractTCPSignature,
+
+	"pcapFile":       WithPcapFile,
+	"device":         WithDevice,
+	"bpfFilter":      WithBPFFilter,
+	"context":        WithContext,
+	"runtimeID":      WithRuntimeID,
+	"callback":       WithResultCallback,
+	"tlsClient":      WithTLSClient,
+	"maxBannerTries": WithMaxBannerTries,
+	"stateTTL": func(seconds float64) Option {
+		return WithStateTTL(time.Duration(seconds * float64(time.Second)))
+	},
+	"save": func(b bool) Option {
+		if b {
+			return WithDatabase(consts.GetGormProjectDatabase())
+		}
+		return WithDatabase(nil)
+	},
+	"osSignatures": func(raw string) Option {
+		sigs, err := ParseOSSignatures(raw)
+		if err != nil {
+			log.Errorf("parse os signatures failed: %v", err)
+			return func(c *Config) {}
+		}
+		return WithOSSignatures(sigs...)
+	},
+}
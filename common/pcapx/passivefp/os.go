@@ -0,0 +1,259 @@
+package passivefp
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// maxTTLDistance 是允许的最大跳数，与 p0f 相同
+const maxTTLDistance = 35
+
+// OSSignature 是一条 p0f 格式的操作系统签名
+type OSSignature struct {
+	// Generic 为 true 表示签名只能确定操作系统大类（p0f 的 g: 标签）
+	Generic bool
+	Class   string
+	Name    string
+	Flavor  string
+	// IsResponse 为 true 表示匹配 SYN+ACK（[tcp:response]），否则匹配 SYN（[tcp:request]）
+	IsResponse bool
+	Raw        string
+
+	version int
+	ittl    int
+	optLen  int
+	mss     int
+	// wsizeType: 0 固定值，1 任意，2 mss 倍数，3 取模
+	wsizeType int
+	wsize     int
+	scale     int
+	layout    string
+	quirks    []string
+	// pclass: -1 任意，0 无数据，1 有数据
+	pclass int
+}
+
+// OSMatch 是操作系统的识别结果
+type OSMatch struct {
+	Class  string `json:"class"`
+	Name   string `json:"name"`
+	Flavor string `json:"flavor"`
+	// Generic 为 true 表示只确定了操作系统大类
+	Generic bool `json:"generic"`
+	// Fuzzy 为 true 表示忽略了 quirks 之后才匹配成功
+	Fuzzy bool `json:"fuzzy"`
+	// Distance 是根据 TTL 推测的跳数，从 User-Agent 等推测时为 -1
+	Distance  int    `json:"distance"`
+	Signature string `json:"signature"`
+}
+
+func (o *OSMatch) String() string {
+	if o.Flavor == "" {
+		return o.Name
+	}
+	return o.Name + " " + o.Flavor
+}
+
+// ParseOSSignatures 解析 p0f.fp 格式的签名，只使用 [tcp:request] 和 [tcp:response] 中的 label / sig，
+// 其他段落（mtu / http 等）会被忽略
+func ParseOSSignatures(raw string) ([]*OSSignature, error) {
+	var (
+		sigs       []*OSSignature
+		section    string
+		label      string
+		lineNumber int
+	)
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			label = ""
+			continue
+		}
+		if section != "tcp:request" && section != "tcp:response" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, utils.Errorf("invalid p0f signature line %d: %v", lineNumber, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "label":
+			label = value
+		case "sig":
+			if label == "" {
+				return nil, utils.Errorf("p0f signature without label at line %d", lineNumber)
+			}
+			sig, err := parseOSSignature(label, value)
+			if err != nil {
+				return nil, utils.Errorf("parse p0f signature at line %d failed: %v", lineNumber, err)
+			}
+			sig.IsResponse = section == "tcp:response"
+			sigs = append(sigs, sig)
+		}
+	}
+	return sigs, nil
+}
+
+func parseOSSignature(label string, raw string) (*OSSignature, error) {
+	// label = s:unix:Linux:3.11 and newer
+	labelParts := strings.SplitN(label, ":", 4)
+	if len(labelParts) != 4 {
+		return nil, fmt.Errorf("invalid label: %v", label)
+	}
+	sig := &OSSignature{
+		Generic: labelParts[0] == "g",
+		Class:   labelParts[1],
+		Name:    labelParts[2],
+		Flavor:  labelParts[3],
+		Raw:     raw,
+	}
+
+	// sig = ver:ittl:olen:mss:wsize,scale:olayout:quirks:pclass
+	fields := strings.Split(raw, ":")
+	if len(fields) != 8 {
+		return nil, fmt.Errorf("invalid sig: %v", raw)
+	}
+	var err error
+	parseInt := func(s string) int {
+		if err != nil || s == "*" {
+			return -1
+		}
+		var v int
+		v, err = strconv.Atoi(s)
+		return v
+	}
+
+	sig.version = parseInt(fields[0])
+	// 64- 表示 TTL 异常，这里只使用数值
+	sig.ittl = parseInt(strings.TrimSuffix(fields[1], "-"))
+	sig.optLen = parseInt(fields[2])
+	sig.mss = parseInt(fields[3])
+	wsize, scale, ok := strings.Cut(fields[4], ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid wsize,scale: %v", fields[4])
+	}
+	switch {
+	case wsize == "*":
+		sig.wsizeType = 1
+	case strings.HasPrefix(wsize, "mss*"):
+		sig.wsizeType = 2
+		sig.wsize = parseInt(strings.TrimPrefix(wsize, "mss*"))
+	case strings.HasPrefix(wsize, "%"):
+		sig.wsizeType = 3
+		sig.wsize = parseInt(strings.TrimPrefix(wsize, "%"))
+	default:
+		sig.wsize = parseInt(wsize)
+	}
+	sig.scale = parseInt(scale)
+	sig.layout = fields[5]
+	if fields[6] != "" {
+		sig.quirks = strings.Split(fields[6], ",")
+		sort.Strings(sig.quirks)
+	}
+	switch fields[7] {
+	case "*":
+		sig.pclass = -1
+	case "0":
+		sig.pclass = 0
+	case "+":
+		sig.pclass = 1
+	default:
+		return nil, fmt.Errorf("invalid payload class: %v", fields[7])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sig.ittl <= 0 {
+		return nil, fmt.Errorf("invalid initial ttl: %v", fields[1])
+	}
+	if sig.wsizeType == 3 && sig.wsize <= 0 {
+		return nil, fmt.Errorf("invalid window size modulo: %v", wsize)
+	}
+	return sig, nil
+}
+
+// match 检查签名是否与 TCP 特征匹配，fuzzy 为 true 时忽略 quirks
+func (o *OSSignature) match(s *TCPSignature, fuzzy bool) bool {
+	if o.IsResponse != s.IsResponse {
+		return false
+	}
+	if o.version > 0 && o.version != s.Version {
+		return false
+	}
+	if distance := o.ittl - s.TTL; distance < 0 || distance > maxTTLDistance {
+		return false
+	}
+	if o.optLen >= 0 && o.optLen != s.OptLen {
+		return false
+	}
+	if o.mss >= 0 && o.mss != s.MSS {
+		return false
+	}
+	switch o.wsizeType {
+	case 0:
+		if o.wsize != s.WSize {
+			return false
+		}
+	case 2:
+		if s.MSS <= 0 || o.wsize*s.MSS != s.WSize {
+			return false
+		}
+	case 3:
+		if s.WSize%o.wsize != 0 {
+			return false
+		}
+	}
+	if o.scale >= 0 && o.scale != s.Scale {
+		return false
+	}
+	if o.layout != strings.Join(s.Layout, ",") {
+		return false
+	}
+	if o.pclass >= 0 && (o.pclass == 1) != s.HasPayload {
+		return false
+	}
+	if fuzzy {
+		return true
+	}
+	quirks := append([]string(nil), s.Quirks...)
+	sort.Strings(quirks)
+	return strings.Join(o.quirks, ",") == strings.Join(quirks, ",")
+}
+
+// MatchOS 使用签名识别操作系统，精确匹配优先于忽略 quirks 的模糊匹配，具体签名优先于通用签名
+func MatchOS(sigs []*OSSignature, s *TCPSignature) (*OSMatch, bool) {
+	if s == nil {
+		return nil, false
+	}
+	for _, fuzzy := range []bool{false, true} {
+		for _, generic := range []bool{false, true} {
+			for _, sig := range sigs {
+				if sig.Generic != generic || !sig.match(s, fuzzy) {
+					continue
+				}
+				return &OSMatch{
+					Class:     sig.Class,
+					Name:      sig.Name,
+					Flavor:    sig.Flavor,
+					Generic:   sig.Generic,
+					Fuzzy:     fuzzy,
+					Distance:  sig.ittl - s.TTL,
+					Signature: s.String(),
+				}, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,136 @@
+package passivefp
+
+import (
+	"sync"
+
+	"github.com/yaklang/yaklang/common/log"
+)
+
+// defaultOSSignatures 是内置的操作系统签名，格式与 p0f.fp 相同，取自 p0f v3 并补充了较新的 Windows / Linux / macOS
+const defaultOSSignatures = `
+[tcp:request]
+
+label = s:unix:Linux:3.11 and newer
+sig   = *:64:0:*:mss*44,7:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*44,8:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*20,10:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*20,7:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*44,7:mss,sok,ts,nop,ws:df:0
+sig   = *:64:0:*:mss*20,7:mss,sok,ts,nop,ws:df:0
+
+label = s:unix:Linux:3.1-3.10
+sig   = *:64:0:*:mss*10,4:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*10,5:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*10,6:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*10,7:mss,sok,ts,nop,ws:df,id+:0
+
+label = s:unix:Linux:2.6.x
+sig   = *:64:0:*:mss*4,6:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*4,7:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:*:mss*4,8:mss,sok,ts,nop,ws:df,id+:0
+
+label = s:unix:Linux:loopback
+sig   = *:64:0:65495:mss*1,7:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:65495:mss*1,7:mss,sok,ts,nop,ws:df:0
+sig   = *:64:0:16396:mss*2,4:mss,sok,ts,nop,ws:df,id+:0
+sig   = *:64:0:16376:mss*2,4:mss,sok,ts,nop,ws:df,id+:0
+
+label = s:win:Windows:XP
+sig   = *:128:0:*:16384,0:mss,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,0:mss,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,0:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,1:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,2:mss,nop,ws,nop,nop,sok:df,id+:0
+
+label = s:win:Windows:7 or 8
+sig   = *:128:0:*:8192,0:mss,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:8192,2:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:8192,8:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:8192,2:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:win:Windows:10 or 11
+sig   = *:128:0:*:64240,8:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,8:mss,nop,ws,nop,nop,sok:df,id+:0
+
+label = s:unix:Mac OS X:10.x
+sig   = *:64:0:*:65535,1:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0
+sig   = *:64:0:*:65535,3:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0
+sig   = *:64:0:*:65535,4:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0
+
+label = s:unix:Mac OS X:11 and newer
+sig   = *:64:0:*:65535,6:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0
+sig   = *:64:0:*:65535,6:mss,nop,ws,nop,nop,ts,sok,eol+1:df:0
+
+label = s:unix:FreeBSD:9.x or newer
+sig   = *:64:0:*:65535,6:mss,nop,ws,sok,ts:df,id+:0
+sig   = *:64:0:*:65535,9:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:unix:FreeBSD:8.x
+sig   = *:64:0:*:65535,3:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:unix:OpenBSD:3.x
+sig   = *:64:0:*:16384,0:mss,nop,nop,sok,nop,ws,nop,nop,ts:df,id+:0
+
+label = s:unix:OpenBSD:4.x-5.x
+sig   = *:64:0:*:16384,3:mss,nop,nop,sok,nop,ws,nop,nop,ts:df,id+:0
+
+label = s:unix:Solaris:10
+sig   = *:64:0:*:32850,1:nop,ws,nop,nop,ts,nop,nop,sok,mss:df,id+:0
+
+[tcp:response]
+
+label = s:unix:Linux:4.x and newer
+sig   = *:64:0:*:65160,*:mss,sok,ts,nop,ws:df:0
+sig   = *:64:0:*:65160,*:mss,nop,nop,sok,nop,ws:df:0
+
+label = g:unix:Linux:3.x
+sig   = *:64:0:*:mss*10,0:mss:df:0
+sig   = *:64:0:*:mss*10,0:mss,sok,ts:df:0
+sig   = *:64:0:*:mss*10,0:mss,nop,nop,ts:df:0
+sig   = *:64:0:*:mss*10,0:mss,nop,nop,sok:df:0
+sig   = *:64:0:*:mss*10,*:mss,nop,ws:df:0
+sig   = *:64:0:*:mss*10,*:mss,sok,ts,nop,ws:df:0
+sig   = *:64:0:*:mss*10,*:mss,nop,nop,ts,nop,ws:df:0
+sig   = *:64:0:*:mss*10,*:mss,nop,nop,sok,nop,ws:df:0
+
+label = s:win:Windows:XP
+sig   = *:128:0:*:65535,0:mss:df,id+:0
+sig   = *:128:0:*:65535,0:mss,nop,nop,sok:df,id+:0
+
+label = s:win:Windows:7 or 8
+sig   = *:128:0:*:8192,0:mss:df,id+:0
+sig   = *:128:0:*:8192,0:mss,sok:df,id+:0
+sig   = *:128:0:*:8192,8:mss,nop,ws:df,id+:0
+sig   = *:128:0:*:8192,0:mss,nop,nop,ts:df,id+:0
+sig   = *:128:0:*:8192,0:mss,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:8192,8:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:8192,8:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:win:Windows:10 or newer
+sig   = *:128:0:*:65535,8:mss,nop,ws,nop,nop,sok:df,id+:0
+sig   = *:128:0:*:65535,8:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:unix:FreeBSD:9.x or newer
+sig   = *:64:0:*:65535,6:mss,nop,ws,sok,ts:df,id+:0
+
+label = s:unix:Mac OS X:10.x or newer
+sig   = *:64:0:*:65535,*:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0
+`
+
+var (
+	defaultOSSignaturesOnce   sync.Once
+	defaultOSSignaturesParsed []*OSSignature
+)
+
+// DefaultOSSignatures 返回内置的操作系统签名
+func DefaultOSSignatures() []*OSSignature {
+	defaultOSSignaturesOnce.Do(func() {
+		sigs, err := ParseOSSignatures(defaultOSSignatures)
+		if err != nil {
+			log.Errorf("parse default os signatures failed: %v", err)
+			return
+		}
+		defaultOSSignaturesParsed = sigs
+	})
+	return defaultOSSignaturesParsed
+}
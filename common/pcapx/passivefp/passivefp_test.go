@@ -0,0 +1,357 @@
+package passivefp
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+type testSegment struct {
+	src, dst     string
+	sport, dport int
+	syn, ack     bool
+	fin          bool
+	seq, ackNum  uint32
+	ttl          uint8
+	window       uint16
+	options      []layers.TCPOption
+	payload      string
+}
+
+func (s *testSegment) bytes(t *testing.T) []byte {
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      s.ttl,
+		Flags:    layers.IPv4DontFragment,
+		Id:       0x1234,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    net.ParseIP(s.src).To4(),
+		DstIP:    net.ParseIP(s.dst).To4(),
+	}
+	if ip.TTL == 0 {
+		ip.TTL = 64
+	}
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(s.sport),
+		DstPort: layers.TCPPort(s.dport),
+		Seq:     s.seq,
+		Ack:     s.ackNum,
+		SYN:     s.syn,
+		ACK:     s.ack,
+		FIN:     s.fin,
+		PSH:     s.payload != "",
+		Window:  s.window,
+		Options: s.options,
+	}
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+		&layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+			EthernetType: layers.EthernetTypeIPv4,
+		},
+		ip, tcp, gopacket.Payload(s.payload),
+	))
+	return buf.Bytes()
+}
+
+func linuxSynOptions() []layers.TCPOption {
+	return []layers.TCPOption{
+		{OptionType: layers.TCPOptionKindMSS, OptionLength: 4, OptionData: []byte{0x05, 0xb4}},
+		{OptionType: layers.TCPOptionKindSACKPermitted, OptionLength: 2},
+		{OptionType: layers.TCPOptionKindTimestamps, OptionLength: 10, OptionData: []byte{0, 0, 0, 1, 0, 0, 0, 0}},
+		{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+		{OptionType: layers.TCPOptionKindWindowScale, OptionLength: 3, OptionData: []byte{7}},
+	}
+}
+
+func windowsSynOptions() []layers.TCPOption {
+	return []layers.TCPOption{
+		{OptionType: layers.TCPOptionKindMSS, OptionLength: 4, OptionData: []byte{0x05, 0xb4}},
+		{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+		{OptionType: layers.TCPOptionKindWindowScale, OptionLength: 3, OptionData: []byte{8}},
+		{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+		{OptionType: layers.TCPOptionKindNop, OptionLength: 1},
+		{OptionType: layers.TCPOptionKindSACKPermitted, OptionLength: 2},
+	}
+}
+
+func TestMatchOS(t *testing.T) {
+	for _, c := range []struct {
+		segment *testSegment
+		name    string
+		flavor  string
+		layout  string
+	}{
+		{
+			segment: &testSegment{src: "10.0.0.1", dst: "10.0.0.2", sport: 40000, dport: 80, syn: true, seq: 1000, ttl: 61, window: 64240, options: linuxSynOptions()},
+			name:    "Linux", flavor: "3.11 and newer", layout: "4:64+3:0:1460:64240,7:mss,sok,ts,nop,ws:df,id+:0",
+		},
+		{
+			segment: &testSegment{src: "10.0.0.3", dst: "10.0.0.2", sport: 50000, dport: 443, syn: true, seq: 2000, ttl: 128, window: 64240, options: windowsSynOptions()},
+			name:    "Windows", flavor: "10 or 11", layout: "4:128+0:0:1460:64240,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+		},
+	} {
+		packet := gopacket.NewPacket(c.segment.bytes(t), layers.LayerTypeEthernet, gopacket.Default)
+		sig, err := ExtractTCPSignature(packet)
+		require.NoError(t, err)
+		require.Equal(t, c.layout, sig.String())
+
+		match, ok := MatchOS(DefaultOSSignatures(), sig)
+		require.True(t, ok)
+		require.Equal(t, c.name, match.Name)
+		require.Equal(t, c.flavor, match.Flavor)
+		require.False(t, match.Fuzzy)
+	}
+
+	// 非 SYN 包不提取特征
+	packet := gopacket.NewPacket((&testSegment{src: "10.0.0.1", dst: "10.0.0.2", sport: 1, dport: 2, ack: true}).bytes(t), layers.LayerTypeEthernet, gopacket.Default)
+	_, err := ExtractTCPSignature(packet)
+	require.Error(t, err)
+}
+
+func TestParseOSSignatures(t *testing.T) {
+	sigs, err := ParseOSSignatures(`
+[mtu]
+label = Ethernet or modem
+sig   = 1500
+
+[tcp:request]
+label = s:unix:Test:1.x
+sig   = 4:64:0:*:%8192,*:mss,nop,ws:df:0
+`)
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	require.Equal(t, "Test", sigs[0].Name)
+	require.False(t, sigs[0].IsResponse)
+
+	_, err = ParseOSSignatures("[tcp:request]\nsig = 4:64:0:*:8192,0:mss:df:0\n")
+	require.Error(t, err)
+	_, err = ParseOSSignatures("[tcp:request]\nlabel = s:unix:Test:1.x\nsig = 4:64:0:*:8192:mss:df:0\n")
+	require.Error(t, err)
+}
+
+func TestParseUserAgent(t *testing.T) {
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91"
+	app := ParseUserAgent(ua)
+	require.Equal(t, "Edge", app.Name)
+	require.Equal(t, "120.0.2210.91", app.Version)
+	os, ok := ParseUserAgentOS(ua)
+	require.True(t, ok)
+	require.Equal(t, "Windows", os.Name)
+	require.Equal(t, "10 or 11", os.Flavor)
+
+	app = ParseUserAgent("curl/8.4.0")
+	require.Equal(t, "curl", app.Name)
+	require.Equal(t, "8.4.0", app.Version)
+
+	os, ok = ParseUserAgentOS("Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1")
+	require.True(t, ok)
+	require.Equal(t, "iOS", os.Name)
+	require.Equal(t, "17.1", os.Flavor)
+
+	ssh, ok := ParseSSHVersion("SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n")
+	require.True(t, ok)
+	require.Equal(t, "OpenSSH", ssh.Name)
+	require.Equal(t, "9.6p1", ssh.Version)
+}
+
+func writeTestPcap(t *testing.T, segments []*testSegment) string {
+	filename := filepath.Join(t.TempDir(), "passive.pcap")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+	w := pcapgo.NewWriter(f)
+	require.NoError(t, w.WriteFileHeader(65535, layers.LinkTypeEthernet))
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, s := range segments {
+		raw := s.bytes(t)
+		require.NoError(t, w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     ts.Add(time.Duration(i) * 10 * time.Millisecond),
+			CaptureLength: len(raw),
+			Length:        len(raw),
+		}, raw))
+	}
+	return filename
+}
+
+// tcpSession 生成一次完整的 TCP 会话：三次握手、客户端请求、服务端响应、四次挥手
+func tcpSession(client string, cport int, server string, sport int, request, response string) []*testSegment {
+	c, s := uint32(1000), uint32(5000)
+	segments := []*testSegment{
+		{src: client, dst: server, sport: cport, dport: sport, syn: true, seq: c, window: 64240, options: linuxSynOptions()},
+		{src: server, dst: client, sport: sport, dport: cport, syn: true, ack: true, seq: s, ackNum: c + 1, window: 65160},
+		{src: client, dst: server, sport: cport, dport: sport, ack: true, seq: c + 1, ackNum: s + 1, window: 502},
+	}
+	c, s = c+1, s+1
+	if request != "" {
+		segments = append(segments, &testSegment{src: client, dst: server, sport: cport, dport: sport, ack: true, seq: c, ackNum: s, window: 502, payload: request})
+		c += uint32(len(request))
+	}
+	if response != "" {
+		segments = append(segments, &testSegment{src: server, dst: client, sport: sport, dport: cport, ack: true, seq: s, ackNum: c, window: 509, payload: response})
+		s += uint32(len(response))
+	}
+	return append(segments,
+		&testSegment{src: client, dst: server, sport: cport, dport: sport, ack: true, fin: true, seq: c, ackNum: s, window: 502},
+		&testSegment{src: server, dst: client, sport: sport, dport: cport, ack: true, fin: true, seq: s, ackNum: c + 1, window: 509},
+		&testSegment{src: client, dst: server, sport: cport, dport: sport, ack: true, seq: c + 1, ackNum: s + 1, window: 502},
+	)
+}
+
+func TestRunPcapFile(t *testing.T) {
+	var segments []*testSegment
+	segments = append(segments, tcpSession("10.0.0.1", 40000, "10.0.0.2", 22,
+		"SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n", "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.6\r\n")...)
+	segments = append(segments, tcpSession("10.0.0.1", 40001, "10.0.0.2", 8080,
+		"GET / HTTP/1.1\r\nHost: 10.0.0.2\r\nUser-Agent: Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0\r\n\r\n",
+		"")...)
+	filename := writeTestPcap(t, segments)
+
+	var (
+		mu      sync.Mutex
+		results []*Result
+	)
+	runtimeID := utils.RandStringBytes(16)
+	db := consts.GetGormProjectDatabase()
+	require.NoError(t, Run(
+		WithPcapFile(filename),
+		WithDatabase(db),
+		WithRuntimeID(runtimeID),
+		WithResultCallback(func(r *Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, r)
+		}),
+	))
+
+	find := func(typ ResultType, source string) *Result {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range results {
+			if r.Type == typ && r.Source == source {
+				return r
+			}
+		}
+		return nil
+	}
+
+	syn := find(ResultOS, "tcp-syn")
+	require.NotNil(t, syn)
+	require.Equal(t, "10.0.0.1", syn.Host)
+	require.Equal(t, "Linux", syn.OS.Name)
+
+	service := find(ResultService, "banner")
+	require.NotNil(t, service)
+	require.Equal(t, "10.0.0.2", service.Host)
+	require.Equal(t, 22, service.Port)
+	require.Equal(t, "ssh", service.Service.ServiceName)
+	require.Contains(t, service.Service.ProductVerbose, "OpenSSH")
+
+	sshClient := find(ResultClient, "ssh")
+	require.NotNil(t, sshClient)
+	require.Equal(t, "10.0.0.1", sshClient.Host)
+	require.Equal(t, "9.6p1", sshClient.Client.Version)
+
+	browser := find(ResultClient, "user-agent")
+	require.NotNil(t, browser)
+	require.Equal(t, "Firefox", browser.Client.Name)
+	uaOS := find(ResultOS, "user-agent")
+	require.NotNil(t, uaOS)
+	require.Equal(t, "Windows", uaOS.OS.Name)
+
+	osList, err := yakit.QueryHostOS(db, "10.0.0.1")
+	require.NoError(t, err)
+	var found bool
+	for _, item := range osList {
+		if item.RuntimeId == runtimeID && item.Source == "tcp-syn" {
+			found = true
+			require.Equal(t, "Linux", item.Name)
+		}
+	}
+	require.True(t, found)
+
+	apps, err := yakit.QueryHostClientApps(db, "10.0.0.1")
+	require.NoError(t, err)
+	found = false
+	for _, item := range apps {
+		if item.RuntimeId == runtimeID && item.Kind == "http" {
+			found = true
+			require.Equal(t, "Firefox", item.Name)
+		}
+	}
+	require.True(t, found)
+
+	banners, err := yakit.QueryPortBanners(db, "10.0.0.2", 22)
+	require.NoError(t, err)
+	found = false
+	for _, item := range banners {
+		if item.RuntimeId == runtimeID {
+			found = true
+			require.Equal(t, "passive", item.Probe)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestStreamContext(t *testing.T) {
+	filename := writeTestPcap(t, tcpSession("10.0.0.1", 40000, "10.0.0.2", 22,
+		"SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n", "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.6\r\n"))
+
+	var sources []string
+	for r := range Stream(context.Background(), WithPcapFile(filename)) {
+		sources = append(sources, r.Source)
+	}
+	require.Contains(t, sources, "ssh")
+	require.Contains(t, sources, "banner")
+
+	// ctx 结束后不读取结果，channel 也会关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch := Stream(ctx, WithPcapFile(filename))
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("passive fingerprint stream is not closed after context canceled")
+		}
+	}
+}
+
+func TestEngineStateTTL(t *testing.T) {
+	var count int
+	engine := NewEngine(WithStateTTL(200*time.Millisecond), WithResultCallback(func(r *Result) {
+		count++
+	}))
+	defer engine.Close()
+
+	emit := func() {
+		engine.emit(&Result{Type: ResultClient, Host: "10.0.0.1", Source: "ssh", Client: &ClientApp{Kind: "ssh", Name: "OpenSSH"}})
+	}
+	emit()
+	emit()
+	require.Equal(t, 1, count)
+
+	// 超过保留时间后结果被淘汰，再次出现时重新输出
+	time.Sleep(time.Second)
+	require.Equal(t, 0, engine.seen.Count())
+	emit()
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,220 @@
+package passivefp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// TCPSignature 是从 SYN / SYN+ACK 包中提取的 TCP/IP 协议栈特征，字段含义与 p0f v3 的签名相同
+type TCPSignature struct {
+	Version int
+	TTL     int
+	// OptLen 是 IPv4 选项的长度
+	OptLen int
+	// MSS / Scale 为 -1 表示没有对应的 TCP 选项
+	MSS    int
+	WSize  int
+	Scale  int
+	Layout []string
+	Quirks []string
+	// HasPayload 表示 SYN 包中是否带有数据
+	HasPayload bool
+	// IsResponse 为 true 表示 SYN+ACK，否则为 SYN
+	IsResponse bool
+}
+
+// guessInitialTTL 猜测发送方的初始 TTL
+func guessInitialTTL(ttl int) int {
+	switch {
+	case ttl <= 32:
+		return 32
+	case ttl <= 64:
+		return 64
+	case ttl <= 128:
+		return 128
+	}
+	return 255
+}
+
+// String 返回 p0f 格式的签名，例如 4:64+0:0:1460:64240,7:mss,sok,ts,nop,ws:df,id+:0
+func (s *TCPSignature) String() string {
+	ittl := guessInitialTTL(s.TTL)
+	mss := "*"
+	if s.MSS >= 0 {
+		mss = strconv.Itoa(s.MSS)
+	}
+	scale := "*"
+	if s.Scale >= 0 {
+		scale = strconv.Itoa(s.Scale)
+	}
+	pclass := "0"
+	if s.HasPayload {
+		pclass = "+"
+	}
+	return fmt.Sprintf(
+		"%d:%d+%d:%d:%s:%d,%s:%s:%s:%s",
+		s.Version, ittl, ittl-s.TTL, s.OptLen, mss, s.WSize, scale,
+		strings.Join(s.Layout, ","), strings.Join(s.Quirks, ","), pclass,
+	)
+}
+
+func (s *TCPSignature) hasQuirk(q string) bool {
+	for _, quirk := range s.Quirks {
+		if quirk == q {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractTCPSignature 从 SYN 或 SYN+ACK 包中提取 TCP/IP 协议栈特征，其他包返回错误
+func ExtractTCPSignature(packet gopacket.Packet) (*TCPSignature, error) {
+	tcpLayer, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok || tcpLayer == nil {
+		return nil, utils.Error("not a tcp packet")
+	}
+	if !tcpLayer.SYN || tcpLayer.RST || tcpLayer.FIN {
+		return nil, utils.Error("not a tcp syn or syn+ack packet")
+	}
+
+	sig := &TCPSignature{
+		MSS:        -1,
+		Scale:      -1,
+		WSize:      int(tcpLayer.Window),
+		HasPayload: len(tcpLayer.Payload) > 0,
+		IsResponse: tcpLayer.ACK,
+	}
+
+	var ecn bool
+	switch ip := packet.NetworkLayer().(type) {
+	case *layers.IPv4:
+		sig.Version = 4
+		sig.TTL = int(ip.TTL)
+		sig.OptLen = int(ip.IHL)*4 - 20
+		if sig.OptLen < 0 {
+			sig.OptLen = 0
+		}
+		df := ip.Flags&layers.IPv4DontFragment != 0
+		if df {
+			sig.Quirks = append(sig.Quirks, "df")
+			if ip.Id != 0 {
+				sig.Quirks = append(sig.Quirks, "id+")
+			}
+		} else if ip.Id == 0 {
+			sig.Quirks = append(sig.Quirks, "id-")
+		}
+		ecn = ip.TOS&0x03 != 0
+		if ip.Flags&layers.IPv4EvilBit != 0 {
+			sig.Quirks = append(sig.Quirks, "0+")
+		}
+	case *layers.IPv6:
+		sig.Version = 6
+		sig.TTL = int(ip.HopLimit)
+		ecn = ip.TrafficClass&0x03 != 0
+		if ip.FlowLabel != 0 {
+			sig.Quirks = append(sig.Quirks, "flow")
+		}
+	default:
+		return nil, utils.Error("tcp packet without ip layer")
+	}
+
+	if ecn || tcpLayer.ECE || tcpLayer.CWR {
+		sig.Quirks = append(sig.Quirks, "ecn")
+	}
+	if tcpLayer.Seq == 0 {
+		sig.Quirks = append(sig.Quirks, "seq-")
+	}
+	if tcpLayer.ACK && tcpLayer.Ack == 0 {
+		sig.Quirks = append(sig.Quirks, "ack-")
+	}
+	if !tcpLayer.ACK && tcpLayer.Ack != 0 {
+		sig.Quirks = append(sig.Quirks, "ack+")
+	}
+	if tcpLayer.URG {
+		sig.Quirks = append(sig.Quirks, "urgf+")
+	} else if tcpLayer.Urgent != 0 {
+		sig.Quirks = append(sig.Quirks, "uptr+")
+	}
+	if tcpLayer.PSH {
+		sig.Quirks = append(sig.Quirks, "pushf+")
+	}
+
+	headerLen := int(tcpLayer.DataOffset) * 4
+	if headerLen > len(tcpLayer.Contents) {
+		headerLen = len(tcpLayer.Contents)
+	}
+	if headerLen > 20 {
+		sig.parseOptions(tcpLayer.Contents[20:headerLen])
+	}
+	return sig, nil
+}
+
+// parseOptions 按照原始顺序解析 TCP 选项，不使用 gopacket 的解析结果是为了保留 EOL 之后的填充
+func (s *TCPSignature) parseOptions(data []byte) {
+	for len(data) > 0 {
+		kind := data[0]
+		switch kind {
+		case 0:
+			// eol+N，N 为 EOL 之后的字节数
+			rest := data[1:]
+			s.Layout = append(s.Layout, fmt.Sprintf("eol+%d", len(rest)))
+			for _, b := range rest {
+				if b != 0 {
+					s.Quirks = append(s.Quirks, "opt+")
+					break
+				}
+			}
+			return
+		case 1:
+			s.Layout = append(s.Layout, "nop")
+			data = data[1:]
+			continue
+		}
+
+		if len(data) < 2 || int(data[1]) < 2 || int(data[1]) > len(data) {
+			s.Layout = append(s.Layout, fmt.Sprintf("?%d", kind))
+			s.Quirks = append(s.Quirks, "bad")
+			return
+		}
+		length := int(data[1])
+		body := data[2:length]
+		switch kind {
+		case 2:
+			s.Layout = append(s.Layout, "mss")
+			if len(body) == 2 {
+				s.MSS = int(binary.BigEndian.Uint16(body))
+			}
+		case 3:
+			s.Layout = append(s.Layout, "ws")
+			if len(body) == 1 {
+				s.Scale = int(body[0])
+				if s.Scale > 14 {
+					s.Quirks = append(s.Quirks, "exws")
+				}
+			}
+		case 4:
+			s.Layout = append(s.Layout, "sok")
+		case 5:
+			s.Layout = append(s.Layout, "sack")
+		case 8:
+			s.Layout = append(s.Layout, "ts")
+			if len(body) == 8 {
+				if binary.BigEndian.Uint32(body[:4]) == 0 {
+					s.Quirks = append(s.Quirks, "ts1-")
+				}
+				if !s.IsResponse && binary.BigEndian.Uint32(body[4:]) != 0 {
+					s.Quirks = append(s.Quirks, "ts2+")
+				}
+			}
+		default:
+			s.Layout = append(s.Layout, fmt.Sprintf("?%d", kind))
+		}
+		data = data[length:]
+	}
+}
@@ -209,6 +209,20 @@ func WithTLSClientHello(h func(flow *TrafficFlow, hello *tlsutils.HandshakeClien
 	})
 }
 
+func WithTLSServerHello(h func(flow *TrafficFlow, hello *tlsutils.HandshakeServerHello)) CaptureOption {
+	return withPool(func(pool *TrafficPool) {
+		pool.onFlowFrameDataFrameReassembled = append(pool.onFlowFrameDataFrameReassembled, func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
+			if len(frame.Payload) <= 0 || frame.Payload[0] != 0x16 {
+				return
+			}
+
+			if hello, err := tlsutils.ParseServerHello(frame.Payload); err == nil {
+				h(flow, hello)
+			}
+		})
+	})
+}
+
 func WithHTTPRequest(h func(flow *TrafficFlow, req *http.Request)) CaptureOption {
 	return withPool(func(pool *TrafficPool) {
 		pool.onFlowFrameDataFrameReassembled = append(pool.onFlowFrameDataFrameReassembled, func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
@@ -14,6 +14,7 @@ var Exports = map[string]any{
 	"pcap_onFlowDataFrameNoReassembled": WithOnTrafficFlowOnDataFrameArrived,
 	"pcap_onFlowDataFrame":              WithOnTrafficFlowOnDataFrameReassembled,
 	"pcap_onTLSClientHello":             WithTLSClientHello,
+	"pcap_onTLSServerHello":             WithTLSServerHello,
 	"pcap_onHTTPRequest":                WithHTTPRequest,
 	"pcap_onHTTPFlow":                   WithHTTPFlow,
 	"pcap_everyPacket":                  WithEveryPacket,
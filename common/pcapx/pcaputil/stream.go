@@ -0,0 +1,29 @@
+package pcaputil
+
+import (
+	"context"
+
+	"github.com/yaklang/yaklang/common/log"
+)
+
+// StreamResults 在后台执行基于抓包的分析 run，run 通过 emit 输出的结果写入返回的 channel，run 结束后 channel 关闭
+// run 需要把 ctx 传给抓包，ctx 结束时抓包随之停止；此时 emit 直接返回，未被读取的结果会被丢弃，不会阻塞后台 goroutine
+func StreamResults[T any](ctx context.Context, name string, run func(ctx context.Context, emit func(T)) error) chan T {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ch := make(chan T, 1024)
+	emit := func(result T) {
+		select {
+		case ch <- result:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(ch)
+		if err := run(ctx, emit); err != nil {
+			log.Errorf("%v stream failed: %v", name, err)
+		}
+	}()
+	return ch
+}
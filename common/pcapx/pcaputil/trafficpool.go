@@ -149,6 +149,12 @@ func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket
 }
 
 func (p *TrafficPool) flowhash(netType, srcAddr, dstAddr string) string {
+	return FlowHash(netType, srcAddr, dstAddr)
+}
+
+// FlowHash 计算一个流(五元组)的 hash，与方向无关，两个方向的包得到相同的 hash
+// netType 例如 tcp4 / tcp6 / udp4，srcAddr / dstAddr 为 host:port
+func FlowHash(netType, srcAddr, dstAddr string) string {
 	hashMaterial := []string{netType, srcAddr, dstAddr}
 	sort.Strings(hashMaterial)
 	return codec.Sha256(strings.Join(hashMaterial, "-"))
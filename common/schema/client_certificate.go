@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// ClientCertificate 是按 host 选择的 TLS 客户端证书(双向认证)
+// 证书可以从 PKCS#12(p12/pfx) 或 PEM(crt/key/ca) 导入，PEM 支持国密 SM2 证书
+// p12 在导入时就会被解码为 PEM，数据库中不保存 p12 的密码
+// 注意：KeyPem 以明文保存在 profile 数据库中，数据库文件需要与私钥一样妥善保管
+type ClientCertificate struct {
+	gorm.Model
+
+	Name string `json:"name"`
+	// HostPattern 多个模式用逗号分隔，支持 glob，例如 *.example.com,10.0.0.*
+	HostPattern string `json:"host_pattern" gorm:"index"`
+
+	CrtPem []byte `json:"crt_pem"`
+	KeyPem []byte `json:"key_pem"`
+	// CaPem 可以包含多个 PEM 证书
+	CaPem []byte `json:"ca_pem"`
+
+	IsSM2    bool `json:"is_sm2"`
+	Disabled bool `json:"disabled"`
+
+	Hash string `json:"hash" gorm:"unique_index"`
+}
+
+func (c *ClientCertificate) CalcHash() string {
+	return utils.CalcSha1(c.HostPattern, c.CrtPem, c.KeyPem)
+}
+
+// ToGRPCModel 不包含私钥
+func (c *ClientCertificate) ToGRPCModel() *ypb.ClientCertificate {
+	return &ypb.ClientCertificate{
+		Id:          int64(c.ID),
+		Name:        c.Name,
+		HostPattern: c.HostPattern,
+		CrtPem:      c.CrtPem,
+		CaPem:       c.CaPem,
+		IsSM2:       c.IsSM2,
+		Disabled:    c.Disabled,
+	}
+}
+
+func (c *ClientCertificate) BeforeSave() error {
+	c.Hash = c.CalcHash()
+	return nil
+}
@@ -33,6 +33,7 @@ var ProfileTables = []interface{}{
 	&WebFuzzerLabel{},
 	&PluginGroup{},
 	&CodecFlow{},
+	&ClientCertificate{},
 }
 
 var databaseSchemas = map[uint8][]any{
@@ -46,10 +47,12 @@ var databaseSchemas = map[uint8][]any{
 
 // ProjectTables 这些表是和项目关联的，导出项目可以直接复制给用户
 var ProjectTables = []interface{}{
-	&WebsocketFlow{},
+	&WebsocketFlow{}, &HTTP2Stream{}, &ServerSentEvent{},
 	&HTTPFlow{}, &ExecHistory{},
 	&ExtractedData{},
 	&Port{},
+	&PortCertificate{}, &PortTLSFingerprint{}, &PortBanner{},
+	&HostOS{}, &HostClientApp{},
 	&Domain{}, &Host{},
 	&MarkdownDoc{}, &ExecResult{},
 	&Risk{}, &WebFuzzerTask{}, &WebFuzzerResponse{},
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// HostOS 是主机的操作系统识别结果，Source 说明结果的来源，例如 tcp-syn / user-agent / banner
+type HostOS struct {
+	gorm.Model
+
+	Host   string `json:"host" gorm:"index"`
+	Class  string `json:"class"`
+	Name   string `json:"name" gorm:"index"`
+	Flavor string `json:"flavor"`
+	Source string `json:"source"`
+	// Distance 是根据 TTL 推测的跳数，无法推测时为 -1
+	Distance  int    `json:"distance"`
+	Signature string `json:"signature"`
+
+	Hash      string `json:"hash" gorm:"unique_index"`
+	RuntimeId string `json:"runtime_id"`
+}
+
+func (o *HostOS) CalcHash() string {
+	return utils.CalcSha1(o.Host, o.Name, o.Flavor, o.Source, o.RuntimeId)
+}
+
+func (o *HostOS) BeforeSave() error {
+	o.Hash = o.CalcHash()
+	return nil
+}
+
+// HostClientApp 是主机上运行的客户端程序，例如浏览器、命令行工具、SSH 客户端
+type HostClientApp struct {
+	gorm.Model
+
+	Host    string `json:"host" gorm:"index"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name" gorm:"index"`
+	Version string `json:"version"`
+	Raw     string `json:"raw"`
+	JA3     string `json:"ja3" gorm:"column:ja3"`
+	JA4     string `json:"ja4" gorm:"column:ja4"`
+
+	Hash      string `json:"hash" gorm:"unique_index"`
+	RuntimeId string `json:"runtime_id"`
+}
+
+func (c *HostClientApp) CalcHash() string {
+	return utils.CalcSha1(c.Host, c.Kind, c.Name, c.Version, c.Raw, c.JA4, c.RuntimeId)
+}
+
+func (c *HostClientApp) BeforeSave() error {
+	c.Hash = c.CalcHash()
+	return nil
+}
@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	// HTTP2StreamSideClient 是 MITM 面向客户端(浏览器)一侧的流
+	HTTP2StreamSideClient = "client"
+	// HTTP2StreamSideUpstream 是 MITM 通过 lowhttp 面向服务器一侧的流
+	HTTP2StreamSideUpstream = "upstream"
+)
+
+type HTTP2Stream struct {
+	gorm.Model
+
+	// HTTPFlow 的 HiddenIndex，一个 HTTPFlow 可以有两侧的流(client/upstream)与若干推送流
+	HTTPFlowHiddenIndex string `json:"http_flow_hidden_index" gorm:"index"`
+
+	Side         string `json:"side" gorm:"index"`
+	ConnectionId string `json:"connection_id" gorm:"index"`
+	StreamId     int    `json:"stream_id" gorm:"index"`
+
+	// PRIORITY
+	Weight    int  `json:"weight"`
+	StreamDep int  `json:"stream_dep"`
+	Exclusive bool `json:"exclusive"`
+
+	// RST_STREAM 错误码，为空表示流正常结束
+	ResetErrCode string `json:"reset_err_code"`
+
+	// PUSH_PROMISE 推送的流
+	IsPushed           bool `json:"is_pushed" gorm:"index"`
+	PromisedByStreamId int  `json:"promised_by_stream_id"`
+
+	// 帧时间线(json)
+	FrameTimeline string `json:"frame_timeline"`
+	FrameCount    int    `json:"frame_count"`
+
+	Hash string `json:"hash" gorm:"unique_index"`
+}
+
+func (s *HTTP2Stream) CalcHash() string {
+	return utils.CalcSha1(s.HTTPFlowHiddenIndex, s.Side, s.ConnectionId, s.StreamId)
+}
+
+func (s *HTTP2Stream) BeforeSave() error {
+	s.Hash = s.CalcHash()
+	return nil
+}
@@ -46,6 +46,20 @@ type HTTPFlow struct {
 	// 用来计算 websocket hash, 每次连接都不一样，一般来说，内部对象 req 指针足够了
 	WebsocketHash string
 
+	// Server-Sent Events 相关字段，每一个事件保存在 ServerSentEvent 中
+	IsSSE   bool
+	SSEHash string `gorm:"index"`
+
+	// HTTP/2 相关字段，详细的帧时间线保存在 HTTP2Stream 中
+	IsHTTP2           bool
+	HTTP2ConnectionId string `gorm:"index"`
+	HTTP2StreamId     int
+
+	// 客户端指纹，JA3 / JA4 来自客户端的 TLS ClientHello，JA4H 来自原始请求
+	JA3  string `gorm:"index;column:ja3"`
+	JA4  string `gorm:"index;column:ja4"`
+	JA4H string `gorm:"index;column:ja4h"`
+
 	RuntimeId  string
 	FromPlugin string
 
@@ -72,6 +86,12 @@ func (f *HTTPFlow) FixHTTPRequest(req *http.Request) {
 		f.TooLargeResponseBodyFile = httpctx.GetResponseTooLargeBodyFile(req)
 		f.BodyLength = httpctx.GetResponseTooLargeSize(req)
 	}
+	if hash := httpctx.GetResponseSSEHash(req); hash != "" {
+		f.IsSSE = true
+		f.SSEHash = hash
+	}
+	f.JA3 = httpctx.GetRequestJA3(req)
+	f.JA4 = httpctx.GetRequestJA4(req)
 }
 
 // 颜色与 Tag API
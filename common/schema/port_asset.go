@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// PortCertificate 是端口 TLS 握手得到的证书链中的一张证书
+type PortCertificate struct {
+	gorm.Model
+
+	Host string `json:"host" gorm:"index"`
+	Port int    `json:"port" gorm:"index"`
+	// ChainIndex 是证书在证书链中的位置，0 为服务器证书
+	ChainIndex int `json:"chain_index"`
+
+	Subject            string `json:"subject"`
+	SubjectCN          string `json:"subject_cn" gorm:"index"`
+	Issuer             string `json:"issuer"`
+	IssuerCN           string `json:"issuer_cn"`
+	SerialNumber       string `json:"serial_number"`
+	NotBefore          int64  `json:"not_before"`
+	NotAfter           int64  `json:"not_after"`
+	KeyType            string `json:"key_type"`
+	KeyBits            int    `json:"key_bits"`
+	SignatureAlgorithm string `json:"signature_algorithm"`
+	// DNSNames / IPAddresses / EmailAddresses 是 SAN 中的内容，用逗号分隔
+	DNSNames       string `json:"dns_names"`
+	IPAddresses    string `json:"ip_addresses"`
+	EmailAddresses string `json:"email_addresses"`
+	IsCA           bool   `json:"is_ca"`
+	SelfSigned     bool   `json:"self_signed"`
+	SM2            bool   `json:"sm2"`
+	SHA256         string `json:"sha256" gorm:"index"`
+	PEM            string `json:"pem"`
+
+	Hash      string `json:"hash" gorm:"unique_index"`
+	RuntimeId string `json:"runtime_id"`
+}
+
+func (c *PortCertificate) CalcHash() string {
+	return utils.CalcSha1(c.Host, c.Port, c.SHA256, c.RuntimeId)
+}
+
+func (c *PortCertificate) BeforeSave() error {
+	c.Hash = c.CalcHash()
+	return nil
+}
+
+// PortTLSFingerprint 是端口的 TLS 服务端指纹
+type PortTLSFingerprint struct {
+	gorm.Model
+
+	Host        string `json:"host" gorm:"index"`
+	Port        int    `json:"port" gorm:"index"`
+	Fingerprint string `json:"fingerprint" gorm:"index"`
+	Raw         string `json:"raw"`
+	JA3S        string `json:"ja3s"`
+
+	Hash      string `json:"hash" gorm:"unique_index"`
+	RuntimeId string `json:"runtime_id"`
+}
+
+func (f *PortTLSFingerprint) CalcHash() string {
+	return utils.CalcSha1(f.Host, f.Port, f.RuntimeId)
+}
+
+func (f *PortTLSFingerprint) BeforeSave() error {
+	f.Hash = f.CalcHash()
+	return nil
+}
+
+// PortBanner 是服务指纹识别时某个探针收到的 Banner
+type PortBanner struct {
+	gorm.Model
+
+	Host   string `json:"host" gorm:"index"`
+	Port   int    `json:"port" gorm:"index"`
+	Proto  string `json:"proto"`
+	Probe  string `json:"probe"`
+	Banner string `json:"banner"`
+
+	Hash      string `json:"hash" gorm:"unique_index"`
+	RuntimeId string `json:"runtime_id"`
+}
+
+func (b *PortBanner) CalcHash() string {
+	return utils.CalcSha1(b.Host, b.Port, b.Proto, b.Probe, b.RuntimeId)
+}
+
+func (b *PortBanner) BeforeSave() error {
+	b.Hash = b.CalcHash()
+	return nil
+}
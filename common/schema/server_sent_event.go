@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// ServerSentEvent 是 text/event-stream 响应中的一个事件，通过 SSEHash 关联到 HTTPFlow
+type ServerSentEvent struct {
+	gorm.Model
+
+	SSEHash    string `json:"sse_hash" gorm:"index"`
+	EventIndex int    `json:"event_index" gorm:"index"`
+
+	EventId    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Retry      int    `json:"retry"`
+	QuotedData string `json:"quoted_data"`
+	// 原始的事件块(包含 id/event/data 等字段)
+	QuotedRaw string `json:"quoted_raw"`
+
+	// 事件到达 MITM 的时间(unix nano)
+	Timestamp int64 `json:"timestamp"`
+
+	IsModified bool `json:"is_modified"`
+	IsDropped  bool `json:"is_dropped"`
+
+	Hash string `json:"hash" gorm:"unique_index"`
+}
+
+func (e *ServerSentEvent) CalcHash() string {
+	return utils.CalcSha1(e.SSEHash, e.EventIndex)
+}
+
+func (e *ServerSentEvent) BeforeSave() error {
+	e.Hash = e.CalcHash()
+	return nil
+}
+
+func (e *ServerSentEvent) ToGRPCModel() *ypb.ServerSentEvent {
+	data, err := strconv.Unquote(e.QuotedData)
+	if err != nil {
+		data = e.QuotedData
+	}
+	raw, err := strconv.Unquote(e.QuotedRaw)
+	if err != nil {
+		raw = e.QuotedRaw
+	}
+	return &ypb.ServerSentEvent{
+		Id:         int64(e.ID),
+		SSEHash:    e.SSEHash,
+		EventIndex: int64(e.EventIndex),
+		EventId:    e.EventId,
+		EventType:  e.EventType,
+		Retry:      int64(e.Retry),
+		Data:       []byte(data),
+		Raw:        []byte(raw),
+		Timestamp:  e.Timestamp,
+		IsModified: e.IsModified,
+		IsDropped:  e.IsDropped,
+	}
+}
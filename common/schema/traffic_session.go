@@ -58,4 +58,11 @@ type TrafficSession struct {
 	// TLS ClientHello
 	HaveClientHello bool
 	SNI             string
+
+	// TLS / HTTP 指纹，JA4 系列使用哈希之后的形式
+	JA3  string
+	JA4  string
+	JA3S string
+	JA4S string
+	JA4H string
 }
@@ -7,6 +7,9 @@ const (
 	BRUTE
 	SEARCH
 	ZONE_TRANSFER
+	CT_LOG
+	PERMUTATION
+	ZONE_WALK
 )
 
 type SubdomainScannerConfig struct {
@@ -55,6 +58,28 @@ type SubdomainScannerConfig struct {
 	// 进行各种数据源搜索的时候，需要设置的 HTTP 超时时间
 	// 默认 10s
 	TimeoutForEachHTTPSearch time.Duration
+
+	// 证书透明度日志地址（RFC 6962），默认为空，CT_LOG 模式需要手动设置日志或镜像：
+	// 日志按证书过期时间分片，只会收录过期时间落在分片时间段内的证书，需要选择覆盖目标证书有效期的分片
+	CTLogURLs []string
+	// CT_LOG 模式只抽样每个日志末尾（最新）的 CTLogMaxEntries 条记录，更早的证书不会被扫描，
+	// RFC 6962 日志没有按域名的索引，无法查询某个域名的全部证书；需要完整的历史证书请使用
+	// SEARCH 模式中基于索引的数据源（crt.sh / certspotter）。小于等于 0 时读取整个日志，默认 10000
+	CTLogMaxEntries int
+	// 每次 get-entries 请求的条目数量，日志服务器可能返回更少
+	CTLogBatchSize int
+
+	// 置换模式（altdns）使用的单词，已发现子域名中的标签也会被加入
+	PermutationWords []string
+	// 置换模式的种子域名，其他模式发现的子域名会自动加入
+	PermutationSeeds []string
+	// 单个目标最多生成的置换域名数量
+	MaxPermutations int
+
+	// NSEC 区域遍历最多记录的域名数量
+	ZoneWalkMaxNames int
+	// 收集 NSEC3 哈希时最多发送的查询数量，哈希链闭合时会提前结束
+	NSEC3MaxQueries int
 }
 
 func (s *SubdomainScannerConfig) init() {
@@ -70,6 +95,12 @@ func (s *SubdomainScannerConfig) init() {
 	s.TimeoutForEachQuery = 3 * time.Second
 	s.WildCardToStop = false
 	s.TimeoutForEachHTTPSearch = 10 * time.Second
+	s.CTLogMaxEntries = 10000
+	s.CTLogBatchSize = 256
+	s.PermutationWords = DefaultPermutationWords
+	s.MaxPermutations = 50000
+	s.ZoneWalkMaxNames = 10000
+	s.NSEC3MaxQueries = 500
 }
 
 type ConfigOption func(s *SubdomainScannerConfig)
@@ -147,6 +178,55 @@ func WithTimeoutForEachHTTPSearch(timeout time.Duration) ConfigOption {
 	}
 }
 
+func WithCTLogURLs(urls []string) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.CTLogURLs = urls
+	}
+}
+
+// WithCTLogMaxEntries 设置 CT_LOG 模式从每个日志末尾抽样的记录数量，只会扫描最新的 n 条记录
+func WithCTLogMaxEntries(n int) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.CTLogMaxEntries = n
+	}
+}
+
+func WithCTLogBatchSize(n int) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.CTLogBatchSize = n
+	}
+}
+
+func WithPermutationWords(words []string) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.PermutationWords = words
+	}
+}
+
+func WithPermutationSeeds(domains []string) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.PermutationSeeds = domains
+	}
+}
+
+func WithMaxPermutations(n int) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.MaxPermutations = n
+	}
+}
+
+func WithZoneWalkMaxNames(n int) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.ZoneWalkMaxNames = n
+	}
+}
+
+func WithNSEC3MaxQueries(n int) ConfigOption {
+	return func(s *SubdomainScannerConfig) {
+		s.NSEC3MaxQueries = n
+	}
+}
+
 func NewSubdomainScannerConfig(options ...ConfigOption) *SubdomainScannerConfig {
 	config := &SubdomainScannerConfig{}
 	config.init()
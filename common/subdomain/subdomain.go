@@ -29,6 +29,9 @@ func removeRepeatedMode(modes ...int) []int {
 		case BRUTE:
 		case SEARCH:
 		case ZONE_TRANSFER:
+		case CT_LOG:
+		case PERMUTATION:
+		case ZONE_WALK:
 		default:
 			continue
 		}
@@ -148,7 +151,12 @@ func (s *SubdomainScanner) RunWithContext(ctx context.Context) error {
 			// 针对不同模式启动 goroutine 并发
 			wg := utils.NewSizedWaitGroup(3)
 			defer wg.Wait()
+			var permutation bool
 			for _, mode := range modes {
+				if mode == PERMUTATION {
+					permutation = true
+					continue
+				}
 
 				// 使用 AddWithContext 安全取消队列中的任务
 				err := wg.AddWithContext(ctx)
@@ -176,10 +184,28 @@ func (s *SubdomainScanner) RunWithContext(ctx context.Context) error {
 
 						s.ZoneTransfer(ctx, target)
 					}()
+				case CT_LOG:
+					go func() {
+						defer wg.Done()
+
+						s.CTLog(ctx, target)
+					}()
+				case ZONE_WALK:
+					go func() {
+						defer wg.Done()
+
+						s.ZoneWalk(ctx, target)
+					}()
 				default:
 					wg.Done()
 				}
 			}
+
+			// 置换模式依赖其他模式发现的子域名，需要等其他模式结束之后再执行
+			wg.Wait()
+			if permutation && ctx.Err() == nil {
+				s.Permutation(ctx, target)
+			}
 		}(t)
 	}
 
@@ -139,6 +139,7 @@ func (s *SubdomainScanner) BruteWithSubDictionarySelection(ctx context.Context,
 
 func (s *SubdomainScanner) isWildCard(ctx context.Context, target string) (ok bool, tested []string, blacklist []string) {
 	result := sync.Map{}
+	testedLock := sync.Mutex{}
 
 	wg := sync.WaitGroup{}
 	for i := 0; i < 5; i++ {
@@ -159,7 +160,9 @@ func (s *SubdomainScanner) isWildCard(ctx context.Context, target string) (ok bo
 				s.logger.Debugf("wildcard detected: checking %s failed: %s", payload, err)
 				return
 			}
+			testedLock.Lock()
 			tested = append(tested, payload)
+			testedLock.Unlock()
 
 			result.Store(ip, 0)
 		}()
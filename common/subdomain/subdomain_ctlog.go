@@ -0,0 +1,228 @@
+package subdomain
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// RFC 6962 中 TimestampedEntry 的 entry_type
+const (
+	ctLogX509Entry    = 0
+	ctLogPrecertEntry = 1
+)
+
+type ctLogTreeHead struct {
+	TreeSize int64 `json:"tree_size"`
+}
+
+type ctLogEntries struct {
+	Entries []struct {
+		LeafInput []byte `json:"leaf_input"`
+		ExtraData []byte `json:"extra_data"`
+	} `json:"entries"`
+}
+
+// readCTLogOpaque 读取 opaque<1..2^24-1> 格式的数据，返回数据和剩余部分
+func readCTLogOpaque(raw []byte) ([]byte, []byte, error) {
+	if len(raw) < 3 {
+		return nil, nil, utils.Error("ct log entry too short")
+	}
+	length := int(raw[0])<<16 | int(raw[1])<<8 | int(raw[2])
+	if len(raw) < 3+length {
+		return nil, nil, utils.Errorf("ct log entry truncated: need %v bytes, got %v", length, len(raw)-3)
+	}
+	return raw[3 : 3+length], raw[3+length:], nil
+}
+
+// parseCTLogEntry 解析 get-entries 中的一条记录，预签名证书从 extra_data 的 PrecertChainEntry 中读取
+func parseCTLogEntry(leafInput, extraData []byte) (*x509.Certificate, error) {
+	// MerkleTreeLeaf: version(1) + leaf_type(1) + timestamp(8) + entry_type(2)
+	if len(leafInput) < 12 {
+		return nil, utils.Error("ct log leaf input too short")
+	}
+	if leafInput[0] != 0 || leafInput[1] != 0 {
+		return nil, utils.Errorf("unsupported ct log leaf version: %v type: %v", leafInput[0], leafInput[1])
+	}
+
+	var der []byte
+	var err error
+	switch entryType := binary.BigEndian.Uint16(leafInput[10:12]); entryType {
+	case ctLogX509Entry:
+		der, _, err = readCTLogOpaque(leafInput[12:])
+	case ctLogPrecertEntry:
+		der, _, err = readCTLogOpaque(extraData)
+	default:
+		return nil, utils.Errorf("unsupported ct log entry type: %v", entryType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// certificateSubdomains 返回证书中属于 target 的子域名，通配符证书会去掉 "*."
+func certificateSubdomains(cert *x509.Certificate, target string) []string {
+	var domains []string
+	for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+		name = strings.TrimPrefix(name, "*.")
+		if strings.HasSuffix(name, "."+target) {
+			domains = append(domains, name)
+		}
+	}
+	return domains
+}
+
+func ctLogGetJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	body, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return utils.Errorf("%s responded %v: %s", u, rsp.StatusCode, utils.ShrinkString(string(body), 200))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// searchCTLog 读取日志最新的 CTLogMaxEntries 条记录，把属于 target 的子域名交给 handler
+//
+// 这里只是抽样日志的末尾，不是按域名查询，更早写入日志的证书不会被发现
+func (s *SubdomainScanner) searchCTLog(ctx context.Context, client *http.Client, logURL string, target string, handler func(domain string)) error {
+	logURL = strings.TrimRight(logURL, "/")
+
+	var sth ctLogTreeHead
+	if err := ctLogGetJSON(ctx, client, logURL+"/ct/v1/get-sth", &sth); err != nil {
+		return utils.Errorf("get sth failed: %s", err)
+	}
+
+	start := int64(0)
+	if s.config.CTLogMaxEntries > 0 && sth.TreeSize > int64(s.config.CTLogMaxEntries) {
+		start = sth.TreeSize - int64(s.config.CTLogMaxEntries)
+	}
+	if start > 0 {
+		s.logger.Infof("ct log %s has %v entries, only sampling the newest %v entries [%v, %v), "+
+			"older certificates are not scanned (use SEARCH mode, e.g. crt.sh, for an indexed lookup)",
+			logURL, sth.TreeSize, sth.TreeSize-start, start, sth.TreeSize)
+	}
+	batch := int64(s.config.CTLogBatchSize)
+	if batch <= 0 {
+		batch = 256
+	}
+
+	for start < sth.TreeSize {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		end := start + batch - 1
+		if end >= sth.TreeSize {
+			end = sth.TreeSize - 1
+		}
+
+		var entries ctLogEntries
+		u := fmt.Sprintf("%s/ct/v1/get-entries?start=%d&end=%d", logURL, start, end)
+		if err := ctLogGetJSON(ctx, client, u, &entries); err != nil {
+			return utils.Errorf("get entries failed: %s", err)
+		}
+		// 日志服务器可能返回比请求更少的条目
+		if len(entries.Entries) <= 0 {
+			break
+		}
+		for index, entry := range entries.Entries {
+			cert, err := parseCTLogEntry(entry.LeafInput, entry.ExtraData)
+			if err != nil {
+				s.logger.Debugf("parse ct log entry %v from %s failed: %s", start+int64(index), logURL, err)
+				continue
+			}
+			for _, domain := range certificateSubdomains(cert, target) {
+				handler(domain)
+			}
+		}
+		start += int64(len(entries.Entries))
+	}
+	return nil
+}
+
+// CTLog 从证书透明度日志末尾抽样查找子域名，找到的域名会被解析之后报告
+func (s *SubdomainScanner) CTLog(ctx context.Context, target string) {
+	target = strings.ToLower(formatDomain(target))
+	if len(s.config.CTLogURLs) <= 0 {
+		s.logger.Warnf("no certificate transparency log configured, skip ct log mode for %s", target)
+		return
+	}
+
+	if s.config.CTLogMaxEntries > 0 {
+		s.logger.Infof("start to sample the newest %v entries of each certificate transparency log for %s", s.config.CTLogMaxEntries, target)
+	} else {
+		s.logger.Infof("start to read whole certificate transparency logs for %s", target)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionSSL30, // nolint[:staticcheck]
+			MaxVersion:         tls.VersionTLS13,
+		}},
+		Timeout: s.config.TimeoutForEachHTTPSearch,
+	}
+
+	var (
+		found = make(map[string]string)
+		lock  sync.Mutex
+		wg    sync.WaitGroup
+	)
+	for _, logURL := range s.config.CTLogURLs {
+		wg.Add(1)
+		go func(logURL string) {
+			defer wg.Done()
+			err := s.searchCTLog(ctx, client, logURL, target, func(domain string) {
+				lock.Lock()
+				defer lock.Unlock()
+				if _, ok := found[domain]; !ok {
+					found[domain] = logURL
+				}
+			})
+			if err != nil {
+				s.logger.Warnf("search certificate transparency log %s failed: %s", logURL, err)
+			}
+		}(logURL)
+	}
+	wg.Wait()
+
+	domains := make([]string, 0, len(found))
+	for domain := range found {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var results []*SubdomainResult
+	for _, domain := range domains {
+		s.logger.Infof("ct log mode found: %s", domain)
+		results = append(results, &SubdomainResult{
+			FromTarget:  target,
+			FromModeRaw: CT_LOG,
+			Domain:      domain,
+			Tags:        []string{found[domain]},
+		})
+	}
+	s.resolveResults(ctx, results)
+}
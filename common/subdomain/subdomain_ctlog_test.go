@@ -0,0 +1,172 @@
+package subdomain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestResolver 启动本地 DNS 服务器，records 的键支持 "*." 开头的泛解析
+func startTestResolver(t *testing.T, records map[string]string) string {
+	handler := &testDomainServer{}
+	handler.AddHandler(func(w dns.ResponseWriter, r *dns.Msg) {
+		msg := &dns.Msg{}
+		msg.SetReply(r)
+		name := strings.Trim(strings.ToLower(r.Question[0].Name), ".")
+		ip, ok := records[name]
+		if !ok {
+			_, parent, _ := strings.Cut(name, ".")
+			ip, ok = records["*."+parent]
+		}
+		if ok && r.Question[0].Qtype == dns.TypeA {
+			msg.Answer = append(msg.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP(ip),
+			})
+		}
+		w.WriteMsg(msg)
+	})
+
+	addr := utils.HostPort("127.0.0.1", utils.GetRandomAvailableUDPPort())
+	started := make(chan struct{})
+	server := &dns.Server{Addr: addr, Net: "udp", Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ListenAndServe()
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("start dns server timeout")
+	}
+	t.Cleanup(func() { server.Shutdown() })
+	return addr
+}
+
+func testCertificate(t *testing.T, cn string, names ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     names,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return der
+}
+
+func testCTLogOpaque(raw []byte) []byte {
+	return append([]byte{byte(len(raw) >> 16), byte(len(raw) >> 8), byte(len(raw))}, raw...)
+}
+
+func testCTLogLeaf(entryType uint16, body []byte) []byte {
+	leaf := make([]byte, 12)
+	binary.BigEndian.PutUint64(leaf[2:], uint64(time.Now().UnixMilli()))
+	binary.BigEndian.PutUint16(leaf[10:], entryType)
+	return append(leaf, body...)
+}
+
+func TestCTLogMode(t *testing.T) {
+	type entry struct {
+		LeafInput []byte `json:"leaf_input"`
+		ExtraData []byte `json:"extra_data"`
+	}
+	precert := testCertificate(t, "", "secret.example.com")
+	entries := []entry{
+		// 不在读取范围之内
+		{LeafInput: testCTLogLeaf(ctLogX509Entry, testCTLogOpaque(testCertificate(t, "skipped.example.com")))},
+		{LeafInput: testCTLogLeaf(ctLogX509Entry, testCTLogOpaque(testCertificate(t, "mail.example.com", "www.example.com", "*.dev.example.com", "other.org")))},
+		{
+			LeafInput: testCTLogLeaf(ctLogPrecertEntry, append(make([]byte, 32), testCTLogOpaque([]byte("tbs"))...)),
+			ExtraData: append(testCTLogOpaque(precert), testCTLogOpaque(nil)...),
+		},
+		{LeafInput: testCTLogLeaf(5, nil)},
+	}
+
+	var requested []string
+	var lock sync.Mutex
+	log := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/log/ct/v1/get-sth":
+			json.NewEncoder(w).Encode(map[string]any{"tree_size": len(entries)})
+		case "/log/ct/v1/get-entries":
+			lock.Lock()
+			requested = append(requested, r.URL.RawQuery)
+			lock.Unlock()
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			end, _ := strconv.Atoi(r.URL.Query().Get("end"))
+			// 每次最多返回两条，模拟日志服务器的限制
+			if end-start+1 > 2 {
+				end = start + 1
+			}
+			json.NewEncoder(w).Encode(map[string]any{"entries": entries[start : end+1]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer log.Close()
+
+	resolver := startTestResolver(t, map[string]string{
+		"www.example.com": "10.0.0.1",
+		"dev.example.com": "10.0.0.2",
+	})
+	scanner, err := NewSubdomainScanner(NewSubdomainScannerConfig(
+		WithModes(CT_LOG),
+		WithDNSServers([]string{resolver}),
+		WithCTLogURLs([]string{log.URL + "/log/"}),
+		WithCTLogMaxEntries(3),
+		WithCTLogBatchSize(10),
+	), "example.com")
+	require.NoError(t, err)
+
+	var found, failed []string
+	scanner.OnResult(func(result *SubdomainResult) {
+		lock.Lock()
+		defer lock.Unlock()
+		require.Equal(t, CT_LOG, result.FromModeRaw)
+		found = append(found, result.Domain+"="+result.IP)
+	})
+	scanner.OnResolveFailedResult(func(result *SubdomainResult) {
+		lock.Lock()
+		defer lock.Unlock()
+		failed = append(failed, result.Domain)
+	})
+	require.NoError(t, scanner.Run())
+
+	sort.Strings(found)
+	sort.Strings(failed)
+	require.Equal(t, []string{"dev.example.com=10.0.0.2", "www.example.com=10.0.0.1"}, found)
+	require.Equal(t, []string{"mail.example.com", "secret.example.com"}, failed)
+	require.Equal(t, []string{"start=1&end=3", "start=3&end=3"}, requested)
+}
+
+func TestCTLogModeWithoutLogs(t *testing.T) {
+	require.Empty(t, NewSubdomainScannerConfig().CTLogURLs)
+
+	scanner, err := NewSubdomainScanner(NewSubdomainScannerConfig(WithModes(CT_LOG)), "example.com")
+	require.NoError(t, err)
+	var found []string
+	scanner.OnResult(func(result *SubdomainResult) {
+		found = append(found, result.Domain)
+	})
+	require.NoError(t, scanner.Run())
+	require.Empty(t, found)
+}
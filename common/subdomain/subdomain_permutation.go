@@ -0,0 +1,258 @@
+package subdomain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var DefaultPermutationWords = []string{
+	"dev", "test", "uat", "qa", "stage", "staging", "pre", "prod",
+	"beta", "demo", "new", "old", "bak", "backup", "admin", "api",
+	"internal", "intranet", "m", "mobile", "web", "www", "static",
+	"cdn", "v1", "v2", "gray", "sandbox", "ops", "mgmt",
+}
+
+var permutationSeparators = []string{"-", ""}
+
+func isValidLabel(label string) bool {
+	if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitNumberSuffix 拆分标签末尾的数字，例如 api02 -> api, 2, 2
+func splitNumberSuffix(label string) (prefix string, number int, width int, ok bool) {
+	i := len(label)
+	for i > 0 && label[i-1] >= '0' && label[i-1] <= '9' {
+		i--
+	}
+	if i == len(label) {
+		return label, 0, 0, false
+	}
+	n, err := strconv.Atoi(label[i:])
+	if err != nil {
+		return label, 0, 0, false
+	}
+	return label[:i], n, len(label) - i, true
+}
+
+// generatePermutations 参考 altdns 对已知子域名进行置换：
+//  1. 在每个位置插入单词作为新的标签：api.example.com -> dev.api.example.com / api.dev.example.com
+//  2. 单词与标签通过分隔符拼接：api-dev.example.com / devapi.example.com
+//  3. 标签末尾的数字加减，没有数字的标签追加数字：api2 -> api1 / api3，api -> api1 / api-1
+//
+// 已知子域名中出现的标签也会作为单词使用
+func generatePermutations(seeds []string, target string, words []string, limit int) []string {
+	target = strings.Trim(strings.ToLower(target), ".")
+
+	var subs [][]string
+	known := make(map[string]struct{})
+	wordSet := make(map[string]struct{})
+	var allWords []string
+	addWord := func(word string) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if !isValidLabel(word) {
+			return
+		}
+		if _, ok := wordSet[word]; ok {
+			return
+		}
+		wordSet[word] = struct{}{}
+		allWords = append(allWords, word)
+	}
+	for _, word := range words {
+		addWord(word)
+	}
+	for _, seed := range seeds {
+		seed = strings.Trim(strings.ToLower(strings.TrimSpace(seed)), ".")
+		sub, ok := strings.CutSuffix(seed, "."+target)
+		if !ok || sub == "" {
+			continue
+		}
+		if _, ok := known[seed]; ok {
+			continue
+		}
+		known[seed] = struct{}{}
+		labels := strings.Split(sub, ".")
+		subs = append(subs, labels)
+		for _, label := range labels {
+			addWord(label)
+		}
+	}
+
+	var results []string
+	seen := make(map[string]struct{})
+	add := func(labels ...string) bool {
+		if limit > 0 && len(results) >= limit {
+			return false
+		}
+		for _, label := range labels {
+			if !isValidLabel(label) {
+				return true
+			}
+		}
+		domain := strings.Join(labels, ".") + "." + target
+		if _, ok := known[domain]; ok {
+			return true
+		}
+		if _, ok := seen[domain]; ok {
+			return true
+		}
+		seen[domain] = struct{}{}
+		results = append(results, domain)
+		return true
+	}
+	replace := func(labels []string, index int, label string) []string {
+		ret := append([]string{}, labels...)
+		ret[index] = label
+		return ret
+	}
+
+	for _, labels := range subs {
+		// 数字
+		for index, label := range labels {
+			if prefix, n, width, ok := splitNumberSuffix(label); ok {
+				for _, delta := range []int{-1, 1, -2, 2} {
+					if n+delta < 0 {
+						continue
+					}
+					if !add(replace(labels, index, fmt.Sprintf("%s%0*d", prefix, width, n+delta))...) {
+						return results
+					}
+				}
+				continue
+			}
+			for _, sep := range permutationSeparators {
+				for i := 0; i < 10; i++ {
+					if !add(replace(labels, index, fmt.Sprintf("%s%s%d", label, sep, i))...) {
+						return results
+					}
+				}
+			}
+		}
+
+		for _, word := range allWords {
+			// 插入新的标签
+			for i := 0; i <= len(labels); i++ {
+				inserted := append(append(append([]string{}, labels[:i]...), word), labels[i:]...)
+				if !add(inserted...) {
+					return results
+				}
+			}
+			// 与已有标签拼接
+			for index, label := range labels {
+				if label == word {
+					continue
+				}
+				for _, sep := range permutationSeparators {
+					if !add(replace(labels, index, word+sep+label)...) {
+						return results
+					}
+					if !add(replace(labels, index, label+sep+word)...) {
+						return results
+					}
+				}
+			}
+		}
+	}
+	return results
+}
+
+// permutationSeeds 返回置换模式的种子：配置的种子和已经发现的 target 的子域名
+func (s *SubdomainScanner) permutationSeeds(target string) []string {
+	seeds := append([]string{}, s.config.PermutationSeeds...)
+	s.resultCacher.Range(func(key, value interface{}) bool {
+		result, ok := value.(*SubdomainResult)
+		if !ok {
+			return true
+		}
+		domain := strings.Trim(strings.ToLower(result.Domain), ".")
+		if strings.HasSuffix(domain, "."+target) {
+			seeds = append(seeds, domain)
+		}
+		return true
+	})
+	return seeds
+}
+
+// Permutation 对已发现的子域名进行置换并解析，每个父域名会先检查泛解析
+func (s *SubdomainScanner) Permutation(ctx context.Context, target string) {
+	target = strings.ToLower(formatDomain(target))
+
+	seeds := s.permutationSeeds(target)
+	candidates := generatePermutations(seeds, target, s.config.PermutationWords, s.config.MaxPermutations)
+	if len(candidates) <= 0 {
+		s.logger.Infof("no permutation generated for %s (seeds: %v)", target, len(seeds))
+		return
+	}
+	s.logger.Infof("start to resolve %v permutations for %s from %v seeds", len(candidates), target, len(seeds))
+
+	// 按父域名分组，泛解析是针对父域名检查的
+	var parents []string
+	groups := make(map[string][]string)
+	for _, candidate := range candidates {
+		_, parent, _ := strings.Cut(candidate, ".")
+		if _, ok := groups[parent]; !ok {
+			parents = append(parents, parent)
+		}
+		groups[parent] = append(groups[parent], candidate)
+	}
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for _, parent := range parents {
+		if ctx.Err() != nil {
+			return
+		}
+
+		ok, tested, blacklistIP := s.isWildCard(ctx, parent)
+		if ok {
+			s.logger.Infof("maybe %s has dns wildcard resolving setting, tested: [%s]", parent, strings.Join(tested, "|"))
+			s.logger.Infof("we detected blacklist ip [%s]", strings.Join(blacklistIP, " | "))
+			if s.config.WildCardToStop {
+				continue
+			}
+		}
+
+		for _, candidate := range groups[parent] {
+			err := s.dnsQuerierSwg.AddWithContext(ctx)
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func(domain string, blacklistIP []string) {
+				defer s.dnsQuerierSwg.Done()
+				defer wg.Done()
+
+				ip, server, err := s.QueryA(ctx, domain)
+				if err != nil {
+					s.logger.Debugf("query [%s] A failed: %s", domain, err)
+					return
+				}
+				for _, bip := range blacklistIP {
+					if bip == ip {
+						s.logger.Debugf("maybe [%s] - [%s] from %s is detected by wildcard checking", domain, ip, server)
+						return
+					}
+				}
+				s.onResult(&SubdomainResult{
+					FromTarget:    target,
+					FromDNSServer: server,
+					FromModeRaw:   PERMUTATION,
+					IP:            ip,
+					Domain:        domain,
+					Tags:          []string{"permutation"},
+				})
+			}(candidate, blacklistIP)
+		}
+	}
+}
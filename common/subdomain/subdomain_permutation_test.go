@@ -0,0 +1,65 @@
+package subdomain
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePermutations(t *testing.T) {
+	results := generatePermutations([]string{"api.example.com", "web02.example.com", "other.org", "example.com"}, "example.com", []string{"dev", "Bad Word"}, 0)
+	for _, expected := range []string{
+		"dev.api.example.com", "api.dev.example.com", "api-dev.example.com", "devapi.example.com",
+		"api1.example.com", "api-9.example.com", "web01.example.com", "web03.example.com",
+		// 已知子域名的标签也作为单词使用
+		"api.web02.example.com", "web02-api.example.com",
+	} {
+		require.Contains(t, results, expected)
+	}
+	require.NotContains(t, results, "api.example.com")
+	require.NotContains(t, results, "web2.example.com")
+	for _, result := range results {
+		require.NotContains(t, result, "bad")
+		require.NotContains(t, result, "other.org")
+	}
+
+	require.Len(t, generatePermutations([]string{"api.example.com"}, "example.com", DefaultPermutationWords, 10), 10)
+}
+
+func TestPermutationMode(t *testing.T) {
+	resolver := startTestResolver(t, map[string]string{
+		"dev.api.example.com":    "10.0.0.1",
+		"api-dev.example.com":    "10.0.0.2",
+		"*.wild.example.com":     "10.0.0.3",
+		"x-dev.wild.example.com": "10.0.0.4",
+	})
+
+	run := func(wildcardToStop bool) []string {
+		scanner, err := NewSubdomainScanner(NewSubdomainScannerConfig(
+			WithModes(PERMUTATION),
+			WithDNSServers([]string{resolver}),
+			WithPermutationWords([]string{"dev"}),
+			WithPermutationSeeds([]string{"api.example.com", "x.wild.example.com"}),
+			WithWildCardToStop(wildcardToStop),
+		), "example.com")
+		require.NoError(t, err)
+
+		var lock sync.Mutex
+		var found []string
+		scanner.OnResult(func(result *SubdomainResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			require.Equal(t, PERMUTATION, result.FromModeRaw)
+			found = append(found, result.Domain+"="+result.IP)
+		})
+		require.NoError(t, scanner.Run())
+		sort.Strings(found)
+		return found
+	}
+
+	// 泛解析的 IP 被过滤，与泛解析不同的记录仍然保留
+	require.Equal(t, []string{"api-dev.example.com=10.0.0.2", "dev.api.example.com=10.0.0.1", "x-dev.wild.example.com=10.0.0.4"}, run(false))
+	require.Equal(t, []string{"api-dev.example.com=10.0.0.2", "dev.api.example.com=10.0.0.1"}, run(true))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -69,3 +70,34 @@ func (s *SubdomainScanner) QueryAAAA(ctx context.Context, domain string) (ip6 st
 	}
 	return "", "", errors.Errorf("no A record for %s", domain)
 }
+
+// resolveResults 解析没有 IP 的结果，解析成功的通过 OnResult 报告，解析失败的通过 OnResolveFailedResult 报告
+func (s *SubdomainScanner) resolveResults(ctx context.Context, results []*SubdomainResult) {
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for _, result := range results {
+		if result.IP != "" {
+			s.onResult(result)
+			continue
+		}
+
+		err := s.dnsQuerierSwg.AddWithContext(ctx)
+		if err != nil {
+			return
+		}
+		wg.Add(1)
+		go func(result *SubdomainResult) {
+			defer s.dnsQuerierSwg.Done()
+			defer wg.Done()
+			ip, server, err := s.QueryA(ctx, result.Domain)
+			if err != nil {
+				s.logger.Debugf("domain[%s] cannot be resolved to IP: %s", result.Domain, err)
+				s.onResolveFailedResult(result)
+				return
+			}
+			result.IP = ip
+			result.FromDNSServer = server
+			s.onResult(result)
+		}(result)
+	}
+}
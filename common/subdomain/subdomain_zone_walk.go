@@ -0,0 +1,236 @@
+package subdomain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// nsec3Chain 是从否定应答中收集到的 NSEC3 哈希链
+type nsec3Chain struct {
+	Hash       uint8
+	Iterations uint16
+	Salt       string
+
+	// owner hash -> next hash
+	next map[string]string
+}
+
+func newNSEC3Chain() *nsec3Chain {
+	return &nsec3Chain{next: make(map[string]string)}
+}
+
+// add 记录一条属于 zone 的 NSEC3 记录，返回是否是新的哈希
+func (c *nsec3Chain) add(rr *dns.NSEC3, zone string) bool {
+	hash, parent, _ := strings.Cut(strings.ToLower(rr.Hdr.Name), ".")
+	if parent != zone {
+		return false
+	}
+	if len(c.next) <= 0 {
+		c.Hash, c.Iterations, c.Salt = rr.Hash, rr.Iterations, rr.Salt
+	}
+	hash = strings.ToUpper(hash)
+	if _, ok := c.next[hash]; ok {
+		return false
+	}
+	c.next[hash] = strings.ToUpper(rr.NextDomain)
+	return true
+}
+
+// complete 判断哈希链是否闭合，闭合之后说明已经收集到了区域中的所有哈希
+func (c *nsec3Chain) complete() bool {
+	for start := range c.next {
+		current := start
+		for i := 0; i < len(c.next); i++ {
+			next, ok := c.next[current]
+			if !ok {
+				return false
+			}
+			current = next
+		}
+		return current == start
+	}
+	return false
+}
+
+// hashes 返回收集到的所有哈希，包括只出现在 next 中的
+func (c *nsec3Chain) hashes() map[string]struct{} {
+	ret := make(map[string]struct{})
+	for owner, next := range c.next {
+		ret[owner] = struct{}{}
+		ret[next] = struct{}{}
+	}
+	return ret
+}
+
+// crackNSEC3 使用字典离线破解 NSEC3 哈希，返回能够对应上的域名
+func crackNSEC3(ctx context.Context, chain *nsec3Chain, zone string, dictionary []byte) []string {
+	hashes := chain.hashes()
+	var names []string
+	for word := range generateDictionary(ctx, dictionary) {
+		if word == "" {
+			continue
+		}
+		name := strings.ToLower(word) + "." + zone
+		if _, ok := hashes[dns.HashName(name, chain.Hash, chain.Iterations, chain.Salt)]; ok {
+			names = append(names, strings.TrimSuffix(name, "."))
+		}
+	}
+	return names
+}
+
+// queryDNSSEC 发送设置了 DO 标志的查询，UDP 应答被截断时使用 TCP 重试
+func (s *SubdomainScanner) queryDNSSEC(ctx context.Context, server string, name string, qType uint16) (*dns.Msg, error) {
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(name), qType)
+	msg.SetEdns0(4096, true)
+
+	server = utils.ToNsServer(server)
+	rsp, _, err := s.dnsClient.ExchangeContext(ctx, msg, server)
+	if err == nil && rsp.Truncated {
+		client := &dns.Client{Net: "tcp", Timeout: s.dnsClient.Timeout}
+		rsp, _, err = client.ExchangeContext(ctx, msg, server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func findNSEC(rrs []dns.RR, owner string) *dns.NSEC {
+	for _, rr := range rrs {
+		if nsec, ok := rr.(*dns.NSEC); ok && strings.EqualFold(nsec.Hdr.Name, owner) {
+			return nsec
+		}
+	}
+	return nil
+}
+
+// walkNSEC 沿着 NSEC 链遍历区域中的所有域名
+func (s *SubdomainScanner) walkNSEC(ctx context.Context, server string, zone string) ([]string, error) {
+	var names []string
+	seen := map[string]struct{}{zone: {}}
+	current := zone
+	for s.config.ZoneWalkMaxNames <= 0 || len(names) < s.config.ZoneWalkMaxNames {
+		if ctx.Err() != nil {
+			break
+		}
+		rsp, err := s.queryDNSSEC(ctx, server, current, dns.TypeNSEC)
+		if err != nil {
+			return names, err
+		}
+		nsec := findNSEC(rsp.Answer, current)
+		if nsec == nil {
+			nsec = findNSEC(rsp.Ns, current)
+		}
+		if nsec == nil {
+			if current == zone {
+				return nil, utils.Errorf("no nsec record for %s from %s", zone, server)
+			}
+			s.logger.Infof("nsec chain of %s broken at %s", zone, current)
+			break
+		}
+
+		next := strings.ToLower(nsec.NextDomain)
+		// 在线签名（例如 black lies）返回的 NSEC 只覆盖当前域名，无法继续遍历
+		if strings.HasPrefix(next, "\\000.") {
+			s.logger.Infof("nsec of %s is signed online, stop walking at %s", zone, current)
+			break
+		}
+		if _, ok := seen[next]; ok || !dns.IsSubDomain(zone, next) {
+			break
+		}
+		seen[next] = struct{}{}
+		if !strings.Contains(next, "\\") && !strings.HasPrefix(next, "*.") {
+			names = append(names, strings.TrimSuffix(next, "."))
+		}
+		current = next
+	}
+	return names, nil
+}
+
+// collectNSEC3 通过查询随机的不存在的域名收集 NSEC3 哈希
+func (s *SubdomainScanner) collectNSEC3(ctx context.Context, server string, zone string) *nsec3Chain {
+	chain := newNSEC3Chain()
+	for i := 0; i < s.config.NSEC3MaxQueries && !chain.complete(); i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		rsp, err := s.queryDNSSEC(ctx, server, fmt.Sprintf("%s.%s", utils.RandStringBytes(12), zone), dns.TypeA)
+		if err != nil {
+			s.logger.Debugf("query nsec3 of %s from %s failed: %s", zone, server, err)
+			continue
+		}
+		for _, rr := range rsp.Ns {
+			if nsec3, ok := rr.(*dns.NSEC3); ok {
+				chain.add(nsec3, zone)
+			}
+		}
+	}
+	return chain
+}
+
+// zoneWalkWithServer 根据否定应答中的记录类型选择 NSEC 遍历或者 NSEC3 破解
+func (s *SubdomainScanner) zoneWalkWithServer(ctx context.Context, server string, zone string) ([]string, string, error) {
+	rsp, err := s.queryDNSSEC(ctx, server, fmt.Sprintf("%s.%s", utils.RandStringBytes(12), zone), dns.TypeA)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, rr := range rsp.Ns {
+		switch rr.(type) {
+		case *dns.NSEC:
+			s.logger.Infof("start to walk nsec chain of %s from %s", zone, server)
+			names, err := s.walkNSEC(ctx, server, zone)
+			return names, "nsec-walk-from-" + server, err
+		case *dns.NSEC3:
+			chain := s.collectNSEC3(ctx, server, zone)
+			s.logger.Infof("collected %v nsec3 hashes of %s from %s (complete: %v), start to crack", len(chain.next), zone, server, chain.complete())
+			return crackNSEC3(ctx, chain, zone, s.config.MainDictionary), "nsec3-crack-from-" + server, nil
+		}
+	}
+	return nil, "", utils.Errorf("%s does not respond nsec/nsec3 records for %s", server, zone)
+}
+
+// ZoneWalk 通过 DNSSEC 的 NSEC / NSEC3 记录枚举区域中的域名，找到的域名会被解析之后报告
+func (s *SubdomainScanner) ZoneWalk(ctx context.Context, target string) {
+	target = strings.ToLower(formatDomain(target))
+	zone := dns.Fqdn(target)
+
+	queryNsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ns, err := queryNs(s.dnsClient, queryNsCtx, target, s.config.DNSServers)
+	cancel()
+	if err != nil {
+		s.logger.Debugf("query nameserver of %s failed: %s", target, err)
+	}
+
+	for _, server := range append(ns, s.config.DNSServers...) {
+		if ctx.Err() != nil {
+			return
+		}
+		names, tag, err := s.zoneWalkWithServer(ctx, server, zone)
+		if err != nil {
+			s.logger.Debugf("zone walking %s failed: %s", target, err)
+			continue
+		}
+		if len(names) <= 0 {
+			continue
+		}
+
+		s.logger.Infof("zone walking found %v names of %s from %s", len(names), target, server)
+		var results []*SubdomainResult
+		for _, name := range names {
+			results = append(results, &SubdomainResult{
+				FromTarget:  target,
+				FromModeRaw: ZONE_WALK,
+				Domain:      name,
+				Tags:        []string{tag},
+			})
+		}
+		s.resolveResults(ctx, results)
+		return
+	}
+}
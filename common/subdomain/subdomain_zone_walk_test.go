@@ -0,0 +1,152 @@
+package subdomain
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestSignedZoneServer 模拟两个签名区域：example.com 使用 NSEC，example.org 使用 NSEC3
+func startTestSignedZoneServer(t *testing.T) string {
+	records := map[string]string{
+		"www.example.com.": "10.0.0.1",
+		"a.example.com.":   "10.0.0.2",
+		"www.example.org.": "10.0.0.3",
+	}
+	chain := []string{"example.com.", "a.example.com.", "mail.example.com.", "www.example.com."}
+
+	const salt, iterations = "ABCD", 2
+	var nsec3 []dns.RR
+	var hashes []string
+	for _, name := range []string{"example.org.", "www.example.org.", "ftp.example.org.", "h4rd-t0-gue55.example.org."} {
+		hashes = append(hashes, dns.HashName(name, dns.SHA1, iterations, salt))
+	}
+	sort.Strings(hashes)
+	for i, hash := range hashes {
+		nsec3 = append(nsec3, &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: hash + ".example.org.", Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 60},
+			Hash:       dns.SHA1,
+			Iterations: iterations,
+			SaltLength: 2,
+			Salt:       salt,
+			HashLength: 20,
+			NextDomain: hashes[(i+1)%len(hashes)],
+			TypeBitMap: []uint16{dns.TypeA},
+		})
+	}
+
+	handler := &testDomainServer{}
+	handler.AddHandler(func(w dns.ResponseWriter, r *dns.Msg) {
+		msg := &dns.Msg{}
+		msg.SetReply(r)
+		q := r.Question[0]
+		name := strings.ToLower(q.Name)
+		switch {
+		case strings.HasSuffix(name, "example.com."):
+			index := -1
+			for i, owner := range chain {
+				if owner == name {
+					index = i
+				}
+			}
+			if index < 0 {
+				msg.Rcode = dns.RcodeNameError
+				msg.Ns = append(msg.Ns, &dns.NSEC{
+					Hdr:        dns.RR_Header{Name: chain[0], Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 60},
+					NextDomain: chain[1],
+				})
+				break
+			}
+			if q.Qtype == dns.TypeNSEC {
+				msg.Answer = append(msg.Answer, &dns.NSEC{
+					Hdr:        dns.RR_Header{Name: name, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 60},
+					NextDomain: chain[(index+1)%len(chain)],
+				})
+			}
+		case strings.HasSuffix(name, "example.org.") && records[name] == "":
+			msg.Rcode = dns.RcodeNameError
+			msg.Ns = append(msg.Ns, nsec3...)
+		}
+		if ip := records[name]; ip != "" && q.Qtype == dns.TypeA {
+			msg.Answer = append(msg.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP(ip),
+			})
+		}
+		w.WriteMsg(msg)
+	})
+
+	addr := utils.HostPort("127.0.0.1", utils.GetRandomAvailableUDPPort())
+	started := make(chan struct{})
+	server := &dns.Server{Addr: addr, Net: "udp", Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ListenAndServe()
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("start dns server timeout")
+	}
+	t.Cleanup(func() { server.Shutdown() })
+	return addr
+}
+
+func TestZoneWalk(t *testing.T) {
+	resolver := startTestSignedZoneServer(t)
+
+	run := func(target string) ([]string, []string) {
+		scanner, err := NewSubdomainScanner(NewSubdomainScannerConfig(
+			WithModes(ZONE_WALK),
+			WithDNSServers([]string{resolver}),
+			WithMainDictionary([]byte("www\nftp\nadmin\n")),
+		), target)
+		require.NoError(t, err)
+
+		var lock sync.Mutex
+		var found, failed []string
+		scanner.OnResult(func(result *SubdomainResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			require.Equal(t, ZONE_WALK, result.FromModeRaw)
+			found = append(found, result.Domain+"="+result.IP)
+		})
+		scanner.OnResolveFailedResult(func(result *SubdomainResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			failed = append(failed, result.Domain)
+		})
+		require.NoError(t, scanner.Run())
+		sort.Strings(found)
+		sort.Strings(failed)
+		return found, failed
+	}
+
+	found, failed := run("example.com")
+	require.Equal(t, []string{"a.example.com=10.0.0.2", "www.example.com=10.0.0.1"}, found)
+	require.Equal(t, []string{"mail.example.com"}, failed)
+
+	found, failed = run("example.org")
+	require.Equal(t, []string{"www.example.org=10.0.0.3"}, found)
+	require.Equal(t, []string{"ftp.example.org"}, failed)
+}
+
+func TestNSEC3ChainComplete(t *testing.T) {
+	chain := newNSEC3Chain()
+	add := func(owner, next string) {
+		chain.add(&dns.NSEC3{Hdr: dns.RR_Header{Name: owner + ".example.org."}, NextDomain: next}, "example.org.")
+	}
+	add("aaaa", "bbbb")
+	add("bbbb", "cccc")
+	require.False(t, chain.complete())
+	add("cccc", "aaaa")
+	require.True(t, chain.complete())
+	require.Len(t, chain.hashes(), 3)
+	// 其他区域的记录不收集
+	add("dddd.sub", "aaaa")
+	require.Len(t, chain.next, 3)
+}
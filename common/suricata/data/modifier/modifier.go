@@ -55,6 +55,22 @@ const (
 	// ICMP
 	ICMPV4HDR
 	ICMPV6HDR
+
+	// TLS
+	TLSSNI
+	TLSCertSubject
+	TLSCertIssuer
+	TLSVersion
+	JA3Hash
+	JA3String
+	JA3SHash
+	JA3SString
+
+	// SMB
+	SMBShare
+	SMBNamedPipe
+	SMBNTLMSSPUser
+	SMBNTLMSSPDomain
 )
 
 var HTTP_REQ_ONLY = []Modifier{
@@ -84,3 +100,11 @@ var HTTP_RESP_ONLY = []Modifier{
 func IsHTTPModifier(mdf Modifier) bool {
 	return mdf >= HTTPUri && mdf <= HTTPHeaderNames
 }
+
+func IsTLSModifier(mdf Modifier) bool {
+	return mdf >= TLSSNI && mdf <= JA3SString
+}
+
+func IsSMBModifier(mdf Modifier) bool {
+	return mdf >= SMBShare && mdf <= SMBNTLMSSPDomain
+}
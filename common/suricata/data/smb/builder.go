@@ -0,0 +1,130 @@
+package smb
+
+import (
+	"encoding/binary"
+)
+
+// Header 用于构造 SMB2 请求头
+type Header struct {
+	Command   uint16
+	MessageID uint64
+	TreeID    uint32
+	SessionID uint64
+}
+
+func (h *Header) bytes() []byte {
+	buf := make([]byte, headerSize)
+	copy(buf, smb2Magic)
+	binary.LittleEndian.PutUint16(buf[4:6], headerSize)
+	binary.LittleEndian.PutUint16(buf[6:8], 1) // credit charge
+	binary.LittleEndian.PutUint16(buf[12:14], h.Command)
+	binary.LittleEndian.PutUint16(buf[14:16], 31) // credits requested
+	binary.LittleEndian.PutUint64(buf[24:32], h.MessageID)
+	binary.LittleEndian.PutUint32(buf[32:36], 0xfeff) // process id
+	binary.LittleEndian.PutUint32(buf[36:40], h.TreeID)
+	binary.LittleEndian.PutUint64(buf[40:48], h.SessionID)
+	return buf
+}
+
+// WrapNetBIOS 添加 NetBIOS 会话头，多个消息会放在同一个载荷中
+func WrapNetBIOS(msgs ...[]byte) []byte {
+	var ret []byte
+	for _, msg := range msgs {
+		l := len(msg)
+		ret = append(ret, 0x00, byte(l>>16), byte(l>>8), byte(l))
+		ret = append(ret, msg...)
+	}
+	return ret
+}
+
+// BuildNegotiateRequest 构造 NEGOTIATE 请求，默认协商 2.0.2 - 3.1.1
+func BuildNegotiateRequest(h *Header, dialects ...uint16) []byte {
+	if len(dialects) == 0 {
+		dialects = []uint16{0x0202, 0x0210, 0x0300, 0x0302, 0x0311}
+	}
+	h.Command = CommandNegotiate
+	body := make([]byte, 36)
+	binary.LittleEndian.PutUint16(body[0:2], 36)
+	binary.LittleEndian.PutUint16(body[2:4], uint16(len(dialects)))
+	binary.LittleEndian.PutUint16(body[4:6], 0x01) // signing enabled
+	binary.LittleEndian.PutUint32(body[8:12], 0x7f)
+	for _, d := range dialects {
+		body = binary.LittleEndian.AppendUint16(body, d)
+	}
+	return append(h.bytes(), body...)
+}
+
+// BuildSessionSetupRequest 构造 SESSION_SETUP 请求，securityBlob 通常为 NTLMSSP 消息
+func BuildSessionSetupRequest(h *Header, securityBlob []byte) []byte {
+	h.Command = CommandSessionSetup
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[0:2], 25)
+	body[3] = 0x01 // signing enabled
+	binary.LittleEndian.PutUint16(body[12:14], headerSize+24)
+	binary.LittleEndian.PutUint16(body[14:16], uint16(len(securityBlob)))
+	body = append(body, securityBlob...)
+	return append(h.bytes(), body...)
+}
+
+// BuildTreeConnectRequest 构造 TREE_CONNECT 请求，path 例如 \\10.0.0.1\IPC$
+func BuildTreeConnectRequest(h *Header, path string) []byte {
+	h.Command = CommandTreeConnect
+	p := encodeUTF16(path)
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:2], 9)
+	binary.LittleEndian.PutUint16(body[4:6], headerSize+8)
+	binary.LittleEndian.PutUint16(body[6:8], uint16(len(p)))
+	body = append(body, p...)
+	return append(h.bytes(), body...)
+}
+
+// BuildCreateRequest 构造 CREATE 请求，在 IPC$ 上打开命名管道时 name 为管道名，例如 srvsvc
+func BuildCreateRequest(h *Header, name string) []byte {
+	h.Command = CommandCreate
+	n := encodeUTF16(name)
+	body := make([]byte, 56)
+	binary.LittleEndian.PutUint16(body[0:2], 57)
+	binary.LittleEndian.PutUint32(body[4:8], 2)               // impersonation level
+	binary.LittleEndian.PutUint32(body[24:28], 0x0012019f)    // desired access
+	binary.LittleEndian.PutUint32(body[32:36], 0x00000007)    // share access
+	binary.LittleEndian.PutUint32(body[36:40], 0x00000001)    // create disposition: open
+	binary.LittleEndian.PutUint32(body[40:44], 0x00000040)    // create options: non directory
+	binary.LittleEndian.PutUint16(body[44:46], headerSize+56) // name offset
+	binary.LittleEndian.PutUint16(body[46:48], uint16(len(n)))
+	body = append(body, n...)
+	if len(n) == 0 {
+		// 文件名为空时 buffer 至少需要一个字节
+		body = append(body, 0)
+	}
+	return append(h.bytes(), body...)
+}
+
+// BuildNTLMSSPAuth 构造 NTLMSSP AUTHENTICATE 消息(unicode)，challenge response 为随机数据
+func BuildNTLMSSPAuth(domain, user, workstation string, ntResponse []byte) []byte {
+	fields := [][]byte{
+		make([]byte, 24), // LmChallengeResponse
+		ntResponse,
+		encodeUTF16(domain),
+		encodeUTF16(user),
+		encodeUTF16(workstation),
+		nil, // EncryptedRandomSessionKey
+	}
+	const fixedSize = 64
+	msg := make([]byte, fixedSize)
+	copy(msg, ntlmssp)
+	binary.LittleEndian.PutUint32(msg[8:12], 3)
+	offset := fixedSize
+	for i, f := range fields {
+		pos := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[pos:pos+2], uint16(len(f)))
+		binary.LittleEndian.PutUint16(msg[pos+2:pos+4], uint16(len(f)))
+		binary.LittleEndian.PutUint32(msg[pos+4:pos+8], uint32(offset))
+		offset += len(f)
+	}
+	// NEGOTIATE_UNICODE | REQUEST_TARGET | NEGOTIATE_NTLM | ALWAYS_SIGN | EXTENDED_SESSIONSECURITY
+	binary.LittleEndian.PutUint32(msg[60:64], 0x00088205)
+	for _, f := range fields {
+		msg = append(msg, f...)
+	}
+	return msg
+}
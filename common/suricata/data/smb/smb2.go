@@ -0,0 +1,176 @@
+package smb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// SMB2 命令，只包括规则匹配和流量生成用到的部分
+const (
+	CommandNegotiate    uint16 = 0x0000
+	CommandSessionSetup uint16 = 0x0001
+	CommandTreeConnect  uint16 = 0x0003
+	CommandCreate       uint16 = 0x0005
+)
+
+const headerSize = 64
+
+var (
+	smb2Magic = []byte{0xfe, 'S', 'M', 'B'}
+	smb1Magic = []byte{0xff, 'S', 'M', 'B'}
+	ntlmssp   = []byte("NTLMSSP\x00")
+)
+
+// Message 是一个 SMB2 消息，Raw 从 SMB2 头开始，消息内的偏移都相对于 Raw
+type Message struct {
+	Command    uint16
+	IsResponse bool
+	MessageID  uint64
+	TreeID     uint32
+	SessionID  uint64
+	Raw        []byte
+	Body       []byte
+}
+
+// IsSMBPayload 判断 TCP 载荷是否为 NetBIOS 会话封装的 SMB1 / SMB2 消息
+func IsSMBPayload(payload []byte) bool {
+	if len(payload) < 8 || payload[0] != 0x00 {
+		return false
+	}
+	return bytes.Equal(payload[4:8], smb2Magic) || bytes.Equal(payload[4:8], smb1Magic)
+}
+
+// ParseMessages 解析 TCP 载荷中所有完整的 SMB2 消息，支持一个载荷中有多个 NetBIOS 帧以及复合(compound)消息
+func ParseMessages(payload []byte) []*Message {
+	var msgs []*Message
+	for len(payload) >= 4 && payload[0] == 0x00 {
+		length := int(payload[1])<<16 | int(payload[2])<<8 | int(payload[3])
+		if 4+length > len(payload) {
+			break
+		}
+		frame := payload[4 : 4+length]
+		payload = payload[4+length:]
+		for len(frame) >= headerSize && bytes.Equal(frame[:4], smb2Magic) {
+			next := int(binary.LittleEndian.Uint32(frame[20:24]))
+			raw := frame
+			if next > 0 && next <= len(frame) {
+				raw = frame[:next]
+			}
+			msgs = append(msgs, &Message{
+				Command:    binary.LittleEndian.Uint16(frame[12:14]),
+				IsResponse: binary.LittleEndian.Uint32(frame[16:20])&0x01 != 0,
+				MessageID:  binary.LittleEndian.Uint64(frame[24:32]),
+				TreeID:     binary.LittleEndian.Uint32(frame[36:40]),
+				SessionID:  binary.LittleEndian.Uint64(frame[40:48]),
+				Raw:        raw,
+				Body:       raw[headerSize:],
+			})
+			if next <= 0 || next >= len(frame) {
+				break
+			}
+			frame = frame[next:]
+		}
+	}
+	return msgs
+}
+
+func (m *Message) slice(offset, length int) []byte {
+	if offset < 0 || length <= 0 || offset+length > len(m.Raw) {
+		return nil
+	}
+	return m.Raw[offset : offset+length]
+}
+
+// TreeConnectPath 返回 TREE_CONNECT 请求中的共享路径，例如 \\10.0.0.1\IPC$
+func (m *Message) TreeConnectPath() string {
+	if m.Command != CommandTreeConnect || m.IsResponse || len(m.Body) < 8 {
+		return ""
+	}
+	offset := int(binary.LittleEndian.Uint16(m.Body[4:6]))
+	length := int(binary.LittleEndian.Uint16(m.Body[6:8]))
+	return decodeUTF16(m.slice(offset, length))
+}
+
+// CreateName 返回 CREATE 请求中的文件名，在 IPC$ 上就是命名管道名
+func (m *Message) CreateName() string {
+	if m.Command != CommandCreate || m.IsResponse || len(m.Body) < 48 {
+		return ""
+	}
+	offset := int(binary.LittleEndian.Uint16(m.Body[44:46]))
+	length := int(binary.LittleEndian.Uint16(m.Body[46:48]))
+	return decodeUTF16(m.slice(offset, length))
+}
+
+// SecurityBuffer 返回 SESSION_SETUP 请求或响应中的安全数据(GSS-API / NTLMSSP)
+func (m *Message) SecurityBuffer() []byte {
+	if m.Command != CommandSessionSetup {
+		return nil
+	}
+	if m.IsResponse {
+		if len(m.Body) < 8 {
+			return nil
+		}
+		return m.slice(int(binary.LittleEndian.Uint16(m.Body[4:6])), int(binary.LittleEndian.Uint16(m.Body[6:8])))
+	}
+	if len(m.Body) < 16 {
+		return nil
+	}
+	return m.slice(int(binary.LittleEndian.Uint16(m.Body[12:14])), int(binary.LittleEndian.Uint16(m.Body[14:16])))
+}
+
+// NTLMSSPAuth 是 NTLMSSP AUTHENTICATE(type 3) 消息中的身份信息
+type NTLMSSPAuth struct {
+	Domain      string
+	User        string
+	Workstation string
+}
+
+// ParseNTLMSSPAuth 在安全数据中查找 NTLMSSP AUTHENTICATE 消息，SPNEGO 封装的也可以找到
+func ParseNTLMSSPAuth(buf []byte) (*NTLMSSPAuth, bool) {
+	idx := bytes.Index(buf, ntlmssp)
+	if idx < 0 {
+		return nil, false
+	}
+	msg := buf[idx:]
+	if len(msg) < 64 || binary.LittleEndian.Uint32(msg[8:12]) != 3 {
+		return nil, false
+	}
+	unicode := binary.LittleEndian.Uint32(msg[60:64])&0x01 != 0
+	field := func(pos int) string {
+		length := int(binary.LittleEndian.Uint16(msg[pos : pos+2]))
+		offset := int(binary.LittleEndian.Uint32(msg[pos+4 : pos+8]))
+		if length == 0 || offset+length > len(msg) {
+			return ""
+		}
+		if unicode {
+			return decodeUTF16(msg[offset : offset+length])
+		}
+		return string(msg[offset : offset+length])
+	}
+	return &NTLMSSPAuth{
+		Domain:      field(28),
+		User:        field(36),
+		Workstation: field(44),
+	}, true
+}
+
+func decodeUTF16(b []byte) string {
+	if len(b) < 2 {
+		return ""
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func encodeUTF16(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, len(u)*2)
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[i*2:], c)
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package eve
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"strconv"
+
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+)
+
+// IP 协议号
+const (
+	ProtoICMP   = 1
+	ProtoTCP    = 6
+	ProtoUDP    = 17
+	ProtoICMPv6 = 58
+)
+
+// icmp 请求和应答的类型对应关系，用于把一次 ping 的两个方向算作同一个 community id
+var (
+	icmpPairs = map[int]int{
+		8: 0, 0: 8, 13: 14, 14: 13, 15: 16, 16: 15, 10: 9, 9: 10, 17: 18, 18: 17,
+	}
+	icmpv6Pairs = map[int]int{
+		128: 129, 129: 128, 133: 134, 134: 133, 135: 136, 136: 135, 130: 131, 131: 130,
+		139: 140, 140: 139, 144: 145, 145: 144,
+	}
+)
+
+// CommunityID 按照 Community ID v1 规范计算流标识，与 suricata / zeek 的 community_id 一致
+// ICMP 的 srcPort / dstPort 分别为 type / code
+func CommunityID(srcIP, dstIP net.IP, srcPort, dstPort int, proto uint8, seed uint16) string {
+	if v4 := srcIP.To4(); v4 != nil {
+		srcIP = v4
+	}
+	if v4 := dstIP.To4(); v4 != nil {
+		dstIP = v4
+	}
+
+	oneWay := false
+	switch proto {
+	case ProtoICMP, ProtoICMPv6:
+		pairs := icmpPairs
+		if proto == ProtoICMPv6 {
+			pairs = icmpv6Pairs
+		}
+		if peer, ok := pairs[srcPort]; ok {
+			dstPort = peer
+		} else {
+			oneWay = true
+		}
+	}
+
+	if !oneWay {
+		cmp := bytes.Compare(srcIP, dstIP)
+		if cmp > 0 || (cmp == 0 && srcPort > dstPort) {
+			srcIP, dstIP = dstIP, srcIP
+			srcPort, dstPort = dstPort, srcPort
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, seed)
+	buf.Write(srcIP)
+	buf.Write(dstIP)
+	buf.WriteByte(proto)
+	buf.WriteByte(0)
+	switch proto {
+	case ProtoICMP, ProtoICMPv6, ProtoTCP, ProtoUDP, 132:
+		_ = binary.Write(&buf, binary.BigEndian, uint16(srcPort))
+		_ = binary.Write(&buf, binary.BigEndian, uint16(dstPort))
+	}
+	sum := sha1.Sum(buf.Bytes())
+	return "1:" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// FlowID 返回流的 flow_id，两个方向的包得到相同的值
+// 结果不超过 2^53，保证在 JSON 的 number 中不会丢失精度
+func FlowID(proto string, srcIP string, srcPort int, dstIP string, dstPort int) uint64 {
+	hash := pcaputil.FlowHash(
+		proto,
+		net.JoinHostPort(srcIP, strconv.Itoa(srcPort)),
+		net.JoinHostPort(dstIP, strconv.Itoa(dstPort)),
+	)
+	id, _ := strconv.ParseUint(hash[:13], 16, 64)
+	return id
+}
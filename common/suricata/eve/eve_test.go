@@ -0,0 +1,198 @@
+package eve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func TestCommunityID(t *testing.T) {
+	// https://github.com/corelight/community-id-spec baseline
+	id := CommunityID(net.ParseIP("128.232.110.120"), net.ParseIP("66.35.250.204"), 34855, 80, ProtoTCP, 0)
+	require.Equal(t, "1:LQU9qZlK+B5F3KDmev6m5PMibrg=", id)
+	require.Equal(t, id, CommunityID(net.ParseIP("66.35.250.204"), net.ParseIP("128.232.110.120"), 80, 34855, ProtoTCP, 0))
+
+	require.Equal(t,
+		FlowID("TCP", "10.0.0.1", 40000, "10.0.0.2", 80),
+		FlowID("TCP", "10.0.0.2", 80, "10.0.0.1", 40000),
+	)
+}
+
+func tcpFrame(t *testing.T, src string, srcPort int, dst string, dstPort int, flags string, payload string) []byte {
+	opts := []any{
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP(src),
+		pcapx.WithIPv4_DstIP(dst),
+		pcapx.WithTCP_SrcPort(srcPort),
+		pcapx.WithTCP_DstPort(dstPort),
+		pcapx.WithTCP_Flags(flags),
+	}
+	if payload != "" {
+		opts = append(opts, pcapx.WithPayload([]byte(payload)))
+	}
+	raw, err := pcapx.PacketBuilder(opts...)
+	require.NoError(t, err)
+	return raw
+}
+
+func dnsFrame(t *testing.T, src string, srcPort int, dst string, dstPort int, dns *layers.DNS) []byte {
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, dns.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}))
+	raw, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP(src),
+		pcapx.WithIPv4_DstIP(dst),
+		pcapx.WithUDP_SrcPort(srcPort),
+		pcapx.WithUDP_DstPort(dstPort),
+		pcapx.WithPayload(buf.Bytes()),
+	)
+	require.NoError(t, err)
+	return raw
+}
+
+func writeTestPcap(t *testing.T) string {
+	question := layers.DNSQuestion{Name: []byte("eve.example.com"), Type: layers.DNSTypeA, Class: layers.DNSClassIN}
+	frames := [][]byte{
+		tcpFrame(t, "10.0.0.1", 40000, "10.0.0.2", 80, "syn", ""),
+		tcpFrame(t, "10.0.0.2", 80, "10.0.0.1", 40000, "syn|ack", ""),
+		tcpFrame(t, "10.0.0.1", 40000, "10.0.0.2", 80, "psh|ack", "GET /index.php?id=1 HTTP/1.1\r\nHost: eve.example.com\r\nUser-Agent: yak-eve-test\r\n\r\n"),
+		tcpFrame(t, "10.0.0.2", 80, "10.0.0.1", 40000, "psh|ack", "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nContent-Length: 5\r\n\r\nhello"),
+		tcpFrame(t, "10.0.0.1", 40000, "10.0.0.2", 80, "fin|ack", ""),
+		dnsFrame(t, "10.0.0.1", 50000, "10.0.0.53", 53, &layers.DNS{ID: 0x1234, RD: true, Questions: []layers.DNSQuestion{question}}),
+		dnsFrame(t, "10.0.0.53", 53, "10.0.0.1", 50000, &layers.DNS{
+			ID: 0x1234, QR: true, RD: true, RA: true,
+			Questions: []layers.DNSQuestion{question},
+			Answers: []layers.DNSResourceRecord{{
+				Name: []byte("eve.example.com"), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 300, IP: net.ParseIP("1.2.3.4"),
+			}},
+		}),
+	}
+
+	pcapFile := filepath.Join(t.TempDir(), "eve.pcap")
+	fp, err := os.Create(pcapFile)
+	require.NoError(t, err)
+	w := pcapgo.NewWriter(fp)
+	require.NoError(t, w.WriteFileHeader(65535, layers.LinkTypeEthernet))
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, frame := range frames {
+		require.NoError(t, w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     ts.Add(time.Duration(i) * 100 * time.Millisecond),
+			CaptureLength: len(frame),
+			Length:        len(frame),
+		}, frame))
+	}
+	fp.Close()
+	return pcapFile
+}
+
+func TestRunPcapFile(t *testing.T) {
+	pcapFile := writeTestPcap(t)
+	dir := filepath.Dir(pcapFile)
+
+	rules, err := rule.Parse(`alert http any any -> any any (msg:"eve test user agent"; flow:to_server; http.user_agent; content:"yak-eve-test"; classtype:trojan-activity; metadata:created_at 2024_01_01, tag test; sid:1000001; rev:2;)`)
+	require.NoError(t, err)
+
+	output := filepath.Join(dir, "eve.json")
+	require.NoError(t, Run(rules, WithPcapFile(pcapFile), WithOutputFile(output)))
+
+	out, err := os.Open(output)
+	require.NoError(t, err)
+	defer out.Close()
+	events := make(map[string][]*Event)
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		events[e.EventType] = append(events[e.EventType], &e)
+	}
+
+	httpFlowID := FlowID("TCP", "10.0.0.1", 40000, "10.0.0.2", 80)
+	communityID := CommunityID(net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), 40000, 80, ProtoTCP, 0)
+
+	require.Len(t, events[EventTypeAlert], 1)
+	alert := events[EventTypeAlert][0]
+	require.Equal(t, httpFlowID, alert.FlowID)
+	require.Equal(t, communityID, alert.CommunityID)
+	require.Equal(t, 1000001, alert.Alert.SignatureID)
+	require.Equal(t, 2, alert.Alert.Rev)
+	require.Equal(t, "eve test user agent", alert.Alert.Signature)
+	require.Equal(t, "trojan-activity", alert.Alert.Category)
+	require.Equal(t, []string{"2024_01_01"}, alert.Alert.Metadata["created_at"])
+	require.Equal(t, "2024-01-01T00:00:00.200000+0000", alert.Timestamp)
+
+	require.Len(t, events[EventTypeHTTP], 1)
+	h := events[EventTypeHTTP][0]
+	require.Equal(t, httpFlowID, h.FlowID)
+	require.Equal(t, "10.0.0.1", h.SrcIP)
+	require.Equal(t, 80, h.DestPort)
+	require.Equal(t, "eve.example.com", h.HTTP.Hostname)
+	require.Equal(t, "/index.php?id=1", h.HTTP.URL)
+	require.Equal(t, "GET", h.HTTP.HTTPMethod)
+	require.Equal(t, 200, h.HTTP.Status)
+	require.Equal(t, 5, h.HTTP.Length)
+	require.Equal(t, "text/html", h.HTTP.HTTPContentType)
+
+	require.Len(t, events[EventTypeDNS], 2)
+	require.Equal(t, "query", events[EventTypeDNS][0].DNS.Type)
+	answer := events[EventTypeDNS][1].DNS
+	require.Equal(t, "answer", answer.Type)
+	require.Equal(t, "NOERROR", answer.RCode)
+	require.Equal(t, "1.2.3.4", answer.Answers[0].RData)
+	require.Equal(t, events[EventTypeDNS][0].FlowID, events[EventTypeDNS][1].FlowID)
+
+	require.Len(t, events[EventTypeFlow], 2)
+	for _, e := range events[EventTypeFlow] {
+		if e.FlowID != httpFlowID {
+			continue
+		}
+		require.Equal(t, "http", e.AppProto)
+		require.Equal(t, 3, e.Flow.PktsToServer)
+		require.Equal(t, 2, e.Flow.PktsToClient)
+		require.Equal(t, "closed", e.Flow.State)
+		require.True(t, e.Flow.Alerted)
+		require.Equal(t, communityID, e.CommunityID)
+	}
+}
+
+func TestStreamContext(t *testing.T) {
+	pcapFile := writeTestPcap(t)
+
+	var types []string
+	for e := range Stream(context.Background(), nil, WithPcapFile(pcapFile)) {
+		types = append(types, e.EventType)
+	}
+	require.Contains(t, types, EventTypeHTTP)
+	require.Contains(t, types, EventTypeFlow)
+
+	// ctx 结束后不读取事件，channel 也会关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch := Stream(ctx, nil, WithPcapFile(pcapFile))
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("eve stream is not closed after context canceled")
+		}
+	}
+}
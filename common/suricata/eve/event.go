@@ -0,0 +1,112 @@
+package eve
+
+// 事件类型，与 suricata eve-log 的 event_type 一致
+const (
+	EventTypeAlert = "alert"
+	EventTypeFlow  = "flow"
+	EventTypeHTTP  = "http"
+	EventTypeDNS   = "dns"
+	EventTypeTLS   = "tls"
+)
+
+// TimestampFormat 是 suricata eve 中 timestamp 的格式
+const TimestampFormat = "2006-01-02T15:04:05.000000-0700"
+
+// Event 是一条 EVE JSON 记录，字段和 suricata 的输出保持一致，SIEM 中的解析规则可以直接复用
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	FlowID      uint64 `json:"flow_id"`
+	InIface     string `json:"in_iface,omitempty"`
+	EventType   string `json:"event_type"`
+	SrcIP       string `json:"src_ip"`
+	SrcPort     int    `json:"src_port,omitempty"`
+	DestIP      string `json:"dest_ip"`
+	DestPort    int    `json:"dest_port,omitempty"`
+	Proto       string `json:"proto"`
+	ICMPType    *int   `json:"icmp_type,omitempty"`
+	ICMPCode    *int   `json:"icmp_code,omitempty"`
+	CommunityID string `json:"community_id,omitempty"`
+	AppProto    string `json:"app_proto,omitempty"`
+	TxID        *int   `json:"tx_id,omitempty"`
+
+	Alert *Alert `json:"alert,omitempty"`
+	Flow  *Flow  `json:"flow,omitempty"`
+	HTTP  *HTTP  `json:"http,omitempty"`
+	DNS   *DNS   `json:"dns,omitempty"`
+	TLS   *TLS   `json:"tls,omitempty"`
+}
+
+type Alert struct {
+	Action      string              `json:"action"`
+	GID         int                 `json:"gid"`
+	SignatureID int                 `json:"signature_id"`
+	Rev         int                 `json:"rev"`
+	Signature   string              `json:"signature"`
+	Category    string              `json:"category"`
+	Severity    int                 `json:"severity"`
+	Metadata    map[string][]string `json:"metadata,omitempty"`
+}
+
+type Flow struct {
+	PktsToServer  int    `json:"pkts_toserver"`
+	PktsToClient  int    `json:"pkts_toclient"`
+	BytesToServer int    `json:"bytes_toserver"`
+	BytesToClient int    `json:"bytes_toclient"`
+	Start         string `json:"start"`
+	End           string `json:"end,omitempty"`
+	Age           int64  `json:"age"`
+	State         string `json:"state,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	Alerted       bool   `json:"alerted"`
+}
+
+type HTTP struct {
+	Hostname        string `json:"hostname,omitempty"`
+	URL             string `json:"url,omitempty"`
+	HTTPUserAgent   string `json:"http_user_agent,omitempty"`
+	HTTPContentType string `json:"http_content_type,omitempty"`
+	HTTPRefer       string `json:"http_refer,omitempty"`
+	HTTPMethod      string `json:"http_method,omitempty"`
+	Protocol        string `json:"protocol,omitempty"`
+	Status          int    `json:"status,omitempty"`
+	Length          int    `json:"length"`
+}
+
+type DNS struct {
+	Version int          `json:"version"`
+	Type    string       `json:"type"`
+	ID      int          `json:"id"`
+	Flags   string       `json:"flags,omitempty"`
+	QR      bool         `json:"qr,omitempty"`
+	RD      bool         `json:"rd,omitempty"`
+	RA      bool         `json:"ra,omitempty"`
+	RRName  string       `json:"rrname,omitempty"`
+	RRType  string       `json:"rrtype,omitempty"`
+	RCode   string       `json:"rcode,omitempty"`
+	Answers []*DNSAnswer `json:"answers,omitempty"`
+}
+
+type DNSAnswer struct {
+	RRName string `json:"rrname"`
+	RRType string `json:"rrtype"`
+	TTL    int    `json:"ttl"`
+	RData  string `json:"rdata,omitempty"`
+}
+
+type TLS struct {
+	Subject     string `json:"subject,omitempty"`
+	IssuerDN    string `json:"issuerdn,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	SNI         string `json:"sni,omitempty"`
+	Version     string `json:"version,omitempty"`
+	NotBefore   string `json:"notbefore,omitempty"`
+	NotAfter    string `json:"notafter,omitempty"`
+	JA3         *JA3   `json:"ja3,omitempty"`
+	JA3S        *JA3   `json:"ja3s,omitempty"`
+}
+
+type JA3 struct {
+	Hash   string `json:"hash"`
+	String string `json:"string"`
+}
@@ -0,0 +1,356 @@
+package eve
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// flowRecord 记录一个流的统计信息和应用层状态，initiator 为第一个包，决定了流的方向
+type flowRecord struct {
+	initiator *packetInfo
+	start     time.Time
+	last      time.Time
+
+	pktsToServer  int
+	pktsToClient  int
+	bytesToServer int
+	bytesToClient int
+	replied       bool
+	closed        bool
+	alerted       bool
+
+	appProto string
+
+	// http 请求等待响应后再输出，和 suricata 一样一个事务一条记录
+	txID        int
+	pendingHTTP *httpTx
+	lastHTTP    *httpTx
+
+	tls       *TLS
+	tlsLogged bool
+}
+
+type httpTx struct {
+	id     int
+	record *HTTP
+}
+
+func newFlowRecord(p *packetInfo) *flowRecord {
+	initiator := *p
+	// 只收到 SYN/ACK 时发起方是目的地址
+	if p.tcp != nil && p.tcp.SYN && p.tcp.ACK {
+		initiator.srcIP, initiator.dstIP = p.dstIP, p.srcIP
+		initiator.srcPort, initiator.dstPort = p.dstPort, p.srcPort
+	}
+	initiator.tcp, initiator.payload = nil, nil
+	return &flowRecord{initiator: &initiator, start: p.ts, last: p.ts}
+}
+
+// update 更新流的统计信息，返回数据包是否为发往服务器的方向
+func (f *flowRecord) update(p *packetInfo) bool {
+	toServer := p.srcIP.Equal(f.initiator.srcIP) && p.srcPort == f.initiator.srcPort
+	if p.protoNum == ProtoICMP || p.protoNum == ProtoICMPv6 {
+		toServer = p.srcIP.Equal(f.initiator.srcIP)
+	}
+	if toServer {
+		f.pktsToServer++
+		f.bytesToServer += p.length
+	} else {
+		f.pktsToClient++
+		f.bytesToClient += p.length
+		f.replied = true
+	}
+	if p.ts.After(f.last) {
+		f.last = p.ts
+	}
+	if p.tcp != nil && (p.tcp.RST || p.tcp.FIN) {
+		f.closed = true
+	}
+	return toServer
+}
+
+func (f *flowRecord) record(reason string) *Flow {
+	state := "new"
+	if f.replied {
+		state = "established"
+	}
+	if f.closed {
+		state = "closed"
+	}
+	return &Flow{
+		PktsToServer:  f.pktsToServer,
+		PktsToClient:  f.pktsToClient,
+		BytesToServer: f.bytesToServer,
+		BytesToClient: f.bytesToClient,
+		Start:         f.start.Format(TimestampFormat),
+		End:           f.last.Format(TimestampFormat),
+		Age:           int64(f.last.Sub(f.start).Seconds()),
+		State:         state,
+		Reason:        reason,
+		Alerted:       f.alerted,
+	}
+}
+
+func (l *Logger) handleTCPPayload(f *flowRecord, p *packetInfo, toServer bool) {
+	payload := p.payload
+	switch {
+	case payload[0] == 0x16 && len(payload) > 5:
+		l.handleTLS(f, p)
+	case toServer && isHTTPRequest(payload):
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+		if err != nil {
+			return
+		}
+		if f.pendingHTTP != nil {
+			l.emitHTTP(f, f.pendingHTTP, p.ts)
+		}
+		f.appProto = "http"
+		f.pendingHTTP = &httpTx{
+			id: f.txID,
+			record: &HTTP{
+				Hostname:      req.Host,
+				URL:           req.RequestURI,
+				HTTPUserAgent: req.UserAgent(),
+				HTTPRefer:     req.Referer(),
+				HTTPMethod:    req.Method,
+				Protocol:      req.Proto,
+			},
+		}
+		f.lastHTTP = f.pendingHTTP
+		f.txID++
+	case !toServer && bytes.HasPrefix(payload, []byte("HTTP/")):
+		tx := f.pendingHTTP
+		if tx == nil {
+			return
+		}
+		rsp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(payload)), nil)
+		if err != nil {
+			return
+		}
+		tx.record.Status = rsp.StatusCode
+		tx.record.HTTPContentType = strings.TrimSpace(strings.Split(rsp.Header.Get("Content-Type"), ";")[0])
+		if rsp.ContentLength >= 0 {
+			tx.record.Length = int(rsp.ContentLength)
+		} else {
+			body, _ := io.ReadAll(rsp.Body)
+			tx.record.Length = len(body)
+		}
+		rsp.Body.Close()
+		l.emitHTTP(f, tx, p.ts)
+		f.pendingHTTP = nil
+	}
+}
+
+var httpMethods = []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "CONNECT", "TRACE", "PROPFIND"}
+
+func isHTTPRequest(payload []byte) bool {
+	line, _, _ := bytes.Cut(payload, []byte("\r\n"))
+	if !bytes.Contains(line, []byte(" HTTP/")) {
+		return false
+	}
+	for _, m := range httpMethods {
+		if bytes.HasPrefix(line, []byte(m+" ")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logger) emitHTTP(f *flowRecord, tx *httpTx, ts time.Time) {
+	e := l.event(EventTypeHTTP, ts, f.initiator)
+	e.AppProto = f.appProto
+	id := tx.id
+	e.TxID = &id
+	e.HTTP = tx.record
+	l.emit(e)
+}
+
+func (l *Logger) handleTLS(f *flowRecord, p *packetInfo) {
+	msgs := tlsutils.ParseHandshakeMessages(p.payload)
+	if len(msgs) == 0 {
+		return
+	}
+	f.appProto = "tls"
+	if f.tls == nil {
+		f.tls = &TLS{}
+	}
+	serverHelloSeen := false
+	for _, msg := range msgs {
+		switch msg.Type {
+		case tlsutils.HandshakeTypeClientHello:
+			hello, err := tlsutils.ParseClientHello(msg.Raw)
+			if err != nil {
+				continue
+			}
+			f.tls.SNI = hello.SNI()
+			ja3 := hello.JA3String()
+			f.tls.JA3 = &JA3{Hash: codec.Md5(ja3), String: ja3}
+		case tlsutils.HandshakeTypeServerHello:
+			hello, err := tlsutils.ParseServerHello(msg.Raw)
+			if err != nil {
+				continue
+			}
+			serverHelloSeen = true
+			f.tls.Version = tlsVersionString(hello.SelectedVersion())
+			ja3s := hello.JA3SString()
+			f.tls.JA3S = &JA3{Hash: codec.Md5(ja3s), String: ja3s}
+		}
+	}
+	if certs, err := tlsutils.ParseHandshakeCertificates(p.payload); err == nil && len(certs) > 0 {
+		cert := certs[0]
+		f.tls.Subject = tlsutils.FormatDN(cert.Subject)
+		f.tls.IssuerDN = tlsutils.FormatDN(cert.Issuer)
+		f.tls.Serial = colonHex(cert.SerialNumber.Bytes(), true)
+		sum := sha1.Sum(cert.Raw)
+		f.tls.Fingerprint = colonHex(sum[:], false)
+		f.tls.NotBefore = cert.NotBefore.UTC().Format("2006-01-02T15:04:05")
+		f.tls.NotAfter = cert.NotAfter.UTC().Format("2006-01-02T15:04:05")
+	}
+	if serverHelloSeen && !f.tlsLogged {
+		l.emitTLS(f, p.ts)
+	}
+}
+
+func (l *Logger) emitTLS(f *flowRecord, ts time.Time) {
+	f.tlsLogged = true
+	e := l.event(EventTypeTLS, ts, f.initiator)
+	e.AppProto = f.appProto
+	e.TLS = f.tls
+	l.emit(e)
+}
+
+func tlsVersionString(v uint16) string {
+	switch v {
+	case 0x0300:
+		return "SSLv3"
+	case 0x0301, 0x0302, 0x0303, 0x0304:
+		return "TLS " + tlsutils.TLSVersionName(v)
+	}
+	return "UNDETERMINED"
+}
+
+func colonHex(b []byte, upper bool) string {
+	items := make([]string, len(b))
+	for i, c := range b {
+		if upper {
+			items[i] = fmt.Sprintf("%02X", c)
+		} else {
+			items[i] = fmt.Sprintf("%02x", c)
+		}
+	}
+	return strings.Join(items, ":")
+}
+
+func dnsRecord(dns *layers.DNS) *DNS {
+	d := &DNS{
+		Version: 2,
+		ID:      int(dns.ID),
+		QR:      dns.QR,
+		RD:      dns.RD,
+		RA:      dns.RA,
+	}
+	if len(dns.Questions) > 0 {
+		d.RRName = string(dns.Questions[0].Name)
+		d.RRType = dns.Questions[0].Type.String()
+	}
+	if !dns.QR {
+		d.Type = "query"
+		return d
+	}
+	d.Type = "answer"
+	d.Flags = fmt.Sprintf("%x", dnsFlags(dns))
+	d.RCode = dnsRCodeName(dns.ResponseCode)
+	for _, rr := range dns.Answers {
+		d.Answers = append(d.Answers, &DNSAnswer{
+			RRName: string(rr.Name),
+			RRType: rr.Type.String(),
+			TTL:    int(rr.TTL),
+			RData:  dnsRData(rr),
+		})
+	}
+	return d
+}
+
+func dnsFlags(dns *layers.DNS) uint16 {
+	var flags uint16
+	if dns.QR {
+		flags |= 1 << 15
+	}
+	flags |= uint16(dns.OpCode&0x0f) << 11
+	if dns.AA {
+		flags |= 1 << 10
+	}
+	if dns.TC {
+		flags |= 1 << 9
+	}
+	if dns.RD {
+		flags |= 1 << 8
+	}
+	if dns.RA {
+		flags |= 1 << 7
+	}
+	flags |= uint16(dns.ResponseCode & 0x0f)
+	return flags
+}
+
+func dnsRCodeName(code layers.DNSResponseCode) string {
+	switch code {
+	case layers.DNSResponseCodeNoErr:
+		return "NOERROR"
+	case layers.DNSResponseCodeFormErr:
+		return "FORMERR"
+	case layers.DNSResponseCodeServFail:
+		return "SERVFAIL"
+	case layers.DNSResponseCodeNXDomain:
+		return "NXDOMAIN"
+	case layers.DNSResponseCodeNotImp:
+		return "NOTIMP"
+	case layers.DNSResponseCodeRefused:
+		return "REFUSED"
+	}
+	return strconv.Itoa(int(code))
+}
+
+func dnsRData(rr layers.DNSResourceRecord) string {
+	switch rr.Type {
+	case layers.DNSTypeA, layers.DNSTypeAAAA:
+		return rr.IP.String()
+	case layers.DNSTypeCNAME:
+		return string(rr.CNAME)
+	case layers.DNSTypeNS:
+		return string(rr.NS)
+	case layers.DNSTypePTR:
+		return string(rr.PTR)
+	case layers.DNSTypeMX:
+		return string(rr.MX.Name)
+	case layers.DNSTypeTXT:
+		var txts []string
+		for _, t := range rr.TXTs {
+			txts = append(txts, string(t))
+		}
+		return strings.Join(txts, "")
+	}
+	return ""
+}
+
+func sortedPair(a, b string) []string {
+	pair := []string{a, b}
+	sort.Strings(pair)
+	return pair
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,369 @@
+package eve
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Logger 把数据包和规则匹配结果转换为 suricata EVE JSON
+//
+// Packet 需要按顺序喂入所有的包，用于统计流信息和解析 http / dns / tls 元数据，
+// Alert 在规则匹配时调用，Close 会输出还没有结束的流
+type Logger struct {
+	lock sync.Mutex
+
+	out       io.Writer
+	callbacks []func(*Event)
+	types     map[string]bool
+	seed      uint16
+	timeout   time.Duration
+
+	flows     map[string]*flowRecord
+	lastSweep time.Time
+	closed    bool
+}
+
+type LoggerOption func(l *Logger)
+
+// WithWriter 每个事件作为一行 JSON 写入 w
+func WithWriter(w io.Writer) LoggerOption {
+	return func(l *Logger) {
+		l.out = w
+	}
+}
+
+// WithEventCallback 每个事件都会调用 cb
+func WithEventCallback(cb func(*Event)) LoggerOption {
+	return func(l *Logger) {
+		if cb != nil {
+			l.callbacks = append(l.callbacks, cb)
+		}
+	}
+}
+
+// WithEventTypes 只输出指定类型的事件，默认输出 alert / flow / http / dns / tls
+func WithEventTypes(types ...string) LoggerOption {
+	return func(l *Logger) {
+		types = utils.StringArrayFilterEmpty(types)
+		if len(types) == 0 {
+			return
+		}
+		l.types = make(map[string]bool)
+		for _, t := range types {
+			l.types[strings.ToLower(strings.TrimSpace(t))] = true
+		}
+	}
+}
+
+// WithCommunityIDSeed 设置 community id 的 seed，需要与其他探针保持一致，默认为 0
+func WithCommunityIDSeed(seed uint16) LoggerOption {
+	return func(l *Logger) {
+		l.seed = seed
+	}
+}
+
+// WithFlowTimeout 设置流超时时间，超时的流会输出 flow 事件，默认 60s
+func WithFlowTimeout(timeout time.Duration) LoggerOption {
+	return func(l *Logger) {
+		if timeout > 0 {
+			l.timeout = timeout
+		}
+	}
+}
+
+func NewLogger(opts ...LoggerOption) *Logger {
+	l := &Logger{
+		types: map[string]bool{
+			EventTypeAlert: true,
+			EventTypeFlow:  true,
+			EventTypeHTTP:  true,
+			EventTypeDNS:   true,
+			EventTypeTLS:   true,
+		},
+		timeout: time.Minute,
+		flows:   make(map[string]*flowRecord),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// packetInfo 是从数据包中提取的五元组和载荷
+type packetInfo struct {
+	ts       time.Time
+	srcIP    net.IP
+	dstIP    net.IP
+	srcPort  int
+	dstPort  int
+	proto    string
+	protoNum uint8
+	icmpType int
+	icmpCode int
+	tcp      *layers.TCP
+	payload  []byte
+	length   int
+}
+
+func parsePacket(pk gopacket.Packet) (*packetInfo, bool) {
+	if pk == nil || pk.NetworkLayer() == nil {
+		return nil, false
+	}
+	info := &packetInfo{length: len(pk.Data())}
+	if md := pk.Metadata(); md != nil && !md.Timestamp.IsZero() {
+		info.ts = md.Timestamp
+	} else {
+		info.ts = time.Now()
+	}
+	switch ip := pk.NetworkLayer().(type) {
+	case *layers.IPv4:
+		info.srcIP, info.dstIP = ip.SrcIP, ip.DstIP
+	case *layers.IPv6:
+		info.srcIP, info.dstIP = ip.SrcIP, ip.DstIP
+	default:
+		return nil, false
+	}
+
+	switch t := pk.TransportLayer().(type) {
+	case *layers.TCP:
+		info.proto, info.protoNum = "TCP", ProtoTCP
+		info.srcPort, info.dstPort = int(t.SrcPort), int(t.DstPort)
+		info.tcp = t
+		info.payload = t.Payload
+		return info, true
+	case *layers.UDP:
+		info.proto, info.protoNum = "UDP", ProtoUDP
+		info.srcPort, info.dstPort = int(t.SrcPort), int(t.DstPort)
+		info.payload = t.Payload
+		return info, true
+	}
+	if icmp, ok := pk.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4); ok {
+		info.proto, info.protoNum = "ICMP", ProtoICMP
+		info.icmpType, info.icmpCode = int(icmp.TypeCode.Type()), int(icmp.TypeCode.Code())
+		return info, true
+	}
+	if icmp, ok := pk.Layer(layers.LayerTypeICMPv6).(*layers.ICMPv6); ok {
+		info.proto, info.protoNum = "IPv6-ICMP", ProtoICMPv6
+		info.icmpType, info.icmpCode = int(icmp.TypeCode.Type()), int(icmp.TypeCode.Code())
+		return info, true
+	}
+	return nil, false
+}
+
+// ports 返回参与 flow_id / community id 计算的端口，icmp 使用 type / code
+func (p *packetInfo) ports() (int, int) {
+	if p.protoNum == ProtoICMP || p.protoNum == ProtoICMPv6 {
+		return p.icmpType, p.icmpCode
+	}
+	return p.srcPort, p.dstPort
+}
+
+func (p *packetInfo) flowKey() string {
+	if p.protoNum == ProtoICMP || p.protoNum == ProtoICMPv6 {
+		// icmp 的请求和应答属于同一个流
+		return p.proto + "-" + strings.Join(sortedPair(p.srcIP.String(), p.dstIP.String()), "-")
+	}
+	return p.proto + "-" + strings.Join(sortedPair(
+		net.JoinHostPort(p.srcIP.String(), itoa(p.srcPort)),
+		net.JoinHostPort(p.dstIP.String(), itoa(p.dstPort)),
+	), "-")
+}
+
+func (p *packetInfo) flowID() uint64 {
+	if p.protoNum == ProtoICMP || p.protoNum == ProtoICMPv6 {
+		return FlowID(p.proto, p.srcIP.String(), 0, p.dstIP.String(), 0)
+	}
+	return FlowID(p.proto, p.srcIP.String(), p.srcPort, p.dstIP.String(), p.dstPort)
+}
+
+func (l *Logger) event(typ string, ts time.Time, p *packetInfo) *Event {
+	srcPort, dstPort := p.ports()
+	e := &Event{
+		Timestamp:   ts.Format(TimestampFormat),
+		FlowID:      p.flowID(),
+		EventType:   typ,
+		SrcIP:       p.srcIP.String(),
+		DestIP:      p.dstIP.String(),
+		Proto:       p.proto,
+		CommunityID: CommunityID(p.srcIP, p.dstIP, srcPort, dstPort, p.protoNum, l.seed),
+	}
+	if p.protoNum == ProtoICMP || p.protoNum == ProtoICMPv6 {
+		icmpType, icmpCode := p.icmpType, p.icmpCode
+		e.ICMPType, e.ICMPCode = &icmpType, &icmpCode
+	} else {
+		e.SrcPort, e.DestPort = p.srcPort, p.dstPort
+	}
+	return e
+}
+
+func (l *Logger) emit(e *Event) {
+	if e == nil || !l.types[e.EventType] {
+		return
+	}
+	if l.out != nil {
+		raw, err := json.Marshal(e)
+		if err != nil {
+			log.Errorf("marshal eve event failed: %v", err)
+		} else {
+			raw = append(raw, '\n')
+			if _, err := l.out.Write(raw); err != nil {
+				log.Errorf("write eve event failed: %v", err)
+			}
+		}
+	}
+	for _, cb := range l.callbacks {
+		cb(e)
+	}
+}
+
+// Packet 统计数据包所在的流，并输出其中的 http / dns / tls 事件
+func (l *Logger) Packet(pk gopacket.Packet) {
+	p, ok := parsePacket(pk)
+	if !ok {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return
+	}
+
+	l.sweep(p.ts)
+	key := p.flowKey()
+	f, ok := l.flows[key]
+	if !ok {
+		f = newFlowRecord(p)
+		l.flows[key] = f
+	}
+	toServer := f.update(p)
+
+	switch {
+	case p.tcp != nil && len(p.payload) > 0:
+		l.handleTCPPayload(f, p, toServer)
+	case p.protoNum == ProtoUDP:
+		if dns, ok := pk.Layer(layers.LayerTypeDNS).(*layers.DNS); ok {
+			f.appProto = "dns"
+			e := l.event(EventTypeDNS, p.ts, p)
+			e.AppProto = f.appProto
+			e.DNS = dnsRecord(dns)
+			l.emit(e)
+		}
+	}
+}
+
+// Alert 输出规则匹配的告警，告警的方向与匹配到的数据包一致
+func (l *Logger) Alert(pk gopacket.Packet, r *rule.Rule) {
+	if r == nil {
+		return
+	}
+	p, ok := parsePacket(pk)
+	if !ok {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	e := l.event(EventTypeAlert, p.ts, p)
+	e.Alert = alertFromRule(r)
+	if f, ok := l.flows[p.flowKey()]; ok {
+		f.alerted = true
+		e.AppProto = f.appProto
+		e.TLS = f.tls
+		if f.lastHTTP != nil {
+			e.HTTP = f.lastHTTP.record
+		}
+	}
+	l.emit(e)
+}
+
+// Flush 输出所有还没有结束的流
+func (l *Logger) Flush() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for key, f := range l.flows {
+		l.finishFlow(f, "shutdown")
+		delete(l.flows, key)
+	}
+}
+
+// Close 输出所有还没有结束的流，之后的数据包会被忽略
+func (l *Logger) Close() {
+	l.Flush()
+	l.lock.Lock()
+	l.closed = true
+	l.lock.Unlock()
+}
+
+// sweep 以数据包的时间为准输出已经超时的流，pcap 离线分析时也能得到和实时抓包一样的结果
+func (l *Logger) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Second {
+		return
+	}
+	l.lastSweep = now
+	for key, f := range l.flows {
+		if now.Sub(f.last) >= l.timeout || (f.closed && now.Sub(f.last) >= time.Second) {
+			l.finishFlow(f, "timeout")
+			delete(l.flows, key)
+		}
+	}
+}
+
+func (l *Logger) finishFlow(f *flowRecord, reason string) {
+	if f.pendingHTTP != nil {
+		l.emitHTTP(f, f.pendingHTTP, f.last)
+		f.pendingHTTP = nil
+	}
+	if f.tls != nil && !f.tlsLogged {
+		l.emitTLS(f, f.last)
+	}
+	e := l.event(EventTypeFlow, f.last, f.initiator)
+	e.AppProto = f.appProto
+	e.Flow = f.record(reason)
+	l.emit(e)
+}
+
+func alertFromRule(r *rule.Rule) *Alert {
+	a := &Alert{
+		Action:      "allowed",
+		GID:         1,
+		SignatureID: r.Sid,
+		Rev:         r.Rev,
+		Signature:   r.Message,
+		Category:    r.ClassType,
+		Severity:    r.Priority,
+	}
+	if strings.HasPrefix(r.Action, "drop") || strings.HasPrefix(r.Action, "reject") {
+		a.Action = "blocked"
+	}
+	if r.Gid > 0 {
+		a.GID = r.Gid
+	}
+	if a.Severity <= 0 {
+		a.Severity = 3
+	}
+	for _, meta := range r.Metadata {
+		for _, item := range strings.Split(meta, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(item), " ")
+			if !ok {
+				continue
+			}
+			if a.Metadata == nil {
+				a.Metadata = make(map[string][]string)
+			}
+			a.Metadata[k] = append(a.Metadata[k], strings.TrimSpace(v))
+		}
+	}
+	return a
+}
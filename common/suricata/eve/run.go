@@ -0,0 +1,137 @@
+package eve
+
+import (
+	"context"
+	"os"
+
+	"github.com/google/gopacket"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// RunConfig 是 Run 的配置，pcap 文件和网卡二选一，都为空时监听所有网卡
+type RunConfig struct {
+	ctx        context.Context
+	pcapFile   string
+	devices    []string
+	bpf        string
+	outputFile string
+	stateful   bool
+	loggerOpts []LoggerOption
+}
+
+type RunOption func(c *RunConfig)
+
+func WithContext(ctx context.Context) RunOption {
+	return func(c *RunConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithPcapFile 从 pcap 文件读取流量，读取完成后 Run 返回
+func WithPcapFile(filename string) RunOption {
+	return func(c *RunConfig) {
+		c.pcapFile = filename
+	}
+}
+
+// WithDevice 从网卡实时抓包，需要通过 context 结束
+func WithDevice(devices ...string) RunOption {
+	return func(c *RunConfig) {
+		c.devices = append(c.devices, devices...)
+	}
+}
+
+func WithBPFFilter(bpf string) RunOption {
+	return func(c *RunConfig) {
+		c.bpf = bpf
+	}
+}
+
+// WithOutputFile 把事件追加写入 eve.json 文件
+func WithOutputFile(filename string) RunOption {
+	return func(c *RunConfig) {
+		c.outputFile = filename
+	}
+}
+
+// WithStateful 启用 flowbits / flowint / xbits 的状态匹配，默认启用
+func WithStateful(b bool) RunOption {
+	return func(c *RunConfig) {
+		c.stateful = b
+	}
+}
+
+// WithLoggerOptions 设置输出选项，例如事件回调和事件类型
+func WithLoggerOptions(opts ...LoggerOption) RunOption {
+	return func(c *RunConfig) {
+		c.loggerOpts = append(c.loggerOpts, opts...)
+	}
+}
+
+// Run 使用 suricata 规则检测 pcap 文件或实时流量，并输出 EVE JSON 事件
+func Run(rules []*rule.Rule, opts ...RunOption) error {
+	config := &RunConfig{
+		ctx:      context.Background(),
+		stateful: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	loggerOpts := config.loggerOpts
+	if config.outputFile != "" {
+		fp, err := os.OpenFile(config.outputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return utils.Errorf("open eve output file %v failed: %v", config.outputFile, err)
+		}
+		defer fp.Close()
+		loggerOpts = append(loggerOpts, WithWriter(fp))
+	}
+	logger := NewLogger(loggerOpts...)
+	defer logger.Close()
+
+	groupOpts := []match.GroupOption{match.WithGroupOnMatchedCallback(logger.Alert)}
+	if !config.stateful {
+		groupOpts = append(groupOpts, match.WithGroupFlowState(nil))
+	}
+	group := match.NewGroup(groupOpts...)
+	defer group.Close()
+	group.LoadRules(rules...)
+
+	captureOpts := []pcaputil.CaptureOption{
+		pcaputil.WithContext(config.ctx),
+		pcaputil.WithEveryPacket(func(pk gopacket.Packet) {
+			logger.Packet(pk)
+			group.FeedPacket(pk)
+		}),
+	}
+	if config.pcapFile != "" {
+		captureOpts = append(captureOpts, pcaputil.WithFile(config.pcapFile))
+	} else {
+		captureOpts = append(captureOpts, pcaputil.WithDevice(config.devices...))
+	}
+	if config.bpf != "" {
+		captureOpts = append(captureOpts, pcaputil.WithBPFFilter(config.bpf))
+	}
+
+	err := pcaputil.Start(captureOpts...)
+	// 告警是异步匹配的，需要在输出流结束事件之前全部处理完
+	group.Wait()
+	if err != nil {
+		log.Errorf("eve capture failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Stream 在后台使用 rules 检测流量，生成的 EVE 事件（告警、flow、协议日志等）通过 channel 返回，抓包结束或 ctx 结束后 channel 关闭
+func Stream(ctx context.Context, rules []*rule.Rule, opts ...RunOption) chan *Event {
+	return pcaputil.StreamResults(ctx, "eve", func(ctx context.Context, emit func(*Event)) error {
+		runOpts := append(append([]RunOption{}, opts...), WithContext(ctx), WithLoggerOptions(WithEventCallback(emit)))
+		return Run(rules, runOpts...)
+	})
+}
@@ -0,0 +1,100 @@
+package generate
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/suricata/data/smb"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+var _ ModifierGenerator = (*SMBGen)(nil)
+
+// SMBGen 生成 NetBIOS 封装的 SMB2 请求
+// 根据规则中的 buffer 依次生成 SESSION_SETUP(NTLMSSP) / TREE_CONNECT / CREATE，没有 smb buffer 时生成 NEGOTIATE
+// 普通 content 附加在最后一个消息之后
+type SMBGen struct {
+	gen     map[modifier.Modifier]ModifierGenerator
+	payload ModifierGenerator
+}
+
+func newSMBGen(r *rule.Rule) (Generator, error) {
+	payload, err := newSMBPayloadGen(r)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPGen{r: r, payload: payload}, nil
+}
+
+func newSMBPayloadGen(r *rule.Rule) (*SMBGen, error) {
+	if r.ContentRuleConfig == nil {
+		return nil, errors.New("empty content rule config")
+	}
+	g := &SMBGen{gen: make(map[modifier.Modifier]ModifierGenerator)}
+	for mdf, rr := range contentRuleMap(r.ContentRuleConfig.ContentRules) {
+		switch mdf {
+		case modifier.SMBShare, modifier.SMBNamedPipe:
+			g.gen[mdf] = parse2ContentGen(rr, WithNoise(noiseChar))
+		case modifier.SMBNTLMSSPUser, modifier.SMBNTLMSSPDomain:
+			g.gen[mdf] = parse2ContentGen(rr, WithNoise(noiseDigitChar))
+		case modifier.Default:
+			g.payload = parse2ContentGen(rr, WithNoise(noiseAll))
+		default:
+			log.Warnf("not support modifier %v in smb generator", mdf)
+		}
+	}
+	return g, nil
+}
+
+func (g *SMBGen) genString(mdf modifier.Modifier) (string, bool) {
+	gen, ok := g.gen[mdf]
+	if !ok || gen == nil {
+		return "", false
+	}
+	return string(gen.Gen()), true
+}
+
+func (g *SMBGen) Gen() []byte {
+	h := &smb.Header{MessageID: uint64(rand.Intn(16) + 1), SessionID: rand.Uint64()}
+	var msgs [][]byte
+	user, hasUser := g.genString(modifier.SMBNTLMSSPUser)
+	domain, hasDomain := g.genString(modifier.SMBNTLMSSPDomain)
+	if hasUser || hasDomain {
+		if !hasUser {
+			user = "administrator"
+		}
+		if !hasDomain {
+			domain = "WORKGROUP"
+		}
+		ntResponse := make([]byte, 24)
+		rand.Read(ntResponse)
+		msgs = append(msgs, smb.BuildSessionSetupRequest(h, smb.BuildNTLMSSPAuth(domain, user, "WORKSTATION", ntResponse)))
+		h.MessageID++
+	}
+
+	pipe, hasPipe := g.genString(modifier.SMBNamedPipe)
+	share, hasShare := g.genString(modifier.SMBShare)
+	if hasShare || hasPipe {
+		if !hasShare {
+			share = `\\127.0.0.1\IPC$`
+		}
+		msgs = append(msgs, smb.BuildTreeConnectRequest(h, share))
+		h.MessageID++
+		h.TreeID = rand.Uint32()
+	}
+	if hasPipe {
+		msgs = append(msgs, smb.BuildCreateRequest(h, pipe))
+	}
+
+	if len(msgs) == 0 {
+		msgs = append(msgs, smb.BuildNegotiateRequest(h))
+	}
+	if g.payload != nil {
+		// 规则中的普通 content 放在最后一个消息之后，保证载荷仍然是合法的 NetBIOS 帧
+		last := len(msgs) - 1
+		msgs[last] = append(msgs[last], g.payload.Gen()...)
+	}
+	return smb.WrapNetBIOS(msgs...)
+}
@@ -43,6 +43,22 @@ func newTCPGen(r *rule.Rule) (Generator, error) {
 				g.payload = gen
 				return g, nil
 			}
+			if modifier.IsTLSModifier(mdf) {
+				gen, err := newTLSPayloadGen(r)
+				if err != nil {
+					return nil, errors.Wrap(err, "new tls gen failed")
+				}
+				g.payload = gen
+				return g, nil
+			}
+			if modifier.IsSMBModifier(mdf) {
+				gen, err := newSMBPayloadGen(r)
+				if err != nil {
+					return nil, errors.Wrap(err, "new smb gen failed")
+				}
+				g.payload = gen
+				return g, nil
+			}
 			log.Warnf("not support modifier %v", mdf)
 		}
 	}
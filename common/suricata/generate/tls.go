@@ -0,0 +1,402 @@
+package generate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+var _ ModifierGenerator = (*TLSGen)(nil)
+
+// TLSGen 生成 TLS 握手载荷
+// 规则中有 ja3s / 证书相关的 buffer 时生成服务器的 ServerHello + Certificate + ServerHelloDone，否则生成 ClientHello
+// ja3.hash / ja3s.hash 无法从 md5 反推，需要同时使用 ja3.string / ja3s.string 才能生成匹配的流量
+type TLSGen struct {
+	server bool
+	gen    map[modifier.Modifier]ModifierGenerator
+	key    *ecdsa.PrivateKey
+}
+
+func newTLSGen(r *rule.Rule) (Generator, error) {
+	payload, err := newTLSPayloadGen(r)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPGen{r: r, payload: payload}, nil
+}
+
+func newTLSPayloadGen(r *rule.Rule) (*TLSGen, error) {
+	if r.ContentRuleConfig == nil {
+		return nil, errors.New("empty content rule config")
+	}
+	g := &TLSGen{gen: make(map[modifier.Modifier]ModifierGenerator)}
+	hasClientBuffer := false
+	for mdf, rr := range contentRuleMap(r.ContentRuleConfig.ContentRules) {
+		switch mdf {
+		case modifier.TLSSNI:
+			g.gen[mdf] = parse2ContentGen(rr, WithNoise(noiseDigitChar))
+			hasClientBuffer = true
+		case modifier.TLSCertSubject, modifier.TLSCertIssuer:
+			g.gen[mdf] = parse2ContentGen(rr, WithNoise(noiseChar))
+			g.server = true
+		case modifier.JA3String, modifier.JA3Hash:
+			g.gen[mdf] = parse2DirectGen(rr)
+			hasClientBuffer = true
+		case modifier.JA3SString, modifier.JA3SHash:
+			g.gen[mdf] = parse2DirectGen(rr)
+			g.server = true
+		case modifier.TLSVersion:
+			g.gen[mdf] = parse2DirectGen(rr)
+		default:
+			log.Warnf("not support modifier %v in tls generator", mdf)
+		}
+	}
+	if _, ok := g.gen[modifier.JA3Hash]; ok {
+		if _, ok := g.gen[modifier.JA3String]; !ok {
+			log.Warnf("ja3.hash can't be generated without ja3.string, rule: %v", r.Raw)
+		}
+	}
+	if _, ok := g.gen[modifier.JA3SHash]; ok {
+		if _, ok := g.gen[modifier.JA3SString]; !ok {
+			log.Warnf("ja3s.hash can't be generated without ja3s.string, rule: %v", r.Raw)
+		}
+	}
+	if !g.server && !hasClientBuffer && r.ContentRuleConfig.Flow != nil && r.ContentRuleConfig.Flow.ToClient {
+		g.server = true
+	}
+	if g.server {
+		var err error
+		g.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *TLSGen) genString(mdf modifier.Modifier) (string, bool) {
+	gen, ok := g.gen[mdf]
+	if !ok || gen == nil {
+		return "", false
+	}
+	return string(gen.Gen()), true
+}
+
+func (g *TLSGen) version() (uint16, bool) {
+	s, ok := g.genString(modifier.TLSVersion)
+	if !ok {
+		return 0, false
+	}
+	switch strings.TrimSpace(s) {
+	case "ssl3":
+		return 0x0300, true
+	case "1.0":
+		return 0x0301, true
+	case "1.1":
+		return 0x0302, true
+	case "1.2":
+		return 0x0303, true
+	case "1.3":
+		return 0x0304, true
+	}
+	log.Warnf("unknown tls version %#v in tls generator", s)
+	return 0, false
+}
+
+func (g *TLSGen) Gen() []byte {
+	if g.server {
+		return g.genServer()
+	}
+	return g.genClient()
+}
+
+func parseUint16List(s string) ([]uint16, error) {
+	var ret []uint16
+	for _, item := range strings.Split(s, "-") {
+		if item == "" {
+			continue
+		}
+		i, err := strconv.ParseUint(item, 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, uint16(i))
+	}
+	return ret, nil
+}
+
+func tlsRecord(version uint16, msgs ...[]byte) []byte {
+	var body []byte
+	for _, msg := range msgs {
+		body = append(body, msg...)
+	}
+	return append([]byte{0x16, byte(version >> 8), byte(version), byte(len(body) >> 8), byte(len(body))}, body...)
+}
+
+func handshakeMessage(typ byte, body []byte) []byte {
+	l := len(body)
+	return append([]byte{typ, byte(l >> 16), byte(l >> 8), byte(l)}, body...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return binary.BigEndian.AppendUint16(b, v)
+}
+
+// appendVector 添加带 n 字节长度前缀的数据
+func appendVector(b []byte, n int, data []byte) []byte {
+	l := len(data)
+	switch n {
+	case 1:
+		b = append(b, byte(l))
+	case 2:
+		b = appendUint16(b, uint16(l))
+	case 3:
+		b = append(b, byte(l>>16), byte(l>>8), byte(l))
+	}
+	return append(b, data...)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	mathrand.Read(b)
+	return b
+}
+
+func (g *TLSGen) genClient() []byte {
+	version := uint16(0x0303)
+	ciphers := []uint16{0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0x009c, 0x009d, 0x002f, 0x0035}
+	extensions := []uint16{0, 23, 65281, 10, 11, 35, 16, 13}
+	curves := []uint16{29, 23, 24}
+	points := []uint16{0}
+
+	maxVersion, hasVersion := g.version()
+	if hasVersion && maxVersion >= 0x0304 {
+		extensions = append(extensions, 43, 51)
+		ciphers = append([]uint16{0x1301, 0x1302, 0x1303}, ciphers...)
+	} else if hasVersion {
+		version = maxVersion
+	}
+
+	if ja3, ok := g.genString(modifier.JA3String); ok {
+		fields := strings.Split(ja3, ",")
+		if len(fields) == 5 {
+			v, err1 := strconv.ParseUint(fields[0], 10, 16)
+			c, err2 := parseUint16List(fields[1])
+			e, err3 := parseUint16List(fields[2])
+			cv, err4 := parseUint16List(fields[3])
+			p, err5 := parseUint16List(fields[4])
+			if err := errors.Join(err1, err2, err3, err4, err5); err == nil {
+				version, ciphers, extensions, curves, points = uint16(v), c, e, cv, p
+			} else {
+				log.Warnf("invalid ja3 string %#v: %v", ja3, err)
+			}
+		} else {
+			log.Warnf("invalid ja3 string %#v", ja3)
+		}
+	}
+	sni, ok := g.genString(modifier.TLSSNI)
+	if !ok {
+		sni = string(randomDomain())
+	}
+
+	body := appendUint16(nil, version)
+	body = append(body, randomBytes(32)...)
+	body = appendVector(body, 1, randomBytes(32))
+	var cipherBytes []byte
+	for _, c := range ciphers {
+		cipherBytes = appendUint16(cipherBytes, c)
+	}
+	body = appendVector(body, 2, cipherBytes)
+	body = appendVector(body, 1, []byte{0})
+
+	var exts []byte
+	for _, typ := range extensions {
+		var data []byte
+		switch typ {
+		case 0:
+			entry := appendVector([]byte{0}, 2, []byte(sni))
+			data = appendVector(nil, 2, entry)
+		case 10:
+			var list []byte
+			for _, c := range curves {
+				list = appendUint16(list, c)
+			}
+			data = appendVector(nil, 2, list)
+		case 11:
+			var list []byte
+			for _, p := range points {
+				list = append(list, byte(p))
+			}
+			data = appendVector(nil, 1, list)
+		case 13:
+			data = appendVector(nil, 2, []byte{0x04, 0x03, 0x08, 0x04, 0x04, 0x01, 0x05, 0x03, 0x08, 0x05, 0x05, 0x01})
+		case 16:
+			data = appendVector(nil, 2, append(appendVector(nil, 1, []byte("h2")), appendVector(nil, 1, []byte("http/1.1"))...))
+		case 43:
+			top := uint16(0x0304)
+			if hasVersion {
+				top = maxVersion
+			}
+			var list []byte
+			for v := top; v >= 0x0301; v-- {
+				list = appendUint16(list, v)
+			}
+			data = appendVector(nil, 1, list)
+		case 51:
+			share := appendUint16(nil, 29)
+			share = appendVector(share, 2, randomBytes(32))
+			data = appendVector(nil, 2, share)
+		case 65281:
+			data = []byte{0}
+		}
+		exts = appendUint16(exts, typ)
+		exts = appendVector(exts, 2, data)
+	}
+	body = appendVector(body, 2, exts)
+	return tlsRecord(0x0301, handshakeMessage(0x01, body))
+}
+
+func (g *TLSGen) genServer() []byte {
+	version := uint16(0x0303)
+	cipher := uint16(0xc02f)
+	extensions := []uint16{65281, 11, 23}
+	selected, hasVersion := g.version()
+	if hasVersion && selected >= 0x0304 {
+		cipher = 0x1301
+		extensions = []uint16{43, 51}
+	} else if hasVersion {
+		version = selected
+	}
+	if ja3s, ok := g.genString(modifier.JA3SString); ok {
+		fields := strings.Split(ja3s, ",")
+		v, err1 := strconv.ParseUint(fields[0], 10, 16)
+		var err2, err3 error
+		var c uint64
+		var e []uint16
+		if len(fields) == 3 {
+			c, err2 = strconv.ParseUint(fields[1], 10, 16)
+			e, err3 = parseUint16List(fields[2])
+		} else {
+			err2 = errors.New("need 3 fields")
+		}
+		if err := errors.Join(err1, err2, err3); err == nil {
+			version, cipher, extensions = uint16(v), uint16(c), e
+		} else {
+			log.Warnf("invalid ja3s string %#v: %v", ja3s, err)
+		}
+	}
+
+	body := appendUint16(nil, version)
+	body = append(body, randomBytes(32)...)
+	body = appendVector(body, 1, randomBytes(32))
+	body = appendUint16(body, cipher)
+	body = append(body, 0)
+	var exts []byte
+	for _, typ := range extensions {
+		var data []byte
+		switch typ {
+		case 11:
+			data = []byte{0x01, 0x00}
+		case 43:
+			v := uint16(0x0304)
+			if hasVersion {
+				v = selected
+			}
+			data = appendUint16(nil, v)
+		case 51:
+			data = appendUint16(nil, 29)
+			data = appendVector(data, 2, randomBytes(32))
+		case 65281:
+			data = []byte{0}
+		}
+		exts = appendUint16(exts, typ)
+		exts = appendVector(exts, 2, data)
+	}
+	body = appendVector(body, 2, exts)
+	msgs := [][]byte{handshakeMessage(0x02, body)}
+
+	if cert := g.certificate(); cert != nil {
+		chain := appendVector(nil, 3, cert)
+		msgs = append(msgs, handshakeMessage(0x0b, appendVector(nil, 3, chain)))
+	}
+	msgs = append(msgs, handshakeMessage(0x0e, nil))
+	return tlsRecord(0x0303, msgs...)
+}
+
+var dnOIDs = map[string]asn1.ObjectIdentifier{
+	"CN":           {2, 5, 4, 3},
+	"serialNumber": {2, 5, 4, 5},
+	"C":            {2, 5, 4, 6},
+	"L":            {2, 5, 4, 7},
+	"ST":           {2, 5, 4, 8},
+	"street":       {2, 5, 4, 9},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"postalCode":   {2, 5, 4, 17},
+	"emailAddress": {1, 2, 840, 113549, 1, 9, 1},
+}
+
+// parseDN 把 "C=US, O=Example, CN=example.com" 形式的字符串转换为证书名称，保持属性顺序
+// 无法解析时整个字符串作为 CN
+func parseDN(s string) pkix.Name {
+	var attrs []pkix.AttributeTypeAndValue
+	for _, item := range strings.Split(s, ", ") {
+		k, v, ok := strings.Cut(item, "=")
+		oid, known := dnOIDs[k]
+		if !ok || !known {
+			attrs = nil
+			break
+		}
+		attrs = append(attrs, pkix.AttributeTypeAndValue{Type: oid, Value: v})
+	}
+	if len(attrs) == 0 {
+		attrs = []pkix.AttributeTypeAndValue{{Type: dnOIDs["CN"], Value: s}}
+	}
+	return pkix.Name{ExtraNames: attrs}
+}
+
+func (g *TLSGen) certificate() []byte {
+	subject, hasSubject := g.genString(modifier.TLSCertSubject)
+	issuer, hasIssuer := g.genString(modifier.TLSCertIssuer)
+	if !hasSubject {
+		subject = "CN=" + string(randomDomain())
+	}
+	if !hasIssuer {
+		issuer = subject
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(mathrand.Int63()),
+		Subject:      parseDN(subject),
+		NotBefore:    time.Now().Add(-24 * time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	parent := &x509.Certificate{Subject: parseDN(issuer)}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &g.key.PublicKey, g.key)
+	if err != nil {
+		log.Errorf("generate tls certificate failed: %v", err)
+		return nil
+	}
+	return der
+}
+
+func randomDomain() []byte {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = noiseChar()
+	}
+	return append(b, ".com"...)
+}
@@ -0,0 +1,32 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func TestTLSAndSMBGen(t *testing.T) {
+	for _, raw := range []string{
+		`alert tls any any -> any any (msg:"sni"; tls.sni; content:"evil.example.com"; endswith; sid:1;)`,
+		`alert tcp any any -> any 443 (msg:"sni in tcp rule"; tls_sni; content:"c2.example"; sid:2;)`,
+		`alert tls any any -> any any (msg:"ja3"; ja3.hash; content:"ccbe35054975fe6f0c69b124058c4b47"; ja3.string; content:"771,4865-4866-4867-49195,0-10-11-13-43-51,29-23-24,0"; sid:3;)`,
+		`alert tls any any -> any any (msg:"tls 1.3"; tls.version; content:"1.3"; tls.sni; content:"example.org"; sid:4;)`,
+		`alert tls any 443 -> any any (msg:"subject"; tls.cert_subject; content:"C=US, O=Evil Corp, CN=evil.example.com"; tls.cert_issuer; content:"CN=Evil CA"; sid:5;)`,
+		`alert tls any 443 -> any any (msg:"ja3s"; ja3s.string; content:"771,49199,65281-11-23"; sid:6;)`,
+		`alert smb any any -> any 445 (msg:"share"; smb.share; content:"ADMIN$"; endswith; sid:7;)`,
+		`alert smb any any -> any 445 (msg:"pipe"; smb.named_pipe; content:"svcctl"; smb.ntlmssp_user; content:"administrator"; nocase; sid:8;)`,
+		`alert smb any any -> any 445 (msg:"negotiate"; content:"|fe|SMB"; sid:9;)`,
+	} {
+		rs, err := rule.Parse(raw)
+		require.NoError(t, err)
+		g, err := New(rs[0])
+		require.NoError(t, err, raw)
+		m := match.New(rs[0])
+		for i := 0; i < 5; i++ {
+			require.True(t, m.Match(g.Gen()), raw)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package match
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	defaultFlowStateTimeout = 10 * time.Minute
+	defaultXBitsExpire      = 30 * time.Second
+)
+
+// FlowState 保存 flowbits / flowint / xbits 的状态
+// flowbits / flowint 按照流(五元组，与方向无关，与 pcaputil 重组使用的 hash 一致)保存，流在 timeout 内没有被访问就会过期；
+// xbits 按照 ip / ip 对保存，过期时间由规则中的 expire 决定
+type FlowState struct {
+	flows *utils.Cache[*flowVars]
+	xbits *utils.Cache[struct{}]
+}
+
+type flowVars struct {
+	lock sync.Mutex
+	bits map[string]struct{}
+	ints map[string]int64
+}
+
+// NewFlowState 创建一个状态表，timeout 为流状态的过期时间，默认 10 分钟
+func NewFlowState(timeout ...time.Duration) *FlowState {
+	ttl := defaultFlowStateTimeout
+	if len(timeout) > 0 && timeout[0] > 0 {
+		ttl = timeout[0]
+	}
+	xbits := utils.NewTTLCache[struct{}](defaultXBitsExpire)
+	// xbits 的过期时间从 set 开始计算，查询不会延长
+	xbits.SkipTtlExtensionOnHit(true)
+	return &FlowState{
+		flows: utils.NewTTLCache[*flowVars](ttl),
+		xbits: xbits,
+	}
+}
+
+func (s *FlowState) Close() {
+	s.flows.Close()
+	s.xbits.Close()
+}
+
+// Reset 清空所有状态
+func (s *FlowState) Reset() {
+	s.flows.Purge()
+	s.xbits.Purge()
+}
+
+// FlowCount 返回当前保存了状态的流的数量
+func (s *FlowState) FlowCount() int {
+	return s.flows.Count()
+}
+
+func flowStateKey(pk gopacket.Packet) (string, bool) {
+	nw := pk.NetworkLayer()
+	if nw == nil {
+		return "", false
+	}
+	netType := "ip4"
+	if nw.LayerType() == layers.LayerTypeIPv6 {
+		netType = "ip6"
+	}
+	src, dst := nw.NetworkFlow().Src().String(), nw.NetworkFlow().Dst().String()
+	if tl := pk.TransportLayer(); tl != nil {
+		switch tl.LayerType() {
+		case layers.LayerTypeTCP:
+			netType = "tcp" + netType[2:]
+		case layers.LayerTypeUDP:
+			netType = "udp" + netType[2:]
+		}
+		src = utils.HostPort(src, tl.TransportFlow().Src().String())
+		dst = utils.HostPort(dst, tl.TransportFlow().Dst().String())
+	}
+	return pcaputil.FlowHash(netType, src, dst), true
+}
+
+func (s *FlowState) getFlow(pk gopacket.Packet, create bool) *flowVars {
+	key, ok := flowStateKey(pk)
+	if !ok {
+		return nil
+	}
+	if vars, ok := s.flows.Get(key); ok {
+		return vars
+	}
+	if !create {
+		return nil
+	}
+	vars := &flowVars{bits: make(map[string]struct{}), ints: make(map[string]int64)}
+	s.flows.Set(key, vars)
+	return vars
+}
+
+func xbitsKey(pk gopacket.Packet, x *rule.XBitsRule) (string, bool) {
+	nw := pk.NetworkLayer()
+	if nw == nil {
+		return "", false
+	}
+	src, dst := nw.NetworkFlow().Src().String(), nw.NetworkFlow().Dst().String()
+	var ip string
+	switch x.Track {
+	case "ip_src":
+		ip = src
+	case "ip_dst":
+		ip = dst
+	default:
+		pair := []string{src, dst}
+		sort.Strings(pair)
+		ip = strings.Join(pair, "-")
+	}
+	return x.Track + "|" + ip + "|" + x.Name, true
+}
+
+// check 检查规则中的状态条件(isset / isnotset / flowint 比较)
+func (s *FlowState) check(pk gopacket.Packet, cfg *rule.ContentRuleConfig) bool {
+	vars := s.getFlow(pk, false)
+	if vars != nil {
+		vars.lock.Lock()
+		defer vars.lock.Unlock()
+	}
+	isset := func(name string) bool {
+		if vars == nil {
+			return false
+		}
+		_, ok := vars.bits[name]
+		return ok
+	}
+
+	for _, f := range cfg.FlowBits {
+		if !f.IsCondition() {
+			continue
+		}
+		matched := 0
+		for _, name := range f.Names {
+			if isset(name) == (f.Action == rule.FlowBitsIsSet) {
+				matched++
+			}
+		}
+		if f.Or && matched == 0 || !f.Or && matched != len(f.Names) {
+			return false
+		}
+	}
+
+	for _, f := range cfg.FlowInts {
+		if !f.IsCondition() {
+			continue
+		}
+		var current int64
+		var exists bool
+		if vars != nil {
+			current, exists = vars.ints[f.Name]
+		}
+		switch f.Operator {
+		case "isset":
+			if !exists {
+				return false
+			}
+			continue
+		case "notset":
+			if exists {
+				return false
+			}
+			continue
+		}
+		target, ok := vars.value(f.Value)
+		if !exists || !ok {
+			return false
+		}
+		if !compareFlowInt(current, f.Operator, target) {
+			return false
+		}
+	}
+
+	for _, x := range cfg.XBits {
+		if !x.IsCondition() {
+			continue
+		}
+		key, ok := xbitsKey(pk, x)
+		if !ok {
+			return false
+		}
+		_, exists := s.xbits.Get(key)
+		if exists != (x.Action == rule.FlowBitsIsSet) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply 在规则匹配成功之后执行 set / unset / toggle 以及 flowint 的修改
+func (s *FlowState) apply(pk gopacket.Packet, cfg *rule.ContentRuleConfig) {
+	needFlow := false
+	for _, f := range cfg.FlowBits {
+		needFlow = needFlow || !f.IsCondition()
+	}
+	for _, f := range cfg.FlowInts {
+		needFlow = needFlow || !f.IsCondition()
+	}
+	if needFlow {
+		if vars := s.getFlow(pk, true); vars != nil {
+			vars.lock.Lock()
+			for _, f := range cfg.FlowBits {
+				for _, name := range f.Names {
+					switch f.Action {
+					case rule.FlowBitsSet:
+						vars.bits[name] = struct{}{}
+					case rule.FlowBitsUnset:
+						delete(vars.bits, name)
+					case rule.FlowBitsToggle:
+						if _, ok := vars.bits[name]; ok {
+							delete(vars.bits, name)
+						} else {
+							vars.bits[name] = struct{}{}
+						}
+					}
+				}
+			}
+			for _, f := range cfg.FlowInts {
+				if f.IsCondition() {
+					continue
+				}
+				value, ok := vars.value(f.Value)
+				if !ok {
+					continue
+				}
+				switch f.Operator {
+				case "=":
+					vars.ints[f.Name] = value
+				case "+":
+					// 未设置的变量相当于 0
+					vars.ints[f.Name] += value
+				case "-":
+					vars.ints[f.Name] -= value
+				}
+			}
+			vars.lock.Unlock()
+		}
+	}
+
+	for _, x := range cfg.XBits {
+		if x.IsCondition() {
+			continue
+		}
+		key, ok := xbitsKey(pk, x)
+		if !ok {
+			continue
+		}
+		expire := defaultXBitsExpire
+		if x.Expire > 0 {
+			expire = time.Duration(x.Expire) * time.Second
+		}
+		switch x.Action {
+		case rule.FlowBitsSet:
+			s.xbits.SetWithTTL(key, struct{}{}, expire)
+		case rule.FlowBitsUnset:
+			s.xbits.Remove(key)
+		case rule.FlowBitsToggle:
+			if !s.xbits.Remove(key) {
+				s.xbits.SetWithTTL(key, struct{}{}, expire)
+			}
+		}
+	}
+}
+
+// value 解析 flowint 的值，可以是数字或者另一个已经设置的变量
+func (v *flowVars) value(s string) (int64, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if v == nil {
+		return 0, false
+	}
+	i, ok := v.ints[s]
+	return i, ok
+}
+
+func compareFlowInt(a int64, op string, b int64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case ">":
+		return a > b
+	case "<=":
+		return a <= b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// flowStateMatcher 在状态条件不满足时拒绝，没有设置 FlowState 时忽略状态关键字
+func flowStateMatcher(c *matchContext) error {
+	if c.state == nil {
+		return nil
+	}
+	c.Must(c.state.check(c.PK, c.Rule.ContentRuleConfig))
+	return nil
+}
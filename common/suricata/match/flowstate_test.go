@@ -0,0 +1,171 @@
+package match
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func buildTCPPacket(t *testing.T, src string, srcPort int, dst string, dstPort int, payload string) gopacket.Packet {
+	raw, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP(src),
+		pcapx.WithIPv4_DstIP(dst),
+		pcapx.WithTCP_SrcPort(srcPort),
+		pcapx.WithTCP_DstPort(dstPort),
+		pcapx.WithTCP_Flags("PA"),
+		pcapx.WithPayload([]byte(payload)),
+	)
+	require.NoError(t, err)
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.NoCopy)
+}
+
+func TestParseStatefulKeywords(t *testing.T) {
+	rs, err := rule.Parse(`alert tcp any any -> any any (msg:"stateful"; content:"x"; flowbits:set,a&b; flowbits:isset,c|d; flowbits:noalert; flowint:cnt,+,1; flowint:cnt,>=,3; xbits:set,scan,track ip_src,expire 60; sid:1;)`)
+	require.NoError(t, err)
+	cfg := rs[0].ContentRuleConfig
+	require.True(t, cfg.NoAlert)
+	require.Len(t, cfg.FlowBits, 2)
+	require.Equal(t, []string{"a", "b"}, cfg.FlowBits[0].Names)
+	require.False(t, cfg.FlowBits[0].Or)
+	require.True(t, cfg.FlowBits[1].Or)
+	require.Len(t, cfg.FlowInts, 2)
+	require.Equal(t, ">=", cfg.FlowInts[1].Operator)
+	require.Len(t, cfg.XBits, 1)
+	require.Equal(t, "ip_src", cfg.XBits[0].Track)
+	require.Equal(t, 60, cfg.XBits[0].Expire)
+}
+
+func TestGroup_FlowBits(t *testing.T) {
+	rs, err := rule.Parse(`alert tcp any any -> any any (msg:"stage1"; flow:to_server; content:"LOGIN"; flowbits:set,login; flowbits:noalert; sid:1;)
+alert tcp any any -> any any (msg:"stage2"; flow:to_client; content:"OK"; flowbits:isset,login; sid:2;)`)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	matched := map[int]int{}
+	// 状态匹配默认启用
+	group := NewGroup(WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *rule.Rule) {
+		lock.Lock()
+		defer lock.Unlock()
+		matched[match.Sid]++
+	}))
+	defer group.Close()
+	group.LoadRules(rs...)
+
+	// response without login in the flow should not alert
+	group.feedPacket(buildTCPPacket(t, "10.0.0.2", 21, "10.0.0.1", 40000, "OK"))
+	group.Wait()
+	// login in another flow
+	group.feedPacket(buildTCPPacket(t, "10.0.0.1", 40001, "10.0.0.2", 21, "LOGIN"))
+	group.Wait()
+	group.feedPacket(buildTCPPacket(t, "10.0.0.2", 21, "10.0.0.1", 40000, "OK"))
+	group.Wait()
+	// login then response in the same flow
+	group.feedPacket(buildTCPPacket(t, "10.0.0.1", 40000, "10.0.0.2", 21, "LOGIN"))
+	group.Wait()
+	group.feedPacket(buildTCPPacket(t, "10.0.0.2", 21, "10.0.0.1", 40000, "OK"))
+	group.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, 0, matched[1], "noalert rule should not alert")
+	require.Equal(t, 1, matched[2])
+}
+
+func TestGroup_Stateless(t *testing.T) {
+	rs, err := rule.Parse(`alert tcp any any -> any any (msg:"stage2"; content:"OK"; flowbits:isset,login; sid:2;)`)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var matched int
+	group := NewGroup(WithGroupFlowState(nil), WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *rule.Rule) {
+		lock.Lock()
+		defer lock.Unlock()
+		matched++
+	}))
+	defer group.Close()
+	group.LoadRules(rs...)
+	group.feedPacket(buildTCPPacket(t, "10.0.0.2", 21, "10.0.0.1", 40000, "OK"))
+	group.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, 1, matched, "flowbits should be ignored without flow state")
+}
+
+func TestGroup_HTTPRuleMatchedOnce(t *testing.T) {
+	rs, err := rule.Parse(`alert http any any -> any any (msg:"http"; content:"GET"; http.method; sid:1;)`)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var matched int
+	group := NewGroup(WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *rule.Rule) {
+		lock.Lock()
+		defer lock.Unlock()
+		matched++
+	}))
+	defer group.Close()
+	group.LoadRules(rs...)
+	require.Len(t, group.matchers, 1)
+
+	req := "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	group.FeedHTTPRequestBytes([]byte(req))
+	group.Wait()
+	// 已经喂入过 HTTPFlow，同一份流量的数据包不再匹配 http 规则
+	group.feedPacket(buildTCPPacket(t, "10.0.0.1", 40000, "10.0.0.2", 80, req))
+	group.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, 1, matched)
+}
+
+func TestMatcher_FlowInt(t *testing.T) {
+	rs, err := rule.Parse(`alert tcp any any -> any any (msg:"count"; content:"PASS"; flowint:fail,+,1; flowint:fail,>=,3; sid:1;)
+alert tcp any any -> any any (msg:"reset"; content:"230"; flowint:fail,=,0; noalert; sid:2;)`)
+	require.NoError(t, err)
+	state := NewFlowState()
+	defer state.Close()
+	count, reset := New(rs[0]), New(rs[1])
+	count.SetFlowState(state)
+	reset.SetFlowState(state)
+	require.True(t, reset.IsNoAlert())
+
+	pass := buildTCPPacket(t, "10.0.0.1", 40000, "10.0.0.2", 21, "PASS x")
+	// the comparison is checked before the increment, like suricata
+	require.False(t, count.MatchPackage(pass))
+	for i := 0; i < 3; i++ {
+		state.apply(pass, count.matcher.Rule.ContentRuleConfig)
+	}
+	require.True(t, count.MatchPackage(pass))
+
+	require.True(t, reset.MatchPackage(buildTCPPacket(t, "10.0.0.2", 21, "10.0.0.1", 40000, "230 ok")))
+	require.False(t, count.MatchPackage(pass))
+
+	// without state the keywords are ignored
+	require.True(t, New(rs[0]).MatchPackage(pass))
+}
+
+func TestMatcher_XBits(t *testing.T) {
+	rs, err := rule.Parse(`alert tcp any any -> any any (msg:"set"; content:"SCAN"; xbits:set,scanner,track ip_src,expire 60; noalert; sid:1;)
+alert tcp any any -> any any (msg:"check"; content:"EXPLOIT"; xbits:isset,scanner,track ip_src; sid:2;)`)
+	require.NoError(t, err)
+	state := NewFlowState()
+	defer state.Close()
+	set, check := New(rs[0]), New(rs[1])
+	set.SetFlowState(state)
+	check.SetFlowState(state)
+
+	require.False(t, check.MatchPackage(buildTCPPacket(t, "10.0.0.1", 40000, "10.0.0.2", 80, "EXPLOIT")))
+	require.True(t, set.MatchPackage(buildTCPPacket(t, "10.0.0.1", 40001, "10.0.0.3", 22, "SCAN")))
+	// another flow from the same source ip
+	require.True(t, check.MatchPackage(buildTCPPacket(t, "10.0.0.1", 40002, "10.0.0.2", 80, "EXPLOIT")))
+	require.False(t, check.MatchPackage(buildTCPPacket(t, "10.0.0.9", 40002, "10.0.0.2", 80, "EXPLOIT")))
+}
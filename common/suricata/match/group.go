@@ -36,8 +36,10 @@ type Group struct {
 	pkCache *utils.Cache[gopacket.Packet]
 	loader  SuricataRuleLoaderType
 
-	HTTPMatcher     []*sync.Pool
-	OrdinaryMatcher []*sync.Pool
+	// 每条规则只注册一次，http 规则同时用于数据包与 HTTPFlow
+	matchers []*groupMatcher
+	// httpFlowFed 表示已经喂入过 HTTPFlow，之后 http 规则只匹配 HTTPFlow，避免同一份流量被匹配两次(状态也被修改两次)
+	httpFlowFed *utils.AtomicBool
 
 	// context
 	ctx    context.Context
@@ -50,10 +52,20 @@ type Group struct {
 
 	onMatchedCallback func(packet gopacket.Packet, match *rule.Rule)
 
+	// flowState is nil means flowbits / flowint / xbits are ignored
+	flowState *FlowState
+	// ownFlowState 是 NewGroup 默认创建的状态表，Close 时释放
+	ownFlowState *FlowState
+
 	// control waitgroup
 	wg *sync.WaitGroup
 }
 
+type groupMatcher struct {
+	pool   *sync.Pool
+	isHTTP bool
+}
+
 type SuricataRuleLoaderType func(query string) (chan *rule.Rule, error)
 
 var defaultSuricataRuleLoader SuricataRuleLoaderType = nil
@@ -80,11 +92,19 @@ func NewGroup(opt ...GroupOption) *Group {
 		onMatchedCallback: func(packet gopacket.Packet, match *rule.Rule) {
 			log.Infof("matched: %v", match.Raw)
 		},
-		wg: new(sync.WaitGroup),
+		wg:          new(sync.WaitGroup),
+		httpFlowFed: utils.NewBool(false),
 	}
+	// 默认启用 flowbits / flowint / xbits 的状态匹配
+	group.ownFlowState = NewFlowState()
+	group.flowState = group.ownFlowState
 	for _, i := range opt {
 		i(group)
 	}
+	if group.flowState != group.ownFlowState {
+		group.ownFlowState.Close()
+		group.ownFlowState = nil
+	}
 	group.consumeMain()
 	return group
 }
@@ -95,19 +115,22 @@ func (g *Group) SetLoader(loader SuricataRuleLoaderType) {
 
 func (g *Group) LoadRule(r *rule.Rule) {
 	matcher := New(r)
-	switch r.Protocol {
-	case "http":
-		g.HTTPMatcher = append(g.HTTPMatcher, &sync.Pool{New: func() any {
+	matcher.matcher.state = g.flowState
+	g.matchers = append(g.matchers, &groupMatcher{
+		pool: &sync.Pool{New: func() any {
 			return &Matcher{
 				matcher: matcher.matcher.Clone(),
 			}
-		}})
+		}},
+		isHTTP: r.Protocol == "http",
+	})
+}
+
+func (g *Group) onMatched(packet gopacket.Packet, matcher *Matcher) {
+	if g.flowState != nil && matcher.IsNoAlert() {
+		return
 	}
-	g.OrdinaryMatcher = append(g.OrdinaryMatcher, &sync.Pool{New: func() any {
-		return &Matcher{
-			matcher: matcher.matcher.Clone(),
-		}
-	}})
+	g.onMatchedCallback(packet, matcher.matcher.Rule)
 }
 
 func (g *Group) LoadRules(r ...*rule.Rule) {
@@ -199,6 +222,7 @@ func (g *Group) feedHTTPFlow(flow *HttpFlow) {
 	if flow == nil {
 		return
 	}
+	g.httpFlowFed.Set()
 	g.wg.Add(1)
 	select {
 	case g.httpRequest <- flow:
@@ -219,6 +243,22 @@ func (g *Group) Wait() {
 	g.wg.Wait()
 }
 
+// Close 停止匹配，之后喂入的数据会被丢弃
+func (g *Group) Close() {
+	g.cancel()
+	if g.ownFlowState != nil {
+		g.ownFlowState.Close()
+	}
+}
+
+// FeedPacket 喂入已经解析好的数据包，例如 pcaputil 抓到的包
+func (g *Group) FeedPacket(pk gopacket.Packet) {
+	if pk == nil {
+		return
+	}
+	g.feedPacket(pk)
+}
+
 func (g *Group) FeedFrame(raw []byte) {
 	pk, err := g.unSerializingFrame(false, raw)
 	if err != nil {
@@ -283,12 +323,16 @@ func (g *Group) consumeMain() {
 			for {
 				select {
 				case packetFrame := <-g.frameChan:
-					for _, matcherpool := range g.OrdinaryMatcher {
-						matcher := matcherpool.Get().(*Matcher)
+					skipHTTP := g.httpFlowFed.IsSet()
+					for _, m := range g.matchers {
+						if m.isHTTP && skipHTTP {
+							continue
+						}
+						matcher := m.pool.Get().(*Matcher)
 						if matcher.MatchPackage(packetFrame) {
-							g.onMatchedCallback(packetFrame, matcher.matcher.Rule)
+							g.onMatched(packetFrame, matcher)
 						}
-						matcherpool.Put(matcher)
+						m.pool.Put(matcher)
 					}
 					g.wg.Done()
 				case httpFlowInstance := <-g.httpRequest:
@@ -298,13 +342,16 @@ func (g *Group) consumeMain() {
 						g.wg.Done()
 						continue
 					}
-					for _, matcherpool := range g.HTTPMatcher {
+					for _, m := range g.matchers {
+						if !m.isHTTP {
+							continue
+						}
 						for _, pkg := range pkgs {
-							matcher := matcherpool.Get().(*Matcher)
+							matcher := m.pool.Get().(*Matcher)
 							if matcher.MatchPackage(pkg) {
-								g.onMatchedCallback(pkg, matcher.matcher.Rule)
+								g.onMatched(pkg, matcher)
 							}
-							matcherpool.Put(matcher)
+							m.pool.Put(matcher)
 						}
 					}
 					g.wg.Done()
@@ -12,3 +12,12 @@ func WithGroupOnMatchedCallback(cb func(packet gopacket.Packet, match *rule.Rule
 		c.onMatchedCallback = cb
 	}
 }
+
+// WithGroupFlowState 设置有状态匹配使用的状态表，多个 Group 可以共享同一个状态表；NewGroup 默认会创建一个
+// flowbits / flowint / xbits 会在流之间保存状态，带有 noalert 的规则匹配后只修改状态，不会触发回调
+// 传入 nil 关闭状态匹配，这些关键字会被忽略，每个包单独匹配
+func WithGroupFlowState(state *FlowState) GroupOption {
+	return func(c *Group) {
+		c.flowState = state
+	}
+}
@@ -50,6 +50,19 @@ func (m *Matcher) MatchPackage(pk gopacket.Packet) bool {
 	return m.matcher.Match(pk)
 }
 
+// SetFlowState 设置 flowbits / flowint / xbits 使用的状态表，多个 Matcher 可以共享同一个状态表
+// 没有设置时这些关键字会被忽略，每个包单独匹配
+func (m *Matcher) SetFlowState(state *FlowState) {
+	m.matcher.lock.Lock()
+	defer m.matcher.lock.Unlock()
+	m.matcher.state = state
+}
+
+// IsNoAlert 表示规则带有 noalert，有状态匹配时匹配成功也不应该产生告警
+func (m *Matcher) IsNoAlert() bool {
+	return m.matcher.Rule.ContentRuleConfig != nil && m.matcher.Rule.ContentRuleConfig.NoAlert
+}
+
 type matchHandler func(*matchContext) error
 
 type bufferProvider func(modifier modifier.Modifier) []byte
@@ -73,6 +86,8 @@ type matchContext struct {
 	PK   gopacket.Packet
 	Rule *rule.Rule
 
+	state *FlowState
+
 	workflow []matchHandler
 }
 
@@ -83,6 +98,7 @@ func (c *matchContext) Clone() *matchContext {
 		buffer:   make(map[modifier.Modifier][]byte),
 		Rule:     c.Rule,
 		workflow: c.workflow,
+		state:    c.state,
 	}
 }
 
@@ -167,6 +183,9 @@ func (c *matchContext) Match(pk gopacket.Packet) bool {
 		log.Errorf("match flow failed: %s", err.Error())
 		return false
 	}
+	if !c.rejected && c.state != nil && c.Rule.ContentRuleConfig.IsStateful() {
+		c.state.apply(pk, c.Rule.ContentRuleConfig)
+	}
 	return !c.rejected
 }
 
@@ -187,6 +206,14 @@ func matchMutex(c *matchContext) error {
 		attachFastPattern(c)
 		c.Attach(tcpCfgMatch)
 		attachPayloadMatcher(c)
+	case protocol.TLS:
+		c.Attach(ipMatcher, portMatcher, tlsParser)
+		attachFastPattern(c)
+		attachPayloadMatcher(c)
+	case protocol.SMB:
+		c.Attach(ipMatcher, portMatcher, smbParser)
+		attachFastPattern(c)
+		attachPayloadMatcher(c)
 	case protocol.UDP:
 		c.Attach(ipMatcher, portMatcher, udpParser)
 		attachFastPattern(c)
@@ -199,6 +226,10 @@ func matchMutex(c *matchContext) error {
 	default:
 		return fmt.Errorf("unsupported protocol: %s", c.Rule.Protocol)
 	}
+	if c.Rule.ContentRuleConfig.IsStateful() {
+		// 状态条件放在最后检查，只有内容匹配成功的包才需要查询状态表
+		c.Attach(flowStateMatcher)
+	}
 	return nil
 }
 
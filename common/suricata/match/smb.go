@@ -0,0 +1,56 @@
+package match
+
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/suricata/data/smb"
+)
+
+// smbParser 用于 protocol 为 smb 的规则，只匹配 NetBIOS 会话封装的 SMB 载荷
+// smb.share / smb.named_pipe / smb.ntlmssp_* 从载荷中的 SMB2 消息中提取
+func smbParser(c *matchContext) error {
+	tcp, ok := c.PK.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !c.Must(ok) {
+		return nil
+	}
+	if !c.Must(smb.IsSMBPayload(tcp.Payload)) {
+		return nil
+	}
+
+	var share, pipe, user, domain string
+	for _, msg := range smb.ParseMessages(tcp.Payload) {
+		switch msg.Command {
+		case smb.CommandTreeConnect:
+			if path := msg.TreeConnectPath(); path != "" {
+				share = path
+			}
+		case smb.CommandCreate:
+			if name := msg.CreateName(); name != "" {
+				pipe = name
+			}
+		case smb.CommandSessionSetup:
+			if auth, ok := smb.ParseNTLMSSPAuth(msg.SecurityBuffer()); ok {
+				user, domain = auth.User, auth.Domain
+			}
+		}
+	}
+
+	c.SetBufferProvider(func(mdf modifier.Modifier) []byte {
+		switch mdf {
+		case modifier.TCPHDR:
+			return tcp.Contents
+		case modifier.Default:
+			return tcp.Payload
+		case modifier.SMBShare:
+			return []byte(share)
+		case modifier.SMBNamedPipe:
+			return []byte(pipe)
+		case modifier.SMBNTLMSSPUser:
+			return []byte(user)
+		case modifier.SMBNTLMSSPDomain:
+			return []byte(domain)
+		}
+		return nil
+	})
+	return nil
+}
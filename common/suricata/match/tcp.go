@@ -77,6 +77,8 @@ func newTCPProvider(pk gopacket.Packet) func(modifier modifier.Modifier) []byte
 	if !ok {
 		return nil
 	}
+	var tls *tlsInfo
+	var tlsParsed bool
 	return func(mdf modifier.Modifier) []byte {
 		switch mdf {
 		case modifier.TCPHDR:
@@ -84,6 +86,13 @@ func newTCPProvider(pk gopacket.Packet) func(modifier modifier.Modifier) []byte
 		case modifier.Default:
 			return tcp.Payload
 		}
+		if modifier.IsTLSModifier(mdf) {
+			// tcp 规则也可以使用 tls.sni / ja3.hash 等 buffer
+			if !tlsParsed {
+				tls, tlsParsed = parseTLSInfo(tcp.Payload), true
+			}
+			return tls.buffer(mdf)
+		}
 		return nil
 	}
 }
@@ -0,0 +1,103 @@
+package match
+
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// tlsInfo 是从单个包的载荷中解析出的 TLS 握手信息
+// 客户端的包只有 ClientHello，服务器的包可能同时有 ServerHello 和 Certificate
+type tlsInfo struct {
+	clientHello *tlsutils.HandshakeClientHello
+	serverHello *tlsutils.HandshakeServerHello
+	subject     string
+	issuer      string
+}
+
+func parseTLSInfo(payload []byte) *tlsInfo {
+	msgs := tlsutils.ParseHandshakeMessages(payload)
+	if len(msgs) == 0 {
+		return nil
+	}
+	info := &tlsInfo{}
+	for _, msg := range msgs {
+		switch msg.Type {
+		case tlsutils.HandshakeTypeClientHello:
+			info.clientHello, _ = tlsutils.ParseClientHello(msg.Raw)
+		case tlsutils.HandshakeTypeServerHello:
+			info.serverHello, _ = tlsutils.ParseServerHello(msg.Raw)
+		}
+	}
+	if certs, err := tlsutils.ParseHandshakeCertificates(payload); err == nil && len(certs) > 0 {
+		info.subject = tlsutils.FormatDN(certs[0].Subject)
+		info.issuer = tlsutils.FormatDN(certs[0].Issuer)
+	}
+	if info.clientHello == nil && info.serverHello == nil && info.subject == "" {
+		return nil
+	}
+	return info
+}
+
+func (t *tlsInfo) buffer(mdf modifier.Modifier) []byte {
+	if t == nil {
+		return nil
+	}
+	switch mdf {
+	case modifier.TLSSNI:
+		if t.clientHello != nil {
+			return []byte(t.clientHello.SNI())
+		}
+	case modifier.TLSCertSubject:
+		return []byte(t.subject)
+	case modifier.TLSCertIssuer:
+		return []byte(t.issuer)
+	case modifier.TLSVersion:
+		if t.serverHello != nil {
+			return []byte(tlsutils.TLSVersionName(t.serverHello.SelectedVersion()))
+		}
+		if t.clientHello != nil {
+			return []byte(tlsutils.TLSVersionName(t.clientHello.MaxVersion()))
+		}
+	case modifier.JA3String:
+		if t.clientHello != nil {
+			return []byte(t.clientHello.JA3String())
+		}
+	case modifier.JA3Hash:
+		if t.clientHello != nil {
+			return []byte(codec.Md5(t.clientHello.JA3String()))
+		}
+	case modifier.JA3SString:
+		if t.serverHello != nil {
+			return []byte(t.serverHello.JA3SString())
+		}
+	case modifier.JA3SHash:
+		if t.serverHello != nil {
+			return []byte(codec.Md5(t.serverHello.JA3SString()))
+		}
+	}
+	return nil
+}
+
+// tlsParser 用于 protocol 为 tls 的规则，只有带 TLS 握手的包才会继续匹配
+func tlsParser(c *matchContext) error {
+	tcp, ok := c.PK.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !c.Must(ok) {
+		return nil
+	}
+	info := parseTLSInfo(tcp.Payload)
+	if !c.Must(info != nil) {
+		return nil
+	}
+	c.SetBufferProvider(func(mdf modifier.Modifier) []byte {
+		switch mdf {
+		case modifier.TCPHDR:
+			return tcp.Contents
+		case modifier.Default:
+			return tcp.Payload
+		}
+		return info.buffer(mdf)
+	})
+	return nil
+}
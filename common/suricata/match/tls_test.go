@@ -0,0 +1,101 @@
+package match
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+type recordConn struct {
+	net.Conn
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.lock.Lock()
+	c.buf.Write(b)
+	c.lock.Unlock()
+	return c.Conn.Write(b)
+}
+
+// tlsHandshakeRecords 完成一次 TLS 1.2 握手，返回客户端和服务器写出的原始数据
+func tlsHandshakeRecords(t *testing.T, sni string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Country: []string{"US"}, Organization: []string{"Yak Test"}, CommonName: sni},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{sni},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	c, s := net.Pipe()
+	client, server := &recordConn{Conn: c}, &recordConn{Conn: s}
+	serverConn := tls.Server(server, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MaxVersion:   tls.VersionTLS12,
+	})
+	clientConn := tls.Client(client, &tls.Config{ServerName: sni, InsecureSkipVerify: true})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serverConn.Handshake()
+	}()
+	require.NoError(t, clientConn.Handshake())
+	require.NoError(t, <-errCh)
+	c.Close()
+	s.Close()
+	return client.buf.Bytes(), server.buf.Bytes()
+}
+
+func TestTLSStickyBuffers(t *testing.T) {
+	clientRaw, serverRaw := tlsHandshakeRecords(t, "www.example.com")
+	hello, err := tlsutils.ParseClientHello(clientRaw)
+	require.NoError(t, err)
+	ja3 := codec.Md5(hello.JA3String())
+	serverHello, err := tlsutils.ParseServerHello(serverRaw)
+	require.NoError(t, err)
+	ja3s := codec.Md5(serverHello.JA3SString())
+
+	clientPk := buildTCPPacket(t, "10.0.0.1", 40000, "10.0.0.2", 443, string(clientRaw))
+	serverPk := buildTCPPacket(t, "10.0.0.2", 443, "10.0.0.1", 40000, string(serverRaw))
+
+	for _, c := range []struct {
+		rule   string
+		client bool
+		server bool
+	}{
+		{`alert tls any any -> any any (msg:"sni"; tls.sni; content:"example.com"; endswith; sid:1;)`, true, false},
+		{`alert tcp any any -> any any (msg:"sni in tcp rule"; tls_sni; content:"www.example"; sid:2;)`, true, false},
+		{`alert tls any any -> any any (msg:"ja3"; ja3.hash; content:"` + ja3 + `"; sid:3;)`, true, false},
+		{`alert tls any any -> any any (msg:"ja3 string"; ja3.string; content:"` + hello.JA3String() + `"; sid:4;)`, true, false},
+		{`alert tls any any -> any any (msg:"ja3s"; ja3s.hash; content:"` + ja3s + `"; sid:5;)`, false, true},
+		{`alert tls any any -> any any (msg:"subject"; tls.cert_subject; content:"C=US, O=Yak Test, CN=www.example.com"; sid:6;)`, false, true},
+		{`alert tls any any -> any any (msg:"issuer"; tls.cert_issuer; content:"O=Yak Test"; sid:7;)`, false, true},
+		{`alert tls any any -> any any (msg:"version"; tls.version; content:"1.2"; sid:8;)`, false, true},
+		{`alert tls any any -> any any (msg:"not match"; tls.sni; content:"yaklang.io"; sid:9;)`, false, false},
+	} {
+		rs, err := rule.Parse(c.rule)
+		require.NoError(t, err)
+		m := New(rs[0])
+		require.Equal(t, c.client, m.MatchPackage(clientPk), c.rule)
+		require.Equal(t, c.server, m.MatchPackage(serverPk), c.rule)
+	}
+}
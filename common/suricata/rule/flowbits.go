@@ -0,0 +1,165 @@
+package rule
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+/*
+flowbits / flowint / xbits 是有状态的关键字，需要在多个包之间保存状态才能正确匹配，
+状态由 match.FlowState 保存，这里只负责解析
+
+	flowbits:set,name; flowbits:isset,a|b; flowbits:unset,a&b; flowbits:noalert;
+	flowint:name,+,1; flowint:name,>=,3; flowint:name,isset;
+	xbits:set,name,track ip_src,expire 60;
+*/
+
+const (
+	FlowBitsSet      = "set"
+	FlowBitsUnset    = "unset"
+	FlowBitsToggle   = "toggle"
+	FlowBitsIsSet    = "isset"
+	FlowBitsIsNotSet = "isnotset"
+	FlowBitsNoAlert  = "noalert"
+)
+
+type FlowBitsRule struct {
+	Action string
+	Names  []string
+	// Or 表示名字之间使用 | 连接，只对 isset / isnotset 有意义
+	Or bool
+}
+
+// IsCondition 表示这个 flowbits 是匹配条件，否则是匹配成功之后执行的动作
+func (f *FlowBitsRule) IsCondition() bool {
+	return f.Action == FlowBitsIsSet || f.Action == FlowBitsIsNotSet
+}
+
+type FlowIntRule struct {
+	Name string
+	// Operator: + - = (修改) == != < > <= >= isset notset (条件)
+	Operator string
+	// Value 可以是数字，也可以是另一个 flowint 变量名
+	Value string
+}
+
+func (f *FlowIntRule) IsCondition() bool {
+	switch f.Operator {
+	case "+", "-", "=":
+		return false
+	}
+	return true
+}
+
+type XBitsRule struct {
+	Action string
+	Name   string
+	// Track: ip_src / ip_dst / ip_pair
+	Track string
+	// Expire 秒，为 0 时使用默认值
+	Expire int
+}
+
+func (x *XBitsRule) IsCondition() bool {
+	return x.Action == FlowBitsIsSet || x.Action == FlowBitsIsNotSet
+}
+
+// IsStateful 表示规则中存在需要保存状态的关键字
+func (c *ContentRuleConfig) IsStateful() bool {
+	if c == nil {
+		return false
+	}
+	return len(c.FlowBits) > 0 || len(c.FlowInts) > 0 || len(c.XBits) > 0
+}
+
+func splitStateParams(s string) []string {
+	var ret []string
+	for _, item := range strings.Split(s, ",") {
+		ret = append(ret, strings.TrimSpace(item))
+	}
+	return ret
+}
+
+func parseFlowBits(s string) (*FlowBitsRule, error) {
+	params := splitStateParams(s)
+	action := strings.ToLower(params[0])
+	switch action {
+	case FlowBitsNoAlert:
+		return &FlowBitsRule{Action: action}, nil
+	case FlowBitsSet, FlowBitsUnset, FlowBitsToggle, FlowBitsIsSet, FlowBitsIsNotSet:
+	default:
+		return nil, utils.Errorf("unknown flowbits action: %v", params[0])
+	}
+	if len(params) < 2 || params[1] == "" {
+		return nil, utils.Errorf("flowbits %v need a name", action)
+	}
+	ret := &FlowBitsRule{Action: action}
+	sep := "&"
+	if strings.Contains(params[1], "|") {
+		sep = "|"
+		ret.Or = true
+	}
+	for _, name := range strings.Split(params[1], sep) {
+		if name = strings.TrimSpace(name); name != "" {
+			ret.Names = append(ret.Names, name)
+		}
+	}
+	return ret, nil
+}
+
+func parseFlowInt(s string) (*FlowIntRule, error) {
+	params := splitStateParams(s)
+	if len(params) < 2 || params[0] == "" {
+		return nil, utils.Errorf("invalid flowint: %v", s)
+	}
+	ret := &FlowIntRule{Name: params[0], Operator: strings.ToLower(params[1])}
+	switch ret.Operator {
+	case "isset", "notset", "isnotset":
+		if ret.Operator == "isnotset" {
+			ret.Operator = "notset"
+		}
+		return ret, nil
+	case "+", "-", "=", "==", "!=", "<", ">", "<=", ">=":
+	default:
+		return nil, utils.Errorf("unknown flowint operator: %v", params[1])
+	}
+	if len(params) < 3 || params[2] == "" {
+		return nil, utils.Errorf("flowint %v %v need a value", ret.Name, ret.Operator)
+	}
+	ret.Value = params[2]
+	return ret, nil
+}
+
+func parseXBits(s string) (*XBitsRule, error) {
+	params := splitStateParams(s)
+	action := strings.ToLower(params[0])
+	switch action {
+	case FlowBitsNoAlert:
+		return &XBitsRule{Action: action}, nil
+	case FlowBitsSet, FlowBitsUnset, FlowBitsToggle, FlowBitsIsSet, FlowBitsIsNotSet:
+	default:
+		return nil, utils.Errorf("unknown xbits action: %v", params[0])
+	}
+	if len(params) < 2 || params[1] == "" {
+		return nil, utils.Errorf("xbits %v need a name", action)
+	}
+	ret := &XBitsRule{Action: action, Name: params[1], Track: "ip_pair"}
+	for _, param := range params[2:] {
+		// 语法树中的空白会被丢弃，所以 "track ip_src" 也可能是 "trackip_src"
+		lower := strings.ToLower(param)
+		switch {
+		case strings.HasPrefix(lower, "track"):
+			ret.Track = strings.TrimSpace(lower[len("track"):])
+		case strings.HasPrefix(lower, "expire"):
+			ret.Expire, _ = strconv.Atoi(strings.TrimSpace(lower[len("expire"):]))
+		}
+	}
+	switch ret.Track {
+	case "ip_src", "ip_dst", "ip_pair":
+	default:
+		return nil, utils.Errorf("unknown xbits track: %v", ret.Track)
+	}
+	return ret, nil
+}
@@ -114,6 +114,13 @@ type ContentRuleConfig struct {
 	/* Payload Match */
 	ContentRules []*ContentRule
 
+	/* Stateful: flowbits / flowint / xbits */
+	FlowBits []*FlowBitsRule
+	FlowInts []*FlowIntRule
+	XBits    []*XBitsRule
+	// NoAlert 规则匹配之后只修改状态，不产生告警
+	NoAlert bool
+
 	// PrefilterRule is a contentRuleConfig with no more than single config.
 	// not implement yet
 	PrefilterRule *ContentRuleConfig
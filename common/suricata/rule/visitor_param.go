@@ -76,6 +76,30 @@ func modifierMapping(str string) modifier.Modifier {
 		return modifier.IPv6HDR
 	case "tcp.hdr", "tcp_hdr":
 		return modifier.TCPHDR
+	case "tls.sni", "tls_sni":
+		return modifier.TLSSNI
+	case "tls.cert_subject", "tls_cert_subject":
+		return modifier.TLSCertSubject
+	case "tls.cert_issuer", "tls_cert_issuer":
+		return modifier.TLSCertIssuer
+	case "tls.version":
+		return modifier.TLSVersion
+	case "ja3.hash", "ja3_hash":
+		return modifier.JA3Hash
+	case "ja3.string", "ja3_string":
+		return modifier.JA3String
+	case "ja3s.hash", "ja3s_hash":
+		return modifier.JA3SHash
+	case "ja3s.string", "ja3s_string":
+		return modifier.JA3SString
+	case "smb.share", "smb_share":
+		return modifier.SMBShare
+	case "smb.named_pipe", "smb_named_pipe":
+		return modifier.SMBNamedPipe
+	case "smb.ntlmssp_user", "smb_ntlmssp_user":
+		return modifier.SMBNTLMSSPUser
+	case "smb.ntlmssp_domain", "smb_ntlmssp_domain":
+		return modifier.SMBNTLMSSPDomain
 	}
 	return modifier.Default
 }
@@ -94,7 +118,10 @@ type MultipleBufferMatching struct {
 
 func (m *MultipleBufferMatching) transfer(mdf modifier.Modifier) modifier.Modifier {
 	switch mdf {
-	case modifier.DNSQuery, modifier.FileData, modifier.HTTPHeader:
+	case modifier.DNSQuery, modifier.FileData, modifier.HTTPHeader,
+		modifier.TLSSNI, modifier.TLSCertSubject, modifier.TLSCertIssuer, modifier.TLSVersion,
+		modifier.JA3Hash, modifier.JA3String, modifier.JA3SHash, modifier.JA3SString,
+		modifier.SMBShare, modifier.SMBNamedPipe, modifier.SMBNTLMSSPUser, modifier.SMBNTLMSSPDomain:
 		m.last = mdf
 	case modifier.Default:
 		mdf = m.last
@@ -469,16 +496,43 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			contentRule.FastPattern = true
 		case "flowbits":
 			contentRule.FlowBits = vStr
+			flowbits, err := parseFlowBits(vStr)
+			if err != nil {
+				log.Errorf("parse flowbits err:%v", err)
+				continue
+			}
+			if flowbits.Action == FlowBitsNoAlert {
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.FlowBits = append(rule.ContentRuleConfig.FlowBits, flowbits)
 		case "noalert":
 			contentRule.NoAlert = true
+			rule.ContentRuleConfig.NoAlert = true
 		case "base64_decode":
 			contentRule.Base64Decode = vStr
 		case "base64_data":
 			contentRule.Base64Data = true
 		case "flowint":
 			contentRule.FlowInt = vStr
+			flowint, err := parseFlowInt(vStr)
+			if err != nil {
+				log.Errorf("parse flowint err:%v", err)
+				continue
+			}
+			rule.ContentRuleConfig.FlowInts = append(rule.ContentRuleConfig.FlowInts, flowint)
 		case "xbits":
 			contentRule.XBits = vStr
+			xbits, err := parseXBits(vStr)
+			if err != nil {
+				log.Errorf("parse xbits err:%v", err)
+				continue
+			}
+			if xbits.Action == FlowBitsNoAlert {
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.XBits = append(rule.ContentRuleConfig.XBits, xbits)
 		case "app-layer-event":
 			contentRule.ExtraFlags = append(contentRule.ExtraFlags, fmt.Sprintf("%v:%v", key, vStr))
 		default:
@@ -8,6 +8,7 @@ import (
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
 	"net"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,8 +40,15 @@ type Scanner struct {
 	defaultSrcIp     net.IP
 	defaultGatewayIp net.IP
 
-	_cache_eth          gopacket.SerializableLayer
-	_loopback_linklayer gopacket.SerializableLayer
+	// IPv6 目标使用的源地址、网关和网关的 mac 地址
+	defaultDstHw6     net.HardwareAddr
+	defaultSrcIp6     net.IP
+	defaultGatewayIp6 net.IP
+
+	_cache_eth           gopacket.SerializableLayer
+	_cache_eth6          gopacket.SerializableLayer
+	_loopback_linklayer  gopacket.SerializableLayer
+	_loopback_linklayer6 gopacket.SerializableLayer
 
 	arpHandlerMutex *sync.Mutex
 	arpHandlers     map[string]arpHandler
@@ -64,16 +72,53 @@ func (s *Scanner) SetRateLimit(ms float64, count int) {
 	s.delayGapCount = count
 }
 
-func (s *Scanner) getLoopbackLinkLayer() gopacket.SerializableLayer {
+func (s *Scanner) getLoopbackLinkLayer(v6 bool) gopacket.SerializableLayer {
+	if v6 {
+		if s._loopback_linklayer6 == nil {
+			s._loopback_linklayer6 = newLoopbackLinkLayer(true)
+		}
+		return s._loopback_linklayer6
+	}
 	if s._loopback_linklayer != nil {
 		return s._loopback_linklayer
 	}
-	s._loopback_linklayer = &layers.Loopback{
-		Family: layers.ProtocolFamilyIPv4,
+	s._loopback_linklayer = newLoopbackLinkLayer(false)
+	return s.getLoopbackLinkLayer(false)
+}
+
+// newLoopbackLinkLayer 创建 loopback 链路层，IPv6 的协议族编号在不同系统上不同
+func newLoopbackLinkLayer(v6 bool) *layers.Loopback {
+	if !v6 {
+		return &layers.Loopback{Family: layers.ProtocolFamilyIPv4}
+	}
+	switch runtime.GOOS {
+	case "darwin", "ios":
+		return &layers.Loopback{Family: layers.ProtocolFamilyIPv6Darwin}
+	case "freebsd", "dragonfly":
+		return &layers.Loopback{Family: layers.ProtocolFamilyIPv6FreeBSD}
+	case "linux":
+		return &layers.Loopback{Family: layers.ProtocolFamilyIPv6Linux}
+	default:
+		return &layers.Loopback{Family: layers.ProtocolFamilyIPv6BSD}
+	}
+}
+
+// gatewayFor 返回目标所在协议族的网关，没有网关时返回空字符串
+func (s *Scanner) gatewayFor(dstIp net.IP) string {
+	gateway := s.defaultGatewayIp
+	if isIPv6(dstIp) {
+		gateway = s.defaultGatewayIp6
+	}
+	if gateway == nil {
+		return ""
 	}
-	return s.getLoopbackLinkLayer()
+	return gateway.String()
 }
 
+// synScanBPFFilter 捕获 arp / 邻居通告和 syn 包，tcp[tcpflags] 只对 IPv4 有效，
+// IPv6 没有扩展头时 tcp flags 位于 ip6[53]
+const synScanBPFFilter = "(arp) or (tcp[tcpflags] & (tcp-syn) != 0) or (ip6 and tcp and (ip6[53] & 0x02 != 0)) or (icmp6 and ip6[40] == 136)"
+
 var (
 	cacheEthernetLock = new(sync.Mutex)
 )
@@ -85,8 +130,16 @@ func (s *Scanner) getDefaultEthernet(target string, dstPort int, gateway string)
 	cacheEthernetLock.Lock()
 	defer cacheEthernetLock.Unlock()
 
+	// IPv4 和 IPv6 的网关可能不同，分别缓存
+	cacheEth, defaultDstHw := &s._cache_eth, &s.defaultDstHw
+	ethernetType := layers.EthernetTypeIPv4
+	if isIPv6(net.ParseIP(utils.FixForParseIP(target))) {
+		cacheEth, defaultDstHw = &s._cache_eth6, &s.defaultDstHw6
+		ethernetType = layers.EthernetTypeIPv6
+	}
+
 	// 在加锁之后再判断一次
-	if s._cache_eth != nil && s.defaultDstHw != nil {
+	if *cacheEth != nil && *defaultDstHw != nil {
 		return nil
 	}
 
@@ -104,12 +157,12 @@ func (s *Scanner) getDefaultEthernet(target string, dstPort int, gateway string)
 			log.Warnf("ArpWithTimeout cannot found dstHw: %v, target: %v, iface: %v, gateway: %v", err, target, s.iface.Name, gateway)
 		}
 		if dstHw != nil && srcHw != nil {
-			s._cache_eth = &layers.Ethernet{
+			*cacheEth = &layers.Ethernet{
 				SrcMAC:       srcHw,
 				DstMAC:       dstHw,
-				EthernetType: layers.EthernetTypeIPv4,
+				EthernetType: ethernetType,
 			}
-			s.defaultDstHw = dstHw
+			*defaultDstHw = dstHw
 			log.Infof("use arpx proto to fetch gateway's hw address: %s", dstHw.String())
 			return nil
 		}
@@ -145,12 +198,12 @@ func (s *Scanner) getDefaultEthernet(target string, dstPort int, gateway string)
 	case <-timer.C:
 		return utils.Errorf("cannot fetch hw addr for %v[%v]", target, s.iface.Name)
 	case hw := <-s.macChan:
-		s._cache_eth = &layers.Ethernet{
+		*cacheEth = &layers.Ethernet{
 			SrcMAC:       hw[0],
 			DstMAC:       hw[1],
-			EthernetType: layers.EthernetTypeIPv4,
+			EthernetType: ethernetType,
 		}
-		s.defaultDstHw = hw[1]
+		*defaultDstHw = hw[1]
 		return nil
 	}
 }
@@ -158,13 +211,17 @@ func (s *Scanner) getDefaultEthernet(target string, dstPort int, gateway string)
 func (s *Scanner) getDefaultCacheEthernet(target string, dstPort int, gateway string) (gopacket.SerializableLayer, error) {
 	var err error
 
-	if s._cache_eth != nil && s.defaultDstHw != nil {
-		return s._cache_eth, nil
+	cacheEth, defaultDstHw := &s._cache_eth, &s.defaultDstHw
+	if isIPv6(net.ParseIP(utils.FixForParseIP(target))) {
+		cacheEth, defaultDstHw = &s._cache_eth6, &s.defaultDstHw6
+	}
+	if *cacheEth != nil && *defaultDstHw != nil {
+		return *cacheEth, nil
 	}
 	count := 0
 	for {
 		if err = s.getDefaultEthernet(target, dstPort, gateway); err == nil {
-			return s._cache_eth, nil
+			return *cacheEth, nil
 		}
 		count += 1
 		if count > 5 {
@@ -181,7 +238,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	}
 	_ = gatewayIp
 	// 检测本地回环
-	isLoopback := srcIp.IsLoopback()
+	isLoopback := srcIp.IsLoopback() || (srcIp == nil && config.SourceIPv6.IsLoopback())
 
 	log.Debugf("start to init network dev: %v", iface.Name)
 	// 初始化本地端口，用来扫描本地环回地址
@@ -239,8 +296,10 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 		//handler:               handler,
 		//localHandler:          localHandler,
 
-		defaultSrcIp:     srcIp,
-		defaultGatewayIp: gatewayIp,
+		defaultSrcIp:      srcIp,
+		defaultGatewayIp:  gatewayIp,
+		defaultSrcIp6:     config.SourceIPv6,
+		defaultGatewayIp6: config.GatewayIPv6,
 
 		opts: gopacket.SerializeOptions{
 			FixLengths:       true,
@@ -271,6 +330,12 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 			}
 		}
 
+		// IPv6 使用邻居通告代替 arp 应答
+		if ip, hw, ok := arpx.ParseNeighborAdvertisement(packet); ok {
+			scanner.onARP(ip, hw)
+			return
+		}
+
 		if tcpSynLayer := packet.TransportLayer(); tcpSynLayer != nil {
 			l, ok := tcpSynLayer.(*layers.TCP)
 			if !ok {
@@ -316,7 +381,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 			err := pcaputil.Start(
 				pcaputil.WithDevice(iface.Name),
 				pcaputil.WithEnableCache(true),
-				pcaputil.WithBPFFilter("(arp) or (tcp[tcpflags] & (tcp-syn) != 0)"),
+				pcaputil.WithBPFFilter(synScanBPFFilter),
 				pcaputil.WithContext(ctx),
 				pcaputil.WithNetInterfaceCreated(func(handle *pcap.Handle) {
 					go func() {
@@ -383,7 +448,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 		err := pcaputil.Start(
 			pcaputil.WithDevice(localIfaceName),
 			pcaputil.WithEnableCache(true),
-			pcaputil.WithBPFFilter("(arp) or (tcp[tcpflags] & (tcp-syn) != 0)"),
+			pcaputil.WithBPFFilter(synScanBPFFilter),
 			pcaputil.WithContext(ctx),
 			pcaputil.WithNetInterfaceCreated(func(handle *pcap.Handle) {
 				go func() {
@@ -431,7 +496,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	//	scanner.defaultDstHw = nil
 	//}
 
-	_ = scanner.getLoopbackLinkLayer()
+	_ = scanner.getLoopbackLinkLayer(false)
 
 	return scanner, nil
 }
@@ -0,0 +1,206 @@
+package synscan
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// DefaultCheckpointInterval 是保存扫描进度的默认间隔
+var DefaultCheckpointInterval = 5 * time.Second
+
+// Checkpoint 记录一次可恢复扫描的进度
+//
+// 所有的 host * port 组合按照 Seed 生成的确定性排列发送，下标小于 Index 的组合都已经发送完成，
+// 所以只需要保存 Targets / Ports / Seed / Index 就可以在扫描中断之后从 Index 处继续；
+// Targets 中 host:port 形式的目标除了扫描 host 的所有端口，还会额外扫描其中的端口，与不使用进度时一致
+type Checkpoint struct {
+	Targets  string `json:"targets"`
+	Ports    string `json:"ports"`
+	Seed     int64  `json:"seed"`
+	Total    uint64 `json:"total"`
+	Index    uint64 `json:"index"`
+	Finished bool   `json:"finished"`
+	// UpdatedAt 是最后一次保存进度的时间戳
+	UpdatedAt int64 `json:"updated_at"`
+	// OriginTargets / OriginPorts 是发起扫描时的原始参数，例如存活探测之前的目标和过滤之前的端口
+	OriginTargets string `json:"origin_targets,omitempty"`
+	OriginPorts   string `json:"origin_ports,omitempty"`
+
+	// Interval 是保存进度的间隔，为 0 时使用 DefaultCheckpointInterval
+	Interval time.Duration `json:"-"`
+	// Exclude 返回 true 的组合不会发包，但是仍然计入进度
+	Exclude func(host string, port int) bool `json:"-"`
+
+	hosts     []string
+	portsList []int
+	// extra 是 host:port 目标中的端口，排在 host * port 组合之后
+	extra []extraTarget
+}
+
+type extraTarget struct {
+	host string
+	port int
+}
+
+// NewCheckpoint 为 targets 和 ports 创建一个新的扫描进度，targets 和 ports 的格式与 Scan 相同
+func NewCheckpoint(targets string, ports string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		Targets: targets,
+		Ports:   ports,
+		Seed:    time.Now().UnixNano(),
+	}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ParseCheckpoint 从 json 中恢复扫描进度
+func ParseCheckpoint(raw []byte) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, utils.Errorf("unmarshal checkpoint failed: %v", err)
+	}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *Checkpoint) init() error {
+	c.hosts, c.extra = nil, nil
+	seen := make(map[string]bool)
+	for _, target := range utils.ParseStringToHosts(c.Targets) {
+		host := target
+		if h, port, _ := utils.ParseStringToHostPort(target); port > 0 {
+			host = h
+			c.extra = append(c.extra, extraTarget{host: h, port: port})
+		}
+		if !seen[host] {
+			seen[host] = true
+			c.hosts = append(c.hosts, host)
+		}
+	}
+	c.portsList = utils.ParseStringToPorts(c.Ports)
+	if len(c.hosts) <= 0 {
+		return utils.Errorf("checkpoint has no valid targets: %v", c.Targets)
+	}
+	if len(c.portsList) <= 0 {
+		return utils.Errorf("checkpoint has no valid ports: %v", c.Ports)
+	}
+	total := uint64(len(c.hosts))*uint64(len(c.portsList)) + uint64(len(c.extra))
+	if c.Total != 0 && c.Total != total {
+		return utils.Errorf("checkpoint total mismatch: saved %v, parsed %v", c.Total, total)
+	}
+	c.Total = total
+	if c.Index > c.Total {
+		c.Index = c.Total
+	}
+	return nil
+}
+
+// Match 判断这个进度是否属于 targets 和 ports 组成的扫描任务，扫描目标或者原始参数一致都视为同一个任务
+func (c *Checkpoint) Match(targets string, ports string) bool {
+	if c == nil {
+		return false
+	}
+	if c.Targets == targets && c.Ports == ports {
+		return true
+	}
+	return c.OriginTargets != "" && c.OriginTargets == targets && c.OriginPorts == ports
+}
+
+// Progress 返回扫描进度，范围为 0 - 1
+func (c *Checkpoint) Progress() float64 {
+	if c == nil || c.Total == 0 {
+		return 0
+	}
+	if c.Finished {
+		return 1
+	}
+	return float64(c.Index) / float64(c.Total)
+}
+
+// Hosts 返回展开之后的所有目标，host:port 形式的目标只保留 host
+func (c *Checkpoint) Hosts() []string {
+	if c.hosts == nil {
+		_ = c.init()
+	}
+	return c.hosts
+}
+
+// Walk 从 Index 开始按照排列顺序遍历剩下的 host / port 组合
+//
+// pending 返回已经提交但是还没有写入网卡的数据包数量，保存进度时会从下标中扣除，保证中断之后不会漏扫；
+// save 每隔 Interval 调用一次，ctx 结束和遍历完成时也会调用；所有回调都在同一个 goroutine 中执行
+func (c *Checkpoint) Walk(ctx context.Context, handle func(host string, port int), pending func() int, save func(*Checkpoint)) error {
+	if c.hosts == nil || c.portsList == nil {
+		if err := c.init(); err != nil {
+			return err
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+
+	start := c.Index
+	commit := func(sent uint64) {
+		if pending != nil {
+			if p := uint64(pending()); p < sent-start {
+				sent -= p
+			} else {
+				sent = start
+			}
+		}
+		c.Index = sent
+		c.UpdatedAt = time.Now().Unix()
+		if save != nil {
+			save(c)
+		}
+	}
+
+	perm := utils.NewPermutation(c.Total, c.Seed)
+	portCount := uint64(len(c.portsList))
+	grid := uint64(len(c.hosts)) * portCount
+	lastSave := time.Now()
+	for i := start; i < c.Total; i++ {
+		if ctx.Err() != nil {
+			commit(i)
+			return ctx.Err()
+		}
+		var host string
+		var port int
+		if j := perm.At(i); j < grid {
+			host, port = c.hosts[j/portCount], c.portsList[j%portCount]
+		} else {
+			host, port = c.extra[j-grid].host, c.extra[j-grid].port
+		}
+		if c.Exclude == nil || !c.Exclude(host, port) {
+			handle(host, port)
+		}
+
+		if time.Since(lastSave) >= interval {
+			lastSave = time.Now()
+			commit(i + 1)
+		}
+	}
+	commit(c.Total)
+	return nil
+}
+
+// Finish 标记扫描已经完成并保存
+func (c *Checkpoint) Finish(save func(*Checkpoint)) {
+	c.Index = c.Total
+	c.Finished = true
+	c.UpdatedAt = time.Now().Unix()
+	if save != nil {
+		save(c)
+	}
+}
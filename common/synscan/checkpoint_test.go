@@ -0,0 +1,89 @@
+package synscan
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestCheckpointWalkResume(t *testing.T) {
+	cp, err := NewCheckpoint("192.168.0.0/24,10.0.0.1", "22,80,443,8000-8010")
+	require.NoError(t, err)
+	require.EqualValues(t, 257*14, cp.Total)
+
+	// 第一次扫描在中途被取消
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := make(map[string]int)
+	var saved []byte
+	save := func(c *Checkpoint) {
+		saved, err = json.Marshal(c)
+		require.NoError(t, err)
+	}
+	err = cp.Walk(ctx, func(host string, port int) {
+		sent[utils.HostPort(host, port)]++
+		if len(sent) == 1000 {
+			cancel()
+		}
+	}, func() int {
+		// 模拟还有 100 个包没有写入网卡
+		return 100
+	}, save)
+	require.ErrorIs(t, err, context.Canceled)
+
+	resumed, err := ParseCheckpoint(saved)
+	require.NoError(t, err)
+	require.EqualValues(t, 900, resumed.Index)
+	require.False(t, resumed.Finished)
+
+	// 恢复之后会重发还没有写入网卡的包，并且覆盖剩下所有的组合
+	require.NoError(t, resumed.Walk(context.Background(), func(host string, port int) {
+		sent[utils.HostPort(host, port)]++
+	}, nil, save))
+	resumed.Finish(save)
+	require.Len(t, sent, int(cp.Total))
+	resent := 0
+	for _, count := range sent {
+		if count > 1 {
+			resent++
+		}
+	}
+	require.Equal(t, 100, resent)
+
+	final, err := ParseCheckpoint(saved)
+	require.NoError(t, err)
+	require.True(t, final.Finished)
+	require.Equal(t, 1.0, final.Progress())
+	require.True(t, final.Match("192.168.0.0/24,10.0.0.1", "22,80,443,8000-8010"))
+
+	// 存活探测之后的目标与原始目标不同，两者都可以匹配
+	final.OriginTargets, final.OriginPorts = "192.168.0.0/23", "top100"
+	require.True(t, final.Match("192.168.0.0/23", "top100"))
+	require.True(t, final.Match("192.168.0.0/24,10.0.0.1", "22,80,443,8000-8010"))
+	require.False(t, final.Match("192.168.0.0/23", "22,80,443,8000-8010"))
+}
+
+func TestCheckpointHostPortTarget(t *testing.T) {
+	cp, err := NewCheckpoint("10.0.0.1:8443,10.0.0.2", "22,80")
+	require.NoError(t, err)
+	require.EqualValues(t, 2*2+1, cp.Total)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cp.Hosts())
+
+	// host:port 目标扫描 host 的所有端口，并额外扫描其中的端口
+	var sent []string
+	require.NoError(t, cp.Walk(context.Background(), func(host string, port int) {
+		sent = append(sent, utils.HostPort(host, port))
+	}, nil, nil))
+	require.ElementsMatch(t, []string{
+		"10.0.0.1:22", "10.0.0.1:80", "10.0.0.1:8443",
+		"10.0.0.2:22", "10.0.0.2:80",
+	}, sent)
+
+	raw, err := json.Marshal(cp)
+	require.NoError(t, err)
+	resumed, err := ParseCheckpoint(raw)
+	require.NoError(t, err)
+	require.EqualValues(t, cp.Total, resumed.Total)
+}
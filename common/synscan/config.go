@@ -16,6 +16,10 @@ type Config struct {
 	GatewayIP net.IP
 	SourceIP  net.IP
 
+	// IPv6 目标使用的网关和源地址，双栈网络下与 IPv4 的配置同时存在
+	GatewayIPv6 net.IP
+	SourceIPv6  net.IP
+
 	// Fetch Gateway Hardware Address TimeoutSeconds
 	FetchGatewayHardwareAddressTimeout time.Duration
 }
@@ -41,9 +45,75 @@ func NewConfig(options ...ConfigOption) (*Config, error) {
 	if config.Iface == nil {
 		return nil, errors.New("config default net.Interface failed: empty iface")
 	}
+	config.completeDualStack()
 	return config, nil
 }
 
+// completeDualStack 把 IPv6 的源地址和网关移动到 IPv6 的配置中，并使用网卡上的地址补全缺失的源地址
+func (c *Config) completeDualStack() {
+	if isIPv6(c.SourceIP) {
+		if c.SourceIPv6 == nil {
+			c.SourceIPv6 = c.SourceIP
+		}
+		c.SourceIP = nil
+	}
+	if isIPv6(c.GatewayIP) {
+		if c.GatewayIPv6 == nil {
+			c.GatewayIPv6 = c.GatewayIP
+		}
+		c.GatewayIP = nil
+	}
+
+	v4, v6 := ifaceSourceAddresses(c.Iface)
+	if c.SourceIP == nil {
+		c.SourceIP = v4
+	}
+	if c.SourceIPv6 == nil {
+		c.SourceIPv6 = v6
+	}
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil && ip.To16() != nil
+}
+
+// ifaceSourceAddresses 返回网卡上的 IPv4 地址和 IPv6 地址，IPv6 优先使用全局单播地址
+func ifaceSourceAddresses(iface *net.Interface) (v4 net.IP, v6 net.IP) {
+	if iface == nil {
+		return nil, nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil
+	}
+	var linkLocal net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		switch {
+		case ip.To4() != nil:
+			if v4 == nil {
+				v4 = ip
+			}
+		case ip.IsLinkLocalUnicast():
+			if linkLocal == nil {
+				linkLocal = ip
+			}
+		case ip.IsGlobalUnicast() || ip.IsLoopback():
+			if v6 == nil {
+				v6 = ip
+			}
+		}
+	}
+	if v6 == nil {
+		v6 = linkLocal
+	}
+	return v4, v6
+}
+
 type ConfigOption func(config *Config)
 
 func WithNetInterface(iface *net.Interface) ConfigOption {
@@ -64,6 +134,18 @@ func WithDefaultSourceIP(ip net.IP) ConfigOption {
 	}
 }
 
+func WithGatewayIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.GatewayIPv6 = ip
+	}
+}
+
+func WithDefaultSourceIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.SourceIPv6 = ip
+	}
+}
+
 func CreateConfigOptionsByIfaceName(ifaceName string) ([]ConfigOption, error) {
 	var iface *net.Interface
 	var err error
@@ -117,9 +199,22 @@ func CreateConfigOptionsByTargetNetworkOrDomain(targetRaw string, duration time.
 		WithGatewayIP(gIp),
 		WithNetInterface(iface),
 	}
+
+	// 双栈网络：同一张网卡上有 IPv6 默认路由时，记录 IPv6 的网关，用于扫描 IPv6 目标
+	if !isIPv6(sIp) {
+		if _, v6 := ifaceSourceAddresses(iface); v6 != nil && v6.IsGlobalUnicast() && !v6.IsLinkLocalUnicast() {
+			iface6, gIp6, sIp6, err := netutil.Route(duration, publicIPv6Anchor)
+			if err == nil && iface6 != nil && iface6.Name == iface.Name {
+				opts = append(opts, WithGatewayIPv6(gIp6), WithDefaultSourceIPv6(sIp6))
+			}
+		}
+	}
 	return opts, nil
 }
 
+// publicIPv6Anchor 用于查询 IPv6 默认路由，不会真正发包
+const publicIPv6Anchor = "2001:4860:4860::8888"
+
 func WithIntervalMilliseconds(interval int) ConfigOption {
 	return func(config *Config) {
 	}
@@ -9,14 +9,21 @@ import (
 	"net"
 )
 
-var loopbackIP net.IP
+var loopbackIP, loopbackIPv6 net.IP
 
 func init() {
 	loopbackIP = net.ParseIP("127.0.0.1")
+	loopbackIPv6 = net.IPv6loopback
 }
 
 // dstMac 为空的话，会尝试自动去取一个
 func (s *Scanner) createTCPWithDstMac(dstIp net.IP, dstPort int, syn bool, rst bool, dstMac net.HardwareAddr, gateway string) (_ []gopacket.SerializableLayer, loopback bool, _ error) {
+	v6 := isIPv6(dstIp)
+	ethernetType := layers.EthernetTypeIPv4
+	if v6 {
+		ethernetType = layers.EthernetTypeIPv6
+	}
+
 	var baseLayer gopacket.SerializableLayer
 	var err error
 	if dstMac == nil {
@@ -26,26 +33,45 @@ func (s *Scanner) createTCPWithDstMac(dstIp net.IP, dstPort int, syn bool, rst b
 				return nil, false, err
 			}
 		} else {
-			baseLayer = s.getLoopbackLinkLayer()
+			baseLayer = s.getLoopbackLinkLayer(v6)
 			loopback = true
 		}
 	} else {
 		baseLayer = &layers.Ethernet{
 			SrcMAC:       s.iface.HardwareAddr,
 			DstMAC:       dstMac,
-			EthernetType: layers.EthernetTypeIPv4,
+			EthernetType: ethernetType,
 		}
 	}
 
-	ip4 := layers.IPv4{
-		Version:  4,
-		TTL:      255,
-		Protocol: layers.IPProtocolTCP,
-		SrcIP:    s.defaultSrcIp,
-		DstIP:    dstIp,
-	}
-	if loopback {
-		ip4.SrcIP = loopbackIP
+	var networkLayer gopacket.NetworkLayer
+	if v6 {
+		if s.defaultSrcIp6 == nil && !loopback {
+			return nil, loopback, errors.Errorf("iface %v has no ipv6 address for %v", s.iface.Name, dstIp.String())
+		}
+		ip6 := &layers.IPv6{
+			Version:    6,
+			HopLimit:   255,
+			NextHeader: layers.IPProtocolTCP,
+			SrcIP:      s.defaultSrcIp6,
+			DstIP:      dstIp,
+		}
+		if loopback {
+			ip6.SrcIP = loopbackIPv6
+		}
+		networkLayer = ip6
+	} else {
+		ip4 := &layers.IPv4{
+			Version:  4,
+			TTL:      255,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    s.defaultSrcIp,
+			DstIP:    dstIp,
+		}
+		if loopback {
+			ip4.SrcIP = loopbackIP
+		}
+		networkLayer = ip4
 	}
 	tcp := layers.TCP{
 		SrcPort: layers.TCPPort(rand.Intn(65534) + 1),
@@ -65,18 +91,17 @@ func (s *Scanner) createTCPWithDstMac(dstIp net.IP, dstPort int, syn bool, rst b
 		tcp.Window = 0
 		tcp.Options = nil
 	}
-	err = tcp.SetNetworkLayerForChecksum(&ip4)
+	err = tcp.SetNetworkLayerForChecksum(networkLayer)
 	if err != nil {
-		return nil, loopback, errors.Errorf("ip4 set network layer checksum failed: %s", err)
+		return nil, loopback, errors.Errorf("ip set network layer checksum failed: %s", err)
 	}
 
 	if baseLayer == nil {
-		baseLayer = &layers.Loopback{
-			Family: layers.ProtocolFamilyIPv4,
-		}
+		// vpn 等没有链路层地址的网卡
+		baseLayer = newLoopbackLinkLayer(v6)
 	}
 	return []gopacket.SerializableLayer{
-		baseLayer, &ip4, &tcp,
+		baseLayer, networkLayer.(gopacket.SerializableLayer), &tcp,
 	}, loopback, nil
 }
 
@@ -0,0 +1,79 @@
+package synscan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSynTCP_IPv6(t *testing.T) {
+	srcMac, _ := net.ParseMAC("02:00:00:00:00:01")
+	dstMac, _ := net.ParseMAC("02:00:00:00:00:02")
+	s := &Scanner{
+		iface:         &net.Interface{Name: "test0", HardwareAddr: srcMac},
+		defaultSrcIp:  net.ParseIP("192.168.1.2"),
+		defaultSrcIp6: net.ParseIP("2001:db8::2"),
+	}
+
+	check := func(dst string, ethType layers.EthernetType) gopacket.Packet {
+		ls, loopback, err := s.createSynTCP(net.ParseIP(dst), 443, dstMac, "")
+		require.NoError(t, err)
+		require.False(t, loopback)
+
+		buf := gopacket.NewSerializeBuffer()
+		require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ls...))
+		packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+		require.Nil(t, packet.ErrorLayer())
+		require.Equal(t, ethType, packet.LinkLayer().(*layers.Ethernet).EthernetType)
+		tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		require.True(t, ok)
+		require.True(t, tcp.SYN)
+		require.EqualValues(t, 443, tcp.DstPort)
+		return packet
+	}
+
+	packet := check("2001:db8::1", layers.EthernetTypeIPv6)
+	ip6 := packet.NetworkLayer().(*layers.IPv6)
+	require.Equal(t, "2001:db8::2", ip6.SrcIP.String())
+	require.Equal(t, layers.IPProtocolTCP, ip6.NextHeader)
+
+	packet = check("192.168.1.1", layers.EthernetTypeIPv4)
+	require.Equal(t, "192.168.1.2", packet.NetworkLayer().(*layers.IPv4).SrcIP.String())
+
+	// 网卡没有 IPv6 地址时无法扫描 IPv6 目标
+	s.defaultSrcIp6 = nil
+	_, _, err := s.createSynTCP(net.ParseIP("2001:db8::1"), 443, dstMac, "")
+	require.Error(t, err)
+}
+
+func TestConfigCompleteDualStack(t *testing.T) {
+	config := &Config{
+		SourceIP:  net.ParseIP("2001:db8::2"),
+		GatewayIP: net.ParseIP("fe80::1"),
+	}
+	config.completeDualStack()
+	require.Nil(t, config.SourceIP)
+	require.Nil(t, config.GatewayIP)
+	require.Equal(t, "2001:db8::2", config.SourceIPv6.String())
+	require.Equal(t, "fe80::1", config.GatewayIPv6.String())
+}
+
+func TestGatewayForWithoutIPv6Gateway(t *testing.T) {
+	s := &Scanner{defaultGatewayIp: net.ParseIP("192.168.1.1")}
+	require.Equal(t, "192.168.1.1", s.gatewayFor(net.ParseIP("8.8.8.8")))
+	// 没有 IPv6 网关时不能返回 "<nil>"，IPv6 目标按照链路内邻居解析 mac 地址
+	require.Equal(t, "", s.gatewayFor(net.ParseIP("2001:db8::1")))
+
+	_, ifNet, _ := net.ParseCIDR("192.168.1.2/24")
+	addrs := []net.Addr{ifNet}
+	require.True(t, s.isOnLink(addrs, net.ParseIP("2001:db8::1")))
+	require.True(t, s.isOnLink(addrs, net.ParseIP("192.168.1.3")))
+	require.False(t, s.isOnLink(addrs, net.ParseIP("8.8.8.8")))
+
+	s.defaultGatewayIp6 = net.ParseIP("fe80::1")
+	require.Equal(t, "fe80::1", s.gatewayFor(net.ParseIP("2001:db8::1")))
+	require.False(t, s.isOnLink(addrs, net.ParseIP("2001:db8::1")))
+}
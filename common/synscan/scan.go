@@ -73,7 +73,7 @@ func (s *Scanner) scanPublic(publicHosts []string, ports []int, random bool) err
 				defer swg.Done()
 
 				log.Debugf("create syn packet for %v", utils.HostPort(dstIp.String(), i.port))
-				layers, loopback, err := s.createSynTCP(dstIp, i.port, nil, s.defaultGatewayIp.String())
+				layers, loopback, err := s.createSynTCP(dstIp, i.port, nil, s.gatewayFor(dstIp))
 				if err != nil {
 					log.Warnf("cannot create syn-tcp packet for %s:%v err: %v", dstIp.String(), i.port, err)
 					return
@@ -91,7 +91,7 @@ func (s *Scanner) scanPublic(publicHosts []string, ports []int, random bool) err
 				ip := netx.LookupFirst(dstTarget)
 				if ip != "" {
 					if dstIp := net.ParseIP(ip); dstIp != nil {
-						layers, loopback, err := s.createSynTCP(dstIp, i.port, nil, s.defaultGatewayIp.String())
+						layers, loopback, err := s.createSynTCP(dstIp, i.port, nil, s.gatewayFor(dstIp))
 						if err != nil {
 							log.Warnf("cannot create syn-tcp packet for %s:%v: %v", dstIp.String(), i.port, err)
 							return
@@ -190,14 +190,9 @@ func (s *Scanner) scan(host string, port string, random bool, noWait bool) error
 		// 判断是不是当前网卡内网的地址？如果是，就添加到内网扫描中
 		// 内网扫描需要先去找 MAC 地址
 		setPrivate := false
-		for _, addr := range addrs {
-			ifNet, ok := addr.(*net.IPNet)
-			targetHost := net.ParseIP(host)
-			if ok && targetHost != nil && ifNet.Contains(targetHost) {
-				privateHosts = append(privateHosts, host)
-				setPrivate = true
-				break
-			}
+		if s.isOnLink(addrs, net.ParseIP(host)) {
+			privateHosts = append(privateHosts, host)
+			setPrivate = true
 		}
 
 		// 公网扫描，一般来说网管地址就是目的 MAC，不需要额外处理
@@ -267,3 +262,115 @@ func (s *Scanner) RandomScan(host string, port string, noWait bool) error {
 func (s *Scanner) Scan(host string, port string, noWait bool) error {
 	return s.scan(host, port, false, noWait)
 }
+
+// ScanWithCheckpoint 按照 checkpoint 中的确定性排列发送 SYN 包，从 checkpoint.Index 处开始，
+// 扫描过程中通过 save 定期保存进度，所有数据包写入网卡之后把 checkpoint 标记为完成
+func (s *Scanner) ScanWithCheckpoint(cp *Checkpoint, save func(*Checkpoint)) error {
+	if cp == nil {
+		return utils.Error("empty checkpoint")
+	}
+	hosts := cp.Hosts()
+	privateHosts := s.privateHosts(hosts)
+
+	// 内网目标需要先通过 arp 找到 mac 地址，找不到的主机不发包
+	var hwAddrs map[string]net.HardwareAddr
+	if len(privateHosts) > 0 {
+		log.Infof("start to locate mac addr for %v private hosts", len(privateHosts))
+		var err error
+		hwAddrs, err = arpx.ArpIPAddressesWithContext(utils.TimeoutContextSeconds(5), s.iface.Name, strings.Join(privateHosts, ","))
+		if err != nil {
+			log.Errorf("create arpx results from privateHosts failed: %s", err)
+		}
+	}
+	isPrivate := make(map[string]bool, len(privateHosts))
+	for _, host := range privateHosts {
+		isPrivate[host] = true
+	}
+
+	resolved := make(map[string]net.IP)
+	resolve := func(host string) net.IP {
+		target := utils.FixForParseIP(host)
+		if ip := net.ParseIP(target); ip != nil {
+			return ip
+		}
+		if ip, ok := resolved[target]; ok {
+			return ip
+		}
+		ip := net.ParseIP(netx.LookupFirst(target))
+		if ip == nil {
+			log.Warnf("cannot query dns for %v", target)
+		}
+		resolved[target] = ip
+		return ip
+	}
+
+	log.Infof("start to scan %v packets from index %v", cp.Total-cp.Index, cp.Index)
+	err := cp.Walk(s.ctx, func(host string, port int) {
+		dstIp := resolve(host)
+		if dstIp == nil {
+			return
+		}
+		var hwAddr net.HardwareAddr
+		gateway := s.gatewayFor(dstIp)
+		if isPrivate[host] {
+			hw, ok := hwAddrs[host]
+			if !ok {
+				return
+			}
+			hwAddr, gateway = hw, ""
+		}
+
+		s.callOnSubmitTask(host, port)
+		packetLayers, loopback, err := s.createSynTCP(dstIp, port, hwAddr, gateway)
+		if err != nil {
+			log.Warnf("cannot create syn-tcp packet for %s err: %v", utils.HostPort(dstIp.String(), port), err)
+			return
+		}
+		if err := s.inject(loopback, packetLayers...); err != nil {
+			log.Errorf("inject syn-tcp packet error: %s", err)
+		}
+	}, func() int {
+		return len(s.handlerWriteChan) + len(s.localHandlerWriteChan)
+	}, save)
+	if err != nil {
+		return err
+	}
+
+	s._waitChanEmpty()
+	cp.Finish(save)
+	return nil
+}
+
+// privateHosts 返回在当前网卡网段内的目标
+func (s *Scanner) privateHosts(hosts []string) []string {
+	addrs, err := s.iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, host := range hosts {
+		if utils.IsLoopback(host) {
+			continue
+		}
+		if s.isOnLink(addrs, net.ParseIP(utils.FixForParseIP(host))) {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// isOnLink 判断目标是否需要直接解析邻居的 mac 地址：目标在网卡网段内，或者是没有配置 IPv6 网关时的 IPv6 目标
+func (s *Scanner) isOnLink(addrs []net.Addr, target net.IP) bool {
+	if target == nil {
+		return false
+	}
+	if isIPv6(target) && s.defaultGatewayIp6 == nil {
+		return true
+	}
+	for _, addr := range addrs {
+		if ifNet, ok := addr.(*net.IPNet); ok && ifNet.Contains(target) {
+			return true
+		}
+	}
+	return false
+}
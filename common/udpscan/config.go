@@ -0,0 +1,277 @@
+package udpscan
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/netutil"
+)
+
+type Config struct {
+	// 发包必须的几个字段，Iface 为空时使用系统 socket 发包
+	Iface       *net.Interface
+	GatewayIP   net.IP
+	SourceIP    net.IP
+	GatewayIPv6 net.IP
+	SourceIPv6  net.IP
+
+	Probes []*Probe
+
+	// Retry 没有回复时的重试次数，第 n 次重试前等待 Timeout * 2^n
+	Retry      int
+	Timeout    time.Duration
+	MaxBackoff time.Duration
+
+	// RateLimit 每秒最多发送的包数量，0 为不限制
+	RateLimit int
+
+	// ForceSocket 不使用 pcap 发包，使用系统 socket（不需要 root 权限，但速度较慢）
+	ForceSocket bool
+	// SocketConcurrent 使用 socket 发包时同时等待回复的端口数量
+	SocketConcurrent int
+
+	// Fingerprint 对 open 和 open|filtered 的端口进行 UDP 指纹识别
+	Fingerprint        bool
+	FingerprintOptions []fp.ConfigOption
+	// FingerprintConcurrent 同时进行指纹识别的端口数量
+	FingerprintConcurrent int
+
+	// Fetch Gateway Hardware Address TimeoutSeconds
+	FetchGatewayHardwareAddressTimeout time.Duration
+}
+
+type ConfigOption func(config *Config)
+
+func NewDefaultConfig(extra ...ConfigOption) (*Config, error) {
+	options, err := CreateConfigOptionsByTargetNetworkOrDomain("8.8.8.8", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfig(append(options, extra...)...)
+}
+
+func NewConfig(options ...ConfigOption) (*Config, error) {
+	config := &Config{
+		Probes:                             DefaultProbes,
+		Retry:                              2,
+		Timeout:                            time.Second,
+		MaxBackoff:                         5 * time.Second,
+		SocketConcurrent:                   256,
+		FingerprintConcurrent:              20,
+		FetchGatewayHardwareAddressTimeout: 5 * time.Second,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Iface == nil && !config.ForceSocket {
+		return nil, errors.New("config default net.Interface failed: empty iface")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second
+	}
+	if config.MaxBackoff < config.Timeout {
+		config.MaxBackoff = config.Timeout
+	}
+	if config.Retry < 0 {
+		config.Retry = 0
+	}
+	if config.SocketConcurrent <= 0 {
+		config.SocketConcurrent = 256
+	}
+	if config.FingerprintConcurrent <= 0 {
+		config.FingerprintConcurrent = 20
+	}
+	return config, nil
+}
+
+func CreateConfigOptionsByIfaceName(ifaceName string) ([]ConfigOption, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		iface, err = pcaputil.PcapIfaceNameToNetInterface(ifaceName)
+		if err != nil {
+			return nil, errors.Errorf("get iface failed: %s", err)
+		}
+	}
+	v4, v6 := ifaceSourceAddresses(iface)
+	if v4 == nil && v6 == nil {
+		return nil, errors.Errorf("iface: %s has no addrs", iface.Name)
+	}
+	return []ConfigOption{
+		WithNetInterface(iface),
+		WithDefaultSourceIP(v4),
+		WithDefaultSourceIPv6(v6),
+	}, nil
+}
+
+func CreateConfigOptionsByTargetNetworkOrDomain(targetRaw string, duration time.Duration) ([]ConfigOption, error) {
+	target := utils.ExtractHost(targetRaw)
+	iface, gIp, sIp, err := netutil.Route(duration, target)
+	if err != nil {
+		return nil, errors.Errorf("route to %s failed: %s", target, err)
+	}
+
+	var opts = []ConfigOption{WithNetInterface(iface)}
+	if isIPv6(sIp) {
+		opts = append(opts, WithDefaultSourceIPv6(sIp), WithGatewayIPv6(gIp))
+	} else {
+		opts = append(opts, WithDefaultSourceIP(sIp), WithGatewayIP(gIp))
+	}
+	return opts, nil
+}
+
+func WithNetInterface(iface *net.Interface) ConfigOption {
+	return func(config *Config) {
+		config.Iface = iface
+	}
+}
+
+func WithGatewayIP(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.GatewayIP = ip
+	}
+}
+
+func WithDefaultSourceIP(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.SourceIP = ip
+	}
+}
+
+func WithGatewayIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.GatewayIPv6 = ip
+	}
+}
+
+func WithDefaultSourceIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.SourceIPv6 = ip
+	}
+}
+
+// WithProbes 替换默认的探测包
+func WithProbes(probes ...*Probe) ConfigOption {
+	return func(config *Config) {
+		config.Probes = probes
+	}
+}
+
+// WithExtraProbes 在默认探测包之外追加探测包
+func WithExtraProbes(probes ...*Probe) ConfigOption {
+	return func(config *Config) {
+		config.Probes = append(append([]*Probe{}, config.Probes...), probes...)
+	}
+}
+
+func WithRetry(retry int) ConfigOption {
+	return func(config *Config) {
+		config.Retry = retry
+	}
+}
+
+func WithTimeout(timeout time.Duration) ConfigOption {
+	return func(config *Config) {
+		config.Timeout = timeout
+	}
+}
+
+func WithMaxBackoff(timeout time.Duration) ConfigOption {
+	return func(config *Config) {
+		config.MaxBackoff = timeout
+	}
+}
+
+func WithRateLimit(packetsPerSecond int) ConfigOption {
+	return func(config *Config) {
+		config.RateLimit = packetsPerSecond
+	}
+}
+
+func WithForceSocket(b bool) ConfigOption {
+	return func(config *Config) {
+		config.ForceSocket = b
+	}
+}
+
+func WithSocketConcurrent(concurrent int) ConfigOption {
+	return func(config *Config) {
+		config.SocketConcurrent = concurrent
+	}
+}
+
+func WithFingerprintConcurrent(concurrent int) ConfigOption {
+	return func(config *Config) {
+		config.FingerprintConcurrent = concurrent
+	}
+}
+
+func WithFingerprint(b bool, opts ...fp.ConfigOption) ConfigOption {
+	return func(config *Config) {
+		config.Fingerprint = b
+		config.FingerprintOptions = append(config.FingerprintOptions, opts...)
+	}
+}
+
+func WithFetchGatewayHardwareAddressTimeout(timeout time.Duration) ConfigOption {
+	return func(config *Config) {
+		config.FetchGatewayHardwareAddressTimeout = timeout
+	}
+}
+
+// backoff 返回第 round 轮发包之后的等待时间
+func (c *Config) backoff(round int) time.Duration {
+	d := c.Timeout
+	for i := 0; i < round; i++ {
+		d *= 2
+		if d >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return d
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil && ip.To16() != nil
+}
+
+// ifaceSourceAddresses 返回网卡上的 IPv4 地址和 IPv6 地址，IPv6 优先使用全局单播地址
+func ifaceSourceAddresses(iface *net.Interface) (v4 net.IP, v6 net.IP) {
+	if iface == nil {
+		return nil, nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil
+	}
+	var linkLocal net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		switch {
+		case ip.To4() != nil:
+			if v4 == nil {
+				v4 = ip
+			}
+		case ip.IsLinkLocalUnicast():
+			if linkLocal == nil {
+				linkLocal = ip
+			}
+		default:
+			if v6 == nil {
+				v6 = ip
+			}
+		}
+	}
+	if v6 == nil {
+		v6 = linkLocal
+	}
+	return v4, v6
+}
@@ -0,0 +1,61 @@
+package udpscan
+
+import (
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type PortState string
+
+func (p PortState) String() string {
+	return string(p)
+}
+
+const (
+	// OPEN 收到了 UDP 回复
+	OPEN PortState = "open"
+	// CLOSED 收到了 ICMP port unreachable
+	CLOSED PortState = "closed"
+	// FILTERED 收到了其他类型的 ICMP unreachable（被防火墙拦截）
+	FILTERED PortState = "filtered"
+	// OPEN_FILTERED 重试之后仍然没有任何回复，可能开放也可能被过滤
+	OPEN_FILTERED PortState = "open|filtered"
+)
+
+type UdpScanResult struct {
+	Host     string
+	Port     int
+	State    PortState
+	Probe    string
+	Response []byte
+	Reason   string
+
+	// Fingerprint 启用指纹识别时，开放或者 open|filtered 的端口会使用 fp 进行 UDP 指纹识别
+	Fingerprint *fp.MatchResult
+}
+
+func (s *UdpScanResult) Show() {
+	if s == nil {
+		return
+	}
+	println(s.String())
+}
+
+func (s *UdpScanResult) String() string {
+	if s == nil {
+		return ""
+	}
+	var service string
+	if s.Fingerprint != nil && s.Fingerprint.GetServiceName() != "" {
+		service = " " + s.Fingerprint.GetServiceName()
+	} else if s.Probe != "" {
+		service = " " + s.Probe
+	}
+	return fmt.Sprintf("%-13s udp://%-20s%s from udpscan", s.State.String(), utils.HostPort(s.Host, s.Port), service)
+}
+
+func (s *UdpScanResult) IsOpen() bool {
+	return s != nil && s.State == OPEN
+}
@@ -0,0 +1,90 @@
+package udpscan
+
+import (
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/pcapx"
+)
+
+// buildUDPPacket 使用 pcapx 构造以太网 UDP 数据包
+func buildUDPPacket(srcMac, dstMac net.HardwareAddr, srcIP, dstIP net.IP, srcPort, dstPort int, payload []byte) ([]byte, error) {
+	opts := []any{
+		pcapx.WithEthernet_SrcMac(srcMac),
+		pcapx.WithEthernet_DstMac(dstMac),
+		pcapx.WithUDP_SrcPort(srcPort),
+		pcapx.WithUDP_DstPort(dstPort),
+		pcapx.WithPayload(payload),
+	}
+	if isIPv6(dstIP) {
+		opts = append(opts, pcapx.WithIPv6_SrcIP(srcIP.String()), pcapx.WithIPv6_DstIP(dstIP.String()))
+	} else {
+		opts = append(opts, pcapx.WithIPv4_SrcIP(srcIP.String()), pcapx.WithIPv4_DstIP(dstIP.String()))
+	}
+	return pcapx.PacketBuilder(opts...)
+}
+
+// response 是从抓到的包中解析出的探测结果
+type response struct {
+	ip      net.IP
+	port    int
+	state   PortState
+	payload []byte
+	reason  string
+}
+
+// parseResponse 解析发往 srcPort 的 UDP 回复，以及携带原始探测包头的 ICMP / ICMPv6 不可达报文
+func parseResponse(packet gopacket.Packet, srcPort int) (*response, bool) {
+	if udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP); ok && packet.Layer(layers.LayerTypeICMPv4) == nil && packet.Layer(layers.LayerTypeICMPv6) == nil {
+		if int(udp.DstPort) != srcPort || packet.NetworkLayer() == nil {
+			return nil, false
+		}
+		return &response{
+			ip:      net.ParseIP(packet.NetworkLayer().NetworkFlow().Src().String()),
+			port:    int(udp.SrcPort),
+			state:   OPEN,
+			payload: udp.Payload,
+			reason:  "udp-response",
+		}, true
+	}
+
+	if icmp, ok := packet.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4); ok {
+		if icmp.TypeCode.Type() != layers.ICMPv4TypeDestinationUnreachable {
+			return nil, false
+		}
+		state, reason := FILTERED, "icmp-unreach-"+icmp.TypeCode.String()
+		if icmp.TypeCode.Code() == layers.ICMPv4CodePort {
+			state, reason = CLOSED, "port-unreach"
+		}
+		return parseQuotedUDP(icmp.Payload, layers.LayerTypeIPv4, srcPort, state, reason)
+	}
+
+	if icmp, ok := packet.Layer(layers.LayerTypeICMPv6).(*layers.ICMPv6); ok {
+		if icmp.TypeCode.Type() != layers.ICMPv6TypeDestinationUnreachable || len(icmp.Payload) < 4 {
+			return nil, false
+		}
+		state, reason := FILTERED, "icmp6-unreach-"+icmp.TypeCode.String()
+		if icmp.TypeCode.Code() == layers.ICMPv6CodePortUnreachable {
+			state, reason = CLOSED, "port-unreach"
+		}
+		// ICMPv6 目的不可达报文在原始包之前有 4 字节的保留字段
+		return parseQuotedUDP(icmp.Payload[4:], layers.LayerTypeIPv6, srcPort, state, reason)
+	}
+	return nil, false
+}
+
+// parseQuotedUDP 从 ICMP 报文引用的原始数据包中找出探测的目标
+func parseQuotedUDP(quoted []byte, first gopacket.LayerType, srcPort int, state PortState, reason string) (*response, bool) {
+	inner := gopacket.NewPacket(quoted, first, gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+	udp, ok := inner.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if !ok || int(udp.SrcPort) != srcPort || inner.NetworkLayer() == nil {
+		return nil, false
+	}
+	return &response{
+		ip:     net.ParseIP(inner.NetworkLayer().NetworkFlow().Dst().String()),
+		port:   int(udp.DstPort),
+		state:  state,
+		reason: reason,
+	}, true
+}
@@ -0,0 +1,187 @@
+package udpscan
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// Probe 是一个 UDP 探测包，UDP 服务一般不会回复空包，需要发送对应协议的请求才能确定端口开放
+// 带有事务 ID 的协议使用 Build 在每次发包时生成新的载荷，避免所有探测包使用同一个 ID
+type Probe struct {
+	Name    string
+	Ports   []int
+	Payload []byte
+	Build   func() []byte
+}
+
+// Bytes 返回本次发送的载荷
+func (p *Probe) Bytes() []byte {
+	if p.Build != nil {
+		return p.Build()
+	}
+	return p.Payload
+}
+
+var DefaultProbes = []*Probe{
+	{Name: "dns", Ports: []int{53}, Build: dnsVersionBindPayload},
+	{Name: "snmp", Ports: []int{161}, Build: func() []byte { return snmpGetSysDescrPayload("public") }},
+	{Name: "ntp", Ports: []int{123}, Payload: ntpClientPayload()},
+	{Name: "ssdp", Ports: []int{1900}, Payload: []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 1\r\nST: ssdp:all\r\n\r\n")},
+	{Name: "netbios-ns", Ports: []int{137}, Build: netbiosStatusPayload},
+	{Name: "ike", Ports: []int{500}, Build: ikeMainModePayload},
+	{Name: "memcached", Ports: []int{11211}, Payload: []byte("\x00\x01\x00\x00\x00\x01\x00\x00version\r\n")},
+	{Name: "mdns", Ports: []int{5353}, Payload: mdnsServicesPayload()},
+	{Name: "coap", Ports: []int{5683}, Build: coapWellKnownCorePayload},
+}
+
+// genericProbe 用于没有专用探测包的端口，发送空的 UDP 包
+var genericProbe = &Probe{Name: "generic"}
+
+// ProbesForPort 返回端口对应的探测包，没有专用探测包时返回通用的空包
+func ProbesForPort(probes []*Probe, port int) []*Probe {
+	var ret []*Probe
+	for _, p := range probes {
+		for _, i := range p.Ports {
+			if i == port {
+				ret = append(ret, p)
+				break
+			}
+		}
+	}
+	if len(ret) == 0 {
+		ret = append(ret, genericProbe)
+	}
+	return ret
+}
+
+// dnsName 把域名编码为 DNS 报文中的 label 格式
+func dnsName(labels ...string) []byte {
+	var buf []byte
+	for _, l := range labels {
+		buf = append(buf, byte(len(l)))
+		buf = append(buf, l...)
+	}
+	return append(buf, 0)
+}
+
+func dnsQuery(id uint16, flags uint16, name []byte, qtype, qclass uint16) []byte {
+	buf := make([]byte, 12, 12+len(name)+4)
+	binary.BigEndian.PutUint16(buf[0:], id)
+	binary.BigEndian.PutUint16(buf[2:], flags)
+	binary.BigEndian.PutUint16(buf[4:], 1)
+	buf = append(buf, name...)
+	buf = binary.BigEndian.AppendUint16(buf, qtype)
+	buf = binary.BigEndian.AppendUint16(buf, qclass)
+	return buf
+}
+
+// dnsVersionBindPayload: version.bind TXT CHAOS 查询，大部分 DNS 服务器都会回复（即使是拒绝）
+func dnsVersionBindPayload() []byte {
+	return dnsQuery(uint16(rand.Intn(0xffff)), 0x0100, dnsName("version", "bind"), 16, 3)
+}
+
+// mdnsServicesPayload: 查询 _services._dns-sd._udp.local 的 PTR 记录，源端口不是 5353 时设备会单播回复
+func mdnsServicesPayload() []byte {
+	return dnsQuery(0, 0, dnsName("_services", "_dns-sd", "_udp", "local"), 12, 1)
+}
+
+// berTLV 编码一个 BER 的 TLV，只需要支持短长度
+func berTLV(tag byte, value []byte) []byte {
+	if len(value) < 0x80 {
+		return append([]byte{tag, byte(len(value))}, value...)
+	}
+	return append([]byte{tag, 0x81, byte(len(value))}, value...)
+}
+
+func berConcat(items ...[]byte) []byte {
+	var buf []byte
+	for _, i := range items {
+		buf = append(buf, i...)
+	}
+	return buf
+}
+
+// snmpGetSysDescrPayload: SNMPv1 GetRequest sysDescr.0 (1.3.6.1.2.1.1.1.0)
+func snmpGetSysDescrPayload(community string) []byte {
+	requestId := make([]byte, 4)
+	binary.BigEndian.PutUint32(requestId, uint32(rand.Int31()))
+	varbind := berTLV(0x30, berConcat(
+		berTLV(0x06, []byte{0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00}),
+		berTLV(0x05, nil),
+	))
+	pdu := berTLV(0xa0, berConcat(
+		berTLV(0x02, requestId),
+		berTLV(0x02, []byte{0}),
+		berTLV(0x02, []byte{0}),
+		berTLV(0x30, varbind),
+	))
+	return berTLV(0x30, berConcat(
+		berTLV(0x02, []byte{0}),
+		berTLV(0x04, []byte(community)),
+		pdu,
+	))
+}
+
+// ntpClientPayload: NTPv4 client 模式请求，48 字节
+func ntpClientPayload() []byte {
+	buf := make([]byte, 48)
+	buf[0] = 0xe3 // LI=3 (未同步) VN=4 Mode=3 (client)
+	buf[2] = 0x06 // poll
+	buf[3] = 0xe9 // precision
+	return buf
+}
+
+// netbiosStatusPayload: NetBIOS NBSTAT 查询 "*"，返回主机名和工作组
+func netbiosStatusPayload() []byte {
+	// "*" 加上 15 个 \x00，按照 first-level encoding 编码为 32 字节
+	name := []byte{0x20, 'C', 'K'}
+	for i := 0; i < 15; i++ {
+		name = append(name, 'A', 'A')
+	}
+	name = append(name, 0)
+	return dnsQuery(uint16(rand.Intn(0xffff)), 0, name, 0x21, 1)
+}
+
+// ikeMainModePayload: IKEv1 Main Mode 的第一个包，带一个 3DES-SHA1-PSK-MODP1024 的 proposal
+func ikeMainModePayload() []byte {
+	attrs := []uint16{
+		0x8001, 0x0005, // encryption: 3DES-CBC
+		0x8002, 0x0002, // hash: SHA1
+		0x8003, 0x0001, // auth: pre-shared key
+		0x8004, 0x0002, // group: MODP 1024
+		0x800b, 0x0001, // life type: seconds
+		0x800c, 0x7080, // life duration: 28800
+	}
+	transform := []byte{0, 0, 0, 0, 1, 1, 0, 0} // next / reserved / length / transform #1 / KEY_IKE / reserved
+	for _, a := range attrs {
+		transform = binary.BigEndian.AppendUint16(transform, a)
+	}
+	binary.BigEndian.PutUint16(transform[2:], uint16(len(transform)))
+
+	proposal := []byte{0, 0, 0, 0, 1, 1, 0, 1} // next / reserved / length / proposal #1 / ISAKMP / spi size / 1 transform
+	proposal = append(proposal, transform...)
+	binary.BigEndian.PutUint16(proposal[2:], uint16(len(proposal)))
+
+	sa := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1} // next / reserved / length / DOI: IPSEC / situation: identity only
+	sa = append(sa, proposal...)
+	binary.BigEndian.PutUint16(sa[2:], uint16(len(sa)))
+
+	header := make([]byte, 28)
+	rand.Read(header[:8]) // initiator cookie
+	header[16] = 1        // next payload: SA
+	header[17] = 0x10     // version 1.0
+	header[18] = 2        // exchange type: identity protection (main mode)
+	binary.BigEndian.PutUint32(header[24:], uint32(len(header)+len(sa)))
+	return append(header, sa...)
+}
+
+// coapWellKnownCorePayload: CoAP GET /.well-known/core，返回设备的资源列表
+func coapWellKnownCorePayload() []byte {
+	buf := []byte{0x40, 0x01, 0, 0} // ver 1, CON, token length 0 / GET / message id
+	binary.BigEndian.PutUint16(buf[2:], uint16(rand.Intn(0xffff)))
+	buf = append(buf, 0xbb) // option delta 11 (Uri-Path), length 11
+	buf = append(buf, ".well-known"...)
+	buf = append(buf, 0x04) // option delta 0 (Uri-Path), length 4
+	buf = append(buf, "core"...)
+	return buf
+}
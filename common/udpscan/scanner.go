@@ -0,0 +1,578 @@
+package udpscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/yaklang/pcap"
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/pcapx/arpx"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Scanner 是 UDP 端口扫描器
+//
+// 默认通过 pcap 直接发包并抓取 UDP 回复和 ICMP 不可达报文；本地回环、没有链路层地址的网卡（vpn）
+// 以及无法获取 mac 地址的目标会使用系统 socket 发包，由内核把 ICMP port unreachable 转换为 ECONNREFUSED
+type Scanner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	config *Config
+	iface  *net.Interface
+
+	// srcPort 所有探测包使用同一个源端口，用来过滤回复
+	srcPort int
+
+	handle    *pcap.Handle
+	writeLock sync.Mutex
+
+	hwLock     sync.Mutex
+	gatewayHw  net.HardwareAddr
+	gatewayHw6 net.HardwareAddr
+
+	taskLock sync.Mutex
+	tasks    map[string]*task
+
+	matcher *fp.Matcher
+
+	// socketSwg 限制 socket 发包时等待回复的 goroutine 数量，fingerprintSwg 限制指纹识别的 goroutine 数量
+	socketSwg      *utils.SizedWaitGroup
+	fingerprintSwg *utils.SizedWaitGroup
+
+	// onSubmitTaskCallback: 每提交一个探测目标的时候，这个 callback 调用一次
+	onSubmitTaskCallback func(string, int)
+}
+
+type task struct {
+	ip     net.IP
+	port   int
+	probes []*Probe
+
+	// dstMac 为空时使用 socket 发包
+	dstMac net.HardwareAddr
+	conn   *net.UDPConn
+	// reading 表示有 goroutine 正在读取 conn，readUntil 之后仍然没有回复则退出，下一轮发包时重新读取
+	reading   bool
+	readUntil time.Time
+
+	lastProbe string
+	done      bool
+	result    chan *UdpScanResult
+	swg       *sync.WaitGroup
+}
+
+func taskKey(ip net.IP, port int) string {
+	return utils.HostPort(ip.String(), port)
+}
+
+func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
+	if config == nil {
+		return nil, utils.Error("empty udpscan config")
+	}
+	scannerCtx, cancel := context.WithCancel(ctx)
+	s := &Scanner{
+		ctx:     scannerCtx,
+		cancel:  cancel,
+		config:  config,
+		iface:   config.Iface,
+		srcPort: 40000 + rand.Intn(20000),
+		tasks:   make(map[string]*task),
+
+		socketSwg:      utils.NewSizedWaitGroup(config.SocketConcurrent),
+		fingerprintSwg: utils.NewSizedWaitGroup(config.FingerprintConcurrent),
+	}
+
+	if config.Fingerprint {
+		fpConfig := fp.NewConfig(append(config.FingerprintOptions, fp.WithTransportProtos(fp.UDP))...)
+		matcher, err := fp.NewDefaultFingerprintMatcher(fpConfig)
+		if err != nil {
+			cancel()
+			return nil, utils.Errorf("create udp fingerprint matcher failed: %s", err)
+		}
+		s.matcher = matcher
+	}
+
+	if !config.ForceSocket && s.iface != nil && len(s.iface.HardwareAddr) > 0 {
+		if err := s.startCapture(); err != nil {
+			log.Warnf("udpscan start pcap on %v failed: %v, use socket instead", s.iface.Name, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Scanner) Close() {
+	s.cancel()
+}
+
+func (s *Scanner) OnSubmitTask(i func(addr string, port int)) {
+	s.onSubmitTaskCallback = i
+}
+
+func (s *Scanner) callOnSubmitTask(addr string, port int) {
+	if s == nil || s.onSubmitTaskCallback == nil {
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("on submit task callback failed: %s", err)
+		}
+	}()
+	s.onSubmitTaskCallback(addr, port)
+}
+
+func (s *Scanner) startCapture() error {
+	ready := make(chan struct{})
+	failed := make(chan error, 1)
+	go func() {
+		err := pcaputil.Start(
+			pcaputil.WithDevice(s.iface.Name),
+			pcaputil.WithBPFFilter(fmt.Sprintf("(udp and dst port %d) or (icmp and icmp[0] == 3) or (icmp6 and ip6[40] == 1)", s.srcPort)),
+			pcaputil.WithContext(s.ctx),
+			pcaputil.WithNetInterfaceCreated(func(handle *pcap.Handle) {
+				s.writeLock.Lock()
+				s.handle = handle
+				s.writeLock.Unlock()
+				close(ready)
+			}),
+			pcaputil.WithEveryPacket(s.handlePacket),
+		)
+		s.writeLock.Lock()
+		s.handle = nil
+		s.writeLock.Unlock()
+		if err != nil {
+			failed <- err
+		}
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case err := <-failed:
+		return err
+	case <-time.After(3 * time.Second):
+		return utils.Error("wait for pcap handle timeout")
+	}
+}
+
+func (s *Scanner) handlePacket(packet gopacket.Packet) {
+	rsp, ok := parseResponse(packet, s.srcPort)
+	if !ok {
+		return
+	}
+	s.taskLock.Lock()
+	t := s.tasks[taskKey(rsp.ip, rsp.port)]
+	s.taskLock.Unlock()
+	if t == nil {
+		return
+	}
+	s.finish(t, rsp.state, rsp.payload, rsp.reason)
+}
+
+// Scan 扫描 hosts 的 UDP 端口，结果通过 channel 返回，所有端口都得到结论之后 channel 关闭
+func (s *Scanner) Scan(hosts string, ports string) (chan *UdpScanResult, error) {
+	portList := utils.ParseStringToPorts(ports)
+	if len(portList) == 0 {
+		return nil, utils.Errorf("no valid udp ports: %v", ports)
+	}
+	hostList := utils.ParseStringToHosts(hosts)
+	if len(hostList) == 0 {
+		return nil, utils.Errorf("no valid hosts: %v", hosts)
+	}
+
+	results := make(chan *UdpScanResult, 1000)
+	go func() {
+		defer close(results)
+
+		var ips []net.IP
+		for _, host := range hostList {
+			ip := net.ParseIP(utils.FixForParseIP(host))
+			if ip == nil {
+				if addr := netx.LookupFirst(host, netx.WithTimeout(5*time.Second)); addr != "" {
+					ip = net.ParseIP(addr)
+				}
+			}
+			if ip == nil {
+				log.Warnf("cannot query dns for %v", host)
+				continue
+			}
+			ips = append(ips, ip)
+		}
+		macs := s.resolveHardwareAddrs(ips)
+
+		swg := new(sync.WaitGroup)
+		var tasks []*task
+		s.taskLock.Lock()
+		for _, ip := range ips {
+			for _, port := range portList {
+				key := taskKey(ip, port)
+				if _, existed := s.tasks[key]; existed {
+					log.Warnf("udpscan task %v is running, skip", key)
+					continue
+				}
+				t := &task{
+					ip:     ip,
+					port:   port,
+					probes: ProbesForPort(s.config.Probes, port),
+					dstMac: macs[ip.String()],
+					result: results,
+					swg:    swg,
+				}
+				s.tasks[key] = t
+				tasks = append(tasks, t)
+			}
+		}
+		s.taskLock.Unlock()
+
+		defer func() {
+			s.taskLock.Lock()
+			for _, t := range tasks {
+				delete(s.tasks, taskKey(t.ip, t.port))
+			}
+			s.taskLock.Unlock()
+			for _, t := range tasks {
+				if t.conn != nil {
+					t.conn.Close()
+				}
+			}
+		}()
+
+		s.run(tasks)
+		// ctx 结束时 run 会提前返回，剩余的端口在关闭 results 之前标记为结束，之后收到的回复不再输出结果
+		s.taskLock.Lock()
+		for _, t := range tasks {
+			t.done = true
+		}
+		s.taskLock.Unlock()
+		// 等待指纹识别结束
+		swg.Wait()
+	}()
+	return results, nil
+}
+
+// run 按轮次发包，没有回复的端口会在等待 Timeout * 2^n 之后重试，所有轮次结束后仍然没有回复的端口为 open|filtered
+func (s *Scanner) run(tasks []*task) {
+	var interval time.Duration
+	if s.config.RateLimit > 0 {
+		interval = time.Second / time.Duration(s.config.RateLimit)
+	}
+
+	submitted := false
+	for round := 0; round <= s.config.Retry; round++ {
+		pending := s.pending(tasks)
+		if len(pending) == 0 {
+			break
+		}
+		if round > 0 {
+			log.Debugf("udpscan retry %v for %v ports", round, len(pending))
+		}
+		wait := s.config.backoff(round)
+		for _, t := range pending {
+			if s.ctx.Err() != nil {
+				return
+			}
+			if !submitted {
+				s.callOnSubmitTask(t.ip.String(), t.port)
+			}
+			for _, probe := range t.probes {
+				if err := s.send(t, probe, wait); err != nil {
+					log.Debugf("udpscan send %v probe to %v failed: %v", probe.Name, taskKey(t.ip, t.port), err)
+				}
+				if interval > 0 {
+					time.Sleep(interval)
+				}
+			}
+		}
+		submitted = true
+		// 本轮的等待从最后一个包发出之后开始，socket 读取也要持续到等待结束
+		readUntil := time.Now().Add(wait)
+		s.taskLock.Lock()
+		for _, t := range pending {
+			t.readUntil = readUntil
+		}
+		s.taskLock.Unlock()
+		s.wait(tasks, wait)
+	}
+
+	for _, t := range s.pending(tasks) {
+		s.finish(t, OPEN_FILTERED, nil, "no-response")
+	}
+}
+
+func (s *Scanner) pending(tasks []*task) []*task {
+	s.taskLock.Lock()
+	defer s.taskLock.Unlock()
+	var ret []*task
+	for _, t := range tasks {
+		if !t.done {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+func (s *Scanner) wait(tasks []*task, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C:
+			return
+		case <-ticker.C:
+			if len(s.pending(tasks)) == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (s *Scanner) send(t *task, probe *Probe, wait time.Duration) error {
+	s.taskLock.Lock()
+	t.lastProbe = probe.Name
+	s.taskLock.Unlock()
+
+	if t.dstMac != nil {
+		srcIP := s.config.SourceIP
+		if isIPv6(t.ip) {
+			srcIP = s.config.SourceIPv6
+		}
+		raw, err := buildUDPPacket(s.iface.HardwareAddr, t.dstMac, srcIP, t.ip, s.srcPort, t.port, probe.Bytes())
+		if err != nil {
+			return err
+		}
+		s.writeLock.Lock()
+		defer s.writeLock.Unlock()
+		if s.handle == nil {
+			return utils.Error("pcap handle is closed")
+		}
+		return s.handle.WritePacketData(raw)
+	}
+
+	if t.conn == nil {
+		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: t.ip, Port: t.port})
+		if err != nil {
+			s.finish(t, FILTERED, nil, err.Error())
+			return err
+		}
+		t.conn = conn
+	}
+	_, err := t.conn.Write(probe.Bytes())
+	if err != nil && errors.Is(err, syscall.ECONNREFUSED) {
+		s.finish(t, CLOSED, nil, "port-unreach")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.taskLock.Lock()
+	reading := t.reading
+	t.reading = true
+	if readUntil := time.Now().Add(wait); readUntil.After(t.readUntil) {
+		t.readUntil = readUntil
+	}
+	s.taskLock.Unlock()
+	if reading {
+		return nil
+	}
+	// 达到并发上限时阻塞发包，等待其他端口的读取结束
+	if err := s.socketSwg.AddWithContext(s.ctx); err != nil {
+		return err
+	}
+	go func() {
+		defer s.socketSwg.Done()
+		s.readSocket(t)
+	}()
+	return nil
+}
+
+// readSocket 读取 socket 的回复，直到端口得到结论或者超过 readUntil
+func (s *Scanner) readSocket(t *task) {
+	// 超时退出时在同一个锁内清除 reading，保证 send 延长 readUntil 之后一定有 goroutine 读取
+	stopped := false
+	defer func() {
+		if stopped {
+			return
+		}
+		s.taskLock.Lock()
+		t.reading = false
+		s.taskLock.Unlock()
+	}()
+	buf := make([]byte, 4096)
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+		_ = t.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, err := t.conn.Read(buf)
+		if err == nil {
+			s.finish(t, OPEN, append([]byte{}, buf[:n]...), "udp-response")
+			return
+		}
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			s.finish(t, CLOSED, nil, "port-unreach")
+			return
+		case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+			s.finish(t, FILTERED, nil, err.Error())
+			return
+		case errors.Is(err, net.ErrClosed):
+			return
+		}
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			log.Debugf("udpscan read %v failed: %v", taskKey(t.ip, t.port), err)
+			return
+		}
+		s.taskLock.Lock()
+		if t.done || time.Now().After(t.readUntil) {
+			t.reading = false
+			stopped = true
+		}
+		s.taskLock.Unlock()
+		if stopped {
+			return
+		}
+	}
+}
+
+// finish 记录端口的结论，每个端口只会输出一次结果
+func (s *Scanner) finish(t *task, state PortState, payload []byte, reason string) {
+	s.taskLock.Lock()
+	if t.done {
+		s.taskLock.Unlock()
+		return
+	}
+	t.done = true
+	result := &UdpScanResult{
+		Host:     t.ip.String(),
+		Port:     t.port,
+		State:    state,
+		Probe:    t.lastProbe,
+		Response: payload,
+		Reason:   reason,
+	}
+	t.swg.Add(1)
+	s.taskLock.Unlock()
+
+	if s.matcher == nil || (state != OPEN && state != OPEN_FILTERED) {
+		s.emit(t, result)
+		t.swg.Done()
+		return
+	}
+	// 达到并发上限时阻塞，等待其他端口的指纹识别结束
+	if err := s.fingerprintSwg.AddWithContext(s.ctx); err != nil {
+		s.emit(t, result)
+		t.swg.Done()
+		return
+	}
+	go func() {
+		defer s.fingerprintSwg.Done()
+		defer t.swg.Done()
+		matchResult, err := s.matcher.MatchWithContext(s.ctx, result.Host, result.Port)
+		if err != nil {
+			log.Debugf("udp fingerprint %v failed: %v", taskKey(t.ip, t.port), err)
+		} else if matchResult != nil {
+			result.Fingerprint = matchResult
+			if matchResult.State == fp.OPEN && result.State == OPEN_FILTERED {
+				result.State = OPEN
+				result.Reason = "fingerprint"
+			}
+		}
+		s.emit(t, result)
+	}()
+}
+
+// emit 输出结果，ctx 结束之后调用方可能不再读取结果，此时丢弃结果避免阻塞
+func (s *Scanner) emit(t *task, result *UdpScanResult) {
+	select {
+	case t.result <- result:
+	case <-s.ctx.Done():
+	}
+}
+
+// resolveHardwareAddrs 获取 pcap 发包需要的目的 mac 地址：同网段的目标使用 arp / ndp，其他目标使用网关的 mac 地址
+func (s *Scanner) resolveHardwareAddrs(ips []net.IP) map[string]net.HardwareAddr {
+	ret := make(map[string]net.HardwareAddr)
+	s.writeLock.Lock()
+	usePcap := s.handle != nil
+	s.writeLock.Unlock()
+	if !usePcap {
+		return ret
+	}
+
+	addrs, _ := s.iface.Addrs()
+	var private []string
+	for _, ip := range ips {
+		if ip.IsLoopback() {
+			continue
+		}
+		if isIPv6(ip) && s.config.SourceIPv6 == nil || !isIPv6(ip) && s.config.SourceIP == nil {
+			continue
+		}
+		inSubnet := false
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.Contains(ip) {
+				inSubnet = true
+				break
+			}
+		}
+		if inSubnet {
+			private = append(private, ip.String())
+			continue
+		}
+		if hw := s.getGatewayHardwareAddr(isIPv6(ip)); hw != nil {
+			ret[ip.String()] = hw
+		}
+	}
+
+	if len(private) > 0 {
+		ctx, cancel := context.WithTimeout(s.ctx, s.config.FetchGatewayHardwareAddressTimeout)
+		defer cancel()
+		results, err := arpx.ArpIPAddressesWithContext(ctx, s.iface.Name, strings.Join(private, ","))
+		if err != nil {
+			log.Warnf("udpscan arp for private hosts failed: %v", err)
+		}
+		for ip, hw := range results {
+			ret[ip] = hw
+		}
+	}
+	return ret
+}
+
+func (s *Scanner) getGatewayHardwareAddr(v6 bool) net.HardwareAddr {
+	s.hwLock.Lock()
+	defer s.hwLock.Unlock()
+
+	gateway, cache := s.config.GatewayIP, &s.gatewayHw
+	if v6 {
+		gateway, cache = s.config.GatewayIPv6, &s.gatewayHw6
+	}
+	if *cache != nil {
+		return *cache
+	}
+	if gateway == nil {
+		return nil
+	}
+	hw, err := arpx.ArpWithTimeout(s.config.FetchGatewayHardwareAddressTimeout, s.iface.Name, gateway.String())
+	if err != nil {
+		log.Warnf("udpscan fetch gateway %v hw addr failed: %v", gateway, err)
+		return nil
+	}
+	*cache = hw
+	return hw
+}
@@ -0,0 +1,235 @@
+package udpscan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestProbePayloads(t *testing.T) {
+	for _, name := range []string{"dns", "mdns", "netbios-ns"} {
+		probe := ProbesForPort(DefaultProbes, map[string]int{"dns": 53, "mdns": 5353, "netbios-ns": 137}[name])[0]
+		require.Equal(t, name, probe.Name)
+		dns := &layers.DNS{}
+		require.NoError(t, dns.DecodeFromBytes(probe.Bytes(), gopacket.NilDecodeFeedback), name)
+		require.Len(t, dns.Questions, 1)
+	}
+
+	ntp := ProbesForPort(DefaultProbes, 123)[0]
+	require.Len(t, ntp.Bytes(), 48)
+	require.EqualValues(t, 3, ntp.Bytes()[0]&0x07)
+
+	// SNMP 是一个完整的 BER SEQUENCE
+	snmp := ProbesForPort(DefaultProbes, 161)[0].Bytes()
+	require.EqualValues(t, 0x30, snmp[0])
+	require.Equal(t, len(snmp)-2, int(snmp[1]))
+	require.Contains(t, string(snmp), "public")
+
+	ike := ProbesForPort(DefaultProbes, 500)[0].Bytes()
+	require.Equal(t, len(ike), int(ike[24])<<24|int(ike[25])<<16|int(ike[26])<<8|int(ike[27]))
+
+	require.Equal(t, "generic", ProbesForPort(DefaultProbes, 31337)[0].Name)
+}
+
+func TestParseResponse(t *testing.T) {
+	srcPort := 45678
+	target := net.ParseIP("192.168.1.10")
+	local := net.ParseIP("192.168.1.2")
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{2, 0, 0, 0, 0, 1}, DstMAC: net.HardwareAddr{2, 0, 0, 0, 0, 2}, EthernetType: layers.EthernetTypeIPv4}
+
+	// 构造探测包，ICMP 报文中会引用它
+	probe, err := buildUDPPacket(eth.DstMAC, eth.SrcMAC, local, target, srcPort, 161, []byte("probe"))
+	require.NoError(t, err)
+	probeIP := gopacket.NewPacket(probe, layers.LayerTypeEthernet, gopacket.Default).Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	quoted := append(append([]byte{}, probeIP.Contents...), probeIP.Payload[:8]...)
+
+	// UDP 回复
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: target, DstIP: local}
+	udp := &layers.UDP{SrcPort: 161, DstPort: layers.UDPPort(srcPort)}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, eth, ip, udp, gopacket.Payload("reply")))
+	rsp, ok := parseResponse(gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default), srcPort)
+	require.True(t, ok)
+	require.Equal(t, OPEN, rsp.state)
+	require.Equal(t, "192.168.1.10:161", taskKey(rsp.ip, rsp.port))
+	require.Equal(t, "reply", string(rsp.payload))
+
+	// 其他端口的 UDP 包会被忽略
+	_, ok = parseResponse(gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default), srcPort+1)
+	require.False(t, ok)
+
+	icmpPacket := func(code uint8) gopacket.Packet {
+		ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolICMPv4, SrcIP: target, DstIP: local}
+		icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, code)}
+		buf := gopacket.NewSerializeBuffer()
+		require.NoError(t, gopacket.SerializeLayers(buf, opts, eth, ip, icmp, gopacket.Payload(quoted)))
+		return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	}
+	rsp, ok = parseResponse(icmpPacket(layers.ICMPv4CodePort), srcPort)
+	require.True(t, ok)
+	require.Equal(t, CLOSED, rsp.state)
+	require.Equal(t, "192.168.1.10:161", taskKey(rsp.ip, rsp.port))
+
+	rsp, ok = parseResponse(icmpPacket(layers.ICMPv4CodeCommAdminProhibited), srcPort)
+	require.True(t, ok)
+	require.Equal(t, FILTERED, rsp.state)
+
+	// IPv6 port unreachable
+	target6, local6 := net.ParseIP("2001:db8::10"), net.ParseIP("2001:db8::2")
+	probe6, err := buildUDPPacket(eth.DstMAC, eth.SrcMAC, local6, target6, srcPort, 53, []byte("probe"))
+	require.NoError(t, err)
+	probeIP6 := gopacket.NewPacket(probe6, layers.LayerTypeEthernet, gopacket.Default).Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolICMPv6, SrcIP: target6, DstIP: local6}
+	icmp6 := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeDestinationUnreachable, layers.ICMPv6CodePortUnreachable)}
+	require.NoError(t, icmp6.SetNetworkLayerForChecksum(ip6))
+	buf = gopacket.NewSerializeBuffer()
+	eth.EthernetType = layers.EthernetTypeIPv6
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, eth, ip6, icmp6, gopacket.Payload(append([]byte{0, 0, 0, 0}, probeIP6.Contents...)), gopacket.Payload(probeIP6.Payload)))
+	rsp, ok = parseResponse(gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default), srcPort)
+	require.True(t, ok)
+	require.Equal(t, CLOSED, rsp.state)
+	require.Equal(t, "[2001:db8::10]:53", taskKey(rsp.ip, rsp.port))
+}
+
+func TestScanWithSocket(t *testing.T) {
+	// 开放端口：收到探测包后回复
+	openConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer openConn.Close()
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := openConn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			openConn.WriteToUDP(append([]byte("echo:"), buf[:n]...), addr)
+		}
+	}()
+	openPort := openConn.LocalAddr().(*net.UDPAddr).Port
+
+	// 开放但是不回复的端口
+	silentConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer silentConn.Close()
+	silentPort := silentConn.LocalAddr().(*net.UDPAddr).Port
+
+	closedPort := utils.GetRandomAvailableUDPPort()
+
+	config, err := NewConfig(
+		WithForceSocket(true),
+		WithRetry(1),
+		WithTimeout(300*time.Millisecond),
+		WithExtraProbes(&Probe{Name: "test", Ports: []int{openPort}, Payload: []byte("hello")}),
+	)
+	require.NoError(t, err)
+	scanner, err := NewScanner(context.Background(), config)
+	require.NoError(t, err)
+	defer scanner.Close()
+
+	ch, err := scanner.Scan("127.0.0.1", utils.ConcatPorts([]int{openPort, silentPort, closedPort}))
+	require.NoError(t, err)
+	results := make(map[int]*UdpScanResult)
+	for r := range ch {
+		results[r.Port] = r
+	}
+	require.Len(t, results, 3)
+	require.Equal(t, OPEN, results[openPort].State)
+	require.Equal(t, "test", results[openPort].Probe)
+	require.Equal(t, "echo:hello", string(results[openPort].Response))
+	require.Equal(t, OPEN_FILTERED, results[silentPort].State)
+	require.Equal(t, CLOSED, results[closedPort].State)
+}
+
+func TestProbeBuildPerSend(t *testing.T) {
+	// 带有事务 ID 的探测包每次发送都会重新生成
+	ike := ProbesForPort(DefaultProbes, 500)[0]
+	require.NotEqual(t, ike.Bytes()[:8], ike.Bytes()[:8])
+
+	snmp := ProbesForPort(DefaultProbes, 161)[0]
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		seen[string(snmp.Bytes())] = true
+	}
+	require.Greater(t, len(seen), 1)
+}
+
+func TestScanWithSocketConcurrent(t *testing.T) {
+	var ports []int
+	for i := 0; i < 8; i++ {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+		require.NoError(t, err)
+		defer conn.Close()
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, addr, err := conn.ReadFromUDP(buf)
+				if err != nil {
+					return
+				}
+				conn.WriteToUDP(buf[:n], addr)
+			}
+		}()
+		ports = append(ports, conn.LocalAddr().(*net.UDPAddr).Port)
+	}
+
+	config, err := NewConfig(
+		WithForceSocket(true),
+		WithRetry(1),
+		WithTimeout(300*time.Millisecond),
+		WithSocketConcurrent(2),
+		WithProbes(&Probe{Name: "test", Ports: ports, Payload: []byte("hello")}),
+	)
+	require.NoError(t, err)
+	scanner, err := NewScanner(context.Background(), config)
+	require.NoError(t, err)
+	defer scanner.Close()
+
+	ch, err := scanner.Scan("127.0.0.1", utils.ConcatPorts(ports))
+	require.NoError(t, err)
+	count := 0
+	for r := range ch {
+		require.Equal(t, OPEN, r.State, r.Port)
+		count++
+	}
+	require.Equal(t, len(ports), count)
+}
+
+func TestScanCancelWithLateResponse(t *testing.T) {
+	silentConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer silentConn.Close()
+	silentPort := silentConn.LocalAddr().(*net.UDPAddr).Port
+
+	config, err := NewConfig(WithForceSocket(true), WithTimeout(10*time.Second))
+	require.NoError(t, err)
+	scanner, err := NewScanner(context.Background(), config)
+	require.NoError(t, err)
+
+	ch, err := scanner.Scan("127.0.0.1", fmt.Sprint(silentPort))
+	require.NoError(t, err)
+	var task *task
+	require.Eventually(t, func() bool {
+		scanner.taskLock.Lock()
+		defer scanner.taskLock.Unlock()
+		task = scanner.tasks[taskKey(net.ParseIP("127.0.0.1"), silentPort)]
+		return task != nil
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// 取消扫描之后 results 关闭，在取消之前查找到任务的回复不能再写入 results
+	scanner.Close()
+	for range ch {
+	}
+	require.NotPanics(t, func() {
+		scanner.finish(task, OPEN, []byte("late"), "udp-response")
+	})
+}
@@ -0,0 +1,69 @@
+package bruteutils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+)
+
+// amqpDial 使用指定的 SASL 机制完成 AMQP 0-9-1 握手
+func amqpDial(t *domainTarget, auth amqp.Authentication) (*amqp.Connection, error) {
+	scheme := "amqp"
+	if t.Scheme == "amqps" || t.Port == 5671 {
+		scheme = "amqps"
+	}
+	return amqp.DialConfig(fmt.Sprintf("%v://%v/", scheme, t.Addr()), amqp.Config{
+		SASL:            []amqp.Authentication{auth},
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: t.Host},
+		Dial: func(network, addr string) (net.Conn, error) {
+			conn, err := netx.DialTCPTimeout(defaultTimeout, addr)
+			if err != nil {
+				return nil, err
+			}
+			// 握手完成后 amqp 库会重新设置连接的超时时间
+			conn.SetDeadline(time.Now().Add(defaultTimeout))
+			return conn, nil
+		},
+	})
+}
+
+var amqpAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "amqp",
+	DefaultPorts:     "5672,5671",
+	DefaultUsernames: append([]string{"guest", "admin", "rabbitmq", "root", "test", "user"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"guest", "admin", "rabbitmq", "password"}, CommonPasswords...),
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 5672)
+
+		conn, err := amqpDial(t, &amqp.PlainAuth{Username: i.Username, Password: i.Password})
+		if err == amqp.ErrSASL {
+			// 服务端不支持 PLAIN 时尝试 AMQPLAIN
+			conn, err = amqpDial(t, &amqp.AMQPlainAuth{Username: i.Username, Password: i.Password})
+		}
+		switch err {
+		case nil:
+			conn.Close()
+			res.Ok = true
+		case amqp.ErrCredentials:
+		case amqp.ErrVhost:
+			// 已经通过认证，只是没有默认 vhost 的访问权限
+			res.Ok = true
+			res.ExtraInfo = []byte("no access to vhost /")
+		case amqp.ErrSASL:
+			res.Finished = true
+			res.ExtraInfo = []byte("no supported sasl mechanism (PLAIN / AMQPLAIN)")
+		default:
+			log.Debugf("amqp dial %v failed: %v", t.Addr(), err)
+			if _, ok := err.(net.Error); ok {
+				res.Finished = true
+			}
+		}
+		return res
+	},
+}
@@ -0,0 +1,115 @@
+package bruteutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestAMQPBroker 启动一个只实现连接握手的 AMQP 0-9-1 broker，认证失败时和 RabbitMQ 一样直接断开连接
+func startTestAMQPBroker(t *testing.T, mechanisms string, users map[string]string) string {
+	writeMethod := func(conn net.Conn, class, method uint16, args []byte) {
+		payload := binary.BigEndian.AppendUint16(nil, class)
+		payload = binary.BigEndian.AppendUint16(payload, method)
+		payload = append(payload, args...)
+		frame := []byte{1, 0, 0}
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+		conn.Write(append(append(frame, payload...), 0xce))
+	}
+	readMethod := func(conn net.Conn) (uint16, []byte) {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return 0, nil
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return 0, nil
+		}
+		return binary.BigEndian.Uint16(payload[2:4]), payload[4 : len(payload)-1]
+	}
+	longStr := func(s string) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(len(s))), s...)
+	}
+
+	host, port := utils.DebugMockTCPEx(func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil || string(header) != "AMQP\x00\x00\x09\x01" {
+			return
+		}
+		start := append([]byte{0, 9}, 0, 0, 0, 0)
+		start = append(append(start, longStr(mechanisms)...), longStr("en_US")...)
+		writeMethod(conn, 10, 10, start)
+
+		method, args := readMethod(conn)
+		if method != 11 {
+			return
+		}
+		args = args[4+binary.BigEndian.Uint32(args):]
+		mechanism := string(args[1 : 1+args[0]])
+		args = args[1+args[0]:]
+		response := args[4 : 4+binary.BigEndian.Uint32(args)]
+
+		var username, password string
+		switch mechanism {
+		case "PLAIN":
+			fields := bytes.Split(response, []byte{0})
+			require.Len(t, fields, 3)
+			username, password = string(fields[1]), string(fields[2])
+		case "AMQPLAIN":
+			for len(response) > 0 {
+				key := string(response[1 : 1+response[0]])
+				response = response[1+response[0]:]
+				require.Equal(t, byte('S'), response[0])
+				n := binary.BigEndian.Uint32(response[1:])
+				value := string(response[5 : 5+n])
+				response = response[5+n:]
+				if key == "LOGIN" {
+					username = value
+				} else if key == "PASSWORD" {
+					password = value
+				}
+			}
+		}
+		if expected, ok := users[username]; !ok || expected != password {
+			return
+		}
+
+		writeMethod(conn, 10, 30, []byte{0, 0, 0, 2, 0, 0, 0, 0})
+		if method, _ = readMethod(conn); method != 31 {
+			return
+		}
+		if method, _ = readMethod(conn); method != 40 {
+			return
+		}
+		writeMethod(conn, 10, 41, []byte{0})
+		if method, _ = readMethod(conn); method == 50 {
+			writeMethod(conn, 10, 51, nil)
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestAMQPBrute(t *testing.T) {
+	for _, mechanisms := range []string{"PLAIN AMQPLAIN", "AMQPLAIN"} {
+		addr := startTestAMQPBroker(t, mechanisms, map[string]string{"admin": "rabbitmq"})
+		check := func(username, password string) *BruteItemResult {
+			return amqpAuth.BrutePass(&BruteItem{Type: "amqp", Target: addr, Username: username, Password: password})
+		}
+		require.True(t, check("admin", "rabbitmq").Ok, mechanisms)
+		res := check("admin", "guest")
+		require.False(t, res.Ok, mechanisms)
+		require.False(t, res.Finished, mechanisms)
+	}
+
+	addr := startTestAMQPBroker(t, "EXTERNAL", nil)
+	res := amqpAuth.BrutePass(&BruteItem{Type: "amqp", Target: addr, Username: "guest", Password: "guest"})
+	require.False(t, res.Ok)
+	require.True(t, res.Finished)
+}
@@ -67,6 +67,15 @@ var AuthFunctionMap = []struct {
 	{Name: "socks_proxy/v4", Data: "socks4_proxy"},
 	{Name: "socks_proxy/v4a", Data: "socks4a_proxy"},
 	{Name: "pptp", Data: "pptp"},
+	{Name: "ldap", Data: "ldap"},
+	{Name: "kerberos", Data: "kerberos"},
+	{Name: "winrm", Data: "winrm"},
+	{Name: "mqtt", Data: "mqtt"},
+	{Name: "amqp", Data: "amqp"},
+	{Name: "elasticsearch", Data: "elasticsearch"},
+	{Name: "couchdb", Data: "couchdb"},
+	{Name: "cassandra", Data: "cassandra"},
+	{Name: "zookeeper", Data: "zookeeper"},
 }
 
 // rdp https://palm/common/utils/bruteutils/grdp
@@ -101,6 +110,15 @@ var authFunc = map[string]*DefaultServiceAuthInfo{
 	"socks4_proxy":   SocksProxyBruteAuthFactory("socks4"),
 	"socks4a_proxy":  SocksProxyBruteAuthFactory("socks4a"),
 	"pptp":           pptp_Auth,
+	"ldap":           ldapAuth,
+	"kerberos":       kerberosAuth,
+	"winrm":          winrmAuth,
+	"mqtt":           mqttAuth,
+	"amqp":           amqpAuth,
+	"elasticsearch":  elasticsearchAuth,
+	"couchdb":        couchdbAuth,
+	"cassandra":      cassandraAuth,
+	"zookeeper":      zookeeperAuth,
 }
 
 func GetUsernameListFromBruteType(t string) []string {
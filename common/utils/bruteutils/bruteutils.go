@@ -90,6 +90,13 @@ type BruteUtil struct {
 
 	//
 	beforeBruteCallback func(string) bool
+
+	// 密码喷洒：先用同一个密码尝试所有用户，再换下一个密码
+	spray bool
+	// 锁定策略，设置之后每个用户在观察窗口内的尝试次数不会超过阈值
+	lockoutPolicy *LockoutPolicy
+	// 尝试记录，可以持久化之后在多次运行之间共享
+	ledger *AttemptLedger
 }
 
 func (b *BruteUtil) SetResultCallback(cb BruteItemResultCallback) {
@@ -109,6 +116,9 @@ type BruteItemResult struct {
 	// 标志着该用户名有问题，不应该再使用这个用户名
 	UserEliminated bool
 
+	// 标志着出现了账户锁定的迹象，开启锁定策略时会暂停对当前目标的爆破
+	Lockout bool
+
 	// 该爆破只需要密码，不需要用户名
 	OnlyNeedPassword bool
 
@@ -286,7 +296,12 @@ func (b *BruteUtil) startProcessingTarget(target string, parentCtx context.Conte
 		}
 	}
 
-	for _, i := range process.Items {
+	items := process.Items
+	if b.spray {
+		items = sprayOrder(items)
+	}
+
+	for _, i := range items {
 		if err := currCtx.Err(); err != nil {
 			return errors.New("context canceled")
 		}
@@ -335,6 +350,16 @@ func (b *BruteUtil) startProcessingTarget(target string, parentCtx context.Conte
 				return
 			}
 
+			// 按照锁定策略等待，等待期间用户可能已经被废弃
+			if b.lockoutPolicy != nil {
+				if !b.waitLockoutPolicy(currCtx, item) {
+					return
+				}
+				if _, ok := eliminatedUsers.Load(item.Username); ok {
+					return
+				}
+			}
+
 			// 执行爆破函数
 			result := b.callback(item)
 			if result == nil {
@@ -365,6 +390,18 @@ func (b *BruteUtil) startProcessingTarget(target string, parentCtx context.Conte
 			if result.UserEliminated {
 				eliminatedUsers.Store(item.Username, 1)
 			}
+
+			// 密码喷洒时已经找到密码的用户不再尝试
+			if result.Ok && b.spray {
+				eliminatedUsers.Store(item.Username, 1)
+			}
+
+			// 出现锁定迹象时废弃该用户，并暂停整个目标
+			if b.lockoutPolicy != nil && IsLockoutResult(result) {
+				log.Warnf("lockout indicator for %v@%v, pause target for %v", item.Username, item.Target, b.lockoutPolicy.pause())
+				eliminatedUsers.Store(item.Username, 1)
+				b.ledger.MarkLockout(item.Target, item.Username, b.lockoutPolicy.pause(), time.Now())
+			}
 			b.delayer.Wait()
 		}(i)
 	}
@@ -373,6 +410,29 @@ func (b *BruteUtil) startProcessingTarget(target string, parentCtx context.Conte
 	return nil
 }
 
+// waitLockoutPolicy 等待直到锁定策略允许对该用户再尝试一次，返回 false 表示不应该再尝试
+func (b *BruteUtil) waitLockoutPolicy(ctx context.Context, item *BruteItem) bool {
+	for {
+		now := time.Now()
+		if b.ledger.IsLockedOut(item.Target, item.Username, b.lockoutPolicy.lockoutMemory(), now) {
+			log.Infof("skip locked out user %v@%v", item.Username, item.Target)
+			return false
+		}
+		wait := b.ledger.Acquire(item.Target, item.Username, b.lockoutPolicy, now)
+		if wait <= 0 {
+			return true
+		}
+		log.Infof("lockout policy: wait %v before trying %v@%v", wait, item.Username, item.Target)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
 func (b *BruteUtil) popFirstTarget() (string, error) {
 	b.targetListLock.Lock()
 	defer b.targetListLock.Unlock()
@@ -460,6 +520,32 @@ func WithBeforeBruteCallback(c func(string) bool) OptionsAction {
 	}
 }
 
+// 设置密码喷洒，policy 为 nil 时使用 DefaultLockoutPolicy
+// 开启后先用同一个密码尝试所有用户，并且每个用户在观察窗口内的尝试次数不超过阈值
+func WithPasswordSpray(policy *LockoutPolicy) OptionsAction {
+	return func(util *BruteUtil) {
+		util.spray = true
+		if policy == nil {
+			policy = DefaultLockoutPolicy
+		}
+		util.lockoutPolicy = policy
+	}
+}
+
+// 设置锁定策略，不改变爆破顺序
+func WithLockoutPolicy(policy *LockoutPolicy) OptionsAction {
+	return func(util *BruteUtil) {
+		util.lockoutPolicy = policy
+	}
+}
+
+// 设置尝试记录，用于在多次运行之间遵守锁定策略
+func WithAttemptLedger(ledger *AttemptLedger) OptionsAction {
+	return func(util *BruteUtil) {
+		util.ledger = ledger
+	}
+}
+
 func NewMultiTargetBruteUtilEx(options ...OptionsAction) (*BruteUtil, error) {
 	delayer, err := utils.NewDelayWaiter(0, 0)
 	if err != nil {
@@ -483,6 +569,9 @@ func NewMultiTargetBruteUtilEx(options ...OptionsAction) (*BruteUtil, error) {
 	if bu.callback == nil {
 		return nil, errors.New("callback is not set")
 	}
+	if bu.lockoutPolicy != nil && bu.ledger == nil {
+		bu.ledger = NewAttemptLedger()
+	}
 	return bu, nil
 }
 
@@ -0,0 +1,173 @@
+package bruteutils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Cassandra native protocol 操作码
+const (
+	cqlOpError          byte  = 0x00
+	cqlOpStartup        byte  = 0x01
+	cqlOpReady          byte  = 0x02
+	cqlOpAuthenticate   byte  = 0x03
+	cqlOpAuthResponse   byte  = 0x0f
+	cqlOpAuthSuccess    byte  = 0x10
+	cqlErrProtocol      int32 = 0x000a
+	cqlErrBadCredential int32 = 0x0100
+)
+
+type cqlError struct {
+	Code    int32
+	Message string
+}
+
+func (e *cqlError) Error() string {
+	return fmt.Sprintf("cassandra error 0x%04x: %v", e.Code, e.Message)
+}
+
+func cqlAppendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+func cqlWriteFrame(conn net.Conn, version, opcode byte, body []byte) error {
+	frame := []byte{version, 0x00, 0x00, 0x00, opcode}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(body)))
+	_, err := conn.Write(append(frame, body...))
+	return err
+}
+
+func cqlReadFrame(conn net.Conn) (byte, []byte, error) {
+	header := make([]byte, 9)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	if header[0]&0x80 == 0 {
+		return 0, nil, utils.Errorf("cassandra: not a response frame (version 0x%x)", header[0])
+	}
+	length := binary.BigEndian.Uint32(header[5:])
+	if length > 1<<20 {
+		return 0, nil, utils.Errorf("cassandra: frame too large: %v", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[4]
+	if opcode == cqlOpError {
+		e := &cqlError{}
+		if len(body) >= 6 {
+			e.Code = int32(binary.BigEndian.Uint32(body))
+			n := int(binary.BigEndian.Uint16(body[4:]))
+			if 6+n <= len(body) {
+				e.Message = string(body[6 : 6+n])
+			}
+		}
+		return opcode, body, e
+	}
+	return opcode, body, nil
+}
+
+// cassandraLogin 完成 STARTUP 与 PasswordAuthenticator 认证
+// authRequired 表示服务端要求认证；withAuth 为 false 时只检查服务端是否允许未认证访问
+func cassandraLogin(addr string, version byte, username, password string, withAuth bool) (authRequired bool, dialFailed bool, err error) {
+	conn, err := netx.DialTCPTimeout(defaultTimeout, addr)
+	if err != nil {
+		return false, true, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	startup := binary.BigEndian.AppendUint16(nil, 1)
+	startup = cqlAppendString(startup, "CQL_VERSION")
+	startup = cqlAppendString(startup, "3.0.0")
+	if err := cqlWriteFrame(conn, version, cqlOpStartup, startup); err != nil {
+		return false, false, err
+	}
+	opcode, _, err := cqlReadFrame(conn)
+	if err != nil {
+		return false, false, err
+	}
+	switch opcode {
+	case cqlOpReady:
+		return false, false, nil
+	case cqlOpAuthenticate:
+	default:
+		return false, false, utils.Errorf("cassandra: unexpected opcode 0x%x after startup", opcode)
+	}
+	if !withAuth {
+		return true, false, nil
+	}
+
+	token := append([]byte{0}, username...)
+	token = append(append(token, 0), password...)
+	body := binary.BigEndian.AppendUint32(nil, uint32(len(token)))
+	if err := cqlWriteFrame(conn, version, cqlOpAuthResponse, append(body, token...)); err != nil {
+		return true, false, err
+	}
+	opcode, _, err = cqlReadFrame(conn)
+	if err != nil {
+		return true, false, err
+	}
+	if opcode != cqlOpAuthSuccess {
+		return true, false, utils.Errorf("cassandra: unexpected opcode 0x%x after auth response", opcode)
+	}
+	return true, false, nil
+}
+
+// cassandraDo 先使用 v4 协议，服务端不支持时降级到 v3
+func cassandraDo(i *BruteItem, withAuth bool) (authRequired bool, dialFailed bool, err error) {
+	addr := appendDefaultPort(i.Target, 9042)
+	authRequired, dialFailed, err = cassandraLogin(addr, 0x04, i.Username, i.Password, withAuth)
+	if e, ok := err.(*cqlError); ok && e.Code == cqlErrProtocol {
+		authRequired, dialFailed, err = cassandraLogin(addr, 0x03, i.Username, i.Password, withAuth)
+	}
+	return
+}
+
+var cassandraAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "cassandra",
+	DefaultPorts:     "9042",
+	DefaultUsernames: append([]string{"cassandra", "admin"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"cassandra", "admin", "password"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		authRequired, _, err := cassandraDo(i, false)
+		if err != nil {
+			log.Debugf("cassandra startup %v failed: %v", i.Target, err)
+			res.Finished = true
+			return res
+		}
+		if !authRequired {
+			res.Ok = true
+			res.ExtraInfo = []byte("cassandra does not require authentication")
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		authRequired, dialFailed, err := cassandraDo(i, true)
+		if err != nil {
+			if e, ok := err.(*cqlError); !ok || e.Code != cqlErrBadCredential {
+				log.Debugf("cassandra auth %v failed: %v", i.Target, err)
+			}
+			res.Finished = dialFailed
+			return res
+		}
+		if !authRequired {
+			// 未授权访问已经由 UnAuthVerify 报告
+			res.Finished = true
+			return res
+		}
+		res.Ok = true
+		return res
+	},
+}
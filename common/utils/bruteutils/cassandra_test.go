@@ -0,0 +1,84 @@
+package bruteutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestCassandra 启动一个只支持 v3 协议的模拟 Cassandra，users 为空时不要求认证
+func startTestCassandra(t *testing.T, users map[string]string) string {
+	host, port := utils.DebugMockTCPEx(func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		reply := func(version, opcode byte, body []byte) {
+			frame := []byte{version | 0x80, 0, 0, 0, opcode}
+			frame = binary.BigEndian.AppendUint32(frame, uint32(len(body)))
+			conn.Write(append(frame, body...))
+		}
+		replyError := func(version byte, code int32, msg string) {
+			body := binary.BigEndian.AppendUint32(nil, uint32(code))
+			reply(version, cqlOpError, cqlAppendString(body, msg))
+		}
+		for {
+			header := make([]byte, 9)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			body := make([]byte, binary.BigEndian.Uint32(header[5:]))
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return
+			}
+			version := header[0]
+			if version != 0x03 {
+				replyError(0x03, cqlErrProtocol, "Invalid or unsupported protocol version (4); supported versions are (3/v3)")
+				return
+			}
+			switch header[4] {
+			case cqlOpStartup:
+				require.Contains(t, string(body), "CQL_VERSION")
+				if len(users) == 0 {
+					reply(version, cqlOpReady, nil)
+				} else {
+					reply(version, cqlOpAuthenticate, cqlAppendString(nil, "org.apache.cassandra.auth.PasswordAuthenticator"))
+				}
+			case cqlOpAuthResponse:
+				fields := bytes.Split(body[4:], []byte{0})
+				require.Len(t, fields, 3)
+				if expected, ok := users[string(fields[1])]; ok && expected == string(fields[2]) {
+					reply(version, cqlOpAuthSuccess, binary.BigEndian.AppendUint32(nil, 0xffffffff))
+				} else {
+					replyError(version, cqlErrBadCredential, "Provided username and/or password are incorrect")
+				}
+			}
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestCassandraBrute(t *testing.T) {
+	addr := startTestCassandra(t, map[string]string{"cassandra": "cassandra"})
+	unauth := cassandraAuth.UnAuthVerify(&BruteItem{Type: "cassandra", Target: addr})
+	require.False(t, unauth.Ok)
+	require.False(t, unauth.Finished)
+
+	check := func(username, password string) *BruteItemResult {
+		return cassandraAuth.BrutePass(&BruteItem{Type: "cassandra", Target: addr, Username: username, Password: password})
+	}
+	require.True(t, check("cassandra", "cassandra").Ok)
+	res := check("cassandra", "123456")
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+
+	addr = startTestCassandra(t, nil)
+	res = cassandraAuth.GetBruteHandler()(&BruteItem{Type: "cassandra", Target: addr, Username: "cassandra", Password: "123456"})
+	require.True(t, res.Ok)
+	require.Empty(t, res.Username)
+	res = cassandraAuth.GetBruteHandler()(&BruteItem{Type: "cassandra", Target: addr, Username: "admin", Password: "123456"})
+	require.True(t, res.Finished)
+}
@@ -0,0 +1,80 @@
+package bruteutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type couchDBSession struct {
+	OK      bool `json:"ok"`
+	UserCtx struct {
+		Name  *string  `json:"name"`
+		Roles []string `json:"roles"`
+	} `json:"userCtx"`
+}
+
+func parseCouchDBSession(body []byte) (*couchDBSession, bool) {
+	var session couchDBSession
+	if err := json.Unmarshal(body, &session); err != nil || !session.OK {
+		return nil, false
+	}
+	return &session, true
+}
+
+var couchdbAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "couchdb",
+	DefaultPorts:     "5984,6984",
+	DefaultUsernames: append([]string{"admin", "couchdb"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"admin", "couchdb", "password"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 5984)
+		status, body, err := httpBasicGet(t, "/", "", "", false)
+		if err != nil {
+			log.Debugf("couchdb request %v failed: %v", t.Addr(), err)
+			res.Finished = true
+			return res
+		}
+		if status == 200 && !bytes.Contains(bytes.ToLower(body), []byte(`"couchdb"`)) {
+			res.Finished = true
+			res.ExtraInfo = []byte("not couchdb")
+			return res
+		}
+
+		// CouchDB 3.0 之前未配置管理员时所有请求都是 _admin 身份（admin party）
+		if status, body, err = httpBasicGet(t, "/_session", "", "", false); err == nil && status == 200 {
+			if session, ok := parseCouchDBSession(body); ok && utils.StringArrayContains(session.UserCtx.Roles, "_admin") {
+				res.Ok = true
+				res.ExtraInfo = []byte("admin party: anonymous user has _admin role")
+				return res
+			}
+		}
+		if status, body, err = httpBasicGet(t, "/_all_dbs", "", "", false); err == nil && status == 200 && bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			res.Ok = true
+			res.ExtraInfo = []byte(fmt.Sprintf("anonymous user can list databases: %s", bytes.TrimSpace(body)))
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 5984)
+		status, body, err := httpBasicGet(t, "/_session", i.Username, i.Password, true)
+		if err != nil {
+			log.Debugf("couchdb request %v failed: %v", t.Addr(), err)
+			return res
+		}
+		if status != 200 {
+			return res
+		}
+		// admin party 模式下任意密码都能访问，只有 userCtx 中带有用户名才说明认证成功
+		if session, ok := parseCouchDBSession(body); ok && session.UserCtx.Name != nil && *session.UserCtx.Name != "" {
+			res.Ok = true
+			res.ExtraInfo = []byte(fmt.Sprintf("roles: %v", session.UserCtx.Roles))
+		}
+		return res
+	},
+}
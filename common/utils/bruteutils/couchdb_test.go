@@ -0,0 +1,63 @@
+package bruteutils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func startTestCouchDB(t *testing.T, adminParty bool, users map[string]string) string {
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "CouchDB/2.3.1 (Erlang OTP/19)")
+		w.Header().Set("Content-Type", "application/json")
+		username, password, hasAuth := r.BasicAuth()
+		if hasAuth {
+			if expected, ok := users[username]; !ok || expected != password {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"unauthorized","reason":"Name or password is incorrect."}`))
+				return
+			}
+		}
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`{"couchdb":"Welcome","version":"2.3.1"}`))
+		case "/_session":
+			switch {
+			case hasAuth:
+				w.Write([]byte(`{"ok":true,"userCtx":{"name":"` + username + `","roles":["_admin"]}}`))
+			case adminParty:
+				w.Write([]byte(`{"ok":true,"userCtx":{"name":null,"roles":["_admin"]}}`))
+			default:
+				w.Write([]byte(`{"ok":true,"userCtx":{"name":null,"roles":[]}}`))
+			}
+		case "/_all_dbs":
+			if !hasAuth && !adminParty {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"unauthorized","reason":"You are not authorized to access this db."}`))
+				return
+			}
+			w.Write([]byte(`["_replicator","_users"]`))
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestCouchDBBrute(t *testing.T) {
+	addr := startTestCouchDB(t, false, map[string]string{"admin": "couchdb"})
+	unauth := couchdbAuth.UnAuthVerify(&BruteItem{Type: "couchdb", Target: addr})
+	require.False(t, unauth.Ok)
+	require.False(t, unauth.Finished)
+
+	check := func(username, password string) *BruteItemResult {
+		return couchdbAuth.BrutePass(&BruteItem{Type: "couchdb", Target: addr, Username: username, Password: password})
+	}
+	require.True(t, check("admin", "couchdb").Ok)
+	require.False(t, check("admin", "123456").Ok)
+
+	addr = startTestCouchDB(t, true, nil)
+	unauth = couchdbAuth.UnAuthVerify(&BruteItem{Type: "couchdb", Target: addr})
+	require.True(t, unauth.Ok)
+	require.Contains(t, string(unauth.ExtraInfo), "admin party")
+}
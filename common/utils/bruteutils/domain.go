@@ -0,0 +1,80 @@
+package bruteutils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// domainTarget 是目录服务类爆破（ldap / kerberos / winrm）的目标，也用于需要通过 scheme 区分 TLS 的服务（mqtt / amqp / http 接口类服务）
+// 目标既可以是 host:port，也可以是 scheme://host:port/path?domain=corp.local 形式的 URL
+type domainTarget struct {
+	Scheme string
+	Host   string
+	Port   int
+	Path   string
+	Domain string
+	// ExplicitDomain 表示域名来自目标参数，而不是从主机名推断
+	ExplicitDomain bool
+}
+
+func (t *domainTarget) Addr() string {
+	return utils.HostPort(t.Host, t.Port)
+}
+
+func parseDomainTarget(target string, defaultPort int) *domainTarget {
+	t := &domainTarget{}
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			t.Scheme = strings.ToLower(u.Scheme)
+			t.Path = u.Path
+			query := u.Query()
+			t.Domain = query.Get("domain")
+			if t.Domain == "" {
+				t.Domain = query.Get("realm")
+			}
+			t.ExplicitDomain = t.Domain != ""
+			target = u.Host
+		}
+	}
+	host, port, err := utils.ParseStringToHostPort(target)
+	if err != nil {
+		host = target
+	}
+	if port <= 0 {
+		port = defaultPort
+	}
+	t.Host, t.Port = host, port
+	if t.Domain == "" {
+		t.Domain = domainFromHost(host)
+	}
+	return t
+}
+
+// domainFromHost 从域控的主机名推断域名，例如 dc01.corp.local -> corp.local
+func domainFromHost(host string) string {
+	if host == "" || utils.IsIPv4(host) || utils.IsIPv6(host) {
+		return ""
+	}
+	labels := strings.Split(strings.Trim(host, "."), ".")
+	switch {
+	case len(labels) >= 3:
+		return strings.Join(labels[1:], ".")
+	case len(labels) == 2:
+		return strings.Join(labels, ".")
+	default:
+		return ""
+	}
+}
+
+// splitDomainUser 拆分 CORP\user 或 user@corp.local 形式的用户名，没有域信息时 domain 为空
+func splitDomainUser(username string) (domain string, user string) {
+	if before, after, ok := strings.Cut(username, "\\"); ok {
+		return before, after
+	}
+	if idx := strings.LastIndex(username, "@"); idx > 0 {
+		return username[idx+1:], username[:idx]
+	}
+	return "", username
+}
@@ -0,0 +1,92 @@
+package bruteutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/log"
+)
+
+type esRootInfo struct {
+	Name        string `json:"name"`
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+	Tagline string `json:"tagline"`
+}
+
+func (e *esRootInfo) String() string {
+	product := "elasticsearch"
+	if e.Version.Distribution != "" {
+		product = e.Version.Distribution
+	}
+	return fmt.Sprintf("%v %v, cluster: %v, node: %v", product, e.Version.Number, e.ClusterName, e.Name)
+}
+
+func parseESRootInfo(body []byte) (*esRootInfo, bool) {
+	var info esRootInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, false
+	}
+	if info.ClusterName == "" && info.Tagline == "" {
+		return nil, false
+	}
+	return &info, true
+}
+
+var elasticsearchAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "elasticsearch",
+	DefaultPorts:     "9200",
+	DefaultUsernames: append([]string{"elastic", "admin", "kibana", "kibana_system", "logstash_system"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"changeme", "elastic", "admin", "elasticsearch"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 9200)
+		status, body, err := httpBasicGet(t, "/", "", "", false)
+		if err != nil {
+			log.Debugf("elasticsearch request %v failed: %v", t.Addr(), err)
+			res.Finished = true
+			return res
+		}
+		switch {
+		case status == 401:
+			return res
+		case status == 200:
+			if info, ok := parseESRootInfo(body); ok {
+				res.Ok = true
+				res.ExtraInfo = []byte(info.String())
+				return res
+			}
+		}
+		// 既不需要认证也不像 elasticsearch 的服务没有爆破的必要
+		res.Finished = true
+		res.ExtraInfo = []byte(fmt.Sprintf("not elasticsearch, status code: %v", status))
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 9200)
+		status, body, err := httpBasicGet(t, "/", i.Username, i.Password, true)
+		if err != nil {
+			log.Debugf("elasticsearch request %v failed: %v", t.Addr(), err)
+			return res
+		}
+		switch status {
+		case 200:
+			res.Ok = true
+			if info, ok := parseESRootInfo(body); ok {
+				res.ExtraInfo = []byte(info.String())
+			}
+		case 403:
+			// 密码正确但用户没有 cluster:monitor/main 权限
+			if bytes.Contains(body, []byte("security_exception")) {
+				res.Ok = true
+				res.ExtraInfo = []byte("authenticated without cluster monitor privilege")
+			}
+		}
+		return res
+	},
+}
@@ -0,0 +1,59 @@
+package bruteutils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const testESRoot = `{"name":"node-1","cluster_name":"docker-cluster","version":{"number":"8.11.1"},"tagline":"You Know, for Search"}`
+
+func TestElasticsearchBrute(t *testing.T) {
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		username, password, ok := r.BasicAuth()
+		switch {
+		case !ok || username == "elastic" && password != "changeme":
+			w.Header().Set("WWW-Authenticate", `Basic realm="security" charset="UTF-8"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"type":"security_exception"},"status":401}`))
+		case username == "elastic":
+			w.Write([]byte(testESRoot))
+		case username == "logstash_system" && password == "logstash":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"type":"security_exception","reason":"action [cluster:monitor/main] is unauthorized for user [logstash_system]"},"status":403}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	addr := utils.HostPort(host, port)
+
+	unauth := elasticsearchAuth.UnAuthVerify(&BruteItem{Type: "elasticsearch", Target: addr})
+	require.False(t, unauth.Ok)
+	require.False(t, unauth.Finished)
+
+	check := func(username, password string) *BruteItemResult {
+		return elasticsearchAuth.BrutePass(&BruteItem{Type: "elasticsearch", Target: addr, Username: username, Password: password})
+	}
+	res := check("elastic", "changeme")
+	require.True(t, res.Ok)
+	require.Contains(t, string(res.ExtraInfo), "docker-cluster")
+	require.False(t, check("elastic", "123456").Ok)
+	require.True(t, check("logstash_system", "logstash").Ok)
+
+	host, port = utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testESRoot))
+	})
+	unauth = elasticsearchAuth.UnAuthVerify(&BruteItem{Type: "elasticsearch", Target: utils.HostPort(host, port)})
+	require.True(t, unauth.Ok)
+	require.Contains(t, string(unauth.ExtraInfo), "8.11.1")
+
+	host, port = utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>hello</html>"))
+	})
+	unauth = elasticsearchAuth.UnAuthVerify(&BruteItem{Type: "elasticsearch", Target: utils.HostPort(host, port)})
+	require.False(t, unauth.Ok)
+	require.True(t, unauth.Finished)
+}
@@ -0,0 +1,34 @@
+package bruteutils
+
+import (
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// httpBasicGet 向 HTTP 接口类服务（elasticsearch / couchdb）发送 GET 请求，withAuth 为 true 时携带 Basic 认证
+// 目标没有指定 scheme 时自动探测是否为 TLS 服务
+func httpBasicGet(t *domainTarget, path, username, password string, withAuth bool) (int, []byte, error) {
+	isTls := t.Scheme == "https"
+	if t.Scheme == "" {
+		isTls = netx.IsTLSService(t.Addr())
+	}
+	packet := fmt.Sprintf("GET %v HTTP/1.1\r\n"+
+		"Host: %v\r\n"+
+		"Accept: application/json\r\n"+
+		"User-Agent: Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36\r\n", path, t.Addr())
+	if withAuth {
+		packet += "Authorization: Basic " + codec.EncodeBase64(username+":"+password) + "\r\n"
+	}
+	rsp, err := lowhttp.HTTPWithoutRedirect(
+		lowhttp.WithHttps(isTls),
+		lowhttp.WithRequest([]byte(packet+"\r\n")),
+		lowhttp.WithTimeout(defaultTimeout),
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	return lowhttp.GetStatusCodeFromResponse(rsp.RawPacket), lowhttp.GetHTTPPacketBody(rsp.RawPacket), nil
+}
@@ -0,0 +1,392 @@
+package bruteutils
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Kerberos 消息类型、预认证类型与 KDC 错误码，见 RFC 4120
+const (
+	krbMsgTypeASReq = 10
+	krbMsgTypeASRep = 11
+	krbMsgTypeError = 30
+
+	krbPATypeEncTimestamp = 2
+	krbPATypeETypeInfo2   = 19
+
+	krbNameTypePrincipal = 1
+	krbNameTypeSrvInst   = 2
+
+	krbKeyUsageASReqTimestamp = 1
+
+	krbErrPrincipalUnknown = 6
+	krbErrETypeNoSupport   = 14
+	krbErrClientRevoked    = 18
+	krbErrKeyExpired       = 23
+	krbErrPreAuthFailed    = 24
+	krbErrPreAuthRequired  = 25
+	krbErrClockSkew        = 37
+	krbErrWrongRealm       = 68
+)
+
+type krbPrincipalName struct {
+	NameType   int32           `asn1:"explicit,tag:0"`
+	NameString []asn1.RawValue `asn1:"explicit,tag:1"`
+}
+
+type krbPAData struct {
+	Type  int32  `asn1:"explicit,tag:1"`
+	Value []byte `asn1:"explicit,tag:2"`
+}
+
+type krbReqBody struct {
+	KDCOptions asn1.BitString   `asn1:"explicit,tag:0"`
+	CName      krbPrincipalName `asn1:"explicit,tag:1"`
+	Realm      asn1.RawValue    `asn1:"explicit,tag:2"`
+	SName      krbPrincipalName `asn1:"explicit,tag:3"`
+	Till       time.Time        `asn1:"generalized,explicit,tag:5"`
+	Nonce      int32            `asn1:"explicit,tag:7"`
+	EType      []int32          `asn1:"explicit,tag:8"`
+}
+
+type krbKDCReq struct {
+	PVNO    int         `asn1:"explicit,tag:1"`
+	MsgType int         `asn1:"explicit,tag:2"`
+	PAData  []krbPAData `asn1:"optional,omitempty,explicit,tag:3"`
+	ReqBody krbReqBody  `asn1:"explicit,tag:4"`
+}
+
+type krbEncryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int    `asn1:"optional,explicit,tag:1"`
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+type krbPAEncTSEnc struct {
+	PATimestamp time.Time `asn1:"generalized,explicit,tag:0"`
+	PAUSec      int       `asn1:"optional,explicit,tag:1"`
+}
+
+type krbETypeInfo2Entry struct {
+	EType     int32  `asn1:"explicit,tag:0"`
+	Salt      string `asn1:"optional,explicit,tag:1"`
+	S2KParams []byte `asn1:"optional,explicit,tag:2"`
+}
+
+type krbErrorMessage struct {
+	PVNO      int           `asn1:"explicit,tag:0"`
+	MsgType   int           `asn1:"explicit,tag:1"`
+	CTime     time.Time     `asn1:"generalized,optional,explicit,tag:2"`
+	CUSec     int           `asn1:"optional,explicit,tag:3"`
+	STime     time.Time     `asn1:"generalized,explicit,tag:4"`
+	SUSec     int           `asn1:"explicit,tag:5"`
+	ErrorCode int32         `asn1:"explicit,tag:6"`
+	CRealm    string        `asn1:"optional,explicit,tag:7"`
+	CName     asn1.RawValue `asn1:"optional,explicit,tag:8"`
+	Realm     string        `asn1:"explicit,tag:9"`
+	SName     asn1.RawValue `asn1:"explicit,tag:10"`
+	EText     string        `asn1:"optional,explicit,tag:11"`
+	EData     []byte        `asn1:"optional,explicit,tag:12"`
+}
+
+func krbGeneralString(s string) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagGeneralString, Bytes: []byte(s)}
+}
+
+// krbExplicit 为 RawValue 手动加上 explicit 标签，encoding/asn1 处理 RawValue 字段时会忽略 explicit 标签
+func krbExplicit(tag int, v asn1.RawValue) asn1.RawValue {
+	inner, _ := asn1.Marshal(v)
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: inner}
+}
+
+func krbPrincipal(nameType int32, names ...string) krbPrincipalName {
+	p := krbPrincipalName{NameType: nameType}
+	for _, name := range names {
+		p.NameString = append(p.NameString, krbGeneralString(name))
+	}
+	return p
+}
+
+// krbPreAuthInfo 是 KDC 在 KDC_ERR_PREAUTH_REQUIRED 中给出的加密参数
+type krbPreAuthInfo struct {
+	EType     int32
+	Salt      string
+	S2KParams []byte
+	// Skew 是 KDC 与本机的时间差，加密时间戳时需要修正
+	Skew time.Duration
+}
+
+// krbPreAuthCache 缓存每个用户的预认证参数，避免每次爆破都先发送一次不带预认证的 AS-REQ
+var krbPreAuthCache = new(sync.Map)
+
+// krbASReq 构造 AS-REQ，padata 为空时用于探测用户是否存在以及获取预认证参数
+func krbASReq(realm, username string, etypes []int32, padata ...krbPAData) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, big.NewInt(1<<31-1))
+	if err != nil {
+		return nil, err
+	}
+	req := krbKDCReq{
+		PVNO:    5,
+		MsgType: krbMsgTypeASReq,
+		PAData:  padata,
+		ReqBody: krbReqBody{
+			// forwardable, renewable, canonicalize, renewable-ok
+			KDCOptions: asn1.BitString{Bytes: []byte{0x40, 0x81, 0x00, 0x10}, BitLength: 32},
+			CName:      krbPrincipal(krbNameTypePrincipal, username),
+			Realm:      krbExplicit(2, krbGeneralString(realm)),
+			SName:      krbPrincipal(krbNameTypeSrvInst, "krbtgt", realm),
+			Till:       time.Date(2037, 9, 13, 2, 48, 5, 0, time.UTC),
+			Nonce:      int32(nonce.Int64()),
+			EType:      etypes,
+		},
+	}
+	inner, err := asn1.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassApplication, Tag: krbMsgTypeASReq, IsCompound: true, Bytes: inner})
+}
+
+// krbEncTimestamp 构造 PA-ENC-TIMESTAMP 预认证数据
+func krbEncTimestamp(info *krbPreAuthInfo, password string) (krbPAData, error) {
+	key, err := krbStringToKey(info.EType, password, info.Salt, info.S2KParams)
+	if err != nil {
+		return krbPAData{}, err
+	}
+	now := time.Now().Add(info.Skew).UTC()
+	ts, err := asn1.Marshal(krbPAEncTSEnc{
+		PATimestamp: now.Truncate(time.Second),
+		PAUSec:      now.Nanosecond() / 1000,
+	})
+	if err != nil {
+		return krbPAData{}, err
+	}
+	cipher, err := krbEncrypt(info.EType, key, krbKeyUsageASReqTimestamp, ts)
+	if err != nil {
+		return krbPAData{}, err
+	}
+	value, err := asn1.Marshal(krbEncryptedData{EType: info.EType, Cipher: cipher})
+	if err != nil {
+		return krbPAData{}, err
+	}
+	return krbPAData{Type: krbPATypeEncTimestamp, Value: value}, nil
+}
+
+// krbExchange 通过 TCP 发送 Kerberos 消息，返回 AS-REP 时 krbErr 为空
+func krbExchange(addr string, req []byte) (krbErr *krbErrorMessage, err error) {
+	conn, err := netx.DialTCPTimeout(defaultTimeout, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	packet := make([]byte, 4, 4+len(req))
+	binary.BigEndian.PutUint32(packet, uint32(len(req)))
+	if _, err := conn.Write(append(packet, req...)); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length > 1<<20 {
+		return nil, utils.Errorf("kerberos response too large: %v", length)
+	}
+	rsp := make([]byte, length)
+	if _, err := io.ReadFull(conn, rsp); err != nil {
+		return nil, err
+	}
+
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(rsp, &raw); err != nil {
+		return nil, utils.Errorf("parse kerberos response failed: %v", err)
+	}
+	if raw.Class != asn1.ClassApplication {
+		return nil, utils.Errorf("unexpected kerberos response class: %v", raw.Class)
+	}
+	switch raw.Tag {
+	case krbMsgTypeASRep:
+		return nil, nil
+	case krbMsgTypeError:
+		krbErr = &krbErrorMessage{}
+		if _, err := asn1.Unmarshal(raw.Bytes, krbErr); err != nil {
+			return nil, utils.Errorf("parse KRB-ERROR failed: %v", err)
+		}
+		return krbErr, nil
+	default:
+		return nil, utils.Errorf("unexpected kerberos response: application %v", raw.Tag)
+	}
+}
+
+// krbParsePreAuthInfo 从 KDC_ERR_PREAUTH_REQUIRED 的 METHOD-DATA 中选出第一个支持的加密类型
+func krbParsePreAuthInfo(krbErr *krbErrorMessage, realm, username string) *krbPreAuthInfo {
+	info := &krbPreAuthInfo{
+		EType: krbETypeRC4,
+		Salt:  realm + username,
+		Skew:  time.Until(krbErr.STime),
+	}
+	var methods []krbPAData
+	if _, err := asn1.Unmarshal(krbErr.EData, &methods); err != nil {
+		return info
+	}
+	for _, method := range methods {
+		if method.Type != krbPATypeETypeInfo2 {
+			continue
+		}
+		var entries []krbETypeInfo2Entry
+		if _, err := asn1.Unmarshal(method.Value, &entries); err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !krbSupportedEType(entry.EType) {
+				continue
+			}
+			info.EType = entry.EType
+			if entry.Salt != "" {
+				info.Salt = entry.Salt
+			}
+			info.S2KParams = entry.S2KParams
+			return info
+		}
+	}
+	return info
+}
+
+// krbRealmAndUser 从用户名（CORP\user、user@corp.local）或目标中获取 realm，realm 统一为大写
+func krbRealmAndUser(t *domainTarget, username string) (string, string) {
+	domain, user := splitDomainUser(username)
+	if domain == "" {
+		domain = t.Domain
+	} else if !strings.Contains(domain, ".") && t.Domain != "" && strings.EqualFold(strings.SplitN(t.Domain, ".", 2)[0], domain) {
+		// NetBIOS 域名（CORP\user）优先使用目标上的 DNS 域名作为 realm
+		domain = t.Domain
+	}
+	return strings.ToUpper(domain), user
+}
+
+var kerberosAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "kerberos",
+	DefaultPorts:     "88",
+	DefaultUsernames: []string{"administrator", "admin", "krbtgt", "guest", "test", "svc_sql", "svc_backup", "sqlservice"},
+	DefaultPasswords: append([]string{"P@ssw0rd", "Passw0rd", "Password1", "Admin@123", "Welcome1", "Qwer1234"}, CommonPasswords...),
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 88)
+		realm, user := krbRealmAndUser(t, i.Username)
+		if realm == "" {
+			res.Finished = true
+			res.ExtraInfo = []byte("kerberos realm is unknown, use CORP\\user, user@corp.local or kerberos://dc/?realm=corp.local")
+			return res
+		}
+		if i.Password == "" {
+			return res
+		}
+
+		cacheKey := fmt.Sprintf("%v|%v|%v", t.Addr(), realm, strings.ToLower(user))
+		var info *krbPreAuthInfo
+		if cached, ok := krbPreAuthCache.Load(cacheKey); ok {
+			info = cached.(*krbPreAuthInfo)
+		} else {
+			// 不带预认证的 AS-REQ：通过 KDC 的错误码判断用户是否存在
+			req, err := krbASReq(realm, user, []int32{krbETypeAES256, krbETypeAES128, krbETypeRC4})
+			if err != nil {
+				log.Errorf("build kerberos AS-REQ failed: %v", err)
+				return res
+			}
+			krbErr, err := krbExchange(t.Addr(), req)
+			if err != nil {
+				if _, ok := err.(net.Error); ok {
+					res.Finished = true
+				}
+				log.Debugf("kerberos exchange with %v failed: %v", t.Addr(), err)
+				return res
+			}
+			if krbErr == nil {
+				// 用户不需要预认证，无法通过预认证验证密码，但可以直接 AS-REP Roasting
+				res.UserEliminated = true
+				res.ExtraInfo = []byte("pre-authentication not required (AS-REP roastable)")
+				return res
+			}
+			switch krbErr.ErrorCode {
+			case krbErrPreAuthRequired:
+				info = krbParsePreAuthInfo(krbErr, realm, user)
+				krbPreAuthCache.Store(cacheKey, info)
+			case krbErrPrincipalUnknown:
+				res.UserEliminated = true
+				res.ExtraInfo = []byte("user not found")
+				return res
+			case krbErrClientRevoked:
+				setKerberosClientRevoked(res)
+				return res
+			case krbErrWrongRealm:
+				res.Finished = true
+				res.ExtraInfo = []byte("wrong realm: " + realm)
+				return res
+			default:
+				res.Finished = true
+				res.ExtraInfo = []byte(fmt.Sprintf("unexpected kerberos error code: %v", krbErr.ErrorCode))
+				return res
+			}
+		}
+
+		padata, err := krbEncTimestamp(info, i.Password)
+		if err != nil {
+			log.Errorf("build kerberos pre-authentication failed: %v", err)
+			return res
+		}
+		req, err := krbASReq(realm, user, []int32{info.EType}, padata)
+		if err != nil {
+			log.Errorf("build kerberos AS-REQ failed: %v", err)
+			return res
+		}
+		krbErr, err := krbExchange(t.Addr(), req)
+		if err != nil {
+			if _, ok := err.(net.Error); ok {
+				res.Finished = true
+			}
+			log.Debugf("kerberos exchange with %v failed: %v", t.Addr(), err)
+			return res
+		}
+		if krbErr == nil {
+			res.Ok = true
+			return res
+		}
+		switch krbErr.ErrorCode {
+		case krbErrPreAuthFailed:
+		case krbErrKeyExpired:
+			res.Ok = true
+			res.ExtraInfo = []byte("password expired")
+		case krbErrClientRevoked:
+			setKerberosClientRevoked(res)
+		case krbErrClockSkew, krbErrETypeNoSupport:
+			// 缓存的预认证参数已经失效，下次重新获取
+			krbPreAuthCache.Delete(cacheKey)
+			res.ExtraInfo = []byte(fmt.Sprintf("kerberos error code: %v", krbErr.ErrorCode))
+		default:
+			res.ExtraInfo = []byte(fmt.Sprintf("kerberos error code: %v", krbErr.ErrorCode))
+		}
+		return res
+	},
+}
+
+// setKerberosClientRevoked 处理 KDC_ERR_CLIENT_REVOKED：账户被禁用或者锁定，不再尝试该用户，并且按照锁定处理以暂停目标
+func setKerberosClientRevoked(res *BruteItemResult) {
+	res.UserEliminated = true
+	res.Lockout = true
+	res.ExtraInfo = []byte("account disabled or locked out")
+}
@@ -0,0 +1,212 @@
+package bruteutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/crypto/md4"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Kerberos 加密类型，见 RFC 3961 / RFC 3962 / RFC 4757
+const (
+	krbETypeAES128 = 17
+	krbETypeAES256 = 18
+	krbETypeRC4    = 23
+)
+
+func krbSupportedEType(etype int32) bool {
+	switch etype {
+	case krbETypeAES128, krbETypeAES256, krbETypeRC4:
+		return true
+	}
+	return false
+}
+
+// krbStringToKey 根据密码和 salt 生成长期密钥，s2kparams 为空时使用默认迭代次数
+func krbStringToKey(etype int32, password, salt string, s2kparams []byte) ([]byte, error) {
+	switch etype {
+	case krbETypeRC4:
+		h := md4.New()
+		for _, c := range utf16.Encode([]rune(password)) {
+			h.Write([]byte{byte(c), byte(c >> 8)})
+		}
+		return h.Sum(nil), nil
+	case krbETypeAES128, krbETypeAES256:
+		keyLen := 16
+		if etype == krbETypeAES256 {
+			keyLen = 32
+		}
+		iterations := 4096
+		if len(s2kparams) == 4 {
+			iterations = int(binary.BigEndian.Uint32(s2kparams))
+		}
+		tkey := pbkdf2.Key([]byte(password), []byte(salt), iterations, keyLen, sha1.New)
+		return krbAESDeriveKey(tkey, []byte("kerberos"))
+	default:
+		return nil, utils.Errorf("unsupported kerberos etype: %v", etype)
+	}
+}
+
+// krbAESDeriveKey 是 RFC 3961 中的 DK(key, constant)，AES 的 random-to-key 是恒等变换
+func krbAESDeriveKey(key []byte, constant []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	in := krbNFold(constant, aes.BlockSize)
+	out := make([]byte, 0, len(key)+aes.BlockSize)
+	for len(out) < len(key) {
+		b := make([]byte, aes.BlockSize)
+		block.Encrypt(b, in)
+		out = append(out, b...)
+		in = b
+	}
+	return out[:len(key)], nil
+}
+
+// krbNFold 把输入折叠为 n 字节，见 RFC 3961 5.1
+func krbNFold(in []byte, n int) []byte {
+	inBits, outBits := len(in)*8, n*8
+	a, b := inBits, outBits
+	for b != 0 {
+		a, b = b, a%b
+	}
+	lcm := inBits / a * outBits
+
+	buf := make([]byte, 0, lcm/8)
+	for i := 0; i < lcm/inBits; i++ {
+		buf = append(buf, krbRotateRight(in, 13*i)...)
+	}
+
+	out := make([]byte, n)
+	for off := 0; off < len(buf); off += n {
+		carry := 0
+		for j := n - 1; j >= 0; j-- {
+			s := int(out[j]) + int(buf[off+j]) + carry
+			out[j], carry = byte(s), s>>8
+		}
+		// 反码加法，最高位的进位回加到最低位
+		for carry > 0 {
+			for j := n - 1; j >= 0 && carry > 0; j-- {
+				s := int(out[j]) + carry
+				out[j], carry = byte(s), s>>8
+			}
+		}
+	}
+	return out
+}
+
+func krbRotateRight(in []byte, step int) []byte {
+	bits := len(in) * 8
+	out := make([]byte, len(in))
+	for i := 0; i < bits; i++ {
+		if in[i/8]&(0x80>>(i%8)) == 0 {
+			continue
+		}
+		p := (i + step) % bits
+		out[p/8] |= 0x80 >> (p % 8)
+	}
+	return out
+}
+
+func krbHMAC(h func() hash.Hash, key []byte, data ...[]byte) []byte {
+	mac := hmac.New(h, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// krbEncrypt 使用 key usage 加密数据，输出格式与 EncryptedData.cipher 相同
+func krbEncrypt(etype int32, key []byte, usage uint32, plaintext []byte) ([]byte, error) {
+	switch etype {
+	case krbETypeRC4:
+		usageBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(usageBytes, usage)
+		k1 := krbHMAC(md5.New, key, usageBytes)
+		data := make([]byte, 8, 8+len(plaintext))
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+		data = append(data, plaintext...)
+		checksum := krbHMAC(md5.New, k1, data)
+		k3 := krbHMAC(md5.New, k1, checksum)
+		c, err := rc4.NewCipher(k3)
+		if err != nil {
+			return nil, err
+		}
+		encrypted := make([]byte, len(data))
+		c.XORKeyStream(encrypted, data)
+		return append(checksum, encrypted...), nil
+	case krbETypeAES128, krbETypeAES256:
+		ke, ki, err := krbAESUsageKeys(key, usage)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, aes.BlockSize, aes.BlockSize+len(plaintext))
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+		data = append(data, plaintext...)
+		encrypted, err := krbAESCTSEncrypt(ke, data)
+		if err != nil {
+			return nil, err
+		}
+		return append(encrypted, krbHMAC(sha1.New, ki, data)[:12]...), nil
+	default:
+		return nil, utils.Errorf("unsupported kerberos etype: %v", etype)
+	}
+}
+
+// krbAESUsageKeys 生成指定 key usage 的加密密钥 Ke 和完整性密钥 Ki
+func krbAESUsageKeys(key []byte, usage uint32) ([]byte, []byte, error) {
+	constant := make([]byte, 5)
+	binary.BigEndian.PutUint32(constant, usage)
+	constant[4] = 0xaa
+	ke, err := krbAESDeriveKey(key, constant)
+	if err != nil {
+		return nil, nil, err
+	}
+	constant[4] = 0x55
+	ki, err := krbAESDeriveKey(key, constant)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ke, ki, nil
+}
+
+// krbAESCTSEncrypt 是 Kerberos 使用的 CBC 密文挪用模式（IV 为 0，最后两个分组交换）
+func krbAESCTSEncrypt(key []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aes.BlockSize {
+		return nil, utils.Error("kerberos aes-cts: plaintext too short")
+	}
+	if len(data) == aes.BlockSize {
+		out := make([]byte, aes.BlockSize)
+		block.Encrypt(out, data)
+		return out, nil
+	}
+	padded := make([]byte, (len(data)+aes.BlockSize-1)/aes.BlockSize*aes.BlockSize)
+	copy(padded, data)
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(padded, padded)
+
+	n := len(padded)
+	lastTwo := make([]byte, 2*aes.BlockSize)
+	copy(lastTwo, padded[n-aes.BlockSize:])
+	copy(lastTwo[aes.BlockSize:], padded[n-2*aes.BlockSize:n-aes.BlockSize])
+	copy(padded[n-2*aes.BlockSize:], lastTwo)
+	return padded[:len(data)], nil
+}
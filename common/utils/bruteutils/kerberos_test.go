@@ -0,0 +1,252 @@
+package bruteutils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKerberosNFold(t *testing.T) {
+	// RFC 3961 A.1
+	for _, c := range []struct {
+		in  string
+		n   int
+		out string
+	}{
+		{"012345", 8, "be072631276b1955"},
+		{"password", 7, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 8, "bb6ed30870b7f0e0"},
+		{"password", 21, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"kerberos", 16, "6b65726265726f737b9b5b2b93132b93"},
+	} {
+		require.Equal(t, c.out, hex.EncodeToString(krbNFold([]byte(c.in), c.n)), c.in)
+	}
+}
+
+func TestKerberosStringToKey(t *testing.T) {
+	// RFC 3962 Appendix B
+	key, err := krbStringToKey(krbETypeAES128, "password", "ATHENA.MIT.EDUraeburn", []byte{0, 0, 0, 1})
+	require.NoError(t, err)
+	require.Equal(t, "42263c6e89f4fc28b8df68ee09799f15", hex.EncodeToString(key))
+	key, err = krbStringToKey(krbETypeAES256, "password", "ATHENA.MIT.EDUraeburn", []byte{0, 0, 0, 1})
+	require.NoError(t, err)
+	require.Equal(t, "fe697b52bc0d3ce14432ba036a92e65bbb52280990a2fa27883998d72af30161", hex.EncodeToString(key))
+
+	key, err = krbStringToKey(krbETypeRC4, "password", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c", hex.EncodeToString(key))
+}
+
+// krbDecrypt 是 krbEncrypt 的逆过程，用于模拟 KDC 校验预认证
+func krbDecrypt(t *testing.T, etype int32, key []byte, usage uint32, data []byte) ([]byte, bool) {
+	switch etype {
+	case krbETypeRC4:
+		usageBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(usageBytes, usage)
+		k1 := krbHMAC(md5.New, key, usageBytes)
+		checksum := data[:16]
+		c, err := rc4.NewCipher(krbHMAC(md5.New, k1, checksum))
+		require.NoError(t, err)
+		plain := make([]byte, len(data)-16)
+		c.XORKeyStream(plain, data[16:])
+		return plain[8:], bytes.Equal(krbHMAC(md5.New, k1, plain), checksum)
+	default:
+		ke, ki, err := krbAESUsageKeys(key, usage)
+		require.NoError(t, err)
+		encrypted, mac := data[:len(data)-12], data[len(data)-12:]
+		block, err := aes.NewCipher(ke)
+		require.NoError(t, err)
+
+		n := (len(encrypted) + aes.BlockSize - 1) / aes.BlockSize
+		m := len(encrypted) - aes.BlockSize*(n-1)
+		partial := encrypted[aes.BlockSize*(n-1):]
+		d := make([]byte, aes.BlockSize)
+		block.Decrypt(d, encrypted[aes.BlockSize*(n-2):aes.BlockSize*(n-1)])
+		last := make([]byte, m)
+		for i := range last {
+			last[i] = d[i] ^ partial[i]
+		}
+		full := append(append([]byte{}, encrypted[:aes.BlockSize*(n-2)]...), partial...)
+		full = append(full, d[m:]...)
+		plain := make([]byte, len(full))
+		cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(plain, full)
+		plain = append(plain, last...)
+		return plain[aes.BlockSize:], bytes.Equal(krbHMAC(sha1.New, ki, plain)[:12], mac)
+	}
+}
+
+type testKrbError struct {
+	PVNO      int              `asn1:"explicit,tag:0"`
+	MsgType   int              `asn1:"explicit,tag:1"`
+	STime     time.Time        `asn1:"generalized,explicit,tag:4"`
+	SUSec     int              `asn1:"explicit,tag:5"`
+	ErrorCode int32            `asn1:"explicit,tag:6"`
+	Realm     asn1.RawValue    `asn1:"explicit,tag:9"`
+	SName     krbPrincipalName `asn1:"explicit,tag:10"`
+	EData     []byte           `asn1:"optional,omitempty,explicit,tag:12"`
+}
+
+type testKrbUser struct {
+	password  string
+	etype     int32
+	code      int32
+	noPreAuth bool
+}
+
+// startTestKDC 启动一个模拟的 KDC，KDC 时间比本机快一小时，用于验证时间偏差修正
+func startTestKDC(t *testing.T, realm string, users map[string]*testKrbUser) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	krbError := func(code int32, edata []byte) []byte {
+		inner, err := asn1.Marshal(testKrbError{
+			PVNO: 5, MsgType: krbMsgTypeError, STime: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+			ErrorCode: code, Realm: krbExplicit(9, krbGeneralString(realm)), SName: krbPrincipal(krbNameTypeSrvInst, "krbtgt", realm),
+			EData: edata,
+		})
+		require.NoError(t, err)
+		raw, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassApplication, Tag: krbMsgTypeError, IsCompound: true, Bytes: inner})
+		require.NoError(t, err)
+		return raw
+	}
+	asRep, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassApplication, Tag: krbMsgTypeASRep, IsCompound: true, Bytes: []byte{0x30, 0x00}})
+	require.NoError(t, err)
+
+	handle := func(msg []byte) []byte {
+		var raw asn1.RawValue
+		_, err := asn1.Unmarshal(msg, &raw)
+		require.NoError(t, err)
+		require.Equal(t, krbMsgTypeASReq, raw.Tag)
+		var req krbKDCReq
+		_, err = asn1.Unmarshal(raw.Bytes, &req)
+		require.NoError(t, err)
+		var reqRealm string
+		_, err = asn1.Unmarshal(req.ReqBody.Realm.Bytes, &reqRealm)
+		require.NoError(t, err)
+		require.Equal(t, realm, reqRealm)
+		require.Len(t, req.ReqBody.CName.NameString, 1)
+
+		username := string(req.ReqBody.CName.NameString[0].Bytes)
+		user, ok := users[username]
+		if !ok {
+			return krbError(krbErrPrincipalUnknown, nil)
+		}
+		if user.code == krbErrClientRevoked {
+			return krbError(krbErrClientRevoked, nil)
+		}
+		if user.noPreAuth {
+			return asRep
+		}
+		salt := realm + username
+		if len(req.PAData) == 0 {
+			entries := []krbETypeInfo2Entry{{EType: user.etype}}
+			if user.etype != krbETypeRC4 {
+				entries[0].Salt = salt
+			}
+			info, err := asn1.Marshal(entries)
+			require.NoError(t, err)
+			edata, err := asn1.Marshal([]krbPAData{{Type: krbPATypeETypeInfo2, Value: info}})
+			require.NoError(t, err)
+			return krbError(krbErrPreAuthRequired, edata)
+		}
+
+		var enc krbEncryptedData
+		_, err = asn1.Unmarshal(req.PAData[0].Value, &enc)
+		require.NoError(t, err)
+		require.Equal(t, user.etype, enc.EType)
+		key, err := krbStringToKey(enc.EType, user.password, salt, nil)
+		require.NoError(t, err)
+		plain, ok := krbDecrypt(t, enc.EType, key, krbKeyUsageASReqTimestamp, enc.Cipher)
+		if !ok {
+			return krbError(krbErrPreAuthFailed, nil)
+		}
+		var ts krbPAEncTSEnc
+		_, err = asn1.Unmarshal(plain, &ts)
+		require.NoError(t, err)
+		if skew := time.Until(ts.PATimestamp) - time.Hour; skew > 5*time.Minute || skew < -5*time.Minute {
+			return krbError(krbErrClockSkew, nil)
+		}
+		if user.code != 0 {
+			return krbError(user.code, nil)
+		}
+		return asRep
+	}
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				header := make([]byte, 4)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				msg := make([]byte, binary.BigEndian.Uint32(header))
+				if _, err := io.ReadFull(conn, msg); err != nil {
+					return
+				}
+				rsp := handle(msg)
+				binary.BigEndian.PutUint32(header, uint32(len(rsp)))
+				conn.Write(append(header, rsp...))
+			}()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+func TestKerberosBrute(t *testing.T) {
+	addr := startTestKDC(t, "CORP.LOCAL", map[string]*testKrbUser{
+		"alice":  {password: "Winter2024!", etype: krbETypeAES256},
+		"bob":    {password: "hunter2", etype: krbETypeRC4},
+		"carol":  {password: "Spring2024", etype: krbETypeAES128, code: krbErrKeyExpired},
+		"locked": {code: krbErrClientRevoked},
+		"roast":  {noPreAuth: true},
+	})
+	ipTarget := "kerberos://" + addr + "/?realm=corp.local"
+
+	check := func(target, username, password string) *BruteItemResult {
+		return kerberosAuth.BrutePass(&BruteItem{Type: "kerberos", Target: target, Username: username, Password: password})
+	}
+
+	require.True(t, check(ipTarget, "alice", "Winter2024!").Ok)
+	require.False(t, check(ipTarget, "alice", "Winter2023!").Ok)
+	require.True(t, check(ipTarget, "CORP\\bob", "hunter2").Ok)
+	require.True(t, check(addr, "bob@corp.local", "hunter2").Ok)
+	require.False(t, check(addr, "bob@corp.local", "hunter3").Ok)
+
+	res := check(ipTarget, "carol", "Spring2024")
+	require.True(t, res.Ok)
+	require.Equal(t, "password expired", string(res.ExtraInfo))
+
+	for _, user := range []string{"nobody", "locked", "roast"} {
+		res = check(ipTarget, user, "123456")
+		require.False(t, res.Ok, user)
+		require.True(t, res.UserEliminated, user)
+	}
+	require.Contains(t, string(check(ipTarget, "roast", "123456").ExtraInfo), "AS-REP")
+
+	// 没有 realm 时无法爆破
+	res = check(addr, "alice", "Winter2024!")
+	require.False(t, res.Ok)
+	require.True(t, res.Finished)
+
+	realm, user := krbRealmAndUser(parseDomainTarget("dc01.corp.local", 88), "alice")
+	require.Equal(t, "CORP.LOCAL", realm)
+	require.Equal(t, "alice", user)
+}
@@ -0,0 +1,186 @@
+package bruteutils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-ldap/ldap"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+)
+
+// ldapStartTLSUnsupported 记录不支持 StartTLS 的目标，避免每次爆破都多一次握手
+var ldapStartTLSUnsupported = new(sync.Map)
+
+// ldapADErrors 是 AD 在 invalid credentials 的诊断信息中携带的子错误码
+// valid 为 true 表示密码本身是正确的，只是账户状态不允许登录
+var ldapADErrors = map[string]struct {
+	reason         string
+	valid          bool
+	userEliminated bool
+	lockout        bool
+}{
+	"525": {reason: "user not found", userEliminated: true},
+	"52e": {reason: "invalid credentials"},
+	"530": {reason: "not permitted to logon at this time", valid: true},
+	"531": {reason: "not permitted to logon at this workstation", valid: true},
+	"532": {reason: "password expired", valid: true},
+	"533": {reason: "account disabled", userEliminated: true},
+	"701": {reason: "account expired", userEliminated: true},
+	"773": {reason: "user must reset password", valid: true},
+	"775": {reason: "account locked out", userEliminated: true, lockout: true},
+}
+
+func isLDAPS(t *domainTarget) bool {
+	return t.Scheme == "ldaps" || t.Port == 636 || t.Port == 3269
+}
+
+// ldapBindName 按目标域名格式化绑定用户名：DN、UPN、NetBIOS 形式保持不变，裸用户名在已知域名时转换为 UPN
+func ldapBindName(username string, t *domainTarget) string {
+	if strings.Contains(username, "=") || strings.Contains(username, "\\") || strings.Contains(username, "@") {
+		return username
+	}
+	if t.Domain != "" {
+		return username + "@" + t.Domain
+	}
+	return username
+}
+
+func ldapDial(t *domainTarget) (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true, ServerName: t.Host}
+	if isLDAPS(t) {
+		conn, err := netx.DialTLSTimeout(defaultTimeout, t.Addr(), tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		l := ldap.NewConn(conn, true)
+		l.Start()
+		l.SetTimeout(defaultTimeout)
+		return l, nil
+	}
+
+	dial := func() (*ldap.Conn, error) {
+		conn, err := netx.DialTCPTimeout(defaultTimeout, t.Addr())
+		if err != nil {
+			return nil, err
+		}
+		l := ldap.NewConn(conn, false)
+		l.Start()
+		l.SetTimeout(defaultTimeout)
+		return l, nil
+	}
+
+	l, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	if _, unsupported := ldapStartTLSUnsupported.Load(t.Addr()); unsupported || t.Scheme == "ldap" {
+		return l, nil
+	}
+	if err := l.StartTLS(tlsConfig); err != nil {
+		log.Debugf("ldap %v starttls failed: %v, fallback to plaintext", t.Addr(), err)
+		ldapStartTLSUnsupported.Store(t.Addr(), struct{}{})
+		l.Close()
+		return dial()
+	}
+	return l, nil
+}
+
+var ldapAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "ldap",
+	DefaultPorts:     "389,636",
+	DefaultUsernames: []string{"administrator", "admin", "ldap", "manager", "test", "guest", "cn=admin", "cn=Manager", "cn=root"},
+	DefaultPasswords: append([]string{"P@ssw0rd", "Passw0rd", "Admin@123", "admin@123", "secret", "ldap"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 389)
+		l, err := ldapDial(t)
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		defer l.Close()
+
+		if err := l.UnauthenticatedBind(""); err != nil {
+			return res
+		}
+		rootDSE, err := l.Search(ldap.NewSearchRequest(
+			"", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, int(defaultTimeout.Seconds()), false,
+			"(objectClass=*)", []string{"namingContexts", "defaultNamingContext"}, nil,
+		))
+		if err != nil || len(rootDSE.Entries) <= 0 {
+			return res
+		}
+		baseDN := rootDSE.Entries[0].GetAttributeValue("defaultNamingContext")
+		if baseDN == "" {
+			baseDN = rootDSE.Entries[0].GetAttributeValue("namingContexts")
+		}
+		if baseDN == "" {
+			return res
+		}
+		// 匿名绑定大多数时候都会成功，只有能匿名读取目录数据时才算未授权访问
+		entries, err := l.Search(ldap.NewSearchRequest(
+			baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, int(defaultTimeout.Seconds()), false,
+			"(objectClass=*)", []string{"dn"}, nil,
+		))
+		// 超出 size limit 说明目录中有超过一条可读数据
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || (err == nil && len(entries.Entries) > 0) {
+			res.Ok = true
+			res.Username = ""
+			res.Password = ""
+			res.ExtraInfo = []byte(fmt.Sprintf("anonymous read on %v", baseDN))
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		if i.Password == "" {
+			// 空密码的简单绑定会被当作匿名绑定，不能作为爆破结果
+			return res
+		}
+		t := parseDomainTarget(i.Target, 389)
+		l, err := ldapDial(t)
+		if err != nil {
+			if _, ok := err.(net.Error); ok {
+				res.Finished = true
+			}
+			log.Debugf("ldap dial %v failed: %v", t.Addr(), err)
+			return res
+		}
+		defer l.Close()
+
+		err = l.Bind(ldapBindName(i.Username, t), i.Password)
+		if err == nil {
+			res.Ok = true
+			return res
+		}
+		ldapErr, ok := err.(*ldap.Error)
+		if !ok {
+			return res
+		}
+		switch ldapErr.ResultCode {
+		case ldap.LDAPResultInvalidCredentials:
+			diag := strings.ToLower(ldapErr.Err.Error())
+			for code, info := range ldapADErrors {
+				if !strings.Contains(diag, "data "+code) {
+					continue
+				}
+				res.Ok = info.valid
+				res.UserEliminated = info.userEliminated
+				res.Lockout = info.lockout
+				res.ExtraInfo = []byte(info.reason)
+				break
+			}
+		case ldap.LDAPResultConfidentialityRequired, ldap.LDAPResultStrongAuthRequired:
+			// 服务端要求加密或签名，无法继续使用简单绑定
+			res.Finished = true
+			res.ExtraInfo = []byte(ldapErr.Error())
+		case ldap.ErrorNetwork:
+			res.Finished = true
+		}
+		return res
+	},
+}
@@ -0,0 +1,109 @@
+package bruteutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/facades/ldap/ldapserver"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestLDAPServer 启动一个模拟 AD 的 LDAP 服务：支持简单绑定、匿名读取 rootDSE 和目录
+func startTestLDAPServer(t *testing.T) string {
+	adError := func(code string) string {
+		return fmt.Sprintf("80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data %v, v4563", code)
+	}
+
+	routes := ldapserver.NewRouteMux()
+	routes.Bind(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetBindRequest()
+		name, password := string(r.Name()), string(r.AuthenticationSimple())
+		res := ldapserver.NewBindResponse(ldapserver.LDAPResultInvalidCredentials)
+		switch {
+		case name == "" && password == "":
+			res = ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess)
+		case name == "administrator@corp.local" && password == "P@ssw0rd":
+			res = ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess)
+		case name == "cn=admin,dc=corp,dc=local" && password == "secret":
+			res = ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess)
+		case name == "expired@corp.local" && password == "Summer2023":
+			res.SetDiagnosticMessage(adError("532"))
+		case name == "locked@corp.local":
+			res.SetDiagnosticMessage(adError("775"))
+		case name == "administrator@corp.local" || name == "cn=admin,dc=corp,dc=local":
+			res.SetDiagnosticMessage(adError("52e"))
+		default:
+			res.SetDiagnosticMessage(adError("525"))
+		}
+		w.Write(res)
+	})
+	routes.Search(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetSearchRequest()
+		if string(r.BaseObject()) == "" {
+			e := ldapserver.NewSearchResultEntry("")
+			e.AddAttribute("namingContexts", "dc=corp,dc=local")
+			e.AddAttribute("defaultNamingContext", "dc=corp,dc=local")
+			w.Write(e)
+		} else {
+			w.Write(ldapserver.NewSearchResultEntry("dc=corp,dc=local"))
+			w.Write(ldapserver.NewSearchResultEntry("cn=Users,dc=corp,dc=local"))
+		}
+		w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultSuccess))
+	})
+	routes.Extended(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		w.Write(ldapserver.NewExtendedResponse(ldapserver.LDAPResultProtocolError))
+	})
+
+	server := ldapserver.NewServer()
+	server.Handle(routes)
+	port := utils.GetRandomAvailableTCPPort()
+	go server.ListenAndServe(utils.HostPort("127.0.0.1", port))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", port), 3))
+	t.Cleanup(server.Stop)
+	return utils.HostPort("127.0.0.1", port)
+}
+
+func TestLDAPBrute(t *testing.T) {
+	addr := startTestLDAPServer(t)
+	target := "ldap://" + addr + "/?domain=corp.local"
+
+	check := func(username, password string) *BruteItemResult {
+		return ldapAuth.BrutePass(&BruteItem{Type: "ldap", Target: target, Username: username, Password: password})
+	}
+
+	require.True(t, check("administrator", "P@ssw0rd").Ok)
+	require.True(t, check("cn=admin,dc=corp,dc=local", "secret").Ok)
+
+	res := check("administrator", "123456")
+	require.False(t, res.Ok)
+	require.False(t, res.UserEliminated)
+
+	res = check("nobody", "123456")
+	require.False(t, res.Ok)
+	require.True(t, res.UserEliminated)
+
+	res = check("locked", "123456")
+	require.False(t, res.Ok)
+	require.True(t, res.UserEliminated)
+
+	res = check("expired", "Summer2023")
+	require.True(t, res.Ok)
+	require.Equal(t, "password expired", string(res.ExtraInfo))
+
+	require.False(t, check("administrator", "").Ok, "empty password must not be treated as anonymous bind")
+
+	unauth := ldapAuth.UnAuthVerify(&BruteItem{Type: "ldap", Target: addr})
+	require.True(t, unauth.Ok)
+	require.Contains(t, string(unauth.ExtraInfo), "dc=corp,dc=local")
+}
+
+func TestLDAPBindName(t *testing.T) {
+	target := parseDomainTarget("dc01.corp.local:389", 389)
+	require.Equal(t, "corp.local", target.Domain)
+	require.Equal(t, "admin@corp.local", ldapBindName("admin", target))
+	require.Equal(t, "CORP\\admin", ldapBindName("CORP\\admin", target))
+	require.Equal(t, "admin@other.local", ldapBindName("admin@other.local", target))
+	require.Equal(t, "cn=admin,dc=corp,dc=local", ldapBindName("cn=admin,dc=corp,dc=local", target))
+	require.Equal(t, "admin", ldapBindName("admin", parseDomainTarget("10.0.0.1", 389)))
+}
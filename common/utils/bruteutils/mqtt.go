@@ -0,0 +1,179 @@
+package bruteutils
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	mqttVersion311 byte = 4
+	mqttVersion5   byte = 5
+)
+
+// mqttConnAckReasons 是 CONNACK 中的返回码，3.1.1 和 5.0 的取值范围不重叠
+var mqttConnAckReasons = map[byte]string{
+	0x01: "unacceptable protocol version",
+	0x02: "identifier rejected",
+	0x03: "server unavailable",
+	0x04: "bad username or password",
+	0x05: "not authorized",
+	0x84: "unsupported protocol version",
+	0x85: "client identifier not valid",
+	0x86: "bad username or password",
+	0x87: "not authorized",
+	0x88: "server unavailable",
+	0x8c: "bad authentication method",
+}
+
+func mqttAppendVarInt(buf []byte, n int) []byte {
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			return buf
+		}
+	}
+}
+
+func mqttAppendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// mqttConnectPacket 构造 CONNECT 报文，withAuth 为 false 时不携带用户名密码，用于检测匿名访问
+func mqttConnectPacket(version byte, clientID, username, password string, withAuth bool) []byte {
+	var body []byte
+	body = mqttAppendString(body, "MQTT")
+	body = append(body, version)
+	flags := byte(0x02) // clean session
+	if withAuth {
+		flags |= 0x80 | 0x40
+	}
+	body = append(body, flags, 0x00, 0x3c)
+	if version == mqttVersion5 {
+		body = append(body, 0x00) // properties
+	}
+	body = mqttAppendString(body, clientID)
+	if withAuth {
+		body = mqttAppendString(body, username)
+		body = mqttAppendString(body, password)
+	}
+	packet := mqttAppendVarInt([]byte{0x10}, len(body))
+	return append(packet, body...)
+}
+
+func mqttReadPacket(conn net.Conn) (byte, []byte, error) {
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i >= 4 {
+			return 0, nil, utils.Error("mqtt: malformed remaining length")
+		}
+		b := make([]byte, 1)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return 0, nil, err
+		}
+		length += int(b[0]&0x7f) * multiplier
+		multiplier *= 128
+		if b[0]&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+func mqttDial(t *domainTarget) (net.Conn, error) {
+	if t.Scheme == "mqtts" || t.Scheme == "ssl" || t.Port == 8883 {
+		return netx.DialTLSTimeout(defaultTimeout, t.Addr(), &tls.Config{InsecureSkipVerify: true, ServerName: t.Host})
+	}
+	return netx.DialTCPTimeout(defaultTimeout, t.Addr())
+}
+
+// mqttConnect 发送一次 CONNECT 并返回 CONNACK 中的返回码，dialFailed 表示目标无法连接
+func mqttConnect(t *domainTarget, version byte, username, password string, withAuth bool) (code byte, dialFailed bool, err error) {
+	conn, err := mqttDial(t)
+	if err != nil {
+		return 0, true, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	clientID := "yak" + utils.RandStringBytes(8)
+	if _, err := conn.Write(mqttConnectPacket(version, clientID, username, password, withAuth)); err != nil {
+		return 0, false, err
+	}
+	packetType, body, err := mqttReadPacket(conn)
+	if err != nil {
+		return 0, false, err
+	}
+	if packetType>>4 != 2 || len(body) < 2 {
+		return 0, false, utils.Errorf("mqtt: unexpected packet type 0x%x", packetType)
+	}
+	return body[1], false, nil
+}
+
+// mqttLogin 先使用 3.1.1 连接，服务端只接受 5.0 时再用 5.0 重试
+func mqttLogin(i *BruteItem, withAuth bool) *BruteItemResult {
+	res := i.Result()
+	t := parseDomainTarget(i.Target, 1883)
+	code, dialFailed, err := mqttConnect(t, mqttVersion311, i.Username, i.Password, withAuth)
+	if err == nil && code == 0x01 {
+		code, dialFailed, err = mqttConnect(t, mqttVersion5, i.Username, i.Password, withAuth)
+	}
+	if err != nil {
+		// 部分 broker 认证失败时直接断开连接而不返回 CONNACK，只有连接失败才结束爆破
+		log.Debugf("mqtt connect %v failed: %v", t.Addr(), err)
+		res.Finished = dialFailed
+		return res
+	}
+	switch code {
+	case 0x00:
+		res.Ok = true
+	case 0x01, 0x84:
+		res.Finished = true
+		res.ExtraInfo = []byte(mqttConnAckReasons[code])
+	default:
+		if reason, ok := mqttConnAckReasons[code]; ok {
+			log.Debugf("mqtt connect %v rejected: %v", t.Addr(), reason)
+		} else {
+			log.Debugf("mqtt connect %v rejected: code 0x%x", t.Addr(), code)
+		}
+	}
+	return res
+}
+
+var mqttAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "mqtt",
+	DefaultPorts:     "1883,8883",
+	DefaultUsernames: append([]string{"admin", "mqtt", "guest", "test", "user", "emqx"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"public", "admin", "password", "mqtt", "guest", "emqx"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := mqttLogin(i, false)
+		if res.Ok {
+			res.ExtraInfo = []byte(fmt.Sprintf("mqtt broker %v allows anonymous connect", i.Target))
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		return mqttLogin(i, true)
+	},
+}
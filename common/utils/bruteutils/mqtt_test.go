@@ -0,0 +1,86 @@
+package bruteutils
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestMQTTBroker 启动一个模拟的 MQTT broker，onlyV5 为 true 时拒绝 3.1.1 连接
+func startTestMQTTBroker(t *testing.T, onlyV5, anonymous bool, users map[string]string) string {
+	readString := func(b []byte) (string, []byte) {
+		n := int(binary.BigEndian.Uint16(b))
+		return string(b[2 : 2+n]), b[2+n:]
+	}
+	host, port := utils.DebugMockTCPEx(func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		packetType, body, err := mqttReadPacket(conn)
+		if err != nil || packetType != 0x10 {
+			return
+		}
+		name, body := readString(body)
+		require.Equal(t, "MQTT", name)
+		version, flags := body[0], body[1]
+		body = body[4:]
+		if version == mqttVersion5 {
+			body = body[1:]
+		}
+		connAck := func(code byte) {
+			if version == mqttVersion5 {
+				conn.Write([]byte{0x20, 0x03, 0x00, code, 0x00})
+			} else {
+				conn.Write([]byte{0x20, 0x02, 0x00, code})
+			}
+		}
+		if onlyV5 && version != mqttVersion5 {
+			connAck(0x01)
+			return
+		}
+		clientID, body := readString(body)
+		require.NotEmpty(t, clientID)
+		if flags&0x80 == 0 {
+			if anonymous {
+				connAck(0x00)
+			} else if version == mqttVersion5 {
+				connAck(0x87)
+			} else {
+				connAck(0x05)
+			}
+			return
+		}
+		username, body := readString(body)
+		password, _ := readString(body)
+		if expected, ok := users[username]; ok && expected == password {
+			connAck(0x00)
+		} else if version == mqttVersion5 {
+			connAck(0x86)
+		} else {
+			connAck(0x04)
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestMQTTBrute(t *testing.T) {
+	for _, onlyV5 := range []bool{false, true} {
+		addr := startTestMQTTBroker(t, onlyV5, false, map[string]string{"admin": "public"})
+		unauth := mqttAuth.UnAuthVerify(&BruteItem{Type: "mqtt", Target: addr})
+		require.False(t, unauth.Ok)
+		require.False(t, unauth.Finished)
+
+		require.True(t, mqttAuth.BrutePass(&BruteItem{Type: "mqtt", Target: addr, Username: "admin", Password: "public"}).Ok)
+		res := mqttAuth.BrutePass(&BruteItem{Type: "mqtt", Target: addr, Username: "admin", Password: "123456"})
+		require.False(t, res.Ok)
+		require.False(t, res.Finished)
+	}
+
+	addr := startTestMQTTBroker(t, false, true, nil)
+	res := mqttAuth.GetBruteHandler()(&BruteItem{Type: "mqtt", Target: addr, Username: "admin", Password: "123456"})
+	require.True(t, res.Ok)
+	require.Empty(t, res.Username)
+	require.Contains(t, string(res.ExtraInfo), "anonymous")
+}
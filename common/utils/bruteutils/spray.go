@@ -0,0 +1,269 @@
+package bruteutils
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// LockoutPolicy 描述目标服务的账户锁定策略
+// 爆破时每个用户在 Window 内的尝试次数不会超过 Threshold，Threshold 应当小于目标实际的锁定阈值
+type LockoutPolicy struct {
+	Threshold int
+	Window    time.Duration
+	// LockoutPause 出现账户锁定迹象后整个目标暂停的时间，为 0 时使用 Window
+	LockoutPause time.Duration
+}
+
+func (p *LockoutPolicy) pause() time.Duration {
+	if p.LockoutPause > 0 {
+		return p.LockoutPause
+	}
+	return p.Window
+}
+
+// lockoutMemory 是出现锁定迹象的用户不再尝试的时间
+func (p *LockoutPolicy) lockoutMemory() time.Duration {
+	if p.pause() > p.Window {
+		return p.pause()
+	}
+	return p.Window
+}
+
+// DefaultLockoutPolicy 是没有为服务单独设置策略时使用的默认策略
+var DefaultLockoutPolicy = &LockoutPolicy{Threshold: 3, Window: 30 * time.Minute}
+
+// 域账户默认的锁定阈值通常是 5 次 / 30 分钟，保守起见每个窗口只尝试 3 次
+var defaultLockoutPolicies = map[string]*LockoutPolicy{
+	"ldap":     {Threshold: 3, Window: 30 * time.Minute},
+	"kerberos": {Threshold: 3, Window: 30 * time.Minute},
+	"winrm":    {Threshold: 3, Window: 30 * time.Minute},
+	"smb":      {Threshold: 3, Window: 30 * time.Minute},
+	"rdp":      {Threshold: 3, Window: 30 * time.Minute},
+	"mssql":    {Threshold: 3, Window: 30 * time.Minute},
+	"oracle":   {Threshold: 5, Window: 60 * time.Minute},
+	"imap":     {Threshold: 5, Window: 15 * time.Minute},
+	"pop3":     {Threshold: 5, Window: 15 * time.Minute},
+	"smtp":     {Threshold: 5, Window: 15 * time.Minute},
+}
+
+// GetLockoutPolicy 返回爆破类型对应的锁定策略副本
+func GetLockoutPolicy(bruteType string) *LockoutPolicy {
+	p, ok := defaultLockoutPolicies[strings.ToLower(strings.TrimSpace(bruteType))]
+	if !ok {
+		p = DefaultLockoutPolicy
+	}
+	policy := *p
+	return &policy
+}
+
+var (
+	// lockoutIndicator 匹配爆破结果中常见的账户锁定 / 登录频率限制信息
+	lockoutIndicator = regexp.MustCompile(`(?i)(account|user)\s+(is\s+|has\s+been\s+)?(locked|blocked)|locked\s+out|too\s+many\s+(failed\s+|invalid\s+|bad\s+)?(login|logon|authentication|auth|password)\s*(attempts|failures|errors)|temporarily\s+(locked|blocked|disabled)|blocked\s+because\s+of\s+many\s+connection\s+errors|STATUS_ACCOUNT_LOCKED_OUT`)
+	// 这些服务的模块已经根据协议错误码设置了 Lockout，不再匹配文本
+	lockoutStructuredServices = map[string]bool{"ldap": true, "kerberos": true}
+)
+
+// IsLockoutResult 判断爆破结果是否出现了账户锁定的迹象
+func IsLockoutResult(r *BruteItemResult) bool {
+	if r == nil || r.Ok {
+		return false
+	}
+	if r.Lockout {
+		return true
+	}
+	if lockoutStructuredServices[r.Type] {
+		return false
+	}
+	return lockoutIndicator.Match(r.ExtraInfo)
+}
+
+// AttemptLedger 记录每个目标上每个用户的尝试时间，用于在多次运行之间遵守锁定策略
+// 可以通过 json 序列化保存，OnSave 在每次记录变化之后调用
+type AttemptLedger struct {
+	mu sync.Mutex
+
+	// Attempts 的 key 为 target|username，值为尝试的 unix 时间戳（毫秒）
+	Attempts map[string][]int64 `json:"attempts"`
+	// Lockouts 记录出现锁定迹象的用户和时间
+	Lockouts map[string]int64 `json:"lockouts"`
+	// PausedUntil 记录目标暂停到什么时候
+	PausedUntil map[string]int64 `json:"paused_until"`
+
+	OnSave func(l *AttemptLedger) `json:"-"`
+
+	// lastPrune 是上一次清理过期记录的时间
+	lastPrune time.Time
+}
+
+// maxLedgerPruneInterval 是清理过期记录的最大间隔，窗口更短时按窗口清理
+const maxLedgerPruneInterval = time.Minute
+
+func NewAttemptLedger() *AttemptLedger {
+	return &AttemptLedger{
+		Attempts:    make(map[string][]int64),
+		Lockouts:    make(map[string]int64),
+		PausedUntil: make(map[string]int64),
+	}
+}
+
+// ParseAttemptLedger 从 json 中恢复尝试记录
+func ParseAttemptLedger(raw []byte) (*AttemptLedger, error) {
+	l := NewAttemptLedger()
+	if err := json.Unmarshal(raw, l); err != nil {
+		return nil, utils.Errorf("unmarshal attempt ledger failed: %v", err)
+	}
+	if l.Attempts == nil {
+		l.Attempts = make(map[string][]int64)
+	}
+	if l.Lockouts == nil {
+		l.Lockouts = make(map[string]int64)
+	}
+	if l.PausedUntil == nil {
+		l.PausedUntil = make(map[string]int64)
+	}
+	return l, nil
+}
+
+func ledgerKey(target, username string) string {
+	return target + "|" + username
+}
+
+// Bytes 返回尝试记录的 json
+func (l *AttemptLedger) Bytes() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return json.Marshal(l)
+}
+
+func (l *AttemptLedger) save() {
+	if l.OnSave != nil {
+		l.OnSave(l)
+	}
+}
+
+// Count 返回 window 内用户在目标上的尝试次数
+func (l *AttemptLedger) Count(target, username string, window time.Duration, now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	since := now.Add(-window).UnixMilli()
+	count := 0
+	for _, ts := range l.Attempts[ledgerKey(target, username)] {
+		if ts > since {
+			count++
+		}
+	}
+	return count
+}
+
+// Acquire 在策略允许时记录一次尝试并返回 0，否则返回需要等待的时间
+func (l *AttemptLedger) Acquire(target, username string, policy *LockoutPolicy, now time.Time) time.Duration {
+	wait := func() time.Duration {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.pruneLocked(policy, now)
+		if until := l.PausedUntil[target]; until > now.UnixMilli() {
+			return time.UnixMilli(until).Sub(now)
+		}
+
+		key := ledgerKey(target, username)
+		since := now.Add(-policy.Window).UnixMilli()
+		var attempts []int64
+		for _, ts := range l.Attempts[key] {
+			if ts > since {
+				attempts = append(attempts, ts)
+			}
+		}
+		if policy.Threshold > 0 && len(attempts) >= policy.Threshold {
+			sort.Slice(attempts, func(i, j int) bool { return attempts[i] < attempts[j] })
+			l.Attempts[key] = attempts
+			// 等到窗口内最早的一次尝试过期，记录只精确到毫秒，多等一毫秒
+			return time.UnixMilli(attempts[len(attempts)-policy.Threshold]).Add(policy.Window + time.Millisecond).Sub(now)
+		}
+		l.Attempts[key] = append(attempts, now.UnixMilli())
+		return 0
+	}()
+	if wait <= 0 {
+		l.save()
+	}
+	return wait
+}
+
+// pruneLocked 删除策略窗口之外的尝试、已经过期的锁定记录和暂停，避免记录随着用户和目标增多无限增长
+func (l *AttemptLedger) pruneLocked(policy *LockoutPolicy, now time.Time) {
+	interval := policy.Window
+	if interval <= 0 || interval > maxLedgerPruneInterval {
+		interval = maxLedgerPruneInterval
+	}
+	if now.Sub(l.lastPrune) < interval {
+		return
+	}
+	l.lastPrune = now
+
+	since := now.Add(-policy.Window).UnixMilli()
+	for key, attempts := range l.Attempts {
+		var kept []int64
+		for _, ts := range attempts {
+			if ts > since {
+				kept = append(kept, ts)
+			}
+		}
+		if len(kept) > 0 {
+			l.Attempts[key] = kept
+		} else {
+			delete(l.Attempts, key)
+		}
+	}
+	lockoutSince := now.Add(-policy.lockoutMemory()).UnixMilli()
+	for key, ts := range l.Lockouts {
+		if ts <= lockoutSince {
+			delete(l.Lockouts, key)
+		}
+	}
+	for target, until := range l.PausedUntil {
+		if until <= now.UnixMilli() {
+			delete(l.PausedUntil, target)
+		}
+	}
+}
+
+// MarkLockout 记录用户出现锁定迹象，并把整个目标暂停 pause
+func (l *AttemptLedger) MarkLockout(target, username string, pause time.Duration, now time.Time) {
+	l.mu.Lock()
+	l.Lockouts[ledgerKey(target, username)] = now.UnixMilli()
+	if until := now.Add(pause + time.Millisecond).UnixMilli(); until > l.PausedUntil[target] {
+		l.PausedUntil[target] = until
+	}
+	l.mu.Unlock()
+	l.save()
+}
+
+// IsLockedOut 判断用户在 window 内是否出现过锁定迹象
+func (l *AttemptLedger) IsLockedOut(target, username string, window time.Duration, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ts, ok := l.Lockouts[ledgerKey(target, username)]
+	return ok && ts > now.Add(-window).UnixMilli()
+}
+
+// sprayOrder 把爆破任务按密码重新排列：先用同一个密码尝试所有用户，再换下一个密码
+func sprayOrder(items []*BruteItem) []*BruteItem {
+	passwordIndex := make(map[string]int)
+	for _, item := range items {
+		if _, ok := passwordIndex[item.Password]; !ok {
+			passwordIndex[item.Password] = len(passwordIndex)
+		}
+	}
+	ordered := make([]*BruteItem, len(items))
+	copy(ordered, items)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return passwordIndex[ordered[i].Password] < passwordIndex[ordered[j].Password]
+	})
+	return ordered
+}
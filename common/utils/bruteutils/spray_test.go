@@ -0,0 +1,151 @@
+package bruteutils
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sprayRecorder struct {
+	mu       sync.Mutex
+	attempts []string
+	times    map[string]time.Time
+}
+
+func (r *sprayRecorder) record(item *BruteItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := item.Username + ":" + item.Password
+	r.attempts = append(r.attempts, key)
+	if r.times == nil {
+		r.times = make(map[string]time.Time)
+	}
+	r.times[key] = time.Now()
+}
+
+func runSprayBrute(t *testing.T, items []*BruteItem, callback BruteCallback, opts ...OptionsAction) {
+	opts = append([]OptionsAction{WithBruteCallback(callback)}, opts...)
+	bu, err := NewMultiTargetBruteUtilEx(opts...)
+	require.NoError(t, err)
+	for _, item := range items {
+		bu.Feed(item)
+	}
+	require.NoError(t, bu.Run())
+}
+
+func sprayItems(users, passwords []string) []*BruteItem {
+	var items []*BruteItem
+	for _, user := range users {
+		for _, pass := range passwords {
+			items = append(items, &BruteItem{Type: "smb", Target: "127.0.0.1:445", Username: user, Password: pass})
+		}
+	}
+	return items
+}
+
+func TestPasswordSpray(t *testing.T) {
+	recorder := &sprayRecorder{}
+	policy := &LockoutPolicy{Threshold: 2, Window: 600 * time.Millisecond}
+	runSprayBrute(t, sprayItems([]string{"alice", "bob"}, []string{"p1", "p2", "p3"}), func(item *BruteItem) *BruteItemResult {
+		recorder.record(item)
+		return item.Result()
+	}, WithPasswordSpray(policy))
+
+	require.Equal(t, []string{"alice:p1", "bob:p1", "alice:p2", "bob:p2", "alice:p3", "bob:p3"}, recorder.attempts)
+	require.GreaterOrEqual(t, recorder.times["alice:p3"].Sub(recorder.times["alice:p1"]), policy.Window)
+	require.Less(t, recorder.times["bob:p2"].Sub(recorder.times["alice:p1"]), policy.Window)
+}
+
+func TestPasswordSprayLockoutPause(t *testing.T) {
+	recorder := &sprayRecorder{}
+	policy := &LockoutPolicy{Threshold: 10, Window: time.Second, LockoutPause: 400 * time.Millisecond}
+	runSprayBrute(t, sprayItems([]string{"locked", "carol"}, []string{"q1", "q2"}), func(item *BruteItem) *BruteItemResult {
+		recorder.record(item)
+		res := item.Result()
+		if item.Username == "locked" {
+			res.ExtraInfo = []byte("Account is locked out")
+		}
+		return res
+	}, WithPasswordSpray(policy))
+
+	require.Equal(t, []string{"locked:q1", "carol:q1", "carol:q2"}, recorder.attempts)
+	require.GreaterOrEqual(t, recorder.times["carol:q1"].Sub(recorder.times["locked:q1"]), policy.LockoutPause)
+}
+
+func TestPasswordSprayKerberosClientRevoked(t *testing.T) {
+	recorder := &sprayRecorder{}
+	ledger := NewAttemptLedger()
+	policy := &LockoutPolicy{Threshold: 10, Window: time.Second, LockoutPause: 400 * time.Millisecond}
+	var items []*BruteItem
+	for _, user := range []string{"revoked", "carol"} {
+		items = append(items, &BruteItem{Type: "kerberos", Target: "127.0.0.1:88", Username: user, Password: "q1"})
+	}
+	runSprayBrute(t, items, func(item *BruteItem) *BruteItemResult {
+		recorder.record(item)
+		res := item.Result()
+		if item.Username == "revoked" {
+			setKerberosClientRevoked(res)
+		}
+		return res
+	}, WithPasswordSpray(policy), WithAttemptLedger(ledger))
+
+	require.Equal(t, []string{"revoked:q1", "carol:q1"}, recorder.attempts)
+	require.Contains(t, ledger.Lockouts, ledgerKey("127.0.0.1:88", "revoked"))
+	require.GreaterOrEqual(t, recorder.times["carol:q1"].Sub(recorder.times["revoked:q1"]), policy.LockoutPause)
+}
+
+func TestAttemptLedgerResume(t *testing.T) {
+	var saved []byte
+	ledger := NewAttemptLedger()
+	ledger.OnSave = func(l *AttemptLedger) {
+		raw, err := l.Bytes()
+		require.NoError(t, err)
+		saved = raw
+	}
+	policy := &LockoutPolicy{Threshold: 2, Window: 800 * time.Millisecond}
+	callback := func(item *BruteItem) *BruteItemResult { return item.Result() }
+
+	start := time.Now()
+	runSprayBrute(t, sprayItems([]string{"alice"}, []string{"p1", "p2"}), callback, WithLockoutPolicy(policy), WithAttemptLedger(ledger))
+	require.Less(t, time.Since(start), policy.Window)
+
+	// 重新启动之后从保存的记录继续，需要等到第一次尝试过期
+	restored, err := ParseAttemptLedger(saved)
+	require.NoError(t, err)
+	require.Equal(t, 2, restored.Count("127.0.0.1:445", "alice", policy.Window, time.Now()))
+	runSprayBrute(t, sprayItems([]string{"alice"}, []string{"p3"}), callback, WithLockoutPolicy(policy), WithAttemptLedger(restored))
+	require.GreaterOrEqual(t, time.Since(start), policy.Window)
+}
+
+func TestAttemptLedgerPrune(t *testing.T) {
+	policy := &LockoutPolicy{Threshold: 3, Window: time.Minute}
+	now := time.Now()
+	ledger := NewAttemptLedger()
+	require.Zero(t, ledger.Acquire("10.0.0.1:445", "alice", policy, now))
+	ledger.MarkLockout("10.0.0.1:445", "bob", policy.pause(), now)
+
+	// 超过窗口之后其他用户的尝试会清理掉之前的记录
+	later := now.Add(policy.Window + time.Second)
+	require.Zero(t, ledger.Acquire("10.0.0.2:445", "carol", policy, later))
+	require.Len(t, ledger.Attempts, 1)
+	require.Contains(t, ledger.Attempts, ledgerKey("10.0.0.2:445", "carol"))
+	require.Empty(t, ledger.Lockouts)
+	require.Empty(t, ledger.PausedUntil)
+}
+
+func TestIsLockoutResult(t *testing.T) {
+	for _, msg := range []string{
+		"Account is locked out",
+		"user has been locked",
+		"421 Too many login failures",
+		"Host '10.0.0.1' is blocked because of many connection errors",
+		"STATUS_ACCOUNT_LOCKED_OUT",
+	} {
+		require.True(t, IsLockoutResult(&BruteItemResult{Type: "ftp", ExtraInfo: []byte(msg)}), msg)
+	}
+	require.False(t, IsLockoutResult(&BruteItemResult{Type: "ftp", ExtraInfo: []byte("530 Login incorrect")}))
+	require.False(t, IsLockoutResult(&BruteItemResult{Type: "kerberos", ExtraInfo: []byte("account disabled or locked out")}))
+	require.True(t, IsLockoutResult(&BruteItemResult{Type: "ldap", Lockout: true}))
+}
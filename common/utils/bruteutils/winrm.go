@@ -0,0 +1,106 @@
+package bruteutils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+// winrmNTLMTargets 缓存目标是否是要求 NTLM / Negotiate 认证的 WinRM 服务
+var winrmNTLMTargets = new(sync.Map)
+
+func winrmRequest(t *domainTarget) []byte {
+	path := t.Path
+	if path == "" {
+		path = "/wsman"
+	}
+	return []byte(fmt.Sprintf("POST %v HTTP/1.1\r\n"+
+		"Host: %v\r\n"+
+		"Content-Type: application/soap+xml;charset=UTF-8\r\n"+
+		"User-Agent: Microsoft WinRM Client\r\n"+
+		"Content-Length: 0\r\n\r\n", path, t.Addr()))
+}
+
+func winrmDo(t *domainTarget, opts ...lowhttp.LowhttpOpt) (int, []byte, error) {
+	opts = append([]lowhttp.LowhttpOpt{
+		lowhttp.WithHttps(t.Scheme == "https" || t.Port == 5986),
+		lowhttp.WithRequest(winrmRequest(t)),
+		lowhttp.WithTimeout(defaultTimeout),
+	}, opts...)
+	rsp, err := lowhttp.HTTPWithoutRedirect(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	return lowhttp.GetStatusCodeFromResponse(rsp.RawPacket), rsp.RawPacket, nil
+}
+
+// winrmSupportsNTLM 检查未认证的请求是否返回 401 并提供 NTLM / Negotiate 认证
+func winrmSupportsNTLM(t *domainTarget) (bool, error) {
+	if supported, ok := winrmNTLMTargets.Load(t.Addr()); ok {
+		return supported.(bool), nil
+	}
+	status, rsp, err := winrmDo(t)
+	if err != nil {
+		return false, err
+	}
+	supported := false
+	if status == 401 {
+		for k, values := range lowhttp.GetHTTPPacketHeadersFull(rsp) {
+			if !strings.EqualFold(k, "WWW-Authenticate") {
+				continue
+			}
+			for _, v := range values {
+				scheme := strings.ToLower(strings.SplitN(strings.TrimSpace(v), " ", 2)[0])
+				if scheme == "ntlm" || scheme == "negotiate" {
+					supported = true
+				}
+			}
+		}
+	}
+	winrmNTLMTargets.Store(t.Addr(), supported)
+	return supported, nil
+}
+
+var winrmAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "winrm",
+	DefaultPorts:     "5985,5986",
+	DefaultUsernames: []string{"administrator", "admin", "test", "user", "guest"},
+	DefaultPasswords: append([]string{"P@ssw0rd", "Passw0rd", "Password1", "Admin@123", "Welcome1"}, CommonPasswords...),
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		t := parseDomainTarget(i.Target, 5985)
+		supported, err := winrmSupportsNTLM(t)
+		if err != nil {
+			if _, ok := err.(net.Error); ok || strings.Contains(err.Error(), "connect") {
+				res.Finished = true
+			}
+			log.Debugf("winrm check %v failed: %v", t.Addr(), err)
+			return res
+		}
+		if !supported {
+			res.Finished = true
+			res.ExtraInfo = []byte("target does not require ntlm/negotiate authentication")
+			return res
+		}
+
+		username := i.Username
+		if domain, _ := splitDomainUser(username); domain == "" && t.ExplicitDomain {
+			username = t.Domain + "\\" + username
+		}
+		status, _, err := winrmDo(t, lowhttp.WithUsername(username), lowhttp.WithPassword(i.Password))
+		if err != nil {
+			log.Debugf("winrm auth %v failed: %v", t.Addr(), err)
+			return res
+		}
+		// 认证通过后空的 SOAP 请求会得到 400/415/500 等错误，只有 401 表示认证失败
+		if status > 0 && status != 401 {
+			res.Ok = true
+			res.ExtraInfo = []byte(fmt.Sprintf("status code: %v", status))
+		}
+		return res
+	},
+}
@@ -0,0 +1,95 @@
+package bruteutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bruteutils/grdp/protocol/nla"
+)
+
+func ntlmField(msg []byte, offset int) []byte {
+	length := int(binary.LittleEndian.Uint16(msg[offset:]))
+	start := int(binary.LittleEndian.Uint32(msg[offset+4:]))
+	return msg[start : start+length]
+}
+
+func ntlmUnicode(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// startTestWinRMServer 启动一个只支持 Negotiate(NTLM) 认证的 WinRM 服务，校验 NTLMv2 响应
+func startTestWinRMServer(t *testing.T, users map[string]string) string {
+	serverChallenge := []byte("\x01\x02\x03\x04\x05\x06\x07\x08")
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Microsoft-HTTPAPI/2.0")
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		msg, err := base64.StdEncoding.DecodeString(token)
+		if !strings.EqualFold(scheme, "Negotiate") || err != nil || len(msg) < 12 {
+			w.Header().Add("WWW-Authenticate", "Negotiate")
+			w.Header().Add("WWW-Authenticate", "Kerberos")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch binary.LittleEndian.Uint32(msg[8:12]) {
+		case 1:
+			challenge := nla.NewChallengeMessage()
+			challenge.NegotiateFlags = nla.NTLMSSP_NEGOTIATE_UNICODE | nla.NTLMSSP_NEGOTIATE_NTLM |
+				nla.NTLMSSP_NEGOTIATE_EXTENDED_SESSIONSECURITY | nla.NTLMSSP_NEGOTIATE_128
+			challenge.TargetNameBufferOffset = challenge.BaseLen()
+			challenge.TargetInfoBufferOffset = challenge.BaseLen()
+			copy(challenge.ServerChallenge[:], serverChallenge)
+			w.Header().Set("WWW-Authenticate", "Negotiate "+base64.StdEncoding.EncodeToString(challenge.Serialize()))
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			nt := ntlmField(msg, 20)
+			domain := ntlmUnicode(ntlmField(msg, 28))
+			user := ntlmUnicode(ntlmField(msg, 36))
+			password, ok := users[strings.ToLower(user)]
+			if ok && len(nt) > 16 {
+				expected := nla.HMAC_MD5(nla.NTOWFv2(password, user, domain), append(append([]byte{}, serverChallenge...), nt[16:]...))
+				if bytes.Equal(expected, nt[:16]) {
+					// 空的 SOAP 请求会得到 500 错误
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestWinRMBrute(t *testing.T) {
+	addr := startTestWinRMServer(t, map[string]string{"administrator": "P@ssw0rd"})
+
+	check := func(target, username, password string) *BruteItemResult {
+		return winrmAuth.BrutePass(&BruteItem{Type: "winrm", Target: target, Username: username, Password: password})
+	}
+	require.True(t, check(addr, "administrator", "P@ssw0rd").Ok)
+	require.True(t, check(addr, "CORP\\administrator", "P@ssw0rd").Ok)
+	require.True(t, check("http://"+addr+"/wsman?domain=CORP", "administrator", "P@ssw0rd").Ok)
+	require.False(t, check(addr, "administrator", "123456").Ok)
+	require.False(t, check(addr, "guest", "P@ssw0rd").Ok)
+
+	// 不需要认证的 HTTP 服务不能被当成 WinRM
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	res := check(utils.HostPort(host, port), "administrator", "P@ssw0rd")
+	require.False(t, res.Ok)
+	require.True(t, res.Finished)
+}
@@ -0,0 +1,240 @@
+package bruteutils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	zkOpGetACL      int32 = 6
+	zkOpGetChildren int32 = 8
+
+	zkErrNoAuth int32 = -102
+
+	zkPermAll int32 = 0x1f
+)
+
+// zkConn 是一个最小化的 ZooKeeper jute 协议客户端，只实现未授权检测需要的请求
+type zkConn struct {
+	conn net.Conn
+	xid  int32
+}
+
+func zkAppendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+	return append(buf, s...)
+}
+
+func zkReadString(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	n := int32(binary.BigEndian.Uint32(b))
+	b = b[4:]
+	if n < 0 {
+		return "", b, nil
+	}
+	if int(n) > len(b) {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func (z *zkConn) writePacket(body []byte) error {
+	_, err := z.conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(body))), body...))
+	return err
+}
+
+func (z *zkConn) readPacket() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(z.conn, header); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header)
+	if n > 1<<20 {
+		return nil, utils.Errorf("zookeeper: packet too large: %v", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(z.conn, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func zkDial(addr string) (*zkConn, error) {
+	conn, err := netx.DialTCPTimeout(defaultTimeout, addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+	z := &zkConn{conn: conn}
+
+	// ConnectRequest: protocolVersion, lastZxidSeen, timeOut, sessionId, passwd
+	req := binary.BigEndian.AppendUint32(nil, 0)
+	req = binary.BigEndian.AppendUint64(req, 0)
+	req = binary.BigEndian.AppendUint32(req, 30000)
+	req = binary.BigEndian.AppendUint64(req, 0)
+	req = binary.BigEndian.AppendUint32(req, 16)
+	req = append(req, make([]byte, 16)...)
+	if err := z.writePacket(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	rsp, err := z.readPacket()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if len(rsp) < 16 || binary.BigEndian.Uint32(rsp[4:8]) == 0 {
+		conn.Close()
+		return nil, utils.Error("zookeeper: session rejected")
+	}
+	return z, nil
+}
+
+func (z *zkConn) Close() error {
+	return z.conn.Close()
+}
+
+// call 发送请求并返回应答中的错误码和响应体
+func (z *zkConn) call(op int32, body []byte) (int32, []byte, error) {
+	z.xid++
+	req := binary.BigEndian.AppendUint32(nil, uint32(z.xid))
+	req = binary.BigEndian.AppendUint32(req, uint32(op))
+	if err := z.writePacket(append(req, body...)); err != nil {
+		return 0, nil, err
+	}
+	rsp, err := z.readPacket()
+	if err != nil {
+		return 0, nil, err
+	}
+	// ReplyHeader: xid, zxid, err
+	if len(rsp) < 16 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return int32(binary.BigEndian.Uint32(rsp[12:16])), rsp[16:], nil
+}
+
+func (z *zkConn) GetChildren(path string) ([]string, int32, error) {
+	code, rsp, err := z.call(zkOpGetChildren, append(zkAppendString(nil, path), 0))
+	if err != nil || code != 0 {
+		return nil, code, err
+	}
+	if len(rsp) < 4 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	count := int32(binary.BigEndian.Uint32(rsp))
+	rsp = rsp[4:]
+	var children []string
+	for i := int32(0); i < count; i++ {
+		var child string
+		child, rsp, err = zkReadString(rsp)
+		if err != nil {
+			return children, 0, err
+		}
+		children = append(children, child)
+	}
+	return children, 0, nil
+}
+
+type zkACL struct {
+	Perms  int32
+	Scheme string
+	ID     string
+}
+
+func (a zkACL) String() string {
+	var perms []string
+	for i, name := range []string{"r", "w", "c", "d", "a"} {
+		if a.Perms&(1<<i) != 0 {
+			perms = append(perms, name)
+		}
+	}
+	return fmt.Sprintf("%v:%v:%v", a.Scheme, a.ID, strings.Join(perms, ""))
+}
+
+func (z *zkConn) GetACL(path string) ([]zkACL, int32, error) {
+	code, rsp, err := z.call(zkOpGetACL, zkAppendString(nil, path))
+	if err != nil || code != 0 {
+		return nil, code, err
+	}
+	if len(rsp) < 4 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	count := int32(binary.BigEndian.Uint32(rsp))
+	rsp = rsp[4:]
+	var acls []zkACL
+	for i := int32(0); i < count; i++ {
+		if len(rsp) < 4 {
+			return acls, 0, io.ErrUnexpectedEOF
+		}
+		acl := zkACL{Perms: int32(binary.BigEndian.Uint32(rsp))}
+		if acl.Scheme, rsp, err = zkReadString(rsp[4:]); err != nil {
+			return acls, 0, err
+		}
+		if acl.ID, rsp, err = zkReadString(rsp); err != nil {
+			return acls, 0, err
+		}
+		acls = append(acls, acl)
+	}
+	return acls, 0, nil
+}
+
+var zookeeperAuth = &DefaultServiceAuthInfo{
+	ServiceName:  "zookeeper",
+	DefaultPorts: "2181",
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		res.Finished = true
+		addr := appendDefaultPort(i.Target, 2181)
+		z, err := zkDial(addr)
+		if err != nil {
+			log.Debugf("zookeeper connect %v failed: %v", addr, err)
+			return res
+		}
+		defer z.Close()
+
+		children, code, err := z.GetChildren("/")
+		if err != nil {
+			log.Debugf("zookeeper get children %v failed: %v", addr, err)
+			return res
+		}
+		if code == zkErrNoAuth {
+			res.ExtraInfo = []byte("zookeeper root node requires auth")
+			return res
+		}
+		if code != 0 {
+			log.Debugf("zookeeper get children %v failed: code %v", addr, code)
+			return res
+		}
+
+		res.Ok = true
+		info := fmt.Sprintf("anonymous access to /, children: [%v]", strings.Join(children, ", "))
+		if acls, code, err := z.GetACL("/"); err == nil && code == 0 {
+			for _, acl := range acls {
+				if acl.Scheme == "world" && acl.ID == "anyone" {
+					info += ", acl: " + acl.String()
+					if acl.Perms&zkPermAll == zkPermAll {
+						info += " (world:anyone has all permissions)"
+					}
+				}
+			}
+		}
+		res.ExtraInfo = []byte(info)
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		// ZooKeeper 的 digest 认证在 addauth 时不会校验密码，只检测未授权访问
+		res := i.Result()
+		res.Finished = true
+		return res
+	},
+}
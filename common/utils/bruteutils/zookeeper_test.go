@@ -0,0 +1,78 @@
+package bruteutils
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// startTestZooKeeper 启动一个模拟的 ZooKeeper，只实现 getChildren 和 getACL
+func startTestZooKeeper(t *testing.T, rootACL []zkACL) string {
+	host, port := utils.DebugMockTCPEx(func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		z := &zkConn{conn: conn}
+		if _, err := z.readPacket(); err != nil {
+			return
+		}
+		rsp := binary.BigEndian.AppendUint32(nil, 0)
+		rsp = binary.BigEndian.AppendUint32(rsp, 30000)
+		rsp = binary.BigEndian.AppendUint64(rsp, 0x1234)
+		rsp = binary.BigEndian.AppendUint32(rsp, 16)
+		z.writePacket(append(rsp, make([]byte, 16)...))
+
+		worldReadable := false
+		for _, acl := range rootACL {
+			if acl.Scheme == "world" && acl.Perms&1 != 0 {
+				worldReadable = true
+			}
+		}
+		for {
+			req, err := z.readPacket()
+			if err != nil {
+				return
+			}
+			reply := func(code int32, body []byte) {
+				header := append([]byte{}, req[:4]...)
+				header = binary.BigEndian.AppendUint64(header, 1)
+				header = binary.BigEndian.AppendUint32(header, uint32(code))
+				z.writePacket(append(header, body...))
+			}
+			switch int32(binary.BigEndian.Uint32(req[4:8])) {
+			case zkOpGetChildren:
+				if !worldReadable {
+					reply(zkErrNoAuth, nil)
+					continue
+				}
+				body := binary.BigEndian.AppendUint32(nil, 2)
+				body = zkAppendString(body, "zookeeper")
+				reply(0, zkAppendString(body, "dubbo"))
+			case zkOpGetACL:
+				body := binary.BigEndian.AppendUint32(nil, uint32(len(rootACL)))
+				for _, acl := range rootACL {
+					body = binary.BigEndian.AppendUint32(body, uint32(acl.Perms))
+					body = zkAppendString(zkAppendString(body, acl.Scheme), acl.ID)
+				}
+				reply(0, append(body, make([]byte, 68)...))
+			}
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestZooKeeperUnAuth(t *testing.T) {
+	addr := startTestZooKeeper(t, []zkACL{{Perms: zkPermAll, Scheme: "world", ID: "anyone"}})
+	res := zookeeperAuth.GetBruteHandler()(&BruteItem{Type: "zookeeper", Target: addr})
+	require.True(t, res.Ok)
+	require.Contains(t, string(res.ExtraInfo), "dubbo")
+	require.Contains(t, string(res.ExtraInfo), "world:anyone:rwcda")
+
+	addr = startTestZooKeeper(t, []zkACL{{Perms: zkPermAll, Scheme: "digest", ID: "admin:x1nq8J5GOJVPY6zgBCWovUvvxdk="}})
+	res = zookeeperAuth.GetBruteHandler()(&BruteItem{Type: "zookeeper", Target: addr})
+	require.False(t, res.Ok)
+	require.True(t, res.Finished)
+	require.Contains(t, string(res.ExtraInfo), "requires auth")
+}
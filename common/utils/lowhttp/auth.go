@@ -54,12 +54,18 @@ type NtlmAuthentication struct {
 	Username string
 	Password string
 	Domain   string
+	// Scheme 是服务端要求的认证方案（NTLM / Negotiate），为空时使用 NTLM
+	Scheme string
 }
 
 func (na *NtlmAuthentication) Authenticate(conn net.Conn, config *LowhttpExecConfig) ([]byte, error) {
+	scheme := na.Scheme
+	if scheme == "" {
+		scheme = "NTLM"
+	}
 	ntv2 := nla.NewNTLMv2(na.Domain, na.Username, na.Password)
 	negotiation := ntv2.GetNegotiateMessage()
-	negotiationReq := ReplaceHTTPPacketHeader(config.Packet, "Authorization", "NTLM "+codec.EncodeBase64(negotiation.Serialize()))
+	negotiationReq := ReplaceHTTPPacketHeader(config.Packet, "Authorization", scheme+" "+codec.EncodeBase64(negotiation.Serialize()))
 	_, err := conn.Write(negotiationReq)
 	if err != nil {
 		return nil, utils.Wrap(err, "write negotiation request failed")
@@ -79,15 +85,18 @@ func (na *NtlmAuthentication) Authenticate(conn net.Conn, config *LowhttpExecCon
 		return nil, utils.Wrap(err, "dump http response failed")
 	}
 	challengeHeader := GetHTTPPacketHeader(negotiationResponseByte, "WWW-Authenticate")
-	if !(len(challengeHeader) > 5 && strings.HasPrefix(challengeHeader, "NTLM ")) {
-		return nil, utils.Wrap(err, "Authenticate header non-standard ")
+	if !(len(challengeHeader) > len(scheme)+1 && strings.EqualFold(challengeHeader[:len(scheme)+1], scheme+" ")) {
+		return nil, utils.Errorf("Authenticate header non-standard: %v", challengeHeader)
 	}
-	challenge, err := codec.DecodeBase64(challengeHeader[5:])
+	challenge, err := codec.DecodeBase64(challengeHeader[len(scheme)+1:])
 	if err != nil {
 		return nil, utils.Wrap(err, "decode challenge failed")
 	}
 	authMessage, _ := ntv2.GetAuthenticateMessage(challenge)
-	authReq := ReplaceHTTPPacketHeader(config.Packet, "Authorization", "NTLM "+codec.EncodeBase64(authMessage.Serialize()))
+	if authMessage == nil {
+		return nil, utils.Error("parse ntlm challenge message failed")
+	}
+	authReq := ReplaceHTTPPacketHeader(config.Packet, "Authorization", scheme+" "+codec.EncodeBase64(authMessage.Serialize()))
 	return authReq, nil
 }
 
@@ -122,7 +131,7 @@ func GetAuth(authHeader string, username string, password string) Authentication
 			}
 			return &CustomAuthClient{handler: handler}
 		}
-		return &NtlmAuthentication{username, password, domain}
+		return &NtlmAuthentication{Username: username, Password: password, Domain: domain, Scheme: authResp[0]}
 	case "basic":
 		return &BasicAuthentication{username, password}
 	case "digest":
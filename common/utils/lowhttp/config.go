@@ -10,6 +10,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/utils"
 )
 
@@ -72,6 +73,9 @@ type LowhttpExecConfig struct {
 	// SNI
 	SNI string
 
+	// ClientCertificates 只对这次请求生效的客户端证书，优先于全局按 host 选择的证书
+	ClientCertificates []*netx.ClientCertificate
+
 	// payloads (web fuzzer)
 	Payloads []string
 }
@@ -468,6 +472,13 @@ func WithSNI(sni string) LowhttpOpt {
 	}
 }
 
+// WithClientCertificates 为这次请求的 TLS 连接提供客户端证书(双向认证)，不影响其他请求
+func WithClientCertificates(certs ...*netx.ClientCertificate) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.ClientCertificates = append(o.ClientCertificates, certs...)
+	}
+}
+
 func WithPayloads(payloads []string) LowhttpOpt {
 	return func(o *LowhttpExecConfig) {
 		o.Payloads = payloads
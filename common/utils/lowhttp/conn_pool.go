@@ -383,6 +383,7 @@ func newPersistConn(key connectKey, pool *LowHttpConnPool, opt ...netx.DialXOpti
 func (pc *persistConn) h2Conn() {
 	newH2Conn := &http2ClientConn{
 		conn:              pc.Conn,
+		connectionId:      newH2ConnectionID(),
 		mu:                new(sync.Mutex),
 		streams:           make(map[uint32]*http2ClientStream),
 		currentStreamID:   1,
@@ -404,6 +405,9 @@ func (pc *persistConn) h2Conn() {
 		},
 	}
 
+	// x/net 的 Framer 不允许 PUSH_PROMISE 之后的 CONTINUATION，帧顺序由读循环检查
+	newH2Conn.fr.AllowIllegalReads = true
+
 	newH2Conn.idleTimer = time.AfterFunc(newH2Conn.idleTimeout, func() {
 		newH2Conn.closed = true
 	})
@@ -639,10 +643,12 @@ type connectKey struct {
 	scheme, addr string   //协议和目标地址
 	https        bool
 	gmTls        bool
+	sni          string                    //tls 握手使用的 SNI，同一个地址不同 SNI 的连接不能复用
+	certs        []*netx.ClientCertificate //握手时提供的客户端证书，不同证书的连接不能复用
 }
 
 func (c connectKey) hash() string {
-	return utils.CalcSha1(c.proxy, c.scheme, c.addr, c.https, c.gmTls)
+	return utils.CalcSha1(c.proxy, c.scheme, c.addr, c.https, c.gmTls, c.sni, c.certs)
 }
 
 type connLRU struct {
@@ -447,15 +447,22 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 	// 需要用于标识连接 https gmTLS
 	// configTLS
 	var dialopts []netx.DialXOption
+	// tls 握手实际使用的 SNI，同时作为连接池的 key
+	var serverName string
 
 	dialopts = append(dialopts, netx.DialX_WithTimeout(connectTimeout), netx.DialX_WithTLSNextProto(nextProto...))
 
 	if https {
+		// WithSNI 需要覆盖 TLS 配置中的 ServerName，否则 dialx 会使用这里的配置
+		serverName = host
+		if sni != "" {
+			serverName = sni
+		}
 		if gmTLS {
 			dialopts = append(dialopts, netx.DialX_WithGMTLSConfig(&gmtls.Config{
 				GMSupport:          &gmtls.GMSupport{WorkMode: gmtls.ModeAutoSwitch},
 				NextProtos:         nextProto,
-				ServerName:         host,
+				ServerName:         serverName,
 				InsecureSkipVerify: !option.VerifyCertificate,
 				MinVersion:         tls.VersionSSL30, // nolint[:staticcheck]
 				MaxVersion:         tls.VersionTLS13,
@@ -463,7 +470,7 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		} else {
 			dialopts = append(dialopts, netx.DialX_WithTLSConfig(&tls.Config{
 				NextProtos:         nextProto,
-				ServerName:         host,
+				ServerName:         serverName,
 				InsecureSkipVerify: !option.VerifyCertificate,
 				MinVersion:         tls.VersionSSL30, // nolint[:staticcheck]
 				MaxVersion:         tls.VersionTLS13,
@@ -473,6 +480,9 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		if sni != "" {
 			dialopts = append(dialopts, netx.DialX_WithSNI(sni))
 		}
+		if len(option.ClientCertificates) > 0 {
+			dialopts = append(dialopts, netx.DialX_WithClientCertificates(option.ClientCertificates...))
+		}
 	}
 
 	if forceProxy {
@@ -514,6 +524,8 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		addr:   originAddr,
 		https:  option.Https,
 		gmTls:  option.GmTLS,
+		sni:    serverName,
+		certs:  option.ClientCertificates,
 	}
 	haveNativeHTTPRequestInstance := option.NativeHTTPRequestInstance != nil
 RECONNECT:
@@ -614,8 +626,12 @@ RECONNECT:
 				goto RECONNECT
 			}
 		}
+		streamInfo := h2Stream.info
 		resp, responsePacket := h2Stream.waitResponse(timeout)
 		_ = resp
+		if haveNativeHTTPRequestInstance {
+			SetHTTP2UpstreamStreamInfo(option.NativeHTTPRequestInstance, streamInfo)
+		}
 		httpctx.SetBareResponseBytes(option.NativeHTTPRequestInstance, responsePacket)
 		response.RawPacket = responsePacket
 		return response, nil
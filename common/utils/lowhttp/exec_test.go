@@ -528,3 +528,22 @@ func TestLowhttp_RESP_StreamBody(t *testing.T) {
 		panic("Response has content")
 	}
 }
+
+func TestLowhttp_ConnPool_SNI(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("sni:" + r.TLS.ServerName))
+	}))
+	defer server.Close()
+	packet := []byte("GET / HTTP/1.1\r\nHost: " + server.Listener.Addr().String() + "\r\n\r\n")
+
+	// 同一个地址不同 SNI 的请求不能复用连接池中的连接
+	for _, sni := range []string{"a.example.com", "b.example.com", "a.example.com"} {
+		rsp, err := HTTPWithoutRedirect(WithPacketBytes(packet), WithHttps(true), WithConnPool(true), WithSNI(sni), WithTimeout(5*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body := string(GetHTTPPacketBody(rsp.RawPacket)); body != "sni:"+sni {
+			t.Fatalf("expect sni %v, got %v", sni, body)
+		}
+	}
+}
@@ -20,7 +20,8 @@ import (
 )
 
 type http2ClientConn struct {
-	conn net.Conn
+	conn         net.Conn
+	connectionId string
 
 	mu              *sync.Mutex
 	streams         map[uint32]*http2ClientStream
@@ -75,10 +76,47 @@ type http2ClientStream struct {
 	readHeaderEnd bool
 
 	readEndStreamSignal chan struct{}
+
+	// h2 stream metadata, pushed streams record their response into pushed
+	info   *H2StreamInfo
+	pushed *H2PushedResource
 }
 
 type http2ClientConnReadLoop struct {
 	h2Conn *http2ClientConn
+
+	// pendingPush 是还在等待 CONTINUATION 的 PUSH_PROMISE，头部块需要完整之后才能解码
+	pendingPush *http2PendingPushPromise
+	// lastHeaderStream 是头部块还没有结束的流，之后只能出现这个流的 CONTINUATION
+	lastHeaderStream uint32
+}
+
+// checkFrameOrder 检查头部块(HEADERS/PUSH_PROMISE + CONTINUATION)没有被其他帧打断
+func (rl *http2ClientConnReadLoop) checkFrameOrder(frame http2.Frame) error {
+	fh := frame.Header()
+	if rl.lastHeaderStream != 0 {
+		if fh.Type != http2.FrameContinuation || fh.StreamID != rl.lastHeaderStream {
+			return utils.Errorf("got %s for stream %d; expected CONTINUATION for stream %d", fh.Type, fh.StreamID, rl.lastHeaderStream)
+		}
+	} else if fh.Type == http2.FrameContinuation {
+		return utils.Errorf("unexpected CONTINUATION for stream %d", fh.StreamID)
+	}
+	switch fh.Type {
+	case http2.FrameHeaders, http2.FrameContinuation, http2.FramePushPromise:
+		// END_HEADERS 在这三种帧中使用相同的标志位
+		if fh.Flags.Has(http2.FlagHeadersEndHeaders) {
+			rl.lastHeaderStream = 0
+		} else {
+			rl.lastHeaderStream = fh.StreamID
+		}
+	}
+	return nil
+}
+
+type http2PendingPushPromise struct {
+	streamID  uint32
+	promiseID uint32
+	block     bytes.Buffer
 }
 
 // get stream by id
@@ -174,6 +212,8 @@ func (h2Conn *http2ClientConn) newStream(req *http.Request, packet []byte) *http
 	cs.req = req
 	cs.reqPacket = packet
 	cs.resp.Header = make(http.Header) // init header
+	cs.info = NewH2StreamInfo(h2Conn.connectionId, newStreamID)
+	cs.pushed = nil
 
 	h2Conn.mu.Lock()
 	h2Conn.streams[newStreamID] = cs
@@ -210,6 +250,11 @@ func (h2Conn *http2ClientConn) readLoop() {
 			h2Conn.setClose()
 			return
 		}
+		if err := rl.checkFrameOrder(frame); err != nil {
+			log.Errorf("http2: protocol error on conn %p: %v", rl.h2Conn.conn, err)
+			h2Conn.setClose()
+			return
+		}
 		if !gotSettings {
 			if _, ok := frame.(*http2.SettingsFrame); !ok {
 				log.Errorf("protocol error: received %T before a SETTINGS frame", frame)
@@ -220,6 +265,11 @@ func (h2Conn *http2ClientConn) readLoop() {
 		}
 
 		//log.Infof("h2 stream-id %v found frame: %v", frame.Header().StreamID, frame)
+		if id := frame.Header().StreamID; id != 0 {
+			if cs := h2Conn.streamByID(id); cs != nil {
+				cs.info.AddFrame(H2FrameFromServer, frame)
+			}
+		}
 
 		switch f := frame.(type) {
 		case *http2.HeadersFrame:
@@ -238,6 +288,8 @@ func (h2Conn *http2ClientConn) readLoop() {
 			rl.processWindowUpdate(f)
 		case *http2.PingFrame:
 			rl.processPing(f)
+		case *http2.PushPromiseFrame:
+			rl.processPushPromise(f)
 		default:
 			//log.Debugf("Transport: unhandled response frame type %T", f)
 		}
@@ -337,11 +389,24 @@ func (cs *http2ClientStream) doRequest() error {
 				if err != nil {
 					return err
 				}
+				var flags http2.Flags
+				if endStream {
+					flags |= http2.FlagHeadersEndStream
+				}
+				if endHeaders {
+					flags |= http2.FlagHeadersEndHeaders
+				}
+				cs.info.AddWrittenFrame(H2FrameToServer, http2.FrameHeaders, flags, len(chunk))
 			} else {
 				err := frame.WriteContinuation(streamID, endHeaders, chunk)
 				if err != nil {
 					return err
 				}
+				var flags http2.Flags
+				if endHeaders {
+					flags |= http2.FlagContinuationEndHeaders
+				}
+				cs.info.AddWrittenFrame(H2FrameToServer, http2.FrameContinuation, flags, len(chunk))
 			}
 		}
 		cs.sentEndStream = endStream
@@ -371,6 +436,11 @@ func (cs *http2ClientStream) doRequest() error {
 			if dataFrameErr != nil {
 				return utils.Wrapf(dataFrameErr, "framer WriteData for stream{%v} failed", cs.ID)
 			}
+			var flags http2.Flags
+			if index == len(chunks)-1 {
+				flags |= http2.FlagDataEndStream
+			}
+			cs.info.AddWrittenFrame(H2FrameToServer, http2.FrameData, flags, dataLen)
 		}
 	} else {
 		if !cs.sentEndStream {
@@ -380,6 +450,7 @@ func (cs *http2ClientStream) doRequest() error {
 			if dataFrameErr != nil {
 				return utils.Wrapf(dataFrameErr, "framer WriteData for stream{%v} failed", cs.ID)
 			}
+			cs.info.AddWrittenFrame(H2FrameToServer, http2.FrameData, http2.FlagDataEndStream, 0)
 		}
 	}
 	cs.sentEndStream = true
@@ -416,6 +487,18 @@ func (cs *http2ClientStream) waitResponse(timeout time.Duration) (http.Response,
 
 func (cs *http2ClientStream) setEndStream() {
 	cs.readEndStream = true
+	if cs.pushed != nil {
+		// pushed stream has no waiter, finish the pushed resource here
+		cs.resp.Body = io.NopCloser(cs.bodyBuffer)
+		response, _ := utils.DumpHTTPResponse(cs.resp, cs.bodyBuffer.Len() > 0)
+		cs.info.close()
+		cs.h2Conn.mu.Lock()
+		delete(cs.h2Conn.streams, cs.ID)
+		cs.h2Conn.mu.Unlock()
+		cs.pushed.finish(response)
+		return
+	}
+	cs.info.close()
 	cs.readEndStreamSignal <- struct{}{}
 }
 
@@ -469,6 +552,21 @@ func (rl *http2ClientConnReadLoop) processHeaders(f *http2.HeadersFrame) {
 }
 
 func (rl *http2ClientConnReadLoop) processContinuation(f *http2.ContinuationFrame) {
+	if pending := rl.pendingPush; pending != nil {
+		// PUSH_PROMISE 之后的 CONTINUATION 属于推送的请求头
+		if pending.streamID != f.StreamID {
+			log.Errorf("h2 stream-id %v continuation interleaved with push promise of stream %v", f.StreamID, pending.streamID)
+			rl.pendingPush = nil
+			return
+		}
+		pending.block.Write(f.HeaderBlockFragment())
+		if f.HeadersEnded() {
+			rl.pendingPush = nil
+			rl.finishPushPromise(pending)
+		}
+		return
+	}
+
 	cs := rl.h2Conn.streamByID(f.StreamID) // get stream by id
 	if err := streamAliveCheck(cs, f.StreamID); err != nil {
 		log.Errorf("h2 stream-id %v processContinuation error: %v", f.StreamID, err)
@@ -605,6 +703,84 @@ func (rl *http2ClientConnReadLoop) processResetStream(f *http2.RSTStreamFrame) {
 	return
 }
 
+// processPushPromise buffer the header block of PUSH_PROMISE until END_HEADERS, the block
+// is decoded once it is complete to keep the shared hpack state in sync
+func (rl *http2ClientConnReadLoop) processPushPromise(f *http2.PushPromiseFrame) {
+	pending := &http2PendingPushPromise{streamID: f.StreamID, promiseID: f.PromiseID}
+	pending.block.Write(f.HeaderBlockFragment())
+	if !f.HeadersEnded() {
+		rl.pendingPush = pending
+		return
+	}
+	rl.finishPushPromise(pending)
+}
+
+// finishPushPromise decode the promised request and register a pushed stream,
+// the pushed response will be recorded into parent stream info
+func (rl *http2ClientConnReadLoop) finishPushPromise(pending *http2PendingPushPromise) {
+	h2Conn := rl.h2Conn
+	parsedHeaders, err := h2Conn.hDec.DecodeFull(pending.block.Bytes())
+	if err != nil {
+		log.Errorf("h2 stream-id %v hpack decode push promise failed: %v", pending.streamID, err)
+		return
+	}
+	parent := h2Conn.streamByID(pending.streamID)
+	if parent == nil {
+		log.Errorf("h2 push promise for unknown stream id: %v", pending.streamID)
+		return
+	}
+
+	var method, path, authority, scheme = http.MethodGet, "/", "", "https"
+	var headers []string
+	for _, h := range parsedHeaders {
+		switch h.Name {
+		case ":method":
+			method = h.Value
+		case ":path":
+			path = h.Value
+		case ":authority":
+			authority = h.Value
+		case ":scheme":
+			scheme = h.Value
+		default:
+			if !h.IsPseudo() {
+				headers = append(headers, h.Name+": "+h.Value)
+			}
+		}
+	}
+	_ = scheme
+	var buf bytes.Buffer
+	buf.WriteString(method + " " + path + " HTTP/2\r\n")
+	if authority != "" {
+		buf.WriteString("Host: " + authority + "\r\n")
+	}
+	for _, h := range headers {
+		buf.WriteString(h + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	info := NewH2StreamInfo(h2Conn.connectionId, pending.promiseID)
+	pushed := &H2PushedResource{PromisedStreamID: pending.promiseID, Request: buf.Bytes(), Info: info}
+	parent.info.addPushed(pushed)
+
+	cs := &http2ClientStream{
+		ID:                  pending.promiseID,
+		h2Conn:              h2Conn,
+		streamWindowControl: newControl(int64(h2Conn.initialWindowSize)),
+		resp:                &http.Response{Header: make(http.Header)},
+		bodyBuffer:          new(bytes.Buffer),
+		hPackByte:           new(bytes.Buffer),
+		sentHeaders:         true,
+		sentEndStream:       true,
+		readEndStreamSignal: make(chan struct{}, 1),
+		info:                info,
+		pushed:              pushed,
+	}
+	h2Conn.mu.Lock()
+	h2Conn.streams[pending.promiseID] = cs
+	h2Conn.mu.Unlock()
+}
+
 func (rl *http2ClientConnReadLoop) processGoAway(f *http2.GoAwayFrame) {
 	flow := fmt.Sprintf("%v->%v", rl.h2Conn.conn.LocalAddr(), rl.h2Conn.conn.RemoteAddr())
 	log.Infof("connection: %s is going away by %v", flow, f.ErrCode.String())
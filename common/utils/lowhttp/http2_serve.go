@@ -20,6 +20,7 @@ type h2RequestState struct {
 	config *http2ConnectionConfig
 
 	streamId       int
+	info           *H2StreamInfo
 	headerHPackBuf *bytes.Buffer
 	bodyReader     *utils.PipeReader
 	bodyBuf        *utils.PipeWriter
@@ -63,6 +64,7 @@ func newH2RequestState(
 	return &h2RequestState{
 		config:         config,
 		streamId:       int(streamId),
+		info:           NewH2StreamInfo(config.connectionId, uint32(streamId)),
 		headerHPackBuf: new(bytes.Buffer),
 		bodyReader:     r,
 		bodyBuf:        w,
@@ -96,10 +98,11 @@ func (w *h2RequestState) emitRequestHeader(req *http.Request, pairs []*ypb.KVPai
 
 func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 	var config = &http2ConnectionConfig{
-		handler: func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+		handler: func(_ *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
 			return nil, nil, utils.Errorf("h2 config is nil")
 		},
-		wg: new(sync.WaitGroup),
+		connectionId: newH2ConnectionID(),
+		wg:           new(sync.WaitGroup),
 	}
 	for _, o := range opt {
 		o(config)
@@ -169,6 +172,11 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 			return raw.(*h2RequestState)
 		}
 	}
+	recordFrame := func(f http2.Frame) {
+		if raw, ok := streamToBuf.Load(int(f.Header().StreamID)); ok {
+			raw.(*h2RequestState).info.AddFrame(H2FrameFromClient, f)
+		}
+	}
 
 	handleRequestHeader := func(req *h2RequestState) (*http.Request, []*ypb.KVPair, error) {
 		var reqInstance = new(http.Request)
@@ -236,11 +244,13 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 			// update window
 			log.Debugf("h2(WINDOW_UPDATE) client allow server to (inc) %v bytes", ret.Increment)
 			config.increaseWindowSize(int64(ret.Increment))
+			recordFrame(ret)
 		case *http2.HeadersFrame:
 			// build request
 			// log.Infof("h2 stream-id fetch header: %v", ret.StreamID)
 			streamId := ret.StreamID
 			req := getReq(streamId)
+			req.info.AddFrame(H2FrameFromClient, ret)
 			if b := ret.HeaderBlockFragment(); len(b) > 0 {
 				req.headerHPackBuf.Write(b)
 			}
@@ -263,6 +273,7 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 
 		case *http2.ContinuationFrame:
 			req := getReq(ret.StreamID)
+			req.info.AddFrame(H2FrameFromClient, ret)
 			if b := ret.HeaderBlockFragment(); len(b) > 0 {
 				req.headerHPackBuf.Write(b)
 			}
@@ -295,6 +306,7 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 			}
 
 			req := getReq(ret.StreamID)
+			req.info.AddFrame(H2FrameFromClient, ret)
 			if len(ret.Data()) > 0 {
 				req.bodyBuf.Write(ret.Data())
 			}
@@ -312,9 +324,13 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 			// close stream
 			log.Infof("h2 stream-id closed: %v reason: %v", ret.StreamID, ret.ErrCode.String())
 			req := getReq(ret.StreamID)
+			req.info.AddFrame(H2FrameFromClient, ret)
+			req.info.close()
 			req.Close()
 			streamToBuf.Delete(int(ret.StreamID))
 			streamToBuf.Delete(ret.StreamID)
+		case *http2.PriorityFrame:
+			getReq(ret.StreamID).info.AddFrame(H2FrameFromClient, ret)
 		case *http2.GoAwayFrame:
 			flow := fmt.Sprintf("%v->%v", conn.LocalAddr(), conn.RemoteAddr())
 			log.Infof("connection: %s is going away, start to waitgroup and return", flow)
@@ -334,3 +350,8 @@ func serveH2(r io.Reader, conn net.Conn, opt ...h2Option) error {
 func ServeHTTP2Connection(conn net.Conn, handler func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) error {
 	return serveH2(conn, conn, withH2Handler(handler))
 }
+
+// ServeHTTP2ConnectionWithStreamInfo 与 ServeHTTP2Connection 相同，但是 handler 可以拿到当前流的 h2 元数据(流 ID，优先级，帧时间线)
+func ServeHTTP2ConnectionWithStreamInfo(conn net.Conn, handler func(info *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) error {
+	return serveH2(conn, conn, withH2StreamHandler(handler))
+}
@@ -13,7 +13,8 @@ import (
 )
 
 type http2ConnectionConfig struct {
-	handler      func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)
+	handler      func(info *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)
+	connectionId string
 	frame        *http2.Framer
 	frWriteMutex *sync.Mutex
 
@@ -32,6 +33,8 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 		return utils.Error("h2 server frame config is nil")
 	}
 	streamId := wrapper.streamId
+	info := wrapper.info
+	defer info.close()
 	frame := c.frame
 	henc := c.henc
 	buf := c.hencBuf
@@ -70,6 +73,11 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 			if err != nil {
 				return utils.Wrapf(err, "h2framer write header(%v) for stream:%v failed", len(hpackHeaderBytes), streamId)
 			}
+			var flags http2.Flags
+			if index == len(ret)-1 {
+				flags |= http2.FlagHeadersEndHeaders
+			}
+			info.AddWrittenFrame(H2FrameToClient, http2.FrameHeaders, flags, len(item))
 		} else {
 			frWriteMutex.Lock()
 			err := frame.WriteContinuation(uint32(streamId), index == len(ret)-1, item)
@@ -77,6 +85,11 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 			if err != nil {
 				return utils.Wrapf(err, "h2framer write header(%v)-continuation for stream:%v failed", len(hpackHeaderBytes), streamId)
 			}
+			var flags http2.Flags
+			if index == len(ret)-1 {
+				flags |= http2.FlagContinuationEndHeaders
+			}
+			info.AddWrittenFrame(H2FrameToClient, http2.FrameContinuation, flags, len(item))
 		}
 	}
 	c.hencMutex.Unlock()
@@ -98,6 +111,11 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 			if dataFrameErr != nil {
 				return utils.Wrapf(dataFrameErr, "framer WriteData for stream{%v} failed", streamId)
 			}
+			var flags http2.Flags
+			if index == len(chunks)-1 {
+				flags |= http2.FlagDataEndStream
+			}
+			info.AddWrittenFrame(H2FrameToClient, http2.FrameData, flags, dataLen)
 		}
 	} else {
 		frWriteMutex.Lock()
@@ -106,6 +124,7 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 		if dataFrameErr != nil {
 			return utils.Wrapf(dataFrameErr, "framer WriteData for stream{%v} failed", streamId)
 		}
+		info.AddWrittenFrame(H2FrameToClient, http2.FrameData, http2.FlagDataEndStream, 0)
 	}
 	if err != nil {
 		return utils.Wrapf(err, "read body for stream{%v} failed", streamId)
@@ -116,6 +135,14 @@ func (c *http2ConnectionConfig) writer(wrapper *h2RequestState, header []byte, b
 type h2Option func(*http2ConnectionConfig)
 
 func withH2Handler(h func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) h2Option {
+	return func(c *http2ConnectionConfig) {
+		c.handler = func(_ *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+			return h(header, body)
+		}
+	}
+}
+
+func withH2StreamHandler(h func(info *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) h2Option {
 	return func(c *http2ConnectionConfig) {
 		c.handler = h
 	}
@@ -125,7 +152,7 @@ func (c *http2ConnectionConfig) handleRequest(wrapper *h2RequestState, header []
 	if c == nil || c.handler == nil {
 		return utils.Error("h2 server handler config is nil")
 	}
-	header, rc, err := c.handler(header, body)
+	header, rc, err := c.handler(wrapper.info, header, body)
 	if err != nil {
 		return utils.Errorf("waiting for userspace handling for h2 stream(%v) failed: %v", wrapper.streamId, err)
 	}
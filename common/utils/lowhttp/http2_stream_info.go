@@ -0,0 +1,328 @@
+package lowhttp
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+	"golang.org/x/net/http2"
+)
+
+const (
+	// H2FrameFromClient / H2FrameToClient 描述 h2 服务端(MITM 面向浏览器一侧)的帧方向
+	H2FrameFromClient = "client->proxy"
+	H2FrameToClient   = "proxy->client"
+	// H2FrameToServer / H2FrameFromServer 描述 h2 客户端(lowhttp 面向远端服务器一侧)的帧方向
+	H2FrameToServer   = "proxy->server"
+	H2FrameFromServer = "server->proxy"
+)
+
+// H2FrameEvent 记录一个 h2 帧在时间线上的事件
+type H2FrameEvent struct {
+	Timestamp        int64  `json:"timestamp"` // unix nano
+	Direction        string `json:"direction"`
+	Type             string `json:"type"`
+	Flags            string `json:"flags"`
+	Length           int    `json:"length"`
+	ErrCode          string `json:"err_code,omitempty"`
+	PromisedStreamID uint32 `json:"promised_stream_id,omitempty"`
+}
+
+// H2PushedResource 是服务器通过 PUSH_PROMISE 推送的资源，响应由读循环写入，读取时需要使用 Response/Finished
+type H2PushedResource struct {
+	PromisedStreamID uint32
+	Request          []byte
+	// Info 是推送流自身的流信息(帧时间线，RST_STREAM 等)
+	Info *H2StreamInfo
+
+	mu       sync.Mutex
+	response []byte
+	finished bool
+	onFinish []func(*H2PushedResource)
+}
+
+func (r *H2PushedResource) Response() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.response
+}
+
+func (r *H2PushedResource) Finished() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.finished
+}
+
+// OnFinish 注册推送流结束(END_STREAM 或 RST_STREAM)时的回调，如果推送流已经结束，则立即执行
+func (r *H2PushedResource) OnFinish(f func(*H2PushedResource)) {
+	if r == nil || f == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		f(r)
+		return
+	}
+	r.onFinish = append(r.onFinish, f)
+	r.mu.Unlock()
+}
+
+func (r *H2PushedResource) finish(response []byte) {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.response = response
+	r.finished = true
+	callbacks := r.onFinish
+	r.onFinish = nil
+	r.mu.Unlock()
+	for _, f := range callbacks {
+		f(r)
+	}
+}
+
+// H2StreamInfo 保存一个 h2 流的元数据：所属连接，优先级，帧时间线与推送资源
+type H2StreamInfo struct {
+	mu sync.Mutex
+
+	ConnectionID string
+	StreamID     uint32
+
+	// PRIORITY 信息，来自 HEADERS(PRIORITY flag) 或 PRIORITY 帧
+	Weight    uint8
+	StreamDep uint32
+	Exclusive bool
+
+	// RST_STREAM 错误码，为空表示流没有被重置
+	ResetErrCode string
+
+	frames         []*H2FrameEvent
+	pushed         []*H2PushedResource
+	onPushedFinish []func(*H2PushedResource)
+
+	closed  bool
+	onClose []func(*H2StreamInfo)
+}
+
+func newH2ConnectionID() string {
+	return uuid.NewString()
+}
+
+func NewH2StreamInfo(connectionId string, streamId uint32) *H2StreamInfo {
+	return &H2StreamInfo{
+		ConnectionID: connectionId,
+		StreamID:     streamId,
+		Weight:       15, // RFC 7540 5.3.5: default weight is 16 (encoded as 15)
+	}
+}
+
+func (i *H2StreamInfo) addEvent(direction string, e *H2FrameEvent) {
+	if i == nil {
+		return
+	}
+	e.Direction = direction
+	if e.Timestamp <= 0 {
+		e.Timestamp = time.Now().UnixNano()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.frames = append(i.frames, e)
+}
+
+// AddFrame 把一个读到的帧记录到时间线上，并提取优先级，RST_STREAM 等信息
+func (i *H2StreamInfo) AddFrame(direction string, f http2.Frame) {
+	if i == nil || f == nil {
+		return
+	}
+	h := f.Header()
+	e := &H2FrameEvent{
+		Type:   h.Type.String(),
+		Flags:  h2FlagsString(h.Type, h.Flags),
+		Length: int(h.Length),
+	}
+	switch ret := f.(type) {
+	case *http2.HeadersFrame:
+		if ret.HasPriority() {
+			i.setPriority(ret.Priority)
+		}
+	case *http2.PriorityFrame:
+		i.setPriority(ret.PriorityParam)
+	case *http2.RSTStreamFrame:
+		e.ErrCode = ret.ErrCode.String()
+		i.mu.Lock()
+		i.ResetErrCode = e.ErrCode
+		i.mu.Unlock()
+	case *http2.PushPromiseFrame:
+		e.PromisedStreamID = ret.PromiseID
+	}
+	i.addEvent(direction, e)
+}
+
+// AddWrittenFrame 记录一个由我们写出的帧
+func (i *H2StreamInfo) AddWrittenFrame(direction string, t http2.FrameType, flags http2.Flags, length int) {
+	i.addEvent(direction, &H2FrameEvent{
+		Type:   t.String(),
+		Flags:  h2FlagsString(t, flags),
+		Length: length,
+	})
+}
+
+func (i *H2StreamInfo) setPriority(p http2.PriorityParam) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.Weight = p.Weight
+	i.StreamDep = p.StreamDep
+	i.Exclusive = p.Exclusive
+}
+
+func (i *H2StreamInfo) Frames() []*H2FrameEvent {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]*H2FrameEvent(nil), i.frames...)
+}
+
+func (i *H2StreamInfo) addPushed(r *H2PushedResource) {
+	i.mu.Lock()
+	i.pushed = append(i.pushed, r)
+	callbacks := append([]func(*H2PushedResource){}, i.onPushedFinish...)
+	i.mu.Unlock()
+	for _, f := range callbacks {
+		r.OnFinish(f)
+	}
+}
+
+// OnPushedFinish 注册推送流结束时的回调，对已经推送和之后推送的资源都生效
+func (i *H2StreamInfo) OnPushedFinish(f func(*H2PushedResource)) {
+	if i == nil || f == nil {
+		return
+	}
+	i.mu.Lock()
+	i.onPushedFinish = append(i.onPushedFinish, f)
+	pushed := append([]*H2PushedResource(nil), i.pushed...)
+	i.mu.Unlock()
+	for _, r := range pushed {
+		r.OnFinish(f)
+	}
+}
+
+func (i *H2StreamInfo) PushedResources() []*H2PushedResource {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]*H2PushedResource(nil), i.pushed...)
+}
+
+func (i *H2StreamInfo) IsReset() bool {
+	if i == nil {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.ResetErrCode != ""
+}
+
+// OnClose 注册流结束(END_STREAM 写出或 RST_STREAM)时的回调，如果流已经结束，则立即执行
+func (i *H2StreamInfo) OnClose(f func(*H2StreamInfo)) {
+	if i == nil || f == nil {
+		return
+	}
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		f(i)
+		return
+	}
+	i.onClose = append(i.onClose, f)
+	i.mu.Unlock()
+}
+
+func (i *H2StreamInfo) close() {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		return
+	}
+	i.closed = true
+	callbacks := i.onClose
+	i.onClose = nil
+	i.mu.Unlock()
+	for _, f := range callbacks {
+		f(i)
+	}
+}
+
+func h2FlagsString(t http2.FrameType, flags http2.Flags) string {
+	var ret []string
+	switch t {
+	case http2.FrameData:
+		if flags.Has(http2.FlagDataEndStream) {
+			ret = append(ret, "END_STREAM")
+		}
+		if flags.Has(http2.FlagDataPadded) {
+			ret = append(ret, "PADDED")
+		}
+	case http2.FrameHeaders:
+		if flags.Has(http2.FlagHeadersEndStream) {
+			ret = append(ret, "END_STREAM")
+		}
+		if flags.Has(http2.FlagHeadersEndHeaders) {
+			ret = append(ret, "END_HEADERS")
+		}
+		if flags.Has(http2.FlagHeadersPadded) {
+			ret = append(ret, "PADDED")
+		}
+		if flags.Has(http2.FlagHeadersPriority) {
+			ret = append(ret, "PRIORITY")
+		}
+	case http2.FrameContinuation:
+		if flags.Has(http2.FlagContinuationEndHeaders) {
+			ret = append(ret, "END_HEADERS")
+		}
+	case http2.FramePushPromise:
+		if flags.Has(http2.FlagPushPromiseEndHeaders) {
+			ret = append(ret, "END_HEADERS")
+		}
+		if flags.Has(http2.FlagPushPromisePadded) {
+			ret = append(ret, "PADDED")
+		}
+	}
+	return strings.Join(ret, "|")
+}
+
+// SetHTTP2StreamInfo 把 MITM 面向客户端一侧的 h2 流信息绑定到请求上下文
+func SetHTTP2StreamInfo(req *http.Request, info *H2StreamInfo) {
+	httpctx.SetContextValueInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_HTTP2StreamInfo, info)
+}
+
+func GetHTTP2StreamInfo(req *http.Request) *H2StreamInfo {
+	if ret, ok := httpctx.GetContextAnyFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_HTTP2StreamInfo).(*H2StreamInfo); ok {
+		return ret
+	}
+	return nil
+}
+
+// SetHTTP2UpstreamStreamInfo 把 lowhttp h2 客户端(面向服务器一侧)的流信息绑定到请求上下文
+func SetHTTP2UpstreamStreamInfo(req *http.Request, info *H2StreamInfo) {
+	httpctx.SetContextValueInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_HTTP2UpstreamStreamInfo, info)
+}
+
+func GetHTTP2UpstreamStreamInfo(req *http.Request) *H2StreamInfo {
+	if ret, ok := httpctx.GetContextAnyFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_HTTP2UpstreamStreamInfo).(*H2StreamInfo); ok {
+		return ret
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package lowhttp
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/net/http2"
+)
+
+func TestH2_StreamInfo_Serve(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	lis, err := net.Listen("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	defer lis.Close()
+
+	infoCh := make(chan *H2StreamInfo, 1)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go serveH2(conn, conn, withH2StreamHandler(func(info *H2StreamInfo, header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+				infoCh <- info
+				return []byte("HTTP/2 200 OK\r\nContent-Type: text/plain\r\n\r\n"), io.NopCloser(bytes.NewBufferString("ok")), nil
+			}))
+		}
+	}()
+
+	req, err := http.NewRequest("GET", "http://www.example.com/", nil)
+	require.NoError(t, err)
+	_, err = HTTPWithoutRedirect(WithHttps(false), WithHttp2(true), WithPacketBytes([]byte("GET / HTTP/2\r\nHost: www.example.com\r\n\r\n")),
+		WithHost("127.0.0.1"), WithPort(port), WithNativeHTTPRequestInstance(req))
+	require.NoError(t, err)
+
+	var info *H2StreamInfo
+	select {
+	case info = <-infoCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("h2 server handler not called")
+	}
+
+	closed := make(chan struct{})
+	info.OnClose(func(*H2StreamInfo) { close(closed) })
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("h2 stream info is not closed")
+	}
+	require.Equal(t, uint32(1), info.StreamID)
+	require.NotEmpty(t, info.ConnectionID)
+
+	var types []string
+	for _, f := range info.Frames() {
+		types = append(types, f.Direction+":"+f.Type)
+	}
+	require.Contains(t, types, H2FrameFromClient+":HEADERS")
+	require.Contains(t, types, H2FrameToClient+":HEADERS")
+	require.Contains(t, types, H2FrameToClient+":DATA")
+
+	upstream := GetHTTP2UpstreamStreamInfo(req)
+	require.NotNil(t, upstream)
+	require.Equal(t, uint32(1), upstream.StreamID)
+	require.NotEmpty(t, upstream.Frames())
+}
+
+func TestH2_StreamInfo_PushPromise(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	lis, err := net.Listen("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	defer lis.Close()
+
+	pushedToken := utils.RandStringBytes(16)
+	bigHeader := utils.RandStringBytes(40000)
+	server := &http2.Server{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pushed.js" {
+			w.Write([]byte(pushedToken))
+			return
+		}
+		if pusher, ok := w.(http.Pusher); ok {
+			// 超过一个帧大小的头部会被拆分为 PUSH_PROMISE + CONTINUATION
+			pusher.Push("/pushed.js", &http.PushOptions{Header: http.Header{"X-Big": {bigHeader}}})
+		}
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("index"))
+	})
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+
+	req, err := http.NewRequest("GET", "http://www.example.com/", nil)
+	require.NoError(t, err)
+	rsp, err := HTTPWithoutRedirect(WithHttps(false), WithHttp2(true), WithPacketBytes([]byte("GET / HTTP/2\r\nHost: www.example.com\r\n\r\n")),
+		WithHost("127.0.0.1"), WithPort(port), WithNativeHTTPRequestInstance(req))
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(rsp.RawPacket, []byte("index")))
+
+	upstream := GetHTTP2UpstreamStreamInfo(req)
+	require.NotNil(t, upstream)
+	pushed := upstream.PushedResources()
+	require.Len(t, pushed, 1)
+	require.True(t, strings.HasPrefix(string(pushed[0].Request), "GET /pushed.js HTTP/2"))
+	require.Contains(t, string(pushed[0].Request), "x-big: "+bigHeader)
+
+	finished := make(chan *H2PushedResource, 1)
+	upstream.OnPushedFinish(func(r *H2PushedResource) { finished <- r })
+	select {
+	case r := <-finished:
+		require.True(t, r.Finished())
+		require.Contains(t, string(r.Response()), pushedToken)
+		// 推送流有自己的帧时间线
+		var types []string
+		for _, f := range r.Info.Frames() {
+			require.Equal(t, H2FrameFromServer, f.Direction)
+			types = append(types, f.Type)
+		}
+		require.Contains(t, types, "HEADERS")
+		require.Contains(t, types, "DATA")
+	case <-time.After(5 * time.Second):
+		t.Fatal("pushed stream is not finished")
+	}
+}
@@ -1,6 +1,7 @@
 package lowhttp
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
@@ -72,3 +73,28 @@ func ContentEncodingDecode(contentEncoding string, bodyRaw []byte) (finalResult
 		return bodyRaw, false
 	}
 }
+
+// ContentEncodingDecodeReader 返回流式解码 Content-Encoding 的 reader，用于不能一次读完的响应(例如 text/event-stream)
+func ContentEncodingDecodeReader(contentEncoding string, r io.Reader) (io.Reader, error) {
+	switch true {
+	case utils.IContains(contentEncoding, "gzip"):
+		return gzip.NewReader(r)
+	case utils.IContains(contentEncoding, "br"):
+		return brotli.NewReader(r), nil
+	case utils.IContains(contentEncoding, "deflate"):
+		// deflate 可能是 zlib 格式，也可能是没有 zlib 头部的原始 deflate 数据
+		br := bufio.NewReader(r)
+		header, err := br.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case utils.IContains(contentEncoding, "identity"):
+		return r, nil
+	default:
+		return nil, utils.Errorf("Content-Encoding: %v is not supported", contentEncoding)
+	}
+}
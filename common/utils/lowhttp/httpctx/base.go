@@ -275,6 +275,7 @@ const (
 	REQUEST_CONTEXT_KEY_ConnectedToHost              = "connectedToHost"
 	REQUEST_CONTEXT_KEY_RemoteAddr                   = "remoteAddr"
 	REQUEST_CONTEXT_KEY_ViaConnect                   = "viaConnect"
+	REQUEST_CONTEXT_KEY_ViaInvisibleListener         = "viaInvisibleListener"
 	REQUEST_CONTEXT_KEY_ResponseHeaderCallback       = "responseHeaderCallback"
 	REQUEST_CONTEXT_KEY_ResponseHeaderWriter         = "responseHeaderWriter"
 	REQUEST_CONTEXT_KEY_ResponseMaxContentLength     = "responseMaxContentLength"
@@ -289,6 +290,11 @@ const (
 	REQUEST_CONTEXT_KEY_ResponseTooLargeBodyFile     = "ResponseTooLargeBodyFile"
 	REQUEST_CONTEXT_KEY_ResponseBodySize             = "ResponseBodySize"
 	REQUEST_CONTEXT_KEY_MatchedRules                 = "MatchedRules"
+	REQUEST_CONTEXT_KEY_HTTP2StreamInfo              = "http2StreamInfo"
+	REQUEST_CONTEXT_KEY_HTTP2UpstreamStreamInfo      = "http2UpstreamStreamInfo"
+	RESPONSE_CONTEXT_KEY_SSEHash                     = "responseSSEHash"
+	REQUEST_CONTEXT_KEY_JA3                          = "ja3"
+	REQUEST_CONTEXT_KEY_JA4                          = "ja4"
 )
 
 func SetResponseBodySize(req *http.Request, i int64) {
@@ -575,6 +581,29 @@ func SetRequestViaCONNECT(req *http.Request, b bool) {
 	SetContextValueInfoFromRequest(req, REQUEST_CONTEXT_KEY_ViaConnect, b)
 }
 
+// GetRequestViaInvisibleListener 请求来自 MITM 的 invisible 监听端口(客户端不知道代理的存在)
+func GetRequestViaInvisibleListener(req *http.Request) bool {
+	return GetContextBoolInfoFromRequest(req, REQUEST_CONTEXT_KEY_ViaInvisibleListener)
+}
+
+func SetRequestViaInvisibleListener(req *http.Request, b bool) {
+	SetContextValueInfoFromRequest(req, REQUEST_CONTEXT_KEY_ViaInvisibleListener, b)
+}
+
+// SetRequestTLSClientFingerprint 记录客户端 TLS ClientHello 的 JA3 / JA4 指纹
+func SetRequestTLSClientFingerprint(req *http.Request, ja3, ja4 string) {
+	SetContextValueInfoFromRequest(req, REQUEST_CONTEXT_KEY_JA3, ja3)
+	SetContextValueInfoFromRequest(req, REQUEST_CONTEXT_KEY_JA4, ja4)
+}
+
+func GetRequestJA3(req *http.Request) string {
+	return GetContextStringInfoFromRequest(req, REQUEST_CONTEXT_KEY_JA3)
+}
+
+func GetRequestJA4(req *http.Request) string {
+	return GetContextStringInfoFromRequest(req, REQUEST_CONTEXT_KEY_JA4)
+}
+
 func GetFlowTags(r *http.Request) []string {
 	v := GetContextAnyFromRequest(r, REQUEST_CONTEXT_KEY_Tags)
 	switch ret := v.(type) {
@@ -620,6 +649,19 @@ func GetResponseTimestamp(r *http.Response) time.Time {
 	return time.Time{}
 }
 
+// SetResponseSSEHash 标记响应是 Server-Sent Events，事件以 hash 关联到 HTTPFlow
+func SetResponseSSEHash(r *http.Request, hash string) {
+	SetContextValueInfoFromRequest(r, RESPONSE_CONTEXT_KEY_SSEHash, hash)
+}
+
+func GetResponseSSEHash(r *http.Request) string {
+	return GetContextStringInfoFromRequest(r, RESPONSE_CONTEXT_KEY_SSEHash)
+}
+
+func GetResponseIsSSE(r *http.Request) bool {
+	return GetResponseSSEHash(r) != ""
+}
+
 func SetRequestReaderOffset(r *http.Request, offset int) {
 	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ReaderOffset, offset)
 }
@@ -0,0 +1,148 @@
+package lowhttp
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// SSEEvent 是 text/event-stream 中的一个事件
+type SSEEvent struct {
+	Id    string
+	Event string
+	Data  string
+	Retry int
+	// 是否只包含注释(": ping" 之类的心跳)
+	IsComment bool
+}
+
+// IsSSEContentType 判断 Content-Type 是否为 Server-Sent Events
+func IsSSEContentType(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "text/event-stream")
+}
+
+// ParseSSEEvent 解析一个完整的事件块(以空行结尾)
+// 参考 https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func ParseSSEEvent(raw []byte) *SSEEvent {
+	event := &SSEEvent{}
+	var data []string
+	hasField := false
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(string(raw), "\r\n", "\n"), "\r", "\n"), "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		hasField = true
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.Id = value
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if i, err := strconv.Atoi(value); err == nil {
+				event.Retry = i
+			}
+		}
+	}
+	event.Data = strings.Join(data, "\n")
+	event.IsComment = !hasField
+	if event.Event == "" && hasField {
+		event.Event = "message"
+	}
+	return event
+}
+
+// SSEEventMaxBlockSize 是单个事件块的最大长度，超过之后还没有遇到空行的数据会被直接当成一个事件块返回，避免无限缓存
+var SSEEventMaxBlockSize = 1024 * 1024
+
+// SSEEventSplitter 把流式的 text/event-stream 切分成完整的事件块
+type SSEEventSplitter struct {
+	buf []byte
+	// buf 中已经扫描过且确认没有事件结尾的长度
+	scanned int
+}
+
+func NewSSEEventSplitter() *SSEEventSplitter {
+	return &SSEEventSplitter{}
+}
+
+// Feed 写入新读到的数据，返回所有已经完整的事件块(包含结尾的空行)
+func (s *SSEEventSplitter) Feed(b []byte) [][]byte {
+	s.buf = append(s.buf, b...)
+	var events [][]byte
+	for {
+		// 换行符最多三个字节("\r\n\r")还没有办法确定，回退一点重新扫描即可
+		start := s.scanned - 4
+		if start < 0 {
+			start = 0
+		}
+		end := sseEventEnd(s.buf[start:])
+		if end < 0 {
+			if len(s.buf) < SSEEventMaxBlockSize {
+				s.scanned = len(s.buf)
+				return events
+			}
+			end = len(s.buf)
+		} else {
+			end += start
+		}
+		event := make([]byte, end)
+		copy(event, s.buf[:end])
+		events = append(events, event)
+		s.buf = s.buf[end:]
+		s.scanned = 0
+	}
+}
+
+// Rest 返回还没有结束的数据
+func (s *SSEEventSplitter) Rest() []byte {
+	ret := s.buf
+	s.buf = nil
+	s.scanned = 0
+	return ret
+}
+
+// sseEventEnd 返回第一个事件块结束的位置(空行之后)，找不到返回 -1
+func sseEventEnd(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		var lineEnd int
+		switch b[i] {
+		case '\n':
+			lineEnd = i + 1
+		case '\r':
+			if i+1 >= len(b) {
+				// need more data to decide \r or \r\n
+				return -1
+			}
+			if b[i+1] == '\n' {
+				lineEnd = i + 2
+			} else {
+				lineEnd = i + 1
+			}
+		default:
+			continue
+		}
+		// blank line after line end?
+		rest := b[lineEnd:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("\r\n")):
+			return lineEnd + 2
+		case bytes.HasPrefix(rest, []byte("\n")):
+			return lineEnd + 1
+		case bytes.HasPrefix(rest, []byte("\r")):
+			if len(rest) == 1 {
+				return -1
+			}
+			return lineEnd + 1
+		}
+		i = lineEnd - 1
+	}
+	return -1
+}
@@ -0,0 +1,66 @@
+package lowhttp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEEventSplitter(t *testing.T) {
+	splitter := NewSSEEventSplitter()
+	events := splitter.Feed([]byte("id: 1\ndata: a\n"))
+	require.Len(t, events, 0)
+	events = splitter.Feed([]byte("data: b\n\nevent: ping\r\ndata: c\r\n\r\n: comment\n\nretry: 3"))
+	require.Len(t, events, 3)
+	require.Equal(t, "id: 1\ndata: a\ndata: b\n\n", string(events[0]))
+	require.Equal(t, "retry: 3", string(splitter.Rest()))
+
+	e := ParseSSEEvent(events[0])
+	require.Equal(t, "1", e.Id)
+	require.Equal(t, "message", e.Event)
+	require.Equal(t, "a\nb", e.Data)
+
+	e = ParseSSEEvent(events[1])
+	require.Equal(t, "ping", e.Event)
+	require.Equal(t, "c", e.Data)
+
+	require.True(t, ParseSSEEvent(events[2]).IsComment)
+	require.Equal(t, 3, ParseSSEEvent([]byte("retry: 3\n\n")).Retry)
+}
+
+func TestSSEEventSplitterByteByByte(t *testing.T) {
+	raw := "data: a\r\n\r\ndata: b\n\ndata: c\r\rdata: d\n\r\n"
+	splitter := NewSSEEventSplitter()
+	var events []string
+	for i := 0; i < len(raw); i++ {
+		for _, e := range splitter.Feed([]byte{raw[i]}) {
+			events = append(events, string(e))
+		}
+	}
+	require.Equal(t, []string{"data: a\r\n\r\n", "data: b\n\n", "data: c\r\r", "data: d\n\r\n"}, events)
+	require.Len(t, splitter.Rest(), 0)
+}
+
+func TestSSEEventSplitterMaxBlockSize(t *testing.T) {
+	origin := SSEEventMaxBlockSize
+	SSEEventMaxBlockSize = 64
+	defer func() {
+		SSEEventMaxBlockSize = origin
+	}()
+
+	splitter := NewSSEEventSplitter()
+	chunk := []byte("data: 0123456789\n")
+	var events [][]byte
+	for i := 0; i < 5; i++ {
+		events = append(events, splitter.Feed(chunk)...)
+	}
+	require.Len(t, events, 1)
+	require.Equal(t, bytes.Repeat(chunk, 4), events[0])
+	require.Equal(t, chunk, splitter.Rest())
+}
+
+func TestIsSSEContentType(t *testing.T) {
+	require.True(t, IsSSEContentType("text/event-stream; charset=utf-8"))
+	require.False(t, IsSSEContentType("text/html"))
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// Permutation 是 [0, n) 上确定性的伪随机排列：At(i) = (a * i + b) mod n，其中 a 与 n 互质
+//
+// 相同的 n 和 seed 总是得到相同的排列，因此遍历进度只需要保存一个下标，
+// 大规模扫描中断之后可以从下标处继续，不需要保存已经扫描过的目标
+type Permutation struct {
+	n uint64
+	a uint64
+	b uint64
+}
+
+func NewPermutation(n uint64, seed int64) *Permutation {
+	p := &Permutation{n: n, a: 1}
+	if n <= 2 {
+		return p
+	}
+	r := rand.New(rand.NewSource(seed))
+	p.b = r.Uint64() % n
+	// 步长太小的话相邻的下标会落在同一个主机上，没有打散的效果
+	low, width := uint64(1), n-1
+	if n >= 8 {
+		low, width = n/4, n/2
+	}
+	for k := 0; k < 128; k++ {
+		a := low + r.Uint64()%width
+		if a > 1 && gcd(a, n) == 1 {
+			p.a = a
+			break
+		}
+	}
+	return p
+}
+
+func (p *Permutation) Len() uint64 {
+	return p.n
+}
+
+// At 返回排列中第 i 个元素，i 必须小于 Len()
+func (p *Permutation) At(i uint64) uint64 {
+	if p.n == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(p.a, i%p.n)
+	r := bits.Rem64(hi, lo, p.n)
+	if r >= p.n-p.b {
+		return r - (p.n - p.b)
+	}
+	return r + p.b
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermutation(t *testing.T) {
+	for _, n := range []uint64{0, 1, 2, 3, 7, 10, 256, 1000, 65536 * 3} {
+		p := NewPermutation(n, 42)
+		seen := make([]bool, n)
+		for i := uint64(0); i < n; i++ {
+			v := p.At(i)
+			require.Less(t, v, n)
+			require.False(t, seen[v], "n=%v index=%v", n, i)
+			seen[v] = true
+		}
+	}
+
+	// 相同的 seed 得到相同的排列
+	a, b := NewPermutation(65536, 7), NewPermutation(65536, 7)
+	for i := uint64(0); i < 1000; i++ {
+		require.Equal(t, a.At(i), b.At(i))
+	}
+
+	// 排列需要打散，前几个元素不能是连续的
+	c := NewPermutation(65536, 1)
+	require.NotEqual(t, c.At(0)+1, c.At(1))
+}
@@ -0,0 +1,163 @@
+package tlsutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/gmsm/sm2"
+	gmx509 "github.com/yaklang/yaklang/common/gmsm/x509"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// CertificateInfo 是证书中用于资产管理的结构化信息
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`
+	SubjectCN          string    `json:"subject_cn"`
+	Issuer             string    `json:"issuer"`
+	IssuerCN           string    `json:"issuer_cn"`
+	SerialNumber       string    `json:"serial_number"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	DNSNames           []string  `json:"dns_names"`
+	IPAddresses        []string  `json:"ip_addresses"`
+	EmailAddresses     []string  `json:"email_addresses"`
+	IsCA               bool      `json:"is_ca"`
+	SelfSigned         bool      `json:"self_signed"`
+	// SM2 表示国密证书
+	SM2    bool   `json:"sm2"`
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	Raw    []byte `json:"raw"`
+}
+
+// ParseCertificateInfo 从 DER 或者 PEM 格式的证书中提取结构化信息，标准库无法解析的国密 SM2 证书使用 gmsm 解析
+func ParseCertificateInfo(raw []byte) (*CertificateInfo, error) {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	if len(raw) == 0 {
+		return nil, utils.Error("empty certificate")
+	}
+
+	sha1Sum, sha256Sum := sha1.Sum(raw), sha256.Sum256(raw)
+	info := &CertificateInfo{
+		SHA1:   hex.EncodeToString(sha1Sum[:]),
+		SHA256: hex.EncodeToString(sha256Sum[:]),
+		Raw:    raw,
+	}
+	if cert, err := x509.ParseCertificate(raw); err == nil {
+		info.fill(cert.Subject, cert.Issuer, cert.NotBefore, cert.NotAfter, cert.DNSNames, cert.IPAddresses, cert.EmailAddresses)
+		info.SerialNumber = fmt.Sprintf("%X", cert.SerialNumber)
+		info.SignatureAlgorithm = cert.SignatureAlgorithm.String()
+		info.IsCA = cert.IsCA
+		info.KeyType, info.KeyBits = publicKeyType(cert.PublicKey)
+		return info, nil
+	}
+
+	cert, err := gmx509.ParseCertificate(raw)
+	if err != nil {
+		return nil, utils.Errorf("parse certificate failed: %v", err)
+	}
+	info.fill(cert.Subject, cert.Issuer, cert.NotBefore, cert.NotAfter, cert.DNSNames, cert.IPAddresses, cert.EmailAddresses)
+	info.SerialNumber = fmt.Sprintf("%X", cert.SerialNumber)
+	info.SignatureAlgorithm = cert.SignatureAlgorithm.String()
+	info.IsCA = cert.IsCA
+	info.KeyType, info.KeyBits = publicKeyType(cert.PublicKey)
+	info.SM2 = info.KeyType == "SM2" || strings.HasPrefix(info.SignatureAlgorithm, "SM2")
+	return info, nil
+}
+
+func (c *CertificateInfo) fill(subject, issuer pkix.Name, notBefore, notAfter time.Time, dnsNames []string, ips []net.IP, emails []string) {
+	c.Subject = FormatDN(subject)
+	c.SubjectCN = subject.CommonName
+	c.Issuer = FormatDN(issuer)
+	c.IssuerCN = issuer.CommonName
+	c.NotBefore, c.NotAfter = notBefore, notAfter
+	c.DNSNames = dnsNames
+	for _, ip := range ips {
+		c.IPAddresses = append(c.IPAddresses, ip.String())
+	}
+	c.EmailAddresses = emails
+	c.SelfSigned = c.Subject == c.Issuer
+}
+
+func publicKeyType(pub any) (string, int) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		if key.Curve != nil && key.Curve.Params().Name == sm2.P256Sm2().Params().Name {
+			return "SM2", 256
+		}
+		if key.Curve != nil {
+			return "ECDSA-" + key.Curve.Params().Name, key.Curve.Params().BitSize
+		}
+		return "ECDSA", 0
+	case *sm2.PublicKey:
+		return "SM2", 256
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return fmt.Sprintf("%T", pub), 0
+}
+
+// Domains 返回证书中的域名，包括 CN 和 SAN 中的 DNS 名称，去掉通配符前缀并去重，不包含点的名称会被忽略
+func (c *CertificateInfo) Domains() []string {
+	var domains []string
+	for _, name := range append([]string{c.SubjectCN}, c.DNSNames...) {
+		name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "*."))
+		if !strings.Contains(name, ".") || net.ParseIP(name) != nil || !utils.IsValidDomain(name) {
+			continue
+		}
+		domains = append(domains, name)
+	}
+	return utils.RemoveRepeatStringSlice(domains)
+}
+
+// PEM 返回 PEM 格式的证书
+func (c *CertificateInfo) PEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+}
+
+// IsExpired 判断证书在 t 时刻是否已经过期或者还没有生效
+func (c *CertificateInfo) IsExpired(t time.Time) bool {
+	return t.After(c.NotAfter) || t.Before(c.NotBefore)
+}
+
+// String 返回证书的简要描述，用于无法使用 CertificateText 展示的国密证书
+func (c *CertificateInfo) String() string {
+	var buf strings.Builder
+	buf.WriteString("Certificate:\n")
+	buf.WriteString(fmt.Sprintf("    Serial Number: %s\n", c.SerialNumber))
+	buf.WriteString(fmt.Sprintf("    Signature Algorithm: %s\n", c.SignatureAlgorithm))
+	buf.WriteString(fmt.Sprintf("    Issuer: %s\n", c.Issuer))
+	buf.WriteString(fmt.Sprintf("    Validity:\n        Not Before: %s\n        Not After : %s\n",
+		c.NotBefore.UTC().Format(time.RFC1123), c.NotAfter.UTC().Format(time.RFC1123)))
+	buf.WriteString(fmt.Sprintf("    Subject: %s\n", c.Subject))
+	buf.WriteString(fmt.Sprintf("    Public Key Algorithm: %s (%d bit)\n", c.KeyType, c.KeyBits))
+	if len(c.DNSNames) > 0 || len(c.IPAddresses) > 0 {
+		var names []string
+		for _, name := range c.DNSNames {
+			names = append(names, "DNS:"+name)
+		}
+		for _, ip := range c.IPAddresses {
+			names = append(names, "IP Address:"+ip)
+		}
+		buf.WriteString(fmt.Sprintf("    Subject Alternative Name:\n        %s\n", strings.Join(names, ", ")))
+	}
+	return buf.String()
+}
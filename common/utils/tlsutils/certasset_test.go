@@ -0,0 +1,32 @@
+package tlsutils
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCertificateInfo(t *testing.T) {
+	ca, _, err := GenerateSelfSignedCertKeyWithCommonName("yaklang-test", "", []net.IP{net.ParseIP("10.0.0.1")}, []string{"*.example.com", "www.example.com", "example.com"})
+	require.NoError(t, err)
+	info, err := ParseCertificateInfo(ca)
+	require.NoError(t, err)
+	require.Equal(t, "yaklang-test", info.SubjectCN)
+	require.Equal(t, "RSA", info.KeyType)
+	require.Greater(t, info.KeyBits, 1024)
+	require.False(t, info.SM2)
+	require.Contains(t, info.IPAddresses, "10.0.0.1")
+	require.ElementsMatch(t, []string{"example.com", "www.example.com"}, info.Domains())
+	require.Len(t, info.SHA256, 64)
+	require.False(t, info.IsExpired(time.Now()))
+
+	gmCert, _, err := GenerateGMSelfSignedCertKey("yaklang-gm")
+	require.NoError(t, err)
+	gmInfo, err := ParseCertificateInfo(gmCert)
+	require.NoError(t, err)
+	require.True(t, gmInfo.SM2)
+	require.Equal(t, "SM2", gmInfo.KeyType)
+	require.Equal(t, "yaklang-gm", gmInfo.SubjectCN)
+}
@@ -0,0 +1,349 @@
+package tlsutils
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	HandshakeTypeClientHello = 0x01
+	HandshakeTypeServerHello = 0x02
+	HandshakeTypeCertificate = 0x0b
+
+	extensionSupportedGroups     = 10
+	extensionECPointFormats      = 11
+	extensionSignatureAlgorithms = 13
+	extensionSupportedVersions   = 43
+)
+
+// HandshakeMessage 是 TLS 记录层中的一个握手消息
+type HandshakeMessage struct {
+	Type uint8
+	// Raw 包括 4 字节的消息头
+	Raw  []byte
+	Body []byte
+}
+
+// ParseHandshakeMessages 从一段 TCP 载荷中解析出所有完整的握手消息
+// 载荷可以包括多个 TLS 记录，一个记录也可以包括多个握手消息(例如 ServerHello / Certificate / ServerHelloDone)
+// 不完整的记录和非握手记录会被跳过
+func ParseHandshakeMessages(data []byte) []*HandshakeMessage {
+	var handshake []byte
+	for len(data) >= 5 {
+		length := int(binary.BigEndian.Uint16(data[3:5]))
+		if data[1] != 0x03 && !(data[1] == 0x01 && data[2] == 0x01) {
+			break
+		}
+		end := 5 + length
+		if end > len(data) {
+			end = len(data)
+		}
+		if data[0] == 0x16 {
+			handshake = append(handshake, data[5:end]...)
+		}
+		data = data[end:]
+	}
+
+	var msgs []*HandshakeMessage
+	for len(handshake) >= 4 {
+		length := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if 4+length > len(handshake) {
+			break
+		}
+		msgs = append(msgs, &HandshakeMessage{
+			Type: handshake[0],
+			Raw:  handshake[:4+length],
+			Body: handshake[4 : 4+length],
+		})
+		handshake = handshake[4+length:]
+	}
+	return msgs
+}
+
+// IsGREASE 判断是否为 GREASE(RFC 8701) 值，计算 JA3 时需要忽略
+func IsGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func readUint16List(raw []byte) []uint16 {
+	var ret []uint16
+	for i := 0; i+1 < len(raw); i += 2 {
+		ret = append(ret, binary.BigEndian.Uint16(raw[i:i+2]))
+	}
+	return ret
+}
+
+// CipherSuites 返回 ClientHello 中的加密套件
+func (h *HandshakeClientHello) CipherSuites() []uint16 {
+	return readUint16List(h.CipherSuite)
+}
+
+func (h *HandshakeClientHello) extension(typ uint16) *HandshakeClientHelloExt {
+	for _, ext := range h.Extensions {
+		if ext.TypeInt == typ {
+			return ext
+		}
+	}
+	return nil
+}
+
+// ExtensionTypes 返回所有扩展的类型，保持原始顺序
+func (h *HandshakeClientHello) ExtensionTypes() []uint16 {
+	var ret []uint16
+	for _, ext := range h.Extensions {
+		ret = append(ret, ext.TypeInt)
+	}
+	return ret
+}
+
+// SupportedGroups 返回 supported_groups(elliptic_curves) 扩展中的曲线
+func (h *HandshakeClientHello) SupportedGroups() []uint16 {
+	ext := h.extension(extensionSupportedGroups)
+	if ext == nil || len(ext.RawData) < 2 {
+		return nil
+	}
+	return readUint16List(ext.RawData[2:])
+}
+
+// ECPointFormats 返回 ec_point_formats 扩展中的格式
+func (h *HandshakeClientHello) ECPointFormats() []uint8 {
+	ext := h.extension(extensionECPointFormats)
+	if ext == nil || len(ext.RawData) < 1 {
+		return nil
+	}
+	return ext.RawData[1:]
+}
+
+// SignatureAlgorithms 返回 signature_algorithms 扩展中的签名算法，保持原始顺序
+func (h *HandshakeClientHello) SignatureAlgorithms() []uint16 {
+	ext := h.extension(extensionSignatureAlgorithms)
+	if ext == nil || len(ext.RawData) < 2 {
+		return nil
+	}
+	return readUint16List(ext.RawData[2:])
+}
+
+// SupportedVersions 返回 supported_versions 扩展中的版本
+func (h *HandshakeClientHello) SupportedVersions() []uint16 {
+	ext := h.extension(extensionSupportedVersions)
+	if ext == nil || len(ext.RawData) < 1 {
+		return nil
+	}
+	return readUint16List(ext.RawData[1:])
+}
+
+// MaxVersion 返回客户端支持的最高版本，优先使用 supported_versions 扩展
+func (h *HandshakeClientHello) MaxVersion() uint16 {
+	var max uint16
+	for _, v := range h.SupportedVersions() {
+		if !IsGREASE(v) && v > max {
+			max = v
+		}
+	}
+	if max == 0 {
+		return h.Version
+	}
+	return max
+}
+
+// JA3String 计算 JA3 指纹原文: SSLVersion,Ciphers,Extensions,EllipticCurves,EllipticCurvePointFormats
+func (h *HandshakeClientHello) JA3String() string {
+	var points []uint16
+	for _, p := range h.ECPointFormats() {
+		points = append(points, uint16(p))
+	}
+	return strings.Join([]string{
+		fmt.Sprint(h.Version),
+		joinUint16WithoutGREASE(h.CipherSuites()),
+		joinUint16WithoutGREASE(h.ExtensionTypes()),
+		joinUint16WithoutGREASE(h.SupportedGroups()),
+		joinUint16WithoutGREASE(points),
+	}, ",")
+}
+
+func joinUint16WithoutGREASE(list []uint16) string {
+	var items []string
+	for _, v := range list {
+		if IsGREASE(v) {
+			continue
+		}
+		items = append(items, fmt.Sprint(v))
+	}
+	return strings.Join(items, "-")
+}
+
+type HandshakeServerHello struct {
+	Version           uint16
+	Random            []byte
+	Session           []byte
+	CipherSuite       uint16
+	CompressionMethod uint8
+	Extensions        []*HandshakeClientHelloExt
+}
+
+// ParseServerHello 解析 ServerHello，data 可以是完整的 TLS 记录，也可以是握手消息
+func ParseServerHello(data []byte) (*HandshakeServerHello, error) {
+	var body []byte
+	if len(data) > 0 && data[0] == 0x16 {
+		for _, msg := range ParseHandshakeMessages(data) {
+			if msg.Type == HandshakeTypeServerHello {
+				body = msg.Body
+				break
+			}
+		}
+	} else if len(data) >= 4 && data[0] == HandshakeTypeServerHello {
+		body = data[4:]
+	}
+	if len(body) < 2+32+1 {
+		return nil, utils.Error("not a tls handshake server hello")
+	}
+
+	hello := &HandshakeServerHello{}
+	hello.Version = binary.BigEndian.Uint16(body[0:2])
+	hello.Random = body[2:34]
+	sessionLength := int(body[34])
+	body = body[35:]
+	if len(body) < sessionLength+3 {
+		return nil, utils.Error("tls handshake server hello too short")
+	}
+	hello.Session = body[:sessionLength]
+	body = body[sessionLength:]
+	hello.CipherSuite = binary.BigEndian.Uint16(body[0:2])
+	hello.CompressionMethod = body[2]
+	body = body[3:]
+	if len(body) < 2 {
+		return hello, nil
+	}
+	extensions := body[2:]
+	if l := int(binary.BigEndian.Uint16(body[0:2])); l < len(extensions) {
+		extensions = extensions[:l]
+	}
+	for len(extensions) >= 4 {
+		ext := &HandshakeClientHelloExt{
+			TypeRaw: extensions[0:2],
+			TypeInt: binary.BigEndian.Uint16(extensions[0:2]),
+			Length:  binary.BigEndian.Uint16(extensions[2:4]),
+		}
+		end := 4 + int(ext.Length)
+		if end > len(extensions) {
+			break
+		}
+		ext.RawData = extensions[4:end]
+		hello.Extensions = append(hello.Extensions, ext)
+		extensions = extensions[end:]
+	}
+	return hello, nil
+}
+
+// SelectedVersion 返回协商的版本，TLS 1.3 的版本在 supported_versions 扩展中
+func (h *HandshakeServerHello) SelectedVersion() uint16 {
+	for _, ext := range h.Extensions {
+		if ext.TypeInt == extensionSupportedVersions && len(ext.RawData) == 2 {
+			return binary.BigEndian.Uint16(ext.RawData)
+		}
+	}
+	return h.Version
+}
+
+// JA3SString 计算 JA3S 指纹原文: SSLVersion,Cipher,Extensions
+func (h *HandshakeServerHello) JA3SString() string {
+	var exts []uint16
+	for _, ext := range h.Extensions {
+		exts = append(exts, ext.TypeInt)
+	}
+	return strings.Join([]string{
+		fmt.Sprint(h.Version),
+		fmt.Sprint(h.CipherSuite),
+		joinUint16WithoutGREASE(exts),
+	}, ",")
+}
+
+// ParseHandshakeCertificates 从 TLS 载荷中的 Certificate 握手消息解析出证书链，第一个为服务器证书
+// TLS 1.3 的证书是加密的，无法解析
+func ParseHandshakeCertificates(data []byte) ([]*x509.Certificate, error) {
+	for _, msg := range ParseHandshakeMessages(data) {
+		if msg.Type != HandshakeTypeCertificate || len(msg.Body) < 3 {
+			continue
+		}
+		body := msg.Body[3:]
+		var certs []*x509.Certificate
+		for len(body) >= 3 {
+			length := int(body[0])<<16 | int(body[1])<<8 | int(body[2])
+			if 3+length > len(body) {
+				break
+			}
+			cert, err := x509.ParseCertificate(body[3 : 3+length])
+			if err != nil {
+				return certs, utils.Errorf("parse handshake certificate failed: %s", err)
+			}
+			certs = append(certs, cert)
+			body = body[3+length:]
+		}
+		if len(certs) > 0 {
+			return certs, nil
+		}
+	}
+	return nil, utils.Error("no tls handshake certificate found")
+}
+
+// TLSVersionName 返回 TLS 版本的简短名称，例如 1.2 / 1.3
+func TLSVersionName(v uint16) string {
+	switch v {
+	case 0x0300:
+		return "ssl3"
+	case 0x0301:
+		return "1.0"
+	case 0x0302:
+		return "1.1"
+	case 0x0303:
+		return "1.2"
+	case 0x0304:
+		return "1.3"
+	case 0x0101:
+		return "gm1.1"
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+var dnShortNames = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.5":              "serialNumber",
+	"2.5.4.6":              "C",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "ST",
+	"2.5.4.9":              "street",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"2.5.4.17":             "postalCode",
+	"1.2.840.113549.1.9.1": "emailAddress",
+}
+
+// FormatDN 按照 suricata / openssl 单行的格式输出 DN，例如 "C=US, O=Google Trust Services LLC, CN=GTS CA 1C3"
+// 与 pkix.Name.String() 不同，属性按照证书中的顺序排列
+func FormatDN(name pkix.Name) string {
+	// 解析出的证书中 Names 保持了原始顺序
+	attrs := name.Names
+	if len(attrs) == 0 {
+		for _, rdn := range name.ToRDNSequence() {
+			attrs = append(attrs, rdn...)
+		}
+	}
+	var items []string
+	for _, atv := range attrs {
+		items = append(items, fmt.Sprintf("%s=%v", dnAttributeName(atv.Type), atv.Value))
+	}
+	return strings.Join(items, ", ")
+}
+
+func dnAttributeName(oid asn1.ObjectIdentifier) string {
+	if name, ok := dnShortNames[oid.String()]; ok {
+		return name
+	}
+	return oid.String()
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type HandshakeClientHello struct {
+	Version            uint16
 	Random             []byte
 	Session            []byte
 	CipherSuite        []byte
@@ -71,7 +72,7 @@ func (h *HandshakeClientHelloExt) IsALPN() ([]string, bool) {
 		}
 		protos = append(protos, string(rawProto))
 	}
-	return protos, false
+	return protos, true
 }
 
 func (h *HandshakeClientHello) ALPN() []string {
@@ -123,6 +124,9 @@ func (h *HandshakeClientHello) SNI() string {
 // It returns the parsed message and the number of bytes consumed.
 func ParseClientHello(data []byte) (*HandshakeClientHello, error) {
 	var helloInfo []byte
+	if len(data) == 0 {
+		return nil, utils.Error("empty tls handshake client hello")
+	}
 	if data[0] != 0x16 {
 		if data[0] != 0x01 {
 			return nil, utils.Error("not a tls handshake client hello")
@@ -154,7 +158,10 @@ func ParseClientHello(data []byte) (*HandshakeClientHello, error) {
 	}
 	utils.ReadN(buf, 3) // total len
 
-	utils.ReadN(buf, 2)                    // version
+	versionRaw, _ := utils.ReadN(buf, 2) // version
+	if len(versionRaw) == 2 {
+		hello.Version = binary.BigEndian.Uint16(versionRaw)
+	}
 	hello.Random, _ = utils.ReadN(buf, 32) // random
 
 	// parse session
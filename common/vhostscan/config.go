@@ -0,0 +1,172 @@
+package vhostscan
+
+import (
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// Hosts 候选的完整域名，例如子域名扫描的结果
+	Hosts []string
+	// Words 与 Domains 组合成 word.domain 作为候选域名
+	Words   []string
+	Domains []string
+
+	// Path 请求的路径，默认为 /
+	Path string
+	// SNI HTTPS 请求时把 SNI 也设置为候选域名，部分反向代理根据 SNI 选择证书和后端
+	SNI bool
+	// BaselineCount 随机生成的不存在的域名数量，用来确定默认虚拟主机的响应
+	BaselineCount int
+
+	Concurrent int
+	Timeout    time.Duration
+	Proxy      []string
+
+	// Fingerprint 对发现的虚拟主机进行 Web 指纹识别
+	Fingerprint bool
+
+	// SaveToDB 把发现的虚拟主机的请求保存到 HTTP 流量中（网站树）
+	SaveToDB  bool
+	RuntimeID string
+}
+
+type ConfigOption func(config *Config)
+
+func NewConfig(options ...ConfigOption) *Config {
+	config := &Config{
+		Path:          "/",
+		SNI:           true,
+		BaselineCount: 3,
+		Concurrent:    20,
+		Timeout:       10 * time.Second,
+		Fingerprint:   true,
+		SaveToDB:      true,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if !strings.HasPrefix(config.Path, "/") {
+		config.Path = "/" + config.Path
+	}
+	if config.BaselineCount <= 0 {
+		config.BaselineCount = 1
+	}
+	if config.Concurrent <= 0 {
+		config.Concurrent = 20
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return config
+}
+
+// Candidates 返回去重之后的候选域名
+func (c *Config) Candidates() []string {
+	var candidates []string
+	seen := make(map[string]struct{})
+	add := func(host string) {
+		host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host == "" {
+			return
+		}
+		if _, ok := seen[host]; ok {
+			return
+		}
+		seen[host] = struct{}{}
+		candidates = append(candidates, host)
+	}
+
+	for _, host := range c.Hosts {
+		add(host)
+	}
+	for _, domain := range c.Domains {
+		domain = strings.Trim(strings.TrimSpace(domain), ".")
+		if domain == "" {
+			continue
+		}
+		add(domain)
+		for _, word := range c.Words {
+			if word = strings.Trim(strings.TrimSpace(word), "."); word != "" {
+				add(word + "." + domain)
+			}
+		}
+	}
+	return candidates
+}
+
+func WithHosts(hosts ...string) ConfigOption {
+	return func(config *Config) {
+		config.Hosts = append(config.Hosts, hosts...)
+	}
+}
+
+func WithWords(words ...string) ConfigOption {
+	return func(config *Config) {
+		config.Words = append(config.Words, words...)
+	}
+}
+
+func WithDomains(domains ...string) ConfigOption {
+	return func(config *Config) {
+		config.Domains = append(config.Domains, domains...)
+	}
+}
+
+func WithPath(path string) ConfigOption {
+	return func(config *Config) {
+		config.Path = path
+	}
+}
+
+func WithSNI(b bool) ConfigOption {
+	return func(config *Config) {
+		config.SNI = b
+	}
+}
+
+func WithBaselineCount(count int) ConfigOption {
+	return func(config *Config) {
+		config.BaselineCount = count
+	}
+}
+
+func WithConcurrent(count int) ConfigOption {
+	return func(config *Config) {
+		config.Concurrent = count
+	}
+}
+
+func WithTimeout(timeout time.Duration) ConfigOption {
+	return func(config *Config) {
+		config.Timeout = timeout
+	}
+}
+
+func WithProxy(proxy ...string) ConfigOption {
+	return func(config *Config) {
+		config.Proxy = proxy
+	}
+}
+
+func WithFingerprint(b bool) ConfigOption {
+	return func(config *Config) {
+		config.Fingerprint = b
+	}
+}
+
+func WithSaveToDB(b bool) ConfigOption {
+	return func(config *Config) {
+		config.SaveToDB = b
+	}
+}
+
+func WithRuntimeID(id string) ConfigOption {
+	return func(config *Config) {
+		config.RuntimeID = id
+	}
+}
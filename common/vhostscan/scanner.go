@@ -0,0 +1,286 @@
+package vhostscan
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/fp/webfingerprint"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+type VHostResult struct {
+	// Target 是实际连接的地址，Host 是发现的虚拟主机
+	Target string
+	Host   string
+	Https  bool
+	URL    string
+
+	StatusCode   int
+	Title        string
+	BodyLength   int
+	Location     string
+	Fingerprints []string
+
+	RequestRaw  []byte
+	ResponseRaw []byte
+}
+
+func (r *VHostResult) String() string {
+	return fmt.Sprintf("%v -> %v [%v] title: %v length: %v fingerprint: %v", r.Target, r.URL, r.StatusCode, r.Title, r.BodyLength, strings.Join(r.Fingerprints, ", "))
+}
+
+// responseSignature 是响应中和虚拟主机相关的特征，响应中出现的请求域名会被替换掉
+type responseSignature struct {
+	StatusCode int
+	Title      string
+	Location   string
+	BodyLength int
+	BodyHash   string
+}
+
+func newResponseSignature(host string, rsp []byte) *responseSignature {
+	fixed, _, _ := lowhttp.FixHTTPResponse(rsp)
+	if len(fixed) > 0 {
+		rsp = fixed
+	}
+	hostRe := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(host))
+	body := hostRe.ReplaceAll(lowhttp.GetHTTPPacketBody(rsp), []byte("{{host}}"))
+	hash := md5.Sum(body)
+	return &responseSignature{
+		StatusCode: lowhttp.GetStatusCodeFromResponse(rsp),
+		Title:      utils.ExtractTitleFromHTMLTitle(string(body), ""),
+		Location:   hostRe.ReplaceAllString(lowhttp.GetHTTPPacketHeader(rsp, "Location"), "{{host}}"),
+		BodyLength: len(body),
+		BodyHash:   hex.EncodeToString(hash[:]),
+	}
+}
+
+// similar 判断两个响应是否来自同一个虚拟主机，标题相同且长度相差不超过 5% 时认为是动态内容
+func (s *responseSignature) similar(other *responseSignature) bool {
+	if s.StatusCode != other.StatusCode || s.Location != other.Location {
+		return false
+	}
+	if s.BodyHash == other.BodyHash {
+		return true
+	}
+	if s.Title != other.Title {
+		return false
+	}
+	diff := s.BodyLength - other.BodyLength
+	if diff < 0 {
+		diff = -diff
+	}
+	max := s.BodyLength
+	if other.BodyLength > max {
+		max = other.BodyLength
+	}
+	return diff <= 32 || diff*20 <= max
+}
+
+var (
+	defaultMatcher     *webfingerprint.Matcher
+	defaultMatcherOnce sync.Once
+)
+
+func fingerprint(req []byte, rsp []byte, isHttps bool) []string {
+	defaultMatcherOnce.Do(func() {
+		rules, err := fp.GetDefaultWebFingerprintRules()
+		if err != nil {
+			log.Errorf("get web fingerprint rules failed: %s", err)
+			return
+		}
+		defaultMatcher, _ = webfingerprint.NewWebFingerprintMatcher(rules, false, true)
+	})
+	if defaultMatcher == nil {
+		return nil
+	}
+
+	rspIns, err := lowhttp.ParseBytesToHTTPResponse(rsp)
+	if err != nil {
+		return nil
+	}
+	u, _ := lowhttp.ExtractURLFromHTTPRequestRaw(req, isHttps)
+	cpes, err := defaultMatcher.Match(&webfingerprint.HTTPResponseInfo{
+		StatusCode: rspIns.StatusCode,
+		Status:     rspIns.Status,
+		Header:     &rspIns.Header,
+		Body:       lowhttp.GetHTTPPacketBody(rsp),
+		URL:        u,
+		RequestRaw: req,
+		IsHttps:    isHttps,
+	})
+	if err != nil {
+		return nil
+	}
+	var fingerprints []string
+	for _, cpe := range cpes {
+		fingerprints = append(fingerprints, cpe.String())
+	}
+	return fingerprints
+}
+
+type scanner struct {
+	ctx    context.Context
+	config *Config
+
+	host  string
+	port  int
+	https bool
+}
+
+// parseTarget 解析目标，支持 http(s)://ip:port 和 ip:port，没有协议时自动检测 TLS
+func parseTarget(target string, proxy ...string) (string, int, bool, error) {
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", 0, false, utils.Errorf("parse target %s failed: %s", target, err)
+		}
+		host, port, err := utils.ParseStringToHostPort(target)
+		if err != nil || port <= 0 {
+			return "", 0, false, utils.Errorf("parse target %s failed: unknown port", target)
+		}
+		return host, port, u.Scheme == "https", nil
+	}
+
+	host, port, err := utils.ParseStringToHostPort(target)
+	if err != nil {
+		host, port = target, 80
+	}
+	return host, port, netx.IsTLSService(utils.HostPort(host, port), proxy...), nil
+}
+
+func (s *scanner) request(host string) (*lowhttp.LowhttpResponse, error) {
+	packet := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUser-Agent: Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36\r\nAccept: */*\r\n\r\n", s.config.Path, host)
+	opts := []lowhttp.LowhttpOpt{
+		lowhttp.WithContext(s.ctx),
+		lowhttp.WithPacketBytes([]byte(packet)),
+		lowhttp.WithHttps(s.https),
+		lowhttp.WithHost(s.host),
+		lowhttp.WithPort(s.port),
+		lowhttp.WithTimeout(s.config.Timeout),
+		lowhttp.WithRedirectTimes(0),
+		lowhttp.WithSaveHTTPFlow(false),
+		lowhttp.WithSource("vhostscan"),
+		lowhttp.WithRuntimeId(s.config.RuntimeID),
+	}
+	if len(s.config.Proxy) > 0 {
+		opts = append(opts, lowhttp.WithProxy(s.config.Proxy...))
+	}
+	if s.https && s.config.SNI && !utils.IsIPv4(host) && !utils.IsIPv6(host) {
+		opts = append(opts, lowhttp.WithSNI(host))
+	}
+	return lowhttp.HTTPWithoutRedirect(opts...)
+}
+
+// baseline 请求目标地址本身和随机域名，得到默认虚拟主机的响应特征
+func (s *scanner) baseline() []*responseSignature {
+	hosts := []string{utils.HostPort(s.host, s.port)}
+	for i := 0; i < s.config.BaselineCount; i++ {
+		suffix := "invalid"
+		if len(s.config.Domains) > 0 {
+			suffix = strings.Trim(s.config.Domains[i%len(s.config.Domains)], ".")
+		}
+		hosts = append(hosts, strings.ToLower(utils.RandStringBytes(12))+"."+suffix)
+	}
+
+	var signatures []*responseSignature
+	for _, host := range hosts {
+		rsp, err := s.request(host)
+		if err != nil {
+			log.Debugf("vhost baseline %s for %s failed: %s", host, utils.HostPort(s.host, s.port), err)
+			continue
+		}
+		signatures = append(signatures, newResponseSignature(host, rsp.RawPacket))
+	}
+	return signatures
+}
+
+// Scan 对 target（ip:port 或 URL）进行虚拟主机发现，只返回与默认虚拟主机响应不同的候选域名
+func Scan(ctx context.Context, target string, config *Config) (chan *VHostResult, error) {
+	host, port, isHttps, err := parseTarget(target, config.Proxy...)
+	if err != nil {
+		return nil, err
+	}
+	s := &scanner{ctx: ctx, config: config, host: host, port: port, https: isHttps}
+
+	baselines := s.baseline()
+	if len(baselines) <= 0 {
+		return nil, utils.Errorf("vhost scan %s failed: target cannot be reached", utils.HostPort(host, port))
+	}
+	candidates := config.Candidates()
+	log.Infof("start to check %v virtual hosts for %s with %v baselines", len(candidates), utils.HostPort(host, port), len(baselines))
+
+	ch := make(chan *VHostResult)
+	go func() {
+		defer close(ch)
+
+		var (
+			lock     sync.Mutex
+			reported = baselines
+			swg      = utils.NewSizedWaitGroup(config.Concurrent)
+		)
+		for _, candidate := range candidates {
+			if err := swg.AddWithContext(ctx); err != nil {
+				break
+			}
+			go func(vhost string) {
+				defer swg.Done()
+
+				rsp, err := s.request(vhost)
+				if err != nil {
+					log.Debugf("vhost %s for %s failed: %s", vhost, utils.HostPort(host, port), err)
+					return
+				}
+				signature := newResponseSignature(vhost, rsp.RawPacket)
+
+				// 与默认虚拟主机或者已经发现的虚拟主机相同的响应不再报告
+				lock.Lock()
+				for _, other := range reported {
+					if signature.similar(other) {
+						lock.Unlock()
+						return
+					}
+				}
+				reported = append(reported, signature)
+				lock.Unlock()
+
+				result := &VHostResult{
+					Target:      utils.HostPort(host, port),
+					Host:        vhost,
+					Https:       isHttps,
+					URL:         rsp.Url,
+					StatusCode:  signature.StatusCode,
+					Title:       signature.Title,
+					BodyLength:  signature.BodyLength,
+					Location:    lowhttp.GetHTTPPacketHeader(rsp.RawPacket, "Location"),
+					RequestRaw:  rsp.RawRequest,
+					ResponseRaw: rsp.RawPacket,
+				}
+				if config.Fingerprint {
+					result.Fingerprints = fingerprint(rsp.RawRequest, rsp.RawPacket, isHttps)
+				}
+				if config.SaveToDB {
+					lowhttp.SaveResponse(rsp)
+				}
+				log.Infof("found virtual host %s on %s: [%v] %v", vhost, result.Target, result.StatusCode, result.Title)
+
+				select {
+				case ch <- result:
+				case <-ctx.Done():
+				}
+			}(candidate)
+		}
+		swg.Wait()
+	}()
+	return ch, nil
+}
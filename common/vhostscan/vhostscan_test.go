@@ -0,0 +1,85 @@
+package vhostscan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testVHostHandler(w http.ResponseWriter, r *http.Request) {
+	host := strings.ToLower(r.Host)
+	switch host {
+	case "admin.corp.local", "alias.corp.local":
+		fmt.Fprint(w, "<html><title>Admin Panel</title><body>login</body></html>")
+	case "dev.corp.local":
+		http.Redirect(w, r, "https://dev.corp.local/login", http.StatusFound)
+	case "secure.corp.local":
+		if r.TLS != nil && r.TLS.ServerName == host {
+			fmt.Fprint(w, "<html><title>Secure App</title></html>")
+			return
+		}
+		fallthrough
+	default:
+		// 默认虚拟主机会回显请求的域名，并且带有动态内容
+		fmt.Fprintf(w, "<html><title>Welcome to nginx!</title><body>%s %d</body></html>", host, time.Now().UnixNano()%1000)
+	}
+}
+
+func runTestVHostScan(t *testing.T, target string, options ...ConfigOption) []string {
+	config := NewConfig(append([]ConfigOption{
+		WithDomains("corp.local"),
+		WithWords("www", "admin", "alias", "dev", "nope"),
+		WithHosts("secure.corp.local"),
+		WithConcurrent(1),
+		WithFingerprint(false),
+		WithTimeout(5 * time.Second),
+	}, options...)...)
+	ch, err := Scan(context.Background(), target, config)
+	require.NoError(t, err)
+
+	var found []string
+	for result := range ch {
+		found = append(found, fmt.Sprintf("%s:%d:%s", result.Host, result.StatusCode, result.Title))
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestVHostScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(testVHostHandler))
+	defer server.Close()
+
+	require.Equal(t, []string{
+		"admin.corp.local:200:Admin Panel",
+		"dev.corp.local:302:",
+	}, runTestVHostScan(t, server.URL))
+}
+
+func TestVHostScanSNI(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(testVHostHandler))
+	defer server.Close()
+	target := strings.TrimPrefix(server.URL, "https://")
+
+	require.Equal(t, []string{
+		"admin.corp.local:200:Admin Panel",
+		"dev.corp.local:302:",
+		"secure.corp.local:200:Secure App",
+	}, runTestVHostScan(t, target))
+
+	require.Equal(t, []string{
+		"admin.corp.local:200:Admin Panel",
+		"dev.corp.local:302:",
+	}, runTestVHostScan(t, target, WithSNI(false)))
+}
+
+func TestConfigCandidates(t *testing.T) {
+	config := NewConfig(WithHosts("A.example.com.", "a.example.com"), WithDomains("example.com"), WithWords("a", "b"))
+	require.Equal(t, []string{"a.example.com", "example.com", "b.example.com"}, config.Candidates())
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/mutate"
 	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/pcapx/passivefp"
 	"github.com/yaklang/yaklang/common/rpa"
 	"github.com/yaklang/yaklang/common/sca"
 	"github.com/yaklang/yaklang/common/simulator"
@@ -165,12 +166,18 @@ func initYaklangLib() {
 	yaklang.Import("synscan", tools.SynPortScanExports)
 	yaklang.Import("finscan", tools.FinPortScanExports)
 
+	// UDP 扫描库
+	yaklang.Import("udpscan", tools.UdpPortScanExports)
+
 	// 指纹扫描库
 	yaklang.Import("servicescan", tools.FingerprintScanExports)
 
 	// 子域名扫描库
 	yaklang.Import("subdomain", tools.SubDomainExports)
 
+	// 虚拟主机发现
+	yaklang.Import("vhostscan", tools.VHostScanExports)
+
 	// 执行系统命令的库
 	yaklang.Import("exec", yaklib.ExecExports)
 
@@ -279,6 +286,7 @@ func initYaklangLib() {
 	// suricata
 	yaklang.Import("suricata", chaosmaker.ChaosMakerExports)
 	yaklang.Import("pcapx", pcapx.Exports)
+	yaklang.Import("passivefp", passivefp.Exports)
 
 	// ja3
 	yaklang.Import("ja3", ja3.Exports)
@@ -28,6 +28,7 @@ var MitmExports = map[string]interface{}{
 	"wsforcetext":          mitmConfigWSForceTextFrame,
 	"rootCA":               mitmConfigCertAndKey,
 	"useDefaultCA":         mitmConfigUseDefault,
+	"invisibleListener":    mitmConfigInvisibleListener,
 }
 
 // Start 启动一个 MITM (中间人)代理服务器，它的第一个参数是端口，接下来可以接收零个到多个选项函数，用于影响中间人代理服务器的行为
@@ -53,6 +54,9 @@ type mitmConfig struct {
 	useDefaultMitmCert bool
 	maxContentLength   int
 
+	// 额外的 invisible 监听端口
+	invisibleListeners []*crep.InvisibleListener
+
 	// 是否开启透明劫持
 	isTransparent            bool
 	hijackRequest            func(isHttps bool, urlStr string, req []byte, forward func([]byte), reject func())
@@ -223,6 +227,23 @@ func mitmMaxContentLength(i int) MitmConfigOpt {
 	}
 }
 
+// invisibleListener 是一个选项函数，用于额外监听一个 invisible(非代理) 端口，给不支持设置代理的客户端使用，可以多次使用监听多个端口
+// 客户端把这个端口当作服务器直接连接，自动识别 TLS 与明文 HTTP，流量与代理流量一样会经过劫持与回调
+// 第二个参数为上游目标(host:port)，为空时根据 SNI 与 Host 决定上游目标
+// Example:
+// ```
+// mitm.Start(8080, mitm.invisibleListener("127.0.0.1:8443", "example.com:443"), mitm.invisibleListener("127.0.0.1:8081"))
+// ```
+func mitmConfigInvisibleListener(addr string, target ...string) MitmConfigOpt {
+	return func(config *mitmConfig) {
+		var t string
+		if len(target) > 0 {
+			t = target[0]
+		}
+		config.invisibleListeners = append(config.invisibleListeners, &crep.InvisibleListener{Addr: addr, Target: t})
+	}
+}
+
 // Bridge 启动一个 MITM (中间人)代理服务器，它的第一个参数是端口，第二个参数是下游代理服务器地址，接下来可以接收零个到多个选项函数，用于影响中间人代理服务器的行为
 // Bridge 与 Start 类似，但略有不同，Bridge可以指定下游代理服务器地址，同时默认会在接收到请求和响应时打印到标准输出
 // 如果没有指定 CA 证书和私钥，那么将使用内置的证书和私钥
@@ -451,6 +472,11 @@ func startBridge(
 	if err != nil {
 		return utils.Errorf("create mitm server failed: %s", err)
 	}
+	for _, l := range config.invisibleListeners {
+		if err := crep.MITM_AddInvisibleListener(l.Addr, l.Target)(server); err != nil {
+			return utils.Errorf("add invisible listener failed: %s", err)
+		}
+	}
 	err = server.Serve(config.ctx, utils.HostPort(config.host, port))
 	if err != nil {
 		log.Errorf("server mitm failed: %s", err)
@@ -29,6 +29,9 @@ var BruterExports = map[string]interface{}{
 	"bruteHandler":       yakBruteOpt_coreHandler,
 	"okToStop":           yakBruteOpt_OkToStop,
 	"finishingThreshold": yakBruteOpt_FinishingThreshold,
+	"spray":              yakBruteOpt_spray,
+	"lockoutPolicy":      yakBruteOpt_lockoutPolicy,
+	"ledger":             yakBruteOpt_ledger,
 }
 
 type yakBruter struct {
@@ -60,6 +63,11 @@ type yakBruter struct {
 
 	// 完成阈值
 	finishingThreshold int
+
+	// 密码喷洒与锁定策略，ledger 不为空时尝试记录保存在项目数据库中
+	spray         bool
+	lockoutPolicy *bruteutils.LockoutPolicy
+	ledger        string
 }
 
 type yakBruteOpt func(bruter *yakBruter)
@@ -82,6 +90,52 @@ func yakBruteOpt_FinishingThreshold(i int) yakBruteOpt {
 	}
 }
 
+// spray 是一个请求选项，开启密码喷洒：先用同一个密码尝试所有用户，再换下一个密码
+// 每个用户在观察窗口内的尝试次数不会超过锁定阈值，没有设置 lockoutPolicy 时使用爆破类型的默认策略
+// @param {bool} b 是否开启密码喷洒
+// @return {yakBruteOpt} 返回请求选项
+// Example:
+// ```
+// bruter = brute.New("ldap", brute.spray(true), brute.userList("alice", "bob"), brute.passList("Winter2024!", "Spring2024!"))~
+// ```
+func yakBruteOpt_spray(b bool) yakBruteOpt {
+	return func(bruter *yakBruter) {
+		bruter.spray = b
+	}
+}
+
+// lockoutPolicy 是一个请求选项，设置账户锁定策略：每个用户在 windowSeconds 秒内最多尝试 threshold 次
+// 出现账户锁定迹象时会暂停对该目标的爆破 windowSeconds 秒，threshold 应当小于目标实际的锁定阈值
+// @param {int} threshold 观察窗口内每个用户最多尝试的次数
+// @param {float} windowSeconds 观察窗口，单位为秒
+// @return {yakBruteOpt} 返回请求选项
+// Example:
+// ```
+// bruter = brute.New("smb", brute.lockoutPolicy(4, 1800))~
+// ```
+func yakBruteOpt_lockoutPolicy(threshold int, windowSeconds float64) yakBruteOpt {
+	return func(bruter *yakBruter) {
+		bruter.lockoutPolicy = &bruteutils.LockoutPolicy{
+			Threshold: threshold,
+			Window:    utils.FloatSecondDuration(windowSeconds),
+		}
+	}
+}
+
+// ledger 是一个请求选项，把每个用户的尝试记录以 name 保存到项目数据库中
+// 使用相同 name 重新运行时会读取之前的记录，保证跨越多次运行的喷洒仍然遵守锁定策略
+// @param {string} name 尝试记录的名称
+// @return {yakBruteOpt} 返回请求选项
+// Example:
+// ```
+// bruter = brute.New("ldap", brute.spray(true), brute.ledger("corp-spray"))~
+// ```
+func yakBruteOpt_ledger(name string) yakBruteOpt {
+	return func(bruter *yakBruter) {
+		bruter.ledger = name
+	}
+}
+
 func yakBruteOpt_ConcurrentTarget(c int) yakBruteOpt {
 	return func(bruter *yakBruter) {
 		bruter.concurrentTarget = c
@@ -141,21 +195,42 @@ func (y *yakBruter) Start(targets ...string) (chan *bruteutils.BruteItemResult,
 		return nil, utils.Errorf("empty targets for %v", y.bruteType)
 	}
 
-	bruter, err := bruteutils.NewMultiTargetBruteUtilEx(
+	options := []bruteutils.OptionsAction{
 		bruteutils.WithBruteCallback(y.coreHandler),
 		bruteutils.WithTargetsConcurrent(y.concurrentTarget),
 		bruteutils.WithTargetTasksConcurrent(y.concurrent),
 		bruteutils.WithOkToStop(y.okToStop),
 		bruteutils.WithFinishingThreshold(y.finishingThreshold),
 		action,
-	)
+	}
+	closeLedger := func() {}
+	if y.spray || y.lockoutPolicy != nil || y.ledger != "" {
+		policy := y.lockoutPolicy
+		if policy == nil {
+			policy = bruteutils.GetLockoutPolicy(y.bruteType)
+		}
+		if y.spray {
+			options = append(options, bruteutils.WithPasswordSpray(policy))
+		} else {
+			options = append(options, bruteutils.WithLockoutPolicy(policy))
+		}
+		if y.ledger != "" {
+			var ledger *bruteutils.AttemptLedger
+			ledger, closeLedger = loadBruteAttemptLedger(y.ledger)
+			options = append(options, bruteutils.WithAttemptLedger(ledger))
+		}
+	}
+
+	bruter, err := bruteutils.NewMultiTargetBruteUtilEx(options...)
 	if err != nil {
+		closeLedger()
 		return nil, utils.Errorf("create core bruter[%v] failed: %s", y.bruteType, err.Error())
 	}
 
 	ch := make(chan *bruteutils.BruteItemResult, 100)
 	go func() {
 		defer close(ch)
+		defer closeLedger()
 
 		if funk.IsEmpty(y.userList) {
 			y.userList = []string{""}
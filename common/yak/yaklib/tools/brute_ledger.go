@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils/bruteutils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+// 爆破尝试记录保存在项目数据库的 kv 中，key 为 brute-ledger-<name>
+const bruteAttemptLedgerGroup = "BRUTE_ATTEMPT_LEDGER"
+
+// bruteAttemptLedgerFlushInterval 是尝试记录写回数据库的间隔，每次尝试都写库会拖慢爆破
+var bruteAttemptLedgerFlushInterval = 3 * time.Second
+
+func bruteAttemptLedgerKey(name string) string {
+	return "brute-ledger-" + name
+}
+
+// loadBruteAttemptLedger 读取 name 对应的尝试记录，记录变化后定时写回项目数据库
+// 返回的 closer 停止定时写入并把剩余的变化写回，爆破结束后必须调用
+func loadBruteAttemptLedger(name string) (*bruteutils.AttemptLedger, func()) {
+	ledger := bruteutils.NewAttemptLedger()
+	db := consts.GetGormProjectDatabase()
+	if raw, err := yakit.GetProjectKeyWithError(db, bruteAttemptLedgerKey(name)); err == nil && raw != "" {
+		if saved, err := bruteutils.ParseAttemptLedger([]byte(raw)); err != nil {
+			log.Errorf("load brute attempt ledger %v failed: %v", name, err)
+		} else {
+			ledger = saved
+		}
+	}
+
+	var dirty int32
+	ledger.OnSave = func(l *bruteutils.AttemptLedger) {
+		atomic.StoreInt32(&dirty, 1)
+	}
+	flush := func() {
+		if !atomic.CompareAndSwapInt32(&dirty, 1, 0) {
+			return
+		}
+		raw, err := ledger.Bytes()
+		if err != nil {
+			log.Errorf("marshal brute attempt ledger %v failed: %v", name, err)
+			return
+		}
+		if err := yakit.SetProjectKeyWithGroup(db, bruteAttemptLedgerKey(name), string(raw), bruteAttemptLedgerGroup); err != nil {
+			log.Errorf("save brute attempt ledger %v failed: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(bruteAttemptLedgerFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				flush()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ledger, func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			flush()
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bruteutils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+func TestBruteAttemptLedgerFlush(t *testing.T) {
+	name := utils.RandStringBytes(16)
+	db := consts.GetGormProjectDatabase()
+	policy := &bruteutils.LockoutPolicy{Threshold: 10, Window: time.Minute}
+
+	ledger, closeLedger := loadBruteAttemptLedger(name)
+	for i := 0; i < 5; i++ {
+		require.Zero(t, ledger.Acquire("127.0.0.1:445", "alice", policy, time.Now()))
+	}
+	// 尝试记录不会在每次尝试后立即写库
+	require.Empty(t, yakit.GetProjectKey(db, bruteAttemptLedgerKey(name)))
+
+	closeLedger()
+	closeLedger()
+	restored, closeRestored := loadBruteAttemptLedger(name)
+	defer closeRestored()
+	require.Equal(t, 5, restored.Count("127.0.0.1:445", "alice", policy.Window, time.Now()))
+}
@@ -471,4 +471,7 @@ var FingerprintScanExports = map[string]interface{}{
 
 	// 全部服务扫描
 	"all": _allOption,
+
+	// 启用 TLS 服务端指纹，每个 TLS 端口会额外发送 10 次握手
+	"tlsServerFingerprint": fp.WithEnableTLSServerFingerprint,
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/yaklang/yaklang/common/finscan"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/synscan"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/hostsparser"
 )
@@ -36,6 +37,12 @@ type _yakFinPortScanConfig struct {
 
 	callback           func(result *finscan.FinScanResult)
 	submitTaskCallback func(i string)
+
+	// checkpointTask 不为空时按照确定性排列发包，并把进度保存到项目数据库中
+	checkpointTask    string
+	checkpointTargets string
+	checkpointOrigin  [2]string
+	resumeCheckpoint  *synscan.Checkpoint
 }
 type finScanOpt func(config *_yakFinPortScanConfig)
 
@@ -271,35 +278,51 @@ func _finscanDo(targetChan chan string, ports string, config *_yakFinPortScanCon
 
 		ports = utils.ConcatPorts(portInts)
 
-		for target := range newTargetChan {
-			if config.IsFiltered(target, 0) {
-				continue
+		if config.checkpointTask != "" {
+			cp, err := prepareCheckpoint(
+				"finscan", config.checkpointTask, config.resumeCheckpoint,
+				config.checkpointTargets, newTargetChan, ports, config.checkpointOrigin, config.IsFiltered, hostsFilter, portsFilter,
+			)
+			if err != nil {
+				log.Errorf("prepare finscan checkpoint failed: %s", err)
+				return
 			}
-
-			log.Infof("start to submit finscan for %s ports: %v", target, ports)
-			// 默认的整体 target 一定要包含进去
-			hostsFilter.Add(target)
-			if !utils.IsIPv4(target) {
-				hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
+			err = scanner.ScanWithCheckpoint(cp, savePortScanCheckpoint("finscan", config.checkpointTask))
+			if err != nil {
+				log.Errorf("finscan with checkpoint failed: %s", err)
+				return
 			}
+		} else {
+			for target := range newTargetChan {
+				if config.IsFiltered(target, 0) {
+					continue
+				}
 
-			hostRaw, portRaw, _ := utils.ParseStringToHostPort(target)
-			if portRaw > 0 {
-				// 如果 host 可以解析出端口的话，就需要额外增加 host 的解析
-				portsFilter.Add(fmt.Sprint(portRaw))
-				hostsFilter.Add(hostRaw)
-				if !utils.IsIPv4(hostRaw) {
-					hostsFilter.Add(netx.LookupAll(hostRaw, netx.WithTimeout(5*time.Second))...)
+				log.Infof("start to submit finscan for %s ports: %v", target, ports)
+				// 默认的整体 target 一定要包含进去
+				hostsFilter.Add(target)
+				if !utils.IsIPv4(target) {
+					hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
+				}
+
+				hostRaw, portRaw, _ := utils.ParseStringToHostPort(target)
+				if portRaw > 0 {
+					// 如果 host 可以解析出端口的话，就需要额外增加 host 的解析
+					portsFilter.Add(fmt.Sprint(portRaw))
+					hostsFilter.Add(hostRaw)
+					if !utils.IsIPv4(hostRaw) {
+						hostsFilter.Add(netx.LookupAll(hostRaw, netx.WithTimeout(5*time.Second))...)
+					}
+					_ = scanner.RandomScan(hostRaw, fmt.Sprint(portRaw), true)
+				}
+				err = scanner.RandomScan(target, ports, true)
+				if err != nil {
+					log.Errorf("submit finscan failed: %s", err)
+					return
 				}
-				_ = scanner.RandomScan(hostRaw, fmt.Sprint(portRaw), true)
-			}
-			err = scanner.RandomScan(target, ports, true)
-			if err != nil {
-				log.Errorf("submit finscan failed: %s", err)
-				return
 			}
+			scanner.WaitChannelEmpty()
 		}
-		scanner.WaitChannelEmpty()
 		log.Infof("finished submitting.")
 
 		log.Infof("waiting last packet (fin) for %v seconds", config.waiting) // waiting remote rsp half RTT
@@ -310,33 +333,110 @@ func _finscanDo(targetChan chan string, ports string, config *_yakFinPortScanCon
 	return closeResult, nil
 }
 
+func getDefaultFinPortScanConfig() *_yakFinPortScanConfig {
+	return &_yakFinPortScanConfig{
+		waiting:           10 * time.Second,
+		rateLimitDelayMs:  1,
+		rateLimitDelayGap: 5,
+	}
+}
+
+// checkpoint fin scan 的配置选项，按照确定性的随机顺序发包，并定期把扫描进度保存到项目数据库中
+// 相同任务 ID 的扫描中断之后，使用相同的目标和端口再次扫描（或者使用 finscan.Resume）会从中断的位置继续
+// @param {string} taskId 任务 ID
+// @return {finScanOpt} 返回配置选项
+// Example:
+// ```
+// res, err = finscan.Scan("10.0.0.0/16", "1-1000", finscan.checkpoint("fin-10.0.0.0/16"))
+// die(err)
+// ```
+func _finScanOptCheckpoint(taskId string) finScanOpt {
+	return func(config *_yakFinPortScanConfig) {
+		config.checkpointTask = taskId
+	}
+}
+
+// checkpointOrigin fin scan 的配置选项，记录发起扫描时的原始目标和端口，配合 checkpoint 使用
+// 扫描的目标来自存活探测等中间结果时，GetCheckpoint 返回的进度可以使用原始参数调用 Match 判断是否属于同一个任务
+// @param {string} targets 原始目标
+// @param {string} ports 原始端口
+// @return {finScanOpt} 返回配置选项
+func _finScanOptCheckpointOrigin(targets string, ports string) finScanOpt {
+	return func(config *_yakFinPortScanConfig) {
+		config.checkpointOrigin = [2]string{targets, ports}
+	}
+}
+
+// Resume 从项目数据库中读取 finscan.checkpoint 保存的扫描进度，继续扫描还没有发包的目标
+// 目标和端口以保存的进度为准，其他扫描参数需要重新设置
+// @param {string} taskId 任务 ID，与 finscan.checkpoint 的参数相同
+// @param {finScanOpt} [opts] finscan 扫描参数
+// @return {chan *finscan.FinScanResult} 返回结果
+// Example:
+// ```
+// res, err = finscan.Resume("fin-10.0.0.0/16")
+// die(err)
+// for result := range res {
+// result.Show()
+// }
+// ```
+func _finscanResume(taskId string, opts ...finScanOpt) (chan *finscan.FinScanResult, error) {
+	cp, err := loadPortScanCheckpoint("finscan", taskId)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Finished {
+		return nil, utils.Errorf("finscan task %v is already finished", taskId)
+	}
+	config := getDefaultFinPortScanConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.checkpointTask = taskId
+	config.checkpointTargets = cp.Targets
+	config.resumeCheckpoint = cp
+	log.Infof("resume finscan task %v from %v/%v", taskId, cp.Index, cp.Total)
+	return _finscanDo(hostsToChan(cp.Targets), cp.Ports, config)
+}
+
+// GetCheckpoint 从项目数据库中读取 finscan.checkpoint 保存的扫描进度
+// @param {string} taskId 任务 ID
+// @return {*synscan.Checkpoint} 扫描进度
+// @return {error} 错误
+func _finscanGetCheckpoint(taskId string) (*synscan.Checkpoint, error) {
+	return loadPortScanCheckpoint("finscan", taskId)
+}
+
 // FinPortScanExports 为了防止网卡过载，5个是上限
 //  1. waiting 实现
 //  2. timeout
 var FinPortScanExports = map[string]interface{}{
 	"Scan": func(target string, port string, opts ...finScanOpt) (chan *finscan.FinScanResult, error) {
-		config := &_yakFinPortScanConfig{
-			waiting:           10 * time.Second,
-			rateLimitDelayMs:  1,
-			rateLimitDelayGap: 5,
-		}
+		config := getDefaultFinPortScanConfig()
 		for _, opt := range opts {
 			opt(config)
 		}
+		if config.checkpointTask != "" {
+			config.checkpointTargets = target
+		}
 		return _finscanDo(hostsToChan(target), port, config)
 	},
+	"Resume":        _finscanResume,
+	"GetCheckpoint": _finscanGetCheckpoint,
 
 	//"callback":           _finScanOptCallback,
 	//"submitTaskCallback": _finScanOptSubmitTaskCallback,
-	"excludePorts":   _finScanOptExcludePorts,
-	"excludeHosts":   _finScanOptExcludeHosts,
-	"wait":           _finScanOptWaiting,
-	"outputFile":     _finScanOptOpenPortResult,
-	"outputPrefix":   _finScanOptOpenPortResultPrefix,
-	"initHostFilter": _finScanOptOpenPortInitHostFilter,
-	"initPortFilter": _finScanOptOpenPortInitPortFilter,
-	"rateLimit":      _finScanOptRateLimit,
-	"concurrent":     _finScanOptConcurrent,
+	"excludePorts":     _finScanOptExcludePorts,
+	"excludeHosts":     _finScanOptExcludeHosts,
+	"wait":             _finScanOptWaiting,
+	"outputFile":       _finScanOptOpenPortResult,
+	"outputPrefix":     _finScanOptOpenPortResultPrefix,
+	"initHostFilter":   _finScanOptOpenPortInitHostFilter,
+	"initPortFilter":   _finScanOptOpenPortInitPortFilter,
+	"rateLimit":        _finScanOptRateLimit,
+	"concurrent":       _finScanOptConcurrent,
+	"checkpoint":       _finScanOptCheckpoint,
+	"checkpointOrigin": _finScanOptCheckpointOrigin,
 	//"fpOutputFile":       _scanOptFpResult,
 	//"fingerprint":        _scanOptEnableFpScan,
 	//"fingerprintTimeout": _scanOptFingerprintRequestTimeout,
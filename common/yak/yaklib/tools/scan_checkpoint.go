@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/synscan"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+// 扫描进度保存在项目数据库的 kv 中，key 为 <kind>-checkpoint-<taskId>
+const portScanCheckpointGroup = "PORT_SCAN_CHECKPOINT"
+
+func portScanCheckpointKey(kind string, taskId string) string {
+	return fmt.Sprintf("%s-checkpoint-%s", kind, taskId)
+}
+
+func loadPortScanCheckpoint(kind string, taskId string) (*synscan.Checkpoint, error) {
+	if taskId == "" {
+		return nil, utils.Error("empty task id")
+	}
+	raw, err := yakit.GetProjectKeyWithError(consts.GetGormProjectDatabase(), portScanCheckpointKey(kind, taskId))
+	if err != nil {
+		return nil, utils.Errorf("cannot find %v checkpoint for task %v: %v", kind, taskId, err)
+	}
+	return synscan.ParseCheckpoint([]byte(raw))
+}
+
+func savePortScanCheckpoint(kind string, taskId string) func(*synscan.Checkpoint) {
+	return func(cp *synscan.Checkpoint) {
+		raw, err := json.Marshal(cp)
+		if err != nil {
+			log.Errorf("marshal %v checkpoint failed: %v", kind, err)
+			return
+		}
+		err = yakit.SetProjectKeyWithGroup(consts.GetGormProjectDatabase(), portScanCheckpointKey(kind, taskId), string(raw), portScanCheckpointGroup)
+		if err != nil {
+			log.Errorf("save %v checkpoint for task %v failed: %v", kind, taskId, err)
+			return
+		}
+		log.Debugf("%v task %v progress: %v/%v", kind, taskId, cp.Index, cp.Total)
+	}
+}
+
+// prepareCheckpoint 收集所有的目标，并找到 taskId 对应的扫描进度
+//
+// resume 不为空时直接使用 resume；否则目标和端口都一致并且没有完成的进度会继续扫描，其他情况创建新的进度。
+// targets 为空时使用收集到的目标（例如 ScanFromPing 的存活主机），排序之后保证每次的排列一致；
+// host:port 形式的目标保留端口，由进度额外扫描，端口同时加入 portsFilter；
+// origin 是发起扫描时的原始目标和端口，设置之后原始参数一致的进度也会继续扫描
+func prepareCheckpoint(
+	kind string, taskId string, resume *synscan.Checkpoint,
+	targets string, targetChan chan string, ports string, origin [2]string,
+	isFiltered func(string, int) bool, hostsFilter *utils.HostsFilter, portsFilter *utils.PortsFilter,
+) (*synscan.Checkpoint, error) {
+	var collected []string
+	for target := range targetChan {
+		if isFiltered(target, 0) {
+			continue
+		}
+		hostsFilter.Add(target)
+		host := target
+		if h, port, _ := utils.ParseStringToHostPort(target); port > 0 {
+			host = h
+			hostsFilter.Add(host)
+			portsFilter.Add(fmt.Sprint(port))
+		}
+		if !utils.IsIPv4(host) && !utils.IsIPv6(host) {
+			hostsFilter.Add(netx.LookupAll(host, netx.WithTimeout(5*time.Second))...)
+		}
+		collected = append(collected, target)
+	}
+
+	cp := resume
+	if cp == nil {
+		if targets == "" {
+			sort.Strings(collected)
+			targets = strings.Join(utils.RemoveRepeatStringSlice(collected), ",")
+		}
+		if saved, err := loadPortScanCheckpoint(kind, taskId); err == nil && !saved.Finished {
+			if saved.Match(targets, ports) || (origin[0] != "" && saved.Match(origin[0], origin[1])) {
+				log.Infof("resume %v task %v from %v/%v", kind, taskId, saved.Index, saved.Total)
+				cp = saved
+			} else {
+				log.Warnf("%v task %v has an unfinished checkpoint for other targets or ports, start a new one", kind, taskId)
+			}
+		}
+	}
+	if cp == nil {
+		var err error
+		cp, err = synscan.NewCheckpoint(targets, ports)
+		if err != nil {
+			return nil, err
+		}
+		cp.OriginTargets, cp.OriginPorts = origin[0], origin[1]
+	}
+	cp.Exclude = isFiltered
+	return cp, nil
+}
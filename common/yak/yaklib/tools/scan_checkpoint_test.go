@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestPrepareCheckpointHostPortTarget(t *testing.T) {
+	targets := make(chan string, 2)
+	targets <- "10.0.0.1:8443"
+	targets <- "10.0.0.2"
+	close(targets)
+
+	hostsFilter := utils.NewHostsFilter()
+	portsFilter := utils.NewPortsFilter("22,80")
+	cp, err := prepareCheckpoint(
+		"synscan", utils.RandStringBytes(16), nil,
+		"", targets, "22,80", [2]string{},
+		func(string, int) bool { return false }, hostsFilter, portsFilter,
+	)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:8443,10.0.0.2", cp.Targets)
+	// 与不使用进度时一致，内嵌的端口也在结果的范围内
+	require.True(t, portsFilter.Contains(8443))
+	require.True(t, hostsFilter.Contains("10.0.0.1"))
+
+	var sent []string
+	require.NoError(t, cp.Walk(context.Background(), func(host string, port int) {
+		sent = append(sent, utils.HostPort(host, port))
+	}, nil, nil))
+	require.Contains(t, sent, "10.0.0.1:8443")
+	require.Contains(t, sent, "10.0.0.1:80")
+	require.Len(t, sent, 5)
+}
@@ -74,6 +74,31 @@ var SubDomainExports = map[string]interface{}{
 		return subdomain.WithTimeoutForEachHTTPSearch(utils.FloatSecondDuration(i))
 	},
 
+	// 扫描模式，CT_LOG、PERMUTATION 和 ZONE_WALK 默认不开启
+	"modes":         subdomain.WithModes,
+	"BRUTE":         subdomain.BRUTE,
+	"SEARCH":        subdomain.SEARCH,
+	"ZONE_TRANSFER": subdomain.ZONE_TRANSFER,
+	"CT_LOG":        subdomain.CT_LOG,
+	"PERMUTATION":   subdomain.PERMUTATION,
+	"ZONE_WALK":     subdomain.ZONE_WALK,
+
+	// 证书透明度日志，默认为空，使用 CT_LOG 模式时需要设置覆盖目标证书有效期的日志分片或镜像
+	"ctLogs": func(urls ...string) subdomain.ConfigOption {
+		return subdomain.WithCTLogURLs(urls)
+	},
+	// CT_LOG 模式只抽样每个日志最新的 n 条记录（默认 10000），不是按域名查询，完整的历史证书请使用 SEARCH 模式（crt.sh）
+	"ctLogMaxEntries": subdomain.WithCTLogMaxEntries,
+
+	// 置换模式
+	"permutationWords": func(i interface{}) subdomain.ConfigOption {
+		return subdomain.WithPermutationWords(utils.PrettifyListFromStringSplitEx(string(utils.StringAsFileParams(i)), "\n", ",", "|"))
+	},
+	"permutationSeeds": func(domains ...string) subdomain.ConfigOption {
+		return subdomain.WithPermutationSeeds(domains)
+	},
+	"maxPermutations": subdomain.WithMaxPermutations,
+
 	// 自定义主字典
 	"mainDict": func(i interface{}) subdomain.ConfigOption {
 		return subdomain.WithMainDictionary(utils.StringAsFileParams(i))
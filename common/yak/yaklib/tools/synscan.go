@@ -41,6 +41,12 @@ type _yakPortScanConfig struct {
 
 	callback           func(result *synscan.SynScanResult)
 	submitTaskCallback func(i string)
+
+	// checkpointTask 不为空时按照确定性排列发包，并把进度保存到项目数据库中
+	checkpointTask    string
+	checkpointTargets string
+	checkpointOrigin  [2]string
+	resumeCheckpoint  *synscan.Checkpoint
 }
 
 func (i *_yakPortScanConfig) callCallback(r *synscan.SynScanResult) {
@@ -569,31 +575,45 @@ func runScan(sampleTarget string, filteredTargetChan chan string, ports string,
 	portInts := getFilteredPorts(ports, config)
 
 	ports = utils.ConcatPorts(portInts)
-	for target := range filteredTargetChan {
-		if config.IsFiltered(target, 0) {
-			continue
+	if config.checkpointTask != "" {
+		cp, err := prepareCheckpoint(
+			"synscan", config.checkpointTask, config.resumeCheckpoint,
+			config.checkpointTargets, filteredTargetChan, ports, config.checkpointOrigin, config.IsFiltered, hostsFilter, portsFilter,
+		)
+		if err != nil {
+			return utils.Errorf("prepare synscan checkpoint failed: %v", err)
 		}
-		log.Debugf("start to submit synscan for %s ports: %v", target, ports)
-		hostsFilter.Add(target)
-		if !utils.IsIPv4(target) {
-			hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
+		err = scanCenter.GetSYNScanner().ScanWithCheckpoint(cp, savePortScanCheckpoint("synscan", config.checkpointTask))
+		if err != nil {
+			return utils.Errorf("synscan with checkpoint failed: %v", err)
 		}
-
-		hostRaw, portRaw, _ := utils.ParseStringToHostPort(target)
-		if portRaw > 0 {
-			portsFilter.Add(fmt.Sprint(portRaw))
-			hostsFilter.Add(hostRaw)
+	} else {
+		for target := range filteredTargetChan {
+			if config.IsFiltered(target, 0) {
+				continue
+			}
+			log.Debugf("start to submit synscan for %s ports: %v", target, ports)
+			hostsFilter.Add(target)
 			if !utils.IsIPv4(target) {
 				hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
 			}
-			_ = scanCenter.SubmitOpenPortScanTask(hostRaw, fmt.Sprint(portRaw), true, true)
-		}
-		err = scanCenter.SubmitOpenPortScanTask(target, ports, true, true)
-		if err != nil {
-			return fmt.Errorf("submit synscan failed: %w", err)
+
+			hostRaw, portRaw, _ := utils.ParseStringToHostPort(target)
+			if portRaw > 0 {
+				portsFilter.Add(fmt.Sprint(portRaw))
+				hostsFilter.Add(hostRaw)
+				if !utils.IsIPv4(target) {
+					hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
+				}
+				_ = scanCenter.SubmitOpenPortScanTask(hostRaw, fmt.Sprint(portRaw), true, true)
+			}
+			err = scanCenter.SubmitOpenPortScanTask(target, ports, true, true)
+			if err != nil {
+				return fmt.Errorf("submit synscan failed: %w", err)
+			}
 		}
+		scanCenter.WaitWriteChannelEmpty()
 	}
-	scanCenter.WaitWriteChannelEmpty()
 	log.Infof("finished submitting.")
 
 	log.Infof("waiting last packet (SYN) for %v seconds", config.waiting)
@@ -670,9 +690,58 @@ func _scan(target string, port string, opts ...scanOpt) (chan *synscan.SynScanRe
 	for _, opt := range opts {
 		opt(config)
 	}
+	if config.checkpointTask != "" {
+		config.checkpointTargets = target
+	}
 	return _synScanDo(hostsToChan(target), port, config)
 }
 
+// Resume 从项目数据库中读取 synscan.checkpoint 保存的扫描进度，继续扫描还没有发包的目标
+// 目标和端口以保存的进度为准，其他扫描参数需要重新设置
+// @param {string} taskId 任务 ID，与 synscan.checkpoint 的参数相同
+// @param {scanOpt} [opts] synscan 扫描参数
+// @return {chan *synscan.SynScanResult} 返回结果
+// Example:
+// ```
+// res, err = synscan.Resume("scan-10.0.0.0/16", synscan.concurrent(2000))
+// die(err)
+// for result := range res {
+// result.Show()
+// }
+// ```
+func _synscanResume(taskId string, opts ...scanOpt) (chan *synscan.SynScanResult, error) {
+	cp, err := loadPortScanCheckpoint("synscan", taskId)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Finished {
+		return nil, utils.Errorf("synscan task %v is already finished", taskId)
+	}
+	config := getDefaultPortScanConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.checkpointTask = taskId
+	config.checkpointTargets = cp.Targets
+	config.resumeCheckpoint = cp
+	log.Infof("resume synscan task %v from %v/%v", taskId, cp.Index, cp.Total)
+	return _synScanDo(hostsToChan(cp.Targets), cp.Ports, config)
+}
+
+// GetCheckpoint 从项目数据库中读取 synscan.checkpoint 保存的扫描进度
+// @param {string} taskId 任务 ID
+// @return {*synscan.Checkpoint} 扫描进度，Index / Total 为已经发送的数量和总数，Finished 表示是否已经完成
+// @return {error} 错误
+// Example:
+// ```
+// cp, err = synscan.GetCheckpoint("scan-10.0.0.0/16")
+// die(err)
+// printf("%v/%v finished: %v\n", cp.Index, cp.Total, cp.Finished)
+// ```
+func _synscanGetCheckpoint(taskId string) (*synscan.Checkpoint, error) {
+	return loadPortScanCheckpoint("synscan", taskId)
+}
+
 func getDefaultPortScanConfig() *_yakPortScanConfig {
 	return &_yakPortScanConfig{
 		waiting:           5 * time.Second,
@@ -748,6 +817,35 @@ func _scanOptSubmitTaskCallback(i func(string)) scanOpt {
 	}
 }
 
+// checkpoint syn scan 的配置选项，按照确定性的随机顺序发包，并定期把扫描进度保存到项目数据库中
+// 相同任务 ID 的扫描中断之后，使用相同的目标和端口再次扫描（或者使用 synscan.Resume）会从中断的位置继续
+// @param {string} taskId 任务 ID
+// @return {scanOpt} 返回配置选项
+// Example:
+// ```
+// res, err = synscan.Scan("10.0.0.0/16", "1-1000", synscan.checkpoint("scan-10.0.0.0/16"))
+// die(err)
+// for result := range res {
+// result.Show()
+// }
+// ```
+func _scanOptCheckpoint(taskId string) scanOpt {
+	return func(config *_yakPortScanConfig) {
+		config.checkpointTask = taskId
+	}
+}
+
+// checkpointOrigin syn scan 的配置选项，记录发起扫描时的原始目标和端口，配合 checkpoint 使用
+// 扫描的目标来自存活探测等中间结果时，GetCheckpoint 返回的进度可以使用原始参数调用 Match 判断是否属于同一个任务
+// @param {string} targets 原始目标
+// @param {string} ports 原始端口
+// @return {scanOpt} 返回配置选项
+func _scanOptCheckpointOrigin(targets string, ports string) scanOpt {
+	return func(config *_yakPortScanConfig) {
+		config.checkpointOrigin = [2]string{targets, ports}
+	}
+}
+
 // 为了防止网卡过载，5个是上限
 //  1. waiting 实现
 //  2. timeout
@@ -755,6 +853,8 @@ var SynPortScanExports = map[string]interface{}{
 	"FixPermission": pcapfix.Fix,
 	"Scan":          _scan,
 	"ScanFromPing":  _synscanFromPingUtils,
+	"Resume":        _synscanResume,
+	"GetCheckpoint": _synscanGetCheckpoint,
 
 	"callback":           _scanOptCallback,
 	"submitTaskCallback": _scanOptSubmitTaskCallback,
@@ -768,6 +868,8 @@ var SynPortScanExports = map[string]interface{}{
 	"rateLimit":          _scanOptRateLimit,
 	"concurrent":         _scanOptSYNConcurrent,
 	"iface":              _scanOptIface,
+	"checkpoint":         _scanOptCheckpoint,
+	"checkpointOrigin":   _scanOptCheckpointOrigin,
 	//"fpOutputFile":       _scanOptFpResult,
 	//"fingerprint":        _scanOptEnableFpScan,
 	//"fingerprintTimeout": _scanOptFingerprintRequestTimeout,
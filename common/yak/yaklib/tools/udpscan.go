@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/yaklang/yaklang/common/fp"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/udpscan"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/pcapfix"
+)
+
+type _yakUdpScanConfig struct {
+	ctx          context.Context
+	netInterface string
+	options      []udpscan.ConfigOption
+	callback     func(result *udpscan.UdpScanResult)
+}
+
+type udpScanOpt func(config *_yakUdpScanConfig)
+
+// _udpScan 对目标进行 UDP 端口扫描，针对 DNS / SNMP / NTP / SSDP / NetBIOS / IKE / memcached / mDNS / CoAP 发送协议探测包，
+// 根据 UDP 回复和 ICMP 端口不可达判断端口状态（open / closed / filtered / open|filtered）
+// 需要 pcap 权限，没有权限或者扫描本地回环地址时使用系统 socket 发包
+// @param {string} target 目标，支持 IP、域名、CIDR、IP 段，多个目标使用逗号分隔
+// @param {string} ports 端口，支持 53、1-1000、53,161,123 格式
+// @param {udpScanOpt} opts 扫描选项
+// @return {chan *udpscan.UdpScanResult} 扫描结果，所有端口都得到结论之后 channel 关闭
+// @return {error} 错误
+// Example:
+// ```
+// res, err = udpscan.Scan("192.168.1.1/24", "53,123,161,1900")
+// die(err)
+// for result := range res {
+// if result.IsOpen() { db.SavePortFromResult(result) }
+// println(result.String())
+// }
+// ```
+func _udpScan(target string, ports string, opts ...udpScanOpt) (chan *udpscan.UdpScanResult, error) {
+	config := &_yakUdpScanConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	var options []udpscan.ConfigOption
+	var err error
+	if config.netInterface != "" {
+		options, err = udpscan.CreateConfigOptionsByIfaceName(config.netInterface)
+	} else {
+		var sample string
+		for _, host := range utils.ParseStringToHosts(target) {
+			sample = host
+			break
+		}
+		options, err = udpscan.CreateConfigOptionsByTargetNetworkOrDomain(sample, 5*time.Second)
+	}
+	if err != nil {
+		log.Warnf("udpscan cannot find net interface: %v, use socket instead", err)
+		options = []udpscan.ConfigOption{udpscan.WithForceSocket(true)}
+	} else if !pcapfix.IsPrivilegedForNetRaw() {
+		log.Warnf("udpscan has no privilege for pcap, use socket instead")
+		options = append(options, udpscan.WithForceSocket(true))
+	}
+
+	udpConfig, err := udpscan.NewConfig(append(options, config.options...)...)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := udpscan.NewScanner(config.ctx, udpConfig)
+	if err != nil {
+		return nil, err
+	}
+	results, err := scanner.Scan(target, ports)
+	if err != nil {
+		scanner.Close()
+		return nil, err
+	}
+
+	ch := make(chan *udpscan.UdpScanResult, 1000)
+	go func() {
+		defer close(ch)
+		defer scanner.Close()
+		for result := range results {
+			if config.callback != nil {
+				func() {
+					defer func() {
+						if err := recover(); err != nil {
+							log.Errorf("udpscan callback failed: %s", err)
+						}
+					}()
+					config.callback(result)
+				}()
+			}
+			ch <- result
+		}
+	}()
+	return ch, nil
+}
+
+// callback udp scan 的配置选项，设置每个端口得到结论时的回调函数
+// @param {func(result *udpscan.UdpScanResult)} i 回调函数
+// @return {udpScanOpt} 返回配置选项
+// Example:
+// ```
+// res, err = udpscan.Scan("127.0.0.1", "53", udpscan.callback(func(result) { println(result.String()) }))
+// ```
+func _udpScanOptCallback(i func(result *udpscan.UdpScanResult)) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.callback = i
+	}
+}
+
+// context udp scan 的配置选项，设置扫描的上下文，上下文结束时停止扫描
+// @param {context.Context} ctx 上下文
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptContext(ctx context.Context) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		if ctx != nil {
+			config.ctx = ctx
+		}
+	}
+}
+
+// iface udp scan 的配置选项，设置扫描使用的网卡
+// @param {string} iface 网卡名称
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptIface(iface string) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.netInterface = iface
+	}
+}
+
+// retry udp scan 的配置选项，设置没有回复时的重试次数，默认为 2
+// @param {int} count 重试次数
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptRetry(count int) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithRetry(count))
+	}
+}
+
+// timeout udp scan 的配置选项，设置第一轮发包之后等待回复的时间，之后每次重试等待时间翻倍，默认为 1 秒
+// @param {float64} sec 等待时间，单位秒
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptTimeout(sec float64) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithTimeout(utils.FloatSecondDuration(sec)))
+	}
+}
+
+// rateLimit udp scan 的配置选项，设置每秒最多发送的数据包数量
+// @param {int} count 每秒发包数量，0 为不限制
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptRateLimit(count int) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithRateLimit(count))
+	}
+}
+
+// socket udp scan 的配置选项，不使用 pcap，使用系统 socket 发包（不需要 root 权限）
+// @param {bool} b 是否使用系统 socket
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptSocket(b bool) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithForceSocket(b))
+	}
+}
+
+// concurrent udp scan 的配置选项，设置使用系统 socket 发包时同时等待回复的端口数量，默认为 256
+// @param {int} count 并发数量
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptConcurrent(count int) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithSocketConcurrent(count))
+	}
+}
+
+// fingerprintConcurrent udp scan 的配置选项，设置同时进行指纹识别的端口数量，默认为 20
+// @param {int} count 并发数量
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptFingerprintConcurrent(count int) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithFingerprintConcurrent(count))
+	}
+}
+
+// fingerprint udp scan 的配置选项，对 open 和 open|filtered 的端口进行 UDP 指纹识别
+// @param {bool} b 是否启用指纹识别
+// @return {udpScanOpt} 返回配置选项
+func _udpScanOptFingerprint(b bool) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithFingerprint(b, fp.WithProbeTimeout(5*time.Second)))
+	}
+}
+
+// probe udp scan 的配置选项，添加自定义的探测包
+// @param {string} name 探测包名称
+// @param {string} ports 使用这个探测包的端口
+// @param {[]byte} payload 探测包内容
+// @return {udpScanOpt} 返回配置选项
+// Example:
+// ```
+// res, err = udpscan.Scan("192.168.1.1", "9999", udpscan.probe("custom", "9999", "\x00\x01hello"))
+// ```
+func _udpScanOptProbe(name string, ports string, payload []byte) udpScanOpt {
+	return func(config *_yakUdpScanConfig) {
+		config.options = append(config.options, udpscan.WithExtraProbes(&udpscan.Probe{
+			Name:    name,
+			Ports:   utils.ParseStringToPorts(ports),
+			Payload: payload,
+		}))
+	}
+}
+
+var UdpPortScanExports = map[string]interface{}{
+	"Scan": _udpScan,
+
+	"callback":    _udpScanOptCallback,
+	"context":     _udpScanOptContext,
+	"iface":       _udpScanOptIface,
+	"retry":       _udpScanOptRetry,
+	"timeout":     _udpScanOptTimeout,
+	"rateLimit":   _udpScanOptRateLimit,
+	"socket":      _udpScanOptSocket,
+	"fingerprint": _udpScanOptFingerprint,
+	"probe":       _udpScanOptProbe,
+
+	"concurrent":            _udpScanOptConcurrent,
+	"fingerprintConcurrent": _udpScanOptFingerprintConcurrent,
+
+	"OPEN":          udpscan.OPEN,
+	"CLOSED":        udpscan.CLOSED,
+	"FILTERED":      udpscan.FILTERED,
+	"OPEN_FILTERED": udpscan.OPEN_FILTERED,
+}
@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/yaklang/yaklang/common/subdomain"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/vhostscan"
+)
+
+type _yakVHostScanConfig struct {
+	ctx     context.Context
+	options []vhostscan.ConfigOption
+}
+
+type vhostScanOpt func(config *_yakVHostScanConfig)
+
+func _vhostScanStringList(i interface{}) []string {
+	if ret, ok := i.([]string); ok {
+		return ret
+	}
+	return utils.PrettifyListFromStringSplitEx(string(utils.StringAsFileParams(i)), "\n", ",", "|")
+}
+
+// Scan 对目标进行虚拟主机发现，使用候选域名作为 Host（HTTPS 时同时作为 SNI）发送请求，
+// 与随机域名得到的默认虚拟主机响应进行比较，返回响应不同的虚拟主机，并且默认保存到 HTTP 流量（网站树）中
+// @param {string} target 目标，支持 ip:port 和 http(s)://ip:port，没有协议时自动检测 TLS
+// @param {vhostScanOpt} opts 扫描选项
+// @return {chan *vhostscan.VHostResult} 发现的虚拟主机，扫描结束之后 channel 关闭
+// @return {error} 错误
+// Example:
+// ```
+// res, err = vhostscan.Scan("10.0.0.1:443", vhostscan.domains("example.com"), vhostscan.words("admin,dev,test"))
+// die(err)
+// for result := range res {
+// println(result.String())
+// }
+// ```
+func _vhostScan(target string, opts ...vhostScanOpt) (chan *vhostscan.VHostResult, error) {
+	config := &_yakVHostScanConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return vhostscan.Scan(config.ctx, target, vhostscan.NewConfig(config.options...))
+}
+
+// hosts vhost scan 的配置选项，添加完整的候选域名
+// @param {string|[]string} i 域名列表，字符串支持换行或逗号分隔，也可以是字典文件路径
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptHosts(i interface{}) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithHosts(_vhostScanStringList(i)...))
+	}
+}
+
+// words vhost scan 的配置选项，添加与 domains 组合成 word.domain 的单词
+// @param {string|[]string} i 单词列表，字符串支持换行或逗号分隔，也可以是字典文件路径
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptWords(i interface{}) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithWords(_vhostScanStringList(i)...))
+	}
+}
+
+// domains vhost scan 的配置选项，添加根域名，随机的基线域名也会使用这些根域名
+// @param {string} domains 根域名
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptDomains(domains ...string) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithDomains(domains...))
+	}
+}
+
+// subdomain vhost scan 的配置选项，把子域名扫描的结果作为候选域名
+// @param {*subdomain.SubdomainResult} results 子域名扫描结果
+// @return {vhostScanOpt} 返回配置选项
+// Example:
+// ```
+// domains = []
+// res, _ = subdomain.Scan("example.com")
+// for r in res { domains.Append(r) }
+// vhosts, err = vhostscan.Scan("10.0.0.1:80", vhostscan.subdomain(domains...))
+// ```
+func _vhostScanOptSubdomain(results ...*subdomain.SubdomainResult) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		for _, result := range results {
+			if result != nil {
+				config.options = append(config.options, vhostscan.WithHosts(result.Domain))
+			}
+		}
+	}
+}
+
+// path vhost scan 的配置选项，设置请求的路径，默认为 /
+// @param {string} path 路径
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptPath(path string) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithPath(path))
+	}
+}
+
+// sni vhost scan 的配置选项，HTTPS 请求时是否把 SNI 设置为候选域名，默认为 true
+// @param {bool} b 是否设置 SNI
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptSNI(b bool) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithSNI(b))
+	}
+}
+
+// baseline vhost scan 的配置选项，设置随机基线域名的数量，默认为 3
+// @param {int} count 数量
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptBaseline(count int) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithBaselineCount(count))
+	}
+}
+
+// concurrent vhost scan 的配置选项，设置并发请求数量，默认为 20
+// @param {int} count 并发数量
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptConcurrent(count int) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithConcurrent(count))
+	}
+}
+
+// timeout vhost scan 的配置选项，设置每个请求的超时时间，默认为 10 秒
+// @param {float64} sec 超时时间，单位秒
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptTimeout(sec float64) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithTimeout(utils.FloatSecondDuration(sec)))
+	}
+}
+
+// proxy vhost scan 的配置选项，设置请求使用的代理
+// @param {string} proxy 代理地址
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptProxy(proxy ...string) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithProxy(proxy...))
+	}
+}
+
+// fingerprint vhost scan 的配置选项，是否对发现的虚拟主机进行 Web 指纹识别，默认为 true
+// @param {bool} b 是否进行指纹识别
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptFingerprint(b bool) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithFingerprint(b))
+	}
+}
+
+// save vhost scan 的配置选项，是否把发现的虚拟主机保存到 HTTP 流量（网站树）中，默认为 true
+// @param {bool} b 是否保存
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptSave(b bool) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithSaveToDB(b))
+	}
+}
+
+// runtimeId vhost scan 的配置选项，设置保存 HTTP 流量时使用的 runtime id
+// @param {string} id runtime id
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptRuntimeID(id string) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		config.options = append(config.options, vhostscan.WithRuntimeID(id))
+	}
+}
+
+// context vhost scan 的配置选项，设置扫描的上下文，上下文结束时停止扫描
+// @param {context.Context} ctx 上下文
+// @return {vhostScanOpt} 返回配置选项
+func _vhostScanOptContext(ctx context.Context) vhostScanOpt {
+	return func(config *_yakVHostScanConfig) {
+		if ctx != nil {
+			config.ctx = ctx
+		}
+	}
+}
+
+var VHostScanExports = map[string]interface{}{
+	"Scan": _vhostScan,
+
+	"hosts":       _vhostScanOptHosts,
+	"words":       _vhostScanOptWords,
+	"domains":     _vhostScanOptDomains,
+	"subdomain":   _vhostScanOptSubdomain,
+	"path":        _vhostScanOptPath,
+	"sni":         _vhostScanOptSNI,
+	"baseline":    _vhostScanOptBaseline,
+	"concurrent":  _vhostScanOptConcurrent,
+	"timeout":     _vhostScanOptTimeout,
+	"proxy":       _vhostScanOptProxy,
+	"fingerprint": _vhostScanOptFingerprint,
+	"save":        _vhostScanOptSave,
+	"runtimeId":   _vhostScanOptRuntimeID,
+	"context":     _vhostScanOptContext,
+}
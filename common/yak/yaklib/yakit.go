@@ -20,11 +20,13 @@ import (
 	"github.com/yaklang/yaklang/common/go-funk"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/synscan"
+	"github.com/yaklang/yaklang/common/udpscan"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/cli"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 	"github.com/yaklang/yaklang/common/utils/lowhttp/http_struct"
 	"github.com/yaklang/yaklang/common/utils/spacengine"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
 	"github.com/yaklang/yaklang/common/yak/yaklib/yakhttp"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
@@ -340,6 +342,8 @@ func MarshalYakitOutput(t interface{}) (string, string) {
 		return "fingerprint", string(raw)
 	case *synscan.SynScanResult:
 		return "synscan-result", string(raw)
+	case *udpscan.UdpScanResult:
+		return "udpscan-result", string(raw)
 	case *schema.Risk:
 		ret.CreatedAt = time.Now()
 		ret.UpdatedAt = time.Now()
@@ -406,6 +410,81 @@ func NewPortFromMatchResult(f *fp.MatchResult) *schema.Port {
 	}
 }
 
+// NewPortCertificatesFromMatchResult 把指纹识别时获取到的证书链转换为证书资产，无法解析的证书会被跳过
+func NewPortCertificatesFromMatchResult(f *fp.MatchResult) []*schema.PortCertificate {
+	if f == nil || f.Fingerprint == nil {
+		return nil
+	}
+	var certs []*schema.PortCertificate
+	for index, result := range f.Fingerprint.TLSInspectResults {
+		if result == nil {
+			continue
+		}
+		info, err := tlsutils.ParseCertificateInfo(result.Raw)
+		if err != nil {
+			log.Debugf("parse certificate from %v failed: %v", utils.HostPort(f.Target, f.Port), err)
+			continue
+		}
+		certs = append(certs, &schema.PortCertificate{
+			Host:               f.Target,
+			Port:               f.Port,
+			ChainIndex:         index,
+			Subject:            info.Subject,
+			SubjectCN:          info.SubjectCN,
+			Issuer:             info.Issuer,
+			IssuerCN:           info.IssuerCN,
+			SerialNumber:       info.SerialNumber,
+			NotBefore:          info.NotBefore.Unix(),
+			NotAfter:           info.NotAfter.Unix(),
+			KeyType:            info.KeyType,
+			KeyBits:            info.KeyBits,
+			SignatureAlgorithm: info.SignatureAlgorithm,
+			DNSNames:           strings.Join(info.DNSNames, ","),
+			IPAddresses:        strings.Join(info.IPAddresses, ","),
+			EmailAddresses:     strings.Join(info.EmailAddresses, ","),
+			IsCA:               info.IsCA,
+			SelfSigned:         info.SelfSigned,
+			SM2:                info.SM2,
+			SHA256:             info.SHA256,
+			PEM:                info.PEM(),
+		})
+	}
+	return certs
+}
+
+func NewPortTLSFingerprintFromMatchResult(f *fp.MatchResult) *schema.PortTLSFingerprint {
+	if f == nil || f.Fingerprint == nil || f.Fingerprint.TLSServerFingerprint == nil {
+		return nil
+	}
+	return &schema.PortTLSFingerprint{
+		Host:        f.Target,
+		Port:        f.Port,
+		Fingerprint: f.Fingerprint.TLSServerFingerprint.Fingerprint,
+		Raw:         f.Fingerprint.TLSServerFingerprint.Raw,
+		JA3S:        f.Fingerprint.TLSServerFingerprint.JA3S,
+	}
+}
+
+func NewPortBannersFromMatchResult(f *fp.MatchResult) []*schema.PortBanner {
+	if f == nil || f.Fingerprint == nil {
+		return nil
+	}
+	var banners []*schema.PortBanner
+	for _, b := range f.Fingerprint.ProbeBanners {
+		if b == nil || b.Banner == "" {
+			continue
+		}
+		banners = append(banners, &schema.PortBanner{
+			Host:   f.Target,
+			Port:   f.Port,
+			Proto:  string(b.Proto),
+			Probe:  b.Probe,
+			Banner: b.Banner,
+		})
+	}
+	return banners
+}
+
 func NewPortFromSpaceEngineResult(f *spacengine.NetSpaceEngineResult) *schema.Port {
 	host, port, _ := utils.ParseStringToHostPort(f.Addr)
 	return &schema.Port{
@@ -429,6 +508,29 @@ func NewPortFromSynScanResult(f *synscan.SynScanResult) *schema.Port {
 	}
 }
 
+func NewPortFromUdpScanResult(f *udpscan.UdpScanResult) *schema.Port {
+	p := &schema.Port{
+		Host:   f.Host,
+		Port:   f.Port,
+		Proto:  "udp",
+		State:  f.State.String(),
+		Reason: f.Reason,
+		From:   "udpscan",
+	}
+	if f.Fingerprint != nil {
+		p.ServiceType = f.Fingerprint.GetServiceName()
+		p.Fingerprint = f.Fingerprint.GetBanner()
+		p.CPE = strings.Join(f.Fingerprint.GetCPEs(), "|")
+	}
+	if p.ServiceType == "" && f.State == udpscan.OPEN && f.Probe != "generic" {
+		p.ServiceType = f.Probe
+	}
+	if p.Fingerprint == "" && len(f.Response) > 0 {
+		p.Fingerprint = utils.EscapeInvalidUTF8Byte(f.Response)
+	}
+	return p
+}
+
 func YakitMessageGenerator(i interface{}) ([]byte, error) {
 	raw, err := json.Marshal(i)
 	if err != nil {
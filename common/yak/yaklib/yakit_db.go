@@ -10,13 +10,16 @@ import (
 	"sync"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/jinzhu/gorm"
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/fp"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/synscan"
+	"github.com/yaklang/yaklang/common/udpscan"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/bizhelper"
 	"github.com/yaklang/yaklang/common/utils/spacengine"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 )
 
@@ -244,6 +247,8 @@ func interfaceToPort(t interface{}) (*schema.Port, error) {
 		r = NewPortFromMatchResult(ret)
 	case *synscan.SynScanResult:
 		r = NewPortFromSynScanResult(ret)
+	case *udpscan.UdpScanResult:
+		r = NewPortFromUdpScanResult(ret)
 	case *spacengine.NetSpaceEngineResult:
 		r = NewPortFromSpaceEngineResult(ret)
 	case *schema.Port:
@@ -263,7 +268,52 @@ func savePortFromObj(t interface{}, RuntimeId ...string) error {
 		r.RuntimeId = RuntimeId[0]
 	}
 
-	return yakit.CreateOrUpdatePort(consts.GetGormProjectDatabase(), r.CalcHash(), r)
+	err = yakit.CreateOrUpdatePort(consts.GetGormProjectDatabase(), r.CalcHash(), r)
+	if err != nil {
+		return err
+	}
+	if result, ok := t.(*fp.MatchResult); ok {
+		savePortAssetsFromMatchResult(consts.GetGormProjectDatabase(), result, r.RuntimeId)
+	}
+	return nil
+}
+
+// savePortAssetsFromMatchResult 保存指纹识别结果中的证书链、TLS 服务端指纹和探针 Banner，
+// 服务器证书 SAN 中的域名会保存为域名资产
+func savePortAssetsFromMatchResult(db *gorm.DB, result *fp.MatchResult, runtimeId string) {
+	if db == nil || result == nil || result.State != fp.OPEN {
+		return
+	}
+	for _, cert := range NewPortCertificatesFromMatchResult(result) {
+		cert.RuntimeId = runtimeId
+		if err := yakit.CreateOrUpdatePortCertificate(db, cert.CalcHash(), cert); err != nil {
+			log.Error(err)
+		}
+		if cert.ChainIndex != 0 {
+			continue
+		}
+		info := &tlsutils.CertificateInfo{
+			SubjectCN: cert.SubjectCN,
+			DNSNames:  utils.PrettifyListFromStringSplited(cert.DNSNames, ","),
+		}
+		for _, domain := range info.Domains() {
+			if err := yakit.SaveDomainFromCertificate(db, domain, result.Target); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+	if fingerprint := NewPortTLSFingerprintFromMatchResult(result); fingerprint != nil {
+		fingerprint.RuntimeId = runtimeId
+		if err := yakit.CreateOrUpdatePortTLSFingerprint(db, fingerprint.CalcHash(), fingerprint); err != nil {
+			log.Error(err)
+		}
+	}
+	for _, banner := range NewPortBannersFromMatchResult(result) {
+		banner.RuntimeId = runtimeId
+		if err := yakit.CreateOrUpdatePortBanner(db, banner.CalcHash(), banner); err != nil {
+			log.Error(err)
+		}
+	}
 }
 
 func queryUrlsByKeyword(k string) chan string {
@@ -3,12 +3,20 @@ package yaklib
 import (
 	"fmt"
 	"github.com/yaklang/yaklang/common/schema"
+	"math/rand"
 	"strings"
 	"testing"
 
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/fp"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 )
 
 func Test_ExactQueryInt64ArrayOr(t *testing.T) {
@@ -40,3 +48,61 @@ func Test_ExactExcludeQueryInt64Array(t *testing.T) {
 		t.Fatal("ExactExcludeQueryInt64Array failed")
 	}
 }
+
+func Test_SavePortAssetsFromMatchResult(t *testing.T) {
+	token := strings.ToLower(utils.RandStringBytes(12))
+	domain := token + ".example.com"
+	ca, _, err := tlsutils.GenerateSelfSignedCertKeyWithCommonName(token, "", nil, []string{"*." + domain, "www." + domain})
+	require.NoError(t, err)
+	cert, err := tlsutils.ParsePEMCertificate(ca)
+	require.NoError(t, err)
+
+	port := 10000 + rand.Intn(50000)
+	result := &fp.MatchResult{
+		Target: "127.0.0.1",
+		Port:   port,
+		State:  fp.OPEN,
+		Fingerprint: &fp.FingerprintInfo{
+			IP:                "127.0.0.1",
+			Port:              port,
+			Proto:             fp.TCP,
+			ServiceName:       "https",
+			TLSInspectResults: []*netx.TLSInspectResult{{Raw: cert.Raw}},
+			TLSServerFingerprint: &netx.TLSServerFingerprint{
+				Fingerprint: strings.Repeat("0", 62),
+				JA3S:        "771,49199,65281-0-11-35-16",
+			},
+			ProbeBanners: []*fp.ProbeBanner{
+				{Probe: "NULL", Proto: fp.TCP, Banner: "SSH-2.0-OpenSSH_8.9"},
+				{Probe: "GetRequest", Proto: fp.TCP, Banner: "HTTP/1.1 400 Bad Request"},
+			},
+		},
+	}
+	runtimeId := uuid.NewString()
+	require.NoError(t, savePortFromObj(result, runtimeId))
+
+	db := consts.GetGormProjectDatabase()
+	certs, err := yakit.QueryPortCertificates(db, "127.0.0.1", port)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	require.Equal(t, token, certs[0].SubjectCN)
+	require.Equal(t, "RSA", certs[0].KeyType)
+	require.Equal(t, runtimeId, certs[0].RuntimeId)
+
+	fingerprint, err := yakit.GetPortTLSFingerprint(db, "127.0.0.1", port)
+	require.NoError(t, err)
+	require.Equal(t, "771,49199,65281-0-11-35-16", fingerprint.JA3S)
+
+	banners, err := yakit.QueryPortBanners(db, "127.0.0.1", port)
+	require.NoError(t, err)
+	require.Len(t, banners, 2)
+
+	var domains []*schema.Domain
+	require.NoError(t, db.Model(&schema.Domain{}).Where("domain LIKE ?", "%"+domain).Find(&domains).Error)
+	var names []string
+	for _, d := range domains {
+		names = append(names, d.Domain)
+		require.Equal(t, yakit.CertificateDomainTag, d.Tags)
+	}
+	require.ElementsMatch(t, []string{domain, "www." + domain}, names)
+}
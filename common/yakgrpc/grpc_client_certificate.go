@@ -0,0 +1,72 @@
+package yakgrpc
+
+import (
+	"context"
+
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func (s *Server) QueryClientCertificates(ctx context.Context, req *ypb.QueryClientCertificatesRequest) (*ypb.QueryClientCertificatesResponse, error) {
+	paging := req.GetPagination()
+	if paging == nil {
+		paging = &ypb.Paging{Page: 1, Limit: 30}
+	}
+	p, certs, err := yakit.QueryClientCertificates(s.GetProfileDatabase(), req.GetKeyword(), int(paging.GetPage()), int(paging.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+	var data []*ypb.ClientCertificate
+	for _, c := range certs {
+		data = append(data, c.ToGRPCModel())
+	}
+	return &ypb.QueryClientCertificatesResponse{
+		Pagination: paging,
+		Total:      int64(p.TotalRecord),
+		Data:       data,
+	}, nil
+}
+
+func (s *Server) SaveClientCertificate(ctx context.Context, req *ypb.SaveClientCertificateRequest) (*ypb.ClientCertificate, error) {
+	var (
+		cert *schema.ClientCertificate
+		err  error
+	)
+	if len(req.GetPkcs12Bytes()) > 0 {
+		cert, err = yakit.NewClientCertificateFromP12(req.GetName(), req.GetHostPattern(), req.GetPkcs12Bytes(), string(req.GetPkcs12Password()))
+	} else if len(req.GetCrtPem()) > 0 && len(req.GetKeyPem()) > 0 {
+		cert, err = yakit.NewClientCertificateFromPEM(req.GetName(), req.GetHostPattern(), req.GetCrtPem(), req.GetKeyPem(), req.GetCaCertificates()...)
+	} else {
+		return nil, utils.Error("client certificate needs crt/key pem or pkcs12 bytes")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cert.Disabled = req.GetDisabled()
+	if err := yakit.CreateOrUpdateClientCertificate(s.GetProfileDatabase(), cert); err != nil {
+		return nil, err
+	}
+	return cert.ToGRPCModel(), nil
+}
+
+func (s *Server) SetClientCertificateDisabled(ctx context.Context, req *ypb.SetClientCertificateDisabledRequest) (*ypb.Empty, error) {
+	if err := yakit.SetClientCertificateDisabled(s.GetProfileDatabase(), req.GetId(), req.GetDisabled()); err != nil {
+		return nil, err
+	}
+	return &ypb.Empty{}, nil
+}
+
+func (s *Server) DeleteClientCertificates(ctx context.Context, req *ypb.DeleteClientCertificatesRequest) (*ypb.Empty, error) {
+	var err error
+	if req.GetDeleteAll() {
+		err = yakit.DeleteAllClientCertificates(s.GetProfileDatabase())
+	} else {
+		err = yakit.DeleteClientCertificateByID(s.GetProfileDatabase(), req.GetIds()...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ypb.Empty{}, nil
+}
@@ -0,0 +1,93 @@
+package yakgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_ClientCertificate_CRUD(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ca, caKey, err := tlsutils.GenerateSelfSignedCertKey("", nil, nil)
+	require.NoError(t, err)
+	crt, key, err := tlsutils.SignClientCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+
+	host := utils.RandStringBytes(16) + ".example.com"
+	saved, err := client.SaveClientCertificate(ctx, &ypb.SaveClientCertificateRequest{
+		Name:           host,
+		HostPattern:    host,
+		CrtPem:         crt,
+		KeyPem:         key,
+		CaCertificates: [][]byte{ca},
+	})
+	require.NoError(t, err)
+	require.Greater(t, saved.GetId(), int64(0))
+	defer client.DeleteClientCertificates(ctx, &ypb.DeleteClientCertificatesRequest{Ids: []int64{saved.GetId()}})
+	// 保存后立即生效
+	require.Len(t, netx.GetClientCertificatesForHost(host), 1)
+
+	rsp, err := client.QueryClientCertificates(ctx, &ypb.QueryClientCertificatesRequest{Keyword: host})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 1)
+	require.Equal(t, host, rsp.GetData()[0].GetHostPattern())
+
+	_, err = client.SetClientCertificateDisabled(ctx, &ypb.SetClientCertificateDisabledRequest{Id: saved.GetId(), Disabled: true})
+	require.NoError(t, err)
+	require.Len(t, netx.GetClientCertificatesForHost(host), 0)
+
+	_, err = client.SetClientCertificateDisabled(ctx, &ypb.SetClientCertificateDisabledRequest{Id: saved.GetId(), Disabled: false})
+	require.NoError(t, err)
+	require.Len(t, netx.GetClientCertificatesForHost(host), 1)
+
+	_, err = client.DeleteClientCertificates(ctx, &ypb.DeleteClientCertificatesRequest{Ids: []int64{saved.GetId()}})
+	require.NoError(t, err)
+	require.Len(t, netx.GetClientCertificatesForHost(host), 0)
+	rsp, err = client.QueryClientCertificates(ctx, &ypb.QueryClientCertificatesRequest{Keyword: host})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 0)
+}
+
+func TestGRPCMUSTPASS_ClientCertificate_SaveDisabledToggle(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ca, caKey, err := tlsutils.GenerateSelfSignedCertKey("", nil, nil)
+	require.NoError(t, err)
+	crt, key, err := tlsutils.SignClientCrtNKeyEx(ca, caKey, "", true)
+	require.NoError(t, err)
+
+	host := utils.RandStringBytes(16) + ".example.com"
+	save := func(disabled bool) *ypb.ClientCertificate {
+		saved, err := client.SaveClientCertificate(ctx, &ypb.SaveClientCertificateRequest{
+			Name:        host,
+			HostPattern: host,
+			CrtPem:      crt,
+			KeyPem:      key,
+			Disabled:    disabled,
+		})
+		require.NoError(t, err)
+		return saved
+	}
+
+	saved := save(true)
+	defer client.DeleteClientCertificates(ctx, &ypb.DeleteClientCertificatesRequest{Ids: []int64{saved.GetId()}})
+	require.Len(t, netx.GetClientCertificatesForHost(host), 0)
+
+	// 重新保存同一个证书，Disabled 改回 false 必须生效
+	require.Equal(t, saved.GetId(), save(false).GetId())
+	rsp, err := client.QueryClientCertificates(ctx, &ypb.QueryClientCertificatesRequest{Keyword: host})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 1)
+	require.False(t, rsp.GetData()[0].GetDisabled())
+	require.Len(t, netx.GetClientCertificatesForHost(host), 1)
+}
@@ -21,21 +21,38 @@ import (
 	"github.com/yaklang/yaklang/common/yak/yaklib"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"google.golang.org/protobuf/proto"
 )
 
 func (s *Server) DeleteHTTPFlows(ctx context.Context, r *ypb.DeleteHTTPFlowRequest) (*ypb.Empty, error) {
-	var websocketHash []string
+	var websocketHash, http2HiddenIndex, sseHash []string
 	db := yakit.QueryWebsocketFlowsByHTTPFlowHash(s.GetProjectDatabase(), r)
 	res := yakit.YieldHTTPFlows(db, ctx)
 	for v := range res {
 		if v.WebsocketHash != "" {
 			websocketHash = append(websocketHash, v.WebsocketHash)
 		}
+		if v.IsHTTP2 {
+			http2HiddenIndex = append(http2HiddenIndex, v.HiddenIndex)
+		}
+		if v.IsSSE && v.SSEHash != "" {
+			sseHash = append(sseHash, v.SSEHash)
+		}
 	}
 	for _, v := range funk.ChunkStrings(websocketHash, 100) {
 		err := yakit.DeleteWebsocketFlowsByHTTPFlowHash(s.GetProjectDatabase(), v)
 		log.Error(err)
 	}
+	for _, v := range funk.ChunkStrings(http2HiddenIndex, 100) {
+		if err := yakit.DeleteHTTP2StreamsByHTTPFlowHiddenIndex(s.GetProjectDatabase(), v); err != nil {
+			log.Errorf("delete http2 streams failed: %s", err)
+		}
+	}
+	for _, v := range funk.ChunkStrings(sseHash, 100) {
+		if err := yakit.DeleteServerSentEventsBySSEHash(s.GetProjectDatabase(), v); err != nil {
+			log.Errorf("delete server-sent events failed: %s", err)
+		}
+	}
 
 	err := yakit.DeleteHTTPFlow(s.GetProjectDatabase(), r)
 	if err != nil {
@@ -49,7 +66,7 @@ func (s *Server) GetHTTPFlowByHash(_ context.Context, r *ypb.GetHTTPFlowByHashRe
 	if err != nil {
 		return nil, err
 	}
-	return model.ToHTTPFlowGRPCModelFull(flow)
+	return s.toHTTPFlowDetailGRPCModel(flow)
 }
 
 func (s *Server) GetHTTPFlowById(_ context.Context, r *ypb.GetHTTPFlowByIdRequest) (*ypb.HTTPFlow, error) {
@@ -57,7 +74,26 @@ func (s *Server) GetHTTPFlowById(_ context.Context, r *ypb.GetHTTPFlowByIdReques
 	if err != nil {
 		return nil, err
 	}
-	return model.ToHTTPFlowGRPCModelFull(flow)
+	return s.toHTTPFlowDetailGRPCModel(flow)
+}
+
+// toHTTPFlowDetailGRPCModel 在完整的 HTTPFlow 上附加 h2 流与帧时间线，
+// 流在响应结束之后才保存，所以不放进 HTTPFlow 的缓存，而是附加在副本上
+func (s *Server) toHTTPFlowDetailGRPCModel(flow *schema.HTTPFlow) (*ypb.HTTPFlow, error) {
+	m, err := model.ToHTTPFlowGRPCModelFull(flow)
+	if err != nil || !flow.IsHTTP2 {
+		return m, err
+	}
+	streams, err := yakit.QueryHTTP2StreamsByHTTPFlowHiddenIndex(s.GetProjectDatabase(), flow.HiddenIndex)
+	if err != nil {
+		log.Warnf("query http2 streams for %v failed: %s", flow.HiddenIndex, err)
+		return m, nil
+	}
+	m = proto.Clone(m).(*ypb.HTTPFlow)
+	for _, stream := range streams {
+		m.HTTP2Streams = append(m.HTTP2Streams, model.ToHTTP2StreamGRPCModel(stream))
+	}
+	return m, nil
 }
 
 func (s *Server) GetHTTPFlowByIds(_ context.Context, r *ypb.GetHTTPFlowByIdsRequest) (*ypb.HTTPFlows, error) {
@@ -67,7 +103,7 @@ func (s *Server) GetHTTPFlowByIds(_ context.Context, r *ypb.GetHTTPFlowByIdsRequ
 	db = bizhelper.ExactQueryInt64ArrayOr(db, "id", r.Ids)
 	db.Find(&g)
 	for _, flow := range g {
-		r, _ := model.ToHTTPFlowGRPCModelFull(flow)
+		r, _ := s.toHTTPFlowDetailGRPCModel(flow)
 		if r != nil {
 			full = append(full, r)
 		}
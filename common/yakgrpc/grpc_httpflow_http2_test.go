@@ -0,0 +1,54 @@
+package yakgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"golang.org/x/net/http2"
+)
+
+func TestGRPCMUSTPASS_HTTPFlow_HTTP2Streams(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	db := consts.GetGormProjectDatabase()
+
+	token := utils.RandStringBytes(16)
+	flow, err := yakit.CreateHTTPFlowFromHTTPWithBodySavedFromRaw(true,
+		[]byte("GET /"+token+" HTTP/2\r\nHost: www.example.com\r\n\r\n"),
+		[]byte("HTTP/2 200 OK\r\nContent-Length: 2\r\n\r\nok"),
+		"mitm", "https://www.example.com/"+token, "",
+	)
+	require.NoError(t, err)
+	info := lowhttp.NewH2StreamInfo(utils.RandStringBytes(10), 1)
+	info.AddWrittenFrame(lowhttp.H2FrameToServer, http2.FrameHeaders, http2.FlagHeadersEndHeaders|http2.FlagHeadersEndStream, 20)
+	info.AddWrittenFrame(lowhttp.H2FrameFromServer, http2.FrameData, http2.FlagDataEndStream, 2)
+	flow.IsHTTP2 = true
+	flow.HTTP2ConnectionId = info.ConnectionID
+	flow.HTTP2StreamId = 1
+	require.NoError(t, yakit.InsertHTTPFlow(db, flow))
+	require.NoError(t, yakit.SaveHTTP2StreamInfo(db, flow.HiddenIndex, schema.HTTP2StreamSideUpstream, info))
+
+	detail, err := client.GetHTTPFlowById(context.Background(), &ypb.GetHTTPFlowByIdRequest{Id: int64(flow.ID)})
+	require.NoError(t, err)
+	require.True(t, detail.GetIsHTTP2())
+	require.Equal(t, info.ConnectionID, detail.GetHTTP2ConnectionId())
+	require.Len(t, detail.GetHTTP2Streams(), 1)
+	stream := detail.GetHTTP2Streams()[0]
+	require.Equal(t, schema.HTTP2StreamSideUpstream, stream.GetSide())
+	require.Len(t, stream.GetFrames(), 2)
+	require.Equal(t, "END_STREAM", stream.GetFrames()[1].GetFlags())
+
+	// 删除 HTTPFlow 时同时删除 h2 流
+	_, err = client.DeleteHTTPFlows(context.Background(), &ypb.DeleteHTTPFlowRequest{Id: []int64{int64(flow.ID)}})
+	require.NoError(t, err)
+	streams, err := yakit.QueryHTTP2StreamsByHTTPFlowHiddenIndex(db, flow.HiddenIndex)
+	require.NoError(t, err)
+	require.Empty(t, streams)
+}
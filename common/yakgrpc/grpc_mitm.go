@@ -25,6 +25,7 @@ import (
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/crep"
 	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/ja3"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/mutate"
 	"github.com/yaklang/yaklang/common/utils"
@@ -827,6 +828,11 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 			rsp = httpctx.GetHijackedResponseBytes(req)
 		}
 
+		// server-sent events 已经逐个事件转发给客户端（规则在事件级别处理），不再对完整的响应应用规则或劫持
+		if httpctx.GetResponseIsSSE(req) {
+			return rsp
+		}
+
 		// 自动转发与否
 		if autoForward.IsSet() { // 如果其请求是自动转发的，响应也不应该劫持
 			httpctx.SetContextValueInfoFromRequest(req, httpctx.RESPONSE_CONTEXT_KEY_AutoFoward, true)
@@ -870,7 +876,6 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		if !httpctx.GetContextBoolInfoFromRequest(req, httpctx.RESPONSE_CONTEXT_KEY_ShouldBeHijackedFromRequest) {
 			return rsp
 		}
-
 		rsp, _, err := lowhttp.FixHTTPResponse(rsp)
 		if err != nil {
 			log.Errorf("fix http response packet failed: %s", err)
@@ -961,6 +966,39 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 			return rspModified
 		}
 	}
+	handleSSEEvent := func(req *http.Request, index int, event []byte, modifiable bool) (finalResult []byte) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Warnf("hijack server-sent event failed: %s", err)
+				finalResult = event
+			}
+		}()
+
+		finalResult = event
+		dropped := false
+		// 压缩或固定 Content-Length 的事件流会原样转发，规则不会生效，只记录原始事件
+		if modifiable && replacer.haveHijackingRules() {
+			rules, hooked, shouldBeDropped := replacer.hookSSEEvent(event)
+			httpctx.AppendMatchedRule(req, rules...)
+			if shouldBeDropped {
+				dropped = true
+			} else {
+				finalResult = hooked
+			}
+		}
+
+		// 保存每一个事件
+		modified := !dropped && !bytes.Equal(finalResult, event)
+		saved := finalResult
+		if dropped {
+			saved = event
+		}
+		yakit.SaveServerSentEventAsync(s.GetProjectDatabase(), httpctx.GetResponseSSEHash(req), index, saved, modified, dropped)
+		if dropped {
+			return nil
+		}
+		return finalResult
+	}
 	handleHijackWsRequest := func(raw []byte, req *http.Request, rsp *http.Response, ts int64) (finalResult []byte) {
 		defer func() {
 			if err := recover(); err != nil {
@@ -1494,6 +1532,10 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		startCreateFlow = time.Now()
 
 		flow.FixHTTPRequest(req)
+		yakit.FixHTTPFlowHTTP2(flow, req)
+		if ja4h, err := ja3.ParseJA4H(httpctx.GetBareRequestBytes(req)); err == nil {
+			flow.JA4H = ja4h.Fingerprint()
+		}
 
 		// Hidden Index 用来标注 MITM 劫持的顺序
 		flow.HiddenIndex = getPacketIndex()
@@ -1619,6 +1661,28 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 			}
 
 			log.Debugf("insert http flow %v cost: %s", truncate(reqUrl), time.Now().Sub(startCreateFlow))
+
+			// HTTP/2 流元数据：客户端一侧的流在响应写完(或被 RST)之后才保存，以便记录完整的帧时间线
+			if flow.IsHTTP2 {
+				hiddenIndex := flow.HiddenIndex
+				lowhttp.GetHTTP2StreamInfo(req).OnClose(func(info *lowhttp.H2StreamInfo) {
+					if err := yakit.SaveHTTP2StreamInfo(s.GetProjectDatabase(), hiddenIndex, schema.HTTP2StreamSideClient, info); err != nil {
+						log.Warnf("save http2 stream(client) failed: %s", err)
+					}
+				})
+				if upstream := lowhttp.GetHTTP2UpstreamStreamInfo(req); upstream != nil {
+					if err := yakit.SaveHTTP2StreamInfo(s.GetProjectDatabase(), hiddenIndex, schema.HTTP2StreamSideUpstream, upstream); err != nil {
+						log.Warnf("save http2 stream(upstream) failed: %s", err)
+					}
+					// 推送流在父请求结束之后才可能完成，每个推送流结束时单独保存
+					parent := flow
+					upstream.OnPushedFinish(func(pushed *lowhttp.H2PushedResource) {
+						if err := yakit.SaveHTTP2PushedResource(s.GetProjectDatabase(), parent, upstream, pushed); err != nil {
+							log.Warnf("save http2 pushed resource failed: %s", err)
+						}
+					})
+				}
+			}
 		}
 
 		// 存储KV，将flow ID作为key，bare request和bare response作为value
@@ -1651,7 +1715,10 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 	// 核心 MITM 服务器
 	var opts []crep.MITMConfig
 	for _, cert := range firstReq.GetCertificates() {
-		opts = append(opts, crep.MITM_MutualTLSClient(cert.CrtPem, cert.KeyPem, cert.GetCaCertificates()...))
+		opts = append(opts, crep.MITM_MutualTLSClientForHost(cert.GetHostPattern(), cert.CrtPem, cert.KeyPem, cert.GetCaCertificates()...))
+	}
+	for _, l := range firstReq.GetInvisibleListeners() {
+		opts = append(opts, crep.MITM_AddInvisibleListener(l.GetAddr(), l.GetTarget()))
 	}
 
 	mServer, err = crep.NewMITMServer(
@@ -1662,6 +1729,7 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		crep.MITM_SetHTTPRequestHijackRaw(handleHijackRequest),
 		crep.MITM_SetWebsocketRequestHijackRaw(handleHijackWsRequest),
 		crep.MITM_SetWebsocketResponseHijackRaw(handleHijackWsResponse),
+		crep.MITM_SetSSEEventHijackRaw(handleSSEEvent),
 		crep.MITM_SetHTTPResponseMirror(handleMirrorResponse),
 		crep.MITM_SetWebsocketHijackMode(true),
 		crep.MITM_SetHTTP2(firstReq.GetEnableHttp2()),
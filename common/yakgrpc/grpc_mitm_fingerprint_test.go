@@ -0,0 +1,115 @@
+package yakgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_MITM_ClientFingerprint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token := utils.RandStringBytes(100)
+	targetHost, targetPort := utils.DebugMockHTTPSEx(func(req []byte) []byte {
+		return []byte("HTTP/1.1 200 OK\r\nContent-Length: 3\r\n\r\nabc")
+	})
+	targetUrl := "https://" + utils.HostPort(targetHost, targetPort) + "/?token=" + token
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+	stream.Send(&ypb.MITMRequest{
+		Host: "127.0.0.1",
+		Port: uint32(mitmPort),
+	})
+	for {
+		data, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if data.GetMessage().GetIsMessage() && strings.Contains(string(data.GetMessage().GetMessage()), "starting mitm server") {
+			_, err := yak.Execute(`rsp, req := poc.Get(targetUrl, poc.proxy(mitmProxy), poc.save(false))~
+assert string(rsp.RawPacket).Contains("abc")
+sleep(1)
+cancel()
+`, map[string]any{"targetUrl": targetUrl, "mitmProxy": `http://` + utils.HostPort("127.0.0.1", mitmPort), "cancel": cancel})
+			require.NoError(t, err)
+		}
+	}
+
+	_, data, err := yakit.QueryHTTPFlow(consts.GetGormProjectDatabase(), &ypb.QueryHTTPFlowRequest{
+		Keyword: token,
+	})
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	require.Len(t, data[0].JA3, 32)
+	require.True(t, strings.HasPrefix(data[0].JA4, "t1"), data[0].JA4)
+	require.True(t, strings.HasPrefix(data[0].JA4H, "ge11"), data[0].JA4H)
+
+	rsp, err := client.QueryHTTPFlows(context.Background(), &ypb.QueryHTTPFlowRequest{Keyword: token})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 1)
+	require.Equal(t, data[0].JA3, rsp.GetData()[0].GetJA3())
+	require.Equal(t, data[0].JA4, rsp.GetData()[0].GetJA4())
+	require.Equal(t, data[0].JA4H, rsp.GetData()[0].GetJA4H())
+}
+
+func TestGRPCMUSTPASS_MITM_ClientFingerprint_H2(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	token := utils.RandStringBytes(100)
+	h2Host, h2Port := utils.DebugMockHTTP2(ctx, func(req []byte) []byte {
+		return []byte("abc")
+	})
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+	stream.Send(&ypb.MITMRequest{
+		Host:        "127.0.0.1",
+		Port:        uint32(mitmPort),
+		EnableHttp2: true,
+	})
+	for {
+		data, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if data.GetMessage().GetIsMessage() && strings.Contains(string(data.GetMessage().GetMessage()), "starting mitm server") {
+			_, err := yak.Execute(`packet = "GET /h2/fingerprint/" + token + " HTTP/2.0\r\nHost: " + target + "\r\n\r\n"
+rsp, req = poc.HTTP(packet, poc.proxy(mitmProxy), poc.http2(true), poc.https(true), poc.save(false), poc.retryTimes(3))~
+assert string(rsp).Contains("abc")
+sleep(1)
+cancel()
+`, map[string]any{
+				"token":     token,
+				"target":    utils.HostPort(h2Host, h2Port),
+				"mitmProxy": `http://` + utils.HostPort("127.0.0.1", mitmPort),
+				"cancel":    cancel,
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	_, data, err := yakit.QueryHTTPFlow(consts.GetGormProjectDatabase(), &ypb.QueryHTTPFlowRequest{
+		SearchURL: "/h2/fingerprint/" + token,
+	})
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	require.Len(t, data[0].JA3, 32)
+	require.True(t, strings.HasPrefix(data[0].JA4, "t1"), data[0].JA4)
+	// h2 客户端会协商 ALPN，JA4 的 ALPN 部分为 h2
+	require.Contains(t, data[0].JA4[:10], "h2", data[0].JA4)
+}
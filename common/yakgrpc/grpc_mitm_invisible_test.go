@@ -0,0 +1,61 @@
+package yakgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_MITM_InvisibleListener(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	token := utils.RandStringBytes(32)
+	targetHost, targetPort := utils.DebugMockHTTP([]byte("HTTP/1.1 200 OK\r\nContent-Length: 3\r\n\r\nabc"))
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	invisiblePort := utils.GetRandomAvailableTCPPort()
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+	stream.Send(&ypb.MITMRequest{
+		Host: "127.0.0.1",
+		Port: uint32(mitmPort),
+		InvisibleListeners: []*ypb.MITMInvisibleListener{
+			{Addr: utils.HostPort("127.0.0.1", invisiblePort), Target: utils.HostPort(targetHost, targetPort)},
+		},
+	})
+	for {
+		data, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if data.GetMessage().GetIsMessage() && strings.Contains(string(data.GetMessage().GetMessage()), "starting mitm server") {
+			require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", invisiblePort), 3))
+			// 不使用代理，直接连接 invisible 端口
+			rsp, err := lowhttp.HTTP(
+				lowhttp.WithHost("127.0.0.1"), lowhttp.WithPort(invisiblePort),
+				lowhttp.WithPacketBytes([]byte("GET /?token="+token+" HTTP/1.1\r\nHost: www.example.com\r\n\r\n")),
+				lowhttp.WithSaveHTTPFlow(false),
+			)
+			require.NoError(t, err)
+			require.True(t, strings.HasSuffix(string(rsp.RawPacket), "abc"))
+			time.Sleep(time.Second)
+			cancel()
+		}
+	}
+
+	_, data, err := yakit.QueryHTTPFlow(consts.GetGormProjectDatabase(), &ypb.QueryHTTPFlowRequest{
+		Keyword: token,
+	})
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+}
@@ -967,3 +967,30 @@ func (m *mitmReplacer) hook(isRequest, isResponse bool, origin []byte, args ...a
 
 	return matchedRules.MITMContentReplacers(), modifiedPacket, dropPacket
 }
+
+// hookSSEEvent 对 text/event-stream 中的单个事件应用响应替换规则(仅作用于事件内容)
+func (m *mitmReplacer) hookSSEEvent(event []byte) ([]*ypb.MITMContentReplacer, []byte, bool) {
+	var matchedRules Rules
+	if m == nil || len(m._hijackingRules) <= 0 {
+		return matchedRules.MITMContentReplacers(), event, false
+	}
+
+	dropEvent := false
+	modified := event
+	for _, rule := range m._hijackingRules {
+		if rule.NoReplace || !rule.EnableForResponse || !rule.EnableForBody {
+			continue
+		}
+		replaced, matched := m.replaceBody(rule.MITMContentReplacer, modified)
+		if !matched {
+			continue
+		}
+		if rule.GetDrop() {
+			dropEvent = true
+		} else {
+			modified = replaced
+		}
+		matchedRules = append(matchedRules, rule)
+	}
+	return matchedRules.MITMContentReplacers(), modified, dropEvent
+}
@@ -0,0 +1,138 @@
+package yakgrpc
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_MITM_ServerSentEvents_DropRule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		var writer io.Writer = w
+		if r.URL.Query().Get("gzip") != "" {
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			defer gw.Close()
+			writer = gw
+		}
+		// flush 之后未压缩的响应使用 chunked 编码，事件可以被修改
+		for _, event := range []string{"id: 1\ndata: keep\n\n", "id: 2\ndata: drop-me\n\n"} {
+			fmt.Fprint(writer, event)
+			if gw, ok := writer.(*gzip.Writer); ok {
+				gw.Flush()
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	token := utils.RandStringBytes(32)
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+	stream.Send(&ypb.MITMRequest{
+		Host: "127.0.0.1",
+		Port: uint32(mitmPort),
+	})
+
+	bodies := make(map[string]string)
+	for {
+		data, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if !data.GetMessage().GetIsMessage() || !strings.Contains(string(data.GetMessage().GetMessage()), "starting mitm server") {
+			continue
+		}
+		require.NoError(t, stream.Send(&ypb.MITMRequest{
+			SetContentReplacers: true,
+			Replacers: []*ypb.MITMContentReplacer{{
+				Rule:              "drop-me",
+				Drop:              true,
+				EnableForResponse: true,
+				EnableForBody:     true,
+			}},
+		}))
+		time.Sleep(500 * time.Millisecond)
+
+		proxyUrl, _ := url.Parse("http://" + utils.HostPort("127.0.0.1", mitmPort))
+		httpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyUrl), DisableCompression: true, DisableKeepAlives: true}, Timeout: 5 * time.Second}
+		for _, q := range []string{"plain", "gzip"} {
+			rsp, err := httpClient.Get(server.URL + "/?token=" + token + "&" + q + "=1")
+			require.NoError(t, err)
+			var body io.Reader = rsp.Body
+			if q == "gzip" {
+				body, err = gzip.NewReader(rsp.Body)
+				require.NoError(t, err)
+			}
+			raw, _ := io.ReadAll(body)
+			rsp.Body.Close()
+			bodies[q] = string(raw)
+		}
+		time.Sleep(time.Second)
+		cancel()
+	}
+
+	// 未压缩的事件流按照规则丢弃事件
+	require.Contains(t, bodies["plain"], "keep")
+	require.NotContains(t, bodies["plain"], "drop-me")
+	// 压缩的事件流原样转发，保存的事件也不能标记为丢弃
+	require.Contains(t, bodies["gzip"], "drop-me")
+
+	db := consts.GetGormProjectDatabase()
+	_, flows, err := yakit.QueryHTTPFlow(db, &ypb.QueryHTTPFlowRequest{Keyword: token})
+	require.NoError(t, err)
+	require.Len(t, flows, 2)
+	var ids []int64
+	for _, flow := range flows {
+		ids = append(ids, int64(flow.ID))
+		detail, err := client.GetHTTPFlowById(context.Background(), &ypb.GetHTTPFlowByIdRequest{Id: int64(flow.ID)})
+		require.NoError(t, err)
+		require.True(t, detail.GetIsSSE(), flow.Url)
+		require.Equal(t, flow.SSEHash, detail.GetSSEHash())
+
+		// 事件在后台异步保存
+		var events []*ypb.ServerSentEvent
+		require.Eventually(t, func() bool {
+			rsp, err := client.QueryServerSentEvents(context.Background(), &ypb.QueryServerSentEventsRequest{
+				SSEHash:    detail.GetSSEHash(),
+				Pagination: &ypb.Paging{Page: 1, Limit: 10},
+			})
+			require.NoError(t, err)
+			events = rsp.GetData()
+			return len(events) == 2
+		}, 5*time.Second, 100*time.Millisecond)
+		gzipped := strings.Contains(flow.Url, "gzip=1")
+		for _, e := range events {
+			require.False(t, e.GetIsModified())
+			require.Equal(t, !gzipped && strings.Contains(string(e.GetRaw()), "drop-me"), e.GetIsDropped(), "%v: %s", flow.Url, e.GetRaw())
+		}
+	}
+
+	// 删除 HTTPFlow 的时候一起删除事件
+	_, err = client.DeleteHTTPFlows(context.Background(), &ypb.DeleteHTTPFlowRequest{Id: ids})
+	require.NoError(t, err)
+	for _, flow := range flows {
+		_, events, err := yakit.QueryServerSentEventsBySSEHash(db, flow.SSEHash, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 0)
+	}
+}
@@ -0,0 +1,78 @@
+package yakgrpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/pcapx/passivefp"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func (s *Server) PassiveFingerprint(req *ypb.PassiveFingerprintRequest, stream ypb.Yak_PassiveFingerprintServer) error {
+	var opts []passivefp.Option
+	if pcapFile := req.GetPcapFile(); pcapFile != "" {
+		if ok, _ := utils.PathExists(pcapFile); !ok {
+			return utils.Errorf("pcap file %v not found", pcapFile)
+		}
+		opts = append(opts, passivefp.WithPcapFile(pcapFile))
+	} else {
+		opts = append(opts, passivefp.WithDevice(utils.StringArrayFilterEmpty(req.GetNetInterfaceList())...))
+	}
+	if req.GetBPFFilter() != "" {
+		opts = append(opts, passivefp.WithBPFFilter(req.GetBPFFilter()))
+	}
+	if !req.GetDisableSave() {
+		opts = append(opts, passivefp.WithDatabase(consts.GetGormProjectDatabase()))
+	}
+	if req.GetRuntimeId() != "" {
+		opts = append(opts, passivefp.WithRuntimeID(req.GetRuntimeId()))
+	}
+	if req.GetStateTTLSeconds() > 0 {
+		opts = append(opts, passivefp.WithStateTTL(time.Duration(req.GetStateTTLSeconds())*time.Second))
+	}
+
+	for r := range passivefp.Stream(stream.Context(), opts...) {
+		err := stream.Send(passiveFingerprintResultToGRPCModel(r))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func passiveFingerprintResultToGRPCModel(r *passivefp.Result) *ypb.PassiveFingerprintResult {
+	raw, _ := json.Marshal(r)
+	result := &ypb.PassiveFingerprintResult{
+		Type:       string(r.Type),
+		Host:       r.Host,
+		Port:       int64(r.Port),
+		Source:     r.Source,
+		Timestamp:  r.Timestamp.Unix(),
+		ResultJSON: raw,
+	}
+	if os := r.OS; os != nil {
+		result.OSClass = os.Class
+		result.OSName = os.Name
+		result.OSFlavor = os.Flavor
+		result.OSDistance = int64(os.Distance)
+	}
+	if c := r.Client; c != nil {
+		result.ClientKind = c.Kind
+		result.ClientName = c.Name
+		result.ClientVersion = c.Version
+		result.ClientRaw = c.Raw
+		result.JA3 = c.JA3
+		result.JA4 = c.JA4
+		result.SNI = c.SNI
+	}
+	if info := r.Service; info != nil {
+		result.ServiceName = info.ServiceName
+		result.ServiceProduct = info.ProductVerbose
+		result.ServiceVersion = info.Version
+		result.Banner = info.Banner
+		result.CPEs = info.CPEs
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package yakgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/pcapx/passivefp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_PassiveFingerprint(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := client.PassiveFingerprint(ctx, &ypb.PassiveFingerprintRequest{
+		PcapFile:    "../pcapx/pcaputil/tests/image.pcapng",
+		DisableSave: true,
+	})
+	require.NoError(t, err)
+	types := make(map[string]int)
+	for {
+		r, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		var raw passivefp.Result
+		require.NoError(t, json.Unmarshal(r.GetResultJSON(), &raw))
+		require.Equal(t, r.GetType(), string(raw.Type))
+		require.Equal(t, r.GetHost(), raw.Host)
+		if r.GetType() == string(passivefp.ResultClient) {
+			require.NotEmpty(t, r.GetClientKind())
+		}
+		types[r.GetType()]++
+	}
+	require.Greater(t, types[string(passivefp.ResultClient)], 0)
+	require.Greater(t, types[string(passivefp.ResultService)], 0)
+}
@@ -150,6 +150,11 @@ func (s *Server) QueryTrafficSession(ctx context.Context, req *ypb.QueryTrafficS
 			IsForceClosed:         item.IsForceClosed,
 			HaveClientHello:       item.HaveClientHello,
 			SNI:                   item.SNI,
+			JA3:                   item.JA3,
+			JA4:                   item.JA4,
+			JA3S:                  item.JA3S,
+			JA4S:                  item.JA4S,
+			JA4H:                  item.JA4H,
 		}
 	})
 	return &ypb.QueryTrafficSessionResponse{
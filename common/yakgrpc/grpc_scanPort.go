@@ -231,6 +231,11 @@ func (s *Server) PortScan(req *ypb.PortScanRequest, stream ypb.Yak_PortScanServe
 		reqParams.ExecParams = append(reqParams.ExecParams, &ypb.KVPair{Key: "host-alive-ports", Value: "22,80,443"})
 	}
 
+	// 设置之后 SYN 扫描会保存进度，中断之后使用相同的 ID、目标和端口再次扫描会从中断的位置继续
+	if req.GetCheckpointTaskId() != "" {
+		reqParams.ExecParams = append(reqParams.ExecParams, &ypb.KVPair{Key: "checkpoint-task-id", Value: req.GetCheckpointTaskId()})
+	}
+
 	if req.GetBasicCrawlerEnableJSParser() {
 		reqParams.ExecParams = append(reqParams.ExecParams, &ypb.KVPair{Key: "basic-crawler-enable-jsparser", Value: ""})
 	}
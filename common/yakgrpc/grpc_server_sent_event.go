@@ -0,0 +1,31 @@
+package yakgrpc
+
+import (
+	"context"
+
+	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func (s *Server) QueryServerSentEvents(ctx context.Context, req *ypb.QueryServerSentEventsRequest) (*ypb.QueryServerSentEventsResponse, error) {
+	paging, events, err := yakit.QueryServerSentEventsBySSEHash(
+		s.GetProjectDatabase(),
+		req.GetSSEHash(),
+		int(req.GetPagination().GetPage()),
+		int(req.GetPagination().GetLimit()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	data := funk.Map(events, func(i *schema.ServerSentEvent) *ypb.ServerSentEvent {
+		return i.ToGRPCModel()
+	}).([]*ypb.ServerSentEvent)
+	return &ypb.QueryServerSentEventsResponse{
+		Pagination: req.Pagination,
+		Data:       data,
+		Total:      int64(paging.TotalRecord),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package yakgrpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/suricata/eve"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func (s *Server) SuricataEve(req *ypb.SuricataEveRequest, stream ypb.Yak_SuricataEveServer) error {
+	var rules []*surirule.Rule
+	if raw := strings.TrimSpace(req.GetRules()); raw != "" {
+		var err error
+		rules, err = surirule.Parse(raw)
+		if err != nil {
+			return utils.Errorf("parse suricata rules failed: %s", err)
+		}
+	}
+
+	opts := []eve.RunOption{eve.WithStateful(!req.GetDisableStateful())}
+	if pcapFile := req.GetPcapFile(); pcapFile != "" {
+		if ok, _ := utils.PathExists(pcapFile); !ok {
+			return utils.Errorf("pcap file %v not found", pcapFile)
+		}
+		opts = append(opts, eve.WithPcapFile(pcapFile))
+	} else {
+		opts = append(opts, eve.WithDevice(utils.StringArrayFilterEmpty(req.GetNetInterfaceList())...))
+	}
+	if req.GetBPFFilter() != "" {
+		opts = append(opts, eve.WithBPFFilter(req.GetBPFFilter()))
+	}
+	if types := utils.StringArrayFilterEmpty(req.GetEventTypes()); len(types) > 0 {
+		opts = append(opts, eve.WithLoggerOptions(eve.WithEventTypes(types...)))
+	}
+	if req.GetCommunityIDSeed() > 0 {
+		opts = append(opts, eve.WithLoggerOptions(eve.WithCommunityIDSeed(uint16(req.GetCommunityIDSeed()))))
+	}
+
+	for e := range eve.Stream(stream.Context(), rules, opts...) {
+		raw, err := json.Marshal(e)
+		if err != nil {
+			return utils.Errorf("marshal eve event failed: %s", err)
+		}
+		err = stream.Send(&ypb.SuricataEveEvent{
+			EventType:   e.EventType,
+			Timestamp:   e.Timestamp,
+			FlowID:      e.FlowID,
+			CommunityID: e.CommunityID,
+			SrcIP:       e.SrcIP,
+			SrcPort:     int64(e.SrcPort),
+			DestIP:      e.DestIP,
+			DestPort:    int64(e.DestPort),
+			Proto:       e.Proto,
+			EveJSON:     raw,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package yakgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/suricata/eve"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_SuricataEve(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := client.SuricataEve(ctx, &ypb.SuricataEveRequest{
+		PcapFile:   "../pcapx/pcaputil/tests/image.pcapng",
+		Rules:      `alert http any any -> any any (msg:"eve grpc http"; flow:to_server; sid:1000002; rev:1;)`,
+		EventTypes: []string{eve.EventTypeAlert, eve.EventTypeFlow},
+	})
+	require.NoError(t, err)
+	types := make(map[string]int)
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		var raw eve.Event
+		require.NoError(t, json.Unmarshal(e.GetEveJSON(), &raw))
+		require.Equal(t, e.GetEventType(), raw.EventType)
+		require.Equal(t, e.GetFlowID(), raw.FlowID)
+		types[e.GetEventType()]++
+	}
+	require.Greater(t, types[eve.EventTypeFlow], 0)
+	require.Greater(t, types[eve.EventTypeAlert], 0)
+	require.Len(t, types, 2)
+}
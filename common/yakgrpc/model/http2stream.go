@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func ToHTTP2StreamGRPCModel(s *schema.HTTP2Stream) *ypb.HTTP2Stream {
+	stream := &ypb.HTTP2Stream{
+		Id:                 int64(s.ID),
+		Side:               s.Side,
+		ConnectionId:       s.ConnectionId,
+		StreamId:           int64(s.StreamId),
+		Weight:             int64(s.Weight),
+		StreamDep:          int64(s.StreamDep),
+		Exclusive:          s.Exclusive,
+		ResetErrCode:       s.ResetErrCode,
+		IsPushed:           s.IsPushed,
+		PromisedByStreamId: int64(s.PromisedByStreamId),
+	}
+	if s.FrameTimeline == "" {
+		return stream
+	}
+	var frames []*lowhttp.H2FrameEvent
+	if err := json.Unmarshal([]byte(s.FrameTimeline), &frames); err != nil {
+		log.Warnf("unmarshal h2 frame timeline failed: %s", err)
+		return stream
+	}
+	for _, f := range frames {
+		stream.Frames = append(stream.Frames, &ypb.HTTP2FrameEvent{
+			Timestamp:        f.Timestamp,
+			Direction:        f.Direction,
+			Type:             f.Type,
+			Flags:            f.Flags,
+			Length:           int64(f.Length),
+			ErrCode:          f.ErrCode,
+			PromisedStreamId: f.PromisedStreamID,
+		})
+	}
+	return stream
+}
@@ -84,10 +84,18 @@ func toHTTPFlowGRPCModel(f *schema.HTTPFlow, full bool) (*ypb.HTTPFlow, error) {
 		NoFixContentLength:         f.NoFixContentLength,
 		IsWebsocket:                f.IsWebsocket,
 		WebsocketHash:              f.WebsocketHash,
+		IsSSE:                      f.IsSSE,
+		SSEHash:                    f.SSEHash,
 		IsTooLargeResponse:         f.IsTooLargeResponse,
 		TooLargeResponseBodyFile:   f.TooLargeResponseBodyFile,
 		TooLargeResponseHeaderFile: f.TooLargeResponseHeaderFile,
 		Payloads:                   strings.Split(f.Payload, ","),
+		JA3:                        f.JA3,
+		JA4:                        f.JA4,
+		JA4H:                       f.JA4H,
+		IsHTTP2:                    f.IsHTTP2,
+		HTTP2ConnectionId:          f.HTTP2ConnectionId,
+		HTTP2StreamId:              int64(f.HTTP2StreamId),
 	}
 	// 设置 title
 	var (
@@ -0,0 +1,168 @@
+package yakit
+
+import (
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+)
+
+func init() {
+	RegisterPostInitDatabaseFunction(func() error {
+		db := consts.GetGormProfileDatabase()
+		if db == nil {
+			return nil
+		}
+		return LoadClientCertificatesFromDatabase(db)
+	})
+}
+
+// NewClientCertificateFromP12 从 PKCS#12 创建客户端证书记录，p12 会被解码为 PEM 保存，密码不会落库
+func NewClientCertificateFromP12(name, hostPattern string, p12 []byte, password string) (*schema.ClientCertificate, error) {
+	crt, key, cas, err := tlsutils.LoadP12ToPEM(p12, password)
+	if err != nil {
+		return nil, utils.Errorf("load p12 failed: %s", err)
+	}
+	return NewClientCertificateFromPEM(name, hostPattern, crt, key, cas...)
+}
+
+// NewClientCertificateFromPEM 创建一个 PEM 格式的客户端证书记录，支持国密 SM2 证书
+func NewClientCertificateFromPEM(name, hostPattern string, crt, key []byte, cas ...[]byte) (*schema.ClientCertificate, error) {
+	isSM2, err := netx.CheckClientCertificatePEM(crt, key)
+	if err != nil {
+		return nil, err
+	}
+	var caPem []byte
+	for _, ca := range cas {
+		caPem = append(caPem, ca...)
+		caPem = append(caPem, '\n')
+	}
+	return &schema.ClientCertificate{
+		Name:        name,
+		HostPattern: hostPattern,
+		CrtPem:      crt,
+		KeyPem:      key,
+		CaPem:       caPem,
+		IsSM2:       isSM2,
+	}, nil
+}
+
+// CreateOrUpdateClientCertificate 保存客户端证书，保存后重新加载证书库使修改立即生效
+func CreateOrUpdateClientCertificate(db *gorm.DB, c *schema.ClientCertificate) error {
+	if c.HostPattern == "" {
+		return utils.Error("client certificate host pattern is empty")
+	}
+	c.Hash = c.CalcHash()
+	var saved schema.ClientCertificate
+	// Assign 结构体时 gorm 会忽略零值字段，Disabled 改回 false 不会生效，所以使用 map
+	if ret := db.Model(&schema.ClientCertificate{}).Where("hash = ?", c.Hash).Assign(map[string]interface{}{
+		"name":         c.Name,
+		"host_pattern": c.HostPattern,
+		"crt_pem":      c.CrtPem,
+		"key_pem":      c.KeyPem,
+		"ca_pem":       c.CaPem,
+		"is_sm2":       c.IsSM2,
+		"disabled":     c.Disabled,
+		"hash":         c.Hash,
+	}).FirstOrCreate(&saved); ret.Error != nil {
+		return utils.Errorf("create/update ClientCertificate failed: %s", ret.Error)
+	}
+	c.Model = saved.Model
+	return LoadClientCertificatesFromDatabase(db)
+}
+
+func GetClientCertificateByID(db *gorm.DB, id int64) (*schema.ClientCertificate, error) {
+	var c schema.ClientCertificate
+	if db := db.Model(&schema.ClientCertificate{}).Where("id = ?", id).First(&c); db.Error != nil {
+		return nil, utils.Errorf("get ClientCertificate failed: %s", db.Error)
+	}
+	return &c, nil
+}
+
+func QueryClientCertificates(db *gorm.DB, keyword string, page, limit int) (*bizhelper.Paginator, []*schema.ClientCertificate, error) {
+	db = db.Model(&schema.ClientCertificate{})
+	if keyword != "" {
+		db = bizhelper.FuzzSearchWithStringArrayOrEx(db, []string{"name", "host_pattern"}, []string{keyword}, false)
+	}
+	db = bizhelper.QueryOrder(db, "id", "asc")
+
+	var ret []*schema.ClientCertificate
+	paging, db := bizhelper.Paging(db, page, limit, &ret)
+	if db.Error != nil {
+		return nil, nil, utils.Errorf("paging failed: %s", db.Error)
+	}
+	return paging, ret, nil
+}
+
+func GetAllEnabledClientCertificates(db *gorm.DB) ([]*schema.ClientCertificate, error) {
+	var ret []*schema.ClientCertificate
+	if db := db.Model(&schema.ClientCertificate{}).Where("disabled = ?", false).Order("id asc").Find(&ret); db.Error != nil {
+		return nil, utils.Errorf("query ClientCertificate failed: %s", db.Error)
+	}
+	return ret, nil
+}
+
+func SetClientCertificateDisabled(db *gorm.DB, id int64, disabled bool) error {
+	if ret := db.Model(&schema.ClientCertificate{}).Where("id = ?", id).Update("disabled", disabled); ret.Error != nil {
+		return utils.Errorf("update ClientCertificate failed: %s", ret.Error)
+	}
+	return LoadClientCertificatesFromDatabase(db)
+}
+
+func DeleteClientCertificateByID(db *gorm.DB, id ...int64) error {
+	if len(id) == 0 {
+		return nil
+	}
+	if ret := db.Model(&schema.ClientCertificate{}).Where("id in (?)", id).Unscoped().Delete(&schema.ClientCertificate{}); ret.Error != nil {
+		return utils.Errorf("delete ClientCertificate failed: %s", ret.Error)
+	}
+	return LoadClientCertificatesFromDatabase(db)
+}
+
+func DeleteAllClientCertificates(db *gorm.DB) error {
+	if ret := db.Model(&schema.ClientCertificate{}).Unscoped().Delete(&schema.ClientCertificate{}); ret.Error != nil {
+		return utils.Errorf("delete ClientCertificate failed: %s", ret.Error)
+	}
+	return LoadClientCertificatesFromDatabase(db)
+}
+
+var (
+	loadClientCertificatesMutex = new(sync.Mutex)
+	// 只移除从数据库加载的证书，不影响 MITM 等其他地方注册的证书
+	removeLoadedClientCertificates []func()
+)
+
+// LoadClientCertificatesFromDatabase 把数据库中所有启用的客户端证书加载到 netx，lowhttp / MITM 上游 / netx.DialX 会按照 host 自动选择
+// 本文件中修改证书库的函数都会重新调用，直接修改数据库之后需要手动调用
+func LoadClientCertificatesFromDatabase(db *gorm.DB) error {
+	loadClientCertificatesMutex.Lock()
+	defer loadClientCertificatesMutex.Unlock()
+
+	certs, err := GetAllEnabledClientCertificates(db)
+	if err != nil {
+		return err
+	}
+	for _, remove := range removeLoadedClientCertificates {
+		remove()
+	}
+	removeLoadedClientCertificates = nil
+	for _, c := range certs {
+		remove, err := loadClientCertificate(c)
+		if err != nil {
+			log.Errorf("load client certificate %v(%v) failed: %s", c.Name, c.HostPattern, err)
+			continue
+		}
+		removeLoadedClientCertificates = append(removeLoadedClientCertificates, remove)
+	}
+	return nil
+}
+
+func loadClientCertificate(c *schema.ClientCertificate) (func(), error) {
+	return netx.RegisterClientCertificateForHost(c.HostPattern, c.CrtPem, c.KeyPem, c.CaPem)
+}
@@ -0,0 +1,170 @@
+package yakit
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+// FixHTTPFlowHTTP2 把请求上下文中 MITM 客户端一侧的 h2 流信息写入 HTTPFlow
+func FixHTTPFlowHTTP2(flow *schema.HTTPFlow, req *http.Request) {
+	if flow == nil || req == nil {
+		return
+	}
+	info := lowhttp.GetHTTP2StreamInfo(req)
+	if info == nil {
+		info = lowhttp.GetHTTP2UpstreamStreamInfo(req)
+	}
+	if info == nil {
+		return
+	}
+	flow.IsHTTP2 = true
+	flow.HTTP2ConnectionId = info.ConnectionID
+	flow.HTTP2StreamId = int(info.StreamID)
+}
+
+func NewHTTP2StreamFromInfo(hiddenIndex string, side string, info *lowhttp.H2StreamInfo) *schema.HTTP2Stream {
+	frames := info.Frames()
+	timeline, err := json.Marshal(frames)
+	if err != nil {
+		timeline = []byte("[]")
+	}
+	s := &schema.HTTP2Stream{
+		HTTPFlowHiddenIndex: hiddenIndex,
+		Side:                side,
+		ConnectionId:        info.ConnectionID,
+		StreamId:            int(info.StreamID),
+		Weight:              int(info.Weight) + 1,
+		StreamDep:           int(info.StreamDep),
+		Exclusive:           info.Exclusive,
+		ResetErrCode:        info.ResetErrCode,
+		FrameTimeline:       string(timeline),
+		FrameCount:          len(frames),
+	}
+	s.Hash = s.CalcHash()
+	return s
+}
+
+// SaveHTTP2StreamInfo 保存(或更新)一个 h2 流的元数据与帧时间线
+func SaveHTTP2StreamInfo(db *gorm.DB, hiddenIndex string, side string, info *lowhttp.H2StreamInfo) error {
+	if info == nil {
+		return utils.Error("empty h2 stream info")
+	}
+	return CreateOrUpdateHTTP2Stream(db, NewHTTP2StreamFromInfo(hiddenIndex, side, info))
+}
+
+func CreateOrUpdateHTTP2Stream(db *gorm.DB, s *schema.HTTP2Stream) error {
+	s.Hash = s.CalcHash()
+	db = db.Model(&schema.HTTP2Stream{})
+	if db := db.Where("hash = ?", s.Hash).Assign(s).FirstOrCreate(&schema.HTTP2Stream{}); db.Error != nil {
+		return utils.Errorf("create/update HTTP2Stream failed: %s", db.Error)
+	}
+	return nil
+}
+
+// ParseHTTP2FrameTimeline 解析 HTTP2Stream 中保存的帧时间线
+func ParseHTTP2FrameTimeline(s *schema.HTTP2Stream) ([]*lowhttp.H2FrameEvent, error) {
+	var frames []*lowhttp.H2FrameEvent
+	if s == nil || s.FrameTimeline == "" {
+		return frames, nil
+	}
+	if err := json.Unmarshal([]byte(s.FrameTimeline), &frames); err != nil {
+		return nil, utils.Errorf("unmarshal h2 frame timeline failed: %s", err)
+	}
+	return frames, nil
+}
+
+func QueryHTTP2StreamsByHTTPFlowHiddenIndex(db *gorm.DB, hiddenIndex string) ([]*schema.HTTP2Stream, error) {
+	var ret []*schema.HTTP2Stream
+	if db := db.Model(&schema.HTTP2Stream{}).Where(
+		"http_flow_hidden_index = ?", hiddenIndex,
+	).Order("stream_id asc").Find(&ret); db.Error != nil {
+		return nil, utils.Errorf("query HTTP2Stream failed: %s", db.Error)
+	}
+	return ret, nil
+}
+
+type HTTP2StreamFilter struct {
+	ConnectionId string
+	StreamId     int
+	Side         string
+	OnlyPushed   bool
+	OnlyReset    bool
+}
+
+func FilterHTTP2Stream(db *gorm.DB, filter *HTTP2StreamFilter) *gorm.DB {
+	db = db.Model(&schema.HTTP2Stream{})
+	if filter == nil {
+		return db
+	}
+	db = bizhelper.ExactQueryString(db, "connection_id", filter.ConnectionId)
+	db = bizhelper.ExactQueryString(db, "side", filter.Side)
+	if filter.StreamId > 0 {
+		db = db.Where("stream_id = ?", filter.StreamId)
+	}
+	if filter.OnlyPushed {
+		db = db.Where("is_pushed = ?", true)
+	}
+	if filter.OnlyReset {
+		db = db.Where("reset_err_code <> ''")
+	}
+	return db
+}
+
+func QueryHTTP2Streams(db *gorm.DB, filter *HTTP2StreamFilter, page int, limit int) (*bizhelper.Paginator, []*schema.HTTP2Stream, error) {
+	db = FilterHTTP2Stream(db, filter)
+	db = bizhelper.QueryOrder(db, "id", "desc")
+
+	var ret []*schema.HTTP2Stream
+	paging, db := bizhelper.Paging(db, page, limit, &ret)
+	if db.Error != nil {
+		return nil, nil, utils.Errorf("paging failed: %s", db.Error)
+	}
+	return paging, ret, nil
+}
+
+func DeleteHTTP2StreamsByHTTPFlowHiddenIndex(db *gorm.DB, hiddenIndex []string) error {
+	if db := db.Model(&schema.HTTP2Stream{}).Where(
+		"http_flow_hidden_index in (?)", hiddenIndex,
+	).Unscoped().Delete(&schema.HTTP2Stream{}); db.Error != nil {
+		return db.Error
+	}
+	return nil
+}
+
+// SaveHTTP2PushedResource 把一个服务器推送(PUSH_PROMISE)的资源保存为独立的 HTTPFlow，并记录推送流，
+// 应该在推送流结束(H2StreamInfo.OnPushedFinish)之后调用
+func SaveHTTP2PushedResource(db *gorm.DB, parent *schema.HTTPFlow, info *lowhttp.H2StreamInfo, pushed *lowhttp.H2PushedResource) error {
+	if parent == nil || info == nil || pushed == nil {
+		return nil
+	}
+	u, err := lowhttp.ExtractURLFromHTTPRequestRaw(pushed.Request, parent.IsHTTPS)
+	if err != nil {
+		return utils.Errorf("extract url from pushed request failed: %s", err)
+	}
+	flow, err := CreateHTTPFlowFromHTTPWithBodySavedFromRaw(parent.IsHTTPS, pushed.Request, pushed.Response(), parent.SourceType, u.String(), parent.RemoteAddr)
+	if err != nil {
+		return err
+	}
+	flow.HiddenIndex = parent.HiddenIndex + "_push_" + utils.InterfaceToString(pushed.PromisedStreamID)
+	flow.IsHTTP2 = true
+	flow.HTTP2ConnectionId = info.ConnectionID
+	flow.HTTP2StreamId = int(pushed.PromisedStreamID)
+	flow.AddTagToFirst("[HTTP2 PUSH]")
+	if err := InsertHTTPFlow(db, flow); err != nil {
+		return err
+	}
+	pushedInfo := pushed.Info
+	if pushedInfo == nil {
+		pushedInfo = lowhttp.NewH2StreamInfo(info.ConnectionID, pushed.PromisedStreamID)
+	}
+	stream := NewHTTP2StreamFromInfo(flow.HiddenIndex, schema.HTTP2StreamSideUpstream, pushedInfo)
+	stream.IsPushed = true
+	stream.PromisedByStreamId = int(info.StreamID)
+	return CreateOrUpdateHTTP2Stream(db, stream)
+}
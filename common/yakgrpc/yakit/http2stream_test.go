@@ -0,0 +1,42 @@
+package yakit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"golang.org/x/net/http2"
+)
+
+func TestSaveHTTP2StreamInfo(t *testing.T) {
+	db := consts.GetGormProjectDatabase()
+	hiddenIndex := utils.RandStringBytes(20)
+	defer DeleteHTTP2StreamsByHTTPFlowHiddenIndex(db, []string{hiddenIndex})
+
+	info := lowhttp.NewH2StreamInfo(utils.RandStringBytes(10), 3)
+	info.AddWrittenFrame(lowhttp.H2FrameToServer, http2.FrameHeaders, http2.FlagHeadersEndHeaders|http2.FlagHeadersEndStream, 20)
+	info.AddWrittenFrame(lowhttp.H2FrameFromServer, http2.FrameRSTStream, 0, 4)
+	info.ResetErrCode = http2.ErrCodeCancel.String()
+
+	require.NoError(t, SaveHTTP2StreamInfo(db, hiddenIndex, schema.HTTP2StreamSideUpstream, info))
+	// save again should update instead of duplicate
+	require.NoError(t, SaveHTTP2StreamInfo(db, hiddenIndex, schema.HTTP2StreamSideUpstream, info))
+
+	streams, err := QueryHTTP2StreamsByHTTPFlowHiddenIndex(db, hiddenIndex)
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Equal(t, 3, streams[0].StreamId)
+	require.Equal(t, 16, streams[0].Weight)
+
+	frames, err := ParseHTTP2FrameTimeline(streams[0])
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	require.Equal(t, "END_STREAM|END_HEADERS", frames[0].Flags)
+
+	_, reset, err := QueryHTTP2Streams(db, &HTTP2StreamFilter{ConnectionId: info.ConnectionID, OnlyReset: true}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, reset, 1)
+}
@@ -679,7 +679,9 @@ no_fix_content_length, hash,url, path, method,
 content_type, status_code, source_type,
 get_params_total, post_params_total, cookie_params_total,
 ip_address, remote_addr, ip_integer,
-tags, is_websocket, websocket_hash, runtime_id, from_plugin,
+tags, is_websocket, websocket_hash, is_sse, sse_hash, runtime_id, from_plugin,
+is_http2, http2_connection_id, http2_stream_id,
+ja3, ja4, ja4h,
 
 -- request is larger than 200K, return empty string
 LENGTH(request) > 204800 as is_request_oversize,
@@ -0,0 +1,124 @@
+package yakit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// CertificateDomainTag 标记从证书 SAN 中得到的域名
+const CertificateDomainTag = "tls-cert"
+
+func CreateOrUpdatePortCertificate(db *gorm.DB, hash string, i interface{}) error {
+	db = db.Model(&schema.PortCertificate{})
+
+	if db := db.Where("hash = ?", hash).Assign(i).FirstOrCreate(&schema.PortCertificate{}); db.Error != nil {
+		return utils.Errorf("create/update PortCertificate failed: %s", db.Error)
+	}
+	return nil
+}
+
+func CreateOrUpdatePortTLSFingerprint(db *gorm.DB, hash string, i interface{}) error {
+	db = db.Model(&schema.PortTLSFingerprint{})
+
+	if db := db.Where("hash = ?", hash).Assign(i).FirstOrCreate(&schema.PortTLSFingerprint{}); db.Error != nil {
+		return utils.Errorf("create/update PortTLSFingerprint failed: %s", db.Error)
+	}
+	return nil
+}
+
+func CreateOrUpdatePortBanner(db *gorm.DB, hash string, i interface{}) error {
+	db = db.Model(&schema.PortBanner{})
+
+	if db := db.Where("hash = ?", hash).Assign(i).FirstOrCreate(&schema.PortBanner{}); db.Error != nil {
+		return utils.Errorf("create/update PortBanner failed: %s", db.Error)
+	}
+	return nil
+}
+
+func QueryPortCertificates(db *gorm.DB, host string, port int) ([]*schema.PortCertificate, error) {
+	var certs []*schema.PortCertificate
+	if db := db.Model(&schema.PortCertificate{}).Where("host = ? AND port = ?", host, port).Order("chain_index asc").Find(&certs); db.Error != nil {
+		return nil, utils.Errorf("query PortCertificate failed: %s", db.Error)
+	}
+	return certs, nil
+}
+
+func QueryPortBanners(db *gorm.DB, host string, port int) ([]*schema.PortBanner, error) {
+	var banners []*schema.PortBanner
+	if db := db.Model(&schema.PortBanner{}).Where("host = ? AND port = ?", host, port).Find(&banners); db.Error != nil {
+		return nil, utils.Errorf("query PortBanner failed: %s", db.Error)
+	}
+	return banners, nil
+}
+
+func GetPortTLSFingerprint(db *gorm.DB, host string, port int) (*schema.PortTLSFingerprint, error) {
+	var fp schema.PortTLSFingerprint
+	if db := db.Model(&schema.PortTLSFingerprint{}).Where("host = ? AND port = ?", host, port).Last(&fp); db.Error != nil {
+		return nil, utils.Errorf("get PortTLSFingerprint failed: %s", db.Error)
+	}
+	return &fp, nil
+}
+
+// SaveDomainFromCertificate 保存证书 SAN 中的域名，与 SaveDomain 不同，不会访问域名获取网页标题
+func SaveDomainFromCertificate(db *gorm.DB, domain string, ip string) error {
+	d := &schema.Domain{
+		Domain: domain,
+		Tags:   CertificateDomainTag,
+	}
+	if utils.IsIPv4(ip) || utils.IsIPv6(ip) {
+		host, err := GetHostByIP(db, ip)
+		if err != nil {
+			host, err = NewHost(ip)
+			if err != nil {
+				return utils.Errorf("create ip host failed: %s", err)
+			}
+		}
+		domains := utils.PrettifyListFromStringSplited(host.Domains, ",")
+		if !utils.StringArrayContains(domains, domain) {
+			domains = append(domains, domain)
+			sort.Strings(domains)
+			host.Domains = strings.Join(domains, ",")
+			_ = CreateOrUpdateHost(db, host.IP, host)
+		}
+		d.IPAddr, d.IPInteger = ip, host.IPInteger
+	}
+	return CreateOrUpdateDomain(db, d.CalcHash(), d)
+}
+
+func CreateOrUpdateHostOS(db *gorm.DB, hash string, i interface{}) error {
+	db = db.Model(&schema.HostOS{})
+
+	if db := db.Where("hash = ?", hash).Assign(i).FirstOrCreate(&schema.HostOS{}); db.Error != nil {
+		return utils.Errorf("create/update HostOS failed: %s", db.Error)
+	}
+	return nil
+}
+
+func CreateOrUpdateHostClientApp(db *gorm.DB, hash string, i interface{}) error {
+	db = db.Model(&schema.HostClientApp{})
+
+	if db := db.Where("hash = ?", hash).Assign(i).FirstOrCreate(&schema.HostClientApp{}); db.Error != nil {
+		return utils.Errorf("create/update HostClientApp failed: %s", db.Error)
+	}
+	return nil
+}
+
+func QueryHostOS(db *gorm.DB, host string) ([]*schema.HostOS, error) {
+	var items []*schema.HostOS
+	if db := db.Model(&schema.HostOS{}).Where("host = ?", host).Find(&items); db.Error != nil {
+		return nil, utils.Errorf("query HostOS failed: %s", db.Error)
+	}
+	return items, nil
+}
+
+func QueryHostClientApps(db *gorm.DB, host string) ([]*schema.HostClientApp, error) {
+	var items []*schema.HostClientApp
+	if db := db.Model(&schema.HostClientApp{}).Where("host = ?", host).Find(&items); db.Error != nil {
+		return nil, utils.Errorf("query HostClientApp failed: %s", db.Error)
+	}
+	return items, nil
+}
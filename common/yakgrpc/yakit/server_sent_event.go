@@ -0,0 +1,134 @@
+package yakit
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/schema"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func newServerSentEvent(owner string, index int, raw []byte, modified bool, dropped bool) *schema.ServerSentEvent {
+	event := lowhttp.ParseSSEEvent(raw)
+	e := &schema.ServerSentEvent{
+		SSEHash:    owner,
+		EventIndex: index,
+		EventId:    event.Id,
+		EventType:  event.Event,
+		Retry:      event.Retry,
+		QuotedData: strconv.Quote(event.Data),
+		QuotedRaw:  strconv.Quote(string(raw)),
+		Timestamp:  time.Now().UnixNano(),
+		IsModified: modified,
+		IsDropped:  dropped,
+	}
+	e.Hash = e.CalcHash()
+	return e
+}
+
+func saveServerSentEvent(db *gorm.DB, e *schema.ServerSentEvent) error {
+	db = db.Model(&schema.ServerSentEvent{})
+	if db := db.Where("hash = ?", e.Hash).Assign(e).FirstOrCreate(&schema.ServerSentEvent{}); db.Error != nil {
+		return utils.Errorf("create/update ServerSentEvent failed: %s", db.Error)
+	}
+	return nil
+}
+
+// SaveServerSentEvent 保存一个 SSE 事件，raw 为最终转发给客户端的事件块(被丢弃时为原始事件块)
+func SaveServerSentEvent(db *gorm.DB, owner string, index int, raw []byte, modified bool, dropped bool) error {
+	return saveServerSentEvent(db, newServerSentEvent(owner, index, raw, modified, dropped))
+}
+
+type serverSentEventSaveItem struct {
+	db    *gorm.DB
+	event *schema.ServerSentEvent
+}
+
+const serverSentEventSaveBatchSize = 200
+
+var (
+	serverSentEventSaveOnce  sync.Once
+	serverSentEventSaveQueue = make(chan *serverSentEventSaveItem, 4096)
+)
+
+// SaveServerSentEventAsync 与 SaveServerSentEvent 相同，但是由后台 goroutine 批量写入数据库，用于转发事件流时不阻塞客户端；
+// 队列满的时候退化为同步写入
+func SaveServerSentEventAsync(db *gorm.DB, owner string, index int, raw []byte, modified bool, dropped bool) {
+	serverSentEventSaveOnce.Do(func() {
+		go serverSentEventSaveLoop()
+	})
+	item := &serverSentEventSaveItem{db: db, event: newServerSentEvent(owner, index, raw, modified, dropped)}
+	select {
+	case serverSentEventSaveQueue <- item:
+	default:
+		if err := saveServerSentEvent(db, item.event); err != nil {
+			log.Warnf("save server-sent event failed: %s", err)
+		}
+	}
+}
+
+func serverSentEventSaveLoop() {
+	for first := range serverSentEventSaveQueue {
+		batch := []*serverSentEventSaveItem{first}
+	COLLECT:
+		for len(batch) < serverSentEventSaveBatchSize {
+			select {
+			case item := <-serverSentEventSaveQueue:
+				batch = append(batch, item)
+			default:
+				break COLLECT
+			}
+		}
+		// 切换项目之后数据库会变化，同一个数据库的事件放在一个事务中写入
+		for len(batch) > 0 {
+			db := batch[0].db
+			var rest []*serverSentEventSaveItem
+			err := utils.GormTransaction(db, func(tx *gorm.DB) error {
+				for _, item := range batch {
+					if item.db != db {
+						rest = append(rest, item)
+						continue
+					}
+					if err := saveServerSentEvent(tx, item.event); err != nil {
+						log.Warnf("save server-sent event failed: %s", err)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				log.Warnf("save server-sent events failed: %s", err)
+			}
+			batch = rest
+		}
+	}
+}
+
+func QueryServerSentEventsBySSEHash(db *gorm.DB, hash string, page int, limit int) (*bizhelper.Paginator, []*schema.ServerSentEvent, error) {
+	if hash == "" {
+		return nil, nil, utils.Errorf("empty hash")
+	}
+	db = db.Model(&schema.ServerSentEvent{})
+	db = bizhelper.ExactQueryString(db, "sse_hash", hash)
+	db = bizhelper.QueryOrder(db, "event_index", "asc")
+
+	var ret []*schema.ServerSentEvent
+	paging, db := bizhelper.Paging(db, page, limit, &ret)
+	if db.Error != nil {
+		return nil, nil, utils.Errorf("paging failed: %s", db.Error)
+	}
+	return paging, ret, nil
+}
+
+func DeleteServerSentEventsBySSEHash(db *gorm.DB, hash []string) error {
+	if db := db.Model(&schema.ServerSentEvent{}).Where(
+		"sse_hash in (?)", hash,
+	).Unscoped().Delete(&schema.ServerSentEvent{}); db.Error != nil {
+		return db.Error
+	}
+	return nil
+}
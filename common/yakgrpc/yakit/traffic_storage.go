@@ -14,9 +14,11 @@ import (
 	"github.com/google/gopacket/layers"
 	uuid "github.com/google/uuid"
 	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/ja3"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
 )
 
 type TrafficStorageManager struct {
@@ -311,6 +313,11 @@ func (m *TrafficStorageManager) SaveTCPReassembledFrame(flow *pcaputil.TrafficFl
 	if !ok {
 		return utils.Errorf("no existed session/flow: %s", hash)
 	}
+	if fingerprintTrafficSession(session, frame.Payload) {
+		if err := m.db.Save(session).Error; err != nil {
+			log.Warnf("save traffic session fingerprint failed: %s", err)
+		}
+	}
 	storageFrame := &schema.TrafficTCPReassembledFrame{
 		SessionUuid: session.Uuid,
 		QuotedData:  strconv.Quote(string(frame.Payload)),
@@ -320,6 +327,49 @@ func (m *TrafficStorageManager) SaveTCPReassembledFrame(flow *pcaputil.TrafficFl
 	return m.db.Save(storageFrame).Error
 }
 
+// fingerprintTrafficSession 从重组后的数据帧中计算 TLS 握手和 HTTP 请求的指纹，每种指纹只记录第一次
+func fingerprintTrafficSession(session *schema.TrafficSession, payload []byte) bool {
+	if len(payload) <= 0 {
+		return false
+	}
+
+	if payload[0] == 0x16 {
+		if !session.HaveClientHello {
+			if hello, err := tlsutils.ParseClientHello(payload); err == nil {
+				session.HaveClientHello = true
+				session.SNI = hello.SNI()
+				if j, err := ja3.ParseJA3FromClientHello(hello); err == nil {
+					session.JA3 = j.Calc()
+				}
+				if j, err := ja3.ParseJA4FromClientHello(hello); err == nil {
+					session.JA4 = j.Fingerprint()
+				}
+				return true
+			}
+		}
+		if session.JA3S == "" {
+			if hello, err := tlsutils.ParseServerHello(payload); err == nil {
+				if j, err := ja3.ParseJA3SFromServerHello(hello); err == nil {
+					session.JA3S = j.Calc()
+				}
+				if j, err := ja3.ParseJA4SFromServerHello(hello); err == nil {
+					session.JA4S = j.Fingerprint()
+				}
+				return true
+			}
+		}
+		return false
+	}
+
+	if session.JA4H == "" {
+		if j, err := ja3.ParseJA4H(payload); err == nil {
+			session.JA4H = j.Fingerprint()
+			return true
+		}
+	}
+	return false
+}
+
 func (m *TrafficStorageManager) CreateHTTPFlow(flow *pcaputil.TrafficFlow, req *http.Request, rsp *http.Response) error {
 	return nil
 }
@@ -0,0 +1,35 @@
+package yakit
+
+import (
+	"net"
+	"testing"
+
+	"github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/schema"
+)
+
+func TestFingerprintTrafficSession(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	uconn := tls.UClient(client, &tls.Config{ServerName: "www.example.com"}, tls.HelloChrome_Auto)
+	require.NoError(t, uconn.BuildHandshakeState())
+	raw := uconn.HandshakeState.Hello.Raw
+	record := append([]byte{0x16, 0x03, 0x01, byte(len(raw) >> 8), byte(len(raw))}, raw...)
+
+	session := &schema.TrafficSession{}
+	require.True(t, fingerprintTrafficSession(session, record))
+	require.True(t, session.HaveClientHello)
+	require.Equal(t, "www.example.com", session.SNI)
+	expected, err := ja3.ParseJA4FromClientHelloRaw(record)
+	require.NoError(t, err)
+	require.Equal(t, expected.Fingerprint(), session.JA4)
+	require.Len(t, session.JA3, 32)
+	// 同一种指纹只记录一次
+	require.False(t, fingerprintTrafficSession(session, record))
+
+	require.True(t, fingerprintTrafficSession(session, []byte("GET / HTTP/1.1\r\nHost: www.example.com\r\nUser-Agent: test\r\n\r\n")))
+	require.Equal(t, "ge11nn020000_", session.JA4H[:13])
+	require.False(t, fingerprintTrafficSession(session, []byte("HTTP/1.1 200 OK\r\n\r\n")))
+}
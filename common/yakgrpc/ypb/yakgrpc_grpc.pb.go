@@ -29,6 +29,11 @@ type YakClient interface {
 	SetMITMFilter(ctx context.Context, in *SetMITMFilterRequest, opts ...grpc.CallOption) (*SetMITMFilterResponse, error)
 	GetMITMFilter(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SetMITMFilterRequest, error)
 	DownloadMITMCert(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*MITMCert, error)
+	// TLS 客户端证书库(双向认证)，修改后立即对 lowhttp / netx.DialX 生效
+	QueryClientCertificates(ctx context.Context, in *QueryClientCertificatesRequest, opts ...grpc.CallOption) (*QueryClientCertificatesResponse, error)
+	SaveClientCertificate(ctx context.Context, in *SaveClientCertificateRequest, opts ...grpc.CallOption) (*ClientCertificate, error)
+	SetClientCertificateDisabled(ctx context.Context, in *SetClientCertificateDisabledRequest, opts ...grpc.CallOption) (*Empty, error)
+	DeleteClientCertificates(ctx context.Context, in *DeleteClientCertificatesRequest, opts ...grpc.CallOption) (*Empty, error)
 	// 开启端口
 	OpenPort(ctx context.Context, opts ...grpc.CallOption) (Yak_OpenPortClient, error)
 	// Exec
@@ -128,6 +133,8 @@ type YakClient interface {
 	QueryWebsocketFlowByHTTPFlowWebsocketHash(ctx context.Context, in *QueryWebsocketFlowByHTTPFlowWebsocketHashRequest, opts ...grpc.CallOption) (*WebsocketFlows, error)
 	DeleteWebsocketFlowByHTTPFlowWebsocketHash(ctx context.Context, in *DeleteWebsocketFlowByHTTPFlowWebsocketHashRequest, opts ...grpc.CallOption) (*Empty, error)
 	DeleteWebsocketFlowAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	// Server-Sent Events: text/event-stream 响应中的事件，通过 HTTPFlow.SSEHash 查询
+	QueryServerSentEvents(ctx context.Context, in *QueryServerSentEventsRequest, opts ...grpc.CallOption) (*QueryServerSentEventsResponse, error)
 	// FuzzerResponse 对象转 HTTPFlow：以便分析和模糊测试
 	ConvertFuzzerResponseToHTTPFlow(ctx context.Context, in *FuzzerResponse, opts ...grpc.CallOption) (*HTTPFlow, error)
 	// 渲染 Fuzzer 模版
@@ -459,6 +466,8 @@ type YakClient interface {
 	// Wireshark
 	GetPcapMetadata(ctx context.Context, in *PcapMetadataRequest, opts ...grpc.CallOption) (*PcapMetadata, error)
 	PcapX(ctx context.Context, opts ...grpc.CallOption) (Yak_PcapXClient, error)
+	SuricataEve(ctx context.Context, in *SuricataEveRequest, opts ...grpc.CallOption) (Yak_SuricataEveClient, error)
+	PassiveFingerprint(ctx context.Context, in *PassiveFingerprintRequest, opts ...grpc.CallOption) (Yak_PassiveFingerprintClient, error)
 	QueryTrafficSession(ctx context.Context, in *QueryTrafficSessionRequest, opts ...grpc.CallOption) (*QueryTrafficSessionResponse, error)
 	QueryTrafficPacket(ctx context.Context, in *QueryTrafficPacketRequest, opts ...grpc.CallOption) (*QueryTrafficPacketResponse, error)
 	QueryTrafficTCPReassembled(ctx context.Context, in *QueryTrafficTCPReassembledRequest, opts ...grpc.CallOption) (*QueryTrafficTCPReassembledResponse, error)
@@ -577,6 +586,42 @@ func (c *yakClient) DownloadMITMCert(ctx context.Context, in *Empty, opts ...grp
 	return out, nil
 }
 
+func (c *yakClient) QueryClientCertificates(ctx context.Context, in *QueryClientCertificatesRequest, opts ...grpc.CallOption) (*QueryClientCertificatesResponse, error) {
+	out := new(QueryClientCertificatesResponse)
+	err := c.cc.Invoke(ctx, "/ypb.Yak/QueryClientCertificates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) SaveClientCertificate(ctx context.Context, in *SaveClientCertificateRequest, opts ...grpc.CallOption) (*ClientCertificate, error) {
+	out := new(ClientCertificate)
+	err := c.cc.Invoke(ctx, "/ypb.Yak/SaveClientCertificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) SetClientCertificateDisabled(ctx context.Context, in *SetClientCertificateDisabledRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ypb.Yak/SetClientCertificateDisabled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) DeleteClientCertificates(ctx context.Context, in *DeleteClientCertificatesRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ypb.Yak/DeleteClientCertificates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *yakClient) OpenPort(ctx context.Context, opts ...grpc.CallOption) (Yak_OpenPortClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[1], "/ypb.Yak/OpenPort", opts...)
 	if err != nil {
@@ -1707,6 +1752,15 @@ func (c *yakClient) DeleteWebsocketFlowAll(ctx context.Context, in *Empty, opts
 	return out, nil
 }
 
+func (c *yakClient) QueryServerSentEvents(ctx context.Context, in *QueryServerSentEventsRequest, opts ...grpc.CallOption) (*QueryServerSentEventsResponse, error) {
+	out := new(QueryServerSentEventsResponse)
+	err := c.cc.Invoke(ctx, "/ypb.Yak/QueryServerSentEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *yakClient) ConvertFuzzerResponseToHTTPFlow(ctx context.Context, in *FuzzerResponse, opts ...grpc.CallOption) (*HTTPFlow, error) {
 	out := new(HTTPFlow)
 	err := c.cc.Invoke(ctx, "/ypb.Yak/ConvertFuzzerResponseToHTTPFlow", in, out, opts...)
@@ -4893,6 +4947,70 @@ func (x *yakPcapXClient) Recv() (*PcapXResponse, error) {
 	return m, nil
 }
 
+func (c *yakClient) SuricataEve(ctx context.Context, in *SuricataEveRequest, opts ...grpc.CallOption) (Yak_SuricataEveClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[59], "/ypb.Yak/SuricataEve", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &yakSuricataEveClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Yak_SuricataEveClient interface {
+	Recv() (*SuricataEveEvent, error)
+	grpc.ClientStream
+}
+
+type yakSuricataEveClient struct {
+	grpc.ClientStream
+}
+
+func (x *yakSuricataEveClient) Recv() (*SuricataEveEvent, error) {
+	m := new(SuricataEveEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *yakClient) PassiveFingerprint(ctx context.Context, in *PassiveFingerprintRequest, opts ...grpc.CallOption) (Yak_PassiveFingerprintClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[60], "/ypb.Yak/PassiveFingerprint", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &yakPassiveFingerprintClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Yak_PassiveFingerprintClient interface {
+	Recv() (*PassiveFingerprintResult, error)
+	grpc.ClientStream
+}
+
+type yakPassiveFingerprintClient struct {
+	grpc.ClientStream
+}
+
+func (x *yakPassiveFingerprintClient) Recv() (*PassiveFingerprintResult, error) {
+	m := new(PassiveFingerprintResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *yakClient) QueryTrafficSession(ctx context.Context, in *QueryTrafficSessionRequest, opts ...grpc.CallOption) (*QueryTrafficSessionResponse, error) {
 	out := new(QueryTrafficSessionResponse)
 	err := c.cc.Invoke(ctx, "/ypb.Yak/QueryTrafficSession", in, out, opts...)
@@ -4930,7 +5048,7 @@ func (c *yakClient) ParseTraffic(ctx context.Context, in *ParseTrafficRequest, o
 }
 
 func (c *yakClient) DuplexConnection(ctx context.Context, opts ...grpc.CallOption) (Yak_DuplexConnectionClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[59], "/ypb.Yak/DuplexConnection", opts...)
+	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[61], "/ypb.Yak/DuplexConnection", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -4961,7 +5079,7 @@ func (x *yakDuplexConnectionClient) Recv() (*DuplexConnectionResponse, error) {
 }
 
 func (c *yakClient) HybridScan(ctx context.Context, opts ...grpc.CallOption) (Yak_HybridScanClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[60], "/ypb.Yak/HybridScan", opts...)
+	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[62], "/ypb.Yak/HybridScan", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -5028,7 +5146,7 @@ func (c *yakClient) GetSpaceEngineAccountStatus(ctx context.Context, in *GetSpac
 }
 
 func (c *yakClient) FetchPortAssetFromSpaceEngine(ctx context.Context, in *FetchPortAssetFromSpaceEngineRequest, opts ...grpc.CallOption) (Yak_FetchPortAssetFromSpaceEngineClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[61], "/ypb.Yak/FetchPortAssetFromSpaceEngine", opts...)
+	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[63], "/ypb.Yak/FetchPortAssetFromSpaceEngine", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -5083,6 +5201,11 @@ type YakServer interface {
 	SetMITMFilter(context.Context, *SetMITMFilterRequest) (*SetMITMFilterResponse, error)
 	GetMITMFilter(context.Context, *Empty) (*SetMITMFilterRequest, error)
 	DownloadMITMCert(context.Context, *Empty) (*MITMCert, error)
+	// TLS 客户端证书库(双向认证)，修改后立即对 lowhttp / netx.DialX 生效
+	QueryClientCertificates(context.Context, *QueryClientCertificatesRequest) (*QueryClientCertificatesResponse, error)
+	SaveClientCertificate(context.Context, *SaveClientCertificateRequest) (*ClientCertificate, error)
+	SetClientCertificateDisabled(context.Context, *SetClientCertificateDisabledRequest) (*Empty, error)
+	DeleteClientCertificates(context.Context, *DeleteClientCertificatesRequest) (*Empty, error)
 	// 开启端口
 	OpenPort(Yak_OpenPortServer) error
 	// Exec
@@ -5182,6 +5305,8 @@ type YakServer interface {
 	QueryWebsocketFlowByHTTPFlowWebsocketHash(context.Context, *QueryWebsocketFlowByHTTPFlowWebsocketHashRequest) (*WebsocketFlows, error)
 	DeleteWebsocketFlowByHTTPFlowWebsocketHash(context.Context, *DeleteWebsocketFlowByHTTPFlowWebsocketHashRequest) (*Empty, error)
 	DeleteWebsocketFlowAll(context.Context, *Empty) (*Empty, error)
+	// Server-Sent Events: text/event-stream 响应中的事件，通过 HTTPFlow.SSEHash 查询
+	QueryServerSentEvents(context.Context, *QueryServerSentEventsRequest) (*QueryServerSentEventsResponse, error)
 	// FuzzerResponse 对象转 HTTPFlow：以便分析和模糊测试
 	ConvertFuzzerResponseToHTTPFlow(context.Context, *FuzzerResponse) (*HTTPFlow, error)
 	// 渲染 Fuzzer 模版
@@ -5513,6 +5638,8 @@ type YakServer interface {
 	// Wireshark
 	GetPcapMetadata(context.Context, *PcapMetadataRequest) (*PcapMetadata, error)
 	PcapX(Yak_PcapXServer) error
+	SuricataEve(*SuricataEveRequest, Yak_SuricataEveServer) error
+	PassiveFingerprint(*PassiveFingerprintRequest, Yak_PassiveFingerprintServer) error
 	QueryTrafficSession(context.Context, *QueryTrafficSessionRequest) (*QueryTrafficSessionResponse, error)
 	QueryTrafficPacket(context.Context, *QueryTrafficPacketRequest) (*QueryTrafficPacketResponse, error)
 	QueryTrafficTCPReassembled(context.Context, *QueryTrafficTCPReassembledRequest) (*QueryTrafficTCPReassembledResponse, error)
@@ -5558,6 +5685,18 @@ func (UnimplementedYakServer) GetMITMFilter(context.Context, *Empty) (*SetMITMFi
 func (UnimplementedYakServer) DownloadMITMCert(context.Context, *Empty) (*MITMCert, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DownloadMITMCert not implemented")
 }
+func (UnimplementedYakServer) QueryClientCertificates(context.Context, *QueryClientCertificatesRequest) (*QueryClientCertificatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryClientCertificates not implemented")
+}
+func (UnimplementedYakServer) SaveClientCertificate(context.Context, *SaveClientCertificateRequest) (*ClientCertificate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveClientCertificate not implemented")
+}
+func (UnimplementedYakServer) SetClientCertificateDisabled(context.Context, *SetClientCertificateDisabledRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetClientCertificateDisabled not implemented")
+}
+func (UnimplementedYakServer) DeleteClientCertificates(context.Context, *DeleteClientCertificatesRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteClientCertificates not implemented")
+}
 func (UnimplementedYakServer) OpenPort(Yak_OpenPortServer) error {
 	return status.Errorf(codes.Unimplemented, "method OpenPort not implemented")
 }
@@ -5813,6 +5952,9 @@ func (UnimplementedYakServer) DeleteWebsocketFlowByHTTPFlowWebsocketHash(context
 func (UnimplementedYakServer) DeleteWebsocketFlowAll(context.Context, *Empty) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebsocketFlowAll not implemented")
 }
+func (UnimplementedYakServer) QueryServerSentEvents(context.Context, *QueryServerSentEventsRequest) (*QueryServerSentEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryServerSentEvents not implemented")
+}
 func (UnimplementedYakServer) ConvertFuzzerResponseToHTTPFlow(context.Context, *FuzzerResponse) (*HTTPFlow, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ConvertFuzzerResponseToHTTPFlow not implemented")
 }
@@ -6554,6 +6696,12 @@ func (UnimplementedYakServer) GetPcapMetadata(context.Context, *PcapMetadataRequ
 func (UnimplementedYakServer) PcapX(Yak_PcapXServer) error {
 	return status.Errorf(codes.Unimplemented, "method PcapX not implemented")
 }
+func (UnimplementedYakServer) SuricataEve(*SuricataEveRequest, Yak_SuricataEveServer) error {
+	return status.Errorf(codes.Unimplemented, "method SuricataEve not implemented")
+}
+func (UnimplementedYakServer) PassiveFingerprint(*PassiveFingerprintRequest, Yak_PassiveFingerprintServer) error {
+	return status.Errorf(codes.Unimplemented, "method PassiveFingerprint not implemented")
+}
 func (UnimplementedYakServer) QueryTrafficSession(context.Context, *QueryTrafficSessionRequest) (*QueryTrafficSessionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryTrafficSession not implemented")
 }
@@ -6755,6 +6903,78 @@ func _Yak_DownloadMITMCert_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Yak_QueryClientCertificates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryClientCertificatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).QueryClientCertificates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ypb.Yak/QueryClientCertificates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).QueryClientCertificates(ctx, req.(*QueryClientCertificatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_SaveClientCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveClientCertificateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).SaveClientCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ypb.Yak/SaveClientCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).SaveClientCertificate(ctx, req.(*SaveClientCertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_SetClientCertificateDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetClientCertificateDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).SetClientCertificateDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ypb.Yak/SetClientCertificateDisabled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).SetClientCertificateDisabled(ctx, req.(*SetClientCertificateDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_DeleteClientCertificates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteClientCertificatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).DeleteClientCertificates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ypb.Yak/DeleteClientCertificates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).DeleteClientCertificates(ctx, req.(*DeleteClientCertificatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Yak_OpenPort_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(YakServer).OpenPort(&yakOpenPortServer{stream})
 }
@@ -8348,6 +8568,24 @@ func _Yak_DeleteWebsocketFlowAll_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Yak_QueryServerSentEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryServerSentEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).QueryServerSentEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ypb.Yak/QueryServerSentEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).QueryServerSentEvents(ctx, req.(*QueryServerSentEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Yak_ConvertFuzzerResponseToHTTPFlow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FuzzerResponse)
 	if err := dec(in); err != nil {
@@ -12935,6 +13173,48 @@ func (x *yakPcapXServer) Recv() (*PcapXRequest, error) {
 	return m, nil
 }
 
+func _Yak_SuricataEve_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SuricataEveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(YakServer).SuricataEve(m, &yakSuricataEveServer{stream})
+}
+
+type Yak_SuricataEveServer interface {
+	Send(*SuricataEveEvent) error
+	grpc.ServerStream
+}
+
+type yakSuricataEveServer struct {
+	grpc.ServerStream
+}
+
+func (x *yakSuricataEveServer) Send(m *SuricataEveEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Yak_PassiveFingerprint_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PassiveFingerprintRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(YakServer).PassiveFingerprint(m, &yakPassiveFingerprintServer{stream})
+}
+
+type Yak_PassiveFingerprintServer interface {
+	Send(*PassiveFingerprintResult) error
+	grpc.ServerStream
+}
+
+type yakPassiveFingerprintServer struct {
+	grpc.ServerStream
+}
+
+func (x *yakPassiveFingerprintServer) Send(m *PassiveFingerprintResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Yak_QueryTrafficSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(QueryTrafficSessionRequest)
 	if err := dec(in); err != nil {
@@ -13205,6 +13485,22 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DownloadMITMCert",
 			Handler:    _Yak_DownloadMITMCert_Handler,
 		},
+		{
+			MethodName: "QueryClientCertificates",
+			Handler:    _Yak_QueryClientCertificates_Handler,
+		},
+		{
+			MethodName: "SaveClientCertificate",
+			Handler:    _Yak_SaveClientCertificate_Handler,
+		},
+		{
+			MethodName: "SetClientCertificateDisabled",
+			Handler:    _Yak_SetClientCertificateDisabled_Handler,
+		},
+		{
+			MethodName: "DeleteClientCertificates",
+			Handler:    _Yak_DeleteClientCertificates_Handler,
+		},
 		{
 			MethodName: "QueryExecHistory",
 			Handler:    _Yak_QueryExecHistory_Handler,
@@ -13481,6 +13777,10 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteWebsocketFlowAll",
 			Handler:    _Yak_DeleteWebsocketFlowAll_Handler,
 		},
+		{
+			MethodName: "QueryServerSentEvents",
+			Handler:    _Yak_QueryServerSentEvents_Handler,
+		},
 		{
 			MethodName: "ConvertFuzzerResponseToHTTPFlow",
 			Handler:    _Yak_ConvertFuzzerResponseToHTTPFlow_Handler,
@@ -14641,6 +14941,16 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "SuricataEve",
+			Handler:       _Yak_SuricataEve_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PassiveFingerprint",
+			Handler:       _Yak_PassiveFingerprint_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "DuplexConnection",
 			Handler:       _Yak_DuplexConnection_Handler,